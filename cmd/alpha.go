@@ -30,11 +30,39 @@ func newAlphaCommand() *cobra.Command {
 		Example: `
 # scaffolds webhook server
 kubebuilder alpha webhook <params>
+
+# generates the samples from the example markers of the API types
+kubebuilder alpha samples
+
+# converts the samples of the hub versions to the other versions of their kinds
+kubebuilder alpha convert-samples
+
+# checks the RBAC markers against the resources the code uses
+kubebuilder alpha verify-rbac
+
+# generates the Helm chart from config/default
+kustomize build config/default | kubebuilder alpha helm
+
+# generates the manifests of config/default without kustomize
+kubebuilder alpha config-gen --image controller:latest | kubectl apply -f -
+
+# generates the OpenAPI document of the CRDs and serves it with Swagger UI
+kubebuilder alpha api-docs --serve localhost:8082
+
+# compares the project with the pristine scaffolding of its recorded commands
+kubebuilder alpha diff-templates
 `,
 	}
 
 	cmd.AddCommand(
 		newWebhookCmd(),
+		newSamplesCmd(),
+		newConvertSamplesCmd(),
+		newVerifyRBACCmd(),
+		newHelmCmd(),
+		newConfigGenCmd(),
+		newAPIDocsCmd(),
+		newDiffTemplatesCmd(),
 	)
 	return cmd
 }
@@ -19,7 +19,6 @@ package main
 import (
 	"bufio"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 
@@ -28,6 +27,7 @@ import (
 
 	"sigs.k8s.io/kubebuilder/cmd/util"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
 )
 
@@ -37,6 +37,12 @@ type apiOptions struct {
 
 	// runMake indicates whether to run make or not after scaffolding APIs
 	runMake bool
+
+	// forceGroupSuffix indicates whether the group is suffixed with the domain
+	// of the project, or fully qualified
+	forceGroupSuffix bool
+
+	output outputOptions
 }
 
 func (o *apiOptions) bindCmdFlags(cmd *cobra.Command) {
@@ -48,7 +54,45 @@ func (o *apiOptions) bindCmdFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&o.apiScaffolder.DoController, "controller", true,
 		"if set, generate the controller without prompting the user")
 	o.controllerFlag = cmd.Flag("controller")
+	cmd.Flags().BoolVar(&o.apiScaffolder.Force, "force", false,
+		"if set, regenerate the scaffold of an existing resource, merging it into its Go files while keeping their code (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.apiScaffolder.StatusApply, "status-apply", false,
+		"if set, generate a helper applying the status of the resource with server-side apply (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.forceGroupSuffix, "force-group-suffix", true,
+		"if false, the group is fully qualified, e.g. widgets.legacy.io, rather than suffixed with the domain of the project, and its domain is recorded in PROJECT (only used with project version 2)")
+	cmd.Flags().StringSliceVar(&o.apiScaffolder.RequiredAPIs, "requires-api", nil,
+		"comma separated <group>/<version>/<Kind> APIs the controller is only set up with when the cluster serves them, e.g. monitoring.coreos.com/v1/ServiceMonitor (only used with projects initialized with --capabilities)")
 	o.apiScaffolder.Resource = resourceForFlags(cmd.Flags())
+	cmd.Flags().BoolVar(&o.apiScaffolder.Resource.CreationGuard, "creation-guard", false,
+		"if set, the controller creates objects with a guard capping the creations of each reconcile (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.apiScaffolder.Resource.Expectations, "expectations", false,
+		"if set, the controller tracks the creations and deletions of children its cache has not observed yet (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.apiScaffolder.Resource.SpecHash, "spec-hash", false,
+		"if set, the controller skips the updates of the children whose desired state has the hash they were last written with (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.apiScaffolder.Resource.PayloadReference, "payload-reference", false,
+		"if set, the spec of the resource references a large payload stored in a ConfigMap or a Secret, resolved by the controller (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.apiScaffolder.Resource.ValidationStubs, "validation-stubs", false,
+		"if set, the spec of the resource has example fields showing the Minimum, Maximum, Pattern and Enum validation markers (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.apiScaffolder.Resource.WithConditions, "with-conditions", false,
+		"if set, the status of the resource has conditions, set and read with SetCondition, GetCondition and IsConditionTrue, and the Ready condition is printed by kubectl get (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.apiScaffolder.Resource.Scale, "scale", false,
+		"if set, the resource has replicas and a pod selector served by the scale subresource, enabling kubectl scale and the HorizontalPodAutoscaler (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.apiScaffolder.Resource.Optional, "optional", false,
+		"if set, scaffold a kustomize component excluding the CRD and the controller of the resource from the deployment (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.apiScaffolder.Resource.External, "external", false,
+		"if set, scaffold a controller for a pre-existing type, e.g. a core type of k8s.io/api, without generating the resource (only used with project version 2)")
+	cmd.Flags().StringVar(&o.apiScaffolder.Resource.ExternalAPIPath, "external-api-path", "",
+		"import path of the package of the group of an external resource, its versions being subpackages. Defaults to k8s.io/api/<group> for the core groups (only used with --external)")
+	cmd.Flags().StringVar(&o.apiScaffolder.Resource.ExternalDomain, "external-domain", "",
+		"domain of the API group of an external resource outside of the core groups. Defaults to the domain of the project (only used with --external)")
+	cmd.Flags().BoolVar(&o.apiScaffolder.Resource.Finalizer, "with-finalizer", false,
+		"if set, the controller adds a finalizer to the resource and removes it once a deleted resource is cleaned up (only used with project version 2)")
+	cmd.Flags().StringSliceVar(&o.apiScaffolder.Resource.Phases, "with-phase", nil,
+		"comma separated phases of the lifecycle of the resource, e.g. Pending,Running,Failed, generating a status phase enum (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.apiScaffolder.Resource.ReconcileStatus, "reconcile-status", false,
+		"if set, the status of the resource records the time and the error of its last reconcile, written by Record<Kind>Reconcile (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.apiScaffolder.Resource.TransitionEvents, "transition-events", false,
+		"if set, the controller records an event for each transition of the conditions and the phase of the resource (only used with project version 2)")
 }
 
 // resourceForFlags registers flags for Resource fields and returns the Resource
@@ -60,6 +104,8 @@ func resourceForFlags(f *flag.FlagSet) *resource.Resource {
 	f.BoolVar(&r.Namespaced, "namespaced", true, "resource is namespaced")
 	f.BoolVar(&r.CreateExampleReconcileBody, "example", true,
 		"if true an example reconcile body should be written while scaffolding a resource.")
+	f.BoolVar(&r.EmbedPodTemplate, "pod-template", false,
+		"if true the spec of the resource embeds a pod template whose labels and annotations survive pruning")
 	return r
 }
 
@@ -68,28 +114,39 @@ func (o *apiOptions) runAddAPI() {
 	dieIfNoProject()
 
 	reader := bufio.NewReader(os.Stdin)
-	if !o.resourceFlag.Changed {
+	if o.apiScaffolder.Resource.External {
+		// the types of external resources already exist, only their
+		// controller is scaffolded
+		if !o.resourceFlag.Changed {
+			o.apiScaffolder.DoResource = false
+		}
+	} else if !o.resourceFlag.Changed {
 		fmt.Println("Create Resource [y/n]")
 		o.apiScaffolder.DoResource = util.Yesno(reader)
 	}
 
-	if !o.controllerFlag.Changed {
+	if !o.controllerFlag.Changed && !o.apiScaffolder.Resource.External {
 		fmt.Println("Create Controller [y/n]")
 		o.apiScaffolder.DoController = util.Yesno(reader)
 	}
 
+	o.apiScaffolder.QualifiedGroup = !o.forceGroupSuffix
+	// the flags keep their values, recorded in the history, when a fully
+	// qualified group is split into the group and the domain of the resource
+	r := *o.apiScaffolder.Resource
+	o.apiScaffolder.Resource = &r
 	if err := o.apiScaffolder.Validate(); err != nil {
-		log.Fatalln(err)
+		failInvalidFlags(err)
 	}
 
 	fmt.Println("Writing scaffold for you to edit...")
 
 	if err := o.apiScaffolder.Scaffold(); err != nil {
-		log.Fatal(err)
+		failScaffold(err)
 	}
 
 	if err := o.postScaffold(); err != nil {
-		log.Fatal(err)
+		failHook(err)
 	}
 }
 
@@ -103,6 +160,19 @@ func (o *apiOptions) postScaffold() error {
 			return fmt.Errorf("error running make: %v", err)
 		}
 	}
+	if p, err := scaffold.LoadProjectFile("PROJECT"); err == nil && p.Packaging == project.PackagingHelm {
+		if !o.runMake {
+			fmt.Println("Next: run make helm to add the CRD of the API to the chart.")
+			return nil
+		}
+		fmt.Println("Running make helm...")
+		cm := exec.Command("make", "helm") // #nosec
+		cm.Stderr = os.Stderr
+		cm.Stdout = os.Stdout
+		if err := cm.Run(); err != nil {
+			return fmt.Errorf("error running make helm: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -112,7 +182,7 @@ func newAPICommand() *cobra.Command {
 	}
 
 	apiCmd := &cobra.Command{
-		Use:   "create api",
+		Use:   "api",
 		Short: "Scaffold a Kubernetes API",
 		Long: `Scaffold a Kubernetes API by creating a Resource definition and / or a Controller.
 
@@ -120,11 +190,165 @@ create resource will prompt the user for if it should scaffold the Resource and
 scaffold a Controller for an existing Resource, select "n" for Resource.  To only define
 the schema for a Resource without writing a Controller, select "n" for Controller.
 
-After the scaffold is written, api will run make on the project.
+With --namespaced=false, the Resource is cluster-scoped: its types are marked
+with +kubebuilder:resource:scope=Cluster, giving its CRD the Cluster scope, and
+the tests scaffolded for it and its webhooks create it without a namespace. Its
+controller is granted its permissions by the ClusterRole of the manager, as the
+controllers of the namespaced Resources are.
+
+With --status-apply, a helper applying the status with server-side apply is
+generated next to the controller, along with an apply configuration of the
+Resource. The status is then written without reading the object first, and
+without the conflicts of a Get and Update loop.
+
+With --creation-guard, the controller creates the objects of the Resource with
+a client refusing the creations of a reconcile over a limit, 10 by default.
+The creations of a Resource reaching the limit are suspended for a backoff
+doubled each time it reaches it again, and a Degraded condition is recorded in
+its status, so that a bug creating objects in a loop does not flood the
+apiserver.
+The CRD prints the status, reason and message of the Ready condition of
+the status as kubectl get columns.
+
+With --with-conditions, the status of the Resource is given Conditions of the
+condition type of its API version, in condition_types.go, along with the
+SetCondition, GetCondition and IsConditionTrue helpers. The CRD prints the
+status, reason and message of the Ready condition as kubectl get columns.
+
+With --scale, the spec of the Resource is given Replicas, and its status
+Replicas and Selector, which the +kubebuilder:subresource:scale marker serves
+as the scale subresource of the CRD, so that the Resource can be scaled with
+kubectl scale and by a HorizontalPodAutoscaler. The controller writes the
+selector of the labels returned by <kind>PodLabels to the status; label the
+pods it runs with them and set the replicas of the status to the number of
+them. The CRD prints the desired and current replicas as kubectl get columns.
+
+With --with-phase Pending,Running,Failed, the status of the Resource is given
+a Phase enum of the listed values, validated by the CRD and shown by kubectl
+get. The controller is generated with Set<Kind>Phase, refusing the transitions
+to an earlier phase. Edit the transitions it allows next to the controller.
+
+With --reconcile-status, the status of the Resource is given LastReconcileTime
+and LastError, the outcome of its last reconcile, and the controller is
+generated with Record<Kind>Reconcile recording them. Return it from Reconcile.
+A write recording the same error as the previous one is skipped for a minute,
+so that a Resource failing in a loop does not flood the apiserver. kubectl get
+-o wide prints the last error.
+
+With --transition-events, given --with-conditions, --creation-guard or
+--with-phase, the controller records an event on the Resource for each
+transition of its conditions and phase, with record<Kind>Transitions called
+once the status is written. The reasons of the events are the EventReason
+constants of controllers/events.go, shared by the kinds of the project so that
+kubectl describe reads the same for each of them: a Normal event is recorded
+when a condition reaches its healthy status, a Warning event when it leaves it.
+Map the condition types of the Resource to their reasons next to the
+controller.
+
+With --expectations, the controller is generated with the expectations of
+kube-controller-manager: the creations and deletions of children of a Resource
+which the informers have not observed yet. Its reconciles are skipped until
+they are observed, so that a lagging cache does not make them create the same
+children twice. Watch the children with r.Expectations.OwnerHandler to observe
+their events.
+
+With --spec-hash, the controller is generated with CreateOrUpdateChild, which
+records the hash of the desired state of a child in its spec-hash annotation
+and skips its update when the hash is unchanged, counting the skipped updates
+in the controller_child_updates_skipped_total metric. The operators managing
+many children then stop writing them on every reconcile. The changes made to a
+child by others are only reverted once its desired state changes.
+
+With --payload-reference, the spec of the Resource is generated with a Payload
+field referencing a key of a ConfigMap or a Secret, and pinning the SHA-256
+digest of its content. Large payloads, e.g. configuration files, are then kept
+out of the object stored by etcd and its size limit. The controller resolves
+the payload with payloadOf, which refuses the payloads not matching the digest.
+Validate the references at admission with create webhook --payload-validation.
+
+With --validation-stubs, the spec of the Resource is generated with example
+Replicas, Name and Mode fields showing the Minimum and Maximum, Pattern and
+length, and Enum validation markers, which controller-gen turns into the
+OpenAPI schema of the CRD. Adapt the markers to your fields, then remove the
+examples.
+
+With --optional, the API is an optional part of the deployment: a kustomize
+component, config/components/disable-<group>-<resource>, deleting its CRD and
+setting the ENABLE_<GROUP>_<KIND> environment variable of the manager to false
+is scaffolded and listed, commented out, in the components of
+config/default/kustomization.yaml. The controller of the API is only set up
+when the variable is not false, so that the products shipping the API
+separately are deployed from the same project by listing the component.
+
+With --external, only a controller is scaffolded, for a pre-existing type
+such as a core type, e.g. --group core --version v1 --kind Pod. The controller
+and main.go import the k8s.io/api package of the core groups, or the
+--external-api-path package of the other groups, and the RBAC markers of the
+controller grant access to the API group of the type.
+
+With --force-group-suffix=false, the group is fully qualified rather than
+suffixed with the domain of the project, e.g. --group widgets.legacy.io, to
+migrate a legacy CRD whose group does not end with the domain. The code of the
+Resource is generated in the widgets group, and its domain, legacy.io, is
+recorded with it in PROJECT: the next Resources and webhooks of the widgets
+group are then created with it, given --group widgets.
+
+With --with-finalizer, the Reconcile of the controller adds the <Kind>Finalizer
+finalizer to the objects of the Resource, and handles their deletion: clean up
+the external resources of a deleted object where the TODO is, the finalizer is
+then removed to let the deletion complete. The HasFinalizer, AddFinalizer and
+RemoveFinalizer helpers are generated in finalizers.go, along with a test of
+the finalizer in <kind>_finalizer_test.go.
+
+In projects initialized with --settings, the controller is given the watch of
+the settings ConfigMap, and reconciles all the objects of the Resource on each
+of its changes.
+
+In projects initialized with --capabilities, --requires-api lists the optional
+APIs the controller depends on, e.g. monitoring.coreos.com/v1/ServiceMonitor.
+The controller is then only set up when discovery finds the cluster serves all
+of them, and skipped with a log message otherwise.
+
+With --force, create api runs again on a Resource which already exists, to
+adopt the features added to the scaffold since it was created, e.g. given new
+flags such as --transition-events. The regenerated scaffold is merged into the
+Go files of the Resource rather than overwriting them: the imports,
+declarations and struct fields they do not have are added, the fields above
+the // +kubebuilder:scaffold: marker of their struct, and the code they have
+is kept as it is. The other existing files, e.g. the sample, are kept, as is
+the setup of the controller in main.go.
+
+With --git-commit, the scaffolded files and the ones generated by make are
+committed with the command as commit message, so that each scaffold is a
+commit of its own. The git working tree must be clean.
+
+After the scaffold is written, api will run make on the project, followed by
+make helm in the projects packaged with kubebuilder edit --packaging=helm.
 `,
 		Example: `	# Create a frigates API with Group: ship, Version: v1beta1 and Kind: Frigate
 	kubebuilder create api --group ship --version v1beta1 --kind Frigate
 	
+	# Create a cluster-scoped Fleet API, its objects having no namespace
+	kubebuilder create api --group ship --version v1beta1 --kind Fleet --namespaced=false
+
+	# Create a Squadron API which kubectl scale and a HorizontalPodAutoscaler can scale
+	kubebuilder create api --group ship --version v1beta1 --kind Squadron --scale
+
+	# Regenerate the scaffold of the Frigate API, adding the conditions to its
+	# status and the events of their transitions to its controller
+	kubebuilder create api --group ship --version v1beta1 --kind Frigate --force \
+		--with-conditions --transition-events
+
+	# Create a controller for the core Pods, without generating an API
+	kubebuilder create api --group core --version v1 --kind Pod --external
+
+	# Create a controller for the Certificates of cert-manager
+	kubebuilder create api --group certmanager --version v1alpha1 --kind Certificate --external \
+		--external-api-path github.com/jetstack/cert-manager/pkg/apis/certmanager --external-domain k8s.io
+
+	# Create a Widget API of the legacy widgets.legacy.io group, migrated to the project
+	kubebuilder create api --group widgets.legacy.io --version v1 --kind Widget --force-group-suffix=false
+
 	# Edit the API Scheme
 	nano api/ship/v1beta1/frigate_types.go
 
@@ -141,11 +365,13 @@ After the scaffold is written, api will run make on the project.
 	make run
 `,
 		Run: func(cmd *cobra.Command, args []string) {
-			options.runAddAPI()
+			options.output.run(options.runAddAPI)
 		},
 	}
 
 	options.bindCmdFlags(apiCmd)
+	options.output.bindCmdFlags(apiCmd)
+	bindGitCommitFlag(apiCmd)
 
 	return apiCmd
 }
@@ -153,6 +379,6 @@ After the scaffold is written, api will run make on the project.
 // dieIfNoProject checks to make sure the command is run from a directory containing a project file.
 func dieIfNoProject() {
 	if _, err := os.Stat("PROJECT"); os.IsNotExist(err) {
-		log.Fatalf("Command must be run from a directory containing %s", "PROJECT")
+		failPreflight(fmt.Errorf("Command must be run from a directory containing %s", "PROJECT"))
 	}
 }
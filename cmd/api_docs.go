@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+)
+
+type apiDocsOptions struct {
+	apiDocsScaffolder scaffold.APIDocs
+
+	// serve is the address the API docs are served at once generated, empty
+	// to only generate them
+	serve string
+
+	output outputOptions
+}
+
+func (o *apiDocsOptions) runAPIDocs() {
+	dieIfNoProject()
+
+	if err := o.apiDocsScaffolder.Validate(); err != nil {
+		failInvalidFlags(err)
+	}
+
+	if err := o.apiDocsScaffolder.Scaffold(); err != nil {
+		failScaffold(err)
+	}
+}
+
+func (o *apiDocsOptions) serveAPIDocs() {
+	fmt.Printf("Serving the API docs of %s at http://%s\n", scaffold.APIDocsDir, o.serve)
+	if err := http.ListenAndServe(o.serve, http.FileServer(http.Dir(scaffold.APIDocsDir))); err != nil {
+		fail(errorKindError, exitError, err)
+	}
+}
+
+func newAPIDocsCmd() *cobra.Command {
+	options := apiDocsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "api-docs",
+		Short: "Generate the OpenAPI v3 document of the CRDs and a Swagger UI page browsing it",
+		Long: `Generate docs/api/openapi.json, the OpenAPI v3 document of the CRDs of
+config/crd/bases generated by make manifests, and docs/api/index.html, a page
+browsing it with Swagger UI, run by make api-docs and make serve-api-docs.
+
+Each served version of a CRD is a schema of the document, named
+<group>.<version>.<Kind> after the OpenAPI schema of the CRD, along with the
+paths of the REST endpoints the API server serves for it. The consumers of the
+APIs of the project browse them without access to a cluster. Commit docs/api
+to publish them, e.g. with GitHub Pages.
+
+openapi.json is overwritten, index.html is only written when missing, so that
+it can be customized.
+
+With --serve, the docs are served at the address once generated, until the
+command is interrupted.
+
+This command is only available for v2 scaffolding project.
+`,
+		Example: `	# Generate the API docs under docs/api
+	kubebuilder alpha api-docs
+
+	# Generate the API docs and browse them at http://localhost:8082
+	kubebuilder alpha api-docs --serve localhost:8082
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.output.run(options.runAPIDocs)
+			if options.serve != "" {
+				options.serveAPIDocs()
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.serve, "serve", "",
+		"if set, the address to serve the API docs at once generated, e.g. localhost:8082")
+	options.output.bindCmdFlags(cmd)
+
+	return cmd
+}
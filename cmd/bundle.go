@@ -0,0 +1,110 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+)
+
+type bundleOptions struct {
+	bundleScaffolder scaffold.Bundle
+
+	output outputOptions
+}
+
+func (o *bundleOptions) bindCmdFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.bundleScaffolder.Version, "version", "",
+		"semantic version of the operator, e.g. 0.1.0")
+	cmd.Flags().StringSliceVar(&o.bundleScaffolder.Channels, "channels", []string{"alpha"},
+		"comma separated channels the bundle is published in")
+	cmd.Flags().StringVar(&o.bundleScaffolder.DefaultChannel, "default-channel", "",
+		"channel subscribed to by default, the first of the channels if unset")
+	o.output.bindCmdFlags(cmd)
+}
+
+func (o *bundleOptions) runBundle() {
+	dieIfNoProject()
+
+	if err := o.bundleScaffolder.Validate(); err != nil {
+		failInvalidFlags(err)
+	}
+
+	manifests, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		failInvalidFlags(fmt.Errorf("error reading the manifests: %v", err))
+	}
+	o.bundleScaffolder.Manifests = manifests
+
+	if err := o.bundleScaffolder.Scaffold(); err != nil {
+		failScaffold(err)
+	}
+
+	fmt.Println("Next: fill in the description, provider, maintainers and icon of the CSV in " +
+		"bundle/manifests, they are kept by the next make bundle, then make bundle-build bundle-push.")
+}
+
+func newBundleCmd() *cobra.Command {
+	options := bundleOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Scaffold the OLM bundle of the operator from the manifests of config/default",
+		Long: `Scaffold the Operator Lifecycle Manager bundle of the operator under bundle/,
+from the manifests built by kustomize from config/default, read from the
+standard input, run by make bundle. The bundle publishes the operator to
+OperatorHub, or to any catalog of OLM.
+
+The ClusterServiceVersion of the bundle, bundle/manifests/<project>.clusterserviceversion.yaml,
+installs the Deployments of the manifests, with the rules of the roles bound
+to their service accounts as permissions and cluster permissions. It owns
+the CRDs of the manifests, and lists the samples of config/samples as examples.
+The CRDs are copied next to the CSV, as well as the Services, ConfigMaps,
+Secrets, ServiceMonitors, PrometheusRules, PodDisruptionBudgets and
+PriorityClasses OLM installs from a bundle. The other objects, e.g. the
+webhook configurations and the cert-manager Certificates, are left out with
+a warning.
+
+The fields of an existing CSV which are not generated, e.g. its description,
+provider, maintainers, icon and the displayName of its owned CRDs, are kept.
+
+bundle/metadata/annotations.yaml and bundle.Dockerfile, building the bundle
+image, record the package, the project name, and the channels of the bundle.
+
+This command is only available for v2 scaffolding project.
+`,
+		Example: `	# Scaffold the bundle of the version 0.1.0 of the operator, in the alpha channel
+	kustomize build config/default | kubebuilder create bundle --version 0.1.0
+
+	# Scaffold the bundle of the version 1.0.0, published in the stable and fast channels
+	kustomize build config/default | kubebuilder create bundle --version 1.0.0 \
+		--channels stable,fast --default-channel stable
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.output.run(options.runBundle)
+		},
+	}
+
+	options.bindCmdFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kubebuilder/pkg/configgen"
+)
+
+type configGenOptions struct {
+	dir      string
+	replicas int32
+	options  configgen.Options
+}
+
+func (o *configGenOptions) runConfigGen() {
+	dieIfNoProject()
+
+	manifests, err := configgen.Generate(o.dir, o.options)
+	if err != nil {
+		fail(errorKindError, exitError, fmt.Errorf("error generating the manifests of %s: %v", o.dir, err))
+	}
+	if _, err := os.Stdout.Write(manifests); err != nil {
+		fail(errorKindError, exitError, err)
+	}
+}
+
+func newConfigGenCmd() *cobra.Command {
+	options := configGenOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "config-gen [kustomization]",
+		Short: "Generate the manifests deploying the project without kustomize",
+		Long: `Generate the manifests deploying the project, the CRDs, the RBAC, the manager
+Deployment and, once enabled in config/default, the webhooks and the
+certificates, printed to the standard output. The kustomization, config/default
+by default, is built the way kustomize build does, so that the project can be
+deployed with kubectl alone.
+
+The resources and bases, the components, the strategic merge patches, the
+images, the namespace, the name prefix and the vars of the kustomizations are
+supported, which is what the scaffolded kustomizations use. The other fields
+are refused, build such a kustomization with kustomize.
+
+The CRDs, the role and the webhook configurations are read from the files
+make manifests generates, run it first after changing the API types or the
+markers.
+`,
+		Example: `	# Deploy the project in its namespace with the image built by make docker-build
+	make manifests
+	kubectl apply -f <(kubebuilder alpha config-gen --image controller:latest)
+
+	# Deploy a single replica in another namespace
+	kubebuilder alpha config-gen --namespace my-operator --replicas 1 | kubectl apply -f -
+`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			options.dir = filepath.Join("config", "default")
+			if len(args) > 0 {
+				options.dir = args[0]
+			}
+			if cmd.Flags().Changed("replicas") {
+				if options.replicas < 0 {
+					failInvalidFlags(fmt.Errorf("--replicas must not be negative"))
+				}
+				options.options.Replicas = &options.replicas
+			}
+			options.runConfigGen()
+		},
+	}
+
+	cmd.Flags().StringVar(&options.options.Image, "image", "",
+		"image of the manager container, replacing the one of config/default")
+	cmd.Flags().StringVar(&options.options.Namespace, "namespace", "",
+		"namespace of the project, replacing the namespace of the kustomization")
+	cmd.Flags().Int32Var(&options.replicas, "replicas", 1,
+		"replicas of the manager Deployment, left as configured when unset")
+
+	return cmd
+}
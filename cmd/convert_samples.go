@@ -0,0 +1,83 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+)
+
+type convertSamplesOptions struct {
+	convertSamplesScaffolder scaffold.ConvertSamples
+
+	output outputOptions
+}
+
+func (o *convertSamplesOptions) runConvertSamples() {
+	dieIfNoProject()
+
+	if err := o.convertSamplesScaffolder.Validate(); err != nil {
+		failInvalidFlags(err)
+	}
+
+	if err := o.convertSamplesScaffolder.Scaffold(); err != nil {
+		failScaffold(err)
+	}
+
+	// the samples are part of the scaffold, the converter and the samples it
+	// already overwrote are rolled back when it fails
+	if err := o.convertSamplesScaffolder.Convert(); err != nil {
+		failScaffold(err)
+	}
+}
+
+func newConvertSamplesCmd() *cobra.Command {
+	options := convertSamplesOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "convert-samples",
+		Short: "Convert the samples of config/samples between the versions of their kinds",
+		Long: `Convert the samples of config/samples between the versions of their kinds,
+run by make convert-samples.
+
+The command generates hack/convert-samples/main.go from the kinds of the
+PROJECT file with several versions, and runs it offline, without a cluster. For
+every such kind, the sample of the version marked as the conversion.Hub is
+converted through the ConvertFrom function of each of the other versions, which
+must implement conversion.Convertible, and the samples of the other versions
+are overwritten with the results. The kinds without a hub version are skipped.
+
+Edit the sample of the hub version and run the command again to keep the
+samples of all the served versions in line with the conversion functions.
+
+This command is only available for v2 scaffolding project.
+`,
+		Example: `	# With v2 of FirstMate marked as the conversion.Hub, overwrite
+	# config/samples/crew_v1_firstmate.yaml with
+	# config/samples/crew_v2_firstmate.yaml converted to v1
+	kubebuilder alpha convert-samples
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.output.run(options.runConvertSamples)
+		},
+	}
+
+	options.output.bindCmdFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+type webhookV2Options struct {
+	webhookScaffolder scaffold.Webhook
+
+	output outputOptions
+}
+
+func (o *webhookV2Options) bindCmdFlags(cmd *cobra.Command) {
+	o.webhookScaffolder.Resource = &resource.Resource{}
+	cmd.Flags().StringVar(&o.webhookScaffolder.Resource.Group, "group", "", "resource Group")
+	cmd.Flags().StringVar(&o.webhookScaffolder.Resource.Version, "version", "", "resource Version")
+	cmd.Flags().StringVar(&o.webhookScaffolder.Resource.Kind, "kind", "", "resource Kind")
+	cmd.Flags().StringVar(&o.webhookScaffolder.Resource.Resource, "resource", "", "resource Resource")
+	cmd.Flags().BoolVar(&o.webhookScaffolder.Defaulting, "defaulting", false,
+		"if set, scaffold the defaulting webhook")
+	cmd.Flags().BoolVar(&o.webhookScaffolder.DefaultMarkers, "default-markers", false,
+		"if set, add a field defaulted by a +kubebuilder:default marker and by the defaulting webhook, "+
+			"with a test checking both defaults match")
+	cmd.Flags().BoolVar(&o.webhookScaffolder.Validation, "programmatic-validation", false,
+		"if set, scaffold the validating webhook")
+	cmd.Flags().BoolVar(&o.webhookScaffolder.ReportOnly, "report-only", false,
+		"if set, the validating webhook admits requests failing validation and records them as audit annotations")
+	cmd.Flags().BoolVar(&o.webhookScaffolder.Envtest, "envtest", true,
+		"if set, serve the defaulting and validating webhooks in the test environment of the controller suite")
+	cmd.Flags().BoolVar(&o.webhookScaffolder.References, "reference-validation", false,
+		"if set, scaffold a validating webhook denying the objects which reference Secrets that do not exist")
+	cmd.Flags().BoolVar(&o.webhookScaffolder.Payloads, "payload-validation", false,
+		"if set, scaffold a validating webhook denying the objects whose payload reference does not match the referenced payload")
+	cmd.Flags().BoolVar(&o.webhookScaffolder.DeletionProtection, "deletion-protection", false,
+		"if set, scaffold a validating webhook denying the deletion of the objects labelled or annotated as protected")
+	cmd.Flags().BoolVar(&o.webhookScaffolder.Quota, "quota", false,
+		"if set, scaffold a validating webhook denying the creation of the objects beyond --quota-limit per namespace")
+	cmd.Flags().IntVar(&o.webhookScaffolder.QuotaLimit, "quota-limit", 10,
+		"maximum number of objects per namespace enforced by the quota webhook")
+	cmd.Flags().StringVar(&o.webhookScaffolder.Delegate, "delegate", "",
+		fmt.Sprintf("if set, scaffold a validating webhook delegating the admission of the objects to a policy engine, one of %s, %s",
+			project.PolicyDelegateOPA, project.PolicyDelegateHTTP))
+	cmd.Flags().BoolVar(&o.webhookScaffolder.Conversion, "conversion", false,
+		"if set, point the conversion webhook patch of the CRD to the conversion webhook registered in main.go")
+	cmd.Flags().StringVar(&o.webhookScaffolder.HubVersion, "hub-version", "",
+		"version the other versions are converted through, marked as the conversion.Hub, --version if unset")
+	cmd.Flags().StringVar(&o.webhookScaffolder.ConversionPath, "conversion-path", "/convert",
+		"path the conversion webhook is served at")
+	cmd.Flags().IntVar(&o.webhookScaffolder.ConversionPort, "conversion-port", 0,
+		"port of the webhook service receiving the conversion reviews, 443 if unset")
+	cmd.Flags().StringSliceVar(&o.webhookScaffolder.ConversionReviewVersions, "conversion-review-versions", nil,
+		"ConversionReview versions accepted by the conversion webhook, v1beta1 if unset")
+	cmd.Flags().StringVar(&o.webhookScaffolder.CertProvider, "cert-provider", project.CertProviderCertManager,
+		fmt.Sprintf("tool provisioning the webhook serving certificate, one of %s, %s, %s, %s",
+			project.CertProviderCertManager, project.CertProviderVault, project.CertProviderCSI,
+			project.CertProviderSelfSigned))
+}
+
+func (o *webhookV2Options) runAddWebhook() {
+	dieIfNoProject()
+
+	if err := o.webhookScaffolder.Validate(); err != nil {
+		failInvalidFlags(err)
+	}
+
+	fmt.Println("Writing scaffold for you to edit...")
+
+	if err := o.webhookScaffolder.Scaffold(); err != nil {
+		failScaffold(err)
+	}
+
+	if o.webhookScaffolder.DefaultMarkers {
+		fmt.Println("Next: run make manifests to add the defaults to the CRD, with a controller-gen " +
+			"supporting the +kubebuilder:default markers (v0.2.2 or later).")
+	}
+
+	if o.webhookScaffolder.Conversion {
+		fmt.Printf("Next: fill in the conversion functions of the <version>/%s_conversion.go files, "+
+			"and make the hub version the storage version of the CRD.\n",
+			strings.ToLower(o.webhookScaffolder.Resource.Kind))
+	}
+
+	if o.webhookScaffolder.Delegate != "" {
+		fmt.Println("Next: set the POLICY_ENDPOINT environment variable of the manager in config/manager/manager.yaml " +
+			"to the URL of the policy engine.")
+	}
+
+	switch o.webhookScaffolder.CertProvider {
+	case project.CertProviderCertManager:
+		fmt.Println("Next: uncomment the [WEBHOOK] and [CERTMANAGER] sections in " +
+			"config/default/kustomization.yaml to deploy the webhooks.")
+	case project.CertProviderVault:
+		fmt.Println("Next: uncomment the [WEBHOOK] section in config/default/kustomization.yaml " +
+			"and replace manager_webhook_patch.yaml with manager_webhook_vault_patch.yaml.")
+		fmt.Println("Set the Vault role and PKI path in config/default/manager_webhook_vault_patch.yaml, " +
+			"and the caBundle of the webhook configurations to the CA of the PKI secrets engine.")
+	case project.CertProviderCSI:
+		fmt.Println("Next: uncomment the [WEBHOOK] section in config/default/kustomization.yaml, " +
+			"replace manager_webhook_patch.yaml with manager_webhook_csi_patch.yaml and add ../secretstore to its bases.")
+		fmt.Println("Set the provider in config/secretstore/secretproviderclass.yaml, " +
+			"and the caBundle of the webhook configurations to the CA which issued the certificate.")
+	case project.CertProviderSelfSigned:
+		fmt.Println("Next: uncomment the [WEBHOOK] section in config/default/kustomization.yaml " +
+			"and replace manager_webhook_patch.yaml with manager_webhook_selfsigned_patch.yaml.")
+	}
+
+	if p, err := scaffold.LoadProjectFile("PROJECT"); err == nil && p.Packaging == project.PackagingHelm {
+		fmt.Println("Next: run make helm to add the webhooks to the chart once they are enabled in config/default.")
+	}
+}
+
+func newWebhookV2Cmd() *cobra.Command {
+	options := webhookV2Options{}
+
+	cmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "Scaffold a webhook for an API resource",
+		Long: `Scaffold a defaulting and / or validating webhook for an API resource.
+
+The defaulting and validating webhooks are registered in main.go. Their
+handlers decode the objects with the decoder injected by the manager, and pass
+the request context and the admission request to Default, ValidateCreate and
+ValidateUpdate, so that they can, e.g., authorize a change depending on the
+user making it.
+
+The webhook serving certificate is issued by cert-manager by default. On
+clusters standardized on another secret store, --cert-provider=vault scaffolds
+a manager patch rendering the certificate with the Vault agent injector, and
+--cert-provider=csi one mounting it with the secrets-store CSI driver. On
+clusters without any, --cert-provider=self-signed scaffolds the certrotator
+package, which has the manager issue the certificate with a self-signed CA
+before serving the webhooks, renew it before it expires and inject the CA in
+the webhook configurations and the converted CRDs calling the webhook service.
+
+The webhooks are scaffolded with the table-driven tests of
+<version>/<kind>_webhook_test.go, sending objects to them the way the
+apiserver does. The defaulting test checks the JSON patch the webhook answers
+with and the object that patch results in: add the lists and maps your
+defaulting fills in to it. The validating tests check the creations and
+updates are admitted or denied as expected: add an entry for each rule
+ValidateCreate and ValidateUpdate enforce.
+
+With --default-markers, a Size field defaulted by a +kubebuilder:default
+marker is added to the spec of the API, and Default fills in the same default.
+The apiserver fills in the defaults of the schema of the CRD before calling
+the webhook, so the defaults of the markers and of Default must not diverge:
+<version>/<kind>_defaults_test.go defaults objects from the schema of the CRD
+generated by make manifests and with Default, and checks the results match.
+Keep both in sync as you add defaulted fields, and add the objects they are
+defaulted in to the test. The defaults of the markers are only generated by
+controller-gen v0.2.2 or later; the test is skipped while the CRD has none.
+
+The defaulting and validating webhooks are registered in the
+envtest_webhooks_test.go file of the controller suite, unless --envtest=false
+is set. The suite serves them from a webhook server on the local host with a
+certificate it issues, and installs their configurations, generated in
+config/webhook by make manifests, in the apiserver of its test environment,
+calling that server: the objects created by the tests go through the webhooks
+as they do in a cluster.
+
+When the controller of the API exists, the defaulting and validating webhooks
+are also scaffolded with an integration test in the suite of the controller,
+admitting an object through the webhooks the way the apiserver does before
+creating it in the test environment and waiting for its reconcile.
+
+With --report-only, the validating webhook is scaffolded with failurePolicy
+Ignore and admits the requests failing validation, recording why they would
+have been denied as audit annotations. This allows rolling out the validation
+progressively, observing its effect in the audit log before enforcing it.
+
+With --reference-validation, a validating webhook denying the objects which
+reference Secrets that do not exist is scaffolded. It is registered in main.go
+and looks the Secrets up with the API reader of the manager, remembering the
+ones found for a few seconds, so that it only needs the get permission on
+Secrets. Fill in referencedSecrets with the references of your spec.
+
+With --payload-validation, a validating webhook denying the objects whose
+payload reference, scaffolded by create api --payload-reference, does not
+resolve to a payload matching its pinned digest is scaffolded and registered
+in main.go.
+
+With --deletion-protection, a validating webhook denying the deletion of the
+objects labelled or annotated with <domain>/deletion-protection=true is
+scaffolded and registered in main.go. The members of the
+<domain>:deletion-protection-break-glass group can still delete them, which is
+recorded as an audit annotation of the request.
+
+With --quota, a validating webhook denying the creation of an object in a
+namespace already holding --quota-limit of them is scaffolded and registered
+in main.go. It lists the objects of the namespace with the client of the
+manager, from the informer of the cache it starts along with the manager, and
+is given the list and watch permissions needed by that informer. The limit is
+the <Kind>QuotaLimit variable of <version>/<kind>_quota_webhook.go, which can
+be set from a flag of main.go. As the cache lags behind the apiserver, a burst
+of creations can exceed the limit by a few objects.
+
+With --delegate, a validating webhook delegating the admission of the objects
+to a policy engine centralizing the policies of the organization is scaffolded
+in <version>/<kind>_policy_webhook.go and registered in main.go.
+--delegate=opa queries the deny rule of an Open Policy Agent with the
+admission request as input, denying the requests it returns messages for, and
+--delegate=http posts the admission request to an endpoint answering with
+{"allowed": <bool>, "reason": <string>}. The URL of the policy engine is read
+from the POLICY_ENDPOINT environment variable of the manager. Each call is
+bounded by the <Kind>PolicyTimeout variable, and <Kind>PolicyFailureMode
+decides the outcome of the requests the policy engine fails to decide:
+fallback, the default, validates the object locally with
+validatePolicyLocally, deny denies them and allow admits them, recording the
+failure as an audit annotation of the request.
+
+With --conversion, the conversion webhook of controller-runtime is registered
+in main.go and the conversion patch of the CRD, config/crd/patches/
+webhook_in_<resource>.yaml, is scaffolded again to point to it and enabled in
+config/crd/kustomization.yaml, along with the CA injection patch with
+cert-manager. The path it is served at, the port of the webhook service and
+the ConversionReview versions it accepts are set with --conversion-path,
+--conversion-port and --conversion-review-versions. The hub version, --version
+unless set with --hub-version, is marked as the conversion.Hub in
+<version>/<kind>_conversion.go, and the stubs of the ConvertTo and ConvertFrom
+functions of the other versions of the kind are scaffolded next to their
+types. The files already present are left as they are, so that the command can
+be run again to scaffold the stubs of a new version.
+
+The webhooks registered in main.go are skipped when the ENABLE_WEBHOOKS
+environment variable is false, so that the manager can run locally with
+ENABLE_WEBHOOKS=false make run, without a serving certificate. The defaulting
+and validating webhooks are registered by the controller of the type and are
+always served.
+
+This command is only available for v2 scaffolding project.
+`,
+		Example: `	# Create defaulting and validating webhooks for CRD of group crew, version
+	# v1 and kind FirstMate.
+	kubebuilder create webhook --group crew --version v1 --kind FirstMate --defaulting --programmatic-validation
+
+	# Create a defaulting webhook whose defaults are checked against the
+	# +kubebuilder:default markers of the spec.
+	kubebuilder create webhook --group crew --version v1 --kind FirstMate --defaulting --default-markers
+
+	# Create a validating webhook which only reports the requests it would deny.
+	kubebuilder create webhook --group crew --version v1 --kind FirstMate --programmatic-validation --report-only
+
+	# Create a validating webhook checking the Secrets referenced by the spec exist.
+	kubebuilder create webhook --group crew --version v1 --kind FirstMate --reference-validation
+
+	# Create a validating webhook checking the payload referenced by the spec matches its digest.
+	kubebuilder create webhook --group crew --version v1 --kind FirstMate --payload-validation
+
+	# Create a webhook protecting the FirstMate objects labelled as protected from deletion.
+	kubebuilder create webhook --group crew --version v1 --kind FirstMate --deletion-protection
+
+	# Create a webhook denying the creation of more than 5 FirstMate objects per namespace.
+	kubebuilder create webhook --group crew --version v1 --kind FirstMate --quota --quota-limit=5
+
+	# Create a webhook delegating the admission of FirstMate to an Open Policy Agent.
+	kubebuilder create webhook --group crew --version v1 --kind FirstMate --delegate=opa
+
+	# Serve the conversion webhook of FirstMate at /convert-firstmate, through the
+	# port 8443 of the webhook service.
+	kubebuilder create webhook --group crew --version v1 --kind FirstMate --conversion \
+		--conversion-path=/convert-firstmate --conversion-port=8443
+
+	# Convert the v1 FirstMate objects through v2, scaffolding the Hub function
+	# of v2 and the conversion functions of v1.
+	kubebuilder create webhook --group crew --version v1 --kind FirstMate --conversion --hub-version=v2
+
+	# Create a defaulting webhook whose certificate is rendered by the Vault agent.
+	kubebuilder create webhook --group crew --version v1 --kind FirstMate --defaulting --cert-provider=vault
+
+	# Create a defaulting webhook whose certificate is self-signed by the manager.
+	kubebuilder create webhook --group crew --version v1 --kind FirstMate --defaulting --cert-provider=self-signed
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.output.run(options.runAddWebhook)
+		},
+	}
+
+	options.bindCmdFlags(cmd)
+	options.output.bindCmdFlags(cmd)
+	bindGitCommitFlag(cmd)
+
+	return cmd
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kubebuilder/pkg/drift"
+)
+
+type diffTemplatesOptions struct {
+	diff bool
+	keep bool
+}
+
+func (o *diffTemplatesOptions) runDiffTemplates() {
+	dieIfNoProject()
+
+	kubebuilder, err := os.Executable()
+	if err != nil {
+		log.Fatal(err)
+	}
+	parent, err := ioutil.TempDir("", "kubebuilder-diff-templates-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !o.keep {
+		defer os.RemoveAll(parent) // nolint: errcheck
+	}
+
+	pristine, err := drift.Pristine(kubebuilder, ".", parent)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if o.keep {
+		fmt.Printf("pristine project scaffolded in %s\n", pristine)
+	}
+	changes, err := drift.Compare(".", pristine)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	if o.diff {
+		for _, c := range changes {
+			if c.Diff != "" {
+				fmt.Println()
+				fmt.Print(c.Diff)
+			}
+		}
+	}
+	if len(changes) > 0 {
+		if len(changes) == 1 {
+			fmt.Println("1 file drifted from the scaffolding")
+		} else {
+			fmt.Printf("%d files drifted from the scaffolding\n", len(changes))
+		}
+		if !o.keep {
+			os.RemoveAll(parent) // nolint: errcheck
+		}
+		os.Exit(1)
+	}
+	fmt.Println("no drift from the scaffolding")
+}
+
+func newDiffTemplatesCmd() *cobra.Command {
+	options := diffTemplatesOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "diff-templates",
+		Short: "Compare the project with its pristine scaffolding",
+		Long: `Compare the project in the current directory with the pristine scaffolding of
+its recorded commands, printing the files which drifted from it.
+
+The commands of .kubebuilder/history.yaml are run again, with the flags they
+were recorded with, by this kubebuilder in a temporary directory: the pristine
+project is the scaffolding of the current templates. Each file of the project
+is then reported as:
+
+- modified: scaffolded, and changed by the user. Its diff from the pristine
+  file is printed, unless --diff=false.
+- deleted: scaffolded, and deleted by the user.
+- added: added by the user, or by a command which is not recorded.
+
+The files generated by make and go, e.g. the CRDs of config/crd/bases, the
+deepcopy functions and go.sum, are not compared. The boilerplate of the project
+heads the pristine files, so that a file differing only by the year of its
+copyright is not reported.
+
+The templates changing between the version of kubebuilder which scaffolded a
+file and this one, the changes of a modified file are also the ones of the
+templates. Keep the pristine project with --keep to merge them into the
+project.
+
+The command exits with an error status when a file drifted.
+`,
+		Example: `	# Print the files of the project which drifted from the scaffolding, with their diff
+	kubebuilder alpha diff-templates
+
+	# Print the files only, keeping the pristine project
+	kubebuilder alpha diff-templates --diff=false --keep
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.runDiffTemplates()
+		},
+	}
+	cmd.Flags().BoolVar(&options.diff, "diff", true,
+		"if set, print the diff of the modified files from the pristine ones")
+	cmd.Flags().BoolVar(&options.keep, "keep", false,
+		"if set, keep the pristine project in its temporary directory, printing it")
+
+	return cmd
+}
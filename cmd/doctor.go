@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kubebuilder/pkg/doctor"
+)
+
+type doctorOptions struct {
+	patterns []string
+	rbac     bool
+}
+
+func (o *doctorOptions) runDoctor() {
+	var patterns []string
+	if o.rbac {
+		patterns = o.patterns
+	}
+	problems, err := doctor.Check(".", patterns...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		if len(problems) == 1 {
+			fmt.Println("1 problem found")
+		} else {
+			fmt.Printf("%d problems found\n", len(problems))
+		}
+		os.Exit(1)
+	}
+	fmt.Println("no problem found")
+}
+
+func newDoctorCmd() *cobra.Command {
+	options := doctorOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "doctor [packages]",
+		Short: "Check the project for the drift from its scaffolding",
+		Long: `Check the project in the current directory for the drift from its scaffolding,
+printing each problem found with its fix.
+
+- The resources of the PROJECT file must have their API types in api, and in
+  projects of version 3 their controllers and webhooks, in the files they are
+  scaffolded in. The API types of api must be tracked in the PROJECT file.
+- The scaffold marker comments must still be in the files the commands update:
+  main.go, the kustomizations of config and the test suites of the controllers.
+  A command finding no marker leaves the file unchanged.
+- The Makefile must install the version of controller-gen generating the
+  markers of the scaffolds, whose minor version matches the one of
+  controller-runtime in go.mod.
+- The resources the code of the packages uses with the client must be granted
+  by +kubebuilder:rbac markers, as checked by kubebuilder alpha verify-rbac.
+  The packages, ./... by default, are type-checked: their dependencies must be
+  downloaded. Skip the check with --rbac=false.
+
+The command exits with an error status when a problem is found.
+`,
+		Example: `	# Check the project in the current directory
+	kubebuilder doctor
+
+	# Check the project, the RBAC markers of the controllers only
+	kubebuilder doctor ./controllers/...
+
+	# Check the project without type-checking its packages
+	kubebuilder doctor --rbac=false
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.patterns = args
+			if len(options.patterns) == 0 {
+				options.patterns = []string{"./..."}
+			}
+			options.runDoctor()
+		},
+	}
+	cmd.Flags().BoolVar(&options.rbac, "rbac", true,
+		"if set, check the resources the code of the packages uses are granted by +kubebuilder:rbac markers")
+
+	return cmd
+}
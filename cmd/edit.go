@@ -0,0 +1,212 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+)
+
+type editOptions struct {
+	editScaffolder scaffold.Edit
+	multiGroup     bool
+	multiGroupFlag *flag.Flag
+}
+
+func (o *editOptions) bindCmdFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.editScaffolder.Observability, "observability", false,
+		"if set, scaffold a prometheus ServiceMonitor, alerting rules and Grafana dashboards for the manager metrics")
+	cmd.Flags().BoolVar(&o.editScaffolder.GrafanaDashboard, "grafana-dashboard", false,
+		"if set, scaffold a ConfigMap of the Grafana dashboards of the manager metrics, loaded by the Grafana dashboard sidecar")
+	cmd.Flags().BoolVar(&o.editScaffolder.Sharding, "sharding", false,
+		"if set, scaffold helpers sharding the controllers across the replicas of a StatefulSet, and its config")
+	cmd.Flags().StringVar(&o.editScaffolder.Autoscaling, "autoscaling", "",
+		"autoscaler scaling the shards of config/sharding on the depth of the workqueues, scaffolded under config/autoscaling (one of hpa, keda)")
+	cmd.Flags().BoolVar(&o.editScaffolder.UninstallJob, "uninstall-job", false,
+		"if set, scaffold a Job deleting the CRs of the project, then its CRDs, before uninstalling the operator")
+	cmd.Flags().BoolVar(&o.editScaffolder.ManagedNamespaces, "managed-namespaces", false,
+		"if set, scaffold a helper creating a namespace per CR, annotated with its owner, and deleting it once released")
+	cmd.Flags().StringVar(&o.editScaffolder.Packaging, "packaging", "",
+		"packaging format to generate from the kustomize config, kept in sync with the APIs by make helm (one of helm)")
+	cmd.Flags().BoolVar(&o.multiGroup, "multigroup", false,
+		"if true, lay out the APIs and controllers by group, allowing APIs in several groups")
+	o.multiGroupFlag = cmd.Flag("multigroup")
+	cmd.Flags().StringVar(&o.editScaffolder.ProjectVersion, "project-version", "",
+		"version the project file is upgraded to, tracking the API types, controller and webhooks of each resource (one of 3)")
+	cmd.Flags().StringVar(&o.editScaffolder.Domain, "domain", "",
+		"domain the API groups of the project are renamed to, in its markers, manifests, webhook paths and PROJECT file")
+	cmd.Flags().StringVar(&o.editScaffolder.Repo, "repo", "",
+		"go module path the project is renamed to, in go.mod, its imports and PROJECT file")
+	cmd.Flags().BoolVar(&o.editScaffolder.DryRun, "dry-run", false,
+		"if set, print the diff of the rename of --domain and --repo instead of applying it")
+}
+
+func (o *editOptions) runEdit() {
+	dieIfNoProject()
+
+	if o.multiGroupFlag.Changed {
+		o.editScaffolder.MultiGroup = &o.multiGroup
+	}
+
+	if o.editScaffolder.Domain != "" {
+		if err := validateDomain(o.editScaffolder.Domain); err != nil {
+			failInvalidFlags(fmt.Errorf("invalid domain %q: %v", o.editScaffolder.Domain, err))
+		}
+	}
+	if o.editScaffolder.Repo != "" {
+		if err := validateRepo(o.editScaffolder.Repo); err != nil {
+			failInvalidFlags(fmt.Errorf("invalid repo %q: %v", o.editScaffolder.Repo, err))
+		}
+	}
+
+	if err := o.editScaffolder.Validate(); err != nil {
+		failInvalidFlags(err)
+	}
+
+	if o.editScaffolder.DryRun {
+		if err := o.editScaffolder.Scaffold(); err != nil {
+			failScaffold(err)
+		}
+		return
+	}
+
+	fmt.Println("Writing scaffold for you to edit...")
+
+	if err := o.editScaffolder.Scaffold(); err != nil {
+		failScaffold(err)
+	}
+
+	if o.editScaffolder.Domain != "" || o.editScaffolder.Repo != "" {
+		fmt.Println("Next: run make to regenerate the manifests and the code and build the renamed project, " +
+			"then delete the CRDs of the old API groups from the clusters the project was installed in.")
+	}
+	if o.editScaffolder.Observability {
+		fmt.Println("Next: uncomment the [PROMETHEUS] section in config/default/kustomization.yaml " +
+			"to deploy the ServiceMonitor and alerting rules.")
+	}
+	if o.editScaffolder.GrafanaDashboard {
+		fmt.Println("Next: uncomment the [GRAFANA] section in config/default/kustomization.yaml " +
+			"to deploy the ConfigMap of the dashboards in the namespace watched by the Grafana dashboard sidecar.")
+	}
+	if o.editScaffolder.Sharding {
+		fmt.Println("Next: filter the events of each controller with WithEventFilter(shard.Predicate()), " +
+			"where shard is returned by controllers.ShardFromEnv() in main.go, then deploy config/sharding.")
+	}
+	if o.editScaffolder.Autoscaling != "" {
+		fmt.Println("Next: get the shard from controllers.ShardFromStatefulSet(ctx, mgr.GetAPIReader()) in main.go, " +
+			"add a controllers.ShardCountWatcher of the shard to the manager, then deploy config/autoscaling.")
+	}
+	if o.editScaffolder.UninstallJob {
+		fmt.Println("Next: run config/uninstall to completion before deleting config/default, " +
+			"or run make uninstall-safe against the CRDs installed with make install.")
+	}
+	if o.editScaffolder.ManagedNamespaces {
+		fmt.Println("Next: call Ensure and Release of controllers.Namespaces from your reconcilers, " +
+			"and add it to the manager in main.go to delete the namespaces of the deleted CRs.")
+	}
+	if o.editScaffolder.Packaging == project.PackagingHelm {
+		if makefile, err := ioutil.ReadFile("Makefile"); err == nil && !strings.Contains(string(makefile), "\nhelm:") {
+			fmt.Println("The Makefile predates the helm target, add it:\n" +
+				"helm: manifests\n\tkustomize build config/default | $(KUBEBUILDER) alpha helm")
+		}
+		fmt.Println("Next: run make helm to generate the templates, CRDs and values of the chart, " +
+			"and again whenever the APIs, webhooks or config/default change.")
+	}
+	if o.editScaffolder.ProjectVersion == project.Version3 {
+		fmt.Println("Next: check the resources detected in the PROJECT file. The controllers of external types " +
+			"are not tracked by version 2, add them with controller: true and no api.")
+	}
+}
+
+func newEditCmd() *cobra.Command {
+	options := editOptions{}
+
+	editCmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Add optional components to an initialized project",
+		Long: `Add optional components to a project which has already been initialized.
+
+This command is only available for v2 scaffolding project.
+
+With --domain and --repo, the project is renamed after init: go.mod, the
+imports of its packages, the API groups in the markers, the CRD manifests
+and the samples, the webhook paths and names, the kustomize references to
+the CRD manifests, which are moved to their new names, and the PROJECT file
+are rewritten. The hidden directories, vendor, bin and testbin are left
+unchanged. With --dry-run, the diff of the rename is printed and nothing is
+written.
+`,
+		Example: `	# Scaffold the prometheus ServiceMonitor, PrometheusRule, alerts and
+	# Grafana dashboards for the manager metrics under config/prometheus
+	kubebuilder edit --observability
+
+	# Generate a ConfigMap of the Grafana dashboards from
+	# config/prometheus/dashboards, labelled to be loaded by the dashboard
+	# sidecar of Grafana
+	kubebuilder edit --grafana-dashboard
+
+	# Scaffold the helpers sharding the controllers across the replicas of a
+	# StatefulSet, deployed by config/sharding
+	kubebuilder edit --sharding
+
+	# Scale the shards on the depth of the workqueues of the controllers with
+	# a HorizontalPodAutoscaler, or a ScaledObject of KEDA, deployed by
+	# config/autoscaling
+	kubebuilder edit --sharding --autoscaling=hpa
+
+	# Scaffold the Job deleting the CRs of the project, removing the finalizers
+	# the controllers leave, then its CRDs, under config/uninstall
+	kubebuilder edit --uninstall-job
+
+	# Scaffold controllers/namespaces.go, creating a namespace per CR labelled
+	# as managed by the operator, and deleting it unless it holds objects the
+	# operator did not create
+	kubebuilder edit --managed-namespaces
+
+	# Scaffold the Helm chart of the project under charts/<project>, whose
+	# templates, CRDs and values are generated from config/default by make helm
+	kubebuilder edit --packaging=helm
+
+	# Enable APIs in several groups, laid out under api/<group>/<version>
+	# and controllers/<group>
+	kubebuilder edit --multigroup=true
+
+	# Upgrade the PROJECT file to version 3, tracking the API types, controller
+	# and webhooks of each resource, detected from the files scaffolded for them
+	kubebuilder edit --project-version=3
+
+	# Preview the rename of the domain of the API groups and of the module
+	# path of the project, then apply it
+	kubebuilder edit --domain example.org --repo github.com/example/project --dry-run
+	kubebuilder edit --domain example.org --repo github.com/example/project
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.runEdit()
+		},
+	}
+
+	options.bindCmdFlags(editCmd)
+
+	return editCmd
+}
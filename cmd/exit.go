@@ -0,0 +1,119 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
+)
+
+// The exit codes of the commands, so that the wrappers and CI can tell the
+// failures apart.
+const (
+	// exitError is the exit code of the other failures
+	exitError = 1
+	// exitInvalidFlags is the exit code of the invalid flags and arguments
+	exitInvalidFlags = 2
+	// exitPreflight is the exit code of the checks failing before anything is
+	// written, e.g. a missing PROJECT file or a dirty git working tree
+	exitPreflight = 3
+	// exitPartialScaffold is the exit code of the scaffolds failing after some
+	// files were written, which could not be rolled back
+	exitPartialScaffold = 4
+	// exitHookFailure is the exit code of the steps run once the scaffold is
+	// written, e.g. make, go mod or git commit
+	exitHookFailure = 5
+)
+
+// The kinds of the failures, reported along with their exit code by the
+// machine-readable outputs.
+const (
+	errorKindError           = "error"
+	errorKindInvalidFlags    = "invalid-flags"
+	errorKindPreflight       = "preflight"
+	errorKindPartialScaffold = "partial-scaffold"
+	errorKindHookFailure     = "post-scaffold-hook"
+)
+
+// failureReport prints the result of the command with the given failure in
+// the machine-readable format of its --output flag, nil with the text format
+// or once the result is printed.
+var failureReport func(e *result.Error)
+
+// fail exits with the exit code of the given kind of failure, after logging
+// err.
+func fail(kind string, code int, err error) {
+	log.Println(err)
+	exit(kind, code, err)
+}
+
+// exit exits with the given exit code, reporting err in the machine-readable
+// format of the command.
+func exit(kind string, code int, err error) {
+	if failureReport != nil {
+		failureReport(&result.Error{Kind: kind, Message: err.Error(), ExitCode: code})
+	}
+	os.Exit(code)
+}
+
+// failInvalidFlags exits with exitInvalidFlags.
+func failInvalidFlags(err error) {
+	fail(errorKindInvalidFlags, exitInvalidFlags, err)
+}
+
+// failPreflight exits with exitPreflight.
+func failPreflight(err error) {
+	fail(errorKindPreflight, exitPreflight, err)
+}
+
+// failHook exits with exitHookFailure.
+func failHook(err error) {
+	fail(errorKindHookFailure, exitHookFailure, err)
+}
+
+// failScaffold rolls back the files written by the failed scaffold and exits
+// with exitError. If the rollback fails, it exits with exitPartialScaffold,
+// printing the files to revert before running the command again.
+func failScaffold(err error) {
+	r := result.Get()
+	if len(r.FilesCreated) == 0 && len(r.FilesModified) == 0 {
+		fail(errorKindError, exitError, err)
+	}
+
+	restored, rollbackErr := rollback.Rollback()
+	if rollbackErr == nil {
+		result.DiscardChanges()
+		log.Println(err)
+		log.Printf("rolled back the %d files written before the failure", len(restored))
+		exit(errorKindError, exitError, err)
+	}
+
+	err = fmt.Errorf("partial scaffold: %v, %v", err, rollbackErr)
+	log.Println(err)
+	log.Println("remove or revert the files already written before running the command again:")
+	for _, path := range r.FilesCreated {
+		log.Printf("  created:  %s", path)
+	}
+	for _, path := range r.FilesModified {
+		log.Printf("  modified: %s", path)
+	}
+	exit(errorKindPartialScaffold, exitPartialScaffold, err)
+}
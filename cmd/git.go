@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const gitCommitFlag = "git-commit"
+
+// bindGitCommitFlag adds the flag committing what the command scaffolded.
+func bindGitCommitFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool(gitCommitFlag, false,
+		"if set, commit the files the command scaffolded, with the command as commit message. "+
+			"The git working tree must be clean, and is initialized by init if needed")
+}
+
+// gitCommitRequested returns true if the command was run with --git-commit.
+func gitCommitRequested(cmd *cobra.Command) bool {
+	f := cmd.Flags().Lookup(gitCommitFlag)
+	return f != nil && f.Value.String() == "true"
+}
+
+// checkGitWorkTree makes sure that the commit of a command run with
+// --git-commit only contains what the command scaffolded.
+func checkGitWorkTree(cmd *cobra.Command, args []string) {
+	if !gitCommitRequested(cmd) || !inGitWorkTree() {
+		return
+	}
+	out, err := exec.Command("git", "status", "--porcelain").Output()
+	if err != nil {
+		failPreflight(fmt.Errorf("error checking the git working tree: %v", err))
+	}
+	if len(out) > 0 {
+		failPreflight(fmt.Errorf("the git working tree has uncommitted changes, commit or stash them before running "+
+			"the command with --%s", gitCommitFlag))
+	}
+}
+
+// gitCommit commits the files scaffolded by a command run with --git-commit,
+// initializing the git repository if needed.
+func gitCommit(cmd *cobra.Command, args []string) {
+	if !gitCommitRequested(cmd) {
+		return
+	}
+	if !inGitWorkTree() {
+		if err := runGit("init"); err != nil {
+			failHook(fmt.Errorf("error initializing the git repository: %v", err))
+		}
+	}
+	if err := runGit("add", "--all"); err != nil {
+		failHook(fmt.Errorf("error adding the scaffolded files: %v", err))
+	}
+	flags := changedFlags(cmd)
+	delete(flags, gitCommitFlag)
+	message := commandLine(cmd.CommandPath(), args, flags)
+	if err := runGit("commit", "--quiet", "--message", message); err != nil {
+		failHook(fmt.Errorf("error committing the scaffolded files: %v", err))
+	}
+}
+
+func inGitWorkTree() bool {
+	out, err := exec.Command("git", "rev-parse", "--is-inside-work-tree").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// runGit runs git with the given arguments, showing its errors. Its output is
+// discarded so that the machine-readable output of the command is preserved.
+func runGit(args ...string) error {
+	c := exec.Command("git", args...) // #nosec
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("git %s: %v", strings.Join(args, " "), err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+)
+
+type helmOptions struct {
+	helmScaffolder scaffold.Helm
+
+	output outputOptions
+}
+
+func (o *helmOptions) runHelm() {
+	dieIfNoProject()
+
+	if err := o.helmScaffolder.Validate(); err != nil {
+		failInvalidFlags(err)
+	}
+
+	manifests, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		failInvalidFlags(fmt.Errorf("error reading the manifests: %v", err))
+	}
+	o.helmScaffolder.Manifests = manifests
+
+	if err := o.helmScaffolder.Scaffold(); err != nil {
+		failScaffold(err)
+	}
+}
+
+func newHelmCmd() *cobra.Command {
+	options := helmOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "helm",
+		Short: "Generate the Helm chart of charts/<project> from the manifests of config/default",
+		Long: `Generate the templates and the CRDs of the Helm chart of the project from the
+manifests built by kustomize from config/default, read from the standard input,
+run by make helm. The chart is scaffolded by kubebuilder edit --packaging=helm.
+
+The CRDs are written to the crds directory of the chart, the CRDs no longer in
+the manifests are removed from it. The other objects are written to
+templates/manifests.yaml, where the namespace of the project is replaced by the
+namespace of the release, and the image and the resources of the manager
+container by the image.repository, image.tag and resources values. The
+Namespace object is left out, install the chart with helm install
+--create-namespace. values.yaml is written with the image and the resources of
+the manifests when it is missing, and left untouched otherwise.
+
+Add your own templates next to templates/manifests.yaml, which is overwritten.
+
+This command is only available for v2 scaffolding project.
+`,
+		Example: `	# Generate the chart from config/default
+	kustomize build config/default | kubebuilder alpha helm
+
+	# Install the chart
+	helm install my-operator charts/<project> --namespace my-operator --create-namespace
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.output.run(options.runHelm)
+		},
+	}
+
+	options.output.bindCmdFlags(cmd)
+
+	return cmd
+}
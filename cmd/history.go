@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	"sigs.k8s.io/kubebuilder/cmd/version"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/history"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+)
+
+// recordHistory appends the command which just ran to the history of the
+// project, if it created or modified any file of the project.
+func recordHistory(cmd *cobra.Command, args []string) {
+	if _, err := os.Stat("PROJECT"); err != nil {
+		return
+	}
+	r := result.Get()
+	if len(r.FilesCreated) == 0 && len(r.FilesModified) == 0 {
+		return
+	}
+
+	v := version.GetVersion()
+	e := history.Entry{
+		Time:          time.Now().UTC().Format(time.RFC3339),
+		Command:       cmd.CommandPath(),
+		Args:          args,
+		Version:       v.KubeBuilderVersion,
+		FilesCreated:  r.FilesCreated,
+		FilesModified: r.FilesModified,
+	}
+	// the commit is left unexpanded when kubebuilder is not built by the release
+	if !strings.HasPrefix(v.GitCommit, "$Format") {
+		e.GitCommit = v.GitCommit
+	}
+	if flags := changedFlags(cmd); len(flags) > 0 {
+		e.Flags = flags
+	}
+
+	if err := history.Append(history.Path, e); err != nil {
+		log.Printf("unable to record the command in %s: %v", history.Path, err)
+	}
+}
+
+func newHistoryCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "history",
+		Short: "Print the commands which scaffolded the project",
+		Long: fmt.Sprintf(`Print the commands which scaffolded the project, oldest first.
+
+Every command creating or modifying files of the project appends the time it
+ran, its arguments and flags, the version of kubebuilder, and the files it
+touched to %s. Commit the file with the project to keep
+track of how each file was scaffolded.
+`, history.Path),
+		Example: `	# Print the commands which scaffolded the project in the current directory
+	kubebuilder history
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			dieIfNoProject()
+			if err := printHistory(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+}
+
+func printHistory() error {
+	h, err := history.Load(history.Path)
+	if err != nil {
+		return err
+	}
+	if len(h.Entries) == 0 {
+		fmt.Printf("No command recorded in %s\n", history.Path)
+		return nil
+	}
+
+	for i, e := range h.Entries {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s  %s\n", e.Time, commandLine(e.Command, e.Args, e.Flags))
+		fmt.Printf("  version: %s\n", e.Version)
+		for _, f := range e.FilesCreated {
+			fmt.Printf("  created:  %s\n", f)
+		}
+		for _, f := range e.FilesModified {
+			fmt.Printf("  modified: %s\n", f)
+		}
+	}
+	return nil
+}
+
+// changedFlags returns the flags set on the command line, by name.
+func changedFlags(cmd *cobra.Command) map[string]string {
+	flags := map[string]string{}
+	cmd.Flags().Visit(func(f *flag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+	return flags
+}
+
+// commandLine returns the command run with the given arguments and flags,
+// the flags sorted by name.
+func commandLine(command string, args []string, flags map[string]string) string {
+	cmdline := append([]string{command}, args...)
+	names := []string{}
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmdline = append(cmdline, fmt.Sprintf("--%s=%s", name, flags[name]))
+	}
+	return strings.Join(cmdline, " ")
+}
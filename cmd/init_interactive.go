@@ -0,0 +1,105 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/cmd/util"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+)
+
+// domainRegex matches the DNS-1123 subdomains the API groups are suffixed with.
+var domainRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// repoElementRegex matches the elements of Go module paths.
+var repoElementRegex = regexp.MustCompile(`^[A-Za-z0-9_.~-]+$`)
+
+// validateDomain validates the domain is a DNS-1123 subdomain.
+func validateDomain(domain string) error {
+	if len(domain) > 253 || !domainRegex.MatchString(domain) {
+		return fmt.Errorf("must be a lowercase DNS-1123 subdomain, e.g. example.org")
+	}
+	return nil
+}
+
+// validateRepo validates the repo is a Go module path whose first element is
+// a domain name.
+func validateRepo(repo string) error {
+	if repo == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	elements := strings.Split(repo, "/")
+	for _, element := range elements {
+		if !repoElementRegex.MatchString(element) || strings.Trim(element, ".") == "" {
+			return fmt.Errorf("must be a Go module path, e.g. github.com/example/project")
+		}
+	}
+	if !strings.Contains(elements[0], ".") {
+		return fmt.Errorf("the first element %q of the module path must be a domain name, "+
+			"e.g. github.com/example/project", elements[0])
+	}
+	return nil
+}
+
+func validateLicense(license string) error {
+	switch license {
+	case "apache2", "none":
+		return nil
+	}
+	return fmt.Errorf("must be one of apache2, none")
+}
+
+func validateProjectVersion(version string) error {
+	switch version {
+	case project.Version1, project.Version2, project.Version3:
+		return nil
+	}
+	return fmt.Errorf("must be one of %s, %s, %s", project.Version1, project.Version2, project.Version3)
+}
+
+// promptProject walks the user through the domain, repo, license and project
+// version of the project, the current values of the flags being the defaults,
+// then asks for the confirmation of their summary. It returns false if the user
+// does not confirm.
+func (o *projectOptions) promptProject(reader *bufio.Reader) bool {
+	o.project.Domain = util.Prompt(reader, "Domain of the API groups", o.project.Domain, validateDomain)
+	o.project.Repo = util.Prompt(reader, "Go module path of the project", o.project.Repo, validateRepo)
+	o.boilerplate.License = util.Prompt(reader, "License of the boilerplate (apache2, none)",
+		o.boilerplate.License, validateLicense)
+	if o.boilerplate.License != "none" {
+		o.boilerplate.Owner = util.Prompt(reader, "Copyright owner", o.boilerplate.Owner,
+			func(string) error { return nil })
+	}
+	o.project.Version = util.Prompt(reader,
+		fmt.Sprintf("Project version (%s, %s, %s)", project.Version1, project.Version2, project.Version3),
+		o.project.Version, validateProjectVersion)
+
+	fmt.Println("The project will be scaffolded with:")
+	fmt.Printf("  domain:          %s\n", o.project.Domain)
+	fmt.Printf("  repo:            %s\n", o.project.Repo)
+	fmt.Printf("  license:         %s\n", o.boilerplate.License)
+	if o.boilerplate.License != "none" {
+		fmt.Printf("  owner:           %s\n", o.boilerplate.Owner)
+	}
+	fmt.Printf("  project version: %s\n", o.project.Version)
+	fmt.Println("Scaffold the project [y/n]")
+	return util.Yesno(reader)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestValidateDomain(t *testing.T) {
+	tests := []struct {
+		domain    string
+		isInvalid bool
+	}{
+		{"example.org", false},
+		{"k8s.io", false},
+		{"my-domain", false},
+		{"", true},
+		{"Example.org", true},
+		{"example.org.", true},
+		{"-example.org", true},
+		{"example_org", true},
+	}
+
+	for _, test := range tests {
+		err := validateDomain(test.domain)
+		if err != nil && !test.isInvalid {
+			t.Errorf("domain check failed valid domain '%s' with error '%s'", test.domain, err)
+		}
+		if err == nil && test.isInvalid {
+			t.Errorf("domain '%s' is invalid, but got no error", test.domain)
+		}
+	}
+}
+
+func TestValidateRepo(t *testing.T) {
+	tests := []struct {
+		repo      string
+		isInvalid bool
+	}{
+		{"github.com/example/project", false},
+		{"sigs.k8s.io/kubebuilder", false},
+		{"example.com/project/v2", false},
+		{"", true},
+		{"project", true},
+		{"github.com//project", true},
+		{"github.com/example/project/", true},
+		{"github.com/example project", true},
+		{"github.com/../project", true},
+	}
+
+	for _, test := range tests {
+		err := validateRepo(test.repo)
+		if err != nil && !test.isInvalid {
+			t.Errorf("repo check failed valid repo '%s' with error '%s'", test.repo, err)
+		}
+		if err == nil && test.isInvalid {
+			t.Errorf("repo '%s' is invalid, but got no error", test.repo)
+		}
+	}
+}
+
+func TestPromptProject(t *testing.T) {
+	o := projectOptions{}
+	o.project.Domain = "k8s.io"
+	o.project.Repo = "github.com/example/project"
+	o.project.Version = "2"
+	o.boilerplate.License = "apache2"
+
+	// an invalid domain is asked again, the empty answers keep the defaults
+	answers := "Example.org\nexample.org\n\nnone\n4\n\ny\n"
+	if !o.promptProject(bufio.NewReader(strings.NewReader(answers))) {
+		t.Fatalf("the confirmed project was not accepted")
+	}
+	if o.project.Domain != "example.org" {
+		t.Errorf("expected domain example.org, got %s", o.project.Domain)
+	}
+	if o.project.Repo != "github.com/example/project" {
+		t.Errorf("expected the default repo, got %s", o.project.Repo)
+	}
+	if o.boilerplate.License != "none" {
+		t.Errorf("expected license none, got %s", o.boilerplate.License)
+	}
+	if o.project.Version != "2" {
+		t.Errorf("expected the default project version, got %s", o.project.Version)
+	}
+
+	if o.promptProject(bufio.NewReader(strings.NewReader("\n\n\n\nn\n"))) {
+		t.Errorf("the project declined at the summary was accepted")
+	}
+}
@@ -17,8 +17,8 @@ limitations under the License.
 package main
 
 import (
+	"bufio"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"regexp"
@@ -55,13 +55,62 @@ project will prompt the user to run 'dep ensure' after writing the project files
 `,
 		Example: `# Scaffold a project using the apache2 license with "The Kubernetes authors" as owners
 kubebuilder init --domain example.org --license apache2 --owner "The Kubernetes authors"
+
+# Scaffold a project whose resources are prefixed with "guestbook" rather than the directory name
+kubebuilder init --domain example.org --project-name guestbook
+
+# Scaffold a project whose resources are named "op-<name>-staging", e.g. kustomize names
+# the webhook service "op-webhook-service-staging"
+kubebuilder init --domain example.org --name-prefix op- --name-suffix -staging
+
+# Scaffold a project whose controllers reconcile all their objects when the
+# controller-manager-settings ConfigMap changes
+kubebuilder init --domain example.org --settings
+
+# Scaffold a project whose controllers cancel the context of the reconciles
+# running longer than the --reconcile-timeout of the manager, counting them in
+# the controller_reconcile_deadline_exceeded_total metric
+kubebuilder init --domain example.org --reconcile-timeout
+
+# Scaffold a project whose controllers only write the status of the objects,
+# skipping the creations, updates and deletions, when the manager runs with
+# --read-only=true, e.g. during an incident or a migration
+kubebuilder init --domain example.org --read-only
+
+# Scaffold a project detecting at startup whether the cluster serves optional
+# APIs, setting up the controllers created with --requires-api only where it does
+kubebuilder init --domain example.org --capabilities
+
+# Scaffold a project whose example controller reconciles the ConfigMaps of a
+# second cluster, whose kubeconfig is read from the Secret of the
+# --remote-kubeconfig-secret flag of the manager
+kubebuilder init --domain example.org --remote-cluster
+
+# Scaffold a project whose metrics and webhook Services get an address of each
+# IP family on dual-stack clusters, and a single one on IPv4 or IPv6-only clusters
+kubebuilder init --domain example.org --dual-stack
+
+# Scaffold a project prompting for its domain, repo, license and project
+# version, the values of the flags being the defaults
+kubebuilder init --interactive --domain example.org
+
+# Scaffold a project in the operators/guestbook directory of a monorepo: its
+# repo defaults to the package of the directory in the module enclosing it, and
+# its module is added to the go.work workspace enclosing it, if any
+cd operators/guestbook && kubebuilder init --domain example.org
+
+# Scaffold a project and commit it, initializing the git repository if needed
+kubebuilder init --domain example.org --git-commit
 `,
 		Run: func(cmd *cobra.Command, args []string) {
-			o.initializeProject()
+			o.output.run(o.initializeProject)
 		},
 	}
 
 	o.bindCmdlineFlags(initCmd)
+	o.output.bindCmdFlags(initCmd)
+	bindGitCommitFlag(initCmd)
+	bindPluginFlag(initCmd.Flags())
 
 	return initCmd
 }
@@ -71,6 +120,17 @@ type projectOptions struct {
 	// flags
 	fetchDeps          bool
 	skipGoVersionCheck bool
+	heartbeat          bool
+	settings           bool
+	capabilities       bool
+	controllerUAs      bool
+	remoteCluster      bool
+	reconcileTimeout   bool
+	readOnly           bool
+	dualStack          bool
+	interactive        bool
+	goWork             bool
+	output             outputOptions
 
 	boilerplate project.Boilerplate
 	project project.Project
@@ -86,6 +146,8 @@ type projectOptions struct {
 
 func (o *projectOptions) bindCmdlineFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&o.skipGoVersionCheck, "skip-go-version-check", false, "if specified, skip checking the Go version")
+	cmd.Flags().BoolVar(&o.interactive, "interactive", false,
+		"if true, prompt for the domain, repo, license and project version, defaulting to the values of their flags")
 
 	// dependency args
 	cmd.Flags().BoolVar(&o.fetchDeps, "fetch-deps", true, "ensure dependencies are downloaded")
@@ -97,8 +159,31 @@ func (o *projectOptions) bindCmdlineFlags(cmd *cobra.Command) {
 	cmd.Flags().MarkDeprecated("dep", "use the fetch-deps flag instead")
 	cmd.Flags().MarkDeprecated("depArgs", "will be removed with version 1 scaffolding")
 
+	// optional components
+	cmd.Flags().BoolVar(&o.heartbeat, "heartbeat", false,
+		"if true, scaffold a heartbeat reporting the operator health to a ConfigMap (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.settings, "settings", false,
+		"if true, scaffold a watch of a settings ConfigMap reconfiguring the controllers without restarts (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.capabilities, "capabilities", false,
+		"if true, scaffold the detection of the optional APIs served by the cluster (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.controllerUAs, "controller-user-agents", false,
+		"if true, scaffold a client of its own user agent for each controller (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.remoteCluster, "remote-cluster", false,
+		"if true, scaffold the watch of a remote cluster read from a kubeconfig Secret (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.reconcileTimeout, "reconcile-timeout", false,
+		"if true, scaffold a --reconcile-timeout flag canceling the context of the reconciles running longer (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.readOnly, "read-only", false,
+		"if true, scaffold a --read-only flag under which the controllers only write the status of the objects (only used with project version 2)")
+	cmd.Flags().BoolVar(&o.dualStack, "dual-stack", false,
+		"if true, scaffold the Services with the PreferDualStack ipFamilyPolicy (only used with project version 2)")
+
+	cmd.Flags().BoolVar(&o.goWork, "go-work", true,
+		"if true, add the project to the go.work workspace enclosing the current directory, if any (only used with project version 2)")
+
 	// boilerplate args
-	cmd.Flags().StringVar(&o.boilerplate.Path, "path", "", "path for boilerplate")
+	cmd.Flags().StringVar(&o.boilerplate.Path, "path", "",
+		"path for boilerplate, relative to the project root (defaults to hack/boilerplate.go.txt). "+
+			"It is recorded in PROJECT for the next commands and the Makefile code generators (only used with project version 2)")
 	cmd.Flags().StringVar(&o.boilerplate.License, "license", "apache2", "license to use to boilerplate.  May be one of apache2,none")
 	cmd.Flags().StringVar(&o.boilerplate.Owner, "owner", "", "Owner to add to the copyright")
 
@@ -106,33 +191,69 @@ func (o *projectOptions) bindCmdlineFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.project.Repo, "repo", util.Repo, "name of the github repo.  "+
 		"defaults to the go package of the current working directory.")
 	cmd.Flags().StringVar(&o.project.Domain, "domain", "k8s.io", "domain for groups")
-	cmd.Flags().StringVar(&o.project.Version, "project-version", project.Version2, "project version")
+	cmd.Flags().StringVar(&o.project.ProjectName, "project-name", "",
+		"name of the project, prefixing the names of its resources. defaults to the name of the current directory.")
+	cmd.Flags().StringVar(&o.project.NamePrefix, "name-prefix", "",
+		"prefix of the names of the project resources. defaults to the project name followed by '-' (only used with project version 2)")
+	cmd.Flags().StringVar(&o.project.NameSuffix, "name-suffix", "",
+		"suffix of the names of the project resources (only used with project version 2)")
+	cmd.Flags().StringVar(&o.project.Version, "project-version", project.Version2,
+		"project version. Version 3 is scaffolded as version 2, its PROJECT file also tracking the "+
+			"API types, controller and webhooks of each resource")
+	cmd.Flags().StringVar(&o.project.SchemeRegistration, "scheme-registration", "",
+		"strategy used to register API types with the manager's scheme. May be one of "+
+			project.SchemeRegistrationMain+","+project.SchemeRegistrationRegistry+" (only used with project version 2)")
 }
 
 func (o *projectOptions) initializeProject() {
+	if o.interactive && !o.promptProject(bufio.NewReader(os.Stdin)) {
+		fail(errorKindError, exitError, fmt.Errorf("project initialization aborted"))
+	}
+
+	if err := o.preflight(); err != nil {
+		failPreflight(err)
+	}
+
 	if err := o.validate(); err != nil {
-		log.Fatal(err)
+		failInvalidFlags(err)
 	}
 
 	if err := o.scaffolder.Scaffold(); err != nil {
-		log.Fatalf("error scaffolding project: %v", err)
+		failScaffold(fmt.Errorf("error scaffolding project: %v", err))
+	}
+
+	if err := o.addToWorkspace(); err != nil {
+		failHook(err)
 	}
 
 	if err := o.postScaffold(); err != nil {
-		log.Fatal(err)
+		failHook(err)
 	}
 
 	fmt.Printf("Next: Define a resource with:\n" +
 		"$ kubebuilder create api\n")
 }
 
-func (o *projectOptions) validate() error {
+// preflight checks the environment the project is initialized in.
+func (o *projectOptions) preflight() error {
 	if !o.skipGoVersionCheck {
 		if err := validateGoVersion(); err != nil {
 			return err
 		}
 	}
 
+	if util.ProjectExist() {
+		return fmt.Errorf("Failed to initialize project because project is already initialized")
+	}
+
+	return nil
+}
+
+func (o *projectOptions) validate() error {
+	if o.project.Repo == "" && repoErr != nil {
+		return fmt.Errorf("error finding current repository: %v, set it with --repo", repoErr)
+	}
+
 	switch o.project.Version {
 	case project.Version1:
 		var defEnsure *bool
@@ -146,24 +267,25 @@ func (o *projectOptions) validate() error {
 			DepArgs: o.depArgs,
 			DefinitelyEnsure: defEnsure,
 		}
-	case project.Version2:
+	case project.Version2, project.Version3:
 		o.scaffolder = &scaffold.V2Project{
-			Project: o.project,
+			Project:     o.project,
 			Boilerplate: o.boilerplate,
+			Heartbeat:   o.heartbeat,
+			Settings:    o.settings,
+
+			Capabilities:         o.capabilities,
+			ControllerUserAgents: o.controllerUAs,
+			RemoteCluster:        o.remoteCluster,
+			ReconcileTimeout:     o.reconcileTimeout,
+			ReadOnly:             o.readOnly,
+			DualStack:            o.dualStack,
 		}
 	default:
 		return fmt.Errorf("unknown project version %v", o.project.Version)
 	}
 
-	if err := o.scaffolder.Validate(); err != nil {
-		return err
-	}
-
-	if util.ProjectExist() {
-		return fmt.Errorf("Failed to initialize project because project is already initialized")
-	}
-
-	return nil
+	return o.scaffolder.Validate()
 }
 
 func validateGoVersion() error {
@@ -216,6 +338,21 @@ func checkGoVersion(verStr string) error {
 	return nil
 }
 
+// addToWorkspace adds the module of a version 2 project to the go.work
+// workspace enclosing it, e.g. the one of a monorepo holding several operators,
+// before its dependencies are fetched.
+func (o *projectOptions) addToWorkspace() error {
+	if !o.goWork || o.project.Version == project.Version1 {
+		return nil
+	}
+	goWork, err := findGoWork()
+	if err != nil || goWork == "" {
+		return err
+	}
+	fmt.Printf("Adding the project to the workspace %s\n", goWork)
+	return addToGoWork(goWork)
+}
+
 func (o *projectOptions) postScaffold() error {
 	// preserve old "ask if not explicitly set" behavior for the `--dep` flag
 	// (asking is handled by the v1 scaffolder)
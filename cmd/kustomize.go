@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/kustomizelint"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+)
+
+// defaultKustomization is the kustomization deployed by make deploy.
+var defaultKustomization = filepath.Join("config", "default")
+
+// pendingFiles are the files of the kustomizations which are missing until
+// the first API is created, or make manifests generates them.
+var pendingFiles = []string{
+	// project version 2
+	filepath.Join("config", "crd"),
+	filepath.Join("config", "crd", "bases", "*"),
+	filepath.Join("config", "rbac", "role.yaml"),
+	filepath.Join("config", "webhook", "manifests.yaml"),
+	// project version 1
+	filepath.Join("config", "crds", "*"),
+	filepath.Join("config", "rbac", "rbac_role.yaml"),
+	filepath.Join("config", "webhook", "webhook.yaml"),
+}
+
+// verifyKustomize checks that config/default still builds once a command
+// created or modified files of config, failing the command with the errors
+// kustomize build would report at make deploy time.
+func verifyKustomize() {
+	if _, err := os.Stat(defaultKustomization); err != nil {
+		return
+	}
+	r := result.Get()
+	touched := false
+	for _, path := range append(r.FilesCreated, r.FilesModified...) {
+		if strings.HasPrefix(filepath.ToSlash(filepath.Clean(path)), "config/") {
+			touched = true
+		}
+	}
+	if !touched {
+		return
+	}
+
+	diagnostics, err := kustomizelint.Lint(defaultKustomization, pendingFiles...)
+	if err != nil {
+		failHook(fmt.Errorf("error checking %s: %v", defaultKustomization, err))
+	}
+	for _, d := range diagnostics {
+		log.Print(d)
+	}
+	if len(diagnostics) > 0 {
+		failHook(fmt.Errorf("%s does not build with kustomize, fix the errors above before running make deploy",
+			defaultKustomization))
+	}
+}
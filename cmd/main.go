@@ -19,9 +19,11 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/tools/go/packages"
@@ -29,6 +31,7 @@ import (
 	"sigs.k8s.io/kubebuilder/cmd/util"
 	"sigs.k8s.io/kubebuilder/cmd/version"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
 )
 
 // module and goMod arg just enough of the output of `go mod edit -json` for our purposes
@@ -39,6 +42,9 @@ type module struct {
 	Path string
 }
 
+// repoErr is the error finding the current repository, if any.
+var repoErr error
+
 // findGoModulePath finds the path of the current module, if present.
 func findGoModulePath(forceModules bool) (string, error) {
 	cmd := exec.Command("go", "mod", "edit", "-json")
@@ -60,6 +66,39 @@ func findGoModulePath(forceModules bool) (string, error) {
 	return mod.Module.Path, nil
 }
 
+// packageInModule returns the path of the package of the current directory in
+// the module of the given path, the module path itself unless the current
+// directory is a subdirectory of the module, e.g. of a monorepo.
+func packageInModule(modulePath string) (string, error) {
+	out, err := exec.Command("go", "env", "GOMOD").Output()
+	if err != nil {
+		return "", err
+	}
+	goMod := strings.TrimSpace(string(out))
+	if goMod == "" || goMod == os.DevNull {
+		return modulePath, nil
+	}
+	moduleDir, err := filepath.EvalSymlinks(filepath.Dir(goMod))
+	if err != nil {
+		return "", err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if wd, err = filepath.EvalSymlinks(wd); err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(moduleDir, wd)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return modulePath, nil
+	}
+	return path.Join(modulePath, filepath.ToSlash(rel)), nil
+}
+
 // findCurrentRepo attempts to determine the current repository
 // though a combination of go/packages and `go mod` commands/tricks.
 func findCurrentRepo() (string, error) {
@@ -69,10 +108,11 @@ func findCurrentRepo() (string, error) {
 		return projFile.Repo, nil
 	}
 
-	// next easy case: existing go module
-	path, err := findGoModulePath(false)
+	// next easy case: existing go module, possibly enclosing the current
+	// directory
+	modulePath, err := findGoModulePath(false)
 	if err == nil {
-		return path, nil
+		return packageInModule(modulePath)
 	}
 
 	// next, check if we've got a package in the current directory
@@ -90,7 +130,7 @@ func findCurrentRepo() (string, error) {
 	cmd.Env = append(cmd.Env, "GO111MODULE=on" /* turn on modules just for these commands */)
 	if _, err := cmd.Output(); err != nil {
 		if exitErr, isExitErr := err.(*exec.ExitError); isExitErr {
-			err = fmt.Errorf("%s", string(exitErr.Stderr))
+			err = fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
 		}
 		// give up, let the user figure it out
 		return "", fmt.Errorf("could not determine repository path from module data, package data, or by initializing a module: %v", err)
@@ -100,26 +140,33 @@ func findCurrentRepo() (string, error) {
 }
 
 func main() {
-	repoPath, err := findCurrentRepo()
-	if err != nil {
-		log.Fatal(fmt.Errorf("error finding current repository: %v", err))
-	}
+	dispatchPlugin(os.Args[1:])
+
+	// the repository is only needed by init, which reports the error unless
+	// it is set with --repo
+	util.Repo, repoErr = findCurrentRepo()
 
-	util.Repo = repoPath
+	// the files written by a failing scaffold are rolled back by failScaffold
+	rollback.Begin()
 
 	rootCmd := defaultCommand()
 
 	rootCmd.AddCommand(
 		newInitProjectCmd(),
-		newAPICommand(),
+		newCreateCmd(),
+		newEditCmd(),
 		version.NewVersionCmd(),
 		newDocsCmd(),
 		newVendorUpdateCmd(),
 		newAlphaCommand(),
+		newHistoryCmd(),
+		newDoctorCmd(),
 	)
+	addPluginCommands(rootCmd)
 
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		// the errors of cobra are the unknown commands and invalid flags
+		failInvalidFlags(err)
 	}
 }
 
@@ -148,6 +195,24 @@ scaffold a Controller for an existing Resource, select "n" for Resource. To only
 the schema for a Resource without writing a Controller, select "n" for Controller.
 
 After the scaffold is written, api will run make on the project.
+
+The commands modifying config check that config/default still builds with
+kustomize, reporting the broken overlays before make deploy.
+
+The commands exit with a distinct code per kind of failure, also reported in
+the error of the --output json and yaml results:
+
+  1  other errors
+  2  invalid flags or arguments
+  3  preflight check failed, nothing was written (e.g. no PROJECT file)
+  4  partial scaffold which could not be rolled back, the files already
+     written are listed to revert them
+  5  post-scaffold hook failed (e.g. make, go mod or git commit)
+
+External tools scaffold their own project flavors as plugins, compiled in or
+found as kubebuilder-<name> executables in the PATH: init --plugin <name> and
+create --plugin <name> run the plugin executable with init or create and the
+other arguments, and kubebuilder <name> runs it with the arguments.
 `,
 		Example: `
 	# Initialize your project
@@ -170,10 +235,30 @@ After the scaffold is written, api will run make on the project.
 
 	# Regenerate code and run against the Kubernetes cluster configured by ~/.kube/config
 	make run
+
+	# Print the commands which scaffolded the project
+	kubebuilder history
+
+	# Check the project for the drift from its scaffolding
+	kubebuilder doctor
 `,
 
 		Run: func(cmd *cobra.Command, args []string) {
 			cmd.Help()
 		},
+		PersistentPreRun: checkGitWorkTree,
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			// record the commands scaffolding the project in its history,
+			// before committing it along with the scaffolded files once
+			// config/default is known to build
+			recordHistory(cmd, args)
+			verifyKustomize()
+			gitCommit(cmd, args)
+			// the result is printed once the hooks succeeded, their failures
+			// being reported in it otherwise
+			if reportResult != nil {
+				reportResult()
+			}
+		},
 	}
 }
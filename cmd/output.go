@@ -0,0 +1,115 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+)
+
+// constants for output formats
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// outputOptions selects how a command reports what it scaffolded.
+type outputOptions struct {
+	format string
+}
+
+func (o *outputOptions) bindCmdFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.format, "output", "o", outputText,
+		fmt.Sprintf("output format, one of %s, %s, %s. The %s and %s formats print the files created and "+
+			"modified, the markers injected and the warnings of the command instead of its progress",
+			outputText, outputJSON, outputYAML, outputJSON, outputYAML))
+}
+
+// reportResult prints the result of the command in the machine-readable
+// format of its --output flag, nil with the text format. It is called once
+// the post-run hooks of the command ran, for their failures to be reported in
+// the result too.
+var reportResult func()
+
+// run runs the command f and reports its result in the selected format. With
+// a machine-readable format, everything f and the post-run hooks print to
+// stdout, including the output of make, is discarded and only the result is
+// printed by reportResult once the hooks ran.
+func (o *outputOptions) run(f func()) {
+	var marshal func(interface{}) ([]byte, error)
+	switch o.format {
+	case outputText:
+		f()
+		return
+	case outputJSON:
+		marshal = func(v interface{}) ([]byte, error) {
+			out := &bytes.Buffer{}
+			enc := json.NewEncoder(out)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			err := enc.Encode(v)
+			return bytes.TrimSpace(out.Bytes()), err
+		}
+	case outputYAML:
+		marshal = yaml.Marshal
+	default:
+		failInvalidFlags(fmt.Errorf("unknown output format %q, must be one of %s, %s, %s",
+			o.format, outputText, outputJSON, outputYAML))
+	}
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	result.Reset()
+	// the failures of f are reported in the format of the flag too
+	failureReport = func(e *result.Error) {
+		os.Stdout = stdout
+		failureReport = nil
+		r := result.Get()
+		r.Error = e
+		if out, err := marshal(r); err == nil {
+			fmt.Println(string(out))
+		}
+	}
+	f()
+	reportResult = func() {
+		reportResult = nil
+		failureReport = nil
+		os.Stdout = stdout
+		if err := devNull.Close(); err != nil {
+			log.Fatal(err)
+		}
+
+		out, err := marshal(result.Get())
+		if err != nil {
+			log.Fatalf("error marshalling the result: %v", err)
+		}
+		fmt.Println(string(out))
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	"sigs.k8s.io/kubebuilder/pkg/plugin"
+)
+
+const pluginFlag = "plugin"
+
+// bindPluginFlag adds the flag selecting the plugin scaffolding the project.
+// The flag is handled by dispatchPlugin, before the flags of the command are
+// parsed, so that the plugins can have their own flags.
+func bindPluginFlag(flags *flag.FlagSet) {
+	flags.String(pluginFlag, "",
+		"name of the plugin to run the command with, registered or found as "+plugin.BinaryPrefix+
+			"<name> in the PATH, instead of the built in scaffolding. See kubebuilder --help for the available plugins")
+}
+
+// dispatchPlugin runs the init or create command of args with the plugin of
+// its --plugin flag and exits with the exit code of the plugin. It returns if
+// args do not select a plugin.
+func dispatchPlugin(args []string) {
+	if len(args) == 0 || (args[0] != "init" && args[0] != "create") {
+		return
+	}
+	name, rest, found := pluginArg(args[1:])
+	if !found {
+		return
+	}
+	if name == "" {
+		failInvalidFlags(fmt.Errorf("--%s must name a plugin", pluginFlag))
+	}
+	p, err := plugin.Get(os.Getenv("PATH"), name)
+	if err != nil {
+		failInvalidFlags(err)
+	}
+	if args[0] == "init" {
+		err = p.Init(rest)
+	} else {
+		err = p.Create(rest)
+	}
+	exitPlugin(name, err)
+}
+
+// pluginArg returns the value of the --plugin flag of args, and args without
+// the flag. The arguments following -- are not flags.
+func pluginArg(args []string) (string, []string, bool) {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return "", args, false
+		case arg == "--"+pluginFlag:
+			if i+1 == len(args) {
+				return "", args, true
+			}
+			return args[i+1], append(append([]string{}, args[:i]...), args[i+2:]...), true
+		case strings.HasPrefix(arg, "--"+pluginFlag+"="):
+			return strings.TrimPrefix(arg, "--"+pluginFlag+"="),
+				append(append([]string{}, args[:i]...), args[i+1:]...), true
+		}
+	}
+	return "", args, false
+}
+
+// exitPlugin exits with the exit code of the plugin failing with err, or 0.
+func exitPlugin(name string, err error) {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		fail(errorKindError, exitError, fmt.Errorf("plugin %s: %v", name, err))
+	}
+	os.Exit(0)
+}
+
+// addPluginCommands adds the subcommands of the plugins to root, and lists the
+// plugins in its help. The plugins do not override the built in commands.
+func addPluginCommands(root *cobra.Command) {
+	plugins := plugin.List(os.Getenv("PATH"))
+	if len(plugins) == 0 {
+		return
+	}
+
+	root.Long += "\nAvailable plugins, selected with init --plugin <name> and create --plugin <name>:\n\n"
+	for _, p := range plugins {
+		root.Long += fmt.Sprintf("  %-20s %s\n", p.Name(), p.Description())
+
+		c, ok := p.(plugin.Commander)
+		if !ok {
+			continue
+		}
+		if existing, _, err := root.Find([]string{p.Name()}); err == nil && existing != root {
+			continue
+		}
+		name := p.Name()
+		root.AddCommand(&cobra.Command{
+			Use:                name,
+			Short:              fmt.Sprintf("Run the %s plugin", name),
+			DisableFlagParsing: true,
+			Run: func(cmd *cobra.Command, args []string) {
+				exitPlugin(name, c.Run(args))
+			},
+		})
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPluginArg(t *testing.T) {
+	tests := []struct {
+		args  []string
+		name  string
+		rest  []string
+		found bool
+	}{
+		{[]string{"--domain", "x.com"}, "", []string{"--domain", "x.com"}, false},
+		{[]string{"--plugin", "foo", "--domain", "x.com"}, "foo", []string{"--domain", "x.com"}, true},
+		{[]string{"api", "--plugin=foo", "--kind", "X"}, "foo", []string{"api", "--kind", "X"}, true},
+		{[]string{"--plugin"}, "", []string{"--plugin"}, true},
+		{[]string{"--", "--plugin", "foo"}, "", []string{"--", "--plugin", "foo"}, false},
+	}
+
+	for _, test := range tests {
+		name, rest, found := pluginArg(test.args)
+		if name != test.name || !reflect.DeepEqual(rest, test.rest) || found != test.found {
+			t.Errorf("pluginArg(%q) = %q, %q, %v, want %q, %q, %v",
+				test.args, name, rest, found, test.name, test.rest, test.found)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+)
+
+type samplesOptions struct {
+	samplesScaffolder scaffold.Samples
+
+	output outputOptions
+}
+
+func (o *samplesOptions) runSamples() {
+	dieIfNoProject()
+
+	if err := o.samplesScaffolder.Validate(); err != nil {
+		failInvalidFlags(err)
+	}
+
+	if err := o.samplesScaffolder.Scaffold(); err != nil {
+		failScaffold(err)
+	}
+}
+
+func newSamplesCmd() *cobra.Command {
+	options := samplesOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "samples",
+		Short: "Generate the samples of config/samples from the example markers of the API types",
+		Long: `Generate the samples of config/samples from the +kubebuilder:example markers
+of the API types, run by make samples.
+
+The value of a marker, parsed as YAML, is the value of the field it precedes in
+the sample. The fields whose type is a struct of the API package without a
+marker are filled in from the markers of their own fields, the lists with a
+single element. The samples of the resources whose spec has no example marker
+are left untouched, the others are overwritten.
+
+This command is only available for v2 scaffolding project.
+`,
+		Example: `	# With the fields below in the spec of FirstMate
+	#
+	#	// +kubebuilder:example=nginx:1.17
+	#	Image string ` + "`" + `json:"image"` + "`" + `
+	#	// +kubebuilder:example=3
+	#	Replicas *int32 ` + "`" + `json:"replicas,omitempty"` + "`" + `
+	#
+	# generate config/samples/crew_v1_firstmate.yaml with the spec
+	# {image: nginx:1.17, replicas: 3}
+	kubebuilder alpha samples
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.output.run(options.runSamples)
+		},
+	}
+
+	options.output.bindCmdFlags(cmd)
+
+	return cmd
+}
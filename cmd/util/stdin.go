@@ -48,3 +48,25 @@ func readstdin(reader *bufio.Reader) string {
 	}
 	return strings.TrimSpace(text)
 }
+
+// Prompt prints the question with its default value and reads answers from
+// stdin until validate accepts one, returning the default value for an empty
+// answer. log.Fatal's if there is an error.
+func Prompt(reader *bufio.Reader, question, defaultValue string, validate func(string) error) string {
+	for {
+		if defaultValue != "" {
+			fmt.Printf("%s [%s]: ", question, defaultValue)
+		} else {
+			fmt.Printf("%s: ", question)
+		}
+		text := readstdin(reader)
+		if text == "" {
+			text = defaultValue
+		}
+		if err := validate(text); err != nil {
+			fmt.Printf("invalid input %q: %v\n", text, err)
+			continue
+		}
+		return text
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kubebuilder/pkg/rbaclint"
+)
+
+type verifyRBACOptions struct {
+	patterns []string
+}
+
+func (o *verifyRBACOptions) runVerifyRBAC() {
+	diagnostics, err := rbaclint.Lint(".", o.patterns...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range diagnostics {
+		fmt.Println(d)
+	}
+	if len(diagnostics) > 0 {
+		os.Exit(1)
+	}
+}
+
+func newVerifyRBACCmd() *cobra.Command {
+	options := verifyRBACOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "verify-rbac [packages]",
+		Short: "Check the RBAC markers of the packages against the resources their code uses",
+		Long: `Check the +kubebuilder:rbac markers of the packages against the resources their
+code reads and writes, run by make verify-rbac.
+
+A marker granting a resource the code of its package never uses is reported,
+as well as the resources used without a marker granting the verb they need.
+The resources are recognized from the types of the objects passed to the
+client, the controller builder and the event recorder. Unstructured objects,
+objects typed as interfaces, and the subresources other than status, are not
+checked.
+
+The check is also available as the go/analysis analyzer of the
+sigs.k8s.io/kubebuilder/pkg/rbaclint package, to run it with other linters.
+`,
+		Example: `	# Check the RBAC markers of the controllers
+	kubebuilder alpha verify-rbac ./controllers/...
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.patterns = args
+			if len(options.patterns) == 0 {
+				options.patterns = []string{"./..."}
+			}
+			options.runVerifyRBAC()
+		},
+	}
+
+	return cmd
+}
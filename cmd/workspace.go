@@ -0,0 +1,69 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
+)
+
+// findGoWork returns the path of the go.work file of the workspace enclosing
+// the current directory, or "" if there is none. Like the go command, it
+// honors the GOWORK environment variable.
+func findGoWork() (string, error) {
+	switch goWork := os.Getenv("GOWORK"); goWork {
+	case "off":
+		return "", nil
+	case "":
+	default:
+		return goWork, nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		goWork := filepath.Join(dir, "go.work")
+		if _, err := os.Stat(goWork); err == nil {
+			return goWork, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// addToGoWork adds the module of the current directory to the workspace of
+// the go.work file at goWork, so that the go commands run in the directory
+// build it along with the other modules of the workspace.
+func addToGoWork(goWork string) error {
+	if err := rollback.Save(goWork); err != nil {
+		return err
+	}
+	cmd := exec.Command("go", "work", "use", ".")
+	cmd.Env = append(os.Environ(), "GOWORK="+goWork)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error adding the project to the workspace %s: %v: %s", goWork, err, out)
+	}
+	return nil
+}
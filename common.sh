@@ -157,7 +157,10 @@ function setup_envs {
   export TEST_ASSET_KUBE_APISERVER=$tmp_root/kubebuilder/bin/kube-apiserver
   export TEST_ASSET_ETCD=$tmp_root/kubebuilder/bin/etcd
   export TEST_DEP=$tmp_root/kubebuilder/init_project
-  export KUBECONFIG="$(kind get kubeconfig-path --name="kind")"
+  # the other cluster providers of the e2e suite use the current kubeconfig
+  if [ "${KB_E2E_CLUSTER_PROVIDER:-kind}" == "kind" ]; then
+    export KUBECONFIG="$(kind get kubeconfig-path --name="${KB_E2E_CLUSTER_NAME:-kind}")"
+  fi
 }
 
 function restore_go_deps {
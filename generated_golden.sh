@@ -70,10 +70,11 @@ scaffold_test_project() {
 		$kb create api --group core --version v1 --kind Namespace --example=false --controller=true --resource=false --namespaced=false --make=false
 		# $kb create api --group policy --version v1beta1 --kind HealthCheckPolicy --example=false --controller=true --resource=true --namespaced=false --make=false
 	fi
-	make all test # v2 doesn't test by default
+	make all test KUBEBUILDER=$kb # v2 doesn't test by default
 	rm -f Gopkg.lock
 	rm -rf ./vendor
 	rm -rf ./bin
+	rm -rf ./.kubebuilder # the history records when each command ran
 	export GOPATH=$oldgopath
 	popd
 }
@@ -0,0 +1,226 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apidocs generates the OpenAPI v3 document of the CRDs of a project,
+// so that the consumers of its APIs browse their schemas and endpoints,
+// e.g. with Swagger UI, without access to a cluster serving them.
+//
+// Each version of a CRD is a schema of the document, named
+// <group>.<version>.<Kind> after its openAPIV3Schema, along with the paths of
+// the REST endpoints the API server serves for it: the collection and the
+// objects of the resource, and its status subresource when enabled.
+package apidocs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/kubebuilder/pkg/internal/mapslice"
+)
+
+// crd holds the fields of a v1beta1 CRD the document is made of.
+type crd struct {
+	Kind string `yaml:"kind"`
+	Spec struct {
+		Group string `yaml:"group"`
+		Names struct {
+			Kind   string `yaml:"kind"`
+			Plural string `yaml:"plural"`
+		} `yaml:"names"`
+		Scope        string        `yaml:"scope"`
+		Version      string        `yaml:"version"`
+		Validation   *validation   `yaml:"validation"`
+		Subresources *subresources `yaml:"subresources"`
+		Versions     []struct {
+			Name         string        `yaml:"name"`
+			Served       bool          `yaml:"served"`
+			Schema       *validation   `yaml:"schema"`
+			Subresources *subresources `yaml:"subresources"`
+		} `yaml:"versions"`
+	} `yaml:"spec"`
+}
+
+type validation struct {
+	OpenAPIV3Schema interface{} `yaml:"openAPIV3Schema"`
+}
+
+type subresources struct {
+	Status interface{} `yaml:"status"`
+}
+
+// version is a served version of a CRD.
+type version struct {
+	group, name, kind, plural string
+	namespaced, status        bool
+	schema                    interface{}
+}
+
+// Generate returns the OpenAPI v3 document, in JSON, of the CRDs of the
+// manifests, which may hold several YAML documents each.
+func Generate(title string, manifests ...[]byte) ([]byte, error) {
+	var versions []version
+	for _, m := range manifests {
+		dec := yaml.NewDecoder(bytes.NewReader(m))
+		for {
+			var c crd
+			if err := dec.Decode(&c); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("error parsing the CRDs: %v", err)
+			}
+			if c.Kind != "CustomResourceDefinition" {
+				continue
+			}
+			versions = append(versions, crdVersions(c)...)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return schemaName(versions[i]) < schemaName(versions[j])
+	})
+
+	schemas := map[string]interface{}{}
+	paths := map[string]interface{}{}
+	for _, v := range versions {
+		name := schemaName(v)
+		schemas[name] = map[string]interface{}{"type": "object"}
+		if v.schema != nil {
+			schemas[name] = mapslice.JSONValue(v.schema)
+		}
+
+		collection := fmt.Sprintf("/apis/%s/%s/%s", v.group, v.name, v.plural)
+		if v.namespaced {
+			collection = fmt.Sprintf("/apis/%s/%s/namespaces/{namespace}/%s", v.group, v.name, v.plural)
+		}
+		object := collection + "/{name}"
+		paths[collection] = map[string]interface{}{
+			"parameters": parameters(v.namespaced, false),
+			"get":        operation(v, "list", "List the "+v.plural, false, false),
+			"post":       operation(v, "create", "Create a "+v.kind, true, true),
+		}
+		paths[object] = map[string]interface{}{
+			"parameters": parameters(v.namespaced, true),
+			"get":        operation(v, "read", "Read a "+v.kind, false, true),
+			"put":        operation(v, "replace", "Replace a "+v.kind, true, true),
+			"delete":     operation(v, "delete", "Delete a "+v.kind, false, false),
+		}
+		if v.status {
+			paths[object+"/status"] = map[string]interface{}{
+				"parameters": parameters(v.namespaced, true),
+				"get":        operation(v, "readStatus", "Read the status of a "+v.kind, false, true),
+				"put":        operation(v, "replaceStatus", "Replace the status of a "+v.kind, true, true),
+			}
+		}
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.0",
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": "unversioned",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// crdVersions returns the served versions of the CRD, the schema and the
+// subresources of each defaulting to the ones of the CRD.
+func crdVersions(c crd) []version {
+	v := version{
+		group:      c.Spec.Group,
+		kind:       c.Spec.Names.Kind,
+		plural:     c.Spec.Names.Plural,
+		namespaced: c.Spec.Scope != "Cluster",
+		status:     c.Spec.Subresources != nil && c.Spec.Subresources.Status != nil,
+	}
+	if c.Spec.Validation != nil {
+		v.schema = c.Spec.Validation.OpenAPIV3Schema
+	}
+	if len(c.Spec.Versions) == 0 {
+		v.name = c.Spec.Version
+		return []version{v}
+	}
+	var versions []version
+	for _, cv := range c.Spec.Versions {
+		if !cv.Served {
+			continue
+		}
+		served := v
+		served.name = cv.Name
+		if cv.Schema != nil {
+			served.schema = cv.Schema.OpenAPIV3Schema
+		}
+		if cv.Subresources != nil {
+			served.status = cv.Subresources.Status != nil
+		}
+		versions = append(versions, served)
+	}
+	return versions
+}
+
+func schemaName(v version) string {
+	return fmt.Sprintf("%s.%s.%s", v.group, v.name, v.kind)
+}
+
+func parameters(namespaced, named bool) []interface{} {
+	var params []interface{}
+	if namespaced {
+		params = append(params, pathParameter("namespace", "The namespace of the objects"))
+	}
+	if named {
+		params = append(params, pathParameter("name", "The name of the object"))
+	}
+	return params
+}
+
+func pathParameter(name, description string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        name,
+		"in":          "path",
+		"required":    true,
+		"description": description,
+		"schema":      map[string]interface{}{"type": "string"},
+	}
+}
+
+// operation returns an operation on the objects of the version, taking an
+// object as body and returning one as response when set.
+func operation(v version, verb, summary string, body, returns bool) map[string]interface{} {
+	content := map[string]interface{}{
+		"application/json": map[string]interface{}{
+			"schema": map[string]interface{}{"$ref": "#/components/schemas/" + schemaName(v)},
+		},
+	}
+	ok := map[string]interface{}{"description": "OK"}
+	if returns {
+		ok["content"] = content
+	}
+	op := map[string]interface{}{
+		"operationId": fmt.Sprintf("%s%s%s", verb, v.kind, v.name),
+		"summary":     summary,
+		"tags":        []string{fmt.Sprintf("%s/%s", v.group, v.name)},
+		"responses":   map[string]interface{}{"200": ok},
+	}
+	if body {
+		op["requestBody"] = map[string]interface{}{"required": true, "content": content}
+	}
+	return op
+}
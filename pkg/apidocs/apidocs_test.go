@@ -0,0 +1,122 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apidocs_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/apidocs"
+)
+
+const (
+	captains = `
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: captains.crew.example.com
+spec:
+  group: crew.example.com
+  names:
+    kind: Captain
+    plural: captains
+  scope: ""
+  subresources:
+    status: {}
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+  versions:
+  - name: v1
+    served: true
+    storage: true
+  - name: v1beta1
+    served: false
+    storage: false
+`
+	admirals = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: admirals.fleet.example.com
+spec:
+  group: fleet.example.com
+  names:
+    kind: Admiral
+    plural: admirals
+  scope: Cluster
+  version: v2
+`
+)
+
+func TestGenerate(t *testing.T) {
+	content, err := apidocs.Generate("proj API", []byte(captains), []byte(admirals))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title string `json:"title"`
+		} `json:"info"`
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.0.0" || doc.Info.Title != "proj API" {
+		t.Errorf("got openapi %q and title %q", doc.OpenAPI, doc.Info.Title)
+	}
+
+	wantSchemas := map[string]interface{}{
+		"crew.example.com.v1.Captain": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"spec": map[string]interface{}{"type": "object"}},
+		},
+		"fleet.example.com.v2.Admiral": map[string]interface{}{"type": "object"},
+	}
+	if !reflect.DeepEqual(doc.Components.Schemas, wantSchemas) {
+		t.Errorf("got schemas %v, want %v", doc.Components.Schemas, wantSchemas)
+	}
+
+	var paths []string
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	wantPaths := []string{
+		"/apis/crew.example.com/v1/namespaces/{namespace}/captains",
+		"/apis/crew.example.com/v1/namespaces/{namespace}/captains/{name}",
+		"/apis/crew.example.com/v1/namespaces/{namespace}/captains/{name}/status",
+		"/apis/fleet.example.com/v2/admirals",
+		"/apis/fleet.example.com/v2/admirals/{name}",
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("got paths %v, want %v", paths, wantPaths)
+	}
+	if _, found := doc.Paths["/apis/fleet.example.com/v2/admirals"]["post"]; !found {
+		t.Errorf("the Admirals cannot be created: %v", doc.Paths["/apis/fleet.example.com/v2/admirals"])
+	}
+}
@@ -0,0 +1,394 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package configgen generates the manifests deploying a project, the CRDs,
+// the RBAC, the manager Deployment and the webhooks, from its config
+// directory without kustomize, so that a project can be deployed with
+// kubectl alone.
+//
+// The kustomizations are built the way kustomize build does, for the fields
+// of kustomization.yaml the scaffolded projects rely on: the resources, bases
+// and components, the strategic merge patches, the images, the namespace, the
+// name prefix and the vars. The name references known to the scaffolded
+// kustomizeconfig.yaml files, e.g. the service of a webhook or the issuer of
+// a certificate, follow the names and the namespaces given to the objects.
+// The other fields are refused rather than ignored, build such a
+// kustomization with kustomize.
+//
+// The CRDs, the role and the webhook configurations are read from the files
+// make manifests generates, they are not generated from the code.
+package configgen
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/kubebuilder/pkg/internal/kustomization"
+	"sigs.k8s.io/kubebuilder/pkg/internal/mapslice"
+)
+
+// ManagerContainer is the name of the container of the manager in the
+// Deployment of the project
+const ManagerContainer = "manager"
+
+// Options customize the generated manifests.
+type Options struct {
+	// Namespace replaces the namespace of the top kustomization when set
+	Namespace string
+
+	// Image replaces the image of the manager container when set
+	Image string
+
+	// Replicas sets the replicas of the manager Deployment when not nil
+	Replicas *int32
+}
+
+// supportedFields are the fields of kustomization.yaml built by the package.
+var supportedFields = map[string]bool{
+	"apiVersion": true, "kind": true, "namespace": true, "namePrefix": true,
+	"resources": true, "bases": true, "components": true, "patches": true,
+	"patchesStrategicMerge": true, "images": true, "vars": true, "configurations": true,
+}
+
+// object is an object of the manifests.
+type object struct {
+	doc yaml.MapSlice
+	// originalName is the name of the definition of the object, which the
+	// patches and the vars refer to it with
+	originalName string
+	// file is the file defining the object
+	file string
+}
+
+func (o *object) kind() string {
+	kind, _ := mapslice.Get(o.doc, "kind").(string)
+	return kind
+}
+
+func (o *object) name() string {
+	name, _ := mapslice.Get(o.metadata(), "name").(string)
+	return name
+}
+
+func (o *object) namespace() string {
+	namespace, _ := mapslice.Get(o.metadata(), "namespace").(string)
+	return namespace
+}
+
+func (o *object) metadata() yaml.MapSlice {
+	m, _ := mapslice.Get(o.doc, "metadata").(yaml.MapSlice)
+	return m
+}
+
+func (o *object) setMetadata(key, value string) {
+	o.doc = mapslice.Set(o.doc, "metadata", mapslice.Set(o.metadata(), key, value))
+}
+
+// matches returns whether the object is the one of the given kind and name,
+// by the name of its definition or the one it is given by the kustomizations.
+func (o *object) matches(kind, name string) bool {
+	return kustomization.Target{Kind: kind, Name: name}.Selects("", o.kind(), o.name(), o.originalName)
+}
+
+type generator struct {
+	options Options
+	vars    []kustomization.Var
+	// building are the kustomizations being built, to detect cycles
+	building kustomization.Stack
+}
+
+// Generate builds the kustomization in dir, e.g. config/default, returning
+// the manifests kustomize build would output, customized with the options.
+func Generate(dir string, options Options) ([]byte, error) {
+	g := &generator{options: options, building: kustomization.Stack{}}
+	objects, err := g.build(dir, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.substituteVars(objects); err != nil {
+		return nil, err
+	}
+	customizeManager(objects, options)
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		return kindOrder(objects[i].kind()) < kindOrder(objects[j].kind())
+	})
+	out := &bytes.Buffer{}
+	for _, o := range objects {
+		content, err := yaml.Marshal(o.doc)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString("---\n")
+		out.Write(content)
+	}
+	return out.Bytes(), nil
+}
+
+// readKustomization reads the kustomization in dir, refusing the fields the
+// package does not build.
+func readKustomization(dir string) (*kustomization.Kustomization, string, error) {
+	path := kustomization.Find(dir)
+	if path == "" {
+		return nil, "", fmt.Errorf("%s has no kustomization", dir)
+	}
+	k, err := kustomization.Read(path)
+	if err != nil {
+		return nil, path, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, field := range k.Fields {
+		if !supportedFields[field] {
+			return nil, path, fmt.Errorf("%s: the %s field is not supported, build the kustomization with kustomize", path, field)
+		}
+	}
+	for _, p := range k.Patches {
+		if _, ok := p.(string); !ok {
+			return nil, path, fmt.Errorf("%s: the patches with a target are not supported, build the kustomization with kustomize", path)
+		}
+	}
+	return k, path, nil
+}
+
+// build builds the kustomization in dir on top of the objects of the
+// kustomization including it as a component.
+func (g *generator) build(dir string, objects []*object, top bool) ([]*object, error) {
+	dir = filepath.Clean(dir)
+	if !g.building.Push(dir) {
+		return nil, fmt.Errorf("%s includes itself", dir)
+	}
+	defer g.building.Pop(dir)
+
+	k, kpath, err := readKustomization(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range append(append([]string{}, k.Bases...), k.Resources...) {
+		if kustomization.IsRemote(entry) {
+			return nil, fmt.Errorf("%s: the remote base %s is not supported, build the kustomization with kustomize", kpath, entry)
+		}
+		path := filepath.Join(dir, entry)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v (the CRDs, the role and the webhook configurations are generated by make manifests)", kpath, err)
+		}
+		var added []*object
+		if info.IsDir() {
+			added, err = g.build(path, nil, false)
+		} else {
+			added, err = readObjects(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, added...)
+	}
+	for _, entry := range k.Components {
+		objects, err = g.build(filepath.Join(dir, entry), objects, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	patches := []string{}
+	for _, p := range k.Patches {
+		patches = append(patches, p.(string))
+	}
+	for _, entry := range append(patches, k.PatchesStrategicMerge...) {
+		objects, err = applyPatches(filepath.Join(dir, entry), objects)
+		if err != nil {
+			return nil, err
+		}
+	}
+	applyImages(objects, k.Images)
+
+	if k.NamePrefix != "" {
+		addNamePrefix(objects, k.NamePrefix)
+	}
+	namespace := k.Namespace
+	if top && g.options.Namespace != "" {
+		namespace = g.options.Namespace
+	}
+	if namespace != "" {
+		setNamespace(objects, namespace)
+	}
+	g.vars = append(g.vars, k.Vars...)
+	return objects, nil
+}
+
+// readObjects reads the objects defined in the YAML file at path.
+func readObjects(path string) ([]*object, error) {
+	read, err := kustomization.ReadObjects(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	var objects []*object
+	for _, r := range read {
+		o := &object{doc: r.Doc, file: path}
+		if o.kind() == "" || o.name() == "" {
+			return nil, fmt.Errorf("%s: object without a kind or a metadata.name", path)
+		}
+		o.originalName = o.name()
+		objects = append(objects, o)
+	}
+	return objects, nil
+}
+
+// applyPatches applies the strategic merge patches of the file at path to the
+// objects they target.
+func applyPatches(path string, objects []*object) ([]*object, error) {
+	patches, err := readObjects(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, patch := range patches {
+		found := -1
+		for i, o := range objects {
+			if o.matches(patch.kind(), patch.name()) {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			return nil, fmt.Errorf("%s: the patch targets %s %s, which is not a resource of the kustomization",
+				path, patch.kind(), patch.name())
+		}
+		if directive, _ := mapslice.Get(patch.doc, "$patch").(string); directive == "delete" {
+			objects = append(objects[:found], objects[found+1:]...)
+			continue
+		}
+		// the patch refers to its target with the name of its definition, the
+		// target keeps the name and the namespace given by the kustomizations
+		target := objects[found]
+		name, namespace := target.name(), target.namespace()
+		target.doc = mergePatch(target.doc, patch.doc)
+		target.setMetadata("name", name)
+		if namespace != "" {
+			target.setMetadata("namespace", namespace)
+		}
+	}
+	return objects, nil
+}
+
+// substituteVars replaces the $(VAR) references in the strings of the objects
+// by the fields of the objects the vars refer to.
+func (g *generator) substituteVars(objects []*object) error {
+	if len(g.vars) == 0 {
+		return nil
+	}
+	values := map[string]string{}
+	for _, v := range g.vars {
+		var target *object
+		for _, o := range objects {
+			if o.matches(v.ObjRef.Kind, v.ObjRef.Name) {
+				target = o
+				break
+			}
+		}
+		if target == nil {
+			return fmt.Errorf("var %s refers to %s %s, which is not a resource of the kustomization",
+				v.Name, v.ObjRef.Kind, v.ObjRef.Name)
+		}
+		switch v.FieldRef.FieldPath {
+		case "", "metadata.name":
+			values["$("+v.Name+")"] = target.name()
+		case "metadata.namespace":
+			values["$("+v.Name+")"] = target.namespace()
+		default:
+			return fmt.Errorf("var %s refers to the %s field, only metadata.name and metadata.namespace are supported",
+				v.Name, v.FieldRef.FieldPath)
+		}
+	}
+	for _, o := range objects {
+		o.doc = replaceStrings(o.doc, values).(yaml.MapSlice)
+	}
+	return nil
+}
+
+// replaceStrings replaces the references in the strings of value.
+func replaceStrings(value interface{}, references map[string]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		for reference, replacement := range references {
+			v = strings.Replace(v, reference, replacement, -1)
+		}
+		return v
+	case yaml.MapSlice:
+		for i := range v {
+			v[i].Value = replaceStrings(v[i].Value, references)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = replaceStrings(v[i], references)
+		}
+		return v
+	}
+	return value
+}
+
+// customizeManager sets the image and the replicas of the options to the
+// Deployments of the manager.
+func customizeManager(objects []*object, options Options) {
+	for _, o := range objects {
+		if o.kind() != "Deployment" {
+			continue
+		}
+		spec, _ := mapslice.Get(o.doc, "spec").(yaml.MapSlice)
+		containers := podSpecList(spec, "containers")
+		isManager := false
+		for i, c := range containers {
+			container, _ := c.(yaml.MapSlice)
+			if name, _ := mapslice.Get(container, "name").(string); name == ManagerContainer {
+				isManager = true
+				if options.Image != "" {
+					containers[i] = mapslice.Set(container, "image", options.Image)
+				}
+			}
+		}
+		if isManager && options.Replicas != nil {
+			o.doc = mapslice.Set(o.doc, "spec", mapslice.Set(spec, "replicas", int(*options.Replicas)))
+		}
+	}
+}
+
+// kindOrder is the order kustomize outputs the kinds in, so that the objects
+// are created before the ones depending on them.
+func kindOrder(kind string) int {
+	order := []string{
+		"Namespace", "ResourceQuota", "LimitRange", "PodSecurityPolicy", "Secret", "ConfigMap",
+		"StorageClass", "PersistentVolume", "PersistentVolumeClaim", "ServiceAccount",
+		"CustomResourceDefinition", "ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding",
+		"Service", "DaemonSet", "Pod", "ReplicationController", "ReplicaSet", "Deployment",
+		"StatefulSet", "Job", "CronJob", "Ingress", "APIService",
+	}
+	for i, k := range order {
+		if k == kind {
+			return i
+		}
+	}
+	switch kind {
+	case "MutatingWebhookConfiguration", "ValidatingWebhookConfiguration":
+		// the webhooks are configured last, once the objects they validate exist
+		return len(order) + 1
+	}
+	return len(order)
+}
@@ -0,0 +1,414 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configgen_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/configgen"
+)
+
+const (
+	managerKustomization = `resources:
+- manager.yaml
+`
+	manager = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: system
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: controller:latest
+        args:
+        - --enable-leader-election
+`
+	rbacKustomization = `resources:
+- role.yaml
+- role_binding.yaml
+`
+	role = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: manager-role
+`
+	roleBinding = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: manager-rolebinding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: manager-role
+subjects:
+- kind: ServiceAccount
+  name: default
+  namespace: system
+`
+	crd = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: captains.crew.example.com
+spec:
+  group: crew.example.com
+`
+)
+
+func TestGenerate(t *testing.T) {
+	replicas := int32(3)
+	tests := []struct {
+		name    string
+		files   map[string]string
+		options configgen.Options
+		// dir is the kustomization to build, default by default
+		dir     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "default",
+			files: map[string]string{
+				"default/kustomization.yaml": `namespace: project-system
+namePrefix: project-
+bases:
+- ../rbac
+- ../manager
+- ../crd
+patches:
+- manager_auth_proxy_patch.yaml
+`,
+				"default/manager_auth_proxy_patch.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: system
+spec:
+  template:
+    spec:
+      containers:
+      - name: kube-rbac-proxy
+        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.4.1
+      - name: manager
+        args:
+        - --metrics-addr=127.0.0.1:8080
+`,
+				"manager/kustomization.yaml":               managerKustomization,
+				"manager/manager.yaml":                     manager,
+				"rbac/kustomization.yaml":                  rbacKustomization,
+				"rbac/role.yaml":                           role,
+				"rbac/role_binding.yaml":                   roleBinding,
+				"crd/kustomization.yaml":                   "resources:\n- bases/crew.example.com_captains.yaml\n",
+				"crd/bases/crew.example.com_captains.yaml": crd,
+			},
+			want: `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: project-system
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: captains.crew.example.com
+spec:
+  group: crew.example.com
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: project-manager-role
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: project-manager-rolebinding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: project-manager-role
+subjects:
+- kind: ServiceAccount
+  name: default
+  namespace: project-system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: project-controller-manager
+  namespace: project-system
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: controller:latest
+        args:
+        - --metrics-addr=127.0.0.1:8080
+      - name: kube-rbac-proxy
+        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.4.1
+`,
+		},
+		{
+			name: "options",
+			files: map[string]string{
+				"default/kustomization.yaml": "namespace: project-system\nbases:\n- ../manager\n",
+				"manager/kustomization.yaml": managerKustomization,
+				"manager/manager.yaml":       manager,
+			},
+			options: configgen.Options{Namespace: "ops", Image: "example.com/operator:v1", Replicas: &replicas},
+			want: `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ops
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: ops
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: example.com/operator:v1
+        args:
+        - --enable-leader-election
+`,
+		},
+		{
+			name: "images and component",
+			files: map[string]string{
+				"default/kustomization.yaml": `resources:
+- ../manager
+- ../crd
+components:
+- ../components/captain
+images:
+- name: controller
+  newName: example.com/operator
+  newTag: v2
+`,
+				"manager/kustomization.yaml": managerKustomization,
+				"manager/manager.yaml":       manager,
+				"crd/kustomization.yaml":     "resources:\n- crd.yaml\n",
+				"crd/crd.yaml":               crd,
+				"components/captain/kustomization.yaml": `apiVersion: kustomize.config.k8s.io/v1alpha1
+kind: Component
+patchesStrategicMerge:
+- crd_patch.yaml
+`,
+				"components/captain/crd_patch.yaml": `$patch: delete
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: captains.crew.example.com
+`,
+			},
+			want: `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: system
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: example.com/operator:v2
+        args:
+        - --enable-leader-election
+`,
+		},
+		{
+			name: "vars",
+			files: map[string]string{
+				"default/kustomization.yaml": `namespace: project-system
+namePrefix: project-
+resources:
+- service.yaml
+- certificate.yaml
+vars:
+- name: SERVICENAME
+  objref:
+    kind: Service
+    version: v1
+    name: webhook-service
+- name: NAMESPACE
+  objref:
+    kind: Service
+    version: v1
+    name: webhook-service
+  fieldref:
+    fieldpath: metadata.namespace
+`,
+				"default/service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: webhook-service
+  namespace: system
+`,
+				"default/certificate.yaml": `apiVersion: certmanager.k8s.io/v1alpha1
+kind: Certificate
+metadata:
+  name: serving-cert
+  namespace: system
+spec:
+  commonName: $(SERVICENAME).$(NAMESPACE).svc
+  issuerRef:
+    kind: Issuer
+    name: selfsigned-issuer
+`,
+			},
+			want: `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: project-webhook-service
+  namespace: project-system
+---
+apiVersion: certmanager.k8s.io/v1alpha1
+kind: Certificate
+metadata:
+  name: project-serving-cert
+  namespace: project-system
+spec:
+  commonName: project-webhook-service.project-system.svc
+  issuerRef:
+    kind: Issuer
+    name: selfsigned-issuer
+`,
+		},
+		{
+			name: "patch of a prefixed base",
+			files: map[string]string{
+				"overlay/kustomization.yaml": "namespace: project-system\nbases:\n- ../default\npatchesStrategicMerge:\n- patch.yaml\n",
+				"overlay/patch.yaml":         "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: controller-manager\n  namespace: system\nspec:\n  replicas: 2\n",
+				"default/kustomization.yaml": "namespace: project-system\nnamePrefix: project-\nbases:\n- ../manager\n",
+				"manager/kustomization.yaml": managerKustomization,
+				"manager/manager.yaml":       manager,
+			},
+			dir: "overlay",
+			want: `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: project-system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: project-controller-manager
+  namespace: project-system
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: controller:latest
+        args:
+        - --enable-leader-election
+`,
+		},
+		{
+			name: "unsupported field",
+			files: map[string]string{
+				"default/kustomization.yaml": "commonLabels:\n  app: operator\n",
+			},
+			wantErr: "the commonLabels field is not supported",
+		},
+		{
+			name: "patch without target",
+			files: map[string]string{
+				"default/kustomization.yaml": "bases:\n- ../manager\npatches:\n- patch.yaml\n",
+				"default/patch.yaml":         "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: manager\n",
+				"manager/kustomization.yaml": managerKustomization,
+				"manager/manager.yaml":       manager,
+			},
+			wantErr: "the patch targets Deployment manager, which is not a resource of the kustomization",
+		},
+		{
+			name: "missing generated file",
+			files: map[string]string{
+				"default/kustomization.yaml": "bases:\n- ../crd\n",
+				"crd/kustomization.yaml":     "resources:\n- bases/crew.example.com_captains.yaml\n",
+			},
+			wantErr: "generated by make manifests",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "configgen")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			for path, content := range test.files {
+				path = filepath.Join(dir, path)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kustomization := test.dir
+			if kustomization == "" {
+				kustomization = "default"
+			}
+			got, err := configgen.Generate(filepath.Join(dir, kustomization), test.options)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error: %v and wanted: %s", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got:\n%s\nwanted:\n%s", got, test.want)
+			}
+		})
+	}
+}
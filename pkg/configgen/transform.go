@@ -0,0 +1,304 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configgen
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/kubebuilder/pkg/internal/kustomization"
+	"sigs.k8s.io/kubebuilder/pkg/internal/mapslice"
+)
+
+// mergeKeys are the keys the strategic merge patches merge the elements of
+// the lists of the core kinds by. The other lists are replaced.
+var mergeKeys = map[string]string{
+	"containers":       "name",
+	"initContainers":   "name",
+	"volumes":          "name",
+	"env":              "name",
+	"imagePullSecrets": "name",
+	"webhooks":         "name",
+	"ports":            "containerPort",
+	"volumeMounts":     "mountPath",
+}
+
+// clusterKinds are the kinds of the scaffolded objects which are not
+// namespaced.
+var clusterKinds = map[string]bool{
+	"Namespace":                      true,
+	"CustomResourceDefinition":       true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"MutatingWebhookConfiguration":   true,
+	"ValidatingWebhookConfiguration": true,
+	"APIService":                     true,
+	"PriorityClass":                  true,
+	"StorageClass":                   true,
+	"PersistentVolume":               true,
+	"PodSecurityPolicy":              true,
+	"ClusterIssuer":                  true,
+}
+
+// mergePatch merges patch into doc the way a strategic merge patch does: the
+// maps are merged, a null deletes its key, and the lists of the mergeKeys
+// are merged by key, an element with $patch: delete deleting its match.
+func mergePatch(doc, patch yaml.MapSlice) yaml.MapSlice {
+	for _, item := range patch {
+		key, _ := item.Key.(string)
+		if key == "$patch" {
+			continue
+		}
+		if item.Value == nil {
+			doc = mapslice.Delete(doc, key)
+			continue
+		}
+		switch value := item.Value.(type) {
+		case yaml.MapSlice:
+			if existing, ok := mapslice.Get(doc, key).(yaml.MapSlice); ok {
+				doc = mapslice.Set(doc, key, mergePatch(existing, value))
+				continue
+			}
+		case []interface{}:
+			if existing, ok := mapslice.Get(doc, key).([]interface{}); ok && mergeKeys[key] != "" {
+				doc = mapslice.Set(doc, key, mergeList(existing, value, mergeKeys[key]))
+				continue
+			}
+		}
+		doc = mapslice.Set(doc, key, item.Value)
+	}
+	return doc
+}
+
+// mergeList merges the elements of patch into the elements of list with the
+// same value of key, appending the others.
+func mergeList(list, patch []interface{}, key string) []interface{} {
+	for _, p := range patch {
+		element, ok := p.(yaml.MapSlice)
+		if !ok {
+			list = append(list, p)
+			continue
+		}
+		found := -1
+		for i, e := range list {
+			if existing, ok := e.(yaml.MapSlice); ok && mapslice.Get(existing, key) == mapslice.Get(element, key) {
+				found = i
+				break
+			}
+		}
+		directive, _ := mapslice.Get(element, "$patch").(string)
+		switch {
+		case found >= 0 && directive == "delete":
+			list = append(list[:found], list[found+1:]...)
+		case found >= 0:
+			list[found] = mergePatch(list[found].(yaml.MapSlice), element)
+		case directive != "delete":
+			list = append(list, element)
+		}
+	}
+	return list
+}
+
+// podSpecList returns the list of the pod template of the spec of a workload,
+// e.g. its containers.
+func podSpecList(spec yaml.MapSlice, key string) []interface{} {
+	template, _ := mapslice.Get(spec, "template").(yaml.MapSlice)
+	podSpec, _ := mapslice.Get(template, "spec").(yaml.MapSlice)
+	list, _ := mapslice.Get(podSpec, key).([]interface{})
+	return list
+}
+
+// applyImages sets the names, the tags and the digests of the images of the
+// containers of the objects.
+func applyImages(objects []*object, images []kustomization.Image) {
+	if len(images) == 0 {
+		return
+	}
+	for _, o := range objects {
+		spec, _ := mapslice.Get(o.doc, "spec").(yaml.MapSlice)
+		for _, key := range []string{"containers", "initContainers"} {
+			containers := podSpecList(spec, key)
+			for i, c := range containers {
+				container, _ := c.(yaml.MapSlice)
+				current, _ := mapslice.Get(container, "image").(string)
+				for _, img := range images {
+					if updated, ok := updateImage(current, img); ok {
+						containers[i] = mapslice.Set(container, "image", updated)
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// updateImage returns the image current updated by img when it is an image
+// of the name of img.
+func updateImage(current string, img kustomization.Image) (string, bool) {
+	name, tag := current, ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, tag = name[:i], name[i:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i:]
+	}
+	if name != img.Name {
+		return "", false
+	}
+	if img.NewName != "" {
+		name = img.NewName
+	}
+	switch {
+	case img.Digest != "":
+		tag = "@" + img.Digest
+	case img.NewTag != "":
+		tag = ":" + img.NewTag
+	}
+	return name + tag, true
+}
+
+// reference is a map of an object naming another object.
+type reference struct {
+	// m is the map holding the name of the object referred to
+	m yaml.MapSlice
+	// kind is the kind of the object referred to
+	kind string
+	// name and namespace are the keys of m holding its name and namespace,
+	// namespace being empty when m has none
+	name, namespace string
+}
+
+// references returns the references of the object to the other objects known
+// to kustomize and to the scaffolded kustomizeconfig.yaml files.
+func references(o *object) []reference {
+	var refs []reference
+	spec, _ := mapslice.Get(o.doc, "spec").(yaml.MapSlice)
+	switch o.kind() {
+	case "RoleBinding", "ClusterRoleBinding":
+		if roleRef, ok := mapslice.Get(o.doc, "roleRef").(yaml.MapSlice); ok {
+			kind, _ := mapslice.Get(roleRef, "kind").(string)
+			refs = append(refs, reference{m: roleRef, kind: kind, name: "name"})
+		}
+		subjects, _ := mapslice.Get(o.doc, "subjects").([]interface{})
+		for _, s := range subjects {
+			subject, _ := s.(yaml.MapSlice)
+			if kind, _ := mapslice.Get(subject, "kind").(string); kind == "ServiceAccount" {
+				refs = append(refs, reference{m: subject, kind: kind, name: "name", namespace: "namespace"})
+			}
+		}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		template, _ := mapslice.Get(spec, "template").(yaml.MapSlice)
+		if podSpec, ok := mapslice.Get(template, "spec").(yaml.MapSlice); ok {
+			refs = append(refs, reference{m: podSpec, kind: "ServiceAccount", name: "serviceAccountName"})
+		}
+		for _, v := range podSpecList(spec, "volumes") {
+			volume, _ := v.(yaml.MapSlice)
+			if secret, ok := mapslice.Get(volume, "secret").(yaml.MapSlice); ok {
+				refs = append(refs, reference{m: secret, kind: "Secret", name: "secretName"})
+			}
+			if configMap, ok := mapslice.Get(volume, "configMap").(yaml.MapSlice); ok {
+				refs = append(refs, reference{m: configMap, kind: "ConfigMap", name: "name"})
+			}
+		}
+	case "MutatingWebhookConfiguration", "ValidatingWebhookConfiguration":
+		webhooks, _ := mapslice.Get(o.doc, "webhooks").([]interface{})
+		for _, w := range webhooks {
+			webhook, _ := w.(yaml.MapSlice)
+			clientConfig, _ := mapslice.Get(webhook, "clientConfig").(yaml.MapSlice)
+			if service, ok := mapslice.Get(clientConfig, "service").(yaml.MapSlice); ok {
+				refs = append(refs, reference{m: service, kind: "Service", name: "name", namespace: "namespace"})
+			}
+		}
+	case "CustomResourceDefinition":
+		// the conversion webhook of the v1beta1 and of the v1 CRDs
+		conversion, _ := mapslice.Get(spec, "conversion").(yaml.MapSlice)
+		clientConfig, _ := mapslice.Get(conversion, "webhookClientConfig").(yaml.MapSlice)
+		if webhook, ok := mapslice.Get(conversion, "webhook").(yaml.MapSlice); ok {
+			clientConfig, _ = mapslice.Get(webhook, "clientConfig").(yaml.MapSlice)
+		}
+		if service, ok := mapslice.Get(clientConfig, "service").(yaml.MapSlice); ok {
+			refs = append(refs, reference{m: service, kind: "Service", name: "name", namespace: "namespace"})
+		}
+	case "Certificate":
+		if issuerRef, ok := mapslice.Get(spec, "issuerRef").(yaml.MapSlice); ok {
+			kind, _ := mapslice.Get(issuerRef, "kind").(string)
+			if kind == "" {
+				kind = "Issuer"
+			}
+			refs = append(refs, reference{m: issuerRef, kind: kind, name: "name"})
+		}
+	}
+	return refs
+}
+
+// update sets the key of the reference to value. Only the keys already set are
+// updated, the maps of the references being shared with their object.
+func (r reference) update(key, value string) {
+	for i := range r.m {
+		if r.m[i].Key == key {
+			r.m[i].Value = value
+		}
+	}
+}
+
+// addNamePrefix prefixes the names of the objects, but the CRDs whose names
+// are fixed, and the references to them.
+func addNamePrefix(objects []*object, prefix string) {
+	type key struct{ kind, name string }
+	renamed := map[key]string{}
+	for _, o := range objects {
+		if o.kind() == "CustomResourceDefinition" {
+			continue
+		}
+		renamed[key{o.kind(), o.name()}] = prefix + o.name()
+		o.setMetadata("name", prefix+o.name())
+	}
+	for _, o := range objects {
+		for _, ref := range references(o) {
+			name, _ := mapslice.Get(ref.m, ref.name).(string)
+			if newName, ok := renamed[key{ref.kind, name}]; ok {
+				ref.update(ref.name, newName)
+			}
+		}
+	}
+}
+
+// setNamespace sets the namespace of the namespaced objects, and of the
+// references to them. The Namespace objects are renamed to the namespace.
+func setNamespace(objects []*object, namespace string) {
+	type key struct{ kind, name string }
+	namespaced := map[key]bool{}
+	for _, o := range objects {
+		switch {
+		case o.kind() == "Namespace":
+			o.setMetadata("name", namespace)
+		case !clusterKinds[o.kind()]:
+			namespaced[key{o.kind(), o.name()}] = true
+			o.setMetadata("namespace", namespace)
+		}
+	}
+	for _, o := range objects {
+		for _, ref := range references(o) {
+			name, _ := mapslice.Get(ref.m, ref.name).(string)
+			// the default service account of the namespace is not an object of
+			// the kustomizations, its subjects follow the namespace as well
+			isDefault := ref.kind == "ServiceAccount" && name == "default"
+			if ref.namespace != "" && (namespaced[key{ref.kind, name}] || isDefault) {
+				ref.update(ref.namespace, namespace)
+			}
+		}
+	}
+}
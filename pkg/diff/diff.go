@@ -0,0 +1,198 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package diff renders the changes between two versions of a text file as a
+// unified diff, so that the rewrites of a project can be reviewed before they
+// are applied.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around the changed ones.
+const context = 3
+
+type opKind int
+
+const (
+	equal opKind = iota
+	deleted
+	inserted
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns the unified diff from before, the content of the file at
+// from, to after, the content of the file at to. It is empty when the paths
+// and the contents are the same.
+func Unified(from, to string, before, after []byte) string {
+	if from == to && string(before) == string(after) {
+		return ""
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "--- a/%s\n+++ b/%s\n", from, to)
+	ops := edits(splitLines(string(before)), splitLines(string(after)))
+	for start := 0; start < len(ops); {
+		// find the next change, then extend the hunk until a run of more than
+		// twice the context of unchanged lines
+		first := start
+		for first < len(ops) && ops[first].kind == equal {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != equal {
+				last = i
+			} else if i-last > 2*context {
+				break
+			}
+		}
+		lo, hi := first-context, last+context+1
+		if lo < start {
+			lo = start
+		}
+		if hi > len(ops) {
+			hi = len(ops)
+		}
+		writeHunk(b, ops, lo, hi)
+		start = hi
+	}
+	return b.String()
+}
+
+// writeHunk writes the hunk of the ops from lo to hi, excluded.
+func writeHunk(b *strings.Builder, ops []op, lo, hi int) {
+	beforeLine, afterLine := 1, 1
+	for _, o := range ops[:lo] {
+		if o.kind != inserted {
+			beforeLine++
+		}
+		if o.kind != deleted {
+			afterLine++
+		}
+	}
+	beforeCount, afterCount := 0, 0
+	for _, o := range ops[lo:hi] {
+		if o.kind != inserted {
+			beforeCount++
+		}
+		if o.kind != deleted {
+			afterCount++
+		}
+	}
+	// an empty range starts at the line before it
+	if beforeCount == 0 {
+		beforeLine--
+	}
+	if afterCount == 0 {
+		afterLine--
+	}
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", beforeLine, beforeCount, afterLine, afterCount)
+	for _, o := range ops[lo:hi] {
+		prefix := " "
+		switch o.kind {
+		case deleted:
+			prefix = "-"
+		case inserted:
+			prefix = "+"
+		}
+		b.WriteString(prefix + o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits content into lines, keeping their line endings.
+func splitLines(content string) []string {
+	var lines []string
+	for content != "" {
+		i := strings.IndexByte(content, '\n') + 1
+		if i == 0 {
+			i = len(content)
+		}
+		lines = append(lines, content[:i])
+		content = content[i:]
+	}
+	return lines
+}
+
+// edits returns the shortest edit script turning a into b, computed from the
+// longest common subsequence of the lines between their common prefix and
+// suffix.
+func edits(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []op
+	for _, line := range a[:prefix] {
+		ops = append(ops, op{equal, line})
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	// lcs[i][j] is the length of the longest common subsequence of ma[i:]
+	// and mb[j:]
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, op{equal, ma[i]})
+			i++
+			j++
+		case j == len(mb) || (i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{deleted, ma[i]})
+			i++
+		default:
+			ops = append(ops, op{inserted, mb[j]})
+			j++
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{equal, line})
+	}
+	return ops
+}
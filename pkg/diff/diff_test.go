@@ -0,0 +1,59 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/diff"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		before   string
+		after    string
+		expected string
+	}{
+		{name: "unchanged", from: "f", to: "f", before: "a\nb\n", after: "a\nb\n", expected: ""},
+		{name: "changed line", from: "f", to: "f", before: "a\nb\nc\n", after: "a\nB\nc\n",
+			expected: "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{name: "context", from: "f", to: "f",
+			before:   "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			after:    "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			expected: "--- a/f\n+++ b/f\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n"},
+		{name: "two hunks", from: "f", to: "f",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			after:  "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			expected: "--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n"},
+		{name: "inserted into empty", from: "f", to: "f", before: "", after: "a\n",
+			expected: "--- a/f\n+++ b/f\n@@ -0,0 +1,1 @@\n+a\n"},
+		{name: "no newline at end", from: "f", to: "f", before: "a", after: "b",
+			expected: "--- a/f\n+++ b/f\n@@ -1,1 +1,1 @@\n-a\n\\ No newline at end of file\n+b\n\\ No newline at end of file\n"},
+		{name: "renamed", from: "f", to: "g", before: "a\n", after: "a\n",
+			expected: "--- a/f\n+++ b/g\n"},
+	}
+
+	for _, test := range tests {
+		got := diff.Unified(test.from, test.to, []byte(test.before), []byte(test.after))
+		if got != test.expected {
+			t.Errorf("%s: got:\n%s\nand wanted:\n%s", test.name, got, test.expected)
+		}
+	}
+}
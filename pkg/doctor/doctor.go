@@ -0,0 +1,455 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package doctor checks a project scaffolded by kubebuilder for the drift
+// from its scaffolding which breaks the commands updating it or the build of
+// its manifests.
+//
+// The resources of the PROJECT file must have their API types, controllers
+// and webhooks in the files of their scaffolding, and the API types found in
+// the api directory must be tracked in the PROJECT file. The scaffold marker
+// comments the commands insert their code at must still be in the files they
+// update: a command finding no marker leaves the file unchanged without an
+// error. The version of controller-gen installed by the Makefile must match the
+// markers of the scaffolds and the controller-runtime version of go.mod.
+// Finally, the resources the code of the controllers uses with the client must
+// be granted by +kubebuilder:rbac markers, as checked by package rbaclint.
+package doctor
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/rbaclint"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+)
+
+// ControllerToolsVersion is the version of controller-gen installed by the
+// Makefile of the projects scaffolded by kubebuilder, which generates the
+// code and manifests of the markers of the scaffolds.
+const ControllerToolsVersion = "v0.2.0-beta.2"
+
+// Problem is a drift of the project from its scaffolding.
+type Problem struct {
+	// File is the file of the project the problem is found in, relative to
+	// the project directory
+	File string
+	// Message describes the problem
+	Message string
+	// Fix is the action fixing the problem
+	Fix string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s\n\tfix: %s", p.File, p.Message, p.Fix)
+}
+
+// marker is a scaffold marker comment a command inserts code at.
+type marker struct {
+	// pattern is the glob pattern of the files holding the marker
+	pattern string
+	marker  string
+	// inserted is what the commands insert at the marker
+	inserted string
+	// where is the place of the marker in the file
+	where string
+	// requires is the file the marker is only scaffolded with, if any
+	requires string
+}
+
+// markers are the scaffold markers of the files updated by the commands.
+var markers = []marker{
+	{"main.go", "// +kubebuilder:scaffold:imports", "the imports of the API and controller packages",
+		"at the end of the import block", ""},
+	{"main.go", "// +kubebuilder:scaffold:scheme", "the registration of the API versions with the scheme",
+		"at the end of the init function", ""},
+	{"main.go", "// +kubebuilder:scaffold:builder", "the set up of the controllers and webhooks",
+		"after the set up of the last controller in the main function", ""},
+	{"main.go", "// +kubebuilder:scaffold:webhooktls", "the webhooks served with the TLS configuration of the flags",
+		"after the set up of the controllers and webhooks in the main function", ""},
+	{"main.go", "// +kubebuilder:scaffold:heartbeat", "the registration of the controllers with the heartbeat",
+		"before the heartbeat is added to the manager in the main function", filepath.Join("controllers", "heartbeat.go")},
+	{filepath.Join("config", "crd", "kustomization.yaml"), "# +kubebuilder:scaffold:crdkustomizeresource",
+		"the CRDs of the APIs", "at the end of the resources", ""},
+	{filepath.Join("config", "crd", "kustomization.yaml"), "# +kubebuilder:scaffold:crdkustomizewebhookpatch",
+		"the patches enabling the conversion webhooks of the CRDs", "after the [WEBHOOK] comment of the patches", ""},
+	{filepath.Join("config", "crd", "kustomization.yaml"), "# +kubebuilder:scaffold:crdkustomizecainjectionpatch",
+		"the patches injecting the CA of the conversion webhooks", "after the [CAINJECTION] comment of the patches", ""},
+	{filepath.Join("config", "default", "kustomization.yaml"), "# +kubebuilder:scaffold:components",
+		"the components excluding the optional APIs", "after the #components: line", ""},
+	{filepath.Join("config", "uninstall", "job.yaml"), "# +kubebuilder:scaffold:uninstallcrds",
+		"the CRDs deleted by the uninstall Job", "at the end of the args of the cleanup container", ""},
+	{filepath.Join("config", "uninstall", "rbac.yaml"), "# +kubebuilder:scaffold:uninstallgroups",
+		"the API groups cleaned up by the uninstall Job", "at the end of the apiGroups of the last rule of the ClusterRole", ""},
+	{filepath.Join("controllers", "suite_test.go"), "// +kubebuilder:scaffold:imports",
+		"the imports of the API packages", "at the end of the import block", ""},
+	{filepath.Join("controllers", "suite_test.go"), "// +kubebuilder:scaffold:scheme",
+		"the registration of the API versions with the scheme", "after the last AddToScheme call of the BeforeSuite", ""},
+	{filepath.Join("controllers", "suite_test.go"), "// +kubebuilder:scaffold:envtestoptions",
+		"the webhooks configured in the test environment", "at the end of the envtest.Environment of the BeforeSuite", ""},
+	{filepath.Join("controllers", "suite_test.go"), "// +kubebuilder:scaffold:envtestsetup",
+		"the webhooks installed in the test environment", "before close(done) in the BeforeSuite", ""},
+	{filepath.Join("controllers", "suite_test.go"), "// +kubebuilder:scaffold:envtestteardown",
+		"the webhooks uninstalled from the test environment", "before testEnv.Stop() in the AfterSuite", ""},
+	{filepath.Join("controllers", "*", "suite_test.go"), "// +kubebuilder:scaffold:imports",
+		"the imports of the API packages", "at the end of the import block", ""},
+	{filepath.Join("controllers", "*", "suite_test.go"), "// +kubebuilder:scaffold:scheme",
+		"the registration of the API versions with the scheme", "after the last AddToScheme call of the BeforeSuite", ""},
+	{filepath.Join("controllers", "*", "suite_test.go"), "// +kubebuilder:scaffold:envtestoptions",
+		"the webhooks configured in the test environment", "at the end of the envtest.Environment of the BeforeSuite", ""},
+	{filepath.Join("controllers", "*", "suite_test.go"), "// +kubebuilder:scaffold:envtestsetup",
+		"the webhooks installed in the test environment", "before close(done) in the BeforeSuite", ""},
+	{filepath.Join("controllers", "*", "suite_test.go"), "// +kubebuilder:scaffold:envtestteardown",
+		"the webhooks uninstalled from the test environment", "before testEnv.Stop() in the AfterSuite", ""},
+	{filepath.Join("controllers", "envtest_webhooks_test.go"), "// +kubebuilder:scaffold:imports",
+		"the imports of the API packages", "at the end of the import block", ""},
+	{filepath.Join("controllers", "envtest_webhooks_test.go"), "// +kubebuilder:scaffold:envtestwebhooks",
+		"the webhooks served in the test environment", "at the end of envtestWebhooks", ""},
+	{filepath.Join("controllers", "*", "envtest_webhooks_test.go"), "// +kubebuilder:scaffold:imports",
+		"the imports of the API packages", "at the end of the import block", ""},
+	{filepath.Join("controllers", "*", "envtest_webhooks_test.go"), "// +kubebuilder:scaffold:envtestwebhooks",
+		"the webhooks served in the test environment", "at the end of envtestWebhooks", ""},
+}
+
+// Check checks the project in dir, returning the problems found sorted by
+// file. The RBAC markers are checked against the code of the packages matching
+// rbacPatterns, which are loaded and type-checked from dir, none being checked
+// when no pattern is given.
+func Check(dir string, rbacPatterns ...string) ([]Problem, error) {
+	p, err := scaffold.LoadProjectFile(filepath.Join(dir, "PROJECT"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the PROJECT file: %v", err)
+	}
+	if p.Version == project.Version1 {
+		return nil, fmt.Errorf("only the projects of version 2 and above are checked, the project is of version %s", p.Version)
+	}
+
+	c := &checker{dir: dir, project: p}
+	checks := []func() error{c.checkResources, c.checkAPITypes, c.checkMarkers, c.checkControllerTools}
+	if len(rbacPatterns) > 0 {
+		checks = append(checks, func() error { return c.checkRBAC(rbacPatterns) })
+	}
+	for _, check := range checks {
+		if err := check(); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(c.problems, func(i, j int) bool { return c.problems[i].File < c.problems[j].File })
+	return c.problems, nil
+}
+
+type checker struct {
+	dir      string
+	project  input.ProjectFile
+	problems []Problem
+}
+
+func (c *checker) report(file, fix, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{File: file, Message: fmt.Sprintf(format, args...), Fix: fix})
+}
+
+func (c *checker) exists(path string) bool {
+	_, err := os.Stat(filepath.Join(c.dir, path))
+	return err == nil
+}
+
+// apiDir returns the directory of the API types of the group version, which
+// is api/<group>/<version> in multigroup projects.
+func (c *checker) apiDir(group, version string) string {
+	if c.project.MultiGroup {
+		return filepath.Join("api", group, version)
+	}
+	return filepath.Join("api", version)
+}
+
+// controllersDir returns the directory of the controllers of the group, which
+// is controllers/<group> in multigroup projects.
+func (c *checker) controllersDir(group string) string {
+	if c.project.MultiGroup {
+		return filepath.Join("controllers", group)
+	}
+	return "controllers"
+}
+
+// checkResources checks the resources of the PROJECT file have the files of
+// their scaffolding. The controllers and webhooks of a resource are only
+// tracked in projects of version 3.
+func (c *checker) checkResources() error {
+	for i := range c.project.Resources {
+		r := &c.project.Resources[i]
+		gvk := fmt.Sprintf("--group %s --version %s --kind %s", r.Group, r.Version, r.Kind)
+		kind := strings.ToLower(r.Kind)
+		if !r.HasAPI() {
+			// the resources of other APIs, e.g. the core ones, have their types
+			// and webhooks outside the project
+			if r.Controller {
+				c.checkFile(filepath.Join(c.controllersDir(r.Group), kind+"_controller.go"), r, "controller",
+					fmt.Sprintf("run kubebuilder create api %s --resource=false --controller=true, or unset the controller of the resource in PROJECT", gvk))
+			}
+			continue
+		}
+
+		c.checkFile(filepath.Join(c.apiDir(r.Group, r.Version), kind+"_types.go"), r, "API types",
+			fmt.Sprintf("run kubebuilder create api %s --resource=true --controller=false, or remove the resource from PROJECT", gvk))
+		if r.Controller {
+			c.checkFile(filepath.Join(c.controllersDir(r.Group), kind+"_controller.go"), r, "controller",
+				fmt.Sprintf("run kubebuilder create api %s --resource=false --controller=true, or unset the controller of the resource in PROJECT", gvk))
+		}
+		if r.Webhooks != nil && (r.Webhooks.Defaulting || r.Webhooks.Validation) {
+			flags := gvk
+			if r.Webhooks.Defaulting {
+				flags += " --defaulting"
+			}
+			if r.Webhooks.Validation {
+				flags += " --programmatic-validation"
+			}
+			c.checkFile(filepath.Join(c.apiDir(r.Group, r.Version), kind+"_webhook.go"), r, "webhooks",
+				fmt.Sprintf("run kubebuilder create webhook %s, or remove the webhooks of the resource from PROJECT", flags))
+		}
+	}
+	return nil
+}
+
+func (c *checker) checkFile(path string, r *input.Resource, what, fix string) {
+	if !c.exists(path) {
+		c.report("PROJECT", fix, "the resource %s/%s, Kind=%s has no %s in %s", r.Group, r.Version, r.Kind, what,
+			filepath.ToSlash(path))
+	}
+}
+
+const typesFileSuffix = "_types.go"
+
+var groupNameRegex = regexp.MustCompile(`\+groupName=([^.\s]+)`)
+
+// checkAPITypes checks the API types of the api directory are tracked in the
+// PROJECT file.
+func (c *checker) checkAPITypes() error {
+	pattern := filepath.Join(c.dir, "api", "*", "*"+typesFileSuffix)
+	if c.project.MultiGroup {
+		pattern = filepath.Join(c.dir, "api", "*", "*", "*"+typesFileSuffix)
+	}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		versionDir := filepath.Dir(file)
+		version := filepath.Base(versionDir)
+		group := groupOf(versionDir)
+		if c.project.MultiGroup {
+			group = filepath.Base(filepath.Dir(versionDir))
+		}
+		kind, err := kindOf(file)
+		if err != nil {
+			return err
+		}
+		if tracked(c.project, group, version, kind) {
+			continue
+		}
+		path, err := filepath.Rel(c.dir, file)
+		if err != nil {
+			return err
+		}
+		c.report(filepath.ToSlash(path),
+			fmt.Sprintf("add {group: %s, version: %s, kind: %s} to the resources of PROJECT", group, version, kind),
+			"the API types of %s/%s, Kind=%s are not tracked in PROJECT, the commands ignore them", group, version, kind)
+	}
+	return nil
+}
+
+// tracked returns whether the API types of the kind are tracked in the
+// project. The group is only compared when known.
+func tracked(p input.ProjectFile, group, version, kind string) bool {
+	for i := range p.Resources {
+		r := &p.Resources[i]
+		if r.HasAPI() && r.Version == version && strings.EqualFold(r.Kind, kind) && (group == "" || r.Group == group) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupOf returns the API group of the package in dir, read from the
+// +groupName marker of its groupversion_info.go, with the domain trimmed. It
+// returns the empty string if the group is not found.
+func groupOf(dir string) string {
+	content, err := ioutil.ReadFile(filepath.Join(dir, "groupversion_info.go"))
+	if err != nil {
+		return ""
+	}
+	match := groupNameRegex.FindSubmatch(content)
+	if match == nil {
+		return ""
+	}
+	return string(match[1])
+}
+
+// kindOf returns the kind of the API types of a <kind>_types.go file, from
+// the type of the file whose lower case name is the one of the file.
+func kindOf(file string) (string, error) {
+	name := strings.TrimSuffix(filepath.Base(file), typesFileSuffix)
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		return "", err
+	}
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if typeSpec := spec.(*ast.TypeSpec); strings.ToLower(typeSpec.Name.Name) == name {
+				return typeSpec.Name.Name, nil
+			}
+		}
+	}
+	return name, nil
+}
+
+// checkMarkers checks the files updated by the commands still hold the
+// scaffold markers the commands insert their code at.
+func (c *checker) checkMarkers() error {
+	for _, m := range markers {
+		if m.requires != "" && !c.exists(m.requires) {
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(c.dir, m.pattern))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			content, err := ioutil.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if hasLine(string(content), m.marker) {
+				continue
+			}
+			path, err := filepath.Rel(c.dir, file)
+			if err != nil {
+				return err
+			}
+			c.report(filepath.ToSlash(path), fmt.Sprintf("add the line %q back %s", m.marker, m.where),
+				"the %s marker is missing, the commands can no longer add %s", m.marker, m.inserted)
+		}
+	}
+	return nil
+}
+
+// hasLine returns whether one of the lines of content is line, ignoring the
+// leading and trailing white space as the commands do.
+func hasLine(content, line string) bool {
+	for _, l := range strings.Split(content, "\n") {
+		if strings.TrimSpace(l) == line {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	controllerGenRegex     = regexp.MustCompile(`sigs\.k8s\.io/controller-tools/cmd/controller-gen@(v[^\s]+)`)
+	controllerRuntimeRegex = regexp.MustCompile(`(?m)^\s*sigs\.k8s\.io/controller-runtime (v[^\s]+)`)
+	minorVersionRegex      = regexp.MustCompile(`^v\d+\.\d+`)
+)
+
+// checkControllerTools checks the version of controller-gen installed by the
+// Makefile generates the markers of the scaffolds, and targets the version of
+// controller-runtime of go.mod: the releases of controller-tools and
+// controller-runtime of the same minor version go together.
+func (c *checker) checkControllerTools() error {
+	makefile, err := ioutil.ReadFile(filepath.Join(c.dir, "Makefile"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	match := controllerGenRegex.FindSubmatch(makefile)
+	if match == nil {
+		c.report("Makefile", fmt.Sprintf("install sigs.k8s.io/controller-tools/cmd/controller-gen@%s in the controller-gen target",
+			ControllerToolsVersion), "the version of controller-gen the Makefile installs is not pinned")
+		return nil
+	}
+	controllerGen := string(match[1])
+	if controllerGen != ControllerToolsVersion {
+		c.report("Makefile", fmt.Sprintf("install sigs.k8s.io/controller-tools/cmd/controller-gen@%s in the controller-gen target, "+
+			"or check the markers of the project are supported by controller-gen %s", ControllerToolsVersion, controllerGen),
+			"the Makefile installs controller-gen %s, the markers of the scaffolds are generated with %s",
+			controllerGen, ControllerToolsVersion)
+	}
+
+	gomod, err := ioutil.ReadFile(filepath.Join(c.dir, "go.mod"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	match = controllerRuntimeRegex.FindSubmatch(gomod)
+	if match == nil {
+		return nil
+	}
+	controllerRuntime := string(match[1])
+	if minor := minorVersionRegex.FindString(controllerGen); minor != minorVersionRegex.FindString(controllerRuntime) {
+		c.report("go.mod", fmt.Sprintf("install a %s release of controller-gen in the Makefile, or require a %s release of "+
+			"sigs.k8s.io/controller-runtime", minorVersionRegex.FindString(controllerRuntime), minor),
+			"controller-runtime %s is used with controller-gen %s, whose generated code targets controller-runtime %s",
+			controllerRuntime, controllerGen, minor)
+	}
+	return nil
+}
+
+// checkRBAC checks the resources the code of the packages uses with the
+// client are granted by +kubebuilder:rbac markers. The markers granting the
+// resources the code does not use are left to kubebuilder alpha verify-rbac.
+func (c *checker) checkRBAC(patterns []string) error {
+	diagnostics, err := rbaclint.Lint(c.dir, patterns...)
+	if err != nil {
+		c.report(strings.Join(patterns, " "), "run go mod download and make generate, so that the packages build",
+			"unable to check the RBAC markers: %v", err)
+		return nil
+	}
+	for _, d := range diagnostics {
+		if strings.Contains(d, "which the package does not use") {
+			continue
+		}
+		// the diagnostics are formatted as file:line:col: message
+		i := strings.Index(d, ": ")
+		if i < 0 {
+			continue
+		}
+		pos, message := d[:i], d[i+2:]
+		if abs, err := filepath.Abs(c.dir); err == nil {
+			if rel, err := filepath.Rel(abs, pos); err == nil && !strings.HasPrefix(rel, "..") {
+				pos = rel
+			}
+		}
+		c.report(filepath.ToSlash(pos), "add a +kubebuilder:rbac marker granting it to the package, "+
+			"e.g. above the Reconcile method, and run make manifests", "%s", message)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doctor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/doctor"
+)
+
+const (
+	project = `version: "2"
+domain: example.com
+repo: example.com/project
+resources:
+- group: crew
+  version: v1
+  kind: Captain
+`
+	types = `package v1
+
+type CaptainSpec struct{}
+
+type Captain struct{}
+`
+	groupVersionInfo = `// +kubebuilder:object:generate=true
+// +groupName=crew.example.com
+package v1
+`
+	main = `package main
+
+import (
+	// +kubebuilder:scaffold:imports
+)
+
+func init() {
+	// +kubebuilder:scaffold:scheme
+}
+
+func main() {
+	// +kubebuilder:scaffold:builder
+	// +kubebuilder:scaffold:webhooktls
+}
+`
+	makefile = `controller-gen:
+	go get sigs.k8s.io/controller-tools/cmd/controller-gen@v0.2.0-beta.2
+`
+	gomod = `module example.com/project
+
+require (
+	sigs.k8s.io/controller-runtime v0.2.0-beta.2
+)
+`
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "in sync",
+			files: map[string]string{
+				"PROJECT":                     project,
+				"api/v1/captain_types.go":     types,
+				"api/v1/groupversion_info.go": groupVersionInfo,
+				"main.go":                     main,
+				"Makefile":                    makefile,
+				"go.mod":                      gomod,
+			},
+		},
+		{
+			name: "missing files",
+			files: map[string]string{
+				"PROJECT": `version: "3"
+domain: example.com
+repo: example.com/project
+resources:
+- group: crew
+  version: v1
+  kind: Captain
+  api:
+    crdVersion: v1beta1
+  controller: true
+  webhooks:
+    defaulting: true
+- group: apps
+  version: v1
+  kind: Deployment
+  controller: true
+`,
+				"main.go": main,
+			},
+			want: []string{
+				"PROJECT: the resource crew/v1, Kind=Captain has no API types in api/v1/captain_types.go",
+				"PROJECT: the resource crew/v1, Kind=Captain has no controller in controllers/captain_controller.go",
+				"PROJECT: the resource crew/v1, Kind=Captain has no webhooks in api/v1/captain_webhook.go",
+				"PROJECT: the resource apps/v1, Kind=Deployment has no controller in controllers/deployment_controller.go",
+			},
+		},
+		{
+			name: "untracked API types",
+			files: map[string]string{
+				"PROJECT":                     project,
+				"api/v1/captain_types.go":     types,
+				"api/v1/firstmate_types.go":   "package v1\n\ntype FirstMate struct{}\n",
+				"api/v1/groupversion_info.go": groupVersionInfo,
+				"main.go":                     main,
+			},
+			want: []string{
+				"api/v1/firstmate_types.go: the API types of crew/v1, Kind=FirstMate are not tracked in PROJECT, the commands ignore them",
+			},
+		},
+		{
+			name: "multigroup",
+			files: map[string]string{
+				"PROJECT":                      project + "multigroup: true\n",
+				"api/crew/v1/captain_types.go": types,
+				"api/ship/v1/frigate_types.go": "package v1\n\ntype Frigate struct{}\n",
+				"main.go":                      main,
+			},
+			want: []string{
+				"api/ship/v1/frigate_types.go: the API types of ship/v1, Kind=Frigate are not tracked in PROJECT, the commands ignore them",
+			},
+		},
+		{
+			name: "missing markers",
+			files: map[string]string{
+				"PROJECT":                 project,
+				"api/v1/captain_types.go": types,
+				"main.go": `package main
+
+import (
+	// +kubebuilder:scaffold:imports
+)
+
+func main() {
+	//+kubebuilder:scaffold:webhooktls
+}
+`,
+				"controllers/suite_test.go": "package controllers\n\n// +kubebuilder:scaffold:imports\n",
+				"config/crd/kustomization.yaml": `resources:
+# +kubebuilder:scaffold:crdkustomizeresource
+patches:
+# +kubebuilder:scaffold:crdkustomizewebhookpatch
+# +kubebuilder:scaffold:crdkustomizecainjectionpatch
+`,
+			},
+			want: []string{
+				"controllers/suite_test.go: the // +kubebuilder:scaffold:scheme marker is missing, " +
+					"the commands can no longer add the registration of the API versions with the scheme",
+				"controllers/suite_test.go: the // +kubebuilder:scaffold:envtestoptions marker is missing, " +
+					"the commands can no longer add the webhooks configured in the test environment",
+				"controllers/suite_test.go: the // +kubebuilder:scaffold:envtestsetup marker is missing, " +
+					"the commands can no longer add the webhooks installed in the test environment",
+				"controllers/suite_test.go: the // +kubebuilder:scaffold:envtestteardown marker is missing, " +
+					"the commands can no longer add the webhooks uninstalled from the test environment",
+				"main.go: the // +kubebuilder:scaffold:scheme marker is missing, " +
+					"the commands can no longer add the registration of the API versions with the scheme",
+				"main.go: the // +kubebuilder:scaffold:builder marker is missing, " +
+					"the commands can no longer add the set up of the controllers and webhooks",
+				"main.go: the // +kubebuilder:scaffold:webhooktls marker is missing, " +
+					"the commands can no longer add the webhooks served with the TLS configuration of the flags",
+			},
+		},
+		{
+			name: "heartbeat marker",
+			files: map[string]string{
+				"PROJECT":                  project,
+				"api/v1/captain_types.go":  types,
+				"main.go":                  main,
+				"controllers/heartbeat.go": "package controllers\n",
+			},
+			want: []string{
+				"main.go: the // +kubebuilder:scaffold:heartbeat marker is missing, " +
+					"the commands can no longer add the registration of the controllers with the heartbeat",
+			},
+		},
+		{
+			name: "controller-gen versions",
+			files: map[string]string{
+				"PROJECT":                 project,
+				"api/v1/captain_types.go": types,
+				"main.go":                 main,
+				"Makefile": `controller-gen:
+	go get sigs.k8s.io/controller-tools/cmd/controller-gen@v0.3.0
+`,
+				"go.mod": gomod,
+			},
+			want: []string{
+				"Makefile: the Makefile installs controller-gen v0.3.0, the markers of the scaffolds are generated with v0.2.0-beta.2",
+				"go.mod: controller-runtime v0.2.0-beta.2 is used with controller-gen v0.3.0, " +
+					"whose generated code targets controller-runtime v0.3",
+			},
+		},
+		{
+			name: "unpinned controller-gen",
+			files: map[string]string{
+				"PROJECT":                 project,
+				"api/v1/captain_types.go": types,
+				"main.go":                 main,
+				"Makefile":                "controller-gen:\n\tgo get sigs.k8s.io/controller-tools/cmd/controller-gen\n",
+			},
+			want: []string{
+				"Makefile: the version of controller-gen the Makefile installs is not pinned",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "doctor")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			for path, content := range test.files {
+				path = filepath.Join(dir, path)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			problems, err := doctor.Check(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range problems {
+				if p.Fix == "" {
+					t.Errorf("problem %q has no fix", p.Message)
+				}
+				got = append(got, p.File+": "+p.Message)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected problems %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestCheckVersion1(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "PROJECT"), []byte("version: \"1\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doctor.Check(dir); err == nil {
+		t.Error("expected an error checking a project of version 1")
+	}
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package drift compares a project with the pristine scaffolding of the
+// commands recorded in its history, as scaffolded by the current templates.
+//
+// The commands of .kubebuilder/history.yaml are run again, with the flags they
+// were recorded with, in a directory of the same name as the project's, so
+// that the names defaulted to it are the same. The files of the project are
+// then compared with the pristine ones: the files the user modified, deleted
+// and added are reported, ignoring the files generated by make and go, which
+// are not scaffolded. The boilerplate of the project heads the pristine Go
+// files, for the files differing only by it, e.g. by the year of its
+// copyright, not to be reported.
+package drift
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/diff"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/history"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+)
+
+// Kind is the kind of drift of a file from its scaffolding.
+type Kind string
+
+const (
+	// Modified is a scaffolded file the user modified
+	Modified Kind = "modified"
+	// Deleted is a scaffolded file the user deleted
+	Deleted Kind = "deleted"
+	// Added is a file the user added, which is not scaffolded
+	Added Kind = "added"
+)
+
+// Change is the drift of a file of the project from its scaffolding.
+type Change struct {
+	// File is the file of the project, relative to the project directory
+	File string
+	// Kind is the kind of drift of the file
+	Kind Kind
+	// Diff is the unified diff from the pristine file to the one of the
+	// project, set for the modified files
+	Diff string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%-9s %s", c.Kind, c.File)
+}
+
+// generated are the files and directories generated from the project by make
+// and go, rather than scaffolded, which are not compared.
+var generated = []string{
+	".git", "bin", "testbin", "vendor", "go.mod", "go.sum", "cover.out",
+	filepath.Join("config", "crd", "bases"),
+	filepath.Join("config", "rbac", "role.yaml"),
+	filepath.Join("config", "webhook", "manifests.yaml"),
+	history.Path,
+}
+
+// isGenerated returns whether the file at path, relative to the project
+// directory, is generated rather than scaffolded.
+func isGenerated(path string) bool {
+	for _, g := range generated {
+		if path == g {
+			return true
+		}
+	}
+	return strings.HasPrefix(filepath.Base(path), "zz_generated.")
+}
+
+// Pristine scaffolds the pristine project of the commands recorded in the
+// history of the project in projectDir, running them with the kubebuilder
+// binary at path in a directory of parent named after projectDir. It returns
+// the directory of the pristine project.
+func Pristine(kubebuilder, projectDir, parent string) (string, error) {
+	p, err := scaffold.LoadProjectFile(filepath.Join(projectDir, "PROJECT"))
+	if err != nil {
+		return "", fmt.Errorf("unable to read the PROJECT file: %v", err)
+	}
+	if p.Version == project.Version1 {
+		return "", fmt.Errorf("only the projects of version 2 and above are compared, the project is of version %s", p.Version)
+	}
+	h, err := history.Load(filepath.Join(projectDir, history.Path))
+	if err != nil {
+		return "", err
+	}
+	if len(h.Entries) == 0 {
+		return "", fmt.Errorf("no command recorded in %s to scaffold the project with", history.Path)
+	}
+
+	abs, err := filepath.Abs(projectDir)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(parent, filepath.Base(abs))
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", err
+	}
+	for _, e := range h.Entries {
+		args, err := replayArgs(e, &p)
+		if err != nil {
+			return "", err
+		}
+		c := exec.Command(kubebuilder, args...) // #nosec
+		c.Dir = dir
+		if out, err := c.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("error running %s: %v\n%s", e.Command, err, out)
+		}
+	}
+	return dir, nil
+}
+
+// replayArgs returns the arguments running the command of the entry again,
+// without prompting the user nor running make or fetching the dependencies.
+func replayArgs(e history.Entry, p *input.ProjectFile) ([]string, error) {
+	words := strings.Fields(e.Command)
+	if len(words) < 2 {
+		return nil, fmt.Errorf("invalid command %q recorded in %s", e.Command, history.Path)
+	}
+	flags := map[string]string{}
+	for name, value := range e.Flags {
+		flags[name] = value
+	}
+	// the pristine project is not committed
+	delete(flags, "git-commit")
+
+	subcommand := strings.Join(words[1:], " ")
+	switch subcommand {
+	case "init":
+		if flags["interactive"] == "true" {
+			return nil, fmt.Errorf("the answers of kubebuilder init --interactive are not recorded, run it with its flags")
+		}
+		flags["fetch-deps"] = "false"
+		flags["go-work"] = "false"
+		// the repo is otherwise read from the go.mod of the project
+		if _, found := flags["repo"]; !found {
+			flags["repo"] = p.Repo
+		}
+	case "create api":
+		flags["make"] = "false"
+		// the answers of the prompts are the files the command created
+		if _, found := flags["resource"]; !found {
+			flags["resource"] = strconv.FormatBool(created(e, "_types.go"))
+		}
+		if _, found := flags["controller"]; !found {
+			flags["controller"] = strconv.FormatBool(created(e, "_controller.go"))
+		}
+	}
+
+	args := append(words[1:], e.Args...)
+	names := []string{}
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, fmt.Sprintf("--%s=%s", name, flags[name]))
+	}
+	return args, nil
+}
+
+// created returns whether the entry created a file with the given suffix.
+func created(e history.Entry, suffix string) bool {
+	for _, f := range e.FilesCreated {
+		if strings.HasSuffix(f, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare compares the project in projectDir with the pristine project in
+// pristineDir, returning the changes sorted by file.
+func Compare(projectDir, pristineDir string) ([]Change, error) {
+	files, err := listFiles(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	pristineFiles, err := listFiles(pristineDir)
+	if err != nil {
+		return nil, err
+	}
+	boilerplate, pristineBoilerplate, err := boilerplates(projectDir, pristineDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for path := range pristineFiles {
+		if !files[path] {
+			changes = append(changes, Change{File: path, Kind: Deleted})
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(projectDir, path)) // nolint: gosec
+		if err != nil {
+			return nil, err
+		}
+		pristine, err := ioutil.ReadFile(filepath.Join(pristineDir, path)) // nolint: gosec
+		if err != nil {
+			return nil, err
+		}
+		if len(pristineBoilerplate) > 0 {
+			pristine = bytes.Replace(pristine, pristineBoilerplate, boilerplate, -1)
+		}
+		if !bytes.Equal(content, pristine) {
+			changes = append(changes, Change{File: path, Kind: Modified,
+				Diff: diff.Unified(filepath.ToSlash(path), filepath.ToSlash(path), pristine, content)})
+		}
+	}
+	for path := range files {
+		if !pristineFiles[path] {
+			changes = append(changes, Change{File: path, Kind: Added})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].File < changes[j].File })
+	return changes, nil
+}
+
+// boilerplates returns the boilerplates of the project and of the pristine
+// project, without their surrounding spaces as they head the Go files. They are
+// empty when they are the same.
+func boilerplates(projectDir, pristineDir string) ([]byte, []byte, error) {
+	p, err := scaffold.LoadProjectFile(filepath.Join(projectDir, "PROJECT"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read the PROJECT file: %v", err)
+	}
+	path := p.Boilerplate
+	if path == "" {
+		path = filepath.Join("hack", "boilerplate.go.txt")
+	}
+	boilerplate, err := ioutil.ReadFile(filepath.Join(projectDir, path)) // nolint: gosec
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	pristine, err := ioutil.ReadFile(filepath.Join(pristineDir, path)) // nolint: gosec
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	boilerplate, pristine = bytes.TrimSpace(boilerplate), bytes.TrimSpace(pristine)
+	if len(boilerplate) == 0 || bytes.Equal(boilerplate, pristine) {
+		return nil, nil, nil
+	}
+	return boilerplate, pristine, nil
+}
+
+// listFiles returns the files of dir which are not generated, relative to dir.
+func listFiles(dir string) (map[string]bool, error) {
+	files := map[string]bool{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if isGenerated(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			files[rel] = true
+		}
+		return nil
+	})
+	return files, err
+}
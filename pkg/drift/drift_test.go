@@ -0,0 +1,156 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/history"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+const projectFile = `version: "2"
+domain: example.com
+repo: example.com/project
+`
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drift")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	projectDir, pristineDir := filepath.Join(dir, "project"), filepath.Join(dir, "pristine")
+
+	writeFiles(t, pristineDir, map[string]string{
+		"PROJECT":                             projectFile,
+		"hack/boilerplate.go.txt":             "// Copyright 2026\n",
+		"main.go":                             "// Copyright 2026\n\npackage main\n",
+		"controllers/captain_controller.go":   "// Copyright 2026\n\npackage controllers\n",
+		"config/samples/crew_v1_captain.yaml": "kind: Captain\n",
+		"go.mod":                              "module example.com/project\n",
+	})
+	writeFiles(t, projectDir, map[string]string{
+		"PROJECT":                           projectFile,
+		"hack/boilerplate.go.txt":           "// Copyright 2019\n",
+		"main.go":                           "// Copyright 2019\n\npackage main\n",
+		"controllers/captain_controller.go": "// Copyright 2019\n\npackage controllers\n\nfunc reconcile() {}\n",
+		"controllers/helpers.go":            "// Copyright 2019\n\npackage controllers\n",
+		"go.mod":                            "module example.com/project\n\nrequire k8s.io/api v0.0.0\n",
+		"go.sum":                            "k8s.io/api v0.0.0 h1:\n",
+		"api/v1/zz_generated.deepcopy.go":   "package v1\n",
+		"config/crd/bases/crew.example.com_captains.yaml": "kind: CustomResourceDefinition\n",
+		history.Path: "entries: []\n",
+	})
+
+	changes, err := Compare(projectDir, pristineDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"deleted   config/samples/crew_v1_captain.yaml",
+		"modified  controllers/captain_controller.go",
+		"added     controllers/helpers.go",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected changes %q, got %q", want, got)
+	}
+	wantDiff := `--- a/controllers/captain_controller.go
++++ b/controllers/captain_controller.go
+@@ -1,3 +1,5 @@
+ // Copyright 2019
+ 
+ package controllers
++
++func reconcile() {}
+`
+	if len(changes) == 3 && changes[1].Diff != wantDiff {
+		t.Errorf("expected diff:\n%s\ngot:\n%s", wantDiff, changes[1].Diff)
+	}
+}
+
+func TestReplayArgs(t *testing.T) {
+	p := &input.ProjectFile{Repo: "example.com/project"}
+	tests := []struct {
+		name  string
+		entry history.Entry
+		want  []string
+	}{
+		{
+			name: "init",
+			entry: history.Entry{
+				Command: "kubebuilder init",
+				Flags:   map[string]string{"domain": "example.com", "git-commit": "true"},
+			},
+			want: []string{"init", "--domain=example.com", "--fetch-deps=false", "--go-work=false",
+				"--repo=example.com/project"},
+		},
+		{
+			name: "create api answering the prompts",
+			entry: history.Entry{
+				Command:      "kubebuilder create api",
+				Flags:        map[string]string{"group": "crew", "version": "v1", "kind": "Captain"},
+				FilesCreated: []string{"api/v1/captain_types.go"},
+			},
+			want: []string{"create", "api", "--controller=false", "--group=crew", "--kind=Captain",
+				"--make=false", "--resource=true", "--version=v1"},
+		},
+		{
+			name: "alpha samples",
+			entry: history.Entry{
+				Command: "kubebuilder alpha samples",
+			},
+			want: []string{"alpha", "samples"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := replayArgs(test.entry, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected args %q, got %q", test.want, got)
+			}
+		})
+	}
+
+	_, err := replayArgs(history.Entry{Command: "kubebuilder init", Flags: map[string]string{"interactive": "true"}}, p)
+	if err == nil {
+		t.Error("expected an error replaying an interactive init")
+	}
+}
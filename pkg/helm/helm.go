@@ -0,0 +1,215 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package helm renders the Helm chart of a project from the manifests built
+// by kustomize from its config, so that the chart is generated from the same
+// source as the kustomize deployment rather than maintained by hand.
+//
+// The CRDs go to the crds directory of the chart, installed by Helm before
+// its templates and never templated. The other objects go to a single
+// generated template, where the namespace of the project is replaced by the
+// namespace of the release, and the image and the resources of the manager
+// container by the values of the chart. The Namespace object is dropped, the
+// namespace being created by helm install --create-namespace.
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/kubebuilder/pkg/internal/mapslice"
+)
+
+const (
+	// ManagerContainer is the name of the container of the manager in the
+	// Deployment of the project
+	ManagerContainer = "manager"
+
+	releaseNamespace = "{{ .Release.Namespace }}"
+	managerImage     = "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+
+	// resourcesPlaceholder stands for the resources of the manager container
+	// until the template is marshalled, toYaml not being a YAML scalar
+	resourcesPlaceholder = "kubebuilder-helm-resources"
+)
+
+// TemplatesHeader prefixes the template generated from the manifests.
+const TemplatesHeader = `# Code generated by kubebuilder alpha helm from config/default. DO NOT EDIT.
+# Run make helm to update it, and add your own templates next to it.
+`
+
+var resourcesLine = regexp.MustCompile(`(?m)^([ -]*)resources: ` + resourcesPlaceholder + `$`)
+
+// escaper escapes the template delimiters the manifests contain, e.g. the
+// annotations of the prometheus alerts, so that Helm renders them verbatim.
+var escaper = strings.NewReplacer("{{", `{{ "{{" }}`, "}}", `{{ "}}" }}`)
+
+// Chart is the content of a chart rendered from manifests.
+type Chart struct {
+	// Templates is the template of the objects other than the CRDs
+	Templates []byte
+
+	// CRDs are the CRDs, by file name in the crds directory
+	CRDs map[string][]byte
+
+	// Values are the default values of the chart, the image and resources of
+	// the manager container in the manifests
+	Values []byte
+}
+
+// Render renders the chart of the manifests built by kustomize.
+func Render(manifests []byte) (*Chart, error) {
+	chart := &Chart{CRDs: map[string][]byte{}}
+
+	var docs []yaml.MapSlice
+	namespace := ""
+	dec := yaml.NewDecoder(bytes.NewReader(manifests))
+	for {
+		var doc yaml.MapSlice
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error parsing the manifests: %v", err)
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		switch kind, _ := mapslice.Get(doc, "kind").(string); kind {
+		case "CustomResourceDefinition":
+			content, err := yaml.Marshal(doc)
+			if err != nil {
+				return nil, err
+			}
+			name, _ := mapslice.Get(metadata(doc), "name").(string)
+			chart.CRDs[name+".yaml"] = content
+		case "Namespace":
+			namespace, _ = mapslice.Get(metadata(doc), "name").(string)
+		default:
+			docs = append(docs, doc)
+		}
+	}
+
+	values := yaml.MapSlice{}
+	templates := &bytes.Buffer{}
+	templates.WriteString(TemplatesHeader)
+	for _, doc := range docs {
+		doc = templatize(doc, namespace).(yaml.MapSlice)
+		if image, resources, found := manager(doc); found {
+			repository, tag := splitImage(image)
+			values = yaml.MapSlice{
+				{Key: "image", Value: yaml.MapSlice{{Key: "repository", Value: repository}, {Key: "tag", Value: tag}}},
+				{Key: "resources", Value: resources},
+			}
+		}
+		content, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		templates.WriteString("---\n")
+		templates.Write(content)
+	}
+	chart.Templates = resourcesLine.ReplaceAllFunc(templates.Bytes(), func(line []byte) []byte {
+		prefix := resourcesLine.FindSubmatch(line)[1]
+		indent := len(prefix) + 2
+		return []byte(fmt.Sprintf("%sresources:\n%s{{- toYaml .Values.resources | nindent %d }}",
+			prefix, strings.Repeat(" ", indent), indent))
+	})
+
+	content, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	chart.Values = append([]byte("# Values of the chart, initialized from config/default by make helm.\n"), content...)
+	return chart, nil
+}
+
+// templatize escapes the template delimiters of the strings of the value and
+// replaces the namespace in them, e.g. in the namespace of an object, the
+// name of a service or the CA injection annotation of cert-manager, by the
+// namespace of the release.
+func templatize(value interface{}, namespace string) interface{} {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		for i := range v {
+			v[i].Value = templatize(v[i].Value, namespace)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = templatize(v[i], namespace)
+		}
+		return v
+	case string:
+		v = escaper.Replace(v)
+		if namespace == "" {
+			return v
+		}
+		if v == namespace {
+			return releaseNamespace
+		}
+		if strings.HasPrefix(v, namespace+"/") {
+			v = releaseNamespace + strings.TrimPrefix(v, namespace)
+		}
+		return strings.Replace(v, "."+namespace+".", "."+releaseNamespace+".", -1)
+	default:
+		return v
+	}
+}
+
+// manager replaces the image and the resources of the manager container of a
+// Deployment by the values of the chart, returning the ones it replaced.
+func manager(doc yaml.MapSlice) (image string, resources interface{}, found bool) {
+	if kind, _ := mapslice.Get(doc, "kind").(string); kind != "Deployment" {
+		return "", nil, false
+	}
+	spec, _ := mapslice.Get(doc, "spec").(yaml.MapSlice)
+	template, _ := mapslice.Get(spec, "template").(yaml.MapSlice)
+	podSpec, _ := mapslice.Get(template, "spec").(yaml.MapSlice)
+	containers, _ := mapslice.Get(podSpec, "containers").([]interface{})
+	for i, c := range containers {
+		container, _ := c.(yaml.MapSlice)
+		if name, _ := mapslice.Get(container, "name").(string); name != ManagerContainer {
+			continue
+		}
+		image, _ = mapslice.Get(container, "image").(string)
+		resources = mapslice.Get(container, "resources")
+		if resources == nil {
+			resources = yaml.MapSlice{}
+		}
+		container = mapslice.Set(container, "image", managerImage)
+		containers[i] = mapslice.Set(container, "resources", resourcesPlaceholder)
+		return image, resources, true
+	}
+	return "", nil, false
+}
+
+// splitImage splits an image into its repository and its tag, latest when
+// the image has none.
+func splitImage(image string) (repository, tag string) {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+func metadata(doc yaml.MapSlice) yaml.MapSlice {
+	m, _ := mapslice.Get(doc, "metadata").(yaml.MapSlice)
+	return m
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm_test
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/helm"
+)
+
+const manifests = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: proj-system
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    cert-manager.io/inject-ca-from: proj-system/proj-serving-cert
+  name: captains.crew.example.com
+spec:
+  group: crew.example.com
+---
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: proj-alerts
+  namespace: proj-system
+spec:
+  groups:
+  - rules:
+    - annotations:
+        summary: '{{ $labels.pod }} is down'
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: proj-controller-manager
+  namespace: proj-system
+spec:
+  template:
+    spec:
+      containers:
+      - args:
+        - --secure-listen-address=0.0.0.0:8443
+        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.4.0
+        name: kube-rbac-proxy
+      - args:
+        - --webhook-service=proj-webhook-service.proj-system.svc
+        image: registry.example.com:5000/proj:v1
+        name: manager
+        resources:
+          limits:
+            cpu: 100m
+`
+
+func TestRender(t *testing.T) {
+	chart, err := helm.Render([]byte(manifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(chart.CRDs) != 1 {
+		t.Fatalf("got CRDs %v, want captains.crew.example.com.yaml", chart.CRDs)
+	}
+	crd := string(chart.CRDs["captains.crew.example.com.yaml"])
+	if !strings.Contains(crd, "cert-manager.io/inject-ca-from: proj-system/proj-serving-cert") {
+		t.Errorf("the CRD is templated:\n%s", crd)
+	}
+
+	templates := string(chart.Templates)
+	if !strings.HasPrefix(templates, helm.TemplatesHeader) {
+		t.Errorf("the templates miss the header:\n%s", templates)
+	}
+	for _, want := range []string{
+		"namespace: '{{ .Release.Namespace }}'",
+		`summary: '{{ "{{" }} $labels.pod {{ "}}" }} is down'`,
+		"--webhook-service=proj-webhook-service.{{ .Release.Namespace }}.svc",
+		"image: gcr.io/kubebuilder/kube-rbac-proxy:v0.4.0",
+		"image: '{{ .Values.image.repository }}:{{ .Values.image.tag }}'",
+		"        resources:\n          {{- toYaml .Values.resources | nindent 10 }}",
+	} {
+		if !strings.Contains(templates, want) {
+			t.Errorf("the templates miss %q:\n%s", want, templates)
+		}
+	}
+	for _, unwanted := range []string{"kind: Namespace", "proj-system", "kind: CustomResourceDefinition"} {
+		if strings.Contains(templates, unwanted) {
+			t.Errorf("the templates contain %q:\n%s", unwanted, templates)
+		}
+	}
+
+	values := string(chart.Values)
+	for _, want := range []string{
+		"image:\n  repository: registry.example.com:5000/proj\n  tag: v1\n",
+		"resources:\n  limits:\n    cpu: 100m\n",
+	} {
+		if !strings.Contains(values, want) {
+			t.Errorf("the values miss %q:\n%s", want, values)
+		}
+	}
+}
+
+func TestRenderWithoutTag(t *testing.T) {
+	chart, err := helm.Render([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - image: controller
+        name: manager
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "image:\n  repository: controller\n  tag: latest\nresources: {}\n"; !strings.Contains(string(chart.Values), want) {
+		t.Errorf("got values\n%s\nwant\n%s", chart.Values, want)
+	}
+	if !strings.Contains(string(chart.Templates), "toYaml .Values.resources") {
+		t.Errorf("the templates miss the resources:\n%s", chart.Templates)
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kustomization reads the kustomization trees of the projects the way
+// kustomize build does, for the packages building or checking them without
+// kustomize: the kustomization files, the objects of their YAML files, the
+// kustomizations including themselves and the objects the vars and the
+// patches select.
+package kustomization
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/kubebuilder/pkg/internal/mapslice"
+)
+
+// Files are the names kustomize looks up a kustomization with.
+var Files = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// Kustomization is the subset of a kustomization.yaml read by the packages.
+// The other fields known to kustomize are only decoded to be allowed.
+type Kustomization struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+
+	Namespace  string `yaml:"namespace"`
+	NamePrefix string `yaml:"namePrefix"`
+	NameSuffix string `yaml:"nameSuffix"`
+
+	Resources             []string        `yaml:"resources"`
+	Bases                 []string        `yaml:"bases"`
+	Components            []string        `yaml:"components"`
+	Crds                  []string        `yaml:"crds"`
+	Configurations        []string        `yaml:"configurations"`
+	Generators            []string        `yaml:"generators"`
+	Transformers          []string        `yaml:"transformers"`
+	Patches               []interface{}   `yaml:"patches"`
+	PatchesStrategicMerge []string        `yaml:"patchesStrategicMerge"`
+	PatchesJSON6902       []JSON6902Patch `yaml:"patchesJson6902"`
+	Images                []Image         `yaml:"images"`
+	Vars                  []Var           `yaml:"vars"`
+	ConfigMapGenerator    []Generator     `yaml:"configMapGenerator"`
+	SecretGenerator       []Generator     `yaml:"secretGenerator"`
+
+	CommonLabels      interface{} `yaml:"commonLabels"`
+	CommonAnnotations interface{} `yaml:"commonAnnotations"`
+	GeneratorOptions  interface{} `yaml:"generatorOptions"`
+	Replicas          interface{} `yaml:"replicas"`
+	Inventory         interface{} `yaml:"inventory"`
+
+	// Fields are the fields set by the file, in their order
+	Fields []string `yaml:"-"`
+}
+
+// Target selects the object a patch or a var applies to.
+type Target struct {
+	Group     string `yaml:"group"`
+	Version   string `yaml:"version"`
+	Kind      string `yaml:"kind"`
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+
+	LabelSelector      string `yaml:"labelSelector"`
+	AnnotationSelector string `yaml:"annotationSelector"`
+}
+
+// Selects returns whether t selects the object of the given group and kind, by
+// the name of its definition or the one it is given by the kustomizations.
+func (t Target) Selects(group, kind, name, originalName string) bool {
+	if t.Kind != kind || (t.Name != name && t.Name != originalName) {
+		return false
+	}
+	return t.Group == "" || t.Group == group
+}
+
+// JSON6902Patch is an entry of patchesJson6902.
+type JSON6902Patch struct {
+	Target *Target `yaml:"target"`
+	Path   string  `yaml:"path"`
+}
+
+// Image is an entry of images, updating the images of the containers of the
+// given name.
+type Image struct {
+	Name    string `yaml:"name"`
+	NewName string `yaml:"newName"`
+	NewTag  string `yaml:"newTag"`
+	Digest  string `yaml:"digest"`
+}
+
+// Var is a var, whose $(NAME) references are replaced by the field of the
+// object it refers to.
+type Var struct {
+	Name     string `yaml:"name"`
+	ObjRef   Target `yaml:"objref"`
+	FieldRef struct {
+		FieldPath string `yaml:"fieldpath"`
+	} `yaml:"fieldref"`
+}
+
+// Generator is an entry of configMapGenerator or secretGenerator.
+type Generator struct {
+	Name      string      `yaml:"name"`
+	Namespace string      `yaml:"namespace"`
+	Behavior  string      `yaml:"behavior"`
+	Files     []string    `yaml:"files"`
+	Env       string      `yaml:"env"`
+	Envs      []string    `yaml:"envs"`
+	Literals  []string    `yaml:"literals"`
+	Type      string      `yaml:"type"`
+	Options   interface{} `yaml:"options"`
+}
+
+// Find returns the path of the kustomization of dir, or "" if it has none.
+func Find(dir string) string {
+	for _, name := range Files {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Read reads the kustomization at path, failing on the fields unknown to
+// kustomize.
+func Read(path string) (*Kustomization, error) {
+	data, err := ioutil.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return nil, err
+	}
+	k := &Kustomization{}
+	if err := yaml.UnmarshalStrict(data, k); err != nil {
+		return nil, fmt.Errorf("%s", YAMLError(err))
+	}
+	fields := yaml.MapSlice{}
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("%s", YAMLError(err))
+	}
+	for _, field := range fields {
+		k.Fields = append(k.Fields, fmt.Sprint(field.Key))
+	}
+	return k, nil
+}
+
+// typeRegex matches the Go types the YAML decoding errors are reported with.
+var typeRegex = regexp.MustCompile(` in type [^ ]+| into [a-z]+\.[^ ]+`)
+
+// YAMLError returns the message of a YAML decoding error, without the Go types
+// of the packages.
+func YAMLError(err error) string {
+	return typeRegex.ReplaceAllString(err.Error(), "")
+}
+
+// IsRemote returns whether the entry of a kustomization is a remote base.
+func IsRemote(entry string) bool {
+	return strings.Contains(entry, "://") || strings.HasPrefix(entry, "github.com/") ||
+		strings.HasPrefix(entry, "git@")
+}
+
+// Stack are the kustomizations being built, the ones including a kustomization
+// being built while it is.
+type Stack map[string]bool
+
+// Push adds the kustomization of dir to the stack, returning false if it is
+// already being built, i.e. it includes itself.
+func (s Stack) Push(dir string) bool {
+	key := stackKey(dir)
+	if s[key] {
+		return false
+	}
+	s[key] = true
+	return true
+}
+
+// Pop removes the kustomization of dir from the stack, once built.
+func (s Stack) Pop(dir string) {
+	delete(s, stackKey(dir))
+}
+
+func stackKey(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return filepath.Clean(dir)
+	}
+	return abs
+}
+
+// Object is an object defined by a YAML file of a kustomization.
+type Object struct {
+	Doc yaml.MapSlice
+	// File is the file defining the object
+	File string
+}
+
+// Kind returns the kind of the object.
+func (o *Object) Kind() string {
+	kind, _ := mapslice.Get(o.Doc, "kind").(string)
+	return kind
+}
+
+// Group returns the API group of the object, "" for the core group.
+func (o *Object) Group() string {
+	apiVersion, _ := mapslice.Get(o.Doc, "apiVersion").(string)
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		return apiVersion[:i]
+	}
+	return ""
+}
+
+// Name returns the name of the object.
+func (o *Object) Name() string {
+	name, _ := mapslice.Get(o.Metadata(), "name").(string)
+	return name
+}
+
+// Namespace returns the namespace of the object.
+func (o *Object) Namespace() string {
+	namespace, _ := mapslice.Get(o.Metadata(), "namespace").(string)
+	return namespace
+}
+
+// Metadata returns the metadata of the object.
+func (o *Object) Metadata() yaml.MapSlice {
+	m, _ := mapslice.Get(o.Doc, "metadata").(yaml.MapSlice)
+	return m
+}
+
+// ReadObjects reads the objects defined in the YAML file at path, skipping the
+// empty documents. The objects without a kind or a name are returned to be
+// reported by the callers.
+func ReadObjects(path string) ([]*Object, error) {
+	data, err := ioutil.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return nil, err
+	}
+	var objects []*Object
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.MapSlice
+		if err := dec.Decode(&doc); err == io.EOF {
+			return objects, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%s", YAMLError(err))
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		objects = append(objects, &Object{Doc: doc, File: path})
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mapslice reads and edits the YAML documents decoded as ordered maps,
+// which are written back with the keys in their original order.
+package mapslice
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Get returns the value of the key, or nil if it is not set.
+func Get(m yaml.MapSlice, key string) interface{} {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+// Set sets the value of an existing key, or appends it.
+func Set(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i := range m {
+		if m[i].Key == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
+
+// Delete returns a copy of m without the key.
+func Delete(m yaml.MapSlice, key string) yaml.MapSlice {
+	var result yaml.MapSlice
+	for _, item := range m {
+		if item.Key != key {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// JSONValue converts the maps of a value parsed from YAML to maps with string
+// keys, which encoding/json marshals.
+func JSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for key, item := range v {
+			m[fmt.Sprint(key)] = JSONValue(item)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = JSONValue(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,447 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kustomizelint checks that a kustomization builds, catching the
+// overlays broken by scaffolding or by hand before kustomize build runs at
+// make deploy time.
+//
+// kustomize itself is not a dependency of kubebuilder: the package resolves
+// the kustomization tree the way kustomize build does and reports the errors
+// it would fail with, for the fields of kustomization.yaml the scaffolded
+// projects rely on. The files referenced by a kustomization must exist, the
+// resources must be defined once, the patches and vars must target one of the
+// resources, and the fields must be known to kustomize. The files generated by
+// make manifests may be missing, the patches and vars which could target the
+// resources they define are then not checked.
+package kustomizelint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/kubebuilder/pkg/internal/kustomization"
+)
+
+type variable struct {
+	kustomization.Var
+
+	// file is the kustomization defining the var
+	file string
+}
+
+// resource is an object of the build output.
+type resource struct {
+	group, kind string
+	// name and namespace are set by the kustomizations the object went
+	// through, originalName is the one of its definition
+	name, originalName, namespace string
+	// file is the file defining the object
+	file string
+}
+
+func (r *resource) String() string {
+	return fmt.Sprintf("%s %s", r.kind, r.name)
+}
+
+// matches returns whether the object is selected by t.
+func (r *resource) matches(t kustomization.Target) bool {
+	return t.Selects(r.group, r.kind, r.name, r.originalName)
+}
+
+// result is the build output of a kustomization.
+type result struct {
+	resources []*resource
+	vars      []variable
+	// incomplete is set when some resources are not known, being defined by
+	// a missing generated file or a remote base
+	incomplete bool
+}
+
+type linter struct {
+	generated   []string
+	diagnostics []string
+	// building are the kustomizations being built, to detect cycles
+	building kustomization.Stack
+}
+
+// Lint checks that the kustomization in dir builds, returning the errors
+// kustomize build would fail with. The files matching the generated glob
+// patterns are allowed to be missing.
+func Lint(dir string, generated ...string) ([]string, error) {
+	for _, pattern := range generated {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+	l := &linter{generated: generated, building: kustomization.Stack{}}
+	res := l.build(filepath.Clean(dir), nil)
+	if res != nil && !res.incomplete {
+		// vars are resolved against the output of the whole build
+		for _, v := range res.vars {
+			if res.find(v.ObjRef) == nil {
+				l.reportf(v.file, "var %s refers to %s %s, which is not one of the resources",
+					v.Name, v.ObjRef.Kind, v.ObjRef.Name)
+			}
+		}
+	}
+	return l.diagnostics, nil
+}
+
+func (l *linter) reportf(path, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+// isGenerated returns whether the file at path is generated by another tool.
+func (l *linter) isGenerated(path string) bool {
+	for _, pattern := range l.generated {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// build builds the kustomization in dir, on top of the output of the
+// kustomization including it as a component if any, returning nil if it can't
+// be read.
+func (l *linter) build(dir string, parent *result) *result {
+	path := kustomization.Find(dir)
+	if path == "" {
+		l.reportf(dir, "no kustomization.yaml in the directory")
+		return nil
+	}
+	if !l.building.Push(dir) {
+		l.reportf(path, "the kustomization includes itself")
+		return nil
+	}
+	defer l.building.Pop(dir)
+
+	k, err := kustomization.Read(path)
+	if err != nil {
+		l.reportf(path, "%v", err)
+		return nil
+	}
+
+	res := &result{}
+	if parent != nil {
+		res.resources = append(res.resources, parent.resources...)
+		res.vars = append(res.vars, parent.vars...)
+		res.incomplete = parent.incomplete
+	}
+	for _, entry := range k.Bases {
+		l.addDirectory(res, path, dir, entry)
+	}
+	for _, entry := range k.Resources {
+		l.addResources(res, path, dir, entry)
+	}
+	for _, entry := range k.Components {
+		l.addComponent(res, path, dir, entry)
+	}
+	for _, entries := range [][]string{k.Crds, k.Configurations, k.Generators, k.Transformers} {
+		for _, entry := range entries {
+			l.checkFile(res, path, dir, entry)
+		}
+	}
+	for _, g := range k.ConfigMapGenerator {
+		l.addGenerated(res, path, dir, "ConfigMap", g)
+	}
+	for _, g := range k.SecretGenerator {
+		l.addGenerated(res, path, dir, "Secret", g)
+	}
+
+	for _, entry := range k.PatchesStrategicMerge {
+		l.checkStrategicMergePatch(res, path, dir, entry, nil)
+	}
+	for _, p := range k.Patches {
+		switch p := p.(type) {
+		case string:
+			l.checkStrategicMergePatch(res, path, dir, p, nil)
+		default:
+			l.checkPatch(res, path, dir, p)
+		}
+	}
+	for _, p := range k.PatchesJSON6902 {
+		l.checkJSON6902Patch(res, path, dir, p)
+	}
+
+	for _, v := range k.Vars {
+		for _, existing := range res.vars {
+			if existing.Name == v.Name {
+				l.reportf(path, "var %s is defined more than once", v.Name)
+			}
+		}
+		res.vars = append(res.vars, variable{Var: v, file: path})
+	}
+
+	for _, r := range res.resources {
+		if k.Namespace != "" {
+			r.namespace = k.Namespace
+		}
+		r.name = k.NamePrefix + r.name + k.NameSuffix
+	}
+	return res
+}
+
+// resolve returns the path of entry of the kustomization in dir, whether it
+// exists, and if so whether it is a directory.
+func (l *linter) resolve(res *result, kpath, dir, entry string) (string, bool, bool) {
+	path := filepath.Join(dir, entry)
+	info, err := os.Stat(path)
+	if err == nil {
+		return path, true, info.IsDir()
+	}
+	// the objects the file would define are unknown
+	res.incomplete = true
+	if !l.isGenerated(path) {
+		l.reportf(kpath, "%s does not exist", entry)
+	}
+	return path, false, false
+}
+
+func (l *linter) checkFile(res *result, kpath, dir, entry string) {
+	l.resolve(res, kpath, dir, entry)
+}
+
+// addDirectory adds the output of the kustomization in the directory entry.
+func (l *linter) addDirectory(res *result, kpath, dir, entry string) {
+	if kustomization.IsRemote(entry) {
+		res.incomplete = true
+		return
+	}
+	path, exists, isDir := l.resolve(res, kpath, dir, entry)
+	if !exists {
+		return
+	}
+	if !isDir {
+		l.reportf(kpath, "base %s is not a directory", entry)
+		return
+	}
+	base := l.build(path, nil)
+	if base == nil {
+		// the errors of the base are reported, not the ones they cause
+		res.incomplete = true
+		return
+	}
+	res.incomplete = res.incomplete || base.incomplete
+	res.vars = append(res.vars, base.vars...)
+	for _, r := range base.resources {
+		l.add(res, kpath, r)
+	}
+}
+
+// addComponent builds the component in the directory entry on top of the
+// output, whose objects it may patch.
+func (l *linter) addComponent(res *result, kpath, dir, entry string) {
+	path, exists, isDir := l.resolve(res, kpath, dir, entry)
+	if !exists {
+		return
+	}
+	if !isDir {
+		l.reportf(kpath, "component %s is not a directory", entry)
+		return
+	}
+	component := l.build(path, res)
+	if component == nil {
+		res.incomplete = true
+		return
+	}
+	*res = *component
+}
+
+// addResources adds the objects of the file or kustomization entry.
+func (l *linter) addResources(res *result, kpath, dir, entry string) {
+	if kustomization.IsRemote(entry) {
+		res.incomplete = true
+		return
+	}
+	path, exists, isDir := l.resolve(res, kpath, dir, entry)
+	if !exists {
+		return
+	}
+	if isDir {
+		l.addDirectory(res, kpath, dir, entry)
+		return
+	}
+	objects, ok := l.readObjects(path)
+	if !ok {
+		return
+	}
+	for _, r := range objects {
+		l.add(res, kpath, r)
+	}
+}
+
+func (l *linter) addGenerated(res *result, kpath, dir, kind string, g kustomization.Generator) {
+	if g.Name == "" {
+		l.reportf(kpath, "%s generator without a name", kind)
+		return
+	}
+	files := append([]string{}, g.Envs...)
+	if g.Env != "" {
+		files = append(files, g.Env)
+	}
+	for _, file := range g.Files {
+		// the files are given as [key=]path
+		files = append(files, file[strings.Index(file, "=")+1:])
+	}
+	for _, file := range files {
+		l.checkFile(res, kpath, dir, file)
+	}
+	if g.Behavior == "merge" || g.Behavior == "replace" {
+		if res.find(kustomization.Target{Kind: kind, Name: g.Name}) == nil && !res.incomplete {
+			l.reportf(kpath, "%s generator %s has behavior %s, but there is no %s %s to %s",
+				kind, g.Name, g.Behavior, kind, g.Name, g.Behavior)
+		}
+		return
+	}
+	l.add(res, kpath, &resource{kind: kind, name: g.Name, originalName: g.Name, namespace: g.Namespace})
+}
+
+// add adds the object r to the output, reporting the duplicates.
+func (l *linter) add(res *result, kpath string, r *resource) {
+	for _, existing := range res.resources {
+		if existing.group == r.group && existing.kind == r.kind &&
+			existing.namespace == r.namespace && existing.name == r.name {
+			l.reportf(kpath, "%s is defined more than once, in %s and %s", r, existing.file, r.file)
+			return
+		}
+	}
+	res.resources = append(res.resources, r)
+}
+
+// find returns the object selected by t.
+func (res *result) find(t kustomization.Target) *resource {
+	for _, r := range res.resources {
+		if r.matches(t) {
+			return r
+		}
+	}
+	return nil
+}
+
+func (l *linter) checkTarget(res *result, kpath, entry string, t kustomization.Target) {
+	if t.Kind == "" || t.Name == "" {
+		l.reportf(kpath, "patch %s does not select the kind and name of the object it applies to", entry)
+		return
+	}
+	if res.find(t) == nil && !res.incomplete {
+		l.reportf(kpath, "patch %s applies to %s %s, which is not one of the resources", entry, t.Kind, t.Name)
+	}
+}
+
+// checkStrategicMergePatch checks the patch file entry, whose objects select
+// the objects they patch unless t is set.
+func (l *linter) checkStrategicMergePatch(res *result, kpath, dir, entry string, t *kustomization.Target) {
+	path, exists, isDir := l.resolve(res, kpath, dir, entry)
+	if !exists {
+		return
+	}
+	if isDir {
+		l.reportf(kpath, "patch %s is a directory", entry)
+		return
+	}
+	if t != nil {
+		if t.Name != "" {
+			// the targets selecting objects by labels or annotations are not checked
+			l.checkTarget(res, kpath, entry, *t)
+		}
+		return
+	}
+	objects, ok := l.readObjects(path)
+	if !ok {
+		return
+	}
+	for _, r := range objects {
+		l.checkTarget(res, kpath, entry, kustomization.Target{Group: r.group, Kind: r.kind, Name: r.name})
+	}
+}
+
+// checkPatch checks an entry of patches given as an object, whose patch is
+// either inline or in the file at its path.
+func (l *linter) checkPatch(res *result, kpath, dir string, p interface{}) {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		l.reportf(kpath, "invalid patch: %v", err)
+		return
+	}
+	patch := struct {
+		Path    string                `yaml:"path"`
+		Patch   string                `yaml:"patch"`
+		Target  *kustomization.Target `yaml:"target"`
+		Options interface{}           `yaml:"options"`
+	}{}
+	if err := yaml.UnmarshalStrict(data, &patch); err != nil {
+		l.reportf(kpath, "invalid patch: %s", kustomization.YAMLError(err))
+		return
+	}
+	if patch.Path == "" {
+		if patch.Patch == "" {
+			l.reportf(kpath, "patch without a path or an inline patch")
+		}
+		return
+	}
+	l.checkStrategicMergePatch(res, kpath, dir, patch.Path, patch.Target)
+}
+
+func (l *linter) checkJSON6902Patch(res *result, kpath, dir string, p kustomization.JSON6902Patch) {
+	if p.Target == nil {
+		l.reportf(kpath, "JSON patch %s without a target", p.Path)
+		return
+	}
+	l.checkTarget(res, kpath, p.Path, *p.Target)
+	path, exists, _ := l.resolve(res, kpath, dir, p.Path)
+	if !exists {
+		return
+	}
+	data, err := ioutil.ReadFile(path) // nolint: gosec
+	if err != nil {
+		l.reportf(path, "%v", err)
+		return
+	}
+	var operations []map[string]interface{}
+	if err := yaml.Unmarshal(data, &operations); err != nil {
+		l.reportf(path, "invalid JSON patch: %s", kustomization.YAMLError(err))
+	}
+}
+
+// readObjects reads the objects defined in the YAML file at path.
+func (l *linter) readObjects(path string) ([]*resource, bool) {
+	objects, err := kustomization.ReadObjects(path)
+	if err != nil {
+		l.reportf(path, "%v", err)
+		return nil, false
+	}
+	var resources []*resource
+	for _, o := range objects {
+		if o.Kind() == "" || o.Name() == "" {
+			l.reportf(path, "object without a kind or a metadata.name")
+			continue
+		}
+		resources = append(resources, &resource{
+			group:        o.Group(),
+			kind:         o.Kind(),
+			name:         o.Name(),
+			originalName: o.Name(),
+			namespace:    o.Namespace(),
+			file:         path,
+		})
+	}
+	return resources, true
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomizelint_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/kustomizelint"
+)
+
+const (
+	managerKustomization = `resources:
+- manager.yaml
+`
+	manager = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: system
+`
+	imagePatch = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: system
+`
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		generated []string
+		want      []string
+	}{
+		{
+			name: "builds",
+			files: map[string]string{
+				"default/kustomization.yaml": `namespace: project-system
+namePrefix: project-
+bases:
+- ../manager
+- ../crd
+patches:
+- manager_image_patch.yaml
+patchesJson6902:
+- target:
+    group: apps
+    version: v1
+    kind: Deployment
+    name: controller-manager
+  path: replicas.yaml
+vars:
+- name: NAMESPACE
+  objref:
+    kind: Namespace
+    version: v1
+    name: system
+  fieldref:
+    fieldpath: metadata.name
+`,
+				"default/manager_image_patch.yaml": imagePatch,
+				"default/replicas.yaml":            "- op: replace\n  path: /spec/replicas\n  value: 2\n",
+				"manager/kustomization.yaml":       managerKustomization,
+				"manager/manager.yaml":             manager,
+				"crd/kustomization.yaml":           "resources:\n- bases/crew_captains.yaml\n",
+			},
+			generated: []string{"crd/bases/*"},
+		},
+		{
+			name: "missing files",
+			files: map[string]string{
+				"default/kustomization.yaml": `bases:
+- ../manager
+- ../webhook
+patches:
+- manager_webhook_patch.yaml
+`,
+				"manager/kustomization.yaml": managerKustomization,
+			},
+			want: []string{
+				"manager/kustomization.yaml: manager.yaml does not exist",
+				"default/kustomization.yaml: ../webhook does not exist",
+				"default/kustomization.yaml: manager_webhook_patch.yaml does not exist",
+			},
+		},
+		{
+			name: "unknown field",
+			files: map[string]string{
+				"default/kustomization.yaml": "resource:\n- manager.yaml\n",
+			},
+			want: []string{
+				"default/kustomization.yaml: yaml: unmarshal errors:\n  line 1: field resource not found",
+			},
+		},
+		{
+			name: "patch without target",
+			files: map[string]string{
+				"default/kustomization.yaml": `bases:
+- ../manager
+patchesStrategicMerge:
+- manager_image_patch.yaml
+`,
+				"default/manager_image_patch.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: manager
+`,
+				"manager/kustomization.yaml": managerKustomization,
+				"manager/manager.yaml":       manager,
+			},
+			want: []string{
+				"default/kustomization.yaml: patch manager_image_patch.yaml applies to Deployment manager, " +
+					"which is not one of the resources",
+			},
+		},
+		{
+			name: "duplicate resource",
+			files: map[string]string{
+				"default/kustomization.yaml": "resources:\n- ../manager\n- manager.yaml\n",
+				"default/manager.yaml":       manager,
+				"manager/kustomization.yaml": managerKustomization,
+				"manager/manager.yaml":       manager,
+			},
+			want: []string{
+				"default/kustomization.yaml: Namespace system is defined more than once, in manager/manager.yaml " +
+					"and default/manager.yaml",
+				"default/kustomization.yaml: Deployment controller-manager is defined more than once, in " +
+					"manager/manager.yaml and default/manager.yaml",
+			},
+		},
+		{
+			name: "var without object",
+			files: map[string]string{
+				"default/kustomization.yaml": "bases:\n- ../manager\n- ../certmanager\n",
+				"manager/kustomization.yaml": managerKustomization,
+				"manager/manager.yaml":       manager,
+				"certmanager/kustomization.yaml": `vars:
+- name: SERVICENAME
+  objref:
+    kind: Service
+    version: v1
+    name: webhook-service
+`,
+			},
+			want: []string{
+				"certmanager/kustomization.yaml: var SERVICENAME refers to Service webhook-service, " +
+					"which is not one of the resources",
+			},
+		},
+		{
+			name: "component patching the resources",
+			files: map[string]string{
+				"default/kustomization.yaml": "bases:\n- ../manager\ncomponents:\n- ../components/replicas\n",
+				"manager/kustomization.yaml": managerKustomization,
+				"manager/manager.yaml":       manager,
+				"components/replicas/kustomization.yaml": `apiVersion: kustomize.config.k8s.io/v1alpha1
+kind: Component
+patchesStrategicMerge:
+- replicas.yaml
+- other.yaml
+`,
+				"components/replicas/replicas.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+spec:
+  replicas: 2
+`,
+				"components/replicas/other.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: other\n",
+			},
+			want: []string{
+				"components/replicas/kustomization.yaml: patch other.yaml applies to Deployment other, " +
+					"which is not one of the resources",
+			},
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"default/kustomization.yaml": "bases:\n- ../manager\n",
+				"manager/kustomization.yaml": "bases:\n- ../default\n",
+			},
+			want: []string{
+				"default/kustomization.yaml: the kustomization includes itself",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "kustomizelint")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			for path, content := range test.files {
+				path = filepath.Join(dir, path)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			wd, err := os.Getwd()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Chdir(wd)
+			if err := os.Chdir(dir); err != nil {
+				t.Fatal(err)
+			}
+
+			diagnostics, err := kustomizelint.Lint("default", test.generated...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(diagnostics) == 0 {
+				diagnostics = nil
+			}
+			if !reflect.DeepEqual(diagnostics, test.want) {
+				t.Errorf("expected diagnostics %q, got %q", test.want, diagnostics)
+			}
+		})
+	}
+}
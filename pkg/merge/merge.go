@@ -0,0 +1,441 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package merge merges the regenerated scaffold of a Go file into the version
+// of the file edited by the user, so that the features added to the scaffold
+// can be adopted without losing the code of the project.
+//
+// The merge only adds to the existing file: the imports, top-level
+// declarations, struct fields and the markers of the doc comments of the
+// scaffold which the file does not have, e.g. // +kubebuilder:printcolumn. The
+// fields are inserted above the // +kubebuilder:scaffold: marker of their
+// struct, if any. The code the file already has is kept as it is, whatever
+// the scaffold has.
+package merge
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// scaffoldMarkerPrefix prefixes the scaffold markers, above which the code of
+// the scaffold is inserted.
+const scaffoldMarkerPrefix = "// +kubebuilder:scaffold:"
+
+// Result is the outcome of a merge.
+type Result struct {
+	// Content is the merged file, formatted
+	Content []byte
+
+	// Added are the imports, declarations and fields of the scaffold added to
+	// the existing file, e.g. "func (*FooReconciler).SetupWithManager"
+	Added []string
+
+	// Kept are the declarations of the scaffold whose code differs from the
+	// one of the existing file, which is kept
+	Kept []string
+}
+
+// insertion is code inserted at an offset of the existing file.
+type insertion struct {
+	offset int
+	text   string
+}
+
+type merger struct {
+	fset                *token.FileSet
+	existing, generated []byte
+	ef, gf              *ast.File
+
+	insertions []insertion
+	appended   []string
+	result     *Result
+}
+
+// Go merges generated, the scaffold of the Go file named filename, into
+// existing, the version of the file in the project.
+func Go(filename string, existing, generated []byte) (*Result, error) {
+	fset := token.NewFileSet()
+	ef, err := parser.ParseFile(fset, filename, existing, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", filename, err)
+	}
+	gf, err := parser.ParseFile(fset, filename, generated, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the scaffold of %s: %v", filename, err)
+	}
+	if ef.Name.Name != gf.Name.Name {
+		return nil, fmt.Errorf("%s is in package %s rather than %s", filename, ef.Name.Name, gf.Name.Name)
+	}
+
+	m := &merger{fset: fset, existing: existing, generated: generated, ef: ef, gf: gf, result: &Result{}}
+	m.mergeImports()
+	m.mergeDecls()
+
+	content := m.apply()
+	formatted, err := format.Source(content)
+	if err != nil {
+		return nil, fmt.Errorf("error formatting the merge of %s: %v", filename, err)
+	}
+	m.result.Content = formatted
+	return m.result, nil
+}
+
+// offset returns the offset of pos in its file.
+func (m *merger) offset(pos token.Pos) int {
+	return m.fset.Position(pos).Offset
+}
+
+// lineStart returns the offset of the start of the line of pos in the
+// existing file.
+func (m *merger) lineStart(pos token.Pos) int {
+	offset := m.offset(pos)
+	return offset - (m.fset.Position(pos).Column - 1)
+}
+
+// existingText returns the source of the existing file between the positions.
+func (m *merger) existingText(from, to token.Pos) string {
+	return string(m.existing[m.offset(from):m.offset(to)])
+}
+
+// generatedText returns the source of the scaffold between the positions.
+func (m *merger) generatedText(from, to token.Pos) string {
+	return string(m.generated[m.offset(from):m.offset(to)])
+}
+
+func (m *merger) insert(offset int, text string) {
+	m.insertions = append(m.insertions, insertion{offset: offset, text: text})
+}
+
+// apply returns the existing file with the insertions and the appended
+// declarations.
+func (m *merger) apply() []byte {
+	sort.SliceStable(m.insertions, func(i, j int) bool { return m.insertions[i].offset < m.insertions[j].offset })
+	out := &bytes.Buffer{}
+	last := 0
+	for _, in := range m.insertions {
+		out.Write(m.existing[last:in.offset])
+		out.WriteString(in.text)
+		last = in.offset
+	}
+	out.Write(m.existing[last:])
+	for _, decl := range m.appended {
+		out.WriteString("\n" + decl + "\n")
+	}
+	return out.Bytes()
+}
+
+// importText returns the source of an import spec, without its comments.
+func importText(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name + " " + spec.Path.Value
+	}
+	return spec.Path.Value
+}
+
+func (m *merger) mergeImports() {
+	existing := map[string]bool{}
+	for _, spec := range m.ef.Imports {
+		existing[spec.Path.Value] = true
+	}
+	var missing []string
+	for _, spec := range m.gf.Imports {
+		if !existing[spec.Path.Value] {
+			missing = append(missing, importText(spec))
+			m.result.Added = append(m.result.Added, "import "+spec.Path.Value)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	// the imports are added to the last import declaration, or after the
+	// package clause of a file without imports
+	var last *ast.GenDecl
+	for _, decl := range m.ef.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			last = gen
+		}
+	}
+	switch {
+	case last != nil && last.Lparen.IsValid():
+		m.insert(m.lineStart(last.Rparen), "\t"+strings.Join(missing, "\n\t")+"\n")
+	case last != nil:
+		m.insert(m.offset(last.End()), "\nimport (\n\t"+strings.Join(missing, "\n\t")+"\n)")
+	default:
+		m.insert(m.offset(m.ef.Name.End()), "\n\nimport (\n\t"+strings.Join(missing, "\n\t")+"\n)")
+	}
+}
+
+// funcKey names a function, or a method after the type of its receiver.
+func funcKey(fn *ast.FuncDecl, text func(from, to token.Pos) string) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	return "(" + text(recv.Pos(), recv.End()) + ")." + fn.Name.Name
+}
+
+// specName returns the name declared by a type or value spec.
+func specName(spec ast.Spec) string {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Name.Name
+	case *ast.ValueSpec:
+		return s.Names[0].Name
+	}
+	return ""
+}
+
+// withDoc returns the start of node, including its doc comment.
+func withDoc(doc *ast.CommentGroup, node ast.Node) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+	return node.Pos()
+}
+
+// compact returns the source without its whitespace, to compare code
+// regardless of its formatting.
+func compact(source string) string {
+	return strings.Join(strings.Fields(source), "")
+}
+
+func (m *merger) mergeDecls() {
+	funcs := map[string]*ast.FuncDecl{}
+	specs := map[string]ast.Spec{}
+	specDecls := map[string]*ast.GenDecl{}
+	for _, decl := range m.ef.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			funcs[funcKey(d, m.existingText)] = d
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if name := specName(spec); name != "" {
+					specs[name] = spec
+					specDecls[name] = d
+				}
+			}
+		}
+	}
+
+	for _, decl := range m.gf.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			key := funcKey(d, m.generatedText)
+			fn, found := funcs[key]
+			if !found {
+				m.appended = append(m.appended, m.generatedText(withDoc(d.Doc, d), d.End()))
+				m.result.Added = append(m.result.Added, "func "+key)
+				continue
+			}
+			m.mergeMarkers("func "+key, declMarkers(m.gf, d), declMarkers(m.ef, fn), withDoc(fn.Doc, fn))
+			if d.Body != nil && fn.Body != nil &&
+				compact(m.generatedText(d.Body.Pos(), d.Body.End())) != compact(m.existingText(fn.Body.Pos(), fn.Body.End())) {
+				m.result.Kept = append(m.result.Kept, "func "+key)
+			}
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			var missing []ast.Spec
+			for _, spec := range d.Specs {
+				existing, found := specs[specName(spec)]
+				if !found {
+					missing = append(missing, spec)
+					m.result.Added = append(m.result.Added, d.Tok.String()+" "+specName(spec))
+					continue
+				}
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					if es, ok := existing.(*ast.TypeSpec); ok {
+						m.mergeTypeMarkers(d, ts, specDecls[ts.Name.Name], es)
+						m.mergeStruct(ts, es)
+					}
+				}
+			}
+			switch {
+			case len(missing) == 0:
+			case len(missing) == len(d.Specs):
+				m.appended = append(m.appended, m.generatedText(withDoc(d.Doc, d), d.End()))
+			default:
+				for _, spec := range missing {
+					m.appended = append(m.appended, m.specDecl(d.Tok, spec))
+				}
+			}
+		}
+	}
+}
+
+// markerLines returns the marker comments of a comment group, e.g.
+// // +kubebuilder:subresource:status.
+func markerLines(group *ast.CommentGroup) []string {
+	var markers []string
+	if group == nil {
+		return markers
+	}
+	for _, c := range group.List {
+		if strings.HasPrefix(c.Text, "// +") {
+			markers = append(markers, strings.TrimSpace(c.Text))
+		}
+	}
+	return markers
+}
+
+// declMarkers returns the marker comments between the previous declaration
+// of the file and decl, those of its doc comment and of the comments separated
+// from it by blank lines, e.g. the RBAC markers of a Reconcile.
+func declMarkers(f *ast.File, decl ast.Decl) []string {
+	start := f.Name.End()
+	for _, d := range f.Decls {
+		if d == decl {
+			break
+		}
+		start = d.End()
+	}
+	var markers []string
+	for _, group := range f.Comments {
+		if group.Pos() > start && group.End() <= decl.Pos() {
+			markers = append(markers, markerLines(group)...)
+		}
+	}
+	return markers
+}
+
+// mergeTypeMarkers adds the markers of a type of the scaffold missing from
+// the existing type, e.g. the printer columns of the CRD of the type.
+func (m *merger) mergeTypeMarkers(decl *ast.GenDecl, spec *ast.TypeSpec, existingDecl *ast.GenDecl, existing *ast.TypeSpec) {
+	var generated []string
+	if decl.Lparen.IsValid() {
+		generated = markerLines(spec.Doc)
+	} else {
+		generated = declMarkers(m.gf, decl)
+	}
+	if existingDecl.Lparen.IsValid() {
+		m.mergeMarkers("type "+spec.Name.Name, generated, markerLines(existing.Doc), withDoc(existing.Doc, existing))
+		return
+	}
+	m.mergeMarkers("type "+spec.Name.Name, generated, declMarkers(m.ef, existingDecl), withDoc(existingDecl.Doc, existingDecl))
+}
+
+// mergeMarkers adds the generated markers of a declaration missing from the
+// existing ones above the existing declaration, which starts at pos.
+func (m *merger) mergeMarkers(name string, generated, existing []string, pos token.Pos) {
+	markers := map[string]bool{}
+	for _, marker := range existing {
+		markers[marker] = true
+	}
+	var missing []string
+	for _, marker := range generated {
+		if !markers[marker] {
+			markers[marker] = true
+			missing = append(missing, marker+"\n")
+			m.result.Added = append(m.result.Added, "marker "+marker+" of "+name)
+		}
+	}
+	if len(missing) > 0 {
+		m.insert(m.lineStart(pos), strings.Join(missing, ""))
+	}
+}
+
+// specDecl returns the declaration of a single spec of a declaration of the
+// scaffold, with the doc comment of the spec.
+func (m *merger) specDecl(tok token.Token, spec ast.Spec) string {
+	var doc *ast.CommentGroup
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		doc = s.Doc
+	case *ast.ValueSpec:
+		doc = s.Doc
+	}
+	decl := tok.String() + " " + m.generatedText(spec.Pos(), spec.End())
+	if doc != nil {
+		return m.generatedText(doc.Pos(), doc.End()) + "\n" + decl
+	}
+	return decl
+}
+
+// fieldKeys returns the names of a field, or the type of an embedded field.
+func fieldKeys(field *ast.Field, text func(from, to token.Pos) string) []string {
+	if len(field.Names) == 0 {
+		return []string{text(field.Type.Pos(), field.Type.End())}
+	}
+	var keys []string
+	for _, name := range field.Names {
+		keys = append(keys, name.Name)
+	}
+	return keys
+}
+
+// mergeStruct adds the fields of the struct of the scaffold missing from the
+// struct of the existing file, above its scaffold marker or else at its end.
+func (m *merger) mergeStruct(generated, existing *ast.TypeSpec) {
+	gs, ok := generated.Type.(*ast.StructType)
+	if !ok {
+		return
+	}
+	es, ok := existing.Type.(*ast.StructType)
+	if !ok {
+		return
+	}
+
+	fields := map[string]bool{}
+	for _, field := range es.Fields.List {
+		for _, key := range fieldKeys(field, m.existingText) {
+			fields[key] = true
+		}
+	}
+	var missing []string
+	for _, field := range gs.Fields.List {
+		keys := fieldKeys(field, m.generatedText)
+		if fields[keys[0]] {
+			continue
+		}
+		end := field.End()
+		if field.Comment != nil {
+			end = field.Comment.End()
+		}
+		missing = append(missing, m.generatedText(withDoc(field.Doc, field), end))
+		for _, key := range keys {
+			m.result.Added = append(m.result.Added, "field "+generated.Name.Name+"."+key)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	text := "\t" + strings.Join(missing, "\n\t") + "\n"
+	for _, group := range m.ef.Comments {
+		for _, c := range group.List {
+			if c.Pos() > es.Fields.Opening && c.End() < es.Fields.Closing &&
+				strings.HasPrefix(c.Text, scaffoldMarkerPrefix) {
+				m.insert(m.lineStart(c.Pos()), text)
+				return
+			}
+		}
+	}
+	if m.fset.Position(es.Fields.Opening).Line == m.fset.Position(es.Fields.Closing).Line {
+		// a struct{} on a single line
+		m.insert(m.offset(es.Fields.Closing), "\n"+text)
+		return
+	}
+	m.insert(m.lineStart(es.Fields.Closing), text)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/merge"
+)
+
+func TestGo(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  string
+		generated string
+		expected  string
+		added     []string
+		kept      []string
+	}{
+		{
+			name:      "unchanged",
+			existing:  "package p\n\nfunc F() {}\n",
+			generated: "package p\n\nfunc F() {}\n",
+			expected:  "package p\n\nfunc F() {}\n",
+		},
+		{
+			name:      "user code kept",
+			existing:  "package p\n\n// F is edited\nfunc F() int {\n\treturn 1\n}\n",
+			generated: "package p\n\n// F is scaffolded\nfunc F() int {\n\treturn 0\n}\n",
+			expected:  "package p\n\n// F is edited\nfunc F() int {\n\treturn 1\n}\n",
+			kept:      []string{"func F"},
+		},
+		{
+			name:     "missing declarations appended",
+			existing: "package p\n\ntype T struct{}\n\nfunc (t *T) A() {}\n",
+			generated: "package p\n\nconst C = 1\n\ntype T struct{}\n\nfunc (t *T) A() {}\n\n" +
+				"// B is new\nfunc (t *T) B() {}\n",
+			expected: "package p\n\ntype T struct{}\n\nfunc (t *T) A() {}\n\nconst C = 1\n\n" +
+				"// B is new\nfunc (t *T) B() {}\n",
+			added: []string{"const C", "func (*T).B"},
+		},
+		{
+			name:      "missing specs of a group",
+			existing:  "package p\n\nvar (\n\ta = 1\n)\n",
+			generated: "package p\n\nvar (\n\ta = 1\n\t// b is new\n\tb = 2\n)\n",
+			expected:  "package p\n\nvar (\n\ta = 1\n)\n\n// b is new\nvar b = 2\n",
+			added:     []string{"var b"},
+		},
+		{
+			name: "fields above the marker",
+			existing: "package p\n\ntype S struct {\n\tA string `json:\"a\"`\n\t// Mine is edited\n\tMine int\n" +
+				"\t// +kubebuilder:scaffold:spec\n}\n",
+			generated: "package p\n\ntype S struct {\n\tA string `json:\"a\"`\n\t// B is new\n\tB []string `json:\"b\"` // tag\n" +
+				"\t// +kubebuilder:scaffold:spec\n}\n",
+			expected: "package p\n\ntype S struct {\n\tA string `json:\"a\"`\n\t// Mine is edited\n\tMine int\n" +
+				"\t// B is new\n\tB []string `json:\"b\"` // tag\n\t// +kubebuilder:scaffold:spec\n}\n",
+			added: []string{"field S.B"},
+		},
+		{
+			name: "markers added",
+			existing: "package p\n\n// T is edited\n// +kubebuilder:object:root=true\ntype T struct{}\n\n" +
+				"// +kubebuilder:webhook:path=/a\nfunc F() {}\n",
+			generated: "package p\n\n// +kubebuilder:object:root=true\n// +kubebuilder:subresource:status\n\n" +
+				"// T is scaffolded\ntype T struct{}\n\n// +kubebuilder:rbac:groups=a\n\nfunc F() {}\n",
+			expected: "package p\n\n// +kubebuilder:subresource:status\n// T is edited\n// +kubebuilder:object:root=true\n" +
+				"type T struct{}\n\n// +kubebuilder:rbac:groups=a\n// +kubebuilder:webhook:path=/a\nfunc F() {}\n",
+			added: []string{"marker // +kubebuilder:subresource:status of type T",
+				"marker // +kubebuilder:rbac:groups=a of func F"},
+		},
+		{
+			name:      "fields at the end",
+			existing:  "package p\n\ntype R struct {\n\tclient.Client\n\tLog int\n}\n",
+			generated: "package p\n\ntype R struct {\n\tclient.Client\n\tLog int\n\n\t// Recorder records\n\tRecorder int\n}\n",
+			expected:  "package p\n\ntype R struct {\n\tclient.Client\n\tLog int\n\t// Recorder records\n\tRecorder int\n}\n",
+			added:     []string{"field R.Recorder"},
+		},
+		{
+			name:      "fields of an empty struct",
+			existing:  "package p\n\ntype S struct{}\n",
+			generated: "package p\n\ntype S struct {\n\tA int\n}\n",
+			expected:  "package p\n\ntype S struct {\n\tA int\n}\n",
+			added:     []string{"field S.A"},
+		},
+		{
+			name:      "imports added",
+			existing:  "package p\n\nimport (\n\t\"fmt\"\n)\n\nvar _ = fmt.Sprint\n",
+			generated: "package p\n\nimport (\n\t\"fmt\"\n\tcorev1 \"k8s.io/api/core/v1\"\n)\n\nvar _ = fmt.Sprint\n\nvar p corev1.Pod\n",
+			expected:  "package p\n\nimport (\n\t\"fmt\"\n\tcorev1 \"k8s.io/api/core/v1\"\n)\n\nvar _ = fmt.Sprint\n\nvar p corev1.Pod\n",
+			added:     []string{`import "k8s.io/api/core/v1"`, "var p"},
+		},
+		{
+			name:      "imports added to a file without",
+			existing:  "package p\n",
+			generated: "package p\n\nimport \"fmt\"\n\nvar _ = fmt.Sprint\n",
+			expected:  "package p\n\nimport (\n\t\"fmt\"\n)\n\nvar _ = fmt.Sprint\n",
+			added:     []string{`import "fmt"`, "var _"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := merge.Go("f.go", []byte(test.existing), []byte(test.generated))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(res.Content) != test.expected {
+				t.Errorf("expected\n%s\ngot\n%s", test.expected, res.Content)
+			}
+			if !reflect.DeepEqual(res.Added, test.added) {
+				t.Errorf("expected added %q, got %q", test.added, res.Added)
+			}
+			if !reflect.DeepEqual(res.Kept, test.kept) {
+				t.Errorf("expected kept %q, got %q", test.kept, res.Kept)
+			}
+		})
+	}
+}
+
+func TestGoPackageMismatch(t *testing.T) {
+	if _, err := merge.Go("f.go", []byte("package p\n"), []byte("package q\n")); err == nil {
+		t.Error("expected the files of different packages not to be merged")
+	}
+}
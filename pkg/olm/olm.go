@@ -0,0 +1,397 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package olm renders the Operator Lifecycle Manager bundle of a project from
+// the manifests built by kustomize from its config, so that the operator is
+// published to OperatorHub without writing its ClusterServiceVersion by hand.
+//
+// The Deployments of the manifests become the install strategy of the CSV,
+// and the rules of the roles bound to their service accounts its permissions
+// and cluster permissions. The CRDs are owned by the CSV and copied to the
+// bundle, along with the objects OLM installs from a bundle, e.g. the Services.
+// The other objects, e.g. the webhook configurations, are skipped and
+// reported. The fields of an existing CSV which are not generated, e.g. its
+// description, maintainers and icon, are kept.
+package olm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/kubebuilder/pkg/internal/mapslice"
+)
+
+// bundled are the kinds of the objects OLM installs from the manifests of a
+// bundle next to the CSV.
+var bundled = map[string]bool{
+	"ConfigMap":           true,
+	"PodDisruptionBudget": true,
+	"PriorityClass":       true,
+	"PrometheusRule":      true,
+	"Secret":              true,
+	"Service":             true,
+	"ServiceMonitor":      true,
+}
+
+// Options are the options of a bundle.
+type Options struct {
+	// Name is the name of the package of the operator, the project name
+	Name string
+
+	// Version is the semantic version of the operator
+	Version string
+
+	// Channels are the channels the bundle is published in
+	Channels []string
+
+	// DefaultChannel is the channel subscribed to by default, the first of
+	// the channels if empty
+	DefaultChannel string
+
+	// CSV is the content of the existing CSV, empty if there is none
+	CSV []byte
+
+	// Samples are the samples of the CRs, listed in the alm-examples
+	// annotation of the CSV
+	Samples [][]byte
+}
+
+// Bundle is the content of a bundle rendered from manifests.
+type Bundle struct {
+	// Manifests are the files of the manifests directory of the bundle, by
+	// name
+	Manifests map[string][]byte
+
+	// Annotations is the metadata/annotations.yaml file of the bundle
+	Annotations []byte
+
+	// Dockerfile is the Dockerfile building the image of the bundle
+	Dockerfile []byte
+
+	// Skipped are the <Kind>/<name> of the objects a bundle cannot hold
+	Skipped []string
+}
+
+// CSVFile returns the name of the file of the CSV of the named package.
+func CSVFile(name string) string {
+	return name + ".clusterserviceversion.yaml"
+}
+
+// binding is a role binding of the manifests.
+type binding struct {
+	cluster         bool
+	roleKind, role  string
+	serviceAccounts []string
+}
+
+// Render renders the bundle of the manifests built by kustomize.
+func Render(manifests []byte, opts Options) (*Bundle, error) {
+	if opts.Name == "" || opts.Version == "" {
+		return nil, fmt.Errorf("the name and the version of the bundle are required")
+	}
+	if len(opts.Channels) == 0 {
+		return nil, fmt.Errorf("the bundle is published in no channel")
+	}
+	if opts.DefaultChannel == "" {
+		opts.DefaultChannel = opts.Channels[0]
+	}
+
+	bundle := &Bundle{Manifests: map[string][]byte{}}
+	var deployments []interface{}
+	var owned []interface{}
+	var bindings []binding
+	rules := map[string]interface{}{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(manifests))
+	for {
+		var doc yaml.MapSlice
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error parsing the manifests: %v", err)
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		kind, _ := mapslice.Get(doc, "kind").(string)
+		meta, _ := mapslice.Get(doc, "metadata").(yaml.MapSlice)
+		name, _ := mapslice.Get(meta, "name").(string)
+		switch {
+		case kind == "CustomResourceDefinition":
+			content, err := yaml.Marshal(doc)
+			if err != nil {
+				return nil, err
+			}
+			bundle.Manifests[name+".yaml"] = content
+			owned = append(owned, ownedCRDs(doc)...)
+		case kind == "Namespace":
+			// the operator is installed in the namespace of its OperatorGroup
+		case kind == "Deployment":
+			deployments = append(deployments, yaml.MapSlice{
+				{Key: "name", Value: name},
+				{Key: "spec", Value: mapslice.Get(doc, "spec")},
+			})
+		case kind == "ClusterRole" || kind == "Role":
+			rules[kind+"/"+name] = mapslice.Get(doc, "rules")
+		case kind == "ClusterRoleBinding" || kind == "RoleBinding":
+			bindings = append(bindings, roleBinding(doc))
+		case kind == "ServiceAccount":
+			// OLM creates the service accounts of the permissions
+		case bundled[kind]:
+			doc = mapslice.Set(doc, "metadata", mapslice.Delete(meta, "namespace"))
+			content, err := yaml.Marshal(doc)
+			if err != nil {
+				return nil, err
+			}
+			bundle.Manifests[fmt.Sprintf("%s_%s.yaml", name, strings.ToLower(kind))] = content
+		default:
+			bundle.Skipped = append(bundle.Skipped, kind+"/"+name)
+		}
+	}
+
+	csv, err := baseCSV(opts)
+	if err != nil {
+		return nil, err
+	}
+	examples, err := almExamples(opts.Samples)
+	if err != nil {
+		return nil, err
+	}
+	meta, _ := mapslice.Get(csv, "metadata").(yaml.MapSlice)
+	meta = mapslice.Set(meta, "name", fmt.Sprintf("%s.v%s", opts.Name, opts.Version))
+	annotations, _ := mapslice.Get(meta, "annotations").(yaml.MapSlice)
+	meta = mapslice.Set(meta, "annotations", mapslice.Set(annotations, "alm-examples", examples))
+	csv = mapslice.Set(csv, "metadata", meta)
+
+	spec, _ := mapslice.Get(csv, "spec").(yaml.MapSlice)
+	spec = mapslice.Set(spec, "version", opts.Version)
+	crds, _ := mapslice.Get(spec, "customresourcedefinitions").(yaml.MapSlice)
+	existing, _ := mapslice.Get(crds, "owned").([]interface{})
+	spec = mapslice.Set(spec, "customresourcedefinitions", mapslice.Set(crds, "owned", keepOwned(owned, existing)))
+	permissions, clusterPermissions := permissions(bindings, rules)
+	install := yaml.MapSlice{{Key: "deployments", Value: deployments}}
+	if len(permissions) > 0 {
+		install = append(install, yaml.MapItem{Key: "permissions", Value: permissions})
+	}
+	if len(clusterPermissions) > 0 {
+		install = append(install, yaml.MapItem{Key: "clusterPermissions", Value: clusterPermissions})
+	}
+	spec = mapslice.Set(spec, "install", yaml.MapSlice{
+		{Key: "strategy", Value: "deployment"},
+		{Key: "spec", Value: install},
+	})
+	csv = mapslice.Set(csv, "spec", spec)
+
+	content, err := yaml.Marshal(csv)
+	if err != nil {
+		return nil, err
+	}
+	bundle.Manifests[CSVFile(opts.Name)] = content
+
+	labels := yaml.MapSlice{
+		{Key: "operators.operatorframework.io.bundle.mediatype.v1", Value: "registry+v1"},
+		{Key: "operators.operatorframework.io.bundle.manifests.v1", Value: "manifests/"},
+		{Key: "operators.operatorframework.io.bundle.metadata.v1", Value: "metadata/"},
+		{Key: "operators.operatorframework.io.bundle.package.v1", Value: opts.Name},
+		{Key: "operators.operatorframework.io.bundle.channels.v1", Value: strings.Join(opts.Channels, ",")},
+		{Key: "operators.operatorframework.io.bundle.channel.default.v1", Value: opts.DefaultChannel},
+	}
+	if bundle.Annotations, err = yaml.Marshal(yaml.MapSlice{{Key: "annotations", Value: labels}}); err != nil {
+		return nil, err
+	}
+	dockerfile := &bytes.Buffer{}
+	dockerfile.WriteString("FROM scratch\n\n")
+	for _, label := range labels {
+		fmt.Fprintf(dockerfile, "LABEL %s=%s\n", label.Key, label.Value)
+	}
+	dockerfile.WriteString("\nCOPY bundle/manifests /manifests/\nCOPY bundle/metadata /metadata/\n")
+	bundle.Dockerfile = dockerfile.Bytes()
+
+	return bundle, nil
+}
+
+// baseCSV returns the existing CSV, or a new one listing the install modes.
+func baseCSV(opts Options) (yaml.MapSlice, error) {
+	if len(opts.CSV) > 0 {
+		csv := yaml.MapSlice{}
+		if err := yaml.Unmarshal(opts.CSV, &csv); err != nil {
+			return nil, fmt.Errorf("error parsing the existing CSV: %v", err)
+		}
+		return csv, nil
+	}
+	installModes := []interface{}{}
+	for _, mode := range []struct {
+		name      string
+		supported bool
+	}{{"OwnNamespace", true}, {"SingleNamespace", true}, {"MultiNamespace", false}, {"AllNamespaces", true}} {
+		installModes = append(installModes, yaml.MapSlice{
+			{Key: "type", Value: mode.name},
+			{Key: "supported", Value: mode.supported},
+		})
+	}
+	return yaml.MapSlice{
+		{Key: "apiVersion", Value: "operators.coreos.com/v1alpha1"},
+		{Key: "kind", Value: "ClusterServiceVersion"},
+		{Key: "metadata", Value: yaml.MapSlice{
+			{Key: "annotations", Value: yaml.MapSlice{{Key: "capabilities", Value: "Basic Install"}}},
+		}},
+		{Key: "spec", Value: yaml.MapSlice{
+			{Key: "displayName", Value: opts.Name},
+			{Key: "description", Value: fmt.Sprintf("TODO(user): describe the %s operator", opts.Name)},
+			{Key: "maturity", Value: "alpha"},
+			{Key: "provider", Value: yaml.MapSlice{{Key: "name", Value: "TODO(user)"}}},
+			{Key: "installModes", Value: installModes},
+		}},
+	}, nil
+}
+
+// ownedCRDs returns the owned CRDs of the CSV for the served versions of a CRD.
+func ownedCRDs(crd yaml.MapSlice) []interface{} {
+	meta, _ := mapslice.Get(crd, "metadata").(yaml.MapSlice)
+	spec, _ := mapslice.Get(crd, "spec").(yaml.MapSlice)
+	names, _ := mapslice.Get(spec, "names").(yaml.MapSlice)
+	kind, _ := mapslice.Get(names, "kind").(string)
+	entry := func(version string, validation interface{}) yaml.MapSlice {
+		schema, _ := mapslice.Get(asMapSlice(validation), "openAPIV3Schema").(yaml.MapSlice)
+		description, _ := mapslice.Get(schema, "description").(string)
+		if description == "" {
+			description = kind
+		}
+		return yaml.MapSlice{
+			{Key: "name", Value: mapslice.Get(meta, "name")},
+			{Key: "version", Value: version},
+			{Key: "kind", Value: kind},
+			{Key: "displayName", Value: kind},
+			{Key: "description", Value: description},
+		}
+	}
+	versions, _ := mapslice.Get(spec, "versions").([]interface{})
+	if len(versions) == 0 {
+		version, _ := mapslice.Get(spec, "version").(string)
+		return []interface{}{entry(version, mapslice.Get(spec, "validation"))}
+	}
+	var owned []interface{}
+	for _, v := range versions {
+		version := asMapSlice(v)
+		if served, _ := mapslice.Get(version, "served").(bool); !served {
+			continue
+		}
+		validation := mapslice.Get(version, "schema")
+		if validation == nil {
+			validation = mapslice.Get(spec, "validation")
+		}
+		name, _ := mapslice.Get(version, "name").(string)
+		owned = append(owned, entry(name, validation))
+	}
+	return owned
+}
+
+// keepOwned returns the owned CRDs, keeping the ones of the existing CSV for
+// the same CRD version, e.g. with the displayName or the descriptors edited
+// by hand.
+func keepOwned(owned, existing []interface{}) []interface{} {
+	for i, o := range owned {
+		for _, e := range existing {
+			if mapslice.Get(asMapSlice(e), "name") == mapslice.Get(asMapSlice(o), "name") &&
+				mapslice.Get(asMapSlice(e), "version") == mapslice.Get(asMapSlice(o), "version") {
+				owned[i] = e
+			}
+		}
+	}
+	return owned
+}
+
+func roleBinding(doc yaml.MapSlice) binding {
+	kind, _ := mapslice.Get(doc, "kind").(string)
+	roleRef, _ := mapslice.Get(doc, "roleRef").(yaml.MapSlice)
+	b := binding{cluster: kind == "ClusterRoleBinding"}
+	b.roleKind, _ = mapslice.Get(roleRef, "kind").(string)
+	b.role, _ = mapslice.Get(roleRef, "name").(string)
+	subjects, _ := mapslice.Get(doc, "subjects").([]interface{})
+	for _, s := range subjects {
+		subject := asMapSlice(s)
+		if kind, _ := mapslice.Get(subject, "kind").(string); kind != "ServiceAccount" {
+			continue
+		}
+		name, _ := mapslice.Get(subject, "name").(string)
+		b.serviceAccounts = append(b.serviceAccounts, name)
+	}
+	return b
+}
+
+// permissions returns the rules granted to each service account by the role
+// bindings, in a namespace and cluster wide.
+func permissions(bindings []binding, rules map[string]interface{}) (permissions, clusterPermissions []interface{}) {
+	add := func(perms []interface{}, serviceAccount string, roleRules interface{}) []interface{} {
+		list, _ := roleRules.([]interface{})
+		for i, p := range perms {
+			perm := asMapSlice(p)
+			if mapslice.Get(perm, "serviceAccountName") == serviceAccount {
+				existing, _ := mapslice.Get(perm, "rules").([]interface{})
+				perms[i] = mapslice.Set(perm, "rules", append(existing, list...))
+				return perms
+			}
+		}
+		return append(perms, yaml.MapSlice{
+			{Key: "serviceAccountName", Value: serviceAccount},
+			{Key: "rules", Value: list},
+		})
+	}
+	for _, b := range bindings {
+		roleRules, found := rules[b.roleKind+"/"+b.role]
+		if !found {
+			continue
+		}
+		for _, sa := range b.serviceAccounts {
+			if b.cluster {
+				clusterPermissions = add(clusterPermissions, sa, roleRules)
+			} else {
+				permissions = add(permissions, sa, roleRules)
+			}
+		}
+	}
+	return permissions, clusterPermissions
+}
+
+// almExamples returns the JSON list of the samples.
+func almExamples(samples [][]byte) (string, error) {
+	examples := []interface{}{}
+	for _, sample := range samples {
+		dec := yaml.NewDecoder(bytes.NewReader(sample))
+		for {
+			var example interface{}
+			if err := dec.Decode(&example); err == io.EOF {
+				break
+			} else if err != nil {
+				return "", fmt.Errorf("error parsing the samples: %v", err)
+			}
+			if example != nil {
+				examples = append(examples, mapslice.JSONValue(example))
+			}
+		}
+	}
+	content, err := json.MarshalIndent(examples, "", "  ")
+	return string(content), err
+}
+
+func asMapSlice(value interface{}) yaml.MapSlice {
+	m, _ := value.(yaml.MapSlice)
+	return m
+}
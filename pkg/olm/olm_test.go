@@ -0,0 +1,261 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package olm_test
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/kubebuilder/pkg/olm"
+)
+
+const manifests = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: proj-system
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: captains.crew.example.com
+spec:
+  group: crew.example.com
+  names:
+    kind: Captain
+    plural: captains
+  validation:
+    openAPIV3Schema:
+      description: Captain is the Schema for the captains API
+  versions:
+  - name: v1
+    served: true
+    storage: true
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: proj-leader-election-role
+  namespace: proj-system
+rules:
+- apiGroups: [""]
+  resources: [configmaps]
+  verbs: [get, create]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: proj-manager-role
+rules:
+- apiGroups: [crew.example.com]
+  resources: [captains]
+  verbs: [get, list, watch]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: proj-leader-election-rolebinding
+  namespace: proj-system
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: proj-leader-election-role
+subjects:
+- kind: ServiceAccount
+  name: default
+  namespace: proj-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: proj-manager-rolebinding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: proj-manager-role
+subjects:
+- kind: ServiceAccount
+  name: default
+  namespace: proj-system
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: proj-controller-manager-metrics-service
+  namespace: proj-system
+spec:
+  ports:
+  - port: 8443
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: proj-controller-manager
+  namespace: proj-system
+spec:
+  replicas: 1
+---
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: proj-validating-webhook-configuration
+`
+
+const sample = `apiVersion: crew.example.com/v1
+kind: Captain
+metadata:
+  name: captain-sample
+`
+
+func TestRender(t *testing.T) {
+	bundle, err := olm.Render([]byte(manifests), olm.Options{
+		Name:     "proj",
+		Version:  "0.1.0",
+		Channels: []string{"alpha", "stable"},
+		Samples:  [][]byte{[]byte(sample)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	for file := range bundle.Manifests {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	wantFiles := []string{
+		"captains.crew.example.com.yaml",
+		"proj-controller-manager-metrics-service_service.yaml",
+		"proj.clusterserviceversion.yaml",
+	}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("got manifests %v, want %v", files, wantFiles)
+	}
+	if service := string(bundle.Manifests[wantFiles[1]]); strings.Contains(service, "namespace") {
+		t.Errorf("the Service keeps its namespace:\n%s", service)
+	}
+	if want := []string{"ValidatingWebhookConfiguration/proj-validating-webhook-configuration"}; !reflect.DeepEqual(bundle.Skipped, want) {
+		t.Errorf("got skipped %v, want %v", bundle.Skipped, want)
+	}
+
+	var csv struct {
+		Metadata struct {
+			Name        string            `yaml:"name"`
+			Annotations map[string]string `yaml:"annotations"`
+		} `yaml:"metadata"`
+		Spec struct {
+			Version string `yaml:"version"`
+			CRDs    struct {
+				Owned []map[string]string `yaml:"owned"`
+			} `yaml:"customresourcedefinitions"`
+			Install struct {
+				Strategy string `yaml:"strategy"`
+				Spec     struct {
+					Deployments []struct {
+						Name string `yaml:"name"`
+					} `yaml:"deployments"`
+					Permissions []struct {
+						ServiceAccountName string        `yaml:"serviceAccountName"`
+						Rules              []interface{} `yaml:"rules"`
+					} `yaml:"permissions"`
+					ClusterPermissions []struct {
+						ServiceAccountName string        `yaml:"serviceAccountName"`
+						Rules              []interface{} `yaml:"rules"`
+					} `yaml:"clusterPermissions"`
+				} `yaml:"spec"`
+			} `yaml:"install"`
+		} `yaml:"spec"`
+	}
+	if err := yaml.Unmarshal(bundle.Manifests["proj.clusterserviceversion.yaml"], &csv); err != nil {
+		t.Fatal(err)
+	}
+	if csv.Metadata.Name != "proj.v0.1.0" || csv.Spec.Version != "0.1.0" {
+		t.Errorf("got name %q and version %q", csv.Metadata.Name, csv.Spec.Version)
+	}
+	if examples := csv.Metadata.Annotations["alm-examples"]; !strings.Contains(examples, `"name": "captain-sample"`) {
+		t.Errorf("the alm-examples miss the sample: %s", examples)
+	}
+	wantOwned := []map[string]string{{
+		"name":        "captains.crew.example.com",
+		"version":     "v1",
+		"kind":        "Captain",
+		"displayName": "Captain",
+		"description": "Captain is the Schema for the captains API",
+	}}
+	if !reflect.DeepEqual(csv.Spec.CRDs.Owned, wantOwned) {
+		t.Errorf("got owned CRDs %v, want %v", csv.Spec.CRDs.Owned, wantOwned)
+	}
+	install := csv.Spec.Install.Spec
+	if len(install.Deployments) != 1 || install.Deployments[0].Name != "proj-controller-manager" {
+		t.Errorf("got deployments %v", install.Deployments)
+	}
+	if len(install.Permissions) != 1 || install.Permissions[0].ServiceAccountName != "default" ||
+		len(install.Permissions[0].Rules) != 1 {
+		t.Errorf("got permissions %v", install.Permissions)
+	}
+	if len(install.ClusterPermissions) != 1 || install.ClusterPermissions[0].ServiceAccountName != "default" ||
+		len(install.ClusterPermissions[0].Rules) != 1 {
+		t.Errorf("got cluster permissions %v", install.ClusterPermissions)
+	}
+
+	for _, want := range []string{
+		"operators.operatorframework.io.bundle.package.v1: proj",
+		"operators.operatorframework.io.bundle.channels.v1: alpha,stable",
+		"operators.operatorframework.io.bundle.channel.default.v1: alpha",
+	} {
+		if !strings.Contains(string(bundle.Annotations), want) {
+			t.Errorf("the annotations miss %q:\n%s", want, bundle.Annotations)
+		}
+		label := "LABEL " + strings.Replace(want, ": ", "=", 1)
+		if !strings.Contains(string(bundle.Dockerfile), label) {
+			t.Errorf("the Dockerfile misses %q:\n%s", label, bundle.Dockerfile)
+		}
+	}
+}
+
+func TestRenderKeepsCSV(t *testing.T) {
+	bundle, err := olm.Render([]byte(manifests), olm.Options{
+		Name:     "proj",
+		Version:  "0.2.0",
+		Channels: []string{"alpha"},
+		CSV: []byte(`apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: proj.v0.1.0
+spec:
+  description: Runs the captains
+  customresourcedefinitions:
+    owned:
+    - name: captains.crew.example.com
+      version: v1
+      kind: Captain
+      displayName: Ship Captain
+`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv := string(bundle.Manifests["proj.clusterserviceversion.yaml"])
+	for _, want := range []string{"name: proj.v0.2.0", "description: Runs the captains", "displayName: Ship Captain"} {
+		if !strings.Contains(csv, want) {
+			t.Errorf("the CSV misses %q:\n%s", want, csv)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plugin lets external tools scaffold their own project flavors with
+// kubebuilder init and create, and add their own subcommands to kubebuilder.
+//
+// A plugin is either compiled in, registering its Scaffolder with Register
+// from an init function, or a kubebuilder-<name> executable found in the
+// PATH. The executables are run with the subcommand and its arguments, e.g.
+// kubebuilder init --plugin foo --bar runs kubebuilder-foo init --bar, and
+// kubebuilder foo --bar runs kubebuilder-foo --bar.
+package plugin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BinaryPrefix prefixes the name of the plugin executables.
+const BinaryPrefix = "kubebuilder-"
+
+// Scaffolder scaffolds a project flavor.
+type Scaffolder interface {
+	// Name is the name the plugin is selected with
+	Name() string
+	// Description is the one line summary of the plugin listed by kubebuilder --help
+	Description() string
+	// Init scaffolds a new project, given the arguments of kubebuilder init
+	Init(args []string) error
+	// Create scaffolds into an existing project, given the arguments of
+	// kubebuilder create, starting with its subcommand
+	Create(args []string) error
+}
+
+// Commander is implemented by the plugins adding a kubebuilder <name>
+// subcommand.
+type Commander interface {
+	// Run runs the subcommand, given its arguments
+	Run(args []string) error
+}
+
+var (
+	mu         sync.Mutex
+	registered = map[string]Scaffolder{}
+)
+
+// Register makes a compiled in plugin available. It panics if a plugin with
+// the same name is already registered.
+func Register(s Scaffolder) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, found := registered[s.Name()]; found {
+		panic(fmt.Sprintf("plugin %q registered twice", s.Name()))
+	}
+	registered[s.Name()] = s
+}
+
+// List returns the registered plugins and the plugin executables found in the
+// directories of path, sorted by name. A registered plugin hides the
+// executables of the same name, and an executable hides those of the same
+// name found later in path.
+func List(path string) []Scaffolder {
+	mu.Lock()
+	plugins := map[string]Scaffolder{}
+	for name, s := range registered {
+		plugins[name] = s
+	}
+	mu.Unlock()
+
+	for _, s := range Discover(path) {
+		if _, found := plugins[s.Name()]; !found {
+			plugins[s.Name()] = s
+		}
+	}
+
+	list := make([]Scaffolder, 0, len(plugins))
+	for _, s := range plugins {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// Get returns the plugin of the given name, as listed by List.
+func Get(path, name string) (Scaffolder, error) {
+	for _, s := range List(path) {
+		if s.Name() == name {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown plugin %q, neither registered nor found as %s%s in the PATH",
+		name, BinaryPrefix, name)
+}
+
+// Discover returns the plugin executables found in the directories of path,
+// in the order of path.
+func Discover(path string) []*Binary {
+	found := map[string]bool{}
+	binaries := []*Binary{}
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			// the PATH may list directories which do not exist
+			continue
+		}
+		for _, f := range files {
+			name := strings.TrimPrefix(f.Name(), BinaryPrefix)
+			if name == f.Name() || name == "" || found[name] || !executable(f) {
+				continue
+			}
+			found[name] = true
+			binaries = append(binaries, &Binary{PluginName: name, Path: filepath.Join(dir, f.Name())})
+		}
+	}
+	return binaries
+}
+
+func executable(f os.FileInfo) bool {
+	return f.Mode().IsRegular() && f.Mode().Perm()&0111 != 0
+}
+
+var _ Scaffolder = &Binary{}
+var _ Commander = &Binary{}
+
+// Binary is a plugin executable, run with the standard streams of kubebuilder.
+type Binary struct {
+	// PluginName is the name of the executable, without BinaryPrefix
+	PluginName string
+	// Path is the path of the executable
+	Path string
+}
+
+// Name implements Scaffolder
+func (b *Binary) Name() string {
+	return b.PluginName
+}
+
+// Description implements Scaffolder
+func (b *Binary) Description() string {
+	return fmt.Sprintf("plugin executable %s", b.Path)
+}
+
+// Init implements Scaffolder, running the executable with init and args.
+func (b *Binary) Init(args []string) error {
+	return b.Run(append([]string{"init"}, args...))
+}
+
+// Create implements Scaffolder, running the executable with create and args.
+func (b *Binary) Create(args []string) error {
+	return b.Run(append([]string{"create"}, args...))
+}
+
+// Run implements Commander, running the executable with args. The returned
+// error is an *exec.ExitError if the executable exits with a non-zero code.
+func (b *Binary) Run(args []string) error {
+	cmd := exec.Command(b.Path, args...) // #nosec
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/plugin"
+)
+
+type registeredPlugin struct{ name string }
+
+func (p registeredPlugin) Name() string               { return p.name }
+func (p registeredPlugin) Description() string        { return "registered" }
+func (p registeredPlugin) Init(args []string) error   { return nil }
+func (p registeredPlugin) Create(args []string) error { return nil }
+
+func TestList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	first, second := filepath.Join(dir, "first"), filepath.Join(dir, "second")
+	files := map[string]os.FileMode{
+		filepath.Join(first, "kubebuilder-foo"):      0755,
+		filepath.Join(first, "kubebuilder-noexec"):   0644,
+		filepath.Join(first, "kubebuilder-shadowed"): 0755,
+		filepath.Join(first, "other"):                0755,
+		filepath.Join(second, "kubebuilder-bar"):     0755,
+		filepath.Join(second, "kubebuilder-foo"):     0755,
+	}
+	for path, mode := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plugin.Register(registeredPlugin{name: "shadowed"})
+
+	path := strings.Join([]string{first, filepath.Join(dir, "missing"), second}, string(os.PathListSeparator))
+	got := map[string]string{}
+	for _, p := range plugin.List(path) {
+		got[p.Name()] = p.Description()
+	}
+	want := map[string]string{
+		"bar":      "plugin executable " + filepath.Join(second, "kubebuilder-bar"),
+		"foo":      "plugin executable " + filepath.Join(first, "kubebuilder-foo"),
+		"shadowed": "registered",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+
+	if _, err := plugin.Get(path, "noexec"); err == nil {
+		t.Errorf("Get(noexec) succeeded, want an error for the file which is not executable")
+	}
+}
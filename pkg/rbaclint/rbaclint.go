@@ -0,0 +1,435 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rbaclint checks the +kubebuilder:rbac markers of a package against
+// the resources its code reads and writes with the controller-runtime client.
+//
+// A marker granting access to a resource the code of the package never uses
+// is reported, as well as the resources the code uses without a marker
+// granting the verb it needs. The resources are recognized from the types of
+// the objects passed to the client, the controller builder and the event
+// recorder. Unstructured objects, objects typed as interfaces, and the
+// subresources other than status, are not checked.
+package rbaclint
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/markbates/inflect"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// Analyzer reports the mismatches between the +kubebuilder:rbac markers of a
+// package and the resources its code uses.
+var Analyzer = &analysis.Analyzer{
+	Name: "rbaclint",
+	Doc: "check the +kubebuilder:rbac markers of a package against the resources its code uses\n\n" +
+		"Markers granting access to resources the package never uses are reported, as well as\n" +
+		"the resources the package reads or writes without a marker granting the verb.",
+	Run: run,
+}
+
+const rbacMarker = "+kubebuilder:rbac:"
+
+const (
+	runtimePkg      = "k8s.io/apimachinery/pkg/runtime"
+	unstructuredPkg = "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	builderPkg      = "sigs.k8s.io/controller-runtime/pkg/builder"
+	sourcePkg       = "sigs.k8s.io/controller-runtime/pkg/source"
+	recordPkg       = "k8s.io/client-go/tools/record"
+	coreAPIPrefix   = "k8s.io/api/"
+)
+
+// clientVerbs maps the methods of the controller-runtime client to the verb
+// they need, and the index of their object argument.
+var clientVerbs = map[string]struct {
+	verb string
+	arg  int
+}{
+	"Get":         {"get", 2},
+	"List":        {"list", 1},
+	"Create":      {"create", 1},
+	"Update":      {"update", 1},
+	"Patch":       {"patch", 1},
+	"Delete":      {"delete", 1},
+	"DeleteAllOf": {"deletecollection", 1},
+}
+
+// apiGroups maps the packages of k8s.io/api to their API group, the ones
+// missing are <package>.k8s.io.
+var apiGroups = map[string]string{
+	"core":        "",
+	"apps":        "apps",
+	"autoscaling": "autoscaling",
+	"batch":       "batch",
+	"extensions":  "extensions",
+	"policy":      "policy",
+	"rbac":        "rbac.authorization.k8s.io",
+}
+
+// rule is a resource granted by a +kubebuilder:rbac marker.
+type rule struct {
+	pos      token.Pos
+	groups   []string
+	resource string
+	verbs    []string
+	used     bool
+}
+
+// usage is a resource used by the code of the package.
+type usage struct {
+	pos token.Pos
+	// group is the API group of the resource, unknown for the types outside
+	// of k8s.io/api as it is set by their group version registration
+	group      string
+	knownGroup bool
+	resource   string
+	verbs      []string
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	var rules []*rule
+	var usages []usage
+	for _, file := range pass.Files {
+		rules = append(rules, fileRules(file)...)
+		usages = append(usages, fileUsages(pass, file)...)
+	}
+	if len(rules) == 0 {
+		// the package does not manage its RBAC with markers
+		return nil, nil
+	}
+
+	for _, u := range usages {
+		matched := false
+		for _, verb := range u.verbs {
+			granted := false
+			for _, r := range rules {
+				if !r.matches(u) {
+					continue
+				}
+				matched, r.used = true, true
+				if containsOrWildcard(r.verbs, verb) {
+					granted = true
+				}
+			}
+			if matched && !granted {
+				pass.Reportf(u.pos, "%s is used with the %s verb, which no +kubebuilder:rbac marker grants",
+					u.String(), verb)
+			}
+		}
+		if !matched {
+			pass.Reportf(u.pos, "%s is used without a +kubebuilder:rbac marker granting it", u.String())
+		}
+	}
+
+	for _, r := range rules {
+		if r.used || r.resource == "*" {
+			continue
+		}
+		if i := strings.Index(r.resource, "/"); i >= 0 && r.resource[i+1:] != "status" {
+			// the uses of the other subresources are not recognized
+			continue
+		}
+		pass.Reportf(r.pos, "+kubebuilder:rbac marker grants %s, which the package does not use", r.resource)
+	}
+	return nil, nil
+}
+
+func (u usage) String() string {
+	if !u.knownGroup {
+		return u.resource
+	}
+	if u.group == "" {
+		return fmt.Sprintf("%s of the core group", u.resource)
+	}
+	return fmt.Sprintf("%s.%s", u.resource, u.group)
+}
+
+func (r *rule) matches(u usage) bool {
+	if r.resource != u.resource && r.resource != "*" {
+		return false
+	}
+	if !u.knownGroup {
+		return true
+	}
+	for _, group := range r.groups {
+		if group == u.group || group == "*" || (group == "core" && u.group == "") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsOrWildcard(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// fileRules parses the +kubebuilder:rbac markers of file, skipping the ones
+// granting non-resource URLs.
+func fileRules(file *ast.File) []*rule {
+	var rules []*rule
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+			if !strings.HasPrefix(text, rbacMarker) {
+				continue
+			}
+			args := map[string][]string{}
+			for _, arg := range strings.Split(strings.TrimPrefix(text, rbacMarker), ",") {
+				kv := strings.SplitN(arg, "=", 2)
+				if len(kv) != 2 {
+					continue
+				}
+				var values []string
+				for _, v := range strings.Split(strings.Trim(kv[1], `"`), ";") {
+					values = append(values, strings.Trim(v, `"`))
+				}
+				args[strings.TrimSpace(kv[0])] = values
+			}
+			if _, found := args["urls"]; found {
+				continue
+			}
+			for _, resource := range args["resources"] {
+				rules = append(rules, &rule{
+					pos:      comment.Pos(),
+					groups:   args["groups"],
+					resource: resource,
+					verbs:    args["verbs"],
+				})
+			}
+		}
+	}
+	return rules
+}
+
+// fileUsages returns the resources used by the code of file.
+func fileUsages(pass *analysis.Pass, file *ast.File) []usage {
+	var usages []usage
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if u, ok := callUsage(pass, n); ok {
+				usages = append(usages, u)
+			}
+		case *ast.CompositeLit:
+			// &source.Kind{Type: &corev1.Pod{}} watches the resource of Type
+			if !isNamed(pass.TypesInfo.TypeOf(n), sourcePkg, "Kind") {
+				return true
+			}
+			for _, elt := range n.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Type" {
+					if u, ok := objectUsage(pass, kv.Value, false); ok {
+						u.verbs = []string{"list", "watch"}
+						usages = append(usages, u)
+					}
+				}
+			}
+		}
+		return true
+	})
+	return usages
+}
+
+// callUsage returns the resource used by a call to the client, the controller
+// builder or the event recorder.
+func callUsage(pass *analysis.Pass, call *ast.CallExpr) (usage, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return usage{}, false
+	}
+	sig, ok := pass.TypesInfo.TypeOf(call.Fun).(*types.Signature)
+	if !ok || pass.TypesInfo.Selections[sel] == nil {
+		return usage{}, false
+	}
+	method := sel.Sel.Name
+
+	switch method {
+	case "For", "Owns":
+		recv := pass.TypesInfo.TypeOf(sel.X)
+		if !isNamed(recv, builderPkg, "Builder") || len(call.Args) != 1 {
+			return usage{}, false
+		}
+		u, ok := objectUsage(pass, call.Args[0], false)
+		u.verbs = []string{"list", "watch"}
+		return u, ok
+	case "Event", "Eventf", "PastEventf", "AnnotatedEventf":
+		if !isNamed(pass.TypesInfo.TypeOf(sel.X), recordPkg, "EventRecorder") {
+			return usage{}, false
+		}
+		return usage{pos: call.Pos(), knownGroup: true, resource: "events",
+			verbs: []string{"create", "patch"}}, true
+	}
+
+	cv, found := clientVerbs[method]
+	if !found || len(call.Args) <= cv.arg || sig.Params().Len() <= cv.arg ||
+		!isNamed(sig.Params().At(cv.arg).Type(), runtimePkg, "Object") ||
+		!isNamed(sig.Params().At(0).Type(), "context", "Context") {
+		return usage{}, false
+	}
+	u, ok := objectUsage(pass, call.Args[cv.arg], method == "List")
+	if !ok {
+		return usage{}, false
+	}
+	u.pos = call.Pos()
+	u.verbs = []string{cv.verb}
+	// c.Status().Update(ctx, obj) writes the status subresource
+	if status, ok := sel.X.(*ast.CallExpr); ok {
+		if statusSel, ok := status.Fun.(*ast.SelectorExpr); ok && statusSel.Sel.Name == "Status" && len(status.Args) == 0 {
+			u.resource += "/status"
+		}
+	}
+	return u, true
+}
+
+// objectUsage returns the resource of the type of the object expr, a list of
+// the objects of the resource when list is set.
+func objectUsage(pass *analysis.Pass, expr ast.Expr, list bool) (usage, bool) {
+	t := pass.TypesInfo.TypeOf(expr)
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return usage{}, false
+	}
+	if _, ok := named.Underlying().(*types.Interface); ok {
+		// the objects of helpers taking any runtime.Object
+		return usage{}, false
+	}
+	path, kind := named.Obj().Pkg().Path(), named.Obj().Name()
+	if path == unstructuredPkg {
+		return usage{}, false
+	}
+	if list {
+		kind = strings.TrimSuffix(kind, "List")
+	}
+
+	u := usage{
+		pos:      expr.Pos(),
+		resource: inflect.NewDefaultRuleset().Pluralize(strings.ToLower(kind)),
+	}
+	if strings.HasPrefix(path, coreAPIPrefix) {
+		pkg := strings.Split(strings.TrimPrefix(path, coreAPIPrefix), "/")[0]
+		group, found := apiGroups[pkg]
+		if !found {
+			group = pkg + ".k8s.io"
+		}
+		u.group, u.knownGroup = group, true
+	}
+	return u, true
+}
+
+func isNamed(t types.Type, pkg, name string) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == pkg && named.Obj().Name() == name
+}
+
+// Lint loads the packages matching patterns from dir and runs Analyzer on
+// them, returning the diagnostics formatted as file:line:col: message, sorted
+// by position. The packages are type-checked from source, their dependencies
+// included.
+func Lint(dir string, patterns ...string) ([]string, error) {
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.LoadFiles, Dir: dir}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	type diagnostic struct {
+		pos     token.Position
+		message string
+	}
+	var diagnostics []diagnostic
+	fset := token.NewFileSet()
+	imp := importer.ForCompiler(fset, "source", nil)
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, fmt.Errorf("error loading package %s: %v", pkg.PkgPath, pkg.Errors[0])
+		}
+		var files []*ast.File
+		for _, filename := range pkg.GoFiles {
+			file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file)
+		}
+		info := &types.Info{
+			Types:      map[ast.Expr]types.TypeAndValue{},
+			Defs:       map[*ast.Ident]types.Object{},
+			Uses:       map[*ast.Ident]types.Object{},
+			Selections: map[*ast.SelectorExpr]*types.Selection{},
+		}
+		conf := &types.Config{
+			Importer: imp,
+			Sizes:    types.SizesFor("gc", build.Default.GOARCH),
+		}
+		typesPkg, err := conf.Check(pkg.PkgPath, fset, files, info)
+		if err != nil {
+			return nil, fmt.Errorf("error type-checking package %s: %v", pkg.PkgPath, err)
+		}
+
+		pass := &analysis.Pass{
+			Analyzer:   Analyzer,
+			Fset:       fset,
+			Files:      files,
+			OtherFiles: pkg.OtherFiles,
+			Pkg:        typesPkg,
+			TypesInfo:  info,
+			TypesSizes: conf.Sizes,
+			ResultOf:   map[*analysis.Analyzer]interface{}{},
+			Report: func(d analysis.Diagnostic) {
+				diagnostics = append(diagnostics, diagnostic{fset.Position(d.Pos), d.Message})
+			},
+		}
+		if _, err := Analyzer.Run(pass); err != nil {
+			return nil, fmt.Errorf("error analyzing package %s: %v", pkg.PkgPath, err)
+		}
+	}
+
+	sort.Slice(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i].pos, diagnostics[j].pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+	var out []string
+	for _, d := range diagnostics {
+		out = append(out, fmt.Sprintf("%s: %s", d.pos, d.message))
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbaclint_test
+
+import (
+	"bufio"
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/rbaclint"
+)
+
+var wantRegex = regexp.MustCompile("// want `(.*)`")
+
+// TestLint checks the diagnostics of the package testdata/src/a, whose
+// dependencies are stubbed in testdata/src, against its // want comments.
+func TestLint(t *testing.T) {
+	testdata, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{"GOPATH": testdata, "GO111MODULE": "off", "GOFLAGS": ""} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Setenv(key, value)
+	}
+	defer func(gopath string) { build.Default.GOPATH = gopath }(build.Default.GOPATH)
+	build.Default.GOPATH = testdata
+
+	dir := filepath.Join(testdata, "src", "a")
+	diagnostics, err := rbaclint.Lint(dir, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(dir, "a.go")
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var wants []string
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if m := wantRegex.FindStringSubmatch(scanner.Text()); m != nil {
+			wants = append(wants, fmt.Sprintf("%s:%d:", filename, line), m[1])
+		}
+	}
+
+	if len(diagnostics) != len(wants)/2 {
+		t.Fatalf("expected %d diagnostics, got %d:\n%s", len(wants)/2, len(diagnostics), strings.Join(diagnostics, "\n"))
+	}
+	for i, d := range diagnostics {
+		prefix, message := wants[2*i], wants[2*i+1]
+		if !strings.HasPrefix(d, prefix) || !regexp.MustCompile(message).MatchString(d) {
+			t.Errorf("expected a diagnostic at %s matching %q, got %s", prefix, message, d)
+		}
+	}
+}
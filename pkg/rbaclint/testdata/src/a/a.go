@@ -0,0 +1,41 @@
+package a
+
+import (
+	"context"
+
+	crewv1 "example.com/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// +kubebuilder:rbac:groups=crew.example.com,resources=captains,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=crew.example.com,resources=captains/status,verbs=get;update
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get // want `\+kubebuilder:rbac marker grants deployments, which the package does not use`
+// +kubebuilder:rbac:groups=crew.example.com,resources=captains/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:urls=/metrics,verbs=get
+
+func reconcile(ctx context.Context, c client.Client, recorder record.EventRecorder, b *builder.Builder, obj runtime.Object) {
+	b.For(&crewv1.Captain{}).Watches(&source.Kind{Type: &corev1.Pod{}})
+
+	var captain crewv1.Captain
+	_ = c.Get(ctx, client.ObjectKey{}, &captain)
+	_ = c.Status().Update(ctx, &captain)
+	_ = c.Delete(ctx, &captain) // want `captains is used with the delete verb, which no \+kubebuilder:rbac marker grants`
+
+	_ = c.Get(ctx, client.ObjectKey{}, &corev1.ConfigMap{})
+	_ = c.Create(ctx, &corev1.ConfigMap{}) // want `configmaps of the core group is used with the create verb, which no \+kubebuilder:rbac marker grants`
+	_ = c.List(ctx, &corev1.PodList{})
+	_ = c.Get(ctx, client.ObjectKey{}, &corev1.Secret{}) // want `secrets of the core group is used without a \+kubebuilder:rbac marker granting it`
+
+	// objects typed as interfaces are not checked
+	_ = c.Create(ctx, obj)
+
+	recorder.Event(&captain, "Normal", "Reconciled", "reconciled")
+}
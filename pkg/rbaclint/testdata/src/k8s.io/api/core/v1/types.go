@@ -0,0 +1,19 @@
+package v1
+
+import "k8s.io/apimachinery/pkg/runtime"
+
+type ConfigMap struct{}
+
+func (*ConfigMap) DeepCopyObject() runtime.Object { return nil }
+
+type Secret struct{}
+
+func (*Secret) DeepCopyObject() runtime.Object { return nil }
+
+type Pod struct{}
+
+func (*Pod) DeepCopyObject() runtime.Object { return nil }
+
+type PodList struct{}
+
+func (*PodList) DeepCopyObject() runtime.Object { return nil }
@@ -0,0 +1,11 @@
+package builder
+
+import "k8s.io/apimachinery/pkg/runtime"
+
+type Builder struct{}
+
+func (b *Builder) For(obj runtime.Object) *Builder { return b }
+
+func (b *Builder) Owns(obj runtime.Object) *Builder { return b }
+
+func (b *Builder) Watches(src interface{}) *Builder { return b }
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type ObjectKey struct{ Namespace, Name string }
+
+type Client interface {
+	Get(ctx context.Context, key ObjectKey, obj runtime.Object) error
+	List(ctx context.Context, list runtime.Object) error
+	Create(ctx context.Context, obj runtime.Object) error
+	Update(ctx context.Context, obj runtime.Object) error
+	Delete(ctx context.Context, obj runtime.Object) error
+	Status() StatusWriter
+}
+
+type StatusWriter interface {
+	Update(ctx context.Context, obj runtime.Object) error
+}
@@ -0,0 +1,7 @@
+package source
+
+import "k8s.io/apimachinery/pkg/runtime"
+
+type Kind struct {
+	Type runtime.Object
+}
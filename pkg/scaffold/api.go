@@ -18,15 +18,19 @@ package scaffold
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/controller"
 	resourcev1 "sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
 	resourcev2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2"
 	crdv2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2/crd"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/uninstall"
 )
 
 // API contains configuration for generating scaffolding for Go type
@@ -43,6 +47,23 @@ type API struct {
 
 	// DoController indicates whether to scaffold controller files or not
 	DoController bool
+
+	// StatusApply indicates whether to scaffold the helper applying the status
+	// of the resource with server-side apply
+	StatusApply bool
+
+	// RequiredAPIs are the APIs, as <group>/<version>/<Kind>, the controller
+	// is only set up with when the cluster serves them
+	RequiredAPIs []string
+
+	// Force regenerates the scaffold of a resource which already exists,
+	// merging it into the Go files of the resource rather than failing
+	Force bool
+
+	// QualifiedGroup indicates the group of the resource is fully qualified,
+	// e.g. widgets.legacy.io, rather than suffixed with the domain of the
+	// project. Its domain is recorded with the resource in the project file.
+	QualifiedGroup bool
 }
 
 // Validate validates whether API scaffold has correct bits to generate
@@ -60,6 +81,158 @@ func (api *API) Validate() error {
 	if api.Resource.Kind == "" {
 		return fmt.Errorf("missing kind information for resource")
 	}
+	if err := api.validateQualifiedGroup(); err != nil {
+		return err
+	}
+	if err := validateSchemeRegistration(api.project.SchemeRegistration); err != nil {
+		return err
+	}
+	if api.Force && !project.HasV2Layout(api.project.Version) {
+		return fmt.Errorf("regenerating a resource with --force is not supported for project version %s", api.project.Version)
+	}
+	if api.StatusApply {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("status apply helpers are not supported for project version %s", api.project.Version)
+		}
+		if !api.DoController {
+			return fmt.Errorf("status apply helpers are scaffolded with the controller")
+		}
+	}
+	if api.Resource.CreationGuard {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("creation guard is not supported for project version %s", api.project.Version)
+		}
+		if !api.DoResource || !api.DoController {
+			return fmt.Errorf("creation guard is scaffolded with both the resource and the controller")
+		}
+	}
+	if len(api.Resource.Phases) > 0 {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("phases are not supported for project version %s", api.project.Version)
+		}
+		if !api.DoResource {
+			return fmt.Errorf("phases are scaffolded with the resource")
+		}
+		if err := resourcev2.ValidatePhases(api.Resource.Phases); err != nil {
+			return err
+		}
+	}
+	if api.Resource.ReconcileStatus {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("reconcile status is not supported for project version %s", api.project.Version)
+		}
+		if !api.DoResource || !api.DoController {
+			return fmt.Errorf("reconcile status is scaffolded with both the resource and the controller")
+		}
+	}
+	if api.Resource.TransitionEvents {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("transition events are not supported for project version %s", api.project.Version)
+		}
+		if !api.DoResource || !api.DoController {
+			return fmt.Errorf("transition events are scaffolded with both the resource and the controller")
+		}
+		if !api.Resource.WithConditions && !api.Resource.CreationGuard && len(api.Resource.Phases) == 0 {
+			return fmt.Errorf("transition events require the conditions or the phases of the resource")
+		}
+	}
+	if api.Resource.Expectations {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("expectations are not supported for project version %s", api.project.Version)
+		}
+		if !api.DoController {
+			return fmt.Errorf("expectations are scaffolded with the controller")
+		}
+	}
+	if api.Resource.SpecHash {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("spec hashes are not supported for project version %s", api.project.Version)
+		}
+		if !api.DoController {
+			return fmt.Errorf("spec hashes are scaffolded with the controller")
+		}
+	}
+	if api.Resource.PayloadReference {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("payload references are not supported for project version %s", api.project.Version)
+		}
+		if !api.DoResource || !api.DoController {
+			return fmt.Errorf("payload references are scaffolded with both the resource and the controller")
+		}
+	}
+	if api.Resource.ValidationStubs {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("validation stubs are not supported for project version %s", api.project.Version)
+		}
+		if !api.DoResource {
+			return fmt.Errorf("validation stubs are scaffolded with the resource")
+		}
+	}
+	if api.Resource.WithConditions {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("conditions are not supported for project version %s", api.project.Version)
+		}
+		if !api.DoResource {
+			return fmt.Errorf("conditions are scaffolded with the resource")
+		}
+	}
+	if api.Resource.Scale {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("the scale subresource is not supported for project version %s", api.project.Version)
+		}
+		if !api.DoResource {
+			return fmt.Errorf("the scale subresource is scaffolded with the resource")
+		}
+	}
+	if api.Resource.Optional {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("optional APIs are not supported for project version %s", api.project.Version)
+		}
+		if !api.DoResource {
+			return fmt.Errorf("optional APIs are scaffolded with the resource")
+		}
+	}
+	if api.Resource.External {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("external resources are not supported for project version %s", api.project.Version)
+		}
+		if api.DoResource {
+			return fmt.Errorf("the types of external resources are not scaffolded, only their controller")
+		}
+		if !api.DoController {
+			return fmt.Errorf("external resources are scaffolded with the controller")
+		}
+		if api.Resource.ExternalAPIPath == "" && !resourcev2.IsCoreGroup(api.Resource.Group) {
+			return fmt.Errorf("group %s is not one of k8s.io/api, set the import path of its package with --external-api-path",
+				api.Resource.Group)
+		}
+	} else if api.Resource.ExternalAPIPath != "" || api.Resource.ExternalDomain != "" {
+		return fmt.Errorf("the external API path and domain are only used with external resources")
+	}
+	if api.Resource.Finalizer {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("finalizers are not supported for project version %s", api.project.Version)
+		}
+		if !api.DoController {
+			return fmt.Errorf("finalizers are handled by the controller")
+		}
+	}
+	if len(api.RequiredAPIs) > 0 {
+		if !project.HasV2Layout(api.project.Version) {
+			return fmt.Errorf("required APIs are not supported for project version %s", api.project.Version)
+		}
+		if !api.DoController {
+			return fmt.Errorf("required APIs gate the set up of the controller")
+		}
+		if _, err := os.Stat(filepath.Join("capabilities", "capabilities.go")); err != nil {
+			return fmt.Errorf("required APIs are detected with the capabilities of projects initialized with --capabilities")
+		}
+		for _, s := range api.RequiredAPIs {
+			if _, err := resourcev2.ParseRequiredAPI(s); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -74,6 +247,65 @@ func (api *API) setDefaults() error {
 	return nil
 }
 
+// validateQualifiedGroup splits a fully qualified group into the group and the
+// domain of the resource. The resources of a group already in the project file
+// otherwise keep the domain recorded for it.
+func (api *API) validateQualifiedGroup() error {
+	r := api.Resource
+	if !api.QualifiedGroup {
+		if strings.Contains(r.Group, ".") {
+			return fmt.Errorf("group %s is fully qualified, use --force-group-suffix=false to create it "+
+				"without the domain %s of the project", r.Group, api.project.Domain)
+		}
+		for _, res := range api.project.Resources {
+			if res.Group == r.Group {
+				r.Domain = res.Domain
+				break
+			}
+		}
+		return nil
+	}
+	if !project.HasV2Layout(api.project.Version) {
+		return fmt.Errorf("fully qualified groups are not supported for project version %s", api.project.Version)
+	}
+	if r.External {
+		return fmt.Errorf("the domain of the group of an external resource is set with --external-domain")
+	}
+	i := strings.Index(r.Group, ".")
+	if i < 0 {
+		return fmt.Errorf("group %s is not fully qualified, e.g. %s.legacy.io, as expected with --force-group-suffix=false",
+			r.Group, r.Group)
+	}
+	group, domain := r.Group[:i], r.Group[i+1:]
+	// a group suffixed with the domain of the project is not qualified
+	if domain != api.project.Domain {
+		r.Domain = domain
+	}
+	r.Group = group
+	for _, res := range api.project.Resources {
+		if res.Group == r.Group && res.Domain != r.Domain {
+			return fmt.Errorf("group %s of the project is already %s, not %s",
+				r.Group, groupDomainOf(api.project, res), r.GroupDomain(api.project.Domain))
+		}
+	}
+	return r.Validate()
+}
+
+// groupDomainOf returns the API group of the given resource of the project.
+func groupDomainOf(p *input.ProjectFile, res input.Resource) string {
+	domain := res.Domain
+	if domain == "" {
+		domain = p.Domain
+	}
+	return res.Group + "." + domain
+}
+
+// newScaffold returns the Scaffold of the files of the resource, merging the
+// existing Go files with --force, with the domain of the group of the resource.
+func (api *API) newScaffold() *Scaffold {
+	return &Scaffold{Merge: api.Force, Domain: api.Resource.Domain}
+}
+
 func (api *API) Scaffold() error {
 	if err := api.setDefaults(); err != nil {
 		return err
@@ -82,7 +314,7 @@ func (api *API) Scaffold() error {
 	switch ver := api.project.Version; ver {
 	case project.Version1:
 		return api.scaffoldV1()
-	case project.Version2:
+	case project.Version2, project.Version3:
 		return api.scaffoldV2()
 	default:
 		return fmt.Errorf("")
@@ -147,20 +379,15 @@ func (api *API) scaffoldV2() error {
 			return err
 		}
 
-		fmt.Println(filepath.Join("api", r.Version,
+		fmt.Println(filepath.Join(apiDir(api.project, r),
 			fmt.Sprintf("%s_types.go", strings.ToLower(r.Kind))))
 
-		err := (&Scaffold{}).Execute(
+		err := api.newScaffold().Execute(
 			input.Options{},
-			&resourcev2.Types{
-				Input: input.Input{
-					Path: filepath.Join("api", r.Version, fmt.Sprintf("%s_types.go", strings.ToLower(r.Kind))),
-				},
-				Resource: r},
+			&resourcev2.Types{Resource: r},
 			&resourcev2.VersionSuiteTest{Resource: r},
 			&resourcev2.TypesTest{Resource: r},
 			&resourcev2.Group{Resource: r},
-			&resourcev2.CRDSample{Resource: r},
 			&crdv2.EnableWebhookPatch{Resource: r},
 			&crdv2.EnableCAInjectionPatch{Resource: r},
 		)
@@ -168,8 +395,53 @@ func (api *API) scaffoldV2() error {
 			return fmt.Errorf("error scaffolding APIs: %v", err)
 		}
 
+		if r.CreationGuard || r.WithConditions {
+			err = api.newScaffold().Execute(
+				input.Options{},
+				&resourcev2.Conditions{Resource: r},
+			)
+			if err != nil {
+				return fmt.Errorf("error scaffolding conditions: %v", err)
+			}
+			conditionTypes := filepath.Join(apiDir(api.project, r), "condition_types.go")
+			if content, err := ioutil.ReadFile(conditionTypes); err == nil && r.WithConditions &&
+				!strings.Contains(string(content), "func GetCondition(") {
+				result.Warnf("%s predates GetCondition and IsConditionTrue, add them to use the conditions of %s.",
+					conditionTypes, r.Kind)
+			}
+		}
+
+		if r.PayloadReference {
+			err = api.newScaffold().Execute(
+				input.Options{},
+				&resourcev2.PayloadTypes{Resource: r},
+			)
+			if err != nil {
+				return fmt.Errorf("error scaffolding payload types: %v", err)
+			}
+		}
+
+		if r.EmbedPodTemplate {
+			err = api.newScaffold().Execute(
+				input.Options{},
+				&resourcev2.EmbeddedTypes{Resource: r},
+			)
+			if err != nil {
+				return fmt.Errorf("error scaffolding embedded types: %v", err)
+			}
+		}
+
+		// the sample is filled in from the example markers of the types
+		err = api.newScaffold().Execute(
+			input.Options{},
+			&resourcev2.CRDSample{Resource: r, FromExamples: true},
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding sample: %v", err)
+		}
+
 		crdKustomization := &crdv2.Kustomization{Resource: r}
-		err = (&Scaffold{}).Execute(
+		err = api.newScaffold().Execute(
 			input.Options{},
 			crdKustomization,
 			&crdv2.KustomizeConfig{},
@@ -183,12 +455,42 @@ func (api *API) scaffoldV2() error {
 			return fmt.Errorf("error updating kustomization.yaml: %v", err)
 		}
 
-		// update scaffolded resource in project file
-		api.project.Resources = append(api.project.Resources,
-			input.Resource{Group: r.Group, Version: r.Version, Kind: r.Kind})
-		err = saveProjectFile("PROJECT", api.project)
-		if err != nil {
-			fmt.Printf("error updating project file with resource information : %v \n", err)
+		if r.Optional {
+			component := &resourcev2.OptionalAPIKustomization{Resource: r, Controller: api.DoController}
+			files := []input.File{component, &resourcev2.OptionalAPICRDPatch{Resource: r}}
+			if api.DoController {
+				files = append(files, &resourcev2.OptionalAPIManagerPatch{Resource: r})
+			}
+			if err := api.newScaffold().Execute(input.Options{}, files...); err != nil {
+				return fmt.Errorf("error scaffolding optional API component: %v", err)
+			}
+			if err := component.Update(); err != nil {
+				return fmt.Errorf("error updating config/default/kustomization.yaml: %v", err)
+			}
+		}
+
+		// projects edited with --uninstall-job clean up the CRs of every CRD
+		if _, err := os.Stat(filepath.Join("config", "uninstall", "job.yaml")); err == nil {
+			uninstallJob := &uninstall.Job{Input: input.Input{Domain: api.project.Domain}}
+			if err := uninstallJob.Update(r); err != nil {
+				return fmt.Errorf("error updating uninstall job: %v", err)
+			}
+		}
+
+		// update scaffolded resource in project file, with the controller
+		// below for project version 3
+		if api.project.Version == project.Version3 {
+			trackResource(api.project, r).API = &input.ResourceAPI{
+				CRDVersion: project.CRDVersionV1beta1,
+				Namespaced: r.Namespaced,
+			}
+		} else if api.project.GetResource(r.Group, r.Version, r.Kind) == nil {
+			api.project.Resources = append(api.project.Resources,
+				input.Resource{Group: r.Group, Version: r.Version, Kind: r.Kind, Domain: r.Domain})
+			err = saveProjectFile("PROJECT", api.project)
+			if err != nil {
+				result.Warnf("error updating project file with resource information : %v", err)
+			}
 		}
 
 	} else {
@@ -199,12 +501,41 @@ func (api *API) scaffoldV2() error {
 		r.CreateExampleReconcileBody = false
 	}
 
+	// projects initialized with --settings reconcile the objects of each
+	// controller on the changes of the settings
+	wireSettings := false
+	if _, err := os.Stat(filepath.Join("controllers", "settings.go")); err == nil {
+		wireSettings = api.DoController
+	}
+	// projects initialized with --reconcile-timeout cancel the context of the
+	// reconciles running past the timeout
+	wireReconcileTimeout := false
+	if _, err := os.Stat(filepath.Join("controllers", "reconcile_timeout.go")); err == nil {
+		wireReconcileTimeout = api.DoController
+	}
+	// projects initialized with --read-only only write the status of the
+	// objects under the read-only mode of the manager
+	wireReadOnly := false
+	if _, err := os.Stat(filepath.Join("controllers", "read_only.go")); err == nil {
+		wireReadOnly = api.DoController
+	}
+	// projects initialized with --heartbeat report the last reconcile time of
+	// each controller
+	wireHeartbeat := false
+	if _, err := os.Stat(filepath.Join("controllers", "heartbeat.go")); err == nil {
+		wireHeartbeat = api.DoController
+	}
+
 	if api.DoController {
-		fmt.Println(filepath.Join("controllers", fmt.Sprintf("%s_controller.go", strings.ToLower(r.Kind))))
+		fmt.Println(filepath.Join(controllersDir(api.project, r), fmt.Sprintf("%s_controller.go", strings.ToLower(r.Kind))))
 
-		ctrlScaffolder := &resourcev2.Controller{Resource: r}
-		testsuiteScaffolder := &resourcev2.ControllerSuiteTest{Resource: r}
-		err := (&Scaffold{}).Execute(
+		ctrlScaffolder := &resourcev2.Controller{Resource: r, Settings: wireSettings,
+			ReconcileTimeout: wireReconcileTimeout, ReadOnly: wireReadOnly, Heartbeat: wireHeartbeat}
+		testsuiteScaffolder := &resourcev2.ControllerSuiteTest{
+			Resource:           r,
+			SchemeRegistration: api.project.SchemeRegistration,
+		}
+		err := api.newScaffold().Execute(
 			input.Options{},
 			testsuiteScaffolder,
 			ctrlScaffolder,
@@ -217,25 +548,168 @@ func (api *API) scaffoldV2() error {
 		if err != nil {
 			return fmt.Errorf("error updating suite_test.go under controllers pkg: %v", err)
 		}
+
+		if r.CreationGuard {
+			err = api.newScaffold().Execute(
+				input.Options{},
+				&resourcev2.CreationGuard{Resource: r},
+			)
+			if err != nil {
+				return fmt.Errorf("error scaffolding creation guard: %v", err)
+			}
+		}
+
+		if r.Finalizer {
+			files := []input.File{&resourcev2.Finalizers{Resource: r}}
+			if !r.External {
+				files = append(files, &resourcev2.FinalizerTest{Resource: r})
+			}
+			err = api.newScaffold().Execute(input.Options{}, files...)
+			if err != nil {
+				return fmt.Errorf("error scaffolding finalizer: %v", err)
+			}
+		}
+
+		if r.Expectations {
+			err = api.newScaffold().Execute(
+				input.Options{},
+				&resourcev2.Expectations{Resource: r},
+			)
+			if err != nil {
+				return fmt.Errorf("error scaffolding expectations: %v", err)
+			}
+		}
+
+		if r.SpecHash {
+			err = api.newScaffold().Execute(
+				input.Options{},
+				&resourcev2.SpecHash{Resource: r},
+			)
+			if err != nil {
+				return fmt.Errorf("error scaffolding spec hashes: %v", err)
+			}
+		}
+
+		if r.PayloadReference {
+			err = api.newScaffold().Execute(
+				input.Options{},
+				&resourcev2.ControllerPayload{Resource: r},
+			)
+			if err != nil {
+				return fmt.Errorf("error scaffolding payload resolution: %v", err)
+			}
+		}
+
+		if len(r.Phases) > 0 && api.DoResource {
+			err = api.newScaffold().Execute(
+				input.Options{},
+				&resourcev2.PhaseTransitions{Resource: r},
+			)
+			if err != nil {
+				return fmt.Errorf("error scaffolding phase transitions: %v", err)
+			}
+		}
+
+		if r.TransitionEvents {
+			err = api.newScaffold().Execute(
+				input.Options{},
+				&resourcev2.Events{Resource: r},
+				&resourcev2.TransitionEvents{Resource: r},
+			)
+			if err != nil {
+				return fmt.Errorf("error scaffolding transition events: %v", err)
+			}
+		}
+
+		if r.ReconcileStatus {
+			err = api.newScaffold().Execute(
+				input.Options{},
+				&resourcev2.ReconcileStatus{Resource: r},
+			)
+			if err != nil {
+				return fmt.Errorf("error scaffolding reconcile status: %v", err)
+			}
+		}
+
+		if api.StatusApply {
+			typesPath := filepath.Join(apiDir(api.project, r), fmt.Sprintf("%s_types.go", strings.ToLower(r.Kind)))
+			if _, err := os.Stat(typesPath); err != nil {
+				return fmt.Errorf("status apply helpers require the API types of the project at %s: %v", typesPath, err)
+			}
+
+			err = api.newScaffold().Execute(
+				input.Options{},
+				&resourcev2.ApplyPatch{Resource: r},
+				&resourcev2.StatusApply{Resource: r},
+			)
+			if err != nil {
+				return fmt.Errorf("error scaffolding status apply helpers: %v", err)
+			}
+		}
+	}
+
+	if api.project.SchemeRegistration == project.SchemeRegistrationRegistry &&
+		(api.DoResource || api.DoController) {
+		if err := api.updateSchemeRegistry(r); err != nil {
+			return err
+		}
 	}
 
-	err := (&resourcev2.Main{}).Update(
-		&resourcev2.MainUpdateOptions{
-			Project:        api.project,
-			WireResource:   api.DoResource,
-			WireController: api.DoController,
-			Resource:       r,
-		})
+	// projects initialized with --controller-user-agents give each controller
+	// a client of its own
+	wireClient := false
+	if _, err := os.Stat(filepath.Join("controllers", "client.go")); err == nil {
+		wireClient = api.DoController
+	}
+	var requiredAPIs []resourcev2.RequiredAPI
+	for _, s := range api.RequiredAPIs {
+		requiredAPI, err := resourcev2.ParseRequiredAPI(s)
+		if err != nil {
+			return err
+		}
+		requiredAPIs = append(requiredAPIs, requiredAPI)
+	}
+	mainUpdate := &resourcev2.MainUpdateOptions{
+		Project:              api.project,
+		WireResource:         api.DoResource,
+		WireController:       api.DoController,
+		WireControllerClient: wireClient,
+		Resource:             r,
+
+		WireControllerSettings:         wireSettings,
+		WireControllerReconcileTimeout: wireReconcileTimeout,
+		WireControllerReadOnly:         wireReadOnly,
+		WireControllerHeartbeat:        wireHeartbeat,
+		RequiredAPIs:                   requiredAPIs,
+	}
+	err := (&resourcev2.Main{}).Update(mainUpdate)
 	if err != nil {
 		return fmt.Errorf("error updating main.go: %v", err)
 	}
+	if mainUpdate.ControllerSetUp && api.Force {
+		result.Warnf("main.go already sets up the %sReconciler and is left as it is, set there the fields "+
+			"the regenerated controller added to the reconciler, if any.", r.Kind)
+	}
+
+	if api.project.Version == project.Version3 && (api.DoResource || api.DoController) {
+		if api.DoController {
+			trackResource(api.project, r).Controller = true
+		}
+		err = saveProjectFile("PROJECT", api.project)
+		if err != nil {
+			result.Warnf("error updating project file with resource information : %v", err)
+		}
+	}
 
 	return nil
 }
 
-// Since we support single group only in v2 scaffolding, validate if resource
-// being created belongs to existing group.
+// Unless the project is multigroup, v2 scaffolding supports a single group
+// only, validate if resource being created belongs to existing group.
 func (api *API) validateResourceGroup(resource *resourcev1.Resource) error {
+	if api.project.MultiGroup {
+		return nil
+	}
 	for _, existingGroup := range api.project.ResourceGroups() {
 		if strings.ToLower(resource.Group) != strings.ToLower(existingGroup) {
 			return fmt.Errorf("Group '%s' is not same as existing group '%s'. Multiple groups are not supported yet.", resource.Group, existingGroup)
@@ -243,3 +717,72 @@ func (api *API) validateResourceGroup(resource *resourcev1.Resource) error {
 	}
 	return nil
 }
+
+// trackResource returns the entry of the given resource in the project file,
+// adding it if the resource is not tracked yet.
+func trackResource(project *input.ProjectFile, r *resourcev1.Resource) *input.Resource {
+	if res := project.GetResource(r.Group, r.Version, r.Kind); res != nil {
+		return res
+	}
+	project.Resources = append(project.Resources,
+		input.Resource{Group: r.Group, Version: r.Version, Kind: r.Kind, Domain: r.Domain})
+	return &project.Resources[len(project.Resources)-1]
+}
+
+// updateSchemeRegistry scaffolds api/scheme.go if it does not exist yet and
+// adds the resource's group version to it.
+func (api *API) updateSchemeRegistry(r *resourcev1.Resource) error {
+	registry := &resourcev2.SchemeRegistry{}
+	err := (&Scaffold{}).Execute(input.Options{}, registry)
+	if err != nil {
+		return fmt.Errorf("error scaffolding scheme registry: %v", err)
+	}
+
+	err = registry.Update(r)
+	if err != nil {
+		return fmt.Errorf("error updating api/scheme.go: %v", err)
+	}
+	return nil
+}
+
+// validateSchemeRegistration checks that the given scheme registration
+// strategy is one known to the v2 scaffolding.
+func validateSchemeRegistration(strategy string) error {
+	switch strategy {
+	case "", project.SchemeRegistrationMain, project.SchemeRegistrationRegistry:
+		return nil
+	default:
+		return fmt.Errorf("unknown scheme registration strategy %q, must be one of %s, %s",
+			strategy, project.SchemeRegistrationMain, project.SchemeRegistrationRegistry)
+	}
+}
+
+// clusterScopeMarker is the marker of the types of the cluster-scoped
+// resources.
+const clusterScopeMarker = "+kubebuilder:resource:scope=Cluster"
+
+// isNamespaced returns whether the resource is namespaced, as its types do not
+// have the cluster scope marker. The resources without types, e.g. the core
+// resources, are namespaced.
+func isNamespaced(p *input.ProjectFile, r *resourcev1.Resource) bool {
+	types, err := ioutil.ReadFile(filepath.Join(apiDir(p, r), fmt.Sprintf("%s_types.go", strings.ToLower(r.Kind))))
+	return err != nil || !strings.Contains(string(types), clusterScopeMarker)
+}
+
+// apiDir returns the directory of the API version of the resource, which is
+// api/<group>/<version> in multigroup projects.
+func apiDir(p *input.ProjectFile, r *resourcev1.Resource) string {
+	if p.MultiGroup {
+		return filepath.Join("api", r.Group, r.Version)
+	}
+	return filepath.Join("api", r.Version)
+}
+
+// controllersDir returns the directory of the controllers of the resource,
+// which is controllers/<group> in multigroup projects.
+func controllersDir(p *input.ProjectFile, r *resourcev1.Resource) string {
+	if p.MultiGroup {
+		return filepath.Join("controllers", r.Group)
+	}
+	return "controllers"
+}
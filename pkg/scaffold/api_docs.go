@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/apidocs"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+	resourcev2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2"
+)
+
+// APIDocsDir is the directory of the API docs of a project.
+var APIDocsDir = filepath.Join("docs", "api")
+
+// APIDocs contains configuration for generating the OpenAPI document of the
+// CRDs of a project, and the page browsing it with Swagger UI.
+type APIDocs struct {
+	project *input.ProjectFile
+}
+
+// Validate validates whether the API docs of the project can be generated.
+func (a *APIDocs) Validate() error {
+	if err := a.setDefaults(); err != nil {
+		return err
+	}
+	if !project.HasV2Layout(a.project.Version) {
+		return fmt.Errorf("generating API docs is not supported for project version %s", a.project.Version)
+	}
+	return nil
+}
+
+func (a *APIDocs) setDefaults() error {
+	if a.project == nil {
+		p, err := LoadProjectFile("PROJECT")
+		if err != nil {
+			return err
+		}
+		a.project = &p
+	}
+	return nil
+}
+
+// Scaffold overwrites the OpenAPI document of the CRDs generated by make
+// manifests in config/crd/bases, and writes the Swagger UI page unless it
+// exists.
+func (a *APIDocs) Scaffold() error {
+	if err := a.setDefaults(); err != nil {
+		return err
+	}
+	name, err := (&input.Input{ProjectName: a.project.ProjectName}).GetProjectName()
+	if err != nil {
+		return err
+	}
+
+	paths, err := filepath.Glob(filepath.Join("config", "crd", "bases", "*.yaml"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no CRD in config/crd/bases, run make manifests first")
+	}
+	var crds [][]byte
+	for _, path := range paths {
+		crd, err := ioutil.ReadFile(path) // nolint: gosec
+		if err != nil {
+			return err
+		}
+		crds = append(crds, crd)
+	}
+	doc, err := apidocs.Generate(name+" API", crds...)
+	if err != nil {
+		return fmt.Errorf("error generating the API docs: %v", err)
+	}
+	if err := writeIfChanged(filepath.Join(APIDocsDir, "openapi.json"), append(doc, '\n')); err != nil {
+		return err
+	}
+
+	err = (&Scaffold{}).Execute(
+		input.Options{},
+		&resourcev2.APIDocsIndex{},
+	)
+	if err != nil {
+		return fmt.Errorf("error scaffolding the API docs page: %v", err)
+	}
+	return nil
+}
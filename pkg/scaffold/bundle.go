@@ -0,0 +1,171 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/olm"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
+)
+
+// BundleDir is the directory of the OLM bundle of a project.
+const BundleDir = "bundle"
+
+var semver = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// Bundle contains configuration for generating the OLM bundle of a project
+// from the manifests built by kustomize from config/default.
+type Bundle struct {
+	project *input.ProjectFile
+
+	// Manifests are the manifests built from config/default
+	Manifests []byte
+
+	// Version is the semantic version of the operator
+	Version string
+
+	// Channels are the channels the bundle is published in
+	Channels []string
+
+	// DefaultChannel is the channel subscribed to by default, the first of
+	// the channels if empty
+	DefaultChannel string
+}
+
+// Validate validates whether the bundle of the project can be generated.
+func (b *Bundle) Validate() error {
+	if err := b.setDefaults(); err != nil {
+		return err
+	}
+	if !project.HasV2Layout(b.project.Version) {
+		return fmt.Errorf("generating an OLM bundle is not supported for project version %s", b.project.Version)
+	}
+	if !semver.MatchString(b.Version) {
+		return fmt.Errorf("version %q is not a semantic version, e.g. 0.1.0", b.Version)
+	}
+	if len(b.Channels) == 0 {
+		return fmt.Errorf("the bundle must be published in a channel")
+	}
+	if b.DefaultChannel != "" {
+		found := false
+		for _, c := range b.Channels {
+			if c == b.DefaultChannel {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("the default channel %s is not one of the channels %s",
+				b.DefaultChannel, strings.Join(b.Channels, ", "))
+		}
+	}
+	return nil
+}
+
+func (b *Bundle) setDefaults() error {
+	if b.project == nil {
+		p, err := LoadProjectFile("PROJECT")
+		if err != nil {
+			return err
+		}
+		b.project = &p
+	}
+	return nil
+}
+
+// Scaffold overwrites the bundle, keeping the fields of the existing CSV
+// which are not generated, and removing the manifests which are no longer in
+// config/default.
+func (b *Bundle) Scaffold() error {
+	if err := b.setDefaults(); err != nil {
+		return err
+	}
+	name, err := (&input.Input{ProjectName: b.project.ProjectName}).GetProjectName()
+	if err != nil {
+		return err
+	}
+	manifestsDir := filepath.Join(BundleDir, "manifests")
+
+	opts := olm.Options{
+		Name:           name,
+		Version:        b.Version,
+		Channels:       b.Channels,
+		DefaultChannel: b.DefaultChannel,
+	}
+	if csv, err := ioutil.ReadFile(filepath.Join(manifestsDir, olm.CSVFile(name))); err == nil {
+		opts.CSV = csv
+	}
+	samples, err := filepath.Glob(filepath.Join("config", "samples", "*.yaml"))
+	if err != nil {
+		return err
+	}
+	for _, path := range samples {
+		sample, err := ioutil.ReadFile(path) // nolint: gosec
+		if err != nil {
+			return err
+		}
+		opts.Samples = append(opts.Samples, sample)
+	}
+
+	bundle, err := olm.Render(b.Manifests, opts)
+	if err != nil {
+		return fmt.Errorf("error rendering the bundle: %v", err)
+	}
+	for _, skipped := range bundle.Skipped {
+		result.Warnf("%s cannot be installed by OLM from the bundle, it is left out.", skipped)
+	}
+
+	stale, err := filepath.Glob(filepath.Join(manifestsDir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		if _, found := bundle.Manifests[filepath.Base(path)]; found {
+			continue
+		}
+		if err := rollback.Save(path); err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("error removing %s: %v", path, err)
+		}
+		result.FileModified(path)
+	}
+
+	files := map[string][]byte{
+		filepath.Join(BundleDir, "metadata", "annotations.yaml"): bundle.Annotations,
+		"bundle.Dockerfile": bundle.Dockerfile,
+	}
+	for file, content := range bundle.Manifests {
+		files[filepath.Join(manifestsDir, file)] = content
+	}
+	for path, content := range files {
+		if err := writeIfChanged(path, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
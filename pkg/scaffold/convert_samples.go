@@ -0,0 +1,161 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
+	resourcev1 "sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+	resourcev2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2"
+)
+
+// ConvertSamples contains configuration for generating the program converting
+// the samples of the kinds with several versions between their versions.
+type ConvertSamples struct {
+	project *input.ProjectFile
+}
+
+// Validate validates whether the samples of the project can be converted.
+func (s *ConvertSamples) Validate() error {
+	if err := s.setDefaults(); err != nil {
+		return err
+	}
+	if !project.HasV2Layout(s.project.Version) {
+		return fmt.Errorf("converting samples is not supported for project version %s", s.project.Version)
+	}
+	versions := map[string]int{}
+	for _, res := range s.project.Resources {
+		if !res.HasAPI() {
+			continue
+		}
+		key := res.Group + "/" + res.Kind
+		versions[key]++
+		if versions[key] > 1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("no kind of the project has several versions to convert its samples between")
+}
+
+func (s *ConvertSamples) setDefaults() error {
+	if s.project == nil {
+		p, err := LoadProjectFile("PROJECT")
+		if err != nil {
+			return err
+		}
+		s.project = &p
+	}
+	return nil
+}
+
+// Scaffold overwrites hack/convert-samples/main.go with the kinds of the
+// project with several versions.
+func (s *ConvertSamples) Scaffold() error {
+	if err := s.setDefaults(); err != nil {
+		return err
+	}
+
+	var resources []*resourcev1.Resource
+	for _, res := range s.project.Resources {
+		if !res.HasAPI() {
+			continue
+		}
+		resources = append(resources, &resourcev1.Resource{Group: res.Group, Domain: res.Domain,
+			Version: res.Version, Kind: res.Kind})
+	}
+	err := (&Scaffold{}).Execute(input.Options{}, &resourcev2.ConvertSamples{Resources: resources})
+	if err != nil {
+		return fmt.Errorf("error scaffolding the samples converter: %v", err)
+	}
+	return nil
+}
+
+// Convert runs hack/convert-samples, overwriting the samples of the kinds with
+// several versions. The samples and the go.mod and go.sum files go run may
+// update are saved beforehand, for a failing conversion to be rolled back.
+func (s *ConvertSamples) Convert() error {
+	if err := s.setDefaults(); err != nil {
+		return err
+	}
+	samples := s.samples()
+	before := map[string][]byte{}
+	for _, path := range samples {
+		if err := rollback.Save(path); err != nil {
+			return err
+		}
+		// a missing sample is reported by the converter
+		before[path], _ = ioutil.ReadFile(path) // nolint: gosec
+	}
+	if err := rollback.SaveGoModule(); err != nil {
+		return err
+	}
+
+	fmt.Println("Running go run ./hack/convert-samples...")
+	cm := exec.Command("go", "run", "./hack/convert-samples") // #nosec
+	cm.Stderr = os.Stderr
+	cm.Stdout = os.Stdout
+	if err := cm.Run(); err != nil {
+		return fmt.Errorf("error converting the samples: %v", err)
+	}
+
+	for _, path := range samples {
+		after, err := ioutil.ReadFile(path) // nolint: gosec
+		if err == nil && !bytes.Equal(before[path], after) {
+			result.FileModified(path)
+		}
+	}
+	return nil
+}
+
+// samples returns the paths of the samples of the versions of the kinds with
+// several versions, which the converter may overwrite.
+func (s *ConvertSamples) samples() []string {
+	versions := map[string][]string{}
+	var kinds []input.Resource
+	for _, res := range s.project.Resources {
+		if !res.HasAPI() {
+			continue
+		}
+		key := res.Group + "/" + res.Kind
+		if _, found := versions[key]; !found {
+			kinds = append(kinds, res)
+		}
+		versions[key] = append(versions[key], res.Version)
+	}
+	var paths []string
+	for _, res := range kinds {
+		vs := versions[res.Group+"/"+res.Kind]
+		if len(vs) < 2 {
+			continue
+		}
+		for _, v := range vs {
+			paths = append(paths, filepath.Join("config", "samples",
+				fmt.Sprintf("%s_%s_%s.yaml", res.Group, v, strings.ToLower(res.Kind))))
+		}
+	}
+	return paths
+}
@@ -0,0 +1,374 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+	resourcev1 "sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+	resourcev2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/autoscaling"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/helm"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/prometheus"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/sharding"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/uninstall"
+)
+
+// Edit contains configuration for adding optional components to an already
+// initialized project.
+type Edit struct {
+	project *input.ProjectFile
+
+	// Observability indicates whether to scaffold the prometheus monitor,
+	// alerting rules and dashboards for the manager metrics
+	Observability bool
+
+	// GrafanaDashboard indicates whether to scaffold the ConfigMap of the
+	// Grafana dashboards of the manager metrics
+	GrafanaDashboard bool
+
+	// Sharding indicates whether to scaffold the helpers sharding the
+	// controllers across the replicas of a StatefulSet
+	Sharding bool
+
+	// Autoscaling is the autoscaler to scaffold scaling the shards of the
+	// manager on the depth of its workqueues, e.g. hpa
+	Autoscaling string
+
+	// UninstallJob indicates whether to scaffold the Job deleting the CRs and
+	// CRDs of the project before the uninstallation of the operator
+	UninstallJob bool
+
+	// ManagedNamespaces indicates whether to scaffold the helper creating the
+	// namespaces of the CRs, annotated with their owner, and deleting them
+	ManagedNamespaces bool
+
+	// Packaging is the packaging format to generate from the kustomize config
+	// in addition to it, e.g. helm
+	Packaging string
+
+	// MultiGroup sets whether the project supports APIs in several groups,
+	// nil leaves the project layout unchanged
+	MultiGroup *bool
+
+	// ProjectVersion is the version the project file is upgraded to, only
+	// version 2 projects being upgraded to version 3
+	ProjectVersion string
+
+	// Domain is the domain the API groups of the project are renamed to,
+	// empty keeping the domain
+	Domain string
+
+	// Repo is the go module path the project is renamed to, empty keeping
+	// the repo
+	Repo string
+
+	// DryRun prints the diff of the rename of the domain and the repo
+	// instead of applying it
+	DryRun bool
+}
+
+// Validate validates whether the project can be edited.
+func (e *Edit) Validate() error {
+	if err := e.setDefaults(); err != nil {
+		return err
+	}
+	if !project.HasV2Layout(e.project.Version) {
+		return fmt.Errorf("edit is not supported for project version %s", e.project.Version)
+	}
+	if e.Packaging != "" && e.Packaging != project.PackagingHelm {
+		return fmt.Errorf("packaging %s is not supported, it must be %s", e.Packaging, project.PackagingHelm)
+	}
+	if e.GrafanaDashboard && !e.Observability {
+		if _, err := os.Stat(filepath.Join("config", "prometheus", "kustomization.yaml")); err != nil {
+			return fmt.Errorf("the Grafana dashboards are scaffolded in config/prometheus, scaffold it with --observability")
+		}
+	}
+	if e.Autoscaling != "" {
+		if err := autoscaling.ValidateAutoscaler(e.Autoscaling); err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join("config", "sharding", "kustomization.yaml")); !e.Sharding && err != nil {
+			return fmt.Errorf("autoscaling scales the shards of config/sharding, scaffold them with --sharding")
+		}
+	}
+	if e.ProjectVersion != "" && e.ProjectVersion != e.project.Version &&
+		(e.ProjectVersion != project.Version3 || e.project.Version != project.Version2) {
+		return fmt.Errorf("project version %s cannot be upgraded to %s, only version %s can be upgraded to %s",
+			e.project.Version, e.ProjectVersion, project.Version2, project.Version3)
+	}
+	if e.DryRun {
+		if e.Domain == "" && e.Repo == "" {
+			return fmt.Errorf("dry-run previews the rename of the project, set --domain or --repo")
+		}
+		if e.Observability || e.GrafanaDashboard || e.Sharding || e.Autoscaling != "" || e.UninstallJob || e.ManagedNamespaces ||
+			e.Packaging != "" || e.MultiGroup != nil || e.ProjectVersion != "" {
+			return fmt.Errorf("dry-run only previews the rename of the project, edit the other components separately")
+		}
+	}
+	if e.Repo != "" && e.Repo != e.project.Repo {
+		if _, err := os.Stat("go.mod"); err != nil {
+			return fmt.Errorf("the repo is given by the module of the project, " +
+				"rename the module containing it rather than the project")
+		}
+	}
+	if e.MultiGroup != nil && !*e.MultiGroup && len(e.project.ResourceGroups()) > 1 {
+		return fmt.Errorf("multigroup cannot be disabled, the project has APIs in groups %s",
+			strings.Join(e.project.ResourceGroups(), ", "))
+	}
+	return nil
+}
+
+func (e *Edit) setDefaults() error {
+	if e.project == nil {
+		p, err := LoadProjectFile("PROJECT")
+		if err != nil {
+			return err
+		}
+		e.project = &p
+	}
+	return nil
+}
+
+// Scaffold scaffolds the requested components.
+func (e *Edit) Scaffold() error {
+	if err := e.setDefaults(); err != nil {
+		return err
+	}
+
+	if e.Domain != "" || e.Repo != "" {
+		if err := renameProject(e.project, e.Domain, e.Repo, e.DryRun); err != nil {
+			return fmt.Errorf("error renaming the project: %v", err)
+		}
+		if e.DryRun {
+			return nil
+		}
+	}
+
+	if e.Observability {
+		err := (&Scaffold{}).Execute(
+			input.Options{},
+			&prometheus.Kustomization{},
+			&prometheus.ServiceMonitor{},
+			&prometheus.PrometheusRule{},
+			&prometheus.Alerts{},
+			&prometheus.Dashboard{},
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding observability: %v", err)
+		}
+	}
+
+	if e.GrafanaDashboard {
+		files := []input.File{&prometheus.DashboardsKustomization{}}
+		if _, err := os.Stat(filepath.Join("config", "prometheus", "dashboards", "controller-runtime.json")); err != nil {
+			files = append(files, &prometheus.Dashboard{})
+		}
+		if err := (&Scaffold{}).Execute(input.Options{}, files...); err != nil {
+			return fmt.Errorf("error scaffolding the Grafana dashboards: %v", err)
+		}
+		listed, err := (&prometheus.DashboardsKustomization{}).Update()
+		if err != nil {
+			return fmt.Errorf("error updating the default kustomization: %v", err)
+		}
+		if !listed {
+			result.Warnf("config/default/kustomization.yaml has no prometheus base, add %s to its bases to deploy the dashboards.",
+				"../prometheus/dashboards")
+		}
+	}
+
+	if e.Sharding {
+		// The governing Service follows the IP families of the metrics
+		// Service scaffolded by init
+		service, err := ioutil.ReadFile(filepath.Join("config", "rbac", "auth_proxy_service.yaml")) // nolint: gosec
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		err = (&Scaffold{}).Execute(
+			input.Options{},
+			&resourcev2.Sharding{},
+			&sharding.Kustomization{},
+			&sharding.Manager{DualStack: strings.Contains(string(service), "ipFamilyPolicy: PreferDualStack")},
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding sharding: %v", err)
+		}
+	}
+
+	if e.Autoscaling != "" {
+		var autoscaler input.File = &autoscaling.HorizontalPodAutoscaler{}
+		if e.Autoscaling == autoscaling.KEDA {
+			autoscaler = &autoscaling.ScaledObject{}
+		}
+		err := (&Scaffold{}).Execute(
+			input.Options{},
+			&resourcev2.ShardAutoscaling{},
+			&resourcev2.OperatorNamespace{},
+			&autoscaling.Kustomization{Autoscaler: e.Autoscaling},
+			&autoscaling.ManagerPatch{},
+			autoscaler,
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding autoscaling: %v", err)
+		}
+	}
+
+	if e.UninstallJob {
+		var resources []*resourcev1.Resource
+		for _, res := range e.project.Resources {
+			if !res.HasAPI() {
+				continue
+			}
+			resources = append(resources, &resourcev1.Resource{Group: res.Group, Domain: res.Domain,
+				Version: res.Version, Kind: res.Kind})
+		}
+		err := (&Scaffold{}).Execute(
+			input.Options{},
+			&uninstall.Script{},
+			&uninstall.Kustomization{},
+			&uninstall.RBAC{Resources: resources},
+			&uninstall.Job{Resources: resources},
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding uninstall job: %v", err)
+		}
+	}
+
+	if e.ManagedNamespaces {
+		err := (&Scaffold{}).Execute(
+			input.Options{},
+			&resourcev2.Namespaces{},
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding managed namespaces: %v", err)
+		}
+	}
+
+	if e.Packaging == project.PackagingHelm {
+		err := (&Scaffold{}).Execute(
+			input.Options{},
+			&helm.Chart{},
+			&helm.HelmIgnore{},
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding the Helm chart: %v", err)
+		}
+		e.project.Packaging = e.Packaging
+		if err := saveProjectFile("PROJECT", e.project); err != nil {
+			return fmt.Errorf("error updating project file: %v", err)
+		}
+	}
+
+	if e.MultiGroup != nil && *e.MultiGroup != e.project.MultiGroup {
+		e.project.MultiGroup = *e.MultiGroup
+		if err := saveProjectFile("PROJECT", e.project); err != nil {
+			return fmt.Errorf("error updating project file: %v", err)
+		}
+		if len(e.project.Resources) > 0 {
+			fmt.Println("The existing APIs and controllers are not moved, " +
+				"move them to the new layout by hand and update their imports.")
+		}
+	}
+
+	if e.ProjectVersion == project.Version3 && e.project.Version == project.Version2 {
+		e.project.Version = project.Version3
+		for i := range e.project.Resources {
+			if err := detectResource(e.project, &e.project.Resources[i]); err != nil {
+				return err
+			}
+		}
+		if err := saveProjectFile("PROJECT", e.project); err != nil {
+			return fmt.Errorf("error updating project file: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// detectResource fills in the API types, controller and webhooks of a
+// resource tracked by a project with version 2, from the files scaffolded for
+// it. The resources with a controller only, which project version 2 does not
+// track, are not detected.
+func detectResource(p *input.ProjectFile, res *input.Resource) error {
+	r := &resourcev1.Resource{Group: res.Group, Version: res.Version, Kind: res.Kind}
+	kind := strings.ToLower(r.Kind)
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	read := func(path string) (string, error) {
+		content, err := ioutil.ReadFile(path) // nolint: gosec
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return string(content), err
+	}
+
+	types, err := read(filepath.Join(apiDir(p, r), fmt.Sprintf("%s_types.go", kind)))
+	if err != nil {
+		return err
+	}
+	if types != "" {
+		res.API = &input.ResourceAPI{
+			CRDVersion: project.CRDVersionV1beta1,
+			Namespaced: !strings.Contains(types, clusterScopeMarker),
+		}
+	}
+	res.Controller = exists(filepath.Join(controllersDir(p, r), fmt.Sprintf("%s_controller.go", kind)))
+
+	webhook, err := read(filepath.Join(apiDir(p, r), fmt.Sprintf("%s_webhook.go", kind)))
+	if err != nil {
+		return err
+	}
+	reportOnly, err := read(filepath.Join(apiDir(p, r), "webhook_reportonly.go"))
+	if err != nil {
+		return err
+	}
+	policy, err := read(filepath.Join(apiDir(p, r), fmt.Sprintf("%s_policy_webhook.go", kind)))
+	if err != nil {
+		return err
+	}
+	delegate := ""
+	if strings.Contains(policy, fmt.Sprintf("%sPolicyPath", r.Kind)) {
+		delegate = project.PolicyDelegateOPA
+	} else if policy != "" {
+		delegate = project.PolicyDelegateHTTP
+	}
+	webhooks := input.ResourceWebhooks{
+		Defaulting: strings.Contains(webhook, fmt.Sprintf("type %sDefaulter struct", r.Kind)),
+		Validation: strings.Contains(webhook, fmt.Sprintf("type %sValidator struct", r.Kind)),
+		ReportOnly: strings.Contains(reportOnly, fmt.Sprintf("*%s) SetupReportOnlyWebhookWithManager", r.Kind)),
+		References: exists(filepath.Join(apiDir(p, r), fmt.Sprintf("%s_references_webhook.go", kind))),
+		Payloads:   exists(filepath.Join(apiDir(p, r), fmt.Sprintf("%s_payload_webhook.go", kind))),
+		DeletionProtection: exists(filepath.Join(apiDir(p, r),
+			fmt.Sprintf("%s_deletion_webhook.go", kind))),
+		Quota:      exists(filepath.Join(apiDir(p, r), fmt.Sprintf("%s_quota_webhook.go", kind))),
+		Delegate:   delegate,
+		Conversion: exists(filepath.Join(apiDir(p, r), fmt.Sprintf("%s_conversion.go", kind))),
+	}
+	if webhooks != (input.ResourceWebhooks{}) {
+		res.Webhooks = &webhooks
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/helm"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
+	helmv2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2/helm"
+)
+
+// Helm contains configuration for generating the Helm chart of a project
+// from the manifests built by kustomize from config/default.
+type Helm struct {
+	project *input.ProjectFile
+
+	// Manifests are the manifests built from config/default
+	Manifests []byte
+}
+
+// Validate validates whether the chart of the project can be generated.
+func (h *Helm) Validate() error {
+	if err := h.setDefaults(); err != nil {
+		return err
+	}
+	if !project.HasV2Layout(h.project.Version) {
+		return fmt.Errorf("generating a Helm chart is not supported for project version %s", h.project.Version)
+	}
+	if h.project.Packaging != project.PackagingHelm {
+		return fmt.Errorf("the project is not packaged with Helm, run kubebuilder edit --packaging=helm first")
+	}
+	return nil
+}
+
+func (h *Helm) setDefaults() error {
+	if h.project == nil {
+		p, err := LoadProjectFile("PROJECT")
+		if err != nil {
+			return err
+		}
+		h.project = &p
+	}
+	return nil
+}
+
+// Scaffold overwrites the templates and the CRDs of the chart, removing the
+// CRDs which are no longer in the manifests. values.yaml is only written when
+// missing, the values being edited by the users of the chart.
+func (h *Helm) Scaffold() error {
+	if err := h.setDefaults(); err != nil {
+		return err
+	}
+	name, err := (&input.Input{ProjectName: h.project.ProjectName}).GetProjectName()
+	if err != nil {
+		return err
+	}
+	dir := helmv2.Dir(name)
+
+	chart, err := helm.Render(h.Manifests)
+	if err != nil {
+		return fmt.Errorf("error rendering the chart: %v", err)
+	}
+
+	crds, err := filepath.Glob(filepath.Join(dir, "crds", "*.yaml"))
+	if err != nil {
+		return err
+	}
+	for _, path := range crds {
+		if _, found := chart.CRDs[filepath.Base(path)]; found {
+			continue
+		}
+		if err := rollback.Save(path); err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("error removing %s: %v", path, err)
+		}
+		result.FileModified(path)
+	}
+
+	files := map[string][]byte{filepath.Join(dir, "templates", "manifests.yaml"): chart.Templates}
+	for file, content := range chart.CRDs {
+		files[filepath.Join(dir, "crds", file)] = content
+	}
+	if _, err := os.Stat(filepath.Join(dir, "values.yaml")); os.IsNotExist(err) {
+		files[filepath.Join(dir, "values.yaml")] = chart.Values
+	}
+	for path, content := range files {
+		if err := writeIfChanged(path, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeIfChanged writes the file unless it already has the content, recording
+// it as created or modified.
+func writeIfChanged(path string, content []byte) error {
+	existing, err := ioutil.ReadFile(path) // nolint: gosec
+	if err == nil && string(existing) == string(content) {
+		return nil
+	}
+	if err := (&FileWriter{}).WriteFile(path, content); err != nil {
+		return err
+	}
+	if os.IsNotExist(err) {
+		result.FileCreated(path)
+	} else {
+		result.FileModified(path)
+	}
+	return nil
+}
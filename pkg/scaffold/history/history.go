@@ -0,0 +1,102 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package history maintains the log of the commands which scaffolded a
+// project, recorded in .kubebuilder/history.yaml at the project root.
+package history
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+)
+
+// Path is the path of the history file relative to the project root.
+var Path = filepath.Join(".kubebuilder", "history.yaml")
+
+// Entry records a command which scaffolded the project.
+type Entry struct {
+	// Time is when the command ran, in RFC3339 format
+	Time string `json:"time" yaml:"time"`
+
+	// Command is the command without its flags, e.g. kubebuilder create api
+	Command string `json:"command" yaml:"command"`
+
+	// Args are the positional arguments of the command
+	Args []string `json:"args,omitempty" yaml:"args,omitempty"`
+
+	// Flags are the flags set on the command line, by name
+	Flags map[string]string `json:"flags,omitempty" yaml:"flags,omitempty"`
+
+	// Version is the version of kubebuilder which ran the command
+	Version string `json:"version" yaml:"version"`
+
+	// GitCommit is the commit kubebuilder was built from
+	GitCommit string `json:"gitCommit,omitempty" yaml:"gitCommit,omitempty"`
+
+	// FilesCreated are the files the command created
+	FilesCreated []string `json:"filesCreated,omitempty" yaml:"filesCreated,omitempty"`
+
+	// FilesModified are the existing files the command changed
+	FilesModified []string `json:"filesModified,omitempty" yaml:"filesModified,omitempty"`
+}
+
+// History is the content of the history file.
+type History struct {
+	// Entries are the commands in the order they ran
+	Entries []Entry `json:"entries" yaml:"entries"`
+}
+
+// Load reads the history file at path. A missing file is an empty history.
+func Load(path string) (*History, error) {
+	h := &History{}
+	in, err := ioutil.ReadFile(path) // nolint: gosec
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(in, h); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return h, nil
+}
+
+// Append adds the entry to the end of the history file at path, creating
+// the file if needed.
+func Append(path string, e Entry) error {
+	h, err := Load(path)
+	if err != nil {
+		return err
+	}
+	h.Entries = append(h.Entries, e)
+
+	content, err := yaml.Marshal(h)
+	if err != nil {
+		return fmt.Errorf("error marshalling the history %v", err)
+	}
+	// the history is written with the modes of the scaffolded files
+	if err := (&scaffold.FileWriter{}).WriteFile(path, content); err != nil {
+		return fmt.Errorf("failed to save the history at %s %v", path, err)
+	}
+	return nil
+}
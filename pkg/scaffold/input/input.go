@@ -16,6 +16,11 @@ limitations under the License.
 
 package input
 
+import (
+	"os"
+	"path/filepath"
+)
+
 // IfExistsAction determines what to do if the scaffold file already exists
 type IfExistsAction int
 
@@ -58,6 +63,20 @@ type Input struct {
 
 	// ProjectPath is the relative path to the project root
 	ProjectPath string
+
+	// MultiGroup is true if the project lays out its APIs and controllers by group
+	MultiGroup bool
+
+	// ProjectName is the name of the project, prefixing the names of its resources
+	ProjectName string
+
+	// NamePrefix is the prefix kustomize adds to the names of the resources of
+	// the project, replacing the one derived from the project name
+	NamePrefix string
+
+	// NameSuffix is the suffix kustomize adds to the names of the resources of
+	// the project
+	NameSuffix string
 }
 
 // Domain allows a domain to be set on an object
@@ -138,6 +157,94 @@ func (i *Input) SetProjectPath(p string) {
 	}
 }
 
+// MultiGroup allows the project layout to be set on an object
+type MultiGroup interface {
+	// SetMultiGroup sets whether the project lays out its APIs by group
+	SetMultiGroup(bool)
+}
+
+// SetMultiGroup sets whether the project lays out its APIs by group
+func (i *Input) SetMultiGroup(m bool) {
+	if !i.MultiGroup {
+		i.MultiGroup = m
+	}
+}
+
+// ProjectName allows the project name to be set on an object
+type ProjectName interface {
+	// SetProjectName sets the project name
+	SetProjectName(string)
+}
+
+// SetProjectName sets the project name
+func (i *Input) SetProjectName(n string) {
+	if i.ProjectName == "" {
+		i.ProjectName = n
+	}
+}
+
+// GetProjectName returns the project name, defaulting to the name of the
+// current directory for the projects created without one.
+func (i *Input) GetProjectName() (string, error) {
+	if i.ProjectName != "" {
+		return i.ProjectName, nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(dir), nil
+}
+
+// NamePrefix allows the prefix of the resource names to be set on an object
+type NamePrefix interface {
+	// SetNamePrefix sets the prefix of the resource names
+	SetNamePrefix(string)
+}
+
+// SetNamePrefix sets the prefix of the resource names
+func (i *Input) SetNamePrefix(p string) {
+	if i.NamePrefix == "" {
+		i.NamePrefix = p
+	}
+}
+
+// NameSuffix allows the suffix of the resource names to be set on an object
+type NameSuffix interface {
+	// SetNameSuffix sets the suffix of the resource names
+	SetNameSuffix(string)
+}
+
+// SetNameSuffix sets the suffix of the resource names
+func (i *Input) SetNameSuffix(s string) {
+	if i.NameSuffix == "" {
+		i.NameSuffix = s
+	}
+}
+
+// GetNamePrefix returns the prefix of the resource names, defaulting to the
+// project name followed by a dash.
+func (i *Input) GetNamePrefix() (string, error) {
+	if i.NamePrefix != "" {
+		return i.NamePrefix, nil
+	}
+	name, err := i.GetProjectName()
+	if err != nil {
+		return "", err
+	}
+	return name + "-", nil
+}
+
+// GetNamespace returns the namespace the resources of the project are
+// deployed to, <project name>-system.
+func (i *Input) GetNamespace() (string, error) {
+	name, err := i.GetProjectName()
+	if err != nil {
+		return "", err
+	}
+	return name + "-system", nil
+}
+
 // File is a scaffoldable file
 type File interface {
 	// GetInput returns the Input for creating a scaffold file
@@ -170,16 +277,64 @@ type ProjectFile struct {
 	// Repo is the go package name of the project root
 	Repo string `yaml:"repo,omitempty"`
 
+	// SchemeRegistration is the strategy used to register API types with the
+	// manager's scheme. It defaults to registering each group version in main.go.
+	// This info is used only in project with version 2.
+	SchemeRegistration string `yaml:"schemeRegistration,omitempty"`
+
+	// ProjectName is the name of the project, prefixing the names of its
+	// resources. It defaults to the name of the project directory.
+	ProjectName string `yaml:"projectName,omitempty"`
+
+	// Boilerplate is the path of the boilerplate file heading the Go files
+	// scaffolded by the commands and generated by the Makefile, relative to the
+	// project root. It defaults to hack/boilerplate.go.txt.
+	// This info is used only in project with version 2.
+	Boilerplate string `yaml:"boilerplate,omitempty"`
+
+	// NamePrefix is the prefix added to the names of the resources of the
+	// project. It defaults to the project name followed by a dash.
+	// This info is used only in project with version 2.
+	NamePrefix string `yaml:"namePrefix,omitempty"`
+
+	// NameSuffix is the suffix added to the names of the resources of the
+	// project. This info is used only in project with version 2.
+	NameSuffix string `yaml:"nameSuffix,omitempty"`
+
+	// MultiGroup indicates whether the project supports APIs in several groups,
+	// laid out under api/<group>/<version> and controllers/<group>.
+	// This info is used only in project with version 2.
+	MultiGroup bool `yaml:"multigroup,omitempty"`
+
+	// Packaging is the packaging format generated from the kustomize config
+	// in addition to it, e.g. helm, kept in sync with the scaffolded APIs.
+	// This info is used only in project with version 2.
+	Packaging string `yaml:"packaging,omitempty"`
+
 	// Resources tracks scaffolded resources in the project. This info is
-	// tracked only in project with version 2.
+	// tracked only in project with version 2 and above.
 	Resources []Resource `yaml:"resources,omitempty"`
 }
 
+// GetResource returns the resource of the given group, version and kind, nil
+// if it is not tracked in the project.
+func (pf *ProjectFile) GetResource(group, version, kind string) *Resource {
+	for i := range pf.Resources {
+		r := &pf.Resources[i]
+		if r.Group == group && r.Version == version && r.Kind == kind {
+			return r
+		}
+	}
+	return nil
+}
+
 // ResourceGroups returns unique groups of scaffolded resources in the project.
 func (pf *ProjectFile) ResourceGroups() []string {
 	groupSet := map[string]struct{}{}
 	for _, r := range pf.Resources {
-		groupSet[r.Group] = struct{}{}
+		if r.HasAPI() {
+			groupSet[r.Group] = struct{}{}
+		}
 	}
 
 	groups := []string{}
@@ -194,4 +349,53 @@ type Resource struct {
 	Group   string `yaml:"group,omitempty"`
 	Version string `yaml:"version,omitempty"`
 	Kind    string `yaml:"kind,omitempty"`
+
+	// Domain is the domain of the group of a resource whose group does not end
+	// with the domain of the project, created with --force-group-suffix=false.
+	// It is empty for the groups suffixed with the domain of the project.
+	Domain string `yaml:"domain,omitempty"`
+
+	// API describes the API types scaffolded for the resource, nil when only a
+	// controller or webhooks are scaffolded for it. This info is tracked only
+	// in project with version 3, the resources of project with version 2 all
+	// having API types.
+	API *ResourceAPI `yaml:"api,omitempty"`
+
+	// Controller indicates whether a controller is scaffolded for the
+	// resource. This info is tracked only in project with version 3.
+	Controller bool `yaml:"controller,omitempty"`
+
+	// Webhooks describes the webhooks scaffolded for the resource. This info
+	// is tracked only in project with version 3.
+	Webhooks *ResourceWebhooks `yaml:"webhooks,omitempty"`
+}
+
+// HasAPI returns whether the API types of the resource are scaffolded in the
+// project.
+func (r *Resource) HasAPI() bool {
+	// the resources of project with version 2 are only tracked with their API
+	return r.API != nil || (!r.Controller && r.Webhooks == nil)
+}
+
+// ResourceAPI describes the API types scaffolded for a resource.
+type ResourceAPI struct {
+	// CRDVersion is the apiextensions.k8s.io version of the CRD of the
+	// resource, e.g. v1beta1
+	CRDVersion string `yaml:"crdVersion,omitempty"`
+
+	// Namespaced indicates whether the resource is namespaced
+	Namespaced bool `yaml:"namespaced,omitempty"`
+}
+
+// ResourceWebhooks describes the webhooks scaffolded for a resource.
+type ResourceWebhooks struct {
+	Defaulting         bool   `yaml:"defaulting,omitempty"`
+	Validation         bool   `yaml:"validation,omitempty"`
+	ReportOnly         bool   `yaml:"reportOnly,omitempty"`
+	References         bool   `yaml:"references,omitempty"`
+	Payloads           bool   `yaml:"payloads,omitempty"`
+	DeletionProtection bool   `yaml:"deletionProtection,omitempty"`
+	Quota              bool   `yaml:"quota,omitempty"`
+	Delegate           string `yaml:"delegate,omitempty"`
+	Conversion         bool   `yaml:"conversion,omitempty"`
 }
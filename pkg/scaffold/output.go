@@ -17,6 +17,7 @@ limitations under the License.
 package scaffold
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"log"
@@ -24,6 +25,18 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/afero"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
+	"sigs.k8s.io/kubebuilder/pkg/textfile"
+)
+
+// The modes of the files and directories created by the scaffolds. They are
+// set explicitly rather than left to the umask, so that a project is
+// scaffolded the same whatever the environment of the user. The modes of the
+// existing files and directories are left as they are.
+const (
+	FileMode os.FileMode = 0644
+	DirMode  os.FileMode = 0755
 )
 
 // FileWriter is a io wrapper to write files
@@ -31,25 +44,93 @@ type FileWriter struct {
 	Fs afero.Fs
 }
 
-// WriteCloser returns a WriteCloser to write to given path
+// WriteCloser returns a WriteCloser to write to given path. The content written
+// to an existing file is given the byte order mark and the line endings the
+// file had, so that the files of a project created on Windows keep them.
 func (fw *FileWriter) WriteCloser(path string) (io.Writer, error) {
 	if fw.Fs == nil {
 		fw.Fs = afero.NewOsFs()
 	}
-	dir := filepath.Dir(path)
-	err := fw.Fs.MkdirAll(dir, 0700)
-	if err != nil {
+	if _, ok := fw.Fs.(*afero.OsFs); ok {
+		if err := rollback.Save(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := fw.mkdirAll(filepath.Dir(path)); err != nil {
 		return nil, err
 	}
 
-	fi, err := fw.Fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	_, err := fw.Fs.Stat(path)
+	created := os.IsNotExist(err)
+	var format textfile.Format
+	if !created {
+		if existing, err := afero.ReadFile(fw.Fs, path); err == nil {
+			// a file in an unsupported encoding is overwritten in UTF-8
+			format, _ = textfile.Detect(existing)
+		}
+	}
+	fi, err := fw.Fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, FileMode)
 	if err != nil {
 		return nil, err
 	}
+	if created {
+		if err := fw.Fs.Chmod(path, FileMode); err != nil {
+			_ = fi.Close()
+			return nil, err
+		}
+	}
 
+	if format != (textfile.Format{}) {
+		return &formatWriter{file: fi, format: format}, nil
+	}
 	return fi, nil
 }
 
+// formatWriter buffers the content written to a file, writing it encoded with
+// the format of the file once closed.
+type formatWriter struct {
+	file   afero.File
+	format textfile.Format
+	buf    bytes.Buffer
+}
+
+// Write implements io.Writer
+func (w *formatWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Close implements io.Closer
+func (w *formatWriter) Close() error {
+	if _, err := w.file.Write(w.format.Restore(w.buf.Bytes())); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// mkdirAll creates dir along with its missing parents with DirMode.
+func (fw *FileWriter) mkdirAll(dir string) error {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := fw.Fs.Stat(d); !os.IsNotExist(err) {
+			break
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	if err := fw.Fs.MkdirAll(dir, DirMode); err != nil {
+		return err
+	}
+	for _, d := range missing {
+		if err := fw.Fs.Chmod(d, DirMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // WriteFile write given content to the file path
 func (fw *FileWriter) WriteFile(filePath string, content []byte) error {
 	if fw.Fs == nil {
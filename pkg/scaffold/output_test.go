@@ -0,0 +1,110 @@
+// +build !windows
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+)
+
+var _ = Describe("FileWriter", func() {
+	var dir string
+	var umask int
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "kubebuilder-output-")
+		Expect(err).NotTo(HaveOccurred())
+		umask = syscall.Umask(0077)
+	})
+
+	AfterEach(func() {
+		syscall.Umask(umask)
+		Expect(os.Chmod(dir, 0700)).To(Succeed())
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	mode := func(path string) os.FileMode {
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		return info.Mode().Perm()
+	}
+
+	It("should create the files and directories with explicit modes whatever the umask", func() {
+		path := filepath.Join(dir, "config", "crd", "bases", "foo.yaml")
+		Expect((&scaffold.FileWriter{}).WriteFile(path, []byte("foo"))).To(Succeed())
+
+		Expect(mode(path)).To(Equal(scaffold.FileMode))
+		Expect(mode(filepath.Join(dir, "config", "crd", "bases"))).To(Equal(scaffold.DirMode))
+		Expect(mode(filepath.Join(dir, "config", "crd"))).To(Equal(scaffold.DirMode))
+		Expect(mode(filepath.Join(dir, "config"))).To(Equal(scaffold.DirMode))
+	})
+
+	It("should leave the modes of the existing files and directories", func() {
+		Expect(os.Chmod(dir, 0711)).To(Succeed())
+		path := filepath.Join(dir, "hack.sh")
+		Expect(ioutil.WriteFile(path, []byte("old"), 0700)).To(Succeed())
+
+		Expect((&scaffold.FileWriter{}).WriteFile(path, []byte("new"))).To(Succeed())
+
+		Expect(mode(path)).To(Equal(os.FileMode(0700)))
+		Expect(mode(dir)).To(Equal(os.FileMode(0711)))
+		content, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("new"))
+	})
+
+	It("should fail naming the file in a read-only directory", func() {
+		if os.Geteuid() == 0 {
+			Skip("root writes to read-only directories")
+		}
+		Expect(os.Chmod(dir, 0555)).To(Succeed())
+		path := filepath.Join(dir, "main.go")
+
+		err := (&scaffold.FileWriter{}).WriteFile(path, []byte("package main"))
+		Expect(err).To(MatchError(ContainSubstring(path)))
+	})
+
+	It("should keep the byte order mark and the line endings of the existing files", func() {
+		path := filepath.Join(dir, "PROJECT")
+		Expect(ioutil.WriteFile(path, []byte("\xEF\xBB\xBFversion: \"2\"\r\n"), 0644)).To(Succeed())
+
+		Expect((&scaffold.FileWriter{}).WriteFile(path, []byte("version: \"3\"\ndomain: example.com\n"))).To(Succeed())
+
+		content, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("\xEF\xBB\xBFversion: \"3\"\r\ndomain: example.com\r\n"))
+	})
+
+	It("should create the files with LF line endings", func() {
+		path := filepath.Join(dir, "main.go")
+		Expect((&scaffold.FileWriter{}).WriteFile(path, []byte("package main\n"))).To(Succeed())
+
+		content, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("package main\n"))
+	})
+})
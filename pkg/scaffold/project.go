@@ -21,11 +21,14 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"strings"
 
 	"sigs.k8s.io/kubebuilder/cmd/util"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
 	scaffoldv1 "sigs.k8s.io/kubebuilder/pkg/scaffold/v1"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/manager"
 	metricsauthv1 "sigs.k8s.io/kubebuilder/pkg/scaffold/v1/metricsauth"
@@ -33,6 +36,7 @@ import (
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/certmanager"
 	managerv2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2/manager"
 	metricsauthv2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2/metricsauth"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/uninstall"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/webhook"
 )
 
@@ -51,6 +55,12 @@ type V1Project struct {
 }
 
 func (p *V1Project) Validate() error {
+	if err := validateProjectName(p.Project.ProjectName); err != nil {
+		return err
+	}
+	if p.Project.NamePrefix != "" || p.Project.NameSuffix != "" {
+		return fmt.Errorf("name prefix and suffix are only supported for project version %s", project.Version2)
+	}
 	_, err := exec.LookPath("dep")
 	if err != nil {
 		return fmt.Errorf("dep is not installed (%v). Follow steps at: https://golang.github.io/dep/docs/installation.html", err)
@@ -133,13 +143,116 @@ func (p *V1Project) Scaffold() error {
 type V2Project struct {
 	Project     project.Project
 	Boilerplate project.Boilerplate
+
+	// Heartbeat indicates whether to scaffold the operator heartbeat
+	Heartbeat bool
+
+	// Settings indicates whether to scaffold the watch of the settings
+	// ConfigMap reconfiguring the controllers
+	Settings bool
+
+	// Capabilities indicates whether to scaffold the detection of the optional
+	// APIs served by the cluster
+	Capabilities bool
+
+	// ControllerUserAgents indicates whether to scaffold the clients giving
+	// each controller a user agent of its own
+	ControllerUserAgents bool
+
+	// RemoteCluster indicates whether to scaffold the watch of a remote
+	// cluster, read from a kubeconfig Secret
+	RemoteCluster bool
+
+	// ReconcileTimeout indicates whether to scaffold the timeout of the
+	// reconciles, and the metric of the reconciles exceeding it
+	ReconcileTimeout bool
+
+	// ReadOnly indicates whether to scaffold the read-only mode of the
+	// controllers, which only write the status of the objects
+	ReadOnly bool
+
+	// DualStack indicates whether to scaffold Services preferring both IP
+	// families, on dual-stack clusters
+	DualStack bool
 }
 
 func (p *V2Project) Validate() error {
+	if err := validateProjectName(p.Project.ProjectName); err != nil {
+		return err
+	}
+	if err := validateBoilerplatePath(p.Boilerplate.Path); err != nil {
+		return err
+	}
+	if err := validateNameAffixes(p.Project.NamePrefix, p.Project.NameSuffix); err != nil {
+		return err
+	}
+	return validateSchemeRegistration(p.Project.SchemeRegistration)
+}
+
+// validateBoilerplatePath validates the boilerplate path, if set, is within the
+// project, for the Makefile to find it wherever the project is checked out.
+func validateBoilerplatePath(path string) error {
+	if path == "" {
+		return nil
+	}
+	clean := filepath.Clean(path)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("invalid boilerplate path %q, must be relative to the project root and within it", path)
+	}
+	return nil
+}
+
+// projectNameRegex matches the DNS-1123 labels the names of the resources of
+// the project are prefixed with.
+var projectNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// validateProjectName validates the project name, if set, can prefix the names
+// of the resources of the project and its <name>-system namespace.
+func validateProjectName(name string) error {
+	if name == "" {
+		return nil
+	}
+	if !projectNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid project name %q, must consist of lower case alphanumeric characters or '-', "+
+			"and start and end with an alphanumeric character", name)
+	}
+	if max := 63 - len("-system"); len(name) > max {
+		return fmt.Errorf("invalid project name %q, must be no more than %d characters", name, max)
+	}
+	return nil
+}
+
+var (
+	namePrefixRegex = regexp.MustCompile(`^[a-z][-a-z0-9]*$`)
+	nameSuffixRegex = regexp.MustCompile(`^([-a-z0-9]*[a-z0-9])?$`)
+)
+
+// longestResourceName is the longest name of the resources of the project
+// which has to be a DNS-1035 label, the name of the metrics service.
+const longestResourceName = "controller-manager-metrics-service"
+
+// validateNameAffixes validates the name prefix and suffix, if set, keep the
+// names of the resources of the project valid DNS-1035 labels.
+func validateNameAffixes(prefix, suffix string) error {
+	if prefix != "" && !namePrefixRegex.MatchString(prefix) {
+		return fmt.Errorf("invalid name prefix %q, must consist of lower case alphanumeric characters or '-', "+
+			"and start with an alphabetic character", prefix)
+	}
+	if !nameSuffixRegex.MatchString(suffix) {
+		return fmt.Errorf("invalid name suffix %q, must consist of lower case alphanumeric characters or '-', "+
+			"and end with an alphanumeric character", suffix)
+	}
+	if max := 63 - len(longestResourceName); len(prefix)+len(suffix) > max {
+		return fmt.Errorf("invalid name prefix %q and suffix %q, must be no more than %d characters together",
+			prefix, suffix, max)
+	}
 	return nil
 }
 
 func (p *V2Project) EnsureDependencies() (bool, error) {
+	if err := rollback.SaveGoModule(); err != nil {
+		return false, err
+	}
 	c := exec.Command("go", "mod", "tidy") // #nosec
 	c.Stderr = os.Stderr
 	c.Stdout = os.Stdout
@@ -148,7 +261,9 @@ func (p *V2Project) EnsureDependencies() (bool, error) {
 }
 
 func (p *V2Project) Scaffold() error {
-	p.Project.Version = project.Version2
+	if p.Project.Version != project.Version3 {
+		p.Project.Version = project.Version2
+	}
 
 	s := &Scaffold{
 		BoilerplateOptional: true,
@@ -164,6 +279,10 @@ func (p *V2Project) Scaffold() error {
 	if err != nil {
 		return err
 	}
+	// a boilerplate of another path is recorded for the next commands
+	if bpInput.Path != filepath.Join("hack", "boilerplate.go.txt") {
+		p.Project.Boilerplate = filepath.ToSlash(filepath.Clean(bpInput.Path))
+	}
 
 	err = s.Execute(
 		input.Options{ProjectPath: projectInput.Path, BoilerplatePath: bpInput.Path},
@@ -177,18 +296,18 @@ func (p *V2Project) Scaffold() error {
 	// default controller manager image name
 	imgName := "controller:latest"
 
-	s = &Scaffold{}
-	return s.Execute(
-		input.Options{ProjectPath: projectInput.Path, BoilerplatePath: bpInput.Path},
+	files := []input.File{
 		&project.GitIgnore{},
 		&scaffoldv2.KustomizeImagePatch{},
 		&metricsauthv2.KustomizePrometheusMetricsPatch{},
-		&metricsauthv2.KustomizeAuthProxyPatch{},
-		&scaffoldv2.AuthProxyService{},
+		&metricsauthv2.KustomizeAuthProxyPatch{ReadOnly: p.ReadOnly},
+		&scaffoldv2.AuthProxyService{DualStack: p.DualStack},
 		&project.AuthProxyRole{},
 		&project.AuthProxyRoleBinding{},
-		&managerv2.Config{Image: imgName},
-		&scaffoldv2.Main{},
+		&managerv2.Config{Image: imgName, ReadOnly: p.ReadOnly},
+		&scaffoldv2.Main{Heartbeat: p.Heartbeat, Settings: p.Settings, Capabilities: p.Capabilities,
+			ControllerUserAgents: p.ControllerUserAgents, RemoteCluster: p.RemoteCluster,
+			ReconcileTimeout: p.ReconcileTimeout, ReadOnly: p.ReadOnly},
 		&scaffoldv2.GoMod{},
 		&scaffoldv2.Makefile{Image: imgName},
 		&scaffoldv2.Dockerfile{},
@@ -201,9 +320,40 @@ func (p *V2Project) Scaffold() error {
 		&managerv2.Kustomization{},
 		&webhook.Kustomization{},
 		&webhook.KustomizeConfigWebhook{},
-		&webhook.Service{},
+		&webhook.Service{DualStack: p.DualStack},
 		&webhook.InjectCAPatch{},
 		&certmanager.CertManager{},
 		&certmanager.Kustomization{},
-		&certmanager.KustomizeConfig{})
+		&certmanager.KustomizeConfig{},
+		&uninstall.Script{},
+	}
+	if p.Heartbeat {
+		files = append(files, &scaffoldv2.Heartbeat{}, &scaffoldv2.HeartbeatTest{})
+	}
+	if p.Settings {
+		files = append(files, &scaffoldv2.Settings{})
+	}
+	if p.Heartbeat || p.Settings {
+		files = append(files, &scaffoldv2.OperatorNamespace{})
+	}
+	if p.Capabilities {
+		files = append(files, &scaffoldv2.Capabilities{})
+	}
+	if p.ControllerUserAgents {
+		files = append(files, &scaffoldv2.ControllerClient{})
+	}
+	if p.RemoteCluster {
+		files = append(files, &scaffoldv2.RemoteCluster{}, &scaffoldv2.RemoteController{})
+	}
+	if p.ReconcileTimeout {
+		files = append(files, &scaffoldv2.ReconcileTimeout{})
+	}
+	if p.ReadOnly {
+		files = append(files, &scaffoldv2.ReadOnly{})
+	}
+
+	s = &Scaffold{}
+	return s.Execute(
+		input.Options{ProjectPath: projectInput.Path, BoilerplatePath: bpInput.Path},
+		files...)
 }
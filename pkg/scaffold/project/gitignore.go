@@ -45,10 +45,13 @@ var gitignoreTemplate = `
 *.dylib
 bin
 
+# Binaries of the test environment, e.g. etcd and kube-apiserver
+testbin
+
 # Test binary, build with ` + "`go test -c`" + `
 *.test
 
-# Output of the go coverage tool, specifically when used with LiteIDE
+# Output of the go coverage tool, e.g. cover.out written by make test
 *.out
 
 # Kubernetes Generated files - skip generated files, except for vendored files
@@ -57,7 +60,11 @@ bin
 
 # editor and IDE paraphernalia
 .idea
+.vscode
 *.swp
 *.swo
 *~
+
+# OS files
+.DS_Store
 `
@@ -17,7 +17,6 @@ limitations under the License.
 package project
 
 import (
-	"os"
 	"path/filepath"
 
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
@@ -39,12 +38,12 @@ func (c *Kustomize) GetInput() (input.Input, error) {
 		c.Path = filepath.Join("config", "default", "kustomization.yaml")
 	}
 	if c.Prefix == "" {
-		// use directory name as prefix
-		dir, err := os.Getwd()
+		// use the project name as prefix
+		name, err := c.GetProjectName()
 		if err != nil {
 			return input.Input{}, err
 		}
-		c.Prefix = filepath.Base(dir)
+		c.Prefix = name
 	}
 	c.TemplateBody = kustomizeTemplate
 	c.Input.IfExistsAction = input.Error
@@ -27,6 +27,65 @@ import (
 const (
 	Version1 = "1"
 	Version2 = "2"
+
+	// Version3 is scaffolded as Version2, its project file also tracking
+	// whether each resource has API types, a controller and webhooks
+	Version3 = "3"
+)
+
+// HasV2Layout returns whether the projects of the given version are
+// scaffolded with the v2 layout.
+func HasV2Layout(version string) bool {
+	return version == Version2 || version == Version3
+}
+
+// constants for CRD versions
+const (
+	// CRDVersionV1beta1 is the apiextensions.k8s.io version of the CRDs
+	// generated by controller-gen
+	CRDVersionV1beta1 = "v1beta1"
+)
+
+// constants for scheme registration strategies
+const (
+	// SchemeRegistrationMain adds each API group version to the scheme in main.go
+	SchemeRegistrationMain = "main"
+
+	// SchemeRegistrationRegistry adds each API group version to a central
+	// registry in api/scheme.go, which main.go registers as a whole
+	SchemeRegistrationRegistry = "registry"
+)
+
+// constants for webhook serving certificate providers
+const (
+	// CertProviderCertManager issues the webhook certificate with cert-manager
+	CertProviderCertManager = "cert-manager"
+
+	// CertProviderVault renders the webhook certificate with the Vault agent injector
+	CertProviderVault = "vault"
+
+	// CertProviderCSI mounts the webhook certificate with the secrets-store CSI driver
+	CertProviderCSI = "csi"
+
+	// CertProviderSelfSigned issues the webhook certificate with a self-signed
+	// CA from the manager
+	CertProviderSelfSigned = "self-signed"
+)
+
+// constants for the policy engines webhooks delegate the admission to
+const (
+	// PolicyDelegateOPA queries the deny rule of an Open Policy Agent
+	PolicyDelegateOPA = "opa"
+
+	// PolicyDelegateHTTP posts the admission request to an HTTP endpoint
+	// answering whether to allow it
+	PolicyDelegateHTTP = "http"
+)
+
+// constants for packaging formats
+const (
+	// PackagingHelm generates a Helm chart from the kustomize config
+	PackagingHelm = "helm"
 )
 
 var _ input.File = &Project{}
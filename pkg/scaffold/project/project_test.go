@@ -202,6 +202,17 @@ Copyright %s Example Owners.
 				instance.Repo = "project"
 				Expect(s.Execute(input.Options{}, instance)).NotTo(HaveOccurred())
 
+				// Verify the contents matches the golden file.
+				Expect(result.Actual.String()).To(BeEquivalentTo(result.Golden))
+			})
+		})
+		Context("with a project name", func() {
+			It("should prefix the resources with the project name", func() {
+				instance := &project.Kustomize{}
+				instance.Repo = "project"
+				instance.ProjectName = "project"
+				Expect(s.Execute(input.Options{}, instance)).NotTo(HaveOccurred())
+
 				// Verify the contents matches the golden file.
 				Expect(result.Actual.String()).To(BeEquivalentTo(result.Golden))
 			})
@@ -0,0 +1,241 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/kubebuilder/pkg/diff"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
+)
+
+// rename is the rewrite of the domain and the repo of a project in the
+// content and the paths of its files.
+type rename struct {
+	oldRepo, newRepo     string
+	oldDomain, newDomain string
+	// dashed are the API groups of the project with dots replaced by dashes,
+	// as in the webhook paths, before and after the rename
+	dashed [][2]string
+}
+
+// renamedFile is a file whose content or path is changed by the rename.
+type renamedFile struct {
+	from, to      string
+	before, after []byte
+}
+
+// renameSkippedDirs are the directories of the project which are not
+// rewritten, along with the hidden ones, .kubebuilder recording the history
+// of the project as it ran.
+var renameSkippedDirs = map[string]bool{"vendor": true, "bin": true, "testbin": true}
+
+// newRename returns the rename of the project to the domain and the repo,
+// an empty one keeping the current value.
+func newRename(p *input.ProjectFile, domain, repo string) *rename {
+	r := &rename{oldRepo: p.Repo, newRepo: p.Repo, oldDomain: p.Domain, newDomain: p.Domain}
+	if repo != "" {
+		r.newRepo = repo
+	}
+	if domain != "" {
+		r.newDomain = domain
+	}
+	for _, group := range p.ResourceGroups() {
+		if group == "" {
+			continue
+		}
+		r.dashed = append(r.dashed, [2]string{
+			strings.Replace(group+"."+r.oldDomain, ".", "-", -1),
+			strings.Replace(group+"."+r.newDomain, ".", "-", -1),
+		})
+	}
+	return r
+}
+
+// isDomainChar returns whether c can be part of a label of a domain name.
+func isDomainChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-'
+}
+
+// isRepoChar returns whether c can be part of an element of a module path.
+func isRepoChar(c byte) bool {
+	return isDomainChar(c) || c == '_' || c == '~'
+}
+
+// rewrite returns s with the occurrences of the repo, the qualified names
+// ending with the domain, e.g. the API groups and webhook names, and the
+// dashed API groups of the webhook paths renamed. The repo is matched first,
+// so that a repo containing the domain is only renamed with --repo.
+func (r *rename) rewrite(s string) string {
+	b := &strings.Builder{}
+	for i := 0; i < len(s); {
+		before := byte(' ')
+		if i > 0 {
+			before = s[i-1]
+		}
+		// ends returns whether the match of n bytes ends the name at i, made
+		// of the characters accepted by isChar
+		ends := func(n int, isChar func(byte) bool) bool {
+			end := i + n
+			if end == len(s) {
+				return true
+			}
+			// a dot continues the name unless it ends a sentence
+			if s[end] == '.' {
+				return end+1 == len(s) || !isChar(s[end+1])
+			}
+			return !isChar(s[end])
+		}
+
+		if strings.HasPrefix(s[i:], r.oldRepo) && !isRepoChar(before) && before != '.' && before != '/' &&
+			ends(len(r.oldRepo), isRepoChar) {
+			b.WriteString(r.newRepo)
+			i += len(r.oldRepo)
+			continue
+		}
+		if before == '.' && strings.HasPrefix(s[i:], r.oldDomain) && ends(len(r.oldDomain), isDomainChar) {
+			b.WriteString(r.newDomain)
+			i += len(r.oldDomain)
+			continue
+		}
+		matched := false
+		for _, d := range r.dashed {
+			if before == '-' && strings.HasPrefix(s[i:], d[0]+"-") {
+				b.WriteString(d[1])
+				i += len(d[0])
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// files returns the files of the project changed by the rename, sorted by
+// path. The PROJECT file is not included, it is saved with the new values.
+func (r *rename) files() ([]renamedFile, error) {
+	var files []renamedFile
+	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != "." && (strings.HasPrefix(info.Name(), ".") || renameSkippedDirs[info.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || path == "PROJECT" || path == "go.sum" {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path) // nolint: gosec
+		if err != nil {
+			return err
+		}
+		// binaries, e.g. the manager built in the project root, are not renamed
+		if bytes.IndexByte(content, 0) >= 0 {
+			return nil
+		}
+		f := renamedFile{
+			from:   path,
+			to:     r.rewrite(filepath.ToSlash(path)),
+			before: content,
+			after:  []byte(r.rewrite(string(content))),
+		}
+		f.to = filepath.FromSlash(f.to)
+		if f.from != f.to || !bytes.Equal(f.before, f.after) {
+			files = append(files, f)
+		}
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].from < files[j].from })
+	return files, err
+}
+
+// renameProject renames the domain and the repo of the project in its files,
+// moving the files named after the API groups, e.g. the CRD manifests, and
+// in the PROJECT file. With dryRun, the diff of the rename is printed
+// instead.
+func renameProject(p *input.ProjectFile, domain, repo string, dryRun bool) error {
+	r := newRename(p, domain, repo)
+	if r.oldDomain == r.newDomain && r.oldRepo == r.newRepo {
+		fmt.Println("The project already has the domain and the repo, nothing to rename.")
+		return nil
+	}
+	files, err := r.files()
+	if err != nil {
+		return fmt.Errorf("error reading the project files: %v", err)
+	}
+
+	renamed := *p
+	renamed.Domain, renamed.Repo = r.newDomain, r.newRepo
+
+	if dryRun {
+		before, err := yaml.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("error marshalling project info %v", err)
+		}
+		after, err := yaml.Marshal(&renamed)
+		if err != nil {
+			return fmt.Errorf("error marshalling project info %v", err)
+		}
+		fmt.Print(diff.Unified("PROJECT", "PROJECT", before, after))
+		for _, f := range files {
+			fmt.Print(diff.Unified(filepath.ToSlash(f.from), filepath.ToSlash(f.to), f.before, f.after))
+		}
+		return nil
+	}
+
+	for _, f := range files {
+		if f.from != f.to {
+			if _, err := os.Stat(f.to); err == nil {
+				return fmt.Errorf("error renaming %s, %s already exists", f.from, f.to)
+			}
+		}
+		if err := writeIfChanged(f.to, f.after); err != nil {
+			return err
+		}
+		if f.from != f.to {
+			if err := rollback.Save(f.from); err != nil {
+				return err
+			}
+			if err := os.Remove(f.from); err != nil {
+				return fmt.Errorf("error removing %s: %v", f.from, err)
+			}
+			result.FileModified(f.from)
+		}
+	}
+	*p = renamed
+	if err := saveProjectFile("PROJECT", p); err != nil {
+		return fmt.Errorf("error updating project file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package result records what a scaffolding run did to the project, so that
+// commands can report it in a machine-readable form.
+package result
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Marker is a scaffold marker which received new code.
+type Marker struct {
+	// File is the file containing the marker
+	File string `json:"file" yaml:"file"`
+
+	// Marker is the marker, e.g. // +kubebuilder:scaffold:imports
+	Marker string `json:"marker" yaml:"marker"`
+}
+
+// Result is the outcome of a scaffolding run.
+type Result struct {
+	// FilesCreated are the files which did not exist before the run
+	FilesCreated []string `json:"filesCreated" yaml:"filesCreated"`
+
+	// FilesModified are the existing files which were changed by the run
+	FilesModified []string `json:"filesModified" yaml:"filesModified"`
+
+	// MarkersInjected are the markers below which code was inserted
+	MarkersInjected []Marker `json:"markersInjected" yaml:"markersInjected"`
+
+	// Warnings are the warnings emitted during the run
+	Warnings []string `json:"warnings" yaml:"warnings"`
+
+	// Error is the failure of the run, nil if it succeeded
+	Error *Error `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Error is the failure of a run, of a kind determining the exit code of the
+// command.
+type Error struct {
+	// Kind is the kind of the failure, e.g. invalid-flags
+	Kind string `json:"kind" yaml:"kind"`
+
+	// Message is the error message
+	Message string `json:"message" yaml:"message"`
+
+	// ExitCode is the exit code of the command
+	ExitCode int `json:"exitCode" yaml:"exitCode"`
+}
+
+var (
+	mu      sync.Mutex
+	current = newResult()
+)
+
+func newResult() *Result {
+	return &Result{
+		FilesCreated:    []string{},
+		FilesModified:   []string{},
+		MarkersInjected: []Marker{},
+		Warnings:        []string{},
+	}
+}
+
+// FileCreated records that the file at path was created.
+func FileCreated(path string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !contains(current.FilesCreated, path) {
+		current.FilesCreated = append(current.FilesCreated, path)
+	}
+}
+
+// FileModified records that the existing file at path was changed. Files
+// created during the same run are only reported as created.
+func FileModified(path string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !contains(current.FilesCreated, path) && !contains(current.FilesModified, path) {
+		current.FilesModified = append(current.FilesModified, path)
+	}
+}
+
+// MarkerInjected records that code was inserted below marker in the file at path.
+func MarkerInjected(path, marker string) {
+	mu.Lock()
+	defer mu.Unlock()
+	m := Marker{File: path, Marker: marker}
+	for _, existing := range current.MarkersInjected {
+		if existing == m {
+			return
+		}
+	}
+	current.MarkersInjected = append(current.MarkersInjected, m)
+}
+
+// Warnf records a warning and prints it.
+func Warnf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	mu.Lock()
+	current.Warnings = append(current.Warnings, msg)
+	mu.Unlock()
+	fmt.Printf("Warning: %s\n", msg)
+}
+
+// Get returns what was recorded so far, with the files and markers sorted.
+func Get() Result {
+	mu.Lock()
+	defer mu.Unlock()
+	r := Result{
+		FilesCreated:    append([]string{}, current.FilesCreated...),
+		FilesModified:   append([]string{}, current.FilesModified...),
+		MarkersInjected: append([]Marker{}, current.MarkersInjected...),
+		Warnings:        append([]string{}, current.Warnings...),
+	}
+	sort.Strings(r.FilesCreated)
+	sort.Strings(r.FilesModified)
+	sort.Slice(r.MarkersInjected, func(i, j int) bool {
+		a, b := r.MarkersInjected[i], r.MarkersInjected[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Marker < b.Marker
+	})
+	return r
+}
+
+// DiscardChanges discards the files and markers recorded so far, once the
+// changes of the run were rolled back. The warnings are kept.
+func DiscardChanges() {
+	mu.Lock()
+	defer mu.Unlock()
+	current.FilesCreated = []string{}
+	current.FilesModified = []string{}
+	current.MarkersInjected = []Marker{}
+}
+
+// Reset discards what was recorded so far.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	current = newResult()
+}
+
+func contains(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rollback undoes the changes of a scaffolding run failing midway, so
+// that the project is left as it was before the run.
+//
+// The writers of the scaffolds call Save before writing a file, which records
+// the content of the file, or that it and its missing parent directories did
+// not exist. Rollback then restores the saved files in the reverse order of
+// their first write. Nothing is recorded until Begin is called.
+package rollback
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// entry is the state of a path before the run first wrote it.
+type entry struct {
+	path string
+	// existed is true if path was an existing file
+	existed bool
+	content []byte
+	mode    os.FileMode
+	// dirs are the parent directories of path which did not exist, the deepest
+	// first
+	dirs []string
+}
+
+var (
+	mu        sync.Mutex
+	recording bool
+	saved     map[string]bool
+	journal   []entry
+)
+
+// Begin starts recording the files saved by the writers, discarding what was
+// recorded before.
+func Begin() {
+	mu.Lock()
+	defer mu.Unlock()
+	recording = true
+	saved = map[string]bool{}
+	journal = nil
+}
+
+// Commit stops recording and discards what was recorded, keeping the changes
+// of the run.
+func Commit() {
+	mu.Lock()
+	defer mu.Unlock()
+	recording = false
+	saved = nil
+	journal = nil
+}
+
+// Save records the state of the file at path, which is about to be written,
+// if it is the first write of path since Begin.
+func Save(path string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if !recording {
+		return nil
+	}
+	path = filepath.Clean(path)
+	if saved[path] {
+		return nil
+	}
+
+	e := entry{path: path}
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		content, err := ioutil.ReadFile(path) // nolint: gosec
+		if err != nil {
+			return fmt.Errorf("error saving %s before writing it: %v", path, err)
+		}
+		e.existed, e.content, e.mode = true, content, info.Mode().Perm()
+	case os.IsNotExist(err):
+		for dir := filepath.Dir(path); !saved[dir]; dir = filepath.Dir(dir) {
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				break
+			}
+			e.dirs = append(e.dirs, dir)
+			saved[dir] = true
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	default:
+		return fmt.Errorf("error saving %s before writing it: %v", path, err)
+	}
+	saved[path] = true
+	journal = append(journal, e)
+	return nil
+}
+
+// SaveGoModule records the state of the go.mod and go.sum files of the current
+// directory, which the go commands about to be run may update.
+func SaveGoModule() error {
+	for _, path := range []string{"go.mod", "go.sum"} {
+		if err := Save(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback restores the files saved since Begin, removing those which did not
+// exist along with their new directories, and stops recording. It returns the
+// files it restored or removed. The files it failed to restore are reported by
+// the error, and remain as the run left them.
+func Rollback() ([]string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	recording = false
+
+	restored := []string{}
+	var failed []string
+	for i := len(journal) - 1; i >= 0; i-- {
+		e := journal[i]
+		var err error
+		if e.existed {
+			err = ioutil.WriteFile(e.path, e.content, e.mode)
+		} else {
+			err = os.Remove(e.path)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		}
+		if err != nil {
+			failed = append(failed, e.path)
+			continue
+		}
+		restored = append(restored, e.path)
+		for _, dir := range e.dirs {
+			// the directories still containing files are kept
+			_ = os.Remove(dir)
+		}
+	}
+	saved = nil
+	journal = nil
+
+	if len(failed) > 0 {
+		return restored, fmt.Errorf("error restoring %v", failed)
+	}
+	return restored, nil
+}
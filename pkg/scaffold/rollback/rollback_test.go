@@ -0,0 +1,93 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollback_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
+)
+
+func TestRollback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rollback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	existing := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(existing, []byte("original"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	created := filepath.Join(dir, "api", "v1", "kind_types.go")
+
+	// the writes before Begin are not recorded
+	if err := rollback.Save(existing); err != nil {
+		t.Fatal(err)
+	}
+	rollback.Begin()
+	for _, path := range []string{existing, created, existing} {
+		if err := rollback.Save(path); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("scaffolded"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	restored, err := rollback.Rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(restored)
+	if want := []string{created, existing}; !reflect.DeepEqual(restored, want) {
+		t.Errorf("Rollback() = %v, want %v", restored, want)
+	}
+	content, err := ioutil.ReadFile(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "original" {
+		t.Errorf("%s contains %q, want %q", existing, content, "original")
+	}
+	info, err := os.Stat(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("%s has mode %v, want %v", existing, info.Mode().Perm(), os.FileMode(0600))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "api")); !os.IsNotExist(err) {
+		t.Errorf("the directories created for %s were not removed: %v", created, err)
+	}
+
+	// nothing is recorded once rolled back
+	if err := rollback.Save(existing); err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := rollback.Rollback(); err != nil || len(restored) != 0 {
+		t.Errorf("Rollback() = %v, %v after a rollback, want nothing restored", restored, err)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+	resourcev1 "sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+	resourcev2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2"
+)
+
+// Samples contains configuration for generating the samples of the resources
+// of a project from the +kubebuilder:example markers of their API types.
+type Samples struct {
+	project *input.ProjectFile
+}
+
+// Validate validates whether the samples of the project can be generated.
+func (s *Samples) Validate() error {
+	if err := s.setDefaults(); err != nil {
+		return err
+	}
+	if !project.HasV2Layout(s.project.Version) {
+		return fmt.Errorf("generating samples is not supported for project version %s", s.project.Version)
+	}
+	return nil
+}
+
+func (s *Samples) setDefaults() error {
+	if s.project == nil {
+		p, err := LoadProjectFile("PROJECT")
+		if err != nil {
+			return err
+		}
+		s.project = &p
+	}
+	return nil
+}
+
+// Scaffold overwrites the samples of the resources whose spec has example
+// markers, leaving the others untouched.
+func (s *Samples) Scaffold() error {
+	if err := s.setDefaults(); err != nil {
+		return err
+	}
+
+	var files []input.File
+	for _, res := range s.project.Resources {
+		if !res.HasAPI() {
+			continue
+		}
+		r := &resourcev1.Resource{Group: res.Group, Domain: res.Domain, Version: res.Version, Kind: res.Kind}
+		// the domain of the resource, if any, is kept over the one of the project
+		files = append(files, &resourcev2.CRDSample{Input: input.Input{Domain: res.Domain}, Resource: r, FromExamples: true})
+	}
+	if err := (&Scaffold{}).Execute(input.Options{}, files...); err != nil {
+		return fmt.Errorf("error generating samples: %v", err)
+	}
+	return nil
+}
@@ -20,17 +20,21 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"text/template"
 
 	"golang.org/x/tools/imports"
 	yaml "gopkg.in/yaml.v2"
+	"sigs.k8s.io/kubebuilder/pkg/merge"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+	"sigs.k8s.io/kubebuilder/pkg/textfile"
 )
 
 // Scaffold writes Templates to scaffold new files
@@ -54,6 +58,15 @@ type Scaffold struct {
 	GetWriter func(path string) (io.Writer, error)
 
 	FileExists func(path string) bool
+
+	// Merge merges the scaffold of the Go files which already exist into them
+	// rather than skipping them or failing, adding the imports, declarations
+	// and struct fields they do not have. The other existing files are kept.
+	Merge bool
+
+	// Domain overrides the domain of the project set on the templates, for
+	// the files of a resource whose group does not end with it
+	Domain string
 }
 
 func (s *Scaffold) setFieldsAndValidate(t input.File) error {
@@ -65,7 +78,11 @@ func (s *Scaffold) setFieldsAndValidate(t input.File) error {
 		b.SetBoilerplate(s.Boilerplate)
 	}
 	if b, ok := t.(input.Domain); ok {
-		b.SetDomain(s.Project.Domain)
+		if s.Domain != "" {
+			b.SetDomain(s.Domain)
+		} else {
+			b.SetDomain(s.Project.Domain)
+		}
 	}
 	if b, ok := t.(input.Version); ok {
 		b.SetVersion(s.Project.Version)
@@ -76,6 +93,18 @@ func (s *Scaffold) setFieldsAndValidate(t input.File) error {
 	if b, ok := t.(input.ProjecPath); ok {
 		b.SetProjectPath(s.ProjectPath)
 	}
+	if b, ok := t.(input.MultiGroup); ok {
+		b.SetMultiGroup(s.Project.MultiGroup)
+	}
+	if b, ok := t.(input.ProjectName); ok {
+		b.SetProjectName(s.Project.ProjectName)
+	}
+	if b, ok := t.(input.NamePrefix); ok {
+		b.SetNamePrefix(s.Project.NamePrefix)
+	}
+	if b, ok := t.(input.NameSuffix); ok {
+		b.SetNameSuffix(s.Project.NameSuffix)
+	}
 
 	// Validate the template is ok
 	if v, ok := t.(input.Validate); ok {
@@ -88,7 +117,7 @@ func (s *Scaffold) setFieldsAndValidate(t input.File) error {
 
 // LoadProjectFile reads the project file and deserializes it into a Project
 func LoadProjectFile(path string) (input.ProjectFile, error) {
-	in, err := ioutil.ReadFile(path) // nolint: gosec
+	in, _, err := textfile.ReadFile(path)
 	if err != nil {
 		return input.ProjectFile{}, err
 	}
@@ -111,47 +140,57 @@ func saveProjectFile(path string, project *input.ProjectFile) error {
 	if err != nil {
 		return fmt.Errorf("error marshalling project info %v", err)
 	}
-	err = ioutil.WriteFile(path, content, os.ModePerm)
+	err = (&FileWriter{}).WriteFile(path, content)
 	if err != nil {
 		return fmt.Errorf("failed to save project file at %s %v", path, err)
 	}
+	result.FileModified(path)
 	return nil
 }
 
-// GetBoilerplate reads the boilerplate file
+// GetBoilerplate reads the boilerplate file. It is read in normal form, as a
+// byte order mark or CRLF line endings would end up in the middle of the
+// scaffolded files.
 func getBoilerplate(path string) (string, error) {
-	b, err := ioutil.ReadFile(path) // nolint: gosec
+	b, _, err := textfile.ReadFile(path)
 	return string(b), err
 }
 
 func (s *Scaffold) defaultOptions(options *input.Options) error {
-	// Use the default Boilerplate path if unset
-	if options.BoilerplatePath == "" {
-		options.BoilerplatePath = filepath.Join("hack", "boilerplate.go.txt")
-	}
-
 	// Use the default Project path if unset
 	if options.ProjectPath == "" {
 		options.ProjectPath = "PROJECT"
 	}
 
-	s.BoilerplatePath = options.BoilerplatePath
-
 	var err error
-	s.Boilerplate, err = getBoilerplate(options.BoilerplatePath)
-	if !s.BoilerplateOptional && err != nil {
+	s.Project, err = LoadProjectFile(options.ProjectPath)
+	if !s.ProjectOptional && err != nil {
 		return err
 	}
 
-	s.Project, err = LoadProjectFile(options.ProjectPath)
-	if !s.ProjectOptional && err != nil {
+	// Use the Boilerplate path of the project, or the default one, if unset
+	if options.BoilerplatePath == "" {
+		options.BoilerplatePath = s.Project.Boilerplate
+	}
+	if options.BoilerplatePath == "" {
+		options.BoilerplatePath = filepath.Join("hack", "boilerplate.go.txt")
+	}
+
+	s.BoilerplatePath = options.BoilerplatePath
+
+	s.Boilerplate, err = getBoilerplate(options.BoilerplatePath)
+	if !s.BoilerplateOptional && err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// Execute executes scaffolding the Files
+// Execute executes scaffolding the Files. The inputs of all the Files are
+// read before any of them is written, and the Files are then rendered
+// concurrently: a File whose input depends on another File of the same call,
+// e.g. on it existing, must be scaffolded by a later call. The Files are
+// written in order, and those before the first failing one are written.
 func (s *Scaffold) Execute(options input.Options, files ...input.File) error {
 	if s.GetWriter == nil {
 		s.GetWriter = (&FileWriter{}).WriteCloser
@@ -166,12 +205,15 @@ func (s *Scaffold) Execute(options input.Options, files ...input.File) error {
 	if err := s.defaultOptions(&options); err != nil {
 		return err
 	}
-	for _, f := range files {
-		if err := s.doFile(f); err != nil {
+
+	jobs, prepareErr := s.prepare(files)
+	renderJobs(jobs)
+	for _, j := range jobs {
+		if err := s.finish(j); err != nil {
 			return err
 		}
 	}
-	return nil
+	return prepareErr
 }
 
 type errorAlreadyExists struct {
@@ -187,59 +229,167 @@ func isAlreadyExistsError(e error) bool {
 	return ok
 }
 
-// doFile scaffolds a single file
-func (s *Scaffold) doFile(e input.File) error {
+// job is a file to scaffold, with its content once rendered.
+type job struct {
+	input  input.Input
+	file   input.File
+	exists bool
+	merge  bool
+
+	// content is the rendered content of the file, nil if a merged file has
+	// nothing to add
+	content []byte
+	// messages are printed when the file is written
+	messages []string
+	err      error
+}
+
+// prepare sets the fields of the files and reads their inputs, returning the
+// jobs of the files to render up to the first failing one, and its error.
+func (s *Scaffold) prepare(files []input.File) ([]*job, error) {
+	jobs := make([]*job, 0, len(files))
+	for _, f := range files {
+		j, err := s.prepareFile(f)
+		if err != nil {
+			return jobs, err
+		}
+		if j != nil {
+			jobs = append(jobs, j)
+		}
+	}
+	return jobs, nil
+}
+
+// prepareFile returns the job scaffolding a single file, nil if it is skipped.
+func (s *Scaffold) prepareFile(e input.File) (*job, error) {
 	// Set common fields
 	err := s.setFieldsAndValidate(e)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Get the template input params
 	i, err := e.GetInput()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Check if the file to write already exists
-	if s.FileExists(i.Path) {
+	exists := s.FileExists(i.Path)
+	if exists && s.Merge && i.IfExistsAction != input.Overwrite {
+		if filepath.Ext(i.Path) != ".go" {
+			return nil, nil
+		}
+		return &job{input: i, file: e, exists: true, merge: true}, nil
+	}
+	if exists {
 		switch i.IfExistsAction {
 		case input.Overwrite:
 		case input.Skip:
-			return nil
+			return nil, nil
 		case input.Error:
-			return &errorAlreadyExists{path: i.Path}
+			return nil, &errorAlreadyExists{path: i.Path}
 		}
 	}
+	return &job{input: i, file: e, exists: exists}, nil
+}
 
-	if err := s.doTemplate(i, e); err != nil {
-		return err
+// renderJobs renders the jobs with a pool of workers.
+func renderJobs(jobs []*job) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(jobs) {
+		workers = len(jobs)
 	}
-	return nil
+	queue := make(chan *job)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				if j.merge {
+					j.err = j.doMerge()
+				} else {
+					j.content, j.err = render(j.input, j.file)
+				}
+			}
+		}()
+	}
+	for _, j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
 }
 
-// doTemplate executes the template for a file using the input
-func (s *Scaffold) doTemplate(i input.Input, e input.File) error {
-	temp, err := newTemplate(e).Parse(i.TemplateBody)
+// doMerge merges the scaffold of an existing Go file into it.
+func (j *job) doMerge() error {
+	generated, err := render(j.input, j.file)
 	if err != nil {
 		return err
 	}
-	f, err := s.GetWriter(i.Path)
+	existing, _, err := textfile.ReadFile(j.input.Path)
 	if err != nil {
 		return err
 	}
-	if c, ok := f.(io.Closer); ok {
-		defer func() {
-			if err := c.Close(); err != nil {
-				log.Fatal(err)
-			}
-		}()
+	merged, err := merge.Go(j.input.Path, existing, generated)
+	if err != nil {
+		return err
+	}
+	if len(merged.Kept) > 0 {
+		j.messages = append(j.messages,
+			fmt.Sprintf("%s: kept the code of %s", j.input.Path, strings.Join(merged.Kept, ", ")))
+	}
+	if len(merged.Added) == 0 {
+		return nil
+	}
+	j.messages = append(j.messages,
+		fmt.Sprintf("%s: added %s", j.input.Path, strings.Join(merged.Added, ", ")))
+
+	// the imports of the added code only are pruned
+	j.content, err = imports.Process(j.input.Path, merged.Content, nil)
+	return err
+}
+
+// finish writes the rendered file of a job and records it in the result.
+func (s *Scaffold) finish(j *job) error {
+	for _, m := range j.messages {
+		fmt.Println(m)
+	}
+	if j.err != nil {
+		return j.err
+	}
+	if j.content == nil {
+		return nil
+	}
+	if err := s.write(j.input.Path, j.content); err != nil {
+		return err
+	}
+	if j.exists {
+		result.FileModified(j.input.Path)
+	} else {
+		result.FileCreated(j.input.Path)
+	}
+	return nil
+}
+
+// render executes the template for a file using the input, returning its
+// content. It is safe for concurrent use.
+func render(i input.Input, e input.File) ([]byte, error) {
+	temp, err := templates.get(e, i.TemplateBody)
+	if err != nil {
+		return nil, err
 	}
+	return execute(temp, i, e)
+}
 
+// execute executes the parsed template for a file using the input, returning
+// its content.
+func execute(temp *template.Template, i input.Input, e input.File) ([]byte, error) {
 	out := &bytes.Buffer{}
-	err = temp.Execute(out, e)
+	err := temp.Execute(out, e)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	b := out.Bytes()
 
@@ -248,14 +398,61 @@ func (s *Scaffold) doTemplate(i input.Input, e input.File) error {
 		b, err = imports.Process(i.Path, b, nil)
 		if err != nil {
 			fmt.Printf("%s\n", out.Bytes())
-			return err
+			return nil, err
 		}
 	}
+	return b, nil
+}
 
+// write writes the content of the file at path.
+func (s *Scaffold) write(path string, b []byte) error {
+	f, err := s.GetWriter(path)
+	if err != nil {
+		return err
+	}
+	if c, ok := f.(io.Closer); ok {
+		defer func() {
+			if err := c.Close(); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
 	_, err = f.Write(b)
 	return err
 }
 
+// templates caches the parsed templates, which most of the commands render
+// once per resource.
+var templates = &templateCache{parsed: map[templateKey]*template.Template{}}
+
+type templateKey struct {
+	name string
+	body string
+}
+
+// templateCache parses each template once. A parsed template is safe to
+// execute concurrently.
+type templateCache struct {
+	mu     sync.Mutex
+	parsed map[templateKey]*template.Template
+}
+
+// get returns the template of the file parsed from body.
+func (c *templateCache) get(t input.File, body string) (*template.Template, error) {
+	key := templateKey{name: fmt.Sprintf("%T", t), body: body}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if temp, found := c.parsed[key]; found {
+		return temp, nil
+	}
+	temp, err := newTemplate(t).Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	c.parsed[key] = temp
+	return temp, nil
+}
+
 // newTemplate a new template with common functions
 func newTemplate(t input.File) *template.Template {
 	return template.New(fmt.Sprintf("%T", t)).Funcs(template.FuncMap{
@@ -0,0 +1,169 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+	resourcev2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2"
+)
+
+// benchmarkKinds is the number of APIs scaffolded by an iteration, each of
+// them with the files of create api.
+const benchmarkKinds = 20
+
+// inBenchmarkProject runs the benchmark in a temporary project, until the
+// returned func is called.
+func inBenchmarkProject(b *testing.B) func() {
+	dir, err := ioutil.TempDir("", "kubebuilder-benchmark-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		b.Fatal(err)
+	}
+
+	files := map[string]string{
+		"PROJECT": "version: \"2\"\ndomain: example.org\nrepo: example.org/project\n",
+		filepath.Join("hack", "boilerplate.go.txt"): "/*\nCopyright The Authors.\n*/",
+	}
+	for path, content := range files {
+		if err := (&FileWriter{}).WriteFile(path, []byte(content)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return func() {
+		if err := os.Chdir(wd); err != nil {
+			b.Fatal(err)
+		}
+		os.RemoveAll(dir) // nolint: errcheck
+	}
+}
+
+func benchmarkFiles() []input.File {
+	files := []input.File{}
+	for k := 0; k < benchmarkKinds; k++ {
+		r := &resource.Resource{Group: "crew", Version: "v1", Kind: fmt.Sprintf("Kind%d", k), Namespaced: true}
+		files = append(files,
+			&resourcev2.Types{Resource: r},
+			&resourcev2.TypesTest{Resource: r},
+			&resourcev2.CRDSample{Resource: r},
+			&resourcev2.Controller{Resource: r},
+		)
+	}
+	return files
+}
+
+// benchmarkScaffold writes the files in memory.
+func benchmarkScaffold() *Scaffold {
+	return &Scaffold{
+		GetWriter:  func(string) (io.Writer, error) { return &bytes.Buffer{}, nil },
+		FileExists: func(string) bool { return false },
+	}
+}
+
+// BenchmarkExecute compares rendering the files of many APIs one after the
+// other, parsing the template of each file, to rendering them with the cached
+// templates and the worker pool of Execute.
+func BenchmarkExecute(b *testing.B) {
+	defer inBenchmarkProject(b)()
+
+	b.Run("sequential", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			s := benchmarkScaffold()
+			if err := s.defaultOptions(&input.Options{}); err != nil {
+				b.Fatal(err)
+			}
+			jobs, err := s.prepare(benchmarkFiles())
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, j := range jobs {
+				// the baseline bypasses the template cache
+				temp, err := newTemplate(j.file).Parse(j.input.TemplateBody)
+				if err != nil {
+					b.Fatal(err)
+				}
+				j.content, j.err = execute(temp, j.input, j.file)
+				if err := s.finish(j); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("concurrent", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			if err := benchmarkScaffold().Execute(input.Options{}, benchmarkFiles()...); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkTemplate compares parsing the template of a file on each render to
+// reusing the parsed template.
+func BenchmarkTemplate(b *testing.B) {
+	defer inBenchmarkProject(b)()
+	s := benchmarkScaffold()
+	if err := s.defaultOptions(&input.Options{}); err != nil {
+		b.Fatal(err)
+	}
+	jobs, err := s.prepare(benchmarkFiles())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	execute := func(b *testing.B, get func(*job) (*template.Template, error)) {
+		for n := 0; n < b.N; n++ {
+			for _, j := range jobs {
+				temp, err := get(j)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := temp.Execute(ioutil.Discard, j.file); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+
+	b.Run("parsed", func(b *testing.B) {
+		execute(b, func(j *job) (*template.Template, error) {
+			return newTemplate(j.file).Parse(j.input.TemplateBody)
+		})
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		execute(b, func(j *job) (*template.Template, error) {
+			return templates.get(j.file, j.input.TemplateBody)
+		})
+	})
+}
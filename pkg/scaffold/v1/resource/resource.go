@@ -32,6 +32,11 @@ type Resource struct {
 	// Group is the API Group.  Does not contain the domain.
 	Group string
 
+	// Domain is the domain of the API Group of a resource whose group does not
+	// end with the domain of the project, e.g. legacy.io for the
+	// widgets.legacy.io group. It defaults to the domain of the project.
+	Domain string
+
 	// Version is the API version - e.g. v1beta1
 	Version string
 
@@ -46,8 +51,86 @@ type Resource struct {
 
 	// CreateExampleReconcileBody will create a Deployment in the Reconcile example
 	CreateExampleReconcileBody bool
+
+	// EmbedPodTemplate will add a pod template, whose metadata survives
+	// structural schema pruning, to the spec of the resource
+	EmbedPodTemplate bool
+
+	// CreationGuard will add the conditions to the status of the resource,
+	// recording when its controller limits the objects it creates
+	CreationGuard bool
+
+	// Expectations will track the creations and deletions of children of the
+	// controller of the resource which its informers have not observed yet
+	Expectations bool
+
+	// SpecHash will skip the updates of the children of the controller of the
+	// resource whose desired state is unchanged, recording its hash on them
+	SpecHash bool
+
+	// PayloadReference will add a reference to a payload stored in a
+	// ConfigMap or a Secret to the spec of the resource
+	PayloadReference bool
+
+	// ValidationStubs will add example fields showing the common validation
+	// markers to the spec of the resource
+	ValidationStubs bool
+
+	// WithConditions will add conditions, with helpers setting and reading
+	// them, to the status of the resource
+	WithConditions bool
+
+	// Scale will add the replicas to the spec and the status of the resource,
+	// the selector of its pods to its status, and the scale subresource to
+	// its CRD
+	Scale bool
+
+	// Optional will scaffold a kustomize component excluding the CRD and the
+	// controller of the resource from the deployment
+	Optional bool
+
+	// External indicates the resource is a pre-existing type, e.g. a core
+	// type of k8s.io/api, for which only a controller is scaffolded
+	External bool
+
+	// ExternalAPIPath is the import path of the package of the group of an
+	// external resource, its versions being subpackages, e.g. k8s.io/api/apps.
+	// It defaults to the k8s.io/api package of the core groups.
+	ExternalAPIPath string
+
+	// ExternalDomain is the domain of the API group of an external resource
+	// outside of the core groups. It defaults to the domain of the project.
+	ExternalDomain string
+
+	// Finalizer will add a finalizer to the resource in the Reconcile of its
+	// controller, removed once a deleted resource is cleaned up
+	Finalizer bool
+
+	// Phases will add a phase enum, with the given values, to the status of
+	// the resource
+	Phases []string
+
+	// ReconcileStatus will add the time and the error of the last reconcile
+	// to the status of the resource, recorded by a helper of its controller
+	ReconcileStatus bool
+
+	// TransitionEvents will make the controller record an event for each
+	// transition of the conditions and the phase of the resource
+	TransitionEvents bool
 }
 
+// GroupDomain returns the API group of the resource, its group qualified with
+// its domain, or else with the given domain of the project.
+func (r *Resource) GroupDomain(domain string) string {
+	if r.Domain != "" {
+		domain = r.Domain
+	}
+	return r.Group + "." + domain
+}
+
+// domainMatch matches the DNS-1123 subdomains of the API groups.
+var domainMatch = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
 // Validate checks the Resource values to make sure they are valid.
 func (r *Resource) Validate() error {
 	if len(r.Group) == 0 {
@@ -70,6 +153,10 @@ func (r *Resource) Validate() error {
 		return fmt.Errorf("group must match ^[a-z]+$ (was %s)", r.Group)
 	}
 
+	if r.Domain != "" && !domainMatch.MatchString(r.Domain) {
+		return fmt.Errorf("domain must be lower case alphanumeric labels separated by dots (was %s)", r.Domain)
+	}
+
 	versionMatch := regexp.MustCompile("^v\\d+(alpha\\d+|beta\\d+)?$")
 	if !versionMatch.MatchString(r.Version) {
 		return fmt.Errorf(
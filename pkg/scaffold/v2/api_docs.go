@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &APIDocsIndex{}
+
+// APIDocsIndex scaffolds the docs/api/index.html page browsing the OpenAPI
+// document of the CRDs, docs/api/openapi.json, with Swagger UI.
+type APIDocsIndex struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (a *APIDocsIndex) GetInput() (input.Input, error) {
+	name, err := a.GetProjectName()
+	if err != nil {
+		return input.Input{}, err
+	}
+	a.ProjectName = name
+	if a.Path == "" {
+		a.Path = filepath.Join("docs", "api", "index.html")
+	}
+	a.TemplateBody = apiDocsIndexTemplate
+	a.Input.IfExistsAction = input.Skip
+	return a.Input, nil
+}
+
+var apiDocsIndexTemplate = `<!DOCTYPE html>
+<!-- The API docs of the CRDs of the project, served by make serve-api-docs.
+     Swagger UI is loaded from unpkg.com, vendor it next to this page to browse
+     the docs offline. -->
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>{{ .ProjectName }} API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@3/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@3/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function() {
+      SwaggerUIBundle({
+        url: "openapi.json",
+        dom_id: "#swagger-ui",
+        // the docs are browsed without a cluster to send the requests to
+        supportedSubmitMethods: []
+      });
+    };
+  </script>
+</body>
+</html>
+`
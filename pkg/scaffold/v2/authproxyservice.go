@@ -27,6 +27,9 @@ var _ input.File = &AuthProxyService{}
 // AuthProxyService scaffolds the config/rbac/auth_proxy_service.yaml file
 type AuthProxyService struct {
 	input.Input
+
+	// DualStack indicates whether the Service prefers both IP families
+	DualStack bool
 }
 
 // GetInput implements input.File
@@ -50,6 +53,9 @@ metadata:
   name: controller-manager-metrics-service
   namespace: system
 spec:
+{{- if .DualStack }}
+  ipFamilyPolicy: PreferDualStack
+{{- end }}
   ports:
   - name: https
     port: 8443
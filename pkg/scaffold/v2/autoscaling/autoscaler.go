@@ -0,0 +1,179 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaling
+
+import (
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+// The bounds and the rate of the scaling are shared by the autoscalers: every
+// pod restarts when the number of shards changes, so the shards are added and
+// removed one at a time, and only once the depth of the workqueues stayed
+// over or under the target for several minutes.
+const scalingBehavior = `    scaleUp:
+      stabilizationWindowSeconds: 300
+      policies:
+      - type: Pods
+        value: 1
+        periodSeconds: 300
+    scaleDown:
+      stabilizationWindowSeconds: 900
+      policies:
+      - type: Pods
+        value: 1
+        periodSeconds: 900
+`
+
+var _ input.File = &HorizontalPodAutoscaler{}
+
+// HorizontalPodAutoscaler scaffolds the HorizontalPodAutoscaler scaling the
+// shards of the manager on the depth of the workqueues of its controllers.
+type HorizontalPodAutoscaler struct {
+	input.Input
+
+	// Prefix is the name prefix of the StatefulSet of the shards
+	Prefix string
+}
+
+// GetInput implements input.File
+func (h *HorizontalPodAutoscaler) GetInput() (input.Input, error) {
+	if h.Path == "" {
+		h.Path = filepath.Join("config", "autoscaling", "hpa.yaml")
+	}
+	if h.Prefix == "" {
+		var err error
+		if h.Prefix, err = h.GetNamePrefix(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	h.TemplateBody = hpaTemplate
+	h.Input.IfExistsAction = input.Error
+	return h.Input, nil
+}
+
+var hpaTemplate = `# Scales the shards of the manager on workqueue_depth, the number of items
+# queued by each controller, served as a pods metric of the custom metrics API
+# by prometheus-adapter, e.g. with the rule
+#   - seriesQuery: 'workqueue_depth{namespace!="",pod!=""}'
+#     resources:
+#       overrides:
+#         namespace: {resource: namespace}
+#         pod: {resource: pod}
+#     metricsQuery: 'sum(<<.Series>>{<<.LabelMatchers>>}) by (<<.GroupBy>>)'
+# The behavior requires Kubernetes 1.18 or later.
+apiVersion: autoscaling/v2beta2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: {{ .Prefix }}controller-manager
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: StatefulSet
+    name: {{ .Prefix }}controller-manager
+  # Every pod restarts when the number of shards changes, keep the bounds close.
+  minReplicas: 2
+  maxReplicas: 5
+  metrics:
+  - type: Pods
+    pods:
+      metric:
+        name: workqueue_depth
+      target:
+        type: AverageValue
+        # TODO(user): the items queued per shard over which a shard is added
+        averageValue: "100"
+  behavior:
+` + scalingBehavior
+
+var _ input.File = &ScaledObject{}
+
+// ScaledObject scaffolds the ScaledObject of KEDA scaling the shards of the
+// manager on the depth of the workqueues of its controllers.
+type ScaledObject struct {
+	input.Input
+
+	// Prefix is the name prefix of the StatefulSet of the shards
+	Prefix string
+
+	// Namespace is the namespace of the manager
+	Namespace string
+}
+
+// GetInput implements input.File
+func (s *ScaledObject) GetInput() (input.Input, error) {
+	if s.Path == "" {
+		s.Path = filepath.Join("config", "autoscaling", "scaledobject.yaml")
+	}
+	var err error
+	if s.Prefix == "" {
+		if s.Prefix, err = s.GetNamePrefix(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	if s.Namespace == "" {
+		if s.Namespace, err = s.GetNamespace(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	s.TemplateBody = scaledObjectTemplate
+	s.Input.IfExistsAction = input.Error
+	return s.Input, nil
+}
+
+var scaledObjectTemplate = `# Scales the shards of the manager on workqueue_depth, the number of items
+# queued by each controller, queried from the Prometheus scraping the metrics
+# of the shards.
+apiVersion: keda.sh/v1alpha1
+kind: ScaledObject
+metadata:
+  name: {{ .Prefix }}controller-manager
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: StatefulSet
+    name: {{ .Prefix }}controller-manager
+  # Every pod restarts when the number of shards changes, keep the bounds close.
+  minReplicaCount: 2
+  maxReplicaCount: 5
+  pollingInterval: 30
+  advanced:
+    horizontalPodAutoscalerConfig:
+      behavior:
+` + indent(scalingBehavior, "    ") + `  triggers:
+  - type: prometheus
+    metadata:
+      # TODO(user): the address of the Prometheus scraping the shards
+      serverAddress: http://prometheus-operated.monitoring.svc:9090
+      metricName: workqueue_depth
+      query: sum(workqueue_depth{namespace="{{ .Namespace }}"})
+      # TODO(user): the items queued per shard over which a shard is added
+      threshold: "100"
+`
+
+// indent indents the lines of s with prefix.
+func indent(s, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
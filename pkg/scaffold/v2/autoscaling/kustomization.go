@@ -0,0 +1,105 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaling
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+// The autoscalers scaling the shards of the manager.
+const (
+	// HPA is the HorizontalPodAutoscaler of Kubernetes, reading the depth of
+	// the workqueues from the custom metrics API
+	HPA = "hpa"
+	// KEDA is the ScaledObject of KEDA, querying the depth of the workqueues
+	// from Prometheus
+	KEDA = "keda"
+)
+
+// ValidateAutoscaler validates the autoscaler is one of HPA and KEDA.
+func ValidateAutoscaler(autoscaler string) error {
+	if autoscaler != HPA && autoscaler != KEDA {
+		return fmt.Errorf("autoscaler %s is not supported, it must be one of %s, %s", autoscaler, HPA, KEDA)
+	}
+	return nil
+}
+
+var _ input.File = &Kustomization{}
+
+// Kustomization scaffolds the Kustomization file of the autoscaled overlay,
+// which deploys the sharded manager of config/sharding along with its
+// autoscaler.
+type Kustomization struct {
+	input.Input
+
+	// Autoscaler is the autoscaler scaling the shards, one of HPA and KEDA
+	Autoscaler string
+
+	// Namespace to deploy the resources to
+	Namespace string
+}
+
+// GetInput implements input.File
+func (k *Kustomization) GetInput() (input.Input, error) {
+	if k.Path == "" {
+		k.Path = filepath.Join("config", "autoscaling", "kustomization.yaml")
+	}
+	if k.Namespace == "" {
+		var err error
+		if k.Namespace, err = k.GetNamespace(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	k.TemplateBody = kustomizationTemplate
+	k.Input.IfExistsAction = input.Error
+	return k.Input, nil
+}
+
+// Validate validates the values
+func (k *Kustomization) Validate() error {
+	return ValidateAutoscaler(k.Autoscaler)
+}
+
+var kustomizationTemplate = `# This overlay deploys the sharded manager of config/sharding, scaled on the
+# depth of the workqueues of its controllers, in place of config/sharding.
+# Deploy it with
+#   cd config/sharding && kustomize edit set image controller=${IMG}
+#   kustomize build config/autoscaling | kubectl apply -f -
+#
+# The number of shards follows the replicas of the StatefulSet: main.go must
+# get the shard of the manager from controllers.ShardFromStatefulSet and add a
+# controllers.ShardCountWatcher to it, restarting the pods once they were
+# scaled. The names are prefixed by config/sharding already, this overlay must
+# not add a namePrefix.
+namespace: {{ .Namespace }}
+
+bases:
+- ../sharding
+
+resources:
+{{- if eq .Autoscaler "keda" }}
+- scaledobject.yaml
+{{- else }}
+- hpa.yaml
+{{- end }}
+
+patchesStrategicMerge:
+- manager_patch.yaml
+`
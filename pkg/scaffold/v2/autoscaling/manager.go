@@ -0,0 +1,58 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaling
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &ManagerPatch{}
+
+// ManagerPatch scaffolds the patch of the sharded manager removing its fixed
+// number of shards, which the autoscaler can not update.
+type ManagerPatch struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (m *ManagerPatch) GetInput() (input.Input, error) {
+	if m.Path == "" {
+		m.Path = filepath.Join("config", "autoscaling", "manager_patch.yaml")
+	}
+	m.TemplateBody = managerPatchTemplate
+	m.Input.IfExistsAction = input.Error
+	return m.Input, nil
+}
+
+var managerPatchTemplate = `# The number of shards is the replicas of the StatefulSet, read by
+# controllers.ShardFromStatefulSet, rather than SHARD_COUNT.
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: controller-manager
+  namespace: system
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        env:
+        - name: SHARD_COUNT
+          $patch: delete
+`
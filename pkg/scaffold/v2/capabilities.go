@@ -0,0 +1,168 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &Capabilities{}
+
+// Capabilities scaffolds the capabilities/capabilities.go file, probing with
+// discovery whether the cluster serves the optional APIs some controllers
+// require.
+type Capabilities struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (c *Capabilities) GetInput() (input.Input, error) {
+	if c.Path == "" {
+		c.Path = filepath.Join("capabilities", "capabilities.go")
+	}
+	c.TemplateBody = capabilitiesTemplate
+	c.Input.IfExistsAction = input.Error
+	return c.Input, nil
+}
+
+// RequiredAPI is an API a controller is only set up with when the cluster
+// serves it.
+type RequiredAPI struct {
+	Group   string
+	Version string
+	Kind    string
+}
+
+var (
+	requiredAPIGroupRegex   = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	requiredAPIVersionRegex = regexp.MustCompile(`^v[1-9][0-9]*((alpha|beta)[1-9][0-9]*)?$`)
+	requiredAPIKindRegex    = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+)
+
+// ParseRequiredAPI parses an API given as <group>/<version>/<Kind>, or
+// <version>/<Kind> for the core group.
+func ParseRequiredAPI(s string) (RequiredAPI, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) == 2 {
+		parts = append([]string{""}, parts...)
+	}
+	if len(parts) != 3 {
+		return RequiredAPI{}, fmt.Errorf("required API %q must be <group>/<version>/<Kind>", s)
+	}
+	api := RequiredAPI{Group: parts[0], Version: parts[1], Kind: parts[2]}
+	if api.Group != "" && !requiredAPIGroupRegex.MatchString(api.Group) {
+		return RequiredAPI{}, fmt.Errorf("required API %q has an invalid group %q", s, api.Group)
+	}
+	if !requiredAPIVersionRegex.MatchString(api.Version) {
+		return RequiredAPI{}, fmt.Errorf("required API %q has an invalid version %q", s, api.Version)
+	}
+	if !requiredAPIKindRegex.MatchString(api.Kind) {
+		return RequiredAPI{}, fmt.Errorf("required API %q has an invalid kind %q, must be CamelCase", s, api.Kind)
+	}
+	return api, nil
+}
+
+var capabilitiesTemplate = `{{ .Boilerplate }}
+
+// Package capabilities detects the optional APIs served by the cluster, so that
+// the controllers depending on them are only set up where they exist, e.g. the
+// ServiceMonitors of the Prometheus operator or the Routes of OpenShift.
+package capabilities
+
+import (
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+// The well-known optional APIs.
+var (
+	// ServiceMonitor is the API of the Prometheus operator scrape targets
+	ServiceMonitor = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+	// Route is the API of the OpenShift ingress routes
+	Route = schema.GroupVersionKind{Group: "route.openshift.io", Version: "v1", Kind: "Route"}
+	// Gateway is the API of the service APIs gateways
+	Gateway = schema.GroupVersionKind{Group: "networking.x-k8s.io", Version: "v1alpha1", Kind: "Gateway"}
+)
+
+// Capabilities probes the APIs served by a cluster. Each group version is only
+// probed once, the APIs are not expected to come and go while the manager runs.
+type Capabilities struct {
+	discovery discovery.DiscoveryInterface
+
+	mu    sync.Mutex
+	kinds map[schema.GroupVersion]map[string]bool
+}
+
+// New creates Capabilities probing the cluster of the given config.
+func New(config *rest.Config) (*Capabilities, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Capabilities{discovery: dc}, nil
+}
+
+// Has returns whether the cluster serves the given API.
+func (c *Capabilities) Has(gvk schema.GroupVersionKind) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	gv := gvk.GroupVersion()
+	kinds, found := c.kinds[gv]
+	if !found {
+		resources, err := c.discovery.ServerResourcesForGroupVersion(gv.String())
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		kinds = map[string]bool{}
+		if err == nil {
+			for _, r := range resources.APIResources {
+				kinds[r.Kind] = true
+			}
+		}
+		if c.kinds == nil {
+			c.kinds = map[schema.GroupVersion]map[string]bool{}
+		}
+		c.kinds[gv] = kinds
+	}
+	return kinds[gvk.Kind], nil
+}
+
+// Missing returns the given APIs the cluster does not serve.
+func (c *Capabilities) Missing(gvks ...schema.GroupVersionKind) ([]schema.GroupVersionKind, error) {
+	var missing []schema.GroupVersionKind
+	for _, gvk := range gvks {
+		found, err := c.Has(gvk)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			missing = append(missing, gvk)
+		}
+	}
+	return missing, nil
+}
+`
@@ -0,0 +1,450 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &CertRotator{}
+
+// CertRotator scaffolds the certrotator/certrotator.go file, which issues a
+// self-signed webhook serving certificate, rotates it before it expires and
+// injects its CA in the webhook configurations and the CRDs converted by the
+// manager, for the clusters without cert-manager.
+type CertRotator struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (c *CertRotator) GetInput() (input.Input, error) {
+	if c.Path == "" {
+		c.Path = filepath.Join("certrotator", "certrotator.go")
+	}
+	c.TemplateBody = certRotatorTemplate
+	c.Input.IfExistsAction = input.Skip
+	return c.Input, nil
+}
+
+var certRotatorTemplate = `{{ .Boilerplate }}
+
+// Package certrotator provisions the webhook serving certificate of the
+// manager without cert-manager. The certificate is issued by a self-signed CA,
+// stored in a Secret shared by the replicas of the manager, written to the
+// directory the webhook server reads it from, and renewed before it expires.
+// The CA is injected in the caBundle of the webhooks and the conversion
+// webhooks of the CRDs which call the webhook service.
+package certrotator
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	kscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;create;update
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations;validatingwebhookconfigurations,verbs=get;list;update
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;update
+
+const (
+	// DefaultCertDir is the directory the webhook server of the manager reads
+	// the serving certificate from
+	DefaultCertDir = "/tmp/k8s-webhook-server/serving-certs"
+
+	caKey   = "ca.crt"
+	certKey = "tls.crt"
+	keyKey  = "tls.key"
+)
+
+var (
+	webhookConfigurations = []schema.GroupVersionKind{
+		{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "MutatingWebhookConfigurationList"},
+		{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "ValidatingWebhookConfigurationList"},
+	}
+	crds = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinitionList"}
+)
+
+// Rotator issues the webhook serving certificate and renews it, as a
+// manager.Runnable, before it expires.
+type Rotator struct {
+	// Client reads and writes the Secret, the webhook configurations and the
+	// CRDs. It is not the client of the manager, the certificate being needed
+	// before the manager starts its cache.
+	Client client.Client
+	Log    logr.Logger
+
+	// Secret is the Secret holding the CA and the serving certificate
+	Secret types.NamespacedName
+	// Service is the webhook service, whose DNS names the certificate is
+	// issued for, and whose webhooks are given the CA
+	Service types.NamespacedName
+	// CertDir is the directory the serving certificate is written to
+	CertDir string
+
+	// Validity is how long the CA and the serving certificate are valid
+	Validity time.Duration
+	// RenewBefore is how long before its expiry the certificate is renewed
+	RenewBefore time.Duration
+	// Interval is how often the expiry of the certificate is checked
+	Interval time.Duration
+}
+
+// Setup ensures the serving certificate of the webhooks of mgr, from the
+// WEBHOOK_SERVICE, WEBHOOK_CERT_SECRET and POD_NAMESPACE environment variables
+// set by manager_webhook_selfsigned_patch.yaml, and adds its rotation to mgr.
+func Setup(mgr ctrl.Manager) error {
+	namespace := os.Getenv("POD_NAMESPACE")
+	service, secret := os.Getenv("WEBHOOK_SERVICE"), os.Getenv("WEBHOOK_CERT_SECRET")
+	if namespace == "" || service == "" || secret == "" {
+		return fmt.Errorf("POD_NAMESPACE, WEBHOOK_SERVICE and WEBHOOK_CERT_SECRET must be set " +
+			"to provision the webhook serving certificate")
+	}
+	c, err := client.New(mgr.GetConfig(), client.Options{Scheme: kscheme.Scheme})
+	if err != nil {
+		return err
+	}
+	certDir := mgr.GetWebhookServer().CertDir
+	if certDir == "" {
+		certDir = DefaultCertDir
+	}
+	r := &Rotator{
+		Client:  c,
+		Log:     ctrl.Log.WithName("certrotator"),
+		Secret:  types.NamespacedName{Namespace: namespace, Name: secret},
+		Service: types.NamespacedName{Namespace: namespace, Name: service},
+		CertDir: certDir,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := r.Ensure(ctx); err != nil {
+		return err
+	}
+	return mgr.Add(r)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, every replica
+// serves the webhooks with the certificate written to its own CertDir.
+func (r *Rotator) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable, renewing the certificate until stop is
+// closed.
+func (r *Rotator) Start(stop <-chan struct{}) error {
+	r.setDefaults()
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+		if err := r.Ensure(context.Background()); err != nil {
+			r.Log.Error(err, "unable to ensure the webhook serving certificate", "secret", r.Secret.String())
+		}
+	}
+}
+
+func (r *Rotator) setDefaults() {
+	if r.Validity <= 0 {
+		r.Validity = 365 * 24 * time.Hour
+	}
+	if r.RenewBefore <= 0 {
+		r.RenewBefore = 30 * 24 * time.Hour
+	}
+	if r.Interval <= 0 {
+		r.Interval = time.Hour
+	}
+}
+
+// Ensure issues the certificate unless the Secret holds one valid longer than
+// RenewBefore, injects its CA, then writes it to CertDir. The previous CA is
+// kept in the caBundle until the certificate it issued expires, so that the
+// replicas still serving it are trusted during a renewal.
+func (r *Rotator) Ensure(ctx context.Context) error {
+	r.setDefaults()
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, r.Secret, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if !exists || r.needsRenewal(secret.Data) {
+		data, err := r.issue(secret.Data)
+		if err != nil {
+			return fmt.Errorf("error issuing the webhook serving certificate: %v", err)
+		}
+		if exists {
+			secret.Data = data
+			err = r.Client.Update(ctx, secret)
+		} else {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: r.Secret.Namespace, Name: r.Secret.Name},
+				Type:       corev1.SecretTypeTLS,
+				Data:       data,
+			}
+			err = r.Client.Create(ctx, secret)
+		}
+		if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+			// another replica renewed the certificate first, use it
+			if err := r.Client.Get(ctx, r.Secret, secret); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+		r.Log.Info("issued the webhook serving certificate", "secret", r.Secret.String())
+	}
+
+	if err := r.injectCA(ctx, secret.Data[caKey]); err != nil {
+		return fmt.Errorf("error injecting the CA of the webhook serving certificate: %v", err)
+	}
+	return r.write(secret.Data)
+}
+
+// needsRenewal returns true if data holds no serving certificate for the
+// webhook service valid longer than RenewBefore.
+func (r *Rotator) needsRenewal(data map[string][]byte) bool {
+	cert, err := parseCert(data[certKey])
+	if err != nil {
+		return true
+	}
+	if time.Now().Add(r.RenewBefore).After(cert.NotAfter) {
+		return true
+	}
+	return cert.VerifyHostname(r.dnsNames()[0]) != nil
+}
+
+// dnsNames are the DNS names of the webhook service.
+func (r *Rotator) dnsNames() []string {
+	return []string{
+		fmt.Sprintf("%s.%s.svc", r.Service.Name, r.Service.Namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", r.Service.Name, r.Service.Namespace),
+	}
+}
+
+// issue returns the data of the Secret with a new CA and a serving certificate
+// issued by it, the CA of previous, if still valid, appended to the caBundle.
+func (r *Rotator) issue(previous map[string][]byte) (map[string][]byte, error) {
+	now := time.Now()
+	caKeyPair, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: r.Service.Name + "-ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(r.Validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKeyPair.PublicKey, caKeyPair)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPair, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serialNumber(),
+		Subject:      pkix.Name{CommonName: r.dnsNames()[0]},
+		DNSNames:     r.dnsNames(),
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(r.Validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca, &keyPair.PublicKey, caKeyPair)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(keyPair)
+	if err != nil {
+		return nil, err
+	}
+
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	if old, err := parseCert(previous[certKey]); err == nil && now.Before(old.NotAfter) {
+		// only the first CA of the previous bundle issued its certificate
+		if block, _ := pem.Decode(previous[caKey]); block != nil {
+			caBundle = append(caBundle, pem.EncodeToMemory(block)...)
+		}
+	}
+	return map[string][]byte{
+		caKey:   caBundle,
+		certKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		keyKey:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+// injectCA sets the caBundle of the webhooks and the conversion webhooks of
+// the CRDs calling the webhook service.
+func (r *Rotator) injectCA(ctx context.Context, caBundle []byte) error {
+	encoded := caBundleValue(caBundle)
+	for _, gvk := range webhookConfigurations {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk)
+		if err := r.Client.List(ctx, list); err != nil {
+			return err
+		}
+		for i := range list.Items {
+			config := &list.Items[i]
+			webhooks, _, err := unstructured.NestedSlice(config.Object, "webhooks")
+			if err != nil {
+				return err
+			}
+			changed := false
+			for j := range webhooks {
+				webhook, ok := webhooks[j].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if r.setCABundle(webhook, encoded, "clientConfig") {
+					changed = true
+				}
+			}
+			if !changed {
+				continue
+			}
+			if err := unstructured.SetNestedSlice(config.Object, webhooks, "webhooks"); err != nil {
+				return err
+			}
+			if err := r.Client.Update(ctx, config); err != nil {
+				return err
+			}
+		}
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(crds)
+	if err := r.Client.List(ctx, list); err != nil {
+		return err
+	}
+	for i := range list.Items {
+		crd := &list.Items[i]
+		conversion, found, err := unstructured.NestedMap(crd.Object, "spec", "conversion")
+		if err != nil || !found || conversion["strategy"] != "Webhook" {
+			continue
+		}
+		if !r.setCABundle(conversion, encoded, "webhookClientConfig") {
+			continue
+		}
+		if err := unstructured.SetNestedMap(crd.Object, conversion, "spec", "conversion"); err != nil {
+			return err
+		}
+		if err := r.Client.Update(ctx, crd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setCABundle sets the caBundle of the client config of webhook at field if
+// it calls the webhook service, returning true if it changed.
+func (r *Rotator) setCABundle(webhook map[string]interface{}, caBundle, field string) bool {
+	namespace, _, _ := unstructured.NestedString(webhook, field, "service", "namespace")
+	name, _, _ := unstructured.NestedString(webhook, field, "service", "name")
+	if namespace != r.Service.Namespace || name != r.Service.Name {
+		return false
+	}
+	if current, _, _ := unstructured.NestedString(webhook, field, "caBundle"); current == caBundle {
+		return false
+	}
+	return unstructured.SetNestedField(webhook, caBundle, field, "caBundle") == nil
+}
+
+// write writes the serving certificate to CertDir, unless it is already
+// written. The certificate is written before the key, both being reloaded by
+// the webhook server once the certificate changes.
+func (r *Rotator) write(data map[string][]byte) error {
+	if err := os.MkdirAll(r.CertDir, 0700); err != nil {
+		return err
+	}
+	for _, key := range []string{keyKey, certKey} {
+		path := filepath.Join(r.CertDir, key)
+		if current, err := ioutil.ReadFile(path); err == nil && bytes.Equal(current, data[key]) { // nolint: gosec
+			continue
+		}
+		// the file is replaced at once, the webhook server reading it at any time
+		tmp := path + ".tmp"
+		if err := ioutil.WriteFile(tmp, data[key], 0600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseCert(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func serialNumber() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return serial
+}
+
+// caBundleValue returns the caBundle as set in unstructured objects, base64
+// encoded as the JSON of a []byte.
+func caBundleValue(caBundle []byte) string {
+	return base64.StdEncoding.EncodeToString(caBundle)
+}
+`
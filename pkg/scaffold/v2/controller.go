@@ -44,6 +44,22 @@ type Controller struct {
 
 	// Is the Group + "." + Domain for the Resource
 	GroupDomain string
+
+	// Settings indicates whether the controller reconciles all its objects on
+	// the changes of the settings ConfigMap
+	Settings bool
+
+	// ReconcileTimeout indicates whether the reconciles of the controller are
+	// given a context canceled past the reconcile timeout of the manager
+	ReconcileTimeout bool
+
+	// ReadOnly indicates whether the controller only writes the status of the
+	// objects under the read-only mode of the manager
+	ReadOnly bool
+
+	// Heartbeat indicates whether the controller reports its last reconcile
+	// time to the heartbeat of the operator
+	Heartbeat bool
 }
 
 // GetInput implements input.File
@@ -57,7 +73,7 @@ func (a *Controller) GetInput() (input.Input, error) {
 	}
 
 	if a.Path == "" {
-		a.Path = filepath.Join("controllers",
+		a.Path = filepath.Join(controllersDir(a.Resource, a.Input),
 			strings.ToLower(a.Resource.Kind)+"_controller.go")
 	}
 	a.TemplateBody = controllerTemplate
@@ -65,27 +81,46 @@ func (a *Controller) GetInput() (input.Input, error) {
 	return a.Input, nil
 }
 
+// coreGroups are the domains of the groups of the k8s.io/api packages, the
+// core group being the empty API group.
+var coreGroups = map[string]string{
+	"apps":                  "",
+	"admissionregistration": "k8s.io",
+	"apiextensions":         "k8s.io",
+	"authentication":        "k8s.io",
+	"autoscaling":           "",
+	"batch":                 "",
+	"certificates":          "k8s.io",
+	"core":                  "",
+	"extensions":            "",
+	"metrics":               "k8s.io",
+	"policy":                "",
+	"rbac":                  "authorization.k8s.io",
+	"storage":               "k8s.io",
+}
+
+// IsCoreGroup returns true if the group is one of the k8s.io/api packages.
+func IsCoreGroup(group string) bool {
+	_, found := coreGroups[group]
+	return found
+}
+
 func getResourceInfo(r *resource.Resource, in input.Input) (resourcePackage, groupDomain string) {
+	if r.External && r.ExternalAPIPath != "" {
+		domain := r.ExternalDomain
+		if domain == "" {
+			domain = in.Domain
+		}
+		return r.ExternalAPIPath, r.Group + "." + domain
+	}
 	// Use the k8s.io/api package for core resources
-	coreGroups := map[string]string{
-		"apps":                  "",
-		"admissionregistration": "k8s.io",
-		"apiextensions":         "k8s.io",
-		"authentication":        "k8s.io",
-		"autoscaling":           "",
-		"batch":                 "",
-		"certificates":          "k8s.io",
-		"core":                  "",
-		"extensions":            "",
-		"metrics":               "k8s.io",
-		"policy":                "",
-		"rbac.authorization":    "k8s.io",
-		"storage":               "k8s.io",
-	}
-	resourcePath := filepath.Join("api", r.Version, fmt.Sprintf("%s_types.go", strings.ToLower(r.Kind)))
-	if _, err := os.Stat(resourcePath); os.IsNotExist(err) {
+	resourcePath := filepath.Join(apiDir(r, in), fmt.Sprintf("%s_types.go", strings.ToLower(r.Kind)))
+	if _, err := os.Stat(resourcePath); r.External || os.IsNotExist(err) {
 		if domain, found := coreGroups[r.Group]; found {
 			resourcePackage := path.Join("k8s.io", "api", r.Group)
+			if r.Group == "core" {
+				return resourcePackage, ""
+			}
 			groupDomain = r.Group
 			if domain != "" {
 				groupDomain = r.Group + "." + domain
@@ -94,44 +129,321 @@ func getResourceInfo(r *resource.Resource, in input.Input) (resourcePackage, gro
 		}
 		// TODO: need to support '--resource-pkg-path' flag for specifying resourcePath
 	}
-	return path.Join(in.Repo, "api"), r.Group + "." + in.Domain
+	if in.MultiGroup {
+		return path.Join(in.Repo, "api", r.Group), r.GroupDomain(in.Domain)
+	}
+	return path.Join(in.Repo, "api"), r.GroupDomain(in.Domain)
+}
+
+// apiDir returns the directory of the API version of the resource, which is
+// api/<group>/<version> in multigroup projects.
+func apiDir(r *resource.Resource, in input.Input) string {
+	if in.MultiGroup {
+		return filepath.Join("api", r.Group, r.Version)
+	}
+	return filepath.Join("api", r.Version)
+}
+
+// controllersDir returns the directory of the controllers of the resource,
+// which is controllers/<group> in multigroup projects.
+func controllersDir(r *resource.Resource, in input.Input) string {
+	if in.MultiGroup {
+		return filepath.Join("controllers", r.Group)
+	}
+	return "controllers"
+}
+
+// controllersImport returns the import of the package of the controllers of
+// the resource, aliased by group in multigroup projects, as well as the
+// identifier its controllers are referred to with.
+func controllersImport(r *resource.Resource, in input.Input) (importSpec, pkgName string) {
+	if in.MultiGroup {
+		pkgName = r.Group + "controllers"
+		return fmt.Sprintf(`%s "%s/controllers/%s"`, pkgName, in.Repo, r.Group), pkgName
+	}
+	return fmt.Sprintf(`"%s/controllers"`, in.Repo), "controllers"
 }
 
 var controllerTemplate = `{{ .Boilerplate }}
 
-package controllers
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
 
 import (
+{{- if not .ReconcileTimeout }}
 	"context"
+{{- else }}
+	"time"
+{{- end }}
 
+{{- if .Resource.CreationGuard }}
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+{{- end }}
+{{- if .Resource.Scale }}
+	"k8s.io/apimachinery/pkg/labels"
+{{- end }}
+{{- if .Resource.TransitionEvents }}
+	"k8s.io/client-go/tools/record"
+{{- end }}
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"github.com/go-logr/logr"
 
 	{{ .Resource.Group}}{{ .Resource.Version }} "{{ .ResourcePackage }}/{{ .Resource.Version }}"
+{{- if and (or .Settings .ReconcileTimeout .ReadOnly .Heartbeat) .MultiGroup }}
+	"{{ .Repo }}/controllers"
+{{- end }}
 )
+{{- if .Resource.Finalizer }}
+
+// {{ .Resource.Kind }}Finalizer is the finalizer of the {{ .Resource.Kind }}s, removed by the
+// reconciler once it has cleaned up the external resources of a deleted {{ .Resource.Kind }}.
+const {{ .Resource.Kind }}Finalizer = "{{ .Resource.Group }}.{{ .Domain }}/{{ .Resource.Kind | lower }}-finalizer"
+{{- end }}
 
 // {{ .Resource.Kind }}Reconciler reconciles a {{ .Resource.Kind }} object
 type {{ .Resource.Kind }}Reconciler struct {
 	client.Client
 	Log logr.Logger
+{{- if .Resource.TransitionEvents }}
+
+	// Recorder records the events of the transitions of the {{ .Resource.Kind }}s
+	Recorder record.EventRecorder
+{{- end }}
+{{- if .Resource.CreationGuard }}
+
+	// CreationGuard caps the objects created by a reconcile of a {{ .Resource.Kind }}
+	CreationGuard *CreationGuard
+{{- end }}
+{{- if .Resource.Expectations }}
+
+	// Expectations tracks the creations and deletions of the children of each
+	// {{ .Resource.Kind }} which the informers have not observed yet
+	Expectations *Expectations
+{{- end }}
+{{- if .Settings }}
+
+	// Settings are the operator settings, whose changes reconcile all the {{ .Resource.Kind }}s
+	Settings *{{ if .MultiGroup }}controllers.{{ end }}Settings
+{{- end }}
+{{- if .ReconcileTimeout }}
+
+	// ReconcileTimeout cancels the context of the reconciles running longer,
+	// zero never cancels it
+	ReconcileTimeout time.Duration
+{{- end }}
+{{- if .ReadOnly }}
+
+	// ReadOnly skips the creations, updates and deletions of the objects, the
+	// reconciles only writing their status
+	ReadOnly bool
+{{- end }}
+{{- if .Heartbeat }}
+
+	// Heartbeat reports the last reconcile time of the controller
+	Heartbeat *{{ if .MultiGroup }}controllers.{{ end }}Heartbeat
+{{- end }}
 }
 
-// +kubebuilder:rbac:groups={{.GroupDomain}},resources={{ .Plural }},verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups={{.GroupDomain}},resources={{ .Plural }}/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups={{ if .GroupDomain }}{{ .GroupDomain }}{{ else }}""{{ end }},resources={{ .Plural }},verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups={{ if .GroupDomain }}{{ .GroupDomain }}{{ else }}""{{ end }},resources={{ .Plural }}/status,verbs=get;update;patch
 
 func (r *{{ .Resource.Kind }}Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+{{- if .Heartbeat }}
+	if r.Heartbeat != nil {
+		defer r.Heartbeat.Reconciled("{{ .Resource.Kind }}")
+	}
+{{ end }}
+{{- if .ReconcileTimeout }}
+	// the client calls made with ctx fail once the reconcile runs past the
+	// timeout, releasing the worker of a stuck reconcile
+	ctx, done := {{ if .MultiGroup }}controllers.{{ end }}ReconcileContext("{{ if .MultiGroup }}{{ .Resource.Group }}-{{ end }}{{ .Resource.Kind | lower }}", r.ReconcileTimeout)
+	defer done()
+{{ end }}
+{{- if .Resource.Expectations }}
+	// the children created or deleted by the previous reconciles are not all
+	// in the cache yet, the event of the last one observed reconciles again
+	if !r.Expectations.Satisfied(req.NamespacedName) {
+		return ctrl.Result{}, nil
+	}
+	// Call r.Expectations.ExpectCreations(req.NamespacedName, n) before creating
+	// n children, and r.Expectations.CreationObserved(req.NamespacedName) for each
+	// creation failing.
+{{ end }}
+{{- if .Resource.SpecHash }}
+	// Write the children with CreateOrUpdateChild(ctx, r, "{{ if .MultiGroup }}{{ .Resource.Group }}-{{ end }}{{ .Resource.Kind | lower }}", child,
+	// desired, mutate), which skips the update of a child whose desired state is
+	// unchanged since it was last written.
+{{ end }}
+{{- if .Resource.CreationGuard }}
+{{- if not .ReconcileTimeout }}
+	ctx := context.Background()
+{{- end }}
+	_ = r.Log.WithValues("{{ .Resource.Kind | lower }}", req.NamespacedName)
+
+	var {{ .Resource.Kind | lower }} {{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}
+	if err := r.Get(ctx, req.NamespacedName, &{{ .Resource.Kind | lower }}); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.CreationGuard.Forget(req.NamespacedName)
+{{- if .Resource.Expectations }}
+			r.Expectations.Forget(req.NamespacedName)
+{{- end }}
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+{{- if .Resource.Finalizer }}
+{{ template "finalizer" . }}
+{{- end }}
+{{- if .Resource.TransitionEvents }}
+{{ template "original" . }}
+{{- end }}
+{{- if .Resource.Scale }}
+{{ template "scale" . }}
+{{- end }}
+
+	// Create the objects of the {{ .Resource.Kind }} with creator rather than r: the
+	// creations of a reconcile over r.CreationGuard.MaxCreations are refused with
+	// ErrCreationLimited, and the {{ .Resource.Kind }} is marked Degraded.
+	creator := r.CreationGuard.For(req.NamespacedName, r.Client)
+
+	// your logic here, setting the Ready condition printed by kubectl get
+
+	result := creator.Done()
+	status, reason, message := creator.DegradedCondition()
+	degraded := {{ .Resource.Group}}{{ .Resource.Version }}.Condition{
+		Type:    {{ .Resource.Group}}{{ .Resource.Version }}.ConditionDegraded,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+	if {{ .Resource.Group}}{{ .Resource.Version }}.SetCondition(&{{ .Resource.Kind | lower }}.Status.Conditions, degraded) {
+		if err := r.Status().Update(ctx, &{{ .Resource.Kind | lower }}); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+{{- if .Resource.TransitionEvents }}
+	record{{ .Resource.Kind }}Transitions(r.Recorder, original, &{{ .Resource.Kind | lower }})
+{{- end }}
+
+	return result, nil
+{{- else if or .Resource.Finalizer .Resource.TransitionEvents .Resource.Scale }}
+{{- if not .ReconcileTimeout }}
+	ctx := context.Background()
+{{- end }}
+	_ = r.Log.WithValues("{{ .Resource.Kind | lower }}", req.NamespacedName)
+
+	var {{ .Resource.Kind | lower }} {{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}
+	if err := r.Get(ctx, req.NamespacedName, &{{ .Resource.Kind | lower }}); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+{{- if .Resource.Finalizer }}
+{{ template "finalizer" . }}
+{{- end }}
+{{- if .Resource.TransitionEvents }}
+{{ template "original" . }}
+{{- end }}
+{{- if .Resource.Scale }}
+{{ template "scale" . }}
+{{- end }}
+{{- if .Resource.TransitionEvents }}
+
+	// your logic here, writing the status of the {{ .Resource.Kind }}
+
+	record{{ .Resource.Kind }}Transitions(r.Recorder, original, &{{ .Resource.Kind | lower }})
+{{- else }}
+
+	// your logic here
+{{- end }}
+
+	return ctrl.Result{}, nil
+{{- else }}
+{{- if .ReconcileTimeout }}
+	_ = ctx
+{{- else }}
 	_ = context.Background()
+{{- end }}
 	_ = r.Log.WithValues("{{ .Resource.Kind | lower }}", req.NamespacedName)
 
 	// your logic here
 
 	return ctrl.Result{}, nil
+{{- end }}
+}
+{{- if .Resource.Scale }}
+
+// {{ .Resource.Kind | lower }}PodLabels returns the labels of the pods of the {{ .Resource.Kind }},
+// which the selector of its scale subresource selects.
+func {{ .Resource.Kind | lower }}PodLabels({{ .Resource.Kind | lower }} *{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":     "{{ .Resource.Kind | lower }}",
+		"app.kubernetes.io/instance": {{ .Resource.Kind | lower }}.Name,
+	}
 }
+{{- end }}
 
 func (r *{{ .Resource.Kind }}Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+{{- if .ReadOnly }}
+	if r.ReadOnly {
+		r.Client = {{ if .MultiGroup }}controllers.{{ end }}ReadOnlyClient(r.Client, r.Log.WithName("read-only"))
+	}
+{{- end }}
+{{- if .Settings }}
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}{})
+	if r.Settings != nil {
+		// reconcile all the {{ .Resource.Kind }}s with the new settings
+		b = b.Watches(r.Settings.Source(),
+			r.Settings.EnqueueAll(mgr.GetClient(), &{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}List{}))
+	}
+	return b.Complete(r)
+{{- else }}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}{}).
 		Complete(r)
+{{- end }}
 }
+{{ define "original" }}
+	// the {{ .Resource.Kind }} as read, the transitions of its status are recorded as
+	// events once the status is written
+	original := {{ .Resource.Kind | lower }}.DeepCopy()
+{{- end }}
+{{ define "scale" }}
+	// kubectl scale and the HorizontalPodAutoscaler find the pods of the
+	// {{ .Resource.Kind }} with the selector of its status: keep it up to date
+	// with the labels of the pods
+	selector := labels.SelectorFromSet({{ .Resource.Kind | lower }}PodLabels(&{{ .Resource.Kind | lower }})).String()
+	if {{ .Resource.Kind | lower }}.Status.Selector != selector {
+		{{ .Resource.Kind | lower }}.Status.Selector = selector
+		if err := r.Status().Update(ctx, &{{ .Resource.Kind | lower }}); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Run Spec.Replicas pods labelled with {{ .Resource.Kind | lower }}PodLabels, and set
+	// Status.Replicas to the number of them.
+{{- end }}
+{{ define "finalizer" }}
+	if !{{ .Resource.Kind | lower }}.DeletionTimestamp.IsZero() {
+		// the {{ .Resource.Kind }} is being deleted, it is only gone once the
+		// finalizer is removed
+		if HasFinalizer(&{{ .Resource.Kind | lower }}, {{ .Resource.Kind }}Finalizer) {
+			// clean up the external resources of the {{ .Resource.Kind }} here,
+			// returning the error of a failing cleanup to retry it
+
+			RemoveFinalizer(&{{ .Resource.Kind | lower }}, {{ .Resource.Kind }}Finalizer)
+			if err := r.Update(ctx, &{{ .Resource.Kind | lower }}); err != nil {
+				return ctrl.Result{}, client.IgnoreNotFound(err)
+			}
+		}
+		// nothing else is done for a deleted {{ .Resource.Kind }}
+		return ctrl.Result{}, nil
+	}
+
+	// the finalizer is added before any external resource is created, so that
+	// none is left behind by a {{ .Resource.Kind }} deleted in the meantime
+	if AddFinalizer(&{{ .Resource.Kind | lower }}, {{ .Resource.Kind }}Finalizer) {
+		if err := r.Update(ctx, &{{ .Resource.Kind | lower }}); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+{{- end }}
 `
@@ -0,0 +1,102 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &ControllerClient{}
+
+// ControllerClient scaffolds the controllers/client.go file, creating the
+// clients each controller talks to the API server with under a user agent of
+// its own.
+type ControllerClient struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (c *ControllerClient) GetInput() (input.Input, error) {
+	if c.Path == "" {
+		c.Path = filepath.Join("controllers", "client.go")
+	}
+	c.TemplateBody = controllerClientTemplate
+	c.Input.IfExistsAction = input.Error
+	return c.Input, nil
+}
+
+var controllerClientTemplate = `{{ .Boilerplate }}
+
+package controllers
+
+import (
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ShareRateLimiter sets a rate limiter of the QPS and burst of the given
+// config on it, so the clients of all the controllers, copied from the config
+// of the manager, share the --kube-api-qps and --kube-api-burst budget.
+func ShareRateLimiter(cfg *rest.Config) {
+	if cfg.RateLimiter != nil {
+		return
+	}
+	qps, burst := cfg.QPS, cfg.Burst
+	if qps == 0 {
+		qps = rest.DefaultQPS
+	}
+	if burst == 0 {
+		burst = rest.DefaultBurst
+	}
+	cfg.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+}
+
+// NewClient returns the client of the given controller. It reads objects from
+// the cache of the manager, and sends its requests with the user agent
+// <manager user agent>/<controller>, so cluster admins can attribute the
+// traffic of each controller in the API server audit log and metrics.
+//
+// API Priority and Fairness classifies the requests by the user sending them,
+// the service account of the manager. To throttle a controller apart from the
+// others, run it in a manager of its own with a service account a FlowSchema
+// matches.
+func NewClient(mgr ctrl.Manager, controller string) (client.Client, error) {
+	cfg := rest.CopyConfig(mgr.GetConfig())
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = rest.DefaultKubernetesUserAgent()
+	}
+	cfg.UserAgent = userAgent + "/" + controller
+
+	c, err := client.New(cfg, client.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+	if err != nil {
+		return nil, err
+	}
+	return &client.DelegatingClient{
+		Reader: &client.DelegatingReader{
+			CacheReader:  mgr.GetCache(),
+			ClientReader: c,
+		},
+		Writer:       c,
+		StatusClient: c,
+	}, nil
+}
+`
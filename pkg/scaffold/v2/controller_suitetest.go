@@ -18,15 +18,27 @@ package v2
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"strings"
 
 	"github.com/markbates/inflect"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/internal"
 )
 
+const (
+	envtestOptionsScaffoldMarker  = "// +kubebuilder:scaffold:envtestoptions"
+	envtestSetupScaffoldMarker    = "// +kubebuilder:scaffold:envtestsetup"
+	envtestTeardownScaffoldMarker = "// +kubebuilder:scaffold:envtestteardown"
+)
+
+// registryAddToScheme adds the group versions of the registry of api/scheme.go
+// to the scheme of the controllers suite.
+const registryAddToScheme = "err = api.AddToScheme(scheme.Scheme)"
+
 var _ input.File = &ControllerSuiteTest{}
 
 // ControllerSuiteTest scaffolds the suite_test.go file to setup the controller test
@@ -44,12 +56,15 @@ type ControllerSuiteTest struct {
 
 	// Is the Group + "." + Domain for the Resource
 	GroupDomain string
+
+	// SchemeRegistration is the project's scheme registration strategy
+	SchemeRegistration string
 }
 
 // GetInput implements input.File
 func (v *ControllerSuiteTest) GetInput() (input.Input, error) {
 	if v.Path == "" {
-		v.Path = filepath.Join("controllers", "suite_test.go")
+		v.Path = filepath.Join(controllersDir(v.Resource, v.Input), "suite_test.go")
 	}
 	v.TemplateBody = controllerSuiteTestTemplate
 	return v.Input, nil
@@ -62,7 +77,7 @@ func (v *ControllerSuiteTest) Validate() error {
 
 var controllerSuiteTestTemplate = `{{ .Boilerplate }}
 
-package controllers
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
 
 import (
 	"path/filepath"
@@ -101,10 +116,12 @@ var _ = BeforeSuite(func(done Done) {
 
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths: []string{filepath.Join("..", "config", "crd", "bases")},
+		CRDDirectoryPaths: []string{filepath.Join("..", {{ if .MultiGroup }}"..", {{ end }}"config", "crd", "bases")},
+		// +kubebuilder:scaffold:envtestoptions
 	}
 	
-	cfg, err := testEnv.Start()
+	var err error
+	cfg, err = testEnv.Start()
 	Expect(err).ToNot(HaveOccurred())
 	Expect(cfg).ToNot(BeNil())
 
@@ -115,11 +132,14 @@ var _ = BeforeSuite(func(done Done) {
 	Expect(err).ToNot(HaveOccurred())
 	Expect(k8sClient).ToNot(BeNil())
 
+	// +kubebuilder:scaffold:envtestsetup
+
 	close(done)
 }, 60)
 
 var _ = AfterSuite(func() {
 	By("tearing down the test environment")
+	// +kubebuilder:scaffold:envtestteardown
 	err := testEnv.Stop()
 	Expect(err).ToNot(HaveOccurred())
 })
@@ -135,20 +155,37 @@ func (a *ControllerSuiteTest) Update() error {
 		a.Plural = rs.Pluralize(strings.ToLower(a.Resource.Kind))
 	}
 
-	ctrlImportCodeFragment := fmt.Sprintf(`"%s/controllers"
-`, a.Repo)
+	ctrlImport, _ := controllersImport(a.Resource, a.Input)
+	ctrlImportCodeFragment := ctrlImport + "\n"
 	apiImportCodeFragment := fmt.Sprintf(`%s%s "%s/%s"
 `, a.Resource.Group, a.Resource.Version, a.ResourcePackage, a.Resource.Version)
 
-	addschemeCodeFragment := fmt.Sprintf(`err = %s%s.AddToScheme(scheme.Scheme)
+	addschemeCodeFragments := []string{fmt.Sprintf(`err = %s%s.AddToScheme(scheme.Scheme)
 Expect(err).NotTo(HaveOccurred())
 
-`, a.Resource.Group, a.Resource.Version)
+`, a.Resource.Group, a.Resource.Version)}
+	if a.SchemeRegistration == project.SchemeRegistrationRegistry {
+		apiImportCodeFragment = fmt.Sprintf(`"%s/api"
+`, a.Repo)
+		// the registry adds all the group versions at once, so it is only
+		// added to the scheme by the first API
+		content, err := ioutil.ReadFile(a.Path) // nolint: gosec
+		if err != nil {
+			return err
+		}
+		addschemeCodeFragments = nil
+		if !strings.Contains(string(content), registryAddToScheme) {
+			addschemeCodeFragments = []string{registryAddToScheme + `
+Expect(err).NotTo(HaveOccurred())
+
+`}
+		}
+	}
 
 	err := internal.InsertStringsInFile(a.Path,
 		map[string][]string{
 			apiPkgImportScaffoldMarker: []string{ctrlImportCodeFragment, apiImportCodeFragment},
-			apiSchemeScaffoldMarker:    []string{addschemeCodeFragment},
+			apiSchemeScaffoldMarker:    addschemeCodeFragments,
 		})
 	if err != nil {
 		return err
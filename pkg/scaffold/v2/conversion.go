@@ -0,0 +1,142 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &ConversionHub{}
+
+// ConversionHub scaffolds the file marking a version of a Resource as the hub
+// the other versions are converted through
+type ConversionHub struct {
+	input.Input
+
+	// Resource is the version of the Resource to mark as the hub
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (h *ConversionHub) GetInput() (input.Input, error) {
+	if h.Path == "" {
+		h.Path = filepath.Join(apiDir(h.Resource, h.Input),
+			fmt.Sprintf("%s_conversion.go", strings.ToLower(h.Resource.Kind)))
+	}
+	h.TemplateBody = conversionHubTemplate
+	h.Input.IfExistsAction = input.Skip
+	return h.Input, nil
+}
+
+// Validate validates the values
+func (h *ConversionHub) Validate() error {
+	return h.Resource.Validate()
+}
+
+var conversionHubTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// Hub marks {{ .Resource.Version }} as the version the other versions of {{ .Resource.Kind }}
+// are converted through. It should be the storage version of the CRD.
+func (*{{ .Resource.Kind }}) Hub() {}
+`
+
+var _ input.File = &ConversionSpoke{}
+
+// ConversionSpoke scaffolds the stubs of the conversion functions of a
+// version of a Resource from and to its hub version
+type ConversionSpoke struct {
+	input.Input
+
+	// Resource is the version of the Resource to convert
+	Resource *resource.Resource
+
+	// Hub is the hub version of the Resource
+	Hub *resource.Resource
+
+	// HubImport is the import of the API package of the hub version
+	HubImport string
+
+	// HubPackage is the alias of the API package of the hub version
+	HubPackage string
+}
+
+// GetInput implements input.File
+func (s *ConversionSpoke) GetInput() (input.Input, error) {
+	if s.Path == "" {
+		s.Path = filepath.Join(apiDir(s.Resource, s.Input),
+			fmt.Sprintf("%s_conversion.go", strings.ToLower(s.Resource.Kind)))
+	}
+	s.HubPackage = s.Hub.Group + s.Hub.Version
+	s.HubImport = path.Join(s.Repo, filepath.ToSlash(apiDir(s.Hub, s.Input)))
+	s.TemplateBody = conversionSpokeTemplate
+	s.Input.IfExistsAction = input.Skip
+	return s.Input, nil
+}
+
+// Validate validates the values
+func (s *ConversionSpoke) Validate() error {
+	if err := s.Resource.Validate(); err != nil {
+		return err
+	}
+	return s.Hub.Validate()
+}
+
+var conversionSpokeTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	{{ .HubPackage }} "{{ .HubImport }}"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// ConvertTo converts this {{ .Resource.Kind }} to the hub version ({{ .Hub.Version }}).
+func (src *{{ .Resource.Kind }}) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*{{ .HubPackage }}.{{ .Hub.Kind }})
+	dst.ObjectMeta = src.ObjectMeta
+
+	// TODO(user): convert the spec and status of src to the ones of dst,
+	// keeping the fields dst has no room for in annotations so that the
+	// round trip through the hub does not lose them.
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version ({{ .Hub.Version }}) to this version.
+func (dst *{{ .Resource.Kind }}) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*{{ .HubPackage }}.{{ .Hub.Kind }})
+	dst.ObjectMeta = src.ObjectMeta
+
+	// TODO(user): convert the spec and status of src to the ones of dst,
+	// restoring the fields kept in annotations by ConvertTo.
+
+	return nil
+}
+`
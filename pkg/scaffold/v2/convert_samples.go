@@ -0,0 +1,273 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &ConvertSamples{}
+
+// ConvertSamples scaffolds the hack/convert-samples/main.go program, which
+// writes the samples of every version of the kinds with several versions by
+// converting the sample of the conversion hub through the conversion
+// functions of the API types.
+type ConvertSamples struct {
+	input.Input
+
+	// Resources are the resources of the project
+	Resources []*resource.Resource
+
+	// Kinds are the kinds of the project with several versions
+	Kinds []ConvertSamplesKind
+
+	// Imports are the API packages of the versions of Kinds
+	Imports []ConvertSamplesImport
+}
+
+// ConvertSamplesKind is a kind whose samples are converted between its versions.
+type ConvertSamplesKind struct {
+	// GroupDomain is the API group of the kind, e.g. crew.example.com
+	GroupDomain string
+	// Group is the group of the kind prefixing the names of its samples
+	Group    string
+	Kind     string
+	Versions []string
+}
+
+// ConvertSamplesImport is an API package registered in the scheme of the
+// converter.
+type ConvertSamplesImport struct {
+	Alias string
+	Path  string
+}
+
+// GetInput implements input.File
+func (c *ConvertSamples) GetInput() (input.Input, error) {
+	if c.Path == "" {
+		c.Path = filepath.Join("hack", "convert-samples", "main.go")
+	}
+	c.Kinds, c.Imports = c.versionedKinds()
+	c.TemplateBody = convertSamplesTemplate
+	// the converter is generated from the resources of the PROJECT file
+	c.Input.IfExistsAction = input.Overwrite
+	return c.Input, nil
+}
+
+// versionedKinds returns the kinds of the project API packages with several
+// versions, in the order of Resources, and the packages of their versions.
+func (c *ConvertSamples) versionedKinds() ([]ConvertSamplesKind, []ConvertSamplesImport) {
+	var kinds []ConvertSamplesKind
+	index := map[string]int{}
+	for _, r := range c.Resources {
+		// the kinds of other projects, e.g. the core types, have no conversion
+		// functions of ours
+		typesFile := filepath.Join(apiDir(r, c.Input), fmt.Sprintf("%s_types.go", strings.ToLower(r.Kind)))
+		if _, err := os.Stat(typesFile); err != nil {
+			continue
+		}
+		key := r.Group + "/" + r.Kind
+		i, found := index[key]
+		if !found {
+			_, groupDomain := getResourceInfo(r, c.Input)
+			i = len(kinds)
+			index[key] = i
+			kinds = append(kinds, ConvertSamplesKind{GroupDomain: groupDomain, Group: r.Group, Kind: r.Kind})
+		}
+		kinds[i].Versions = append(kinds[i].Versions, r.Version)
+	}
+
+	var versioned []ConvertSamplesKind
+	var imports []ConvertSamplesImport
+	imported := map[string]bool{}
+	for _, k := range kinds {
+		if len(k.Versions) < 2 {
+			continue
+		}
+		versioned = append(versioned, k)
+		for _, v := range k.Versions {
+			r := &resource.Resource{Group: k.Group, Version: v, Kind: k.Kind}
+			resPkg, _ := getResourceInfo(r, c.Input)
+			alias := k.Group + v
+			if !imported[alias] {
+				imported[alias] = true
+				imports = append(imports, ConvertSamplesImport{Alias: alias, Path: resPkg + "/" + v})
+			}
+		}
+	}
+	return versioned, imports
+}
+
+var convertSamplesTemplate = `{{ .Boilerplate }}
+
+// Command convert-samples writes the samples of config/samples of every version
+// of the kinds with several versions, converting the sample of the version
+// marked as the conversion.Hub through the conversion functions of the other
+// versions. The other samples are overwritten.
+//
+// It is generated from the PROJECT file by kubebuilder alpha convert-samples,
+// run by make convert-samples.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/yaml"
+{{ range .Imports }}
+	{{ .Alias }} "{{ .Path }}"
+{{- end }}
+)
+
+// versionedKind is a kind with several versions.
+type versionedKind struct {
+	// group is the API group of the kind
+	group string
+	// prefix is the prefix of the names of the samples of the kind
+	prefix   string
+	kind     string
+	versions []string
+}
+
+var kinds = []versionedKind{
+{{- range .Kinds }}
+	{
+		group:    "{{ .GroupDomain }}",
+		prefix:   "{{ .Group }}",
+		kind:     "{{ .Kind }}",
+		versions: []string{ {{- range $i, $v := .Versions }}{{ if $i }}, {{ end }}"{{ $v }}"{{ end -}} },
+	},
+{{- end }}
+}
+
+var scheme = runtime.NewScheme()
+
+func init() {
+{{- range .Imports }}
+	if err := {{ .Alias }}.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+{{- end }}
+}
+
+func main() {
+	failed := false
+	for _, k := range kinds {
+		if err := convertSamples(k); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to convert the samples of %s: %v\n", k.kind, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// samplePath returns the path of the sample of the given version of the kind.
+func samplePath(k versionedKind, version string) string {
+	return filepath.Join("config", "samples", fmt.Sprintf("%s_%s_%s.yaml", k.prefix, version, strings.ToLower(k.kind)))
+}
+
+// convertSamples overwrites the samples of the versions of the kind with the
+// sample of its hub version converted to them.
+func convertSamples(k versionedKind) error {
+	hubVersion := ""
+	for _, v := range k.versions {
+		obj, err := scheme.New(schema.GroupVersionKind{Group: k.group, Version: v, Kind: k.kind})
+		if err != nil {
+			return err
+		}
+		if _, ok := obj.(conversion.Hub); ok {
+			hubVersion = v
+			break
+		}
+	}
+	if hubVersion == "" {
+		fmt.Printf("skipping %s: none of its versions is marked as the conversion.Hub\n", k.kind)
+		return nil
+	}
+
+	source := samplePath(k, hubVersion)
+	data, err := ioutil.ReadFile(source) // nolint: gosec
+	if err != nil {
+		return err
+	}
+	obj, _, err := serializer.NewCodecFactory(scheme).UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return fmt.Errorf("unable to decode %s: %v", source, err)
+	}
+	hub, ok := obj.(conversion.Hub)
+	if !ok {
+		return fmt.Errorf("%s is not a sample of the hub version %s", source, hubVersion)
+	}
+
+	for _, v := range k.versions {
+		if v == hubVersion {
+			continue
+		}
+		gvk := schema.GroupVersionKind{Group: k.group, Version: v, Kind: k.kind}
+		obj, err := scheme.New(gvk)
+		if err != nil {
+			return err
+		}
+		spoke, ok := obj.(conversion.Convertible)
+		if !ok {
+			return fmt.Errorf("%s is neither the conversion.Hub nor a conversion.Convertible", gvk)
+		}
+		if err := spoke.ConvertFrom(hub); err != nil {
+			return fmt.Errorf("unable to convert %s to %s: %v", source, v, err)
+		}
+		spoke.GetObjectKind().SetGroupVersionKind(gvk)
+		if err := writeSample(samplePath(k, v), spoke); err != nil {
+			return err
+		}
+		fmt.Printf("converted %s to %s\n", source, samplePath(k, v))
+	}
+	return nil
+}
+
+// writeSample writes the object to the sample at path, without the fields
+// the conversion fills in with zero values.
+func writeSample(path string, obj runtime.Object) error {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	delete(u, "status")
+	if metadata, ok := u["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+	data, err := yaml.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+`
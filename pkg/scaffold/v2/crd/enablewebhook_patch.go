@@ -33,6 +33,18 @@ type EnableWebhookPatch struct {
 
 	// Resource is the Resource to make the EnableWebhookPatch for
 	Resource *resource.Resource
+
+	// WebhookPath is the path the conversion webhook is served at, /convert
+	// by default
+	WebhookPath string
+
+	// WebhookPort is the port of the webhook service receiving the conversion
+	// reviews, left to the apiserver default of 443 when 0
+	WebhookPort int
+
+	// ConversionReviewVersions are the ConversionReview versions accepted by
+	// the conversion webhook, left to the apiserver default of v1beta1 when empty
+	ConversionReviewVersions []string
 }
 
 // GetInput implements input.File
@@ -43,6 +55,9 @@ func (p *EnableWebhookPatch) GetInput() (input.Input, error) {
 		p.Path = filepath.Join("config", "crd", "patches",
 			fmt.Sprintf("webhook_in_%s.yaml", plural))
 	}
+	if p.WebhookPath == "" {
+		p.WebhookPath = "/convert"
+	}
 	p.TemplateBody = enableWebhookPatchTemplate
 	return p.Input, nil
 }
@@ -59,6 +74,8 @@ kind: CustomResourceDefinition
 metadata:
   name: {{ .Resource.Resource }}.{{ .Resource.Group }}.{{ .Domain }}
 spec:
+  # the apiserver only converts the CRDs pruning their unknown fields
+  preserveUnknownFields: false
   conversion:
     strategy: Webhook
     webhookClientConfig:
@@ -68,5 +85,15 @@ spec:
       service:
         namespace: system
         name: webhook-service
-        path: /convert
+        path: {{ .WebhookPath }}
+{{- if .WebhookPort }}
+        # the port of the service requires k8s 1.15 or later
+        port: {{ .WebhookPort }}
+{{- end }}
+{{- if .ConversionReviewVersions }}
+    conversionReviewVersions:
+{{- range .ConversionReviewVersions }}
+    - {{ . }}
+{{- end }}
+{{- end }}
 `
@@ -24,8 +24,11 @@ import (
 	"github.com/markbates/inflect"
 
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/internal"
+	"sigs.k8s.io/kubebuilder/pkg/textfile"
 )
 
 const (
@@ -64,7 +67,7 @@ func (c *Kustomization) Update() error {
 	rs := inflect.NewDefaultRuleset()
 	plural := rs.Pluralize(strings.ToLower(c.Resource.Kind))
 
-	kustomizeResourceCodeFragment := fmt.Sprintf("- bases/%s.%s_%s.yaml\n", c.Resource.Group, c.Domain, plural)
+	kustomizeResourceCodeFragment := fmt.Sprintf("- bases/%s_%s.yaml\n", c.Resource.GroupDomain(c.Domain), plural)
 	kustomizeWebhookPatchCodeFragment := fmt.Sprintf("#- patches/webhook_in_%s.yaml\n", plural)
 	kustomizeCAInjectionPatchCodeFragment := fmt.Sprintf("#- patches/cainjection_in_%s.yaml\n", plural)
 
@@ -76,6 +79,45 @@ func (c *Kustomization) Update() error {
 		})
 }
 
+// EnableConversion uncomments the conversion webhook patch of the CRD of the
+// Resource, and its CA injection patch if caInjection is set, in the
+// kustomization file.
+func (c *Kustomization) EnableConversion(caInjection bool) error {
+	if c.Path == "" {
+		c.Path = filepath.Join("config", "crd", "kustomization.yaml")
+	}
+	plural := inflect.NewDefaultRuleset().Pluralize(strings.ToLower(c.Resource.Kind))
+	patches := []string{fmt.Sprintf("patches/webhook_in_%s.yaml", plural)}
+	if caInjection {
+		patches = append(patches, fmt.Sprintf("patches/cainjection_in_%s.yaml", plural))
+	}
+
+	content, format, err := textfile.ReadFile(c.Path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		for _, patch := range patches {
+			if strings.TrimSpace(line) == "#- "+patch {
+				lines[i] = strings.Replace(line, "#- ", "- ", 1)
+			}
+		}
+	}
+	updated := strings.Join(lines, "\n")
+	if updated == string(content) {
+		return nil
+	}
+	if err := rollback.Save(c.Path); err != nil {
+		return err
+	}
+	if err := textfile.WriteFile(c.Path, []byte(updated), format, 0644); err != nil {
+		return err
+	}
+	result.FileModified(c.Path)
+	return nil
+}
+
 var kustomizationTemplate = fmt.Sprintf(`# This kustomization.yaml is not intended to be run by itself,
 # since it depends on service name and namespace that are out of this kustomize package.
 # It should be run by config/default
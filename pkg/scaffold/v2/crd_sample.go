@@ -21,8 +21,10 @@ import (
 	"path/filepath"
 	"strings"
 
+	yaml "gopkg.in/yaml.v2"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/internal"
 )
 
 var _ input.File = &CRDSample{}
@@ -33,6 +35,17 @@ type CRDSample struct {
 
 	// Resource is a resource in the API group
 	Resource *resource.Resource
+
+	// FromExamples indicates whether to build Spec from the example markers of
+	// the API types, keeping the existing sample if they have none
+	FromExamples bool
+
+	// Spec is the spec of the sample. The sample is scaffolded with a
+	// placeholder spec when nil, and overwritten otherwise.
+	Spec map[string]interface{}
+
+	// SpecYAML is Spec marshalled to YAML, indented under the spec field
+	SpecYAML string
 }
 
 // GetInput implements input.File
@@ -44,6 +57,23 @@ func (c *CRDSample) GetInput() (input.Input, error) {
 
 	c.IfExistsAction = input.Error
 	c.TemplateBody = crdSampleTemplate
+	if c.FromExamples && c.Spec == nil {
+		spec, err := internal.SpecExamples(apiDir(c.Resource, c.Input), c.Resource.Kind)
+		if err != nil {
+			return input.Input{}, err
+		}
+		c.Spec = spec
+		c.IfExistsAction = input.Skip
+	}
+	if c.Spec != nil {
+		out, err := yaml.Marshal(c.Spec)
+		if err != nil {
+			return input.Input{}, err
+		}
+		c.SpecYAML = "  " + strings.Replace(strings.TrimSuffix(string(out), "\n"), "\n", "\n  ", -1)
+		c.IfExistsAction = input.Overwrite
+		c.TemplateBody = crdExampleSampleTemplate
+	}
 	return c.Input, nil
 }
 
@@ -60,3 +90,12 @@ spec:
   # Add fields here
   foo: bar
 `
+
+var crdExampleSampleTemplate = `apiVersion: {{ .Resource.Group }}.{{ .Domain }}/{{ .Resource.Version }}
+kind: {{ .Resource.Kind }}
+metadata:
+  name: {{ lower .Resource.Kind }}-sample
+spec:
+  # Generated from the +kubebuilder:example markers of {{ .Resource.Kind }}Spec
+{{ .SpecYAML }}
+`
@@ -0,0 +1,309 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &Conditions{}
+
+// Conditions scaffolds the condition type shared by the statuses of the
+// Resources of an API version
+type Conditions struct {
+	input.Input
+
+	// Resource is a Resource of the API version
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (c *Conditions) GetInput() (input.Input, error) {
+	if c.Path == "" {
+		c.Path = filepath.Join(apiDir(c.Resource, c.Input), "condition_types.go")
+	}
+	c.TemplateBody = conditionsTemplate
+	c.Input.IfExistsAction = input.Skip
+	return c.Input, nil
+}
+
+var conditionsTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionReady is the type of the condition recording that an object is
+// ready, printed by kubectl get.
+const ConditionReady = "Ready"
+
+// ConditionDegraded is the type of the condition recording that the controller
+// limits the objects it creates for an object.
+const ConditionDegraded = "Degraded"
+
+// Condition is an observation of the state of an object.
+type Condition struct {
+	// Type of the condition, in CamelCase.
+	Type string ` + "`" + `json:"type"` + "`" + `
+
+	// Status of the condition, one of True, False or Unknown.
+	// +kubebuilder:validation:Enum=True;False;Unknown
+	Status corev1.ConditionStatus ` + "`" + `json:"status"` + "`" + `
+
+	// LastTransitionTime is the last time the status of the condition changed.
+	// +optional
+	LastTransitionTime metav1.Time ` + "`" + `json:"lastTransitionTime,omitempty"` + "`" + `
+
+	// Reason is the CamelCase reason of the last transition of the condition.
+	// +optional
+	Reason string ` + "`" + `json:"reason,omitempty"` + "`" + `
+
+	// Message is a human readable message about the last transition.
+	// +optional
+	Message string ` + "`" + `json:"message,omitempty"` + "`" + `
+}
+
+// SetCondition sets the condition of its type in conditions, updating its last
+// transition time when its status changes. It reports whether the conditions
+// changed, so that the status is only written when needed.
+func SetCondition(conditions *[]Condition, condition Condition) bool {
+	for i := range *conditions {
+		existing := &(*conditions)[i]
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status {
+			if existing.Reason == condition.Reason && existing.Message == condition.Message {
+				return false
+			}
+			condition.LastTransitionTime = existing.LastTransitionTime
+		} else {
+			condition.LastTransitionTime = metav1.Now()
+		}
+		*existing = condition
+		return true
+	}
+	condition.LastTransitionTime = metav1.Now()
+	*conditions = append(*conditions, condition)
+	return true
+}
+
+// GetCondition returns the condition of the given type in conditions, nil if
+// there is none.
+func GetCondition(conditions []Condition, conditionType string) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue returns true if the condition of the given type in
+// conditions has the True status.
+func IsConditionTrue(conditions []Condition, conditionType string) bool {
+	condition := GetCondition(conditions, conditionType)
+	return condition != nil && condition.Status == corev1.ConditionTrue
+}
+`
+
+var _ input.File = &CreationGuard{}
+
+// CreationGuard scaffolds the guard capping the objects created by the
+// reconciles of the controllers of a controllers package
+type CreationGuard struct {
+	input.Input
+
+	// Resource is a Resource of the controllers package
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (g *CreationGuard) GetInput() (input.Input, error) {
+	if g.Path == "" {
+		g.Path = filepath.Join(controllersDir(g.Resource, g.Input), "creation_guard.go")
+	}
+	g.TemplateBody = creationGuardTemplate
+	g.Input.IfExistsAction = input.Skip
+	return g.Input, nil
+}
+
+var creationGuardTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrCreationLimited is returned by GuardedClient.Create for the objects it
+// refuses to create.
+var ErrCreationLimited = errors.New("creation limit reached")
+
+// CreationGuard protects the apiserver from a reconcile loop creating objects
+// without bound, as a bug in the naming or the ownership of the children of a
+// kind easily causes. Each reconcile of an owner can attempt up to MaxCreations
+// creations. Once an owner reaches the limit, its creations are suspended for
+// a backoff, doubled with each consecutive reconcile reaching it again.
+type CreationGuard struct {
+	// MaxCreations is the number of creations a reconcile can attempt. It
+	// defaults to 10.
+	MaxCreations int
+	// Backoff is how long the creations of an owner are suspended once it
+	// reaches the limit. It defaults to 5 seconds.
+	Backoff time.Duration
+	// MaxBackoff caps the backoff. It defaults to 5 minutes.
+	MaxBackoff time.Duration
+
+	mu     sync.Mutex
+	owners map[types.NamespacedName]*creationBackoff
+}
+
+// creationBackoff is the backoff of an owner which reached the limit.
+type creationBackoff struct {
+	// trips is the number of consecutive reconciles which reached the limit
+	trips int
+	// until is the end of the suspension of the creations
+	until time.Time
+}
+
+// For returns the client creating the objects of a reconcile of owner. Its
+// creations are refused while those of owner are suspended.
+func (g *CreationGuard) For(owner types.NamespacedName, c client.Client) *GuardedClient {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	gc := &GuardedClient{Client: c, guard: g, owner: owner, limit: g.maxCreations()}
+	if b, found := g.owners[owner]; found && time.Now().Before(b.until) {
+		gc.limit = 0
+		gc.suspendedUntil = b.until
+	}
+	return gc
+}
+
+// Forget drops the backoff of owner, once it is deleted.
+func (g *CreationGuard) Forget(owner types.NamespacedName) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.owners, owner)
+}
+
+func (g *CreationGuard) maxCreations() int {
+	if g.MaxCreations <= 0 {
+		return 10
+	}
+	return g.MaxCreations
+}
+
+// backoff returns the backoff after the given number of consecutive
+// reconciles reaching the limit.
+func (g *CreationGuard) backoff(trips int) time.Duration {
+	backoff, maxBackoff := g.Backoff, g.MaxBackoff
+	if backoff <= 0 {
+		backoff = 5 * time.Second
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = 5 * time.Minute
+	}
+	for i := 1; i < trips && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// GuardedClient is the client of a reconcile, refusing the creations over the
+// limit of the CreationGuard it was returned by.
+type GuardedClient struct {
+	client.Client
+
+	guard          *CreationGuard
+	owner          types.NamespacedName
+	limit          int
+	attempted      int
+	refused        int
+	suspendedUntil time.Time
+}
+
+// Create creates obj, unless the reconcile already attempted as many creations
+// as allowed, in which case ErrCreationLimited is returned.
+func (c *GuardedClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOptionFunc) error {
+	if c.attempted >= c.limit {
+		c.refused++
+		return ErrCreationLimited
+	}
+	c.attempted++
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// Done ends the reconcile. When creations were refused, the creations of the
+// owner are suspended and the returned result requeues it once they resume.
+func (c *GuardedClient) Done() ctrl.Result {
+	g := c.guard
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c.refused == 0 {
+		delete(g.owners, c.owner)
+		return ctrl.Result{}
+	}
+	if g.owners == nil {
+		g.owners = map[types.NamespacedName]*creationBackoff{}
+	}
+	b, found := g.owners[c.owner]
+	if !found {
+		b = &creationBackoff{}
+		g.owners[c.owner] = b
+	}
+	if c.suspendedUntil.IsZero() {
+		// the reconcile reached the limit itself
+		b.trips++
+		b.until = time.Now().Add(g.backoff(b.trips))
+		c.suspendedUntil = b.until
+	}
+	return ctrl.Result{RequeueAfter: time.Until(b.until)}
+}
+
+// DegradedCondition returns the status, reason and message of the Degraded
+// condition of the owner once the reconcile is Done.
+func (c *GuardedClient) DegradedCondition() (status corev1.ConditionStatus, reason, message string) {
+	if c.refused == 0 {
+		return corev1.ConditionFalse, "CreationsWithinLimit", ""
+	}
+	return corev1.ConditionTrue, "CreationLimitReached", fmt.Sprintf(
+		"refused %d creations over the limit of %d per reconcile, creations are suspended until %s",
+		c.refused, c.guard.maxCreations(), c.suspendedUntil.UTC().Format(time.RFC3339))
+}
+`
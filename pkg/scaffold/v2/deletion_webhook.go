@@ -0,0 +1,213 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &DeletionProtectionWebhook{}
+
+// DeletionProtectionWebhook scaffolds a validating webhook denying the deletion
+// of the protected objects of a Resource
+type DeletionProtectionWebhook struct {
+	input.Input
+
+	// Resource is the Resource to make the webhook for
+	Resource *resource.Resource
+
+	// GroupDomainWithDash is the API group of the Resource with dots replaced
+	// by dashes, as used by controller-runtime in the webhook paths
+	GroupDomainWithDash string
+}
+
+// GetInput implements input.File
+func (w *DeletionProtectionWebhook) GetInput() (input.Input, error) {
+	if w.Path == "" {
+		w.Path = filepath.Join(apiDir(w.Resource, w.Input),
+			fmt.Sprintf("%s_deletion_webhook.go", strings.ToLower(w.Resource.Kind)))
+	}
+	w.GroupDomainWithDash = strings.Replace(
+		fmt.Sprintf("%s.%s", w.Resource.Group, w.Domain), ".", "-", -1)
+	w.TemplateBody = deletionProtectionWebhookTemplate
+	w.Input.IfExistsAction = input.Error
+	return w.Input, nil
+}
+
+// Validate validates the values
+func (w *DeletionProtectionWebhook) Validate() error {
+	return w.Resource.Validate()
+}
+
+var deletionProtectionWebhookTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// +kubebuilder:webhook:path=/validate-deletion-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }},mutating=false,failurePolicy=fail,groups={{ .Resource.Group }}.{{ .Domain }},resources={{ .Resource.Resource }},verbs=delete,versions={{ .Resource.Version }},name=d{{ lower .Resource.Kind }}.{{ .Domain }}
+
+// SetupDeletionProtectionWebhookWithManager registers the webhook denying the
+// deletion of the protected {{ .Resource.Kind }} objects with the manager's
+// webhook server.
+func (r *{{ .Resource.Kind }}) SetupDeletionProtectionWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register("/validate-deletion-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }}",
+		&admission.Webhook{Handler: &deletionProtector{}})
+	return nil
+}
+`
+
+var _ input.File = &DeletionProtector{}
+
+// DeletionProtector scaffolds the handler shared by the deletion protection
+// webhooks of an API version
+type DeletionProtector struct {
+	input.Input
+
+	// Resource is a Resource of the API version
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (p *DeletionProtector) GetInput() (input.Input, error) {
+	if p.Path == "" {
+		p.Path = filepath.Join(apiDir(p.Resource, p.Input), "webhook_deletionprotection.go")
+	}
+	p.TemplateBody = deletionProtectorTemplate
+	p.Input.IfExistsAction = input.Skip
+	return p.Input, nil
+}
+
+var deletionProtectorTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// The deletion protection webhooks deny the deletion of the objects labelled
+// or annotated with DeletionProtectionKey set to "true". To delete such an
+// object, remove the label or the annotation first. In an emergency, the
+// members of DeletionProtectionBreakGlassGroup can delete the protected
+// objects, which is recorded as an audit annotation of the request.
+//
+// Deleting the namespace of a protected object leaves the namespace
+// terminating until the protection is removed, while deleting the
+// CustomResourceDefinition deletes the protected objects regardless.
+
+const (
+	// DeletionProtectionKey is the label or annotation protecting an object
+	// from deletion when set to "true".
+	DeletionProtectionKey = "{{ .Domain }}/deletion-protection"
+
+	// DeletionProtectionOverriddenAnnotation is the key of the audit annotation
+	// recording the user who deleted a protected object.
+	DeletionProtectionOverriddenAnnotation = "deletion-protection-overridden-by"
+)
+
+// DeletionProtectionBreakGlassGroup is the group whose members can delete
+// the protected objects.
+var DeletionProtectionBreakGlassGroup = "{{ .Domain }}:deletion-protection-break-glass"
+
+var deletionprotectionlog = logf.Log.WithName("deletion-protection-webhook")
+
+// deletionProtector is the admission handler denying the deletion of the
+// protected objects. Its reader is injected by the manager when the webhook
+// server starts.
+type deletionProtector struct {
+	reader client.Reader
+}
+
+var _ inject.APIReader = &deletionProtector{}
+
+// InjectAPIReader implements inject.APIReader.
+func (p *deletionProtector) InjectAPIReader(r client.Reader) error {
+	p.reader = r
+	return nil
+}
+
+// Handle denies the deletion of the object if it is protected, unless the
+// user is a member of DeletionProtectionBreakGlassGroup.
+func (p *deletionProtector) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Delete {
+		return admission.Allowed("")
+	}
+
+	var obj metav1.Object
+	if len(req.OldObject.Raw) > 0 {
+		old := &struct {
+			metav1.ObjectMeta ` + "`" + `json:"metadata"` + "`" + `
+		}{}
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		obj = &old.ObjectMeta
+	} else {
+		// the apiservers older than 1.15 do not send the object being deleted
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(schema.GroupVersionKind{Group: req.Kind.Group, Version: req.Kind.Version, Kind: req.Kind.Kind})
+		err := p.reader.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: req.Name}, u)
+		if apierrors.IsNotFound(err) {
+			return admission.Allowed("")
+		}
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		obj = u
+	}
+
+	if obj.GetLabels()[DeletionProtectionKey] != "true" && obj.GetAnnotations()[DeletionProtectionKey] != "true" {
+		return admission.Allowed("")
+	}
+	for _, group := range req.UserInfo.Groups {
+		if group == DeletionProtectionBreakGlassGroup {
+			deletionprotectionlog.Info("allowing the deletion of a protected object", "kind", req.Kind.Kind,
+				"namespace", req.Namespace, "name", req.Name, "user", req.UserInfo.Username)
+			allowed := admission.Allowed("")
+			allowed.AuditAnnotations = map[string]string{DeletionProtectionOverriddenAnnotation: req.UserInfo.Username}
+			return allowed
+		}
+	}
+	return admission.Denied(fmt.Sprintf("%s %s is protected from deletion, remove its %s label or annotation first",
+		req.Kind.Kind, req.Name, DeletionProtectionKey))
+}
+`
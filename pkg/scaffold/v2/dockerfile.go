@@ -36,8 +36,12 @@ func (c *Dockerfile) GetInput() (input.Input, error) {
 	return c.Input, nil
 }
 
-var dockerfileTemplate = `# Build the manager binary
-FROM golang:1.12.5 as builder
+var dockerfileTemplate = `# The manager binary is cross-compiled on the platform of the build, set by
+# docker buildx, or amd64 otherwise, rather than built under emulation
+ARG BUILDPLATFORM
+
+# Build the manager binary
+FROM --platform=${BUILDPLATFORM:-linux/amd64} golang:1.12.5 as builder
 
 WORKDIR /workspace
 # Copy the Go Modules manifests
@@ -52,13 +56,17 @@ COPY main.go main.go
 COPY api/ api/
 COPY controllers/ controllers/
 
-# Build
-RUN CGO_ENABLED=0 GOOS=linux GOARCH=amd64 GO111MODULE=on go build -a -o manager main.go
+# Build for the platform of the image, set by docker buildx, or linux/amd64 otherwise
+ARG TARGETOS
+ARG TARGETARCH
+RUN CGO_ENABLED=0 GOOS=${TARGETOS:-linux} GOARCH=${TARGETARCH:-amd64} GO111MODULE=on go build -a -o manager main.go
 
 # Use distroless as minimal base image to package the manager binary
 # Refer to https://github.com/GoogleContainerTools/distroless for more details
-FROM gcr.io/distroless/static:latest
+FROM gcr.io/distroless/static:nonroot
 WORKDIR /
 COPY --from=builder /workspace/manager .
+# Run as the nonroot user of the base image, so that the pod can run as non-root
+USER 65532:65532
 ENTRYPOINT ["/manager"]
 `
@@ -0,0 +1,84 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &EmbeddedTypes{}
+
+// EmbeddedTypes scaffolds the api/<version>/embedded_types.go file defining
+// the pod template embedded in the spec of the resources of an API version
+type EmbeddedTypes struct {
+	input.Input
+
+	// Resource is a Resource of the API version
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (t *EmbeddedTypes) GetInput() (input.Input, error) {
+	if t.Path == "" {
+		t.Path = filepath.Join(apiDir(t.Resource, t.Input), "embedded_types.go")
+	}
+	t.TemplateBody = embeddedTypesTemplate
+	t.IfExistsAction = input.Skip
+	return t.Input, nil
+}
+
+var embeddedTypesTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// EmbeddedObjectMeta is the metadata of an object embedded in a resource of
+// this API version, e.g. a pod template.
+//
+// metav1.ObjectMeta is not used here: its generated schema is an object
+// without properties, so with a structural schema the apiserver prunes every
+// field set in it. Only the fields listed here are kept.
+type EmbeddedObjectMeta struct {
+	// Labels of the embedded object.
+	// +optional
+	// +kubebuilder:example={app: example}
+	Labels map[string]string ` + "`" + `json:"labels,omitempty"` + "`" + `
+
+	// Annotations of the embedded object.
+	// +optional
+	Annotations map[string]string ` + "`" + `json:"annotations,omitempty"` + "`" + `
+}
+
+// EmbeddedPodTemplateSpec describes the pods created from a resource of this
+// API version, keeping the labels and annotations of their metadata.
+type EmbeddedPodTemplateSpec struct {
+	// Metadata of the pods.
+	// +optional
+	ObjectMeta EmbeddedObjectMeta ` + "`" + `json:"metadata,omitempty"` + "`" + `
+
+	// Spec of the pods.
+	// +optional
+	// +kubebuilder:example={containers: [{name: main, image: "nginx:1.17"}]}
+	Spec corev1.PodSpec ` + "`" + `json:"spec,omitempty"` + "`" + `
+}
+`
@@ -0,0 +1,471 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/internal"
+)
+
+const envtestWebhooksScaffoldMarker = "// +kubebuilder:scaffold:envtestwebhooks"
+
+var _ input.File = &EnvtestWebhooks{}
+
+// EnvtestWebhooks scaffolds the envtest_webhooks_test.go file of the controller
+// suite, serving the webhooks of the types of the package in the test
+// environment and installing their configurations in its apiserver
+type EnvtestWebhooks struct {
+	input.Input
+
+	// Resource is a resource of the controllers of the suite
+	Resource *resource.Resource
+
+	// ResourcePackage is the package of the Resource
+	ResourcePackage string
+
+	// Defaulting indicates whether the webhooks of the Resource registered by
+	// Update include its defaulting webhook
+	Defaulting bool
+
+	// Validating indicates whether the webhooks of the Resource registered by
+	// Update include its validating webhook
+	Validating bool
+}
+
+// GetInput implements input.File
+func (w *EnvtestWebhooks) GetInput() (input.Input, error) {
+	if w.Path == "" {
+		w.Path = filepath.Join(controllersDir(w.Resource, w.Input), "envtest_webhooks_test.go")
+	}
+	w.TemplateBody = envtestWebhooksTemplate
+	w.Input.IfExistsAction = input.Skip
+	return w.Input, nil
+}
+
+// Validate validates the values
+func (w *EnvtestWebhooks) Validate() error {
+	return w.Resource.Validate()
+}
+
+// Install installs the webhooks of the file in the test environment of the
+// controller suite, once, when the first webhook of the suite is scaffolded.
+func (w *EnvtestWebhooks) Install() error {
+	suite := filepath.Join(filepath.Dir(w.Path), "suite_test.go")
+	content, err := ioutil.ReadFile(suite) // nolint: gosec
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(content), "webhookInstall.Install(") {
+		return nil
+	}
+	return internal.InsertStringsInFile(suite,
+		map[string][]string{
+			envtestOptionsScaffoldMarker: []string{`// the apiserver calls the webhooks of envtest_webhooks_test.go
+KubeAPIServerFlags: webhookInstall.KubeAPIServerFlags(),
+`},
+			envtestSetupScaffoldMarker: []string{`err = webhookInstall.Install(cfg)
+Expect(err).ToNot(HaveOccurred())
+`},
+			envtestTeardownScaffoldMarker: []string{`webhookInstall.Cleanup()
+`},
+		})
+}
+
+// Update registers the webhooks of the Resource, served by
+// SetupWebhookWithManager, in the envtest_webhooks_test.go file.
+func (w *EnvtestWebhooks) Update() error {
+	w.ResourcePackage, _ = getResourceInfo(w.Resource, w.Input)
+	content, err := ioutil.ReadFile(w.Path) // nolint: gosec
+	if err != nil {
+		return err
+	}
+	setup := fmt.Sprintf("(&%s%s.%s{}).SetupWebhookWithManager", w.Resource.Group, w.Resource.Version, w.Resource.Kind)
+	if strings.Contains(string(content), setup) {
+		return nil
+	}
+
+	groupDomainWithDash := strings.Replace(
+		fmt.Sprintf("%s.%s", w.Resource.Group, w.Domain), ".", "-", -1)
+	paths := []string{}
+	if w.Defaulting {
+		paths = append(paths, fmt.Sprintf("%q", fmt.Sprintf("/mutate-%s-%s-%s",
+			groupDomainWithDash, w.Resource.Version, strings.ToLower(w.Resource.Kind))))
+	}
+	if w.Validating {
+		paths = append(paths, fmt.Sprintf("%q", fmt.Sprintf("/validate-%s-%s-%s",
+			groupDomainWithDash, w.Resource.Version, strings.ToLower(w.Resource.Kind))))
+	}
+	webhookCodeFragment := fmt.Sprintf(`{
+	Setup: %s,
+	Paths: []string{%s},
+},
+`, setup, strings.Join(paths, ", "))
+	apiImportCodeFragment := fmt.Sprintf(`%s%s "%s/%s"
+`, w.Resource.Group, w.Resource.Version, w.ResourcePackage, w.Resource.Version)
+
+	return internal.InsertStringsInFile(w.Path,
+		map[string][]string{
+			apiPkgImportScaffoldMarker:    []string{apiImportCodeFragment},
+			envtestWebhooksScaffoldMarker: []string{webhookCodeFragment},
+		})
+}
+
+var envtestWebhooksTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	// +kubebuilder:scaffold:imports
+)
+
+// The webhooks registered below by create webhook are served in the test
+// environment of the suite: their configurations, generated in config/webhook
+// by make manifests, are installed in its apiserver, calling a webhook server
+// started on the local host. The objects created by the tests then go through
+// the webhooks as they do in a cluster. The configurations of the webhooks
+// which are not registered below are not installed.
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// envtestWebhook is a webhook served in the test environment.
+type envtestWebhook struct {
+	// Setup registers the webhook with the manager's webhook server
+	Setup func(ctrl.Manager) error
+
+	// Paths are the paths the webhook is served at
+	Paths []string
+}
+
+// envtestWebhooks are the webhooks served in the test environment.
+var envtestWebhooks = []envtestWebhook{
+	// +kubebuilder:scaffold:envtestwebhooks
+}
+
+// webhookInstall installs envtestWebhooks in the test environment of the suite.
+var webhookInstall = &WebhookInstallOptions{
+	Paths: []string{filepath.Join("..", {{ if .MultiGroup }}"..", {{ end }}"config", "webhook")},
+}
+
+// WebhookInstallOptions installs the configurations of the webhooks read from
+// Paths in a test environment, calling a webhook server listening on
+// LocalServingHost and LocalServingPort. It follows the WebhookInstallOptions
+// of the envtest package of the later controller-runtime releases, which can
+// replace it once the project upgrades.
+type WebhookInstallOptions struct {
+	// Paths are the directories of the webhook configuration manifests
+	Paths []string
+
+	// LocalServingHost is the host the webhook server listens on, 127.0.0.1
+	// when empty
+	LocalServingHost string
+
+	// LocalServingPort is the port the webhook server listens on, a free port
+	// when 0
+	LocalServingPort int
+
+	// LocalServingCertDir is the directory of the tls.crt and tls.key of the
+	// webhook server, issued in a temporary directory when empty
+	LocalServingCertDir string
+
+	// LocalServingCAData is the PEM CA bundle of the certificate of the
+	// webhook server, set when LocalServingCertDir is issued
+	LocalServingCAData []byte
+
+	issuedCertDir bool
+	stop          chan struct{}
+}
+
+// KubeAPIServerFlags returns the flags of the apiserver of the test
+// environment, enabling the admission webhooks which the default flags of
+// envtest disable.
+func (o *WebhookInstallOptions) KubeAPIServerFlags() []string {
+	flags := []string{}
+	for _, flag := range envtest.DefaultKubeAPIServerFlags {
+		if strings.HasPrefix(flag, "--admission-control=") {
+			flag = "--admission-control=MutatingAdmissionWebhook,ValidatingAdmissionWebhook"
+		}
+		flags = append(flags, flag)
+	}
+	return flags
+}
+
+// Install starts a manager serving envtestWebhooks, and installs their
+// configurations in the apiserver of cfg. It does nothing when no webhook is
+// registered. The apiserver calls the webhooks once it has observed their
+// configurations, which takes up to a second.
+func (o *WebhookInstallOptions) Install(cfg *rest.Config) error {
+	if len(envtestWebhooks) == 0 {
+		return nil
+	}
+	if err := o.setDefaults(); err != nil {
+		return err
+	}
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:             scheme.Scheme,
+		MetricsBindAddress: "0",
+		Host:               o.LocalServingHost,
+		Port:               o.LocalServingPort,
+	})
+	if err != nil {
+		return err
+	}
+	mgr.GetWebhookServer().CertDir = o.LocalServingCertDir
+	served := map[string]bool{}
+	for _, webhook := range envtestWebhooks {
+		if err := webhook.Setup(mgr); err != nil {
+			return err
+		}
+		for _, path := range webhook.Paths {
+			served[path] = true
+		}
+	}
+	o.stop = make(chan struct{})
+	go func() {
+		if err := mgr.Start(o.stop); err != nil {
+			ctrl.Log.WithName("envtest-webhooks").Error(err, "unable to serve the webhooks")
+		}
+	}()
+	if err := o.waitForServing(); err != nil {
+		return err
+	}
+
+	configs, err := o.configurations(served)
+	if err != nil {
+		return err
+	}
+	c, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		if err := c.Create(context.Background(), config); err != nil {
+			return fmt.Errorf("error installing %s %s: %v", config.GetKind(), config.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// Cleanup stops the webhook server, and removes the certificate issued for it.
+func (o *WebhookInstallOptions) Cleanup() {
+	if o.stop != nil {
+		close(o.stop)
+		o.stop = nil
+	}
+	if o.issuedCertDir {
+		_ = os.RemoveAll(o.LocalServingCertDir)
+	}
+}
+
+func (o *WebhookInstallOptions) setDefaults() error {
+	if o.LocalServingHost == "" {
+		o.LocalServingHost = "127.0.0.1"
+	}
+	if o.LocalServingPort == 0 {
+		l, err := net.Listen("tcp", net.JoinHostPort(o.LocalServingHost, "0"))
+		if err != nil {
+			return err
+		}
+		o.LocalServingPort = l.Addr().(*net.TCPAddr).Port
+		if err := l.Close(); err != nil {
+			return err
+		}
+	}
+	if o.LocalServingCertDir == "" {
+		dir, err := ioutil.TempDir("", "envtest-webhook-certs-")
+		if err != nil {
+			return err
+		}
+		o.LocalServingCertDir = dir
+		o.issuedCertDir = true
+		return o.issueCertificate()
+	}
+	return nil
+}
+
+// issueCertificate writes a serving certificate of LocalServingHost, issued
+// by a self-signed CA, to LocalServingCertDir.
+func (o *WebhookInstallOptions) issueCertificate() error {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "envtest-webhook-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: o.LocalServingHost},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(o.LocalServingHost); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{o.LocalServingHost}
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	o.LocalServingCAData = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	if err := ioutil.WriteFile(filepath.Join(o.LocalServingCertDir, "tls.crt"), cert, 0600); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(o.LocalServingCertDir, "tls.key"),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+}
+
+// waitForServing waits for the webhook server to answer TLS handshakes with
+// its certificate.
+func (o *WebhookInstallOptions) waitForServing() error {
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(o.LocalServingCAData)
+	addr := net.JoinHostPort(o.LocalServingHost, strconv.Itoa(o.LocalServingPort))
+
+	var err error
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		var conn *tls.Conn
+		conn, err = tls.Dial("tcp", addr, &tls.Config{RootCAs: pool, ServerName: o.LocalServingHost})
+		if err == nil {
+			return conn.Close()
+		}
+	}
+	return fmt.Errorf("the webhook server is not serving at %s: %v", addr, err)
+}
+
+// configurations returns the webhook configurations of Paths, keeping their
+// webhooks served at one of the served paths, calling the local webhook server.
+func (o *WebhookInstallOptions) configurations(served map[string]bool) ([]*unstructured.Unstructured, error) {
+	configs := []*unstructured.Unstructured{}
+	for _, dir := range o.Paths {
+		files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+			for {
+				config := &unstructured.Unstructured{}
+				if err := decoder.Decode(&config.Object); err == io.EOF {
+					break
+				} else if err != nil {
+					return nil, fmt.Errorf("error decoding %s: %v", file, err)
+				}
+				if config.GetKind() != "MutatingWebhookConfiguration" && config.GetKind() != "ValidatingWebhookConfiguration" {
+					continue
+				}
+
+				webhooks, _, err := unstructured.NestedSlice(config.Object, "webhooks")
+				if err != nil {
+					return nil, fmt.Errorf("error reading the webhooks of %s: %v", file, err)
+				}
+				local := []interface{}{}
+				for _, w := range webhooks {
+					webhook, ok := w.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					path, _, _ := unstructured.NestedString(webhook, "clientConfig", "service", "path")
+					if !served[path] {
+						continue
+					}
+					webhook["clientConfig"] = map[string]interface{}{
+						"url": fmt.Sprintf("https://%s%s",
+							net.JoinHostPort(o.LocalServingHost, strconv.Itoa(o.LocalServingPort)), path),
+						"caBundle": base64.StdEncoding.EncodeToString(o.LocalServingCAData),
+					}
+					local = append(local, webhook)
+				}
+				if len(local) == 0 {
+					continue
+				}
+				if err := unstructured.SetNestedSlice(config.Object, local, "webhooks"); err != nil {
+					return nil, err
+				}
+				configs = append(configs, config)
+			}
+		}
+	}
+	return configs, nil
+}
+`
@@ -0,0 +1,219 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &Events{}
+
+// Events scaffolds the event reasons and helpers shared by the controllers of
+// a controllers package recording the transitions of their Resources
+type Events struct {
+	input.Input
+
+	// Resource is a Resource of the controllers package
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (e *Events) GetInput() (input.Input, error) {
+	if e.Path == "" {
+		e.Path = filepath.Join(controllersDir(e.Resource, e.Input), "events.go")
+	}
+	e.TemplateBody = eventsTemplate
+	e.Input.IfExistsAction = input.Skip
+	return e.Input, nil
+}
+
+var eventsTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// EventReason is the CamelCase reason of the events recorded by the
+// controllers. The reasons are shared by all the kinds, so that kubectl
+// describe reads the same for each of them.
+type EventReason string
+
+const (
+	// ReasonReady is recorded when the Ready condition becomes True.
+	ReasonReady EventReason = "Ready"
+	// ReasonNotReady is recorded when the Ready condition leaves True.
+	ReasonNotReady EventReason = "NotReady"
+	// ReasonDegraded is recorded when the Degraded condition becomes True.
+	ReasonDegraded EventReason = "Degraded"
+	// ReasonRecovered is recorded when the Degraded condition leaves True.
+	ReasonRecovered EventReason = "Recovered"
+	// ReasonConditionChanged is recorded on the transitions of the conditions
+	// without reasons of their own.
+	ReasonConditionChanged EventReason = "ConditionChanged"
+	// ReasonPhaseChanged is recorded on the transitions of the phase.
+	ReasonPhaseChanged EventReason = "PhaseChanged"
+)
+
+// ConditionEvents are the reasons of the events recorded on the transitions
+// of a condition type: a Normal event when the condition reaches its healthy
+// status, a Warning event when it leaves it.
+type ConditionEvents struct {
+	// Healthy is the status of the condition of a healthy object
+	Healthy corev1.ConditionStatus
+	// Reached is the reason of the event recorded when the condition reaches
+	// the Healthy status
+	Reached EventReason
+	// Left is the reason of the event recorded when the condition leaves the
+	// Healthy status
+	Left EventReason
+}
+
+// defaultConditionEvents are the events of the condition types missing from
+// the ConditionEvents of a kind.
+var defaultConditionEvents = ConditionEvents{
+	Healthy: corev1.ConditionTrue,
+	Reached: ReasonConditionChanged,
+	Left:    ReasonConditionChanged,
+}
+
+// RecordConditionTransition records on obj the event of the transition of its
+// condition of type conditionType from the status from to the status to, the
+// reason of the event being the one of the condition type in events. Nothing
+// is recorded when the status did not change.
+func RecordConditionTransition(recorder record.EventRecorder, obj runtime.Object, events map[string]ConditionEvents,
+	conditionType string, from, to corev1.ConditionStatus, message string) {
+	if from == to {
+		return
+	}
+	e, found := events[conditionType]
+	if !found {
+		e = defaultConditionEvents
+	}
+	eventType, reason := corev1.EventTypeNormal, e.Reached
+	if to != e.Healthy {
+		eventType, reason = corev1.EventTypeWarning, e.Left
+	}
+	if message == "" {
+		recorder.Eventf(obj, eventType, string(reason), "Condition %s changed from %s to %s", conditionType, from, to)
+		return
+	}
+	recorder.Eventf(obj, eventType, string(reason), "Condition %s changed from %s to %s: %s", conditionType, from, to, message)
+}
+
+// RecordPhaseTransition records on obj the Normal event of the transition of
+// its phase from from to to. Nothing is recorded when the phase did not change.
+func RecordPhaseTransition(recorder record.EventRecorder, obj runtime.Object, from, to string) {
+	if from == to {
+		return
+	}
+	if from == "" {
+		recorder.Eventf(obj, corev1.EventTypeNormal, string(ReasonPhaseChanged), "Phase set to %s", to)
+		return
+	}
+	recorder.Eventf(obj, corev1.EventTypeNormal, string(ReasonPhaseChanged), "Phase changed from %s to %s", from, to)
+}
+`
+
+var _ input.File = &TransitionEvents{}
+
+// TransitionEvents scaffolds the helper recording the events of the
+// transitions of the conditions and the phase of a Resource
+type TransitionEvents struct {
+	input.Input
+
+	// Resource is the Resource to make the helper for
+	Resource *resource.Resource
+
+	// ResourcePackage is the package of the Resource
+	ResourcePackage string
+}
+
+// GetInput implements input.File
+func (t *TransitionEvents) GetInput() (input.Input, error) {
+	t.ResourcePackage, _ = getResourceInfo(t.Resource, t.Input)
+	if t.Path == "" {
+		t.Path = filepath.Join(controllersDir(t.Resource, t.Input),
+			strings.ToLower(t.Resource.Kind)+"_events.go")
+	}
+	t.TemplateBody = transitionEventsTemplate
+	t.Input.IfExistsAction = input.Error
+	return t.Input, nil
+}
+
+// Validate validates the values
+func (t *TransitionEvents) Validate() error {
+	return t.Resource.Validate()
+}
+
+var transitionEventsTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+{{- if or .Resource.WithConditions .Resource.CreationGuard }}
+	corev1 "k8s.io/api/core/v1"
+{{- end }}
+	"k8s.io/client-go/tools/record"
+
+	{{ .Resource.Group }}{{ .Resource.Version }} "{{ .ResourcePackage }}/{{ .Resource.Version }}"
+)
+{{- if or .Resource.WithConditions .Resource.CreationGuard }}
+
+// {{ lower .Resource.Kind }}ConditionEvents are the reasons of the events recorded on the
+// transitions of the conditions of a {{ .Resource.Kind }}.
+// TODO(user): add the condition types of {{ .Resource.Kind }}, using the reasons of
+// events.go or adding new ones there.
+var {{ lower .Resource.Kind }}ConditionEvents = map[string]ConditionEvents{
+{{- if .Resource.WithConditions }}
+	{{ .Resource.Group }}{{ .Resource.Version }}.ConditionReady: {Healthy: corev1.ConditionTrue, Reached: ReasonReady, Left: ReasonNotReady},
+{{- end }}
+{{- if .Resource.CreationGuard }}
+	{{ .Resource.Group }}{{ .Resource.Version }}.ConditionDegraded: {Healthy: corev1.ConditionFalse, Reached: ReasonRecovered, Left: ReasonDegraded},
+{{- end }}
+}
+{{- end }}
+
+// record{{ .Resource.Kind }}Transitions records on {{ lower .Resource.Kind }} the events of the transitions of
+// its {{ if or .Resource.WithConditions .Resource.CreationGuard }}conditions{{ if .Resource.Phases }} and {{ end }}{{ end }}{{ if .Resource.Phases }}phase{{ end }} since old, the {{ .Resource.Kind }} as read at the start of the
+// reconcile. Call it once the status is written, so that the events only
+// report the transitions stored by the apiserver.
+func record{{ .Resource.Kind }}Transitions(recorder record.EventRecorder, old, {{ lower .Resource.Kind }} *{{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}) {
+{{- if or .Resource.WithConditions .Resource.CreationGuard }}
+	for _, condition := range {{ lower .Resource.Kind }}.Status.Conditions {
+		from := corev1.ConditionUnknown
+		if previous := {{ .Resource.Group }}{{ .Resource.Version }}.GetCondition(old.Status.Conditions, condition.Type); previous != nil {
+			from = previous.Status
+		}
+		RecordConditionTransition(recorder, {{ lower .Resource.Kind }}, {{ lower .Resource.Kind }}ConditionEvents,
+			condition.Type, from, condition.Status, condition.Message)
+	}
+{{- end }}
+{{- if .Resource.Phases }}
+	RecordPhaseTransition(recorder, {{ lower .Resource.Kind }}, string(old.Status.Phase), string({{ lower .Resource.Kind }}.Status.Phase))
+{{- end }}
+}
+`
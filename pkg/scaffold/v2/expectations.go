@@ -0,0 +1,209 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &Expectations{}
+
+// Expectations scaffolds the expectations shared by the controllers of a
+// package, tracking the creations and deletions of children their informers
+// have yet to observe
+type Expectations struct {
+	input.Input
+
+	// Resource is a Resource whose controller tracks its expectations
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (e *Expectations) GetInput() (input.Input, error) {
+	if e.Path == "" {
+		e.Path = filepath.Join(controllersDir(e.Resource, e.Input), "expectations.go")
+	}
+	e.TemplateBody = expectationsTemplate
+	e.Input.IfExistsAction = input.Skip
+	return e.Input, nil
+}
+
+var expectationsTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ExpectationsTTL is how long the expectations of an object are waited for.
+// Past it, the events are assumed lost and the object is reconciled again.
+const ExpectationsTTL = 5 * time.Minute
+
+// Expectations tracks, for each reconciled object, the creations and deletions
+// of children its reconciles made which the informers have not observed yet,
+// like the expectations of the controllers of kube-controller-manager. Until
+// they are observed, the cache lags behind the cluster: a reconcile counting
+// the children in the cache would create them again.
+//
+// A reconcile creating or deleting children:
+//   - returns early unless Satisfied, the next event of a child reconciling
+//     the object again;
+//   - calls ExpectCreations or ExpectDeletions before creating or deleting the
+//     children, and CreationObserved or DeletionObserved for each of them it
+//     failed to create or delete.
+//
+// The children are watched with OwnerHandler, observing their creations and
+// deletions before reconciling their owner, e.g. for Pods:
+//	Watches(&source.Kind{Type: &corev1.Pod{}}, r.Expectations.OwnerHandler(&v1.MyKind{}))
+//
+// The zero value is ready to use.
+type Expectations struct {
+	mu      sync.Mutex
+	pending map[types.NamespacedName]*expectation
+}
+
+// expectation is the number of creations and deletions of the children of an
+// object which have not been observed yet.
+type expectation struct {
+	creations, deletions int
+	timestamp            time.Time
+}
+
+// ExpectCreations expects n more creations of children of the given object.
+func (e *Expectations) ExpectCreations(key types.NamespacedName, n int) {
+	e.expect(key, n, 0)
+}
+
+// ExpectDeletions expects n more deletions of children of the given object.
+func (e *Expectations) ExpectDeletions(key types.NamespacedName, n int) {
+	e.expect(key, 0, n)
+}
+
+func (e *Expectations) expect(key types.NamespacedName, creations, deletions int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.pending == nil {
+		e.pending = map[types.NamespacedName]*expectation{}
+	}
+	exp, found := e.pending[key]
+	if !found {
+		exp = &expectation{}
+		e.pending[key] = exp
+	}
+	exp.creations += creations
+	exp.deletions += deletions
+	exp.timestamp = time.Now()
+}
+
+// CreationObserved records the creation of a child of the given object, or the
+// failure to create it.
+func (e *Expectations) CreationObserved(key types.NamespacedName) {
+	e.observe(key, 1, 0)
+}
+
+// DeletionObserved records the deletion of a child of the given object, or the
+// failure to delete it.
+func (e *Expectations) DeletionObserved(key types.NamespacedName) {
+	e.observe(key, 0, 1)
+}
+
+func (e *Expectations) observe(key types.NamespacedName, creations, deletions int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if exp, found := e.pending[key]; found {
+		exp.creations -= creations
+		exp.deletions -= deletions
+	}
+}
+
+// Satisfied returns whether all the creations and deletions expected for the
+// given object have been observed, or have expired.
+func (e *Expectations) Satisfied(key types.NamespacedName) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	exp, found := e.pending[key]
+	if !found {
+		return true
+	}
+	if (exp.creations <= 0 && exp.deletions <= 0) || time.Since(exp.timestamp) > ExpectationsTTL {
+		delete(e.pending, key)
+		return true
+	}
+	return false
+}
+
+// Forget drops the expectations of the given object, e.g. once it is deleted.
+func (e *Expectations) Forget(key types.NamespacedName) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.pending, key)
+}
+
+// OwnerHandler returns the handler of the events of the children controlled by
+// objects of ownerType, observing their creations and deletions before
+// enqueuing a reconcile of their owner.
+func (e *Expectations) OwnerHandler(ownerType runtime.Object) handler.EventHandler {
+	return &expectationsHandler{
+		EnqueueRequestForOwner: handler.EnqueueRequestForOwner{OwnerType: ownerType, IsController: true},
+		expectations:           e,
+	}
+}
+
+// expectationsHandler enqueues the owners of the children, which it observes
+// the creations and deletions of on the way. The scheme and mapper injected by
+// the controller reach the embedded handler.
+type expectationsHandler struct {
+	handler.EnqueueRequestForOwner
+	expectations *Expectations
+}
+
+// Create implements handler.EventHandler.
+func (h *expectationsHandler) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.EnqueueRequestForOwner.Create(evt, &observingQueue{RateLimitingInterface: q, observe: h.expectations.CreationObserved})
+}
+
+// Delete implements handler.EventHandler.
+func (h *expectationsHandler) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.EnqueueRequestForOwner.Delete(evt, &observingQueue{RateLimitingInterface: q, observe: h.expectations.DeletionObserved})
+}
+
+// observingQueue observes the owners of the children enqueued by the handler.
+type observingQueue struct {
+	workqueue.RateLimitingInterface
+	observe func(types.NamespacedName)
+}
+
+// Add implements workqueue.Interface.
+func (q *observingQueue) Add(item interface{}) {
+	if req, ok := item.(reconcile.Request); ok {
+		q.observe(req.NamespacedName)
+	}
+	q.RateLimitingInterface.Add(item)
+}
+`
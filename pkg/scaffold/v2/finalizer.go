@@ -0,0 +1,190 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &Finalizers{}
+
+// Finalizers scaffolds the helpers adding, checking and removing the
+// finalizers of the objects reconciled by the controllers
+type Finalizers struct {
+	input.Input
+
+	// Resource is a Resource whose controller handles a finalizer
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (f *Finalizers) GetInput() (input.Input, error) {
+	if f.Path == "" {
+		f.Path = filepath.Join(controllersDir(f.Resource, f.Input), "finalizers.go")
+	}
+	f.TemplateBody = finalizersTemplate
+	f.Input.IfExistsAction = input.Skip
+	return f.Input, nil
+}
+
+var finalizersTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HasFinalizer returns true if the object has the given finalizer.
+func HasFinalizer(obj metav1.Object, finalizer string) bool {
+	for _, f := range obj.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// AddFinalizer adds the given finalizer to the object, returning true if the
+// object changed and must be updated.
+func AddFinalizer(obj metav1.Object, finalizer string) bool {
+	if HasFinalizer(obj, finalizer) {
+		return false
+	}
+	obj.SetFinalizers(append(obj.GetFinalizers(), finalizer))
+	return true
+}
+
+// RemoveFinalizer removes the given finalizer from the object, returning true
+// if the object changed and must be updated.
+func RemoveFinalizer(obj metav1.Object, finalizer string) bool {
+	finalizers := []string{}
+	for _, f := range obj.GetFinalizers() {
+		if f != finalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	if len(finalizers) == len(obj.GetFinalizers()) {
+		return false
+	}
+	obj.SetFinalizers(finalizers)
+	return true
+}
+`
+
+var _ input.File = &FinalizerTest{}
+
+// FinalizerTest scaffolds the controllers/kind_finalizer_test.go file testing
+// the finalizer handling of the controller of a Resource
+type FinalizerTest struct {
+	input.Input
+
+	// Resource is the Resource whose controller handles a finalizer
+	Resource *resource.Resource
+
+	// ResourcePackage is the package of the Resource
+	ResourcePackage string
+}
+
+// GetInput implements input.File
+func (f *FinalizerTest) GetInput() (input.Input, error) {
+	f.ResourcePackage, _ = getResourceInfo(f.Resource, f.Input)
+	if f.Path == "" {
+		f.Path = filepath.Join(controllersDir(f.Resource, f.Input),
+			fmt.Sprintf("%s_finalizer_test.go", strings.ToLower(f.Resource.Kind)))
+	}
+	f.TemplateBody = finalizerTestTemplate
+	f.Input.IfExistsAction = input.Error
+	return f.Input, nil
+}
+
+// Validate validates the values
+func (f *FinalizerTest) Validate() error {
+	return f.Resource.Validate()
+}
+
+var finalizerTestTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+{{- if .Resource.TransitionEvents }}
+	"k8s.io/client-go/tools/record"
+{{- end }}
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	{{ .Resource.Group}}{{ .Resource.Version }} "{{ .ResourcePackage }}/{{ .Resource.Version }}"
+)
+
+var _ = Describe("{{ .Resource.Kind }} finalizer", func() {
+	It("should hold the deletion of a {{ .Resource.Kind }} until it is finalized", func() {
+		ctx := context.Background()
+		key := types.NamespacedName{Name: "{{ .Resource.Kind | lower }}-finalizer"{{ if .Resource.Namespaced }}, Namespace: "default"{{ end }}}
+		r := &{{ .Resource.Kind }}Reconciler{
+			Client: k8sClient,
+			Log:    ctrl.Log.WithName("controllers").WithName("{{ .Resource.Kind }}"),
+{{- if .Resource.CreationGuard }}
+			CreationGuard: &CreationGuard{},
+{{- end }}
+{{- if .Resource.Expectations }}
+			Expectations: &Expectations{},
+{{- end }}
+{{- if .Resource.TransitionEvents }}
+			Recorder: record.NewFakeRecorder(100),
+{{- end }}
+		}
+
+		created := &{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		}
+		Expect(k8sClient.Create(ctx, created)).To(Succeed())
+
+		By("adding the finalizer on the first reconcile")
+		_, err := r.Reconcile(ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		fetched := &{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}{}
+		Expect(k8sClient.Get(ctx, key, fetched)).To(Succeed())
+		Expect(HasFinalizer(fetched, {{ .Resource.Kind }}Finalizer)).To(BeTrue())
+
+		By("holding the deleted {{ .Resource.Kind }} until it is reconciled")
+		Expect(k8sClient.Delete(ctx, fetched)).To(Succeed())
+		Expect(k8sClient.Get(ctx, key, fetched)).To(Succeed())
+		Expect(fetched.DeletionTimestamp).NotTo(BeNil())
+
+		By("removing the finalizer on the reconcile of the deletion")
+		_, err = r.Reconcile(ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		err = k8sClient.Get(ctx, key, fetched)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		// TODO(user): check the external resources of the {{ .Resource.Kind }} are cleaned up
+	})
+})
+`
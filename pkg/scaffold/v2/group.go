@@ -36,7 +36,7 @@ type Group struct {
 // GetInput implements input.File
 func (g *Group) GetInput() (input.Input, error) {
 	if g.Path == "" {
-		g.Path = filepath.Join("api", g.Resource.Version, "groupversion_info.go")
+		g.Path = filepath.Join(apiDir(g.Resource, g.Input), "groupversion_info.go")
 	}
 	g.TemplateBody = groupTemplate
 	return g.Input, nil
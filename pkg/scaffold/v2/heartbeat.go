@@ -0,0 +1,181 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &Heartbeat{}
+
+// Heartbeat scaffolds the controllers/heartbeat.go file, a Runnable which
+// periodically records the health of the operator in a ConfigMap.
+type Heartbeat struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (h *Heartbeat) GetInput() (input.Input, error) {
+	if h.Path == "" {
+		h.Path = filepath.Join("controllers", "heartbeat.go")
+	}
+	h.TemplateBody = heartbeatTemplate
+	h.Input.IfExistsAction = input.Error
+	return h.Input, nil
+}
+
+var heartbeatTemplate = `{{ .Boilerplate }}
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// OperatorVersion is the version reported by the heartbeat. It can be set at
+// build time with -ldflags "-X {{ .Repo }}/controllers.OperatorVersion=<version>".
+var OperatorVersion = "dev"
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
+
+// Heartbeat periodically writes the version, ready controllers, and last
+// reconcile times of the operator to a ConfigMap, giving a uniform way to
+// monitor a fleet of operators.
+type Heartbeat struct {
+	// Client is used to write the heartbeat ConfigMap
+	Client client.Client
+	// Reader is used to read the heartbeat ConfigMap without starting an informer
+	Reader client.Reader
+	Log    logr.Logger
+
+	// Name is the name of the heartbeat ConfigMap
+	Name string
+	// Namespace is the namespace of the heartbeat ConfigMap. It defaults to the
+	// namespace the manager is running in, or default outside of a cluster.
+	Namespace string
+	// Interval is how often the heartbeat is written
+	Interval time.Duration
+
+	mu          sync.Mutex
+	startTime   time.Time
+	controllers map[string]time.Time
+}
+
+// Register records the given controller as ready.
+func (h *Heartbeat) Register(controller string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.controllers == nil {
+		h.controllers = map[string]time.Time{}
+	}
+	if _, found := h.controllers[controller]; !found {
+		h.controllers[controller] = time.Time{}
+	}
+}
+
+// Reconciled records that the given controller has finished a reconcile,
+// reported as its last reconcile time.
+func (h *Heartbeat) Reconciled(controller string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.controllers == nil {
+		h.controllers = map[string]time.Time{}
+	}
+	h.controllers[controller] = time.Now()
+}
+
+// Start implements manager.Runnable, writing the heartbeat until stop is closed.
+func (h *Heartbeat) Start(stop <-chan struct{}) error {
+	if h.Namespace == "" {
+		var inCluster bool
+		h.Namespace, inCluster = operatorNamespace()
+		if !inCluster {
+			h.Log.Info("not running in a cluster, writing the heartbeat to the default namespace, "+
+				"set another one with --heartbeat-namespace", "namespace", h.Namespace)
+		}
+	}
+	if h.Interval <= 0 {
+		h.Interval = time.Minute
+	}
+	h.startTime = time.Now()
+
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+	for {
+		if err := h.beat(context.Background()); err != nil {
+			h.Log.Error(err, "unable to write heartbeat", "configmap", h.Namespace+"/"+h.Name)
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// data returns the contents of the heartbeat ConfigMap.
+func (h *Heartbeat) data() map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	data := map[string]string{
+		"version":           OperatorVersion,
+		"startTime":         h.startTime.UTC().Format(time.RFC3339),
+		"lastHeartbeatTime": time.Now().UTC().Format(time.RFC3339),
+	}
+	names := []string{}
+	for name, lastReconcile := range h.controllers {
+		names = append(names, name)
+		if !lastReconcile.IsZero() {
+			data["lastReconcileTime."+name] = lastReconcile.UTC().Format(time.RFC3339)
+		}
+	}
+	sort.Strings(names)
+	data["controllers"] = strings.Join(names, ",")
+	return data
+}
+
+func (h *Heartbeat) beat(ctx context.Context) error {
+	cm := &corev1.ConfigMap{}
+	err := h.Reader.Get(ctx, types.NamespacedName{Namespace: h.Namespace, Name: h.Name}, cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: h.Namespace, Name: h.Name},
+			Data:       h.data(),
+		}
+		return h.Client.Create(ctx, cm)
+	}
+	if err != nil {
+		return err
+	}
+	cm.Data = h.data()
+	return h.Client.Update(ctx, cm)
+}
+`
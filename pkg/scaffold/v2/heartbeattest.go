@@ -0,0 +1,116 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &HeartbeatTest{}
+
+// HeartbeatTest scaffolds the controllers/heartbeat_test.go file, testing the
+// ConfigMap written by the heartbeat with a fake client, outside of the test
+// environment of the controllers suite.
+type HeartbeatTest struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (t *HeartbeatTest) GetInput() (input.Input, error) {
+	if t.Path == "" {
+		t.Path = filepath.Join("controllers", "heartbeat_test.go")
+	}
+	t.TemplateBody = heartbeatTestTemplate
+	t.Input.IfExistsAction = input.Error
+	return t.Input, nil
+}
+
+var heartbeatTestTemplate = `{{ .Boilerplate }}
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// TestHeartbeat tests the ConfigMap written by the heartbeat. It needs no test
+// environment, run it alone with go test ./controllers -run TestHeartbeat.
+func TestHeartbeat(t *testing.T) {
+	c := fake.NewFakeClient()
+	h := &Heartbeat{
+		Client:    c,
+		Reader:    c,
+		Log:       ctrl.Log.WithName("heartbeat"),
+		Name:      "heartbeat",
+		Namespace: "default",
+	}
+	// a closed stop writes the heartbeat a single time
+	stop := make(chan struct{})
+	close(stop)
+
+	h.Register("Example")
+	if err := h.Start(stop); err != nil {
+		t.Fatalf("unable to create the heartbeat: %v", err)
+	}
+	h.Reconciled("Example")
+	if err := h.Start(stop); err != nil {
+		t.Fatalf("unable to update the heartbeat: %v", err)
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "heartbeat"}, cm); err != nil {
+		t.Fatalf("unable to get the heartbeat: %v", err)
+	}
+	if cm.Data["controllers"] != "Example" {
+		t.Errorf("controllers = %q, want %q", cm.Data["controllers"], "Example")
+	}
+	for _, key := range []string{"startTime", "lastHeartbeatTime", "lastReconcileTime.Example"} {
+		if _, err := time.Parse(time.RFC3339, cm.Data[key]); err != nil {
+			t.Errorf("%s is not a timestamp: %v", key, err)
+		}
+	}
+}
+
+// TestHeartbeatOutsideOfCluster tests that the heartbeat of an unset namespace
+// is written to the default namespace outside of a cluster, as with make run.
+func TestHeartbeatOutsideOfCluster(t *testing.T) {
+	if _, inCluster := operatorNamespace(); inCluster {
+		t.Skip("running in a cluster")
+	}
+	c := fake.NewFakeClient()
+	h := &Heartbeat{Client: c, Reader: c, Log: ctrl.Log.WithName("heartbeat"), Name: "heartbeat"}
+	stop := make(chan struct{})
+	close(stop)
+	if err := h.Start(stop); err != nil {
+		t.Fatalf("unable to write the heartbeat: %v", err)
+	}
+	cm := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: defaultOperatorNamespace, Name: "heartbeat"}
+	if err := c.Get(context.Background(), key, cm); err != nil {
+		t.Errorf("unable to get the heartbeat of the default namespace: %v", err)
+	}
+}
+`
@@ -0,0 +1,61 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+// Dir returns the directory of the chart of the project, charts/<project name>.
+func Dir(projectName string) string {
+	return filepath.Join("charts", projectName)
+}
+
+var _ input.File = &Chart{}
+
+// Chart scaffolds the Chart.yaml file of the chart of the project, whose
+// templates, CRDs and values are generated from config/default by make helm.
+type Chart struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (c *Chart) GetInput() (input.Input, error) {
+	name, err := c.GetProjectName()
+	if err != nil {
+		return input.Input{}, err
+	}
+	c.ProjectName = name
+	if c.Path == "" {
+		c.Path = filepath.Join(Dir(name), "Chart.yaml")
+	}
+	c.TemplateBody = chartTemplate
+	c.Input.IfExistsAction = input.Error
+	return c.Input, nil
+}
+
+var chartTemplate = `apiVersion: v2
+name: {{ .ProjectName }}
+description: Deploys the {{ .ProjectName }} operator and its CRDs
+type: application
+# The version of the chart, to bump with each of its changes
+version: 0.1.0
+# The version of the operator deployed by the chart
+appVersion: "0.1.0"
+`
@@ -0,0 +1,59 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &HelmIgnore{}
+
+// HelmIgnore scaffolds the .helmignore file of the chart of the project.
+type HelmIgnore struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (h *HelmIgnore) GetInput() (input.Input, error) {
+	name, err := h.GetProjectName()
+	if err != nil {
+		return input.Input{}, err
+	}
+	if h.Path == "" {
+		h.Path = filepath.Join(Dir(name), ".helmignore")
+	}
+	h.TemplateBody = helmIgnoreTemplate
+	h.Input.IfExistsAction = input.Skip
+	return h.Input, nil
+}
+
+var helmIgnoreTemplate = `# Patterns to ignore when building packages.
+.DS_Store
+# Common VCS dirs
+.git/
+.gitignore
+# Common backup files
+*.swp
+*.bak
+*.tmp
+*~
+# Various IDEs
+.idea/
+.vscode/
+`
@@ -0,0 +1,204 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ExampleMarker is the marker of the fields of the API types whose value
+// fills in the samples, e.g. // +kubebuilder:example=nginx:1.17
+const ExampleMarker = "+kubebuilder:example="
+
+// SpecExamples returns the spec of a sample of kind, built from the example
+// markers of the fields of the <kind>Spec type of the Go package in dir. The
+// fields of the types of the package without a marker are filled in from the
+// markers of their own fields, a list holding a single element. It returns nil
+// if none of the fields have an example.
+func SpecExamples(dir, kind string) (map[string]interface{}, error) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	structs := map[string]*ast.StructType{}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					if st, ok := ts.Type.(*ast.StructType); ok {
+						structs[ts.Name.Name] = st
+					}
+				}
+			}
+		}
+	}
+
+	spec, found := structs[kind+"Spec"]
+	if !found {
+		return nil, fmt.Errorf("type %sSpec not found in %s", kind, dir)
+	}
+	e := &examples{structs: structs, visiting: map[string]bool{}}
+	obj, err := e.object(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%sSpec: %v", kind, err)
+	}
+	if len(obj) == 0 {
+		return nil, nil
+	}
+	return obj, nil
+}
+
+type examples struct {
+	structs map[string]*ast.StructType
+	// visiting holds the types being filled in, to stop at recursive types
+	visiting map[string]bool
+}
+
+// object returns the example of the given struct, keyed by the JSON names of
+// its fields.
+func (e *examples) object(st *ast.StructType) (map[string]interface{}, error) {
+	obj := map[string]interface{}{}
+	for _, field := range st.Fields.List {
+		name, inline := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		value, err := e.field(field)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		if value == nil {
+			continue
+		}
+		if !inline {
+			obj[name] = value
+			continue
+		}
+		embedded, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the example of an inlined field must be an object")
+		}
+		for k, v := range embedded {
+			obj[k] = v
+		}
+	}
+	return obj, nil
+}
+
+// field returns the example of the given field, nil if it has none.
+func (e *examples) field(field *ast.Field) (interface{}, error) {
+	if field.Doc != nil {
+		for _, c := range field.Doc.List {
+			text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			if !strings.HasPrefix(text, ExampleMarker) {
+				continue
+			}
+			var value interface{}
+			if err := yaml.Unmarshal([]byte(strings.TrimPrefix(text, ExampleMarker)), &value); err != nil {
+				return nil, fmt.Errorf("invalid example %q: %v", text, err)
+			}
+			return stringKeys(value), nil
+		}
+	}
+	return e.typ(field.Type)
+}
+
+// typ returns the example of a value of the given type, built from the
+// markers of the fields of the struct types of the package.
+func (e *examples) typ(expr ast.Expr) (interface{}, error) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return e.typ(t.X)
+	case *ast.ArrayType:
+		item, err := e.typ(t.Elt)
+		if item == nil || err != nil {
+			return nil, err
+		}
+		return []interface{}{item}, nil
+	case *ast.Ident:
+		st, found := e.structs[t.Name]
+		if !found || e.visiting[t.Name] {
+			return nil, nil
+		}
+		e.visiting[t.Name] = true
+		defer delete(e.visiting, t.Name)
+		obj, err := e.object(st)
+		if len(obj) == 0 || err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+	return nil, nil
+}
+
+// stringKeys converts the maps unmarshalled from YAML to maps of string keys,
+// as built from the fields of the structs.
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		obj := map[string]interface{}{}
+		for k, item := range v {
+			obj[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return obj
+	case []interface{}:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+	}
+	return value
+}
+
+// jsonName returns the name of the field in JSON, and whether its fields are
+// inlined in the object holding it.
+func jsonName(field *ast.Field) (string, bool) {
+	tag := ""
+	if field.Tag != nil {
+		tag = reflect.StructTag(strings.Trim(field.Tag.Value, "`")).Get("json")
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			return "", true
+		}
+	}
+	if parts[0] != "" {
+		return parts[0], false
+	}
+	if len(field.Names) == 0 {
+		// embedded fields without a name are inlined
+		return "", true
+	}
+	return field.Names[0].Name, false
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const examplesTypes = `package v1
+
+type Inline struct {
+	// +kubebuilder:example=blue
+	Color string ` + "`" + `json:"color"` + "`" + `
+}
+
+type Port struct {
+	// +kubebuilder:example=http
+	Name string ` + "`" + `json:"name"` + "`" + `
+	// +kubebuilder:example=8080
+	Port int32 ` + "`" + `json:"port"` + "`" + `
+}
+
+type Node struct {
+	// +kubebuilder:example=root
+	Name string ` + "`" + `json:"name"` + "`" + `
+	Children []Node ` + "`" + `json:"children,omitempty"` + "`" + `
+}
+
+type CaptainSpec struct {
+	Inline ` + "`" + `json:",inline"` + "`" + `
+
+	// Image is the image of the ship.
+	// +kubebuilder:example=nginx:1.17
+	Image string ` + "`" + `json:"image"` + "`" + `
+
+	// +optional
+	// +kubebuilder:example={env: prod, tier: "1"}
+	Labels map[string]string ` + "`" + `json:"labels,omitempty"` + "`" + `
+
+	Ports []Port ` + "`" + `json:"ports,omitempty"` + "`" + `
+	Tree *Node ` + "`" + `json:"tree,omitempty"` + "`" + `
+
+	Ignored string ` + "`" + `json:"-"` + "`" + `
+	Empty   string ` + "`" + `json:"empty,omitempty"` + "`" + `
+}
+
+type MateSpec struct {
+	Name string ` + "`" + `json:"name"` + "`" + `
+}
+`
+
+func TestSpecExamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "examples")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "types.go"), []byte(examplesTypes), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := SpecExamples(dir, "Captain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"color":  "blue",
+		"image":  "nginx:1.17",
+		"labels": map[string]interface{}{"env": "prod", "tier": "1"},
+		"ports":  []interface{}{map[string]interface{}{"name": "http", "port": 8080}},
+		"tree":   map[string]interface{}{"name": "root"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	got, err = SpecExamples(dir, "Mate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("expected no examples for a spec without markers, got %v", got)
+	}
+
+	if _, err := SpecExamples(dir, "Frigate"); err == nil {
+		t.Errorf("expected an error for a kind without spec")
+	}
+}
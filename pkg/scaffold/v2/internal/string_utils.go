@@ -20,11 +20,13 @@ import (
 	"bytes"
 	"io"
 	"io/ioutil"
-	"os"
 	"path/filepath"
 	"strings"
 
 	"golang.org/x/tools/imports"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
+	"sigs.k8s.io/kubebuilder/pkg/textfile"
 )
 
 // insertStrings reads content from given reader and insert string below the
@@ -69,23 +71,20 @@ func insertStrings(r io.Reader, markerAndValues map[string][]string) (io.Reader,
 	return out, nil
 }
 
+// InsertStringsInFile inserts the values below the line of their marker in the
+// file at path, leaving its byte order mark and line endings as they were.
 func InsertStringsInFile(path string, markerAndValues map[string][]string) error {
 	isGoFile := false
 	if ext := filepath.Ext(path); ext == ".go" {
 		isGoFile = true
 	}
 
-	f, err := os.Open(path)
+	original, format, err := textfile.ReadFile(path)
 	if err != nil {
 		return err
 	}
 
-	r, err := insertStrings(f, markerAndValues)
-	if err != nil {
-		return err
-	}
-
-	err = f.Close()
+	r, err := insertStrings(bytes.NewReader(original), markerAndValues)
 	if err != nil {
 		return err
 	}
@@ -103,15 +102,39 @@ func InsertStringsInFile(path string, markerAndValues map[string][]string) error
 		}
 	}
 
+	if err := rollback.Save(path); err != nil {
+		return err
+	}
 	// use Go import process to format the content
-	err = ioutil.WriteFile(path, formattedContent, os.ModePerm)
+	err = textfile.WriteFile(path, formattedContent, format, 0644)
 	if err != nil {
 		return err
 	}
 
+	// insertStrings dropped the values already present, so the markers left
+	// with values are the ones which received new code
+	for marker, vals := range markerAndValues {
+		if len(vals) > 0 && hasMarker(original, marker) {
+			result.MarkerInjected(path, strings.TrimSpace(marker))
+		}
+	}
+	if !bytes.Equal(original, formattedContent) {
+		result.FileModified(path)
+	}
+
 	return err
 }
 
+// hasMarker returns true if one of the lines of content is the given marker.
+func hasMarker(content []byte, marker string) bool {
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == strings.TrimSpace(marker) {
+			return true
+		}
+	}
+	return false
+}
+
 // filterExistingValues removes the single-line values that already exists in
 // the given reader. Multi-line values are ignore currently simply because we
 // don't have a use-case for it.
@@ -18,7 +18,12 @@ package internal
 import (
 	"bytes"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
 )
 
 type insertStrTest struct {
@@ -95,3 +100,85 @@ v1.AddToScheme(scheme)
 		}
 	}
 }
+
+func TestInsertStringsInFileRecordsResult(t *testing.T) {
+	dir, err := ioutil.TempDir("", "insert-strings")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "kustomization.yaml")
+	content := `resources:
+- bases/v1beta1.yaml
+# +kubebuilder:scaffold:resources
+patches:
+# +kubebuilder:scaffold:patches
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	result.Reset()
+	err = InsertStringsInFile(path, map[string][]string{
+		"# +kubebuilder:scaffold:resources": []string{"- bases/v1beta1.yaml\n", "- bases/v1.yaml\n"},
+		"# +kubebuilder:scaffold:patches":   []string{},
+		"# +kubebuilder:scaffold:missing":   []string{"- missing.yaml\n"},
+	})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	got := result.Get()
+	expected := []result.Marker{{File: path, Marker: "# +kubebuilder:scaffold:resources"}}
+	if !reflect.DeepEqual(got.MarkersInjected, expected) {
+		t.Errorf("got markers: %v and wanted: %v", got.MarkersInjected, expected)
+	}
+	if !reflect.DeepEqual(got.FilesModified, []string{path}) {
+		t.Errorf("got modified files: %v and wanted: %v", got.FilesModified, []string{path})
+	}
+
+	// inserting the same values again leaves the file untouched
+	result.Reset()
+	err = InsertStringsInFile(path, map[string][]string{
+		"# +kubebuilder:scaffold:resources": []string{"- bases/v1.yaml\n"},
+	})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	got = result.Get()
+	if len(got.MarkersInjected) != 0 || len(got.FilesModified) != 0 {
+		t.Errorf("got: %v and wanted an empty result", got)
+	}
+}
+
+func TestInsertStringsInFileKeepsFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "insert-strings")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a kustomization created on Windows, with a byte order mark and CRLF
+	path := filepath.Join(dir, "kustomization.yaml")
+	content := "\xEF\xBB\xBF# +kubebuilder:scaffold:resources\r\n- bases/v1beta1.yaml\r\n"
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	err = InsertStringsInFile(path, map[string][]string{
+		"# +kubebuilder:scaffold:resources": []string{"- bases/v1beta1.yaml\n", "- bases/v1.yaml\n"},
+	})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	expected := "\xEF\xBB\xBF- bases/v1.yaml\r\n# +kubebuilder:scaffold:resources\r\n- bases/v1beta1.yaml\r\n"
+	if string(b) != expected {
+		t.Errorf("got: %q and wanted: %q", string(b), expected)
+	}
+}
@@ -17,7 +17,7 @@ limitations under the License.
 package v2
 
 import (
-	"os"
+	"fmt"
 	"path/filepath"
 
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
@@ -31,6 +31,12 @@ type Kustomize struct {
 
 	// Prefix to use for name prefix customization
 	Prefix string
+
+	// Suffix to use for name suffix customization
+	Suffix string
+
+	// Namespace to deploy the resources to
+	Namespace string
 }
 
 // GetInput implements input.File
@@ -38,28 +44,37 @@ func (c *Kustomize) GetInput() (input.Input, error) {
 	if c.Path == "" {
 		c.Path = filepath.Join("config", "default", "kustomization.yaml")
 	}
+	var err error
 	if c.Prefix == "" {
-		// use directory name as prefix
-		dir, err := os.Getwd()
-		if err != nil {
+		if c.Prefix, err = c.GetNamePrefix(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	if c.Suffix == "" {
+		c.Suffix = c.NameSuffix
+	}
+	if c.Namespace == "" {
+		if c.Namespace, err = c.GetNamespace(); err != nil {
 			return input.Input{}, err
 		}
-		c.Prefix = filepath.Base(dir)
 	}
 	c.TemplateBody = kustomizeTemplate
 	c.Input.IfExistsAction = input.Error
 	return c.Input, nil
 }
 
-var kustomizeTemplate = `# Adds namespace to all resources.
-namespace: {{.Prefix}}-system
+var kustomizeTemplate = fmt.Sprintf(`# Adds namespace to all resources.
+namespace: {{.Namespace}}
 
 # Value of this field is prepended to the
 # names of all resources, e.g. a deployment named
 # "wordpress" becomes "alices-wordpress".
 # Note that it should also match with the prefix (text before '-') of the namespace
 # field above.
-namePrefix: {{.Prefix}}-
+namePrefix: {{.Prefix}}
+{{- if .Suffix }}
+nameSuffix: {{.Suffix}}
+{{- end }}
 
 # Labels to add to all resources and selectors.
 #commonLabels:
@@ -73,6 +88,10 @@ bases:
 #- ../webhook
 # [CERTMANAGER] To enable cert-manager, uncomment next line. 'WEBHOOK' components are required.
 #- ../certmanager
+# [PROMETHEUS] To enable prometheus monitor, run 'kubebuilder edit --observability' and uncomment next line.
+#- ../prometheus
+# [GRAFANA] To deploy the Grafana dashboards in a ConfigMap, run 'kubebuilder edit --grafana-dashboard' and uncomment next line.
+#- ../prometheus/dashboards
 
 patches:
 - manager_image_patch.yaml
@@ -94,4 +113,10 @@ patches:
 # Uncomment 'CAINJECTION' in crd/kustomization.yaml to enable the CA injection in the admission webhooks.
 # 'CERTMANAGER' needs to be enabled to use ca injection
 #- webhookcainjection_patch.yaml
-`
+
+# [COMPONENTS] To exclude the optional APIs, created with 'create api --optional',
+# from the deployment, uncomment the next line and the components of the APIs.
+# Components require kustomize v3.7.0 or later.
+#components:
+%s
+`, kustomizeComponentsScaffoldMarker)
@@ -18,9 +18,12 @@ package v2
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
+	"strings"
 
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
 	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/internal"
 )
@@ -29,6 +32,8 @@ const (
 	apiPkgImportScaffoldMarker    = "// +kubebuilder:scaffold:imports"
 	apiSchemeScaffoldMarker       = "// +kubebuilder:scaffold:scheme"
 	reconcilerSetupScaffoldMarker = "// +kubebuilder:scaffold:builder"
+	heartbeatScaffoldMarker       = "// +kubebuilder:scaffold:heartbeat"
+	webhookTLSScaffoldMarker      = "// +kubebuilder:scaffold:webhooktls"
 )
 
 var _ input.File = &Main{}
@@ -36,6 +41,33 @@ var _ input.File = &Main{}
 // Main scaffolds a main.go to run Controllers
 type Main struct {
 	input.Input
+
+	// Heartbeat indicates whether to wire the operator heartbeat
+	Heartbeat bool
+
+	// Settings indicates whether to wire the watch of the settings ConfigMap
+	Settings bool
+
+	// Capabilities indicates whether to wire the detection of the optional
+	// APIs served by the cluster
+	Capabilities bool
+
+	// ControllerUserAgents indicates whether the controllers talk to the API
+	// server with clients of their own user agent
+	ControllerUserAgents bool
+
+	// RemoteCluster indicates whether to wire a remote cluster, read from a
+	// kubeconfig Secret, and the example controller watching it
+	RemoteCluster bool
+
+	// ReconcileTimeout indicates whether to wire the timeout of the reconciles
+	ReconcileTimeout bool
+
+	// ReadOnly indicates whether to wire the read-only mode of the controllers
+	ReadOnly bool
+
+	// UserAgent is the default user agent of the manager, the project name
+	UserAgent string
 }
 
 // GetInput implements input.File
@@ -43,6 +75,13 @@ func (m *Main) GetInput() (input.Input, error) {
 	if m.Path == "" {
 		m.Path = filepath.Join("main.go")
 	}
+	if m.ControllerUserAgents && m.UserAgent == "" {
+		name, err := m.GetProjectName()
+		if err != nil {
+			return input.Input{}, err
+		}
+		m.UserAgent = name
+	}
 	m.TemplateBody = mainTemplate
 	return m.Input, nil
 }
@@ -52,19 +91,32 @@ func (m *Main) GetInput() (input.Input, error) {
 func (m *Main) Update(opts *MainUpdateOptions) error {
 	path := "main.go"
 
-	resPkg, _ := getResourceInfo(opts.Resource, input.Input{
-		Domain: opts.Project.Domain,
-		Repo:   opts.Project.Repo,
-	})
+	in := input.Input{
+		Domain:     opts.Project.Domain,
+		Repo:       opts.Project.Repo,
+		MultiGroup: opts.Project.MultiGroup,
+	}
+	resPkg, _ := getResourceInfo(opts.Resource, in)
 
 	// generate all the code fragments
 	apiImportCodeFragment := fmt.Sprintf(`%s%s "%s/%s"
 `, opts.Resource.Group, opts.Resource.Version, resPkg, opts.Resource.Version)
-	ctrlImportCodeFragment := fmt.Sprintf(`"%s/controllers"
-`, opts.Project.Repo)
+	// the webhooks are set up from the group version package, whatever the
+	// scheme registration strategy
+	webhookImportCodeFragment := apiImportCodeFragment
+	ctrlImport, ctrlPkg := controllersImport(opts.Resource, in)
+	ctrlImportCodeFragment := ctrlImport + "\n"
 	addschemeCodeFragment := fmt.Sprintf(`%s%s.AddToScheme(scheme)
 `, opts.Resource.Group, opts.Resource.Version)
-	reconcilerSetupCodeFragment := fmt.Sprintf(`err = (&controllers.%sReconciler{
+	if opts.Project.SchemeRegistration == project.SchemeRegistrationRegistry {
+		// the group version is added to api/scheme.go instead, so only the
+		// registry itself needs to be wired in main.go
+		apiImportCodeFragment = fmt.Sprintf(`"%s/api"
+`, opts.Project.Repo)
+		addschemeCodeFragment = `api.AddToScheme(scheme)
+`
+	}
+	reconcilerSetupCodeFragment := fmt.Sprintf(`err = (&%s.%sReconciler{
 	 	Client: mgr.GetClient(),
         Log: ctrl.Log.WithName("controllers").WithName("%s"),
 	 }).SetupWithManager(mgr)
@@ -72,29 +124,225 @@ func (m *Main) Update(opts *MainUpdateOptions) error {
 	 	setupLog.Error(err, "unable to create controller", "controller", "%s")
 	 	os.Exit(1)
 	 }
-`, opts.Resource.Kind, opts.Resource.Kind, opts.Resource.Kind)
-
-	if opts.WireResource {
-		err := internal.InsertStringsInFile(path,
-			map[string][]string{
-				apiPkgImportScaffoldMarker: []string{apiImportCodeFragment},
-				apiSchemeScaffoldMarker:    []string{addschemeCodeFragment},
-			})
+`, ctrlPkg, opts.Resource.Kind, opts.Resource.Kind, opts.Resource.Kind)
+	if opts.WireControllerClient {
+		clientVar := fmt.Sprintf("%s%sClient", opts.Resource.Group, opts.Resource.Kind)
+		controller := strings.ToLower(opts.Resource.Kind)
+		if in.MultiGroup {
+			// the controllers of the kinds of the same name in several groups
+			// need distinct user agents
+			controller = opts.Resource.Group + "-" + controller
+		}
+		reconcilerSetupCodeFragment = fmt.Sprintf(`%s, err := controllers.NewClient(mgr, "%s")
+	if err != nil {
+		setupLog.Error(err, "unable to create client", "controller", "%s")
+		os.Exit(1)
+	}
+	err = (&%s.%sReconciler{
+		Client: %s,
+		Log: ctrl.Log.WithName("controllers").WithName("%s"),
+	}).SetupWithManager(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "%s")
+		os.Exit(1)
+	}
+`, clientVar, controller, opts.Resource.Kind, ctrlPkg, opts.Resource.Kind,
+			clientVar, opts.Resource.Kind, opts.Resource.Kind)
+	}
+	if opts.WireControllerSettings {
+		reconcilerSetupCodeFragment = strings.Replace(reconcilerSetupCodeFragment,
+			"}).SetupWithManager(mgr)", "Settings: settings,\n\t}).SetupWithManager(mgr)", 1)
+	}
+	if opts.WireControllerReconcileTimeout {
+		reconcilerSetupCodeFragment = strings.Replace(reconcilerSetupCodeFragment,
+			"}).SetupWithManager(mgr)", "ReconcileTimeout: reconcileTimeout,\n\t}).SetupWithManager(mgr)", 1)
+	}
+	if opts.WireControllerReadOnly {
+		reconcilerSetupCodeFragment = strings.Replace(reconcilerSetupCodeFragment,
+			"}).SetupWithManager(mgr)", "ReadOnly: readOnly,\n\t}).SetupWithManager(mgr)", 1)
+	}
+	if opts.WireControllerHeartbeat {
+		reconcilerSetupCodeFragment = strings.Replace(reconcilerSetupCodeFragment,
+			"}).SetupWithManager(mgr)", "Heartbeat: heartbeat,\n\t}).SetupWithManager(mgr)", 1)
+	}
+	if opts.Resource.TransitionEvents {
+		recorder := strings.ToLower(opts.Resource.Kind) + "-controller"
+		if in.MultiGroup {
+			recorder = opts.Resource.Group + "-" + recorder
+		}
+		reconcilerSetupCodeFragment = strings.Replace(reconcilerSetupCodeFragment,
+			"}).SetupWithManager(mgr)",
+			fmt.Sprintf("Recorder: mgr.GetEventRecorderFor(%q),\n\t}).SetupWithManager(mgr)", recorder), 1)
+	}
+	if opts.Resource.Expectations {
+		// each controller tracks the expectations of its own objects
+		reconcilerSetupCodeFragment = strings.Replace(reconcilerSetupCodeFragment,
+			"}).SetupWithManager(mgr)",
+			fmt.Sprintf("Expectations: &%s.Expectations{},\n\t}).SetupWithManager(mgr)", ctrlPkg), 1)
+	}
+	if opts.Resource.CreationGuard {
+		// each controller caps the objects created by its reconciles with a
+		// guard of its own
+		reconcilerSetupCodeFragment = strings.Replace(reconcilerSetupCodeFragment,
+			"}).SetupWithManager(mgr)",
+			fmt.Sprintf("CreationGuard: &%s.CreationGuard{},\n\t}).SetupWithManager(mgr)", ctrlPkg), 1)
+	}
+	if opts.Resource.Optional {
+		// the controller of an optional API is skipped when the component
+		// excluding its CRD from the deployment is enabled
+		reconcilerSetupCodeFragment = fmt.Sprintf(`if os.Getenv("%s") == "false" {
+		setupLog.Info("skipping controller, its optional API is disabled", "controller", "%s")
+	} else {
+		%s
+	}
+`, OptionalAPIEnv(opts.Resource), opts.Resource.Kind, strings.TrimSpace(reconcilerSetupCodeFragment))
+	}
+	schemaImportCodeFragment := `"k8s.io/apimachinery/pkg/runtime/schema"
+`
+	if len(opts.RequiredAPIs) > 0 {
+		// the controller is only set up when the cluster serves the APIs it
+		// requires
+		gvks := []string{}
+		for _, api := range opts.RequiredAPIs {
+			gvks = append(gvks, fmt.Sprintf("schema.GroupVersionKind{Group: %q, Version: %q, Kind: %q}",
+				api.Group, api.Version, api.Kind))
+		}
+		reconcilerSetupCodeFragment = fmt.Sprintf(`if missing, err := caps.Missing(%s); err != nil {
+		setupLog.Error(err, "unable to detect the cluster capabilities", "controller", "%s")
+		os.Exit(1)
+	} else if len(missing) > 0 {
+		setupLog.Info("skipping controller, the cluster does not serve the APIs it requires",
+			"controller", "%s", "missing", missing)
+	} else {
+		%s
+	}
+`, strings.Join(gvks, ", "), opts.Resource.Kind, opts.Resource.Kind, strings.TrimSpace(reconcilerSetupCodeFragment))
+	}
+	// the webhooks are served with the TLS configuration of the flags of the
+	// tlsconfig package, scaffolded along the first webhook
+	webhookTLSImportCodeFragment := fmt.Sprintf(`"%s/tlsconfig"
+`, opts.Project.Repo)
+	heartbeatCodeFragment := fmt.Sprintf(`heartbeat.Register("%s")
+`, opts.Resource.Kind)
+	conversionWebhookRegistration := fmt.Sprintf(`mgr.GetWebhookServer().Register(%q, &conversion.Webhook{})`,
+		opts.ConversionWebhookPath)
+	conversionWebhookImportCodeFragment := `"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+`
+	conversionWebhookSetupCodeFragment := fmt.Sprintf(`if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		%s
+	}
+`, conversionWebhookRegistration)
+
+	// the fragments of all the wired code are inserted at once, main.go
+	// being read, formatted and written a single time
+	var content []byte
+	if opts.WiresWebhooks() || opts.WireCertRotator || opts.WireController {
+		var err error
+		content, err = ioutil.ReadFile(path) // nolint: gosec
 		if err != nil {
 			return err
 		}
 	}
+	f := fragments{}
+
+	if opts.WireResource {
+		f.add(apiPkgImportScaffoldMarker, apiImportCodeFragment)
+		f.add(apiSchemeScaffoldMarker, addschemeCodeFragment)
+	}
+
+	// the webhooks of the resource and the method of its type setting each
+	// up with the manager
+	webhooks := []struct {
+		wire  bool
+		setup string
+	}{
+		{opts.WireWebhook, "SetupWebhookWithManager"},
+		{opts.WireReportOnlyWebhook, "SetupReportOnlyWebhookWithManager"},
+		{opts.WireReferenceWebhook, "SetupReferenceWebhookWithManager"},
+		{opts.WirePayloadWebhook, "SetupPayloadWebhookWithManager"},
+		{opts.WireDeletionProtectionWebhook, "SetupDeletionProtectionWebhookWithManager"},
+		{opts.WireQuotaWebhook, "SetupQuotaWebhookWithManager"},
+		{opts.WirePolicyWebhook, "SetupPolicyWebhookWithManager"},
+	}
+	for _, w := range webhooks {
+		if w.wire {
+			f.add(apiPkgImportScaffoldMarker, webhookImportCodeFragment, webhookTLSImportCodeFragment)
+			f.add(reconcilerSetupScaffoldMarker, webhookSetupCodeFragment(opts.Resource, w.setup))
+		}
+	}
+
+	// a single conversion webhook serves all the CRDs converted at the same
+	// path, so it is only registered once
+	if opts.WireConversionWebhook && !strings.Contains(string(content), conversionWebhookRegistration) {
+		f.add(apiPkgImportScaffoldMarker, conversionWebhookImportCodeFragment, webhookTLSImportCodeFragment)
+		f.add(reconcilerSetupScaffoldMarker, conversionWebhookSetupCodeFragment)
+	}
+
+	// all the webhooks are served by a single TLS server
+	if opts.WiresWebhooks() && !strings.Contains(string(content), webhookTLSSetup) {
+		f.add(webhookTLSScaffoldMarker, webhookTLSCodeFragment)
+	}
+
+	// the certificate is provisioned once for all the webhooks
+	if opts.WireCertRotator && !strings.Contains(string(content), certRotatorSetup) {
+		f.add(apiPkgImportScaffoldMarker, fmt.Sprintf(`"%s/certrotator"
+`, opts.Project.Repo))
+		f.add(webhookTLSScaffoldMarker, certRotatorCodeFragment)
+	}
 
 	if opts.WireController {
-		return internal.InsertStringsInFile(path,
-			map[string][]string{
-				apiPkgImportScaffoldMarker:    []string{apiImportCodeFragment, ctrlImportCodeFragment},
-				apiSchemeScaffoldMarker:       []string{addschemeCodeFragment},
-				reconcilerSetupScaffoldMarker: []string{reconcilerSetupCodeFragment},
-			})
+		f.add(apiPkgImportScaffoldMarker, apiImportCodeFragment, ctrlImportCodeFragment)
+		if len(opts.RequiredAPIs) > 0 {
+			f.add(apiPkgImportScaffoldMarker, schemaImportCodeFragment)
+		}
+		f.add(apiSchemeScaffoldMarker, addschemeCodeFragment)
+		// the controller of a resource regenerated with --force is already
+		// set up, and left as it is
+		if strings.Contains(string(content), fmt.Sprintf("&%s.%sReconciler{", ctrlPkg, opts.Resource.Kind)) {
+			opts.ControllerSetUp = true
+		} else {
+			f.add(reconcilerSetupScaffoldMarker, reconcilerSetupCodeFragment)
+		}
+		// only present in projects initialized with the heartbeat
+		f.add(heartbeatScaffoldMarker, heartbeatCodeFragment)
 	}
 
-	return nil
+	if len(f) == 0 {
+		return nil
+	}
+	return internal.InsertStringsInFile(path, f)
+}
+
+// webhookSetupCodeFragment returns the code of main.go setting up a webhook
+// of the resource with the given method of its type, unless the webhooks are
+// disabled.
+func webhookSetupCodeFragment(r *resource.Resource, setup string) string {
+	return fmt.Sprintf(`if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&%s%s.%s{}).%s(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "%s")
+			os.Exit(1)
+		}
+	}
+`, r.Group, r.Version, r.Kind, setup, r.Kind)
+}
+
+// fragments are the code fragments to insert in a file, by marker.
+type fragments map[string][]string
+
+// add appends the values to the fragments of the marker, once each.
+func (f fragments) add(marker string, values ...string) {
+	for _, v := range values {
+		found := false
+		for _, existing := range f[marker] {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			f[marker] = append(f[marker], v)
+		}
+	}
 }
 
 // MainUpdateOptions contains info required for wiring an API/Controller in
@@ -109,8 +357,109 @@ type MainUpdateOptions struct {
 	// Flags to indicate if resource/controller is being scaffolded or not
 	WireResource   bool
 	WireController bool
+
+	// WireControllerClient indicates whether the controller is given a client
+	// of its own user agent, created with controllers.NewClient
+	WireControllerClient bool
+
+	// WireControllerSettings indicates whether the controller is given the
+	// watch of the settings ConfigMap, to reconcile its objects on its changes
+	WireControllerSettings bool
+
+	// WireControllerReconcileTimeout indicates whether the controller is given
+	// the reconcile timeout of the flags
+	WireControllerReconcileTimeout bool
+
+	// WireControllerReadOnly indicates whether the controller is given the
+	// read-only mode of the flags
+	WireControllerReadOnly bool
+
+	// WireControllerHeartbeat indicates whether the controller is given the
+	// heartbeat of the operator, to report its last reconcile time
+	WireControllerHeartbeat bool
+
+	// RequiredAPIs are the APIs the controller is only set up with when the
+	// cluster serves them, detected with the capabilities of main.go
+	RequiredAPIs []RequiredAPI
+
+	// ControllerSetUp is set by Update when main.go already sets up the
+	// controller, which is then left as it is
+	ControllerSetUp bool
+
+	// WireWebhook indicates whether to register the defaulting and validating
+	// webhooks of the resource with the manager's webhook server
+	WireWebhook bool
+
+	// WireReportOnlyWebhook indicates whether to register the report-only
+	// validating webhook of the resource with the manager's webhook server
+	WireReportOnlyWebhook bool
+
+	// WireReferenceWebhook indicates whether to register the webhook validating
+	// the references of the resource with the manager's webhook server
+	WireReferenceWebhook bool
+
+	// WirePayloadWebhook indicates whether to register the webhook validating
+	// the payload references of the resource with the manager's webhook server
+	WirePayloadWebhook bool
+
+	// WireDeletionProtectionWebhook indicates whether to register the webhook
+	// protecting the objects of the resource from deletion with the manager's
+	// webhook server
+	WireDeletionProtectionWebhook bool
+
+	// WireQuotaWebhook indicates whether to register the webhook enforcing the
+	// quota of the resource with the manager's webhook server
+	WireQuotaWebhook bool
+
+	// WirePolicyWebhook indicates whether to register the webhook delegating
+	// the admission of the resource to a policy engine with the manager's
+	// webhook server
+	WirePolicyWebhook bool
+
+	// WireConversionWebhook indicates whether to register the conversion
+	// webhook with the manager's webhook server, at ConversionWebhookPath
+	WireConversionWebhook bool
+
+	// ConversionWebhookPath is the path the conversion webhook is served at
+	ConversionWebhookPath string
+
+	// WireCertRotator indicates whether to provision the self-signed webhook
+	// serving certificate before the webhooks are served
+	WireCertRotator bool
+}
+
+// WiresWebhooks returns whether any webhook is wired, the webhooks being served
+// with the TLS configuration of the flags of the tlsconfig package.
+func (opts *MainUpdateOptions) WiresWebhooks() bool {
+	return opts.WireWebhook || opts.WireReportOnlyWebhook || opts.WireReferenceWebhook ||
+		opts.WirePayloadWebhook || opts.WireDeletionProtectionWebhook || opts.WireQuotaWebhook ||
+		opts.WirePolicyWebhook || opts.WireConversionWebhook
 }
 
+const certRotatorSetup = "certrotator.Setup(mgr)"
+
+var certRotatorCodeFragment = fmt.Sprintf(`if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		// the webhook serving certificate is issued before the webhook
+		// servers start, then renewed by the manager
+		if err = %s; err != nil {
+			setupLog.Error(err, "unable to provision the webhook serving certificate")
+			os.Exit(1)
+		}
+	}
+`, certRotatorSetup)
+
+const webhookTLSSetup = `tlsconfig.ServeWebhooks(mgr, ":9443")`
+
+var webhookTLSCodeFragment = fmt.Sprintf(`if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		// the webhooks are served on the unprivileged port 9443, the manager
+		// runs as non-root
+		if err = %s; err != nil {
+			setupLog.Error(err, "unable to serve the webhooks")
+			os.Exit(1)
+		}
+	}
+`, webhookTLSSetup)
+
 var mainTemplate = fmt.Sprintf(`{{ .Boilerplate }}
 
 package main
@@ -123,7 +472,12 @@ import (
     ctrl "sigs.k8s.io/controller-runtime"
     "sigs.k8s.io/controller-runtime/pkg/log/zap"
     "k8s.io/apimachinery/pkg/runtime"
-
+{{- if or .Heartbeat .ControllerUserAgents .Settings .RemoteCluster }}
+	"{{ .Repo }}/controllers"
+{{- end }}
+{{- if .Capabilities }}
+	"{{ .Repo }}/capabilities"
+{{- end }}
 
 	%s
 )
@@ -141,17 +495,88 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var kubeAPIQPS float64
+	var kubeAPIBurst int
+{{- if .ControllerUserAgents }}
+	var userAgent string
+{{- end }}
+{{- if .Heartbeat }}
+	var heartbeatName, heartbeatNamespace string
+	var heartbeatInterval time.Duration
+{{- end }}
+{{- if .Settings }}
+	var settingsName, settingsNamespace string
+{{- end }}
+{{- if .ReconcileTimeout }}
+	var reconcileTimeout time.Duration
+{{- end }}
+{{- if .ReadOnly }}
+	var readOnly bool
+{{- end }}
+{{- if .RemoteCluster }}
+	var remoteKubeconfigSecret string
+{{- end }}
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 0,
+		"The maximum queries per second from the manager to the Kubernetes API server. Zero uses the client-go default.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 0,
+		"The maximum burst of queries from the manager to the Kubernetes API server. Zero uses the client-go default.")
+{{- if .ControllerUserAgents }}
+	flag.StringVar(&userAgent, "user-agent", "{{ .UserAgent }}",
+		"The user agent of the manager. The clients of the controllers append /<controller> to it.")
+{{- end }}
+{{- if .Heartbeat }}
+	flag.StringVar(&heartbeatName, "heartbeat-name", "controller-manager-heartbeat",
+		"The name of the ConfigMap the operator heartbeat is written to.")
+	flag.StringVar(&heartbeatNamespace, "heartbeat-namespace", "",
+		"The namespace of the heartbeat ConfigMap. Defaults to the namespace the manager runs in, "+
+			"or default outside of a cluster.")
+	flag.DurationVar(&heartbeatInterval, "heartbeat-interval", time.Minute, "How often the operator heartbeat is written.")
+{{- end }}
+{{- if .Settings }}
+	flag.StringVar(&settingsName, "settings-name", "controller-manager-settings",
+		"The name of the ConfigMap the operator settings are read from.")
+	flag.StringVar(&settingsNamespace, "settings-namespace", "",
+		"The namespace of the settings ConfigMap. Defaults to the namespace the manager runs in, "+
+			"or default outside of a cluster.")
+{{- end }}
+{{- if .ReconcileTimeout }}
+	flag.DurationVar(&reconcileTimeout, "reconcile-timeout", 2*time.Minute,
+		"The maximum duration of a reconcile, whose context is canceled past it. Zero disables the timeout.")
+{{- end }}
+{{- if .ReadOnly }}
+	flag.BoolVar(&readOnly, "read-only", false,
+		"Enable the read-only mode, under which the controllers write the status of the objects but never create, update or delete them.")
+{{- end }}
+{{- if .RemoteCluster }}
+	flag.StringVar(&remoteKubeconfigSecret, "remote-kubeconfig-secret", "",
+		"The <namespace>/<name> of the Secret holding the kubeconfig of the remote cluster. Empty disables the remote cluster.")
+{{- end }}
 	flag.Parse()
 
 	ctrl.SetLogger(zap.Logger(true))
+{{- if .ReadOnly }}
+	if readOnly {
+		setupLog.Info("the controllers run in read-only mode, only writing the status of the objects")
+	}
+{{- end }}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	cfg := ctrl.GetConfigOrDie()
+	cfg.QPS = float32(kubeAPIQPS)
+	cfg.Burst = kubeAPIBurst
+{{- if .ControllerUserAgents }}
+	cfg.UserAgent = userAgent
+	controllers.ShareRateLimiter(cfg)
+{{- end }}
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		LeaderElection:     enableLeaderElection,
+		// Serve the webhooks on an unprivileged port, the manager runs as non-root
+		Port: 9443,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -159,12 +584,87 @@ func main() {
 	}
 
 
+{{ if .Heartbeat }}
+	heartbeat := &controllers.Heartbeat{
+		Client:    mgr.GetClient(),
+		Reader:    mgr.GetAPIReader(),
+		Log:       ctrl.Log.WithName("heartbeat"),
+		Name:      heartbeatName,
+		Namespace: heartbeatNamespace,
+		Interval:  heartbeatInterval,
+	}
+{{ end }}
+{{- if .Capabilities }}
+	// the controllers requiring optional APIs are only set up when the cluster
+	// serves them
+	caps, err := capabilities.New(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create the capabilities")
+		os.Exit(1)
+	}
+	missing, err := caps.Missing(capabilities.ServiceMonitor, capabilities.Route, capabilities.Gateway)
+	if err != nil {
+		setupLog.Error(err, "unable to detect the cluster capabilities")
+		os.Exit(1)
+	}
+	setupLog.Info("detected the cluster capabilities", "missing", missing)
+{{ end }}
+{{- if .Settings }}
+	// the controllers are reconfigured on the changes of the settings
+	// ConfigMap, without restarting the manager
+	settings := &controllers.Settings{
+		Config:    mgr.GetConfig(),
+		Log:       ctrl.Log.WithName("settings"),
+		Name:      settingsName,
+		Namespace: settingsNamespace,
+	}
+	if err = mgr.Add(settings); err != nil {
+		setupLog.Error(err, "unable to add settings")
+		os.Exit(1)
+	}
+{{ end }}
+{{- if .RemoteCluster }}
+	// the controllers of the remote cluster watch it through a cache of its
+	// own, started by the manager
+	if remoteKubeconfigSecret != "" {
+		remote, err := controllers.NewRemoteClusterFromSecret(context.Background(), mgr.GetAPIReader(),
+			"remote", remoteKubeconfigSecret, scheme)
+		if err != nil {
+			setupLog.Error(err, "unable to create the remote cluster")
+			os.Exit(1)
+		}
+		if err = mgr.Add(remote); err != nil {
+			setupLog.Error(err, "unable to add the remote cluster")
+			os.Exit(1)
+		}
+		err = (&controllers.RemoteConfigMapReconciler{
+			Client: mgr.GetClient(),
+			Remote: remote,
+			Log:    ctrl.Log.WithName("controllers").WithName("RemoteConfigMap"),
+		}).SetupWithManager(mgr)
+		if err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "RemoteConfigMap")
+			os.Exit(1)
+		}
+	}
+{{ end }}
     %s
 
+	%s
+{{- if .Heartbeat }}
+
+	%s
+	if err = mgr.Add(heartbeat); err != nil {
+		setupLog.Error(err, "unable to add heartbeat")
+		os.Exit(1)
+	}
+{{- end }}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
-`, apiPkgImportScaffoldMarker, apiSchemeScaffoldMarker, reconcilerSetupScaffoldMarker)
+`, apiPkgImportScaffoldMarker, apiSchemeScaffoldMarker, reconcilerSetupScaffoldMarker, webhookTLSScaffoldMarker,
+	heartbeatScaffoldMarker)
@@ -47,6 +47,26 @@ var makefileTemplate = `
 IMG ?= {{ .Image }}
 # Produce CRDs that work back to Kubernetes 1.11 (no version conversion)
 CRD_OPTIONS ?= "crd:trivialVersions=true"
+# Header of the Go files generated by the code generators, as of the ones
+# scaffolded by kubebuilder
+BOILERPLATE ?= {{ .BoilerplatePath }}
+# Platforms to build the image for with docker-buildx
+PLATFORMS ?= linux/amd64,linux/arm64
+# Output of docker-buildx, e.g. --output=type=docker,dest=image.tar for a single platform,
+# the images are only kept in the build cache otherwise
+BUILDX_OUTPUT ?=
+# docker buildx builder building the images of several platforms, which the
+# builder of the docker driver cannot
+BUILDX_BUILDER ?= kubebuilder
+# kubebuilder binary generating the samples from the example markers of the API types
+KUBEBUILDER ?= kubebuilder
+# Time given to the controllers to finalize the CRs by make uninstall-safe
+CLEANUP_TIMEOUT ?= 60s
+# Version and channels of the OLM bundle of the operator
+VERSION ?= 0.1.0
+BUNDLE_CHANNELS ?= alpha
+# Image URL of the bundle image
+BUNDLE_IMG ?= controller-bundle:$(VERSION)
 
 # Get the currently used golang install path (in GOPATH/bin, unless GOBIN is set)
 ifeq (,$(shell go env GOBIN))
@@ -73,15 +93,53 @@ run: generate fmt vet
 install: manifests
 	kubectl apply -f config/crd/bases
 
+# Uninstall CRDs from a cluster, deleting their CRs first so that neither the
+# CRDs nor the namespaces get stuck on the finalizers of the CRs
+uninstall-safe: manifests
+	CLEANUP_TIMEOUT=$(CLEANUP_TIMEOUT) sh config/uninstall/cleanup.sh \
+		$$(kubectl get -f config/crd/bases -o name --ignore-not-found | cut -d/ -f2)
+
 # Deploy controller in the configured Kubernetes cluster in ~/.kube/config
 deploy: manifests
 	kubectl apply -f config/crd/bases
 	kustomize build config/default | kubectl apply -f -
 
+# Generate the templates and CRDs of the Helm chart of the project from config/default
+helm: manifests
+	kustomize build config/default | $(KUBEBUILDER) alpha helm
+
 # Generate manifests e.g. CRD, RBAC etc.
 manifests: controller-gen
 	$(CONTROLLER_GEN) $(CRD_OPTIONS) rbac:roleName=manager-role webhook paths="./..." output:crd:artifacts:config=config/crd/bases
 
+# Generate the samples of config/samples from the example markers of the API types
+samples:
+	$(KUBEBUILDER) alpha samples
+
+# Generate the OLM bundle of the operator under bundle/ from config/default
+bundle: manifests
+	kustomize build config/default | $(KUBEBUILDER) create bundle --version $(VERSION) --channels $(BUNDLE_CHANNELS)
+
+# Build the bundle image
+bundle-build:
+	docker build -f bundle.Dockerfile -t $(BUNDLE_IMG) .
+
+# Push the bundle image
+bundle-push:
+	docker push $(BUNDLE_IMG)
+
+# Generate the OpenAPI document of the CRDs and its Swagger UI page under docs/api
+api-docs: manifests
+	$(KUBEBUILDER) alpha api-docs
+
+# Serve the API docs at http://localhost:8082 to browse them without a cluster
+serve-api-docs: manifests
+	$(KUBEBUILDER) alpha api-docs --serve localhost:8082
+
+# Convert the samples of the conversion hubs to the other versions of their kinds
+convert-samples:
+	$(KUBEBUILDER) alpha convert-samples
+
 # Run go fmt against code
 fmt:
 	go fmt ./...
@@ -90,9 +148,13 @@ fmt:
 vet:
 	go vet ./...
 
+# Check the RBAC markers against the resources the code uses
+verify-rbac:
+	$(KUBEBUILDER) alpha verify-rbac ./...
+
 # Generate code
 generate: controller-gen
-	$(CONTROLLER_GEN) object:headerFile=./hack/boilerplate.go.txt paths=./api/...
+	$(CONTROLLER_GEN) object:headerFile=$(BOILERPLATE) paths=./api/...
 
 # Build the docker image
 docker-build: test
@@ -104,6 +166,23 @@ docker-build: test
 docker-push:
 	docker push ${IMG}
 
+# Build the docker image for the platforms of PLATFORMS with docker buildx
+docker-buildx: test buildx-builder
+	docker buildx build . --builder ${BUILDX_BUILDER} --platform ${PLATFORMS} -t ${IMG} ${BUILDX_OUTPUT}
+	@echo "updating kustomize image patch file for manager resource"
+	sed -i'' -e 's@image: .*@image: '"${IMG}"'@' ./config/default/manager_image_patch.yaml
+
+# Push the images built by docker-buildx under a manifest list, from which each
+# node pulls the image of its platform
+docker-buildx-push: buildx-builder
+	docker buildx build . --builder ${BUILDX_BUILDER} --platform ${PLATFORMS} -t ${IMG} --push
+	docker buildx imagetools inspect ${IMG}
+
+# create the docker buildx builder if necessary
+buildx-builder:
+	docker buildx inspect ${BUILDX_BUILDER} >/dev/null 2>&1 || \
+		docker buildx create --name ${BUILDX_BUILDER} --driver docker-container
+
 # find or download controller-gen
 # download controller-gen if necessary
 controller-gen:
@@ -29,6 +29,9 @@ type Config struct {
 	input.Input
 	// Image is controller manager image name
 	Image string
+
+	// ReadOnly indicates whether to pass the --read-only flag to the manager
+	ReadOnly bool
 }
 
 // GetInput implements input.File
@@ -64,13 +67,32 @@ spec:
       labels:
         control-plane: controller-manager
     spec:
+      # Run the pods as allowed by the restricted Pod Security Standard
+      securityContext:
+        runAsNonRoot: true
+        seccompProfile:
+          type: RuntimeDefault
       containers:
       - command:
         - /manager
         args:
         - --enable-leader-election
+{{- if .ReadOnly }}
+        # set to true for the controllers to only write the status of the
+        # objects, e.g. during an incident or a migration
+        - --read-only=false
+{{- end }}
+        # +kubebuilder:scaffold:managerargs
         image: {{ .Image }}
         name: manager
+        # The last lines of the logs are the termination message of a manager
+        # crashing without writing one, shown in the status of the pod
+        terminationMessagePolicy: FallbackToLogsOnError
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop:
+            - ALL
         resources:
           limits:
             cpu: 100m
@@ -28,6 +28,9 @@ var _ input.File = &KustomizeAuthProxyPatch{}
 // prometheus metrics for manager Pod.
 type KustomizeAuthProxyPatch struct {
 	input.Input
+
+	// ReadOnly indicates whether to pass the --read-only flag to the manager
+	ReadOnly bool
 }
 
 // GetInput implements input.File
@@ -52,16 +55,33 @@ spec:
     spec:
       containers:
       - name: kube-rbac-proxy
-        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.4.0
+        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.4.1
         args:
-        - "--secure-listen-address=0.0.0.0:8443"
-        - "--upstream=http://127.0.0.1:8080/"
+        - "--secure-listen-address=:8443"
+        - "--upstream=http://localhost:8080/"
+        # the TLS configuration the metrics are served with, which should match
+        # the --tls-min-version and --tls-cipher-suites flags of the manager
+        - "--tls-min-version=VersionTLS12"
+        #- "--tls-cipher-suites=TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"
         - "--logtostderr=true"
         - "--v=10"
         ports:
         - containerPort: 8443
           name: https
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop:
+            - ALL
+          # the image runs as a user the kubelet cannot verify is not root
+          runAsUser: 65532
       - name: manager
         args:
-        - "--metrics-addr=127.0.0.1:8080"
+        - "--metrics-addr=localhost:8080"
+{{- if .ReadOnly }}
+        # set to true for the controllers to only write the status of the
+        # objects, e.g. during an incident or a migration
+        - "--read-only=false"
+{{- end }}
+        # +kubebuilder:scaffold:managerargs
 `
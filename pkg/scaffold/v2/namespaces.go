@@ -0,0 +1,378 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &Namespaces{}
+
+// Namespaces scaffolds the controllers/namespaces.go file, which creates the
+// namespaces of the CRs of the namespace-per-tenant operators, recording their
+// owner, and deletes them once they hold no resources of others.
+type Namespaces struct {
+	input.Input
+
+	// Operator is the value of the label of the namespaces created by the
+	// operator, the project name
+	Operator string
+}
+
+// GetInput implements input.File
+func (n *Namespaces) GetInput() (input.Input, error) {
+	if n.Path == "" {
+		n.Path = filepath.Join("controllers", "namespaces.go")
+	}
+	if n.Operator == "" {
+		name, err := n.GetProjectName()
+		if err != nil {
+			return input.Input{}, err
+		}
+		n.Operator = name
+	}
+	n.TemplateBody = namespacesTemplate
+	n.Input.IfExistsAction = input.Error
+	return n.Input, nil
+}
+
+var namespacesTemplate = `{{ .Boilerplate }}
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const (
+	// NamespaceManagedByLabel labels the namespaces created by the operator,
+	// and the objects it creates in them, with the name of the operator.
+	NamespaceManagedByLabel = "{{ .Domain }}/managed-by"
+
+	// The annotations recording the owner of a namespace created by the
+	// operator. The owner is <namespace>/<name>, or <name> for cluster-scoped
+	// owners.
+	NamespaceOwnerAPIVersionAnnotation = "{{ .Domain }}/owner-api-version"
+	NamespaceOwnerKindAnnotation       = "{{ .Domain }}/owner-kind"
+	NamespaceOwnerAnnotation           = "{{ .Domain }}/owner"
+	NamespaceOwnerUIDAnnotation        = "{{ .Domain }}/owner-uid"
+
+	// NamespaceOperator is the value of NamespaceManagedByLabel.
+	NamespaceOperator = "{{ .Operator }}"
+)
+
+// DefaultForeignKinds are the kinds of the objects whose presence in a
+// namespace, without NamespaceManagedByLabel, protects it from deletion.
+var DefaultForeignKinds = []schema.GroupVersionKind{
+	{Version: "v1", Kind: "Pod"},
+	{Version: "v1", Kind: "Service"},
+	{Version: "v1", Kind: "ConfigMap"},
+	{Version: "v1", Kind: "Secret"},
+	{Version: "v1", Kind: "PersistentVolumeClaim"},
+	{Version: "v1", Kind: "ServiceAccount"},
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	{Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	{Group: "batch", Version: "v1", Kind: "Job"},
+}
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods;services;configmaps;secrets;persistentvolumeclaims;serviceaccounts,verbs=list
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=list
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=list
+
+// ForeignResourcesError is returned when a namespace is not deleted because
+// it holds objects the operator did not create.
+type ForeignResourcesError struct {
+	Namespace string
+	// Objects are some of the foreign objects, as <Kind>/<name>
+	Objects []string
+}
+
+func (e *ForeignResourcesError) Error() string {
+	return fmt.Sprintf("namespace %s holds objects not created by the operator: %s",
+		e.Namespace, strings.Join(e.Objects, ", "))
+}
+
+// Namespaces creates the namespaces of the CRs of the operator, labelled with
+// NamespaceManagedByLabel and annotated with their owner, since a namespace
+// cannot have an owner reference to a namespaced object. It deletes them once
+// released by their owner, or once their owner is gone, unless they hold
+// objects the operator did not create. Label the objects created in the
+// namespaces with ManagedLabels so that they do not protect them.
+type Namespaces struct {
+	// Client is used to create and delete the namespaces
+	Client client.Client
+	// Reader is used to read the namespaces, their objects and owners without
+	// starting informers, e.g. the API reader of the manager
+	Reader client.Reader
+	// Scheme is used to find the kinds of the owners
+	Scheme *runtime.Scheme
+	Log    logr.Logger
+
+	// ForeignKinds are the kinds of the objects protecting the namespaces from
+	// deletion, DefaultForeignKinds when empty
+	ForeignKinds []schema.GroupVersionKind
+	// Interval is how often the namespaces of the owners which are gone are
+	// collected
+	Interval time.Duration
+}
+
+// ManagedLabels returns the labels of the objects created by the operator in
+// its namespaces.
+func ManagedLabels() map[string]string {
+	return map[string]string{NamespaceManagedByLabel: NamespaceOperator}
+}
+
+// Ensure returns the namespace of the given name owned by owner, creating it
+// if it does not exist. It fails if the namespace is not owned by owner.
+func (n *Namespaces) Ensure(ctx context.Context, owner runtime.Object, name string) (*corev1.Namespace, error) {
+	annotations, err := n.ownerAnnotations(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	ns := &corev1.Namespace{}
+	err = n.Reader.Get(ctx, types.NamespacedName{Name: name}, ns)
+	if apierrors.IsNotFound(err) {
+		ns = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      ManagedLabels(),
+				Annotations: annotations,
+			},
+		}
+		return ns, n.Client.Create(ctx, ns)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ownedBy(ns, annotations) {
+		return nil, fmt.Errorf("namespace %s is not owned by %s %s", name,
+			annotations[NamespaceOwnerKindAnnotation], annotations[NamespaceOwnerAnnotation])
+	}
+	if ns.DeletionTimestamp != nil {
+		return nil, fmt.Errorf("namespace %s is being deleted", name)
+	}
+	return ns, nil
+}
+
+// Release deletes the namespace of the given name owned by owner, unless it
+// holds foreign objects, in which case it returns a *ForeignResourcesError.
+// The namespaces which do not exist or are not owned by owner are left alone.
+func (n *Namespaces) Release(ctx context.Context, owner runtime.Object, name string) error {
+	annotations, err := n.ownerAnnotations(owner)
+	if err != nil {
+		return err
+	}
+	ns := &corev1.Namespace{}
+	if err := n.Reader.Get(ctx, types.NamespacedName{Name: name}, ns); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !ownedBy(ns, annotations) {
+		return nil
+	}
+	return n.delete(ctx, ns)
+}
+
+// CollectGarbage deletes the namespaces created by the operator whose owner is
+// gone, except the ones holding foreign objects.
+func (n *Namespaces) CollectGarbage(ctx context.Context) error {
+	namespaces := &corev1.NamespaceList{}
+	err := n.Reader.List(ctx, namespaces, client.MatchingLabels(ManagedLabels()))
+	if err != nil {
+		return err
+	}
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		if ns.DeletionTimestamp != nil {
+			continue
+		}
+		gone, err := n.ownerGone(ctx, ns)
+		if err != nil {
+			return err
+		}
+		if !gone {
+			continue
+		}
+		if err := n.delete(ctx, ns); err != nil {
+			if _, foreign := err.(*ForeignResourcesError); !foreign {
+				return err
+			}
+			n.Log.Info("keeping the namespace of a deleted owner", "reason", err.Error())
+			continue
+		}
+		n.Log.Info("deleted the namespace of a deleted owner", "namespace", ns.Name)
+	}
+	return nil
+}
+
+// Start implements manager.Runnable, collecting the namespaces of the owners
+// which are gone until stop is closed.
+func (n *Namespaces) Start(stop <-chan struct{}) error {
+	if n.Interval <= 0 {
+		n.Interval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(n.Interval)
+	defer ticker.Stop()
+	for {
+		if err := n.CollectGarbage(context.Background()); err != nil {
+			n.Log.Error(err, "unable to collect the namespaces")
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// ownerAnnotations returns the annotations recording owner.
+func (n *Namespaces) ownerAnnotations(owner runtime.Object) (map[string]string, error) {
+	gvk, err := apiutil.GVKForObject(owner, n.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	meta, ok := owner.(metav1.Object)
+	if !ok {
+		return nil, fmt.Errorf("%s has no object metadata", gvk)
+	}
+	name := meta.GetName()
+	if meta.GetNamespace() != "" {
+		name = meta.GetNamespace() + "/" + name
+	}
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	return map[string]string{
+		NamespaceOwnerAPIVersionAnnotation: apiVersion,
+		NamespaceOwnerKindAnnotation:       kind,
+		NamespaceOwnerAnnotation:           name,
+		NamespaceOwnerUIDAnnotation:        string(meta.GetUID()),
+	}, nil
+}
+
+// ownedBy returns true if the namespace was created by the operator for the
+// owner of the given annotations.
+func ownedBy(ns *corev1.Namespace, owner map[string]string) bool {
+	if ns.Labels[NamespaceManagedByLabel] != NamespaceOperator {
+		return false
+	}
+	for key, value := range owner {
+		if ns.Annotations[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ownerGone returns true if the owner recorded by the namespace does not
+// exist, or has been recreated.
+func (n *Namespaces) ownerGone(ctx context.Context, ns *corev1.Namespace) (bool, error) {
+	owner := &unstructured.Unstructured{}
+	owner.SetAPIVersion(ns.Annotations[NamespaceOwnerAPIVersionAnnotation])
+	owner.SetKind(ns.Annotations[NamespaceOwnerKindAnnotation])
+	key := types.NamespacedName{Name: ns.Annotations[NamespaceOwnerAnnotation]}
+	if parts := strings.SplitN(key.Name, "/", 2); len(parts) == 2 {
+		key = types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+	}
+	err := n.Reader.Get(ctx, key, owner)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return string(owner.GetUID()) != ns.Annotations[NamespaceOwnerUIDAnnotation], nil
+}
+
+// delete deletes the namespace unless it holds foreign objects.
+func (n *Namespaces) delete(ctx context.Context, ns *corev1.Namespace) error {
+	foreign, err := n.foreignObjects(ctx, ns.Name)
+	if err != nil {
+		return err
+	}
+	if len(foreign) > 0 {
+		return &ForeignResourcesError{Namespace: ns.Name, Objects: foreign}
+	}
+	// the namespace is only deleted if it is the one which was checked
+	err = n.Client.Delete(ctx, ns, client.Preconditions(metav1.NewUIDPreconditions(string(ns.UID))))
+	return client.IgnoreNotFound(err)
+}
+
+// foreignObjects returns some of the objects of the namespace without
+// NamespaceManagedByLabel, as <Kind>/<name>.
+func (n *Namespaces) foreignObjects(ctx context.Context, namespace string) ([]string, error) {
+	kinds := n.ForeignKinds
+	if len(kinds) == 0 {
+		kinds = DefaultForeignKinds
+	}
+	unmanaged, err := labels.NewRequirement(NamespaceManagedByLabel, selection.DoesNotExist, nil)
+	if err != nil {
+		return nil, err
+	}
+	selector := labels.NewSelector().Add(*unmanaged)
+
+	var foreign []string
+	for _, gvk := range kinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		err := n.Reader.List(ctx, list, client.InNamespace(namespace),
+			client.UseListOptions(&client.ListOptions{LabelSelector: selector}))
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range list.Items {
+			if !createdWithNamespace(gvk, obj) {
+				foreign = append(foreign, gvk.Kind+"/"+obj.GetName())
+			}
+		}
+	}
+	return foreign, nil
+}
+
+// createdWithNamespace returns true if the object is created by Kubernetes in
+// every namespace.
+func createdWithNamespace(gvk schema.GroupVersionKind, obj unstructured.Unstructured) bool {
+	switch gvk.Kind {
+	case "ServiceAccount":
+		return gvk.Group == "" && obj.GetName() == "default"
+	case "ConfigMap":
+		return gvk.Group == "" && obj.GetName() == "kube-root-ca.crt"
+	case "Secret":
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		return gvk.Group == "" && secretType == string(corev1.SecretTypeServiceAccountToken)
+	}
+	return false
+}
+`
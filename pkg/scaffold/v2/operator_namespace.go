@@ -0,0 +1,71 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &OperatorNamespace{}
+
+// OperatorNamespace scaffolds the controllers/operator_namespace.go file,
+// which resolves the namespace the manager runs in for the heartbeat, the
+// settings and the shard autoscaling. It is shared by all of them, so it is
+// left as it is once scaffolded.
+type OperatorNamespace struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (o *OperatorNamespace) GetInput() (input.Input, error) {
+	if o.Path == "" {
+		o.Path = filepath.Join("controllers", "operator_namespace.go")
+	}
+	o.TemplateBody = operatorNamespaceTemplate
+	o.Input.IfExistsAction = input.Skip
+	return o.Input, nil
+}
+
+var operatorNamespaceTemplate = `{{ .Boilerplate }}
+
+package controllers
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// serviceAccountNamespaceFile holds the namespace of the pod of the manager.
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// defaultOperatorNamespace is the namespace of the operator outside of a
+// cluster.
+const defaultOperatorNamespace = "default"
+
+// operatorNamespace returns the namespace the manager runs in, read from the
+// service account of its pod, and whether it runs in a cluster. Outside of a
+// cluster, as with make run, it returns the default namespace.
+func operatorNamespace() (string, bool) {
+	ns, err := ioutil.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return defaultOperatorNamespace, false
+	}
+	return strings.TrimSpace(string(ns)), true
+}
+`
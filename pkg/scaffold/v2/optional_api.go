@@ -0,0 +1,173 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/internal"
+)
+
+const kustomizeComponentsScaffoldMarker = "# +kubebuilder:scaffold:components"
+
+// OptionalAPIEnv returns the environment variable of the manager which skips
+// the set up of the controller of an optional Resource when false.
+func OptionalAPIEnv(r *resource.Resource) string {
+	return strings.ToUpper(fmt.Sprintf("ENABLE_%s_%s", r.Group, r.Kind))
+}
+
+// optionalAPIDir returns the directory of the kustomize component excluding
+// an optional Resource from the deployment.
+func optionalAPIDir(r *resource.Resource) string {
+	return filepath.Join("config", "components", fmt.Sprintf("disable-%s-%s", r.Group, r.Resource))
+}
+
+var _ input.File = &OptionalAPIKustomization{}
+
+// OptionalAPIKustomization scaffolds the kustomize component excluding an
+// optional Resource, its CRD and controller, from the deployment
+type OptionalAPIKustomization struct {
+	input.Input
+
+	// Resource is the optional Resource
+	Resource *resource.Resource
+
+	// Controller indicates whether the component disables the controller of
+	// the Resource
+	Controller bool
+}
+
+// GetInput implements input.File
+func (k *OptionalAPIKustomization) GetInput() (input.Input, error) {
+	if k.Path == "" {
+		k.Path = filepath.Join(optionalAPIDir(k.Resource), "kustomization.yaml")
+	}
+	k.TemplateBody = optionalAPIKustomizationTemplate
+	k.Input.IfExistsAction = input.Error
+	return k.Input, nil
+}
+
+// Validate validates the values
+func (k *OptionalAPIKustomization) Validate() error {
+	return k.Resource.Validate()
+}
+
+// Update lists the component, commented out, in the components of the
+// default overlay, if it has the components marker.
+func (k *OptionalAPIKustomization) Update() error {
+	component := filepath.ToSlash(filepath.Join("..", "components", filepath.Base(optionalAPIDir(k.Resource))))
+	return internal.InsertStringsInFile(filepath.Join("config", "default", "kustomization.yaml"),
+		map[string][]string{
+			kustomizeComponentsScaffoldMarker: {fmt.Sprintf("#- %s\n", component)},
+		})
+}
+
+var optionalAPIKustomizationTemplate = `# Excludes the optional {{ .Resource.Kind }} API from the deployment: its CRD
+# is deleted{{ if .Controller }} and its controller is not set up by the manager{{ end }}.
+# List it in the components of config/default/kustomization.yaml to exclude
+# the API. Components require kustomize v3.7.0 or later.
+apiVersion: kustomize.config.k8s.io/v1alpha1
+kind: Component
+
+patchesStrategicMerge:
+- crd_patch.yaml
+{{- if .Controller }}
+- manager_patch.yaml
+{{- end }}
+`
+
+var _ input.File = &OptionalAPICRDPatch{}
+
+// OptionalAPICRDPatch scaffolds the patch deleting the CRD of an optional
+// Resource
+type OptionalAPICRDPatch struct {
+	input.Input
+
+	// Resource is the optional Resource
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (p *OptionalAPICRDPatch) GetInput() (input.Input, error) {
+	if p.Path == "" {
+		p.Path = filepath.Join(optionalAPIDir(p.Resource), "crd_patch.yaml")
+	}
+	p.TemplateBody = optionalAPICRDPatchTemplate
+	p.Input.IfExistsAction = input.Error
+	return p.Input, nil
+}
+
+// Validate validates the values
+func (p *OptionalAPICRDPatch) Validate() error {
+	return p.Resource.Validate()
+}
+
+var optionalAPICRDPatchTemplate = `$patch: delete
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: {{ .Resource.Resource }}.{{ .Resource.Group }}.{{ .Domain }}
+`
+
+var _ input.File = &OptionalAPIManagerPatch{}
+
+// OptionalAPIManagerPatch scaffolds the patch of the manager disabling the
+// controller of an optional Resource
+type OptionalAPIManagerPatch struct {
+	input.Input
+
+	// Resource is the optional Resource
+	Resource *resource.Resource
+
+	// Env is the environment variable of the manager disabling the controller
+	Env string
+}
+
+// GetInput implements input.File
+func (p *OptionalAPIManagerPatch) GetInput() (input.Input, error) {
+	if p.Path == "" {
+		p.Path = filepath.Join(optionalAPIDir(p.Resource), "manager_patch.yaml")
+	}
+	p.Env = OptionalAPIEnv(p.Resource)
+	p.TemplateBody = optionalAPIManagerPatchTemplate
+	p.Input.IfExistsAction = input.Error
+	return p.Input, nil
+}
+
+// Validate validates the values
+func (p *OptionalAPIManagerPatch) Validate() error {
+	return p.Resource.Validate()
+}
+
+var optionalAPIManagerPatchTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: system
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        env:
+        - name: {{ .Env }}
+          value: "false"
+`
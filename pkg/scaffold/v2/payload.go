@@ -0,0 +1,356 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &PayloadTypes{}
+
+// PayloadTypes scaffolds the api/<version>/payload_types.go file defining the
+// references to the large payloads the resources of an API version store in
+// ConfigMaps or Secrets rather than in their spec
+type PayloadTypes struct {
+	input.Input
+
+	// Resource is a Resource of the API version
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (t *PayloadTypes) GetInput() (input.Input, error) {
+	if t.Path == "" {
+		t.Path = filepath.Join(apiDir(t.Resource, t.Input), "payload_types.go")
+	}
+	t.TemplateBody = payloadTypesTemplate
+	t.Input.IfExistsAction = input.Skip
+	return t.Input, nil
+}
+
+var payloadTypesTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The objects stored by etcd are limited to about 1.5MiB, and every client
+// watching them pays for their size. The large payloads of a spec, e.g. a
+// configuration file, are better stored in a ConfigMap or a Secret it
+// references. The reference pins the SHA-256 digest of the payload, so that
+// changing the payload changes the spec, and the controller never acts on a
+// payload the spec was not admitted with.
+
+// PayloadSHA256Annotation is the annotation the tools writing the payloads may
+// set on their ConfigMap or Secret, recording the digest of each key as
+// <key>=<digest>. The payloads contradicting it are refused.
+const PayloadSHA256Annotation = "{{ .Domain }}/payload-sha256"
+
+// PayloadSourceKind is the kind of the object holding a payload.
+// +kubebuilder:validation:Enum=ConfigMap;Secret
+type PayloadSourceKind string
+
+const (
+	// PayloadSourceConfigMap is a payload held by a ConfigMap, in its data or
+	// binaryData.
+	PayloadSourceConfigMap PayloadSourceKind = "ConfigMap"
+	// PayloadSourceSecret is a payload held by a Secret.
+	PayloadSourceSecret PayloadSourceKind = "Secret"
+)
+
+// PayloadReference references a payload stored in a key of a ConfigMap or a
+// Secret of the namespace of the referencing object.
+type PayloadReference struct {
+	// Kind is the kind of the object holding the payload.
+	Kind PayloadSourceKind ` + "`" + `json:"kind"` + "`" + `
+
+	// Name is the name of the object holding the payload.
+	// +kubebuilder:validation:MinLength=1
+	Name string ` + "`" + `json:"name"` + "`" + `
+
+	// Key is the key of the payload in the object.
+	// +kubebuilder:validation:MinLength=1
+	Key string ` + "`" + `json:"key"` + "`" + `
+
+	// SHA256 is the hex encoded SHA-256 digest of the payload.
+	// +kubebuilder:validation:Pattern=` + "`" + `^[0-9a-f]{64}$` + "`" + `
+	SHA256 string ` + "`" + `json:"sha256"` + "`" + `
+}
+
+// PayloadReferenceError is the error of a reference which does not resolve to
+// the payload it pins, as opposed to an error reading the payload.
+type PayloadReferenceError struct {
+	Reference PayloadReference
+	Reason    string
+}
+
+func (e *PayloadReferenceError) Error() string {
+	return fmt.Sprintf("payload %s of %s %s %s", e.Reference.Key, e.Reference.Kind, e.Reference.Name, e.Reason)
+}
+
+// PayloadDigest returns the hex encoded SHA-256 digest of a payload, as pinned
+// by a PayloadReference.
+func PayloadDigest(payload []byte) string {
+	digest := sha256.Sum256(payload)
+	return hex.EncodeToString(digest[:])
+}
+
+// ResolvePayload reads the payload referenced by ref from the given namespace,
+// checking it matches the digest pinned by ref. It returns a
+// *PayloadReferenceError if the payload does not exist or does not match.
+func ResolvePayload(ctx context.Context, reader client.Reader, namespace string, ref PayloadReference) ([]byte, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+	var payload []byte
+	var found bool
+	var annotations map[string]string
+
+	var err error
+	switch ref.Kind {
+	case PayloadSourceConfigMap:
+		cm := &corev1.ConfigMap{}
+		if err = reader.Get(ctx, key, cm); err == nil {
+			annotations = cm.Annotations
+			if data, ok := cm.Data[ref.Key]; ok {
+				payload, found = []byte(data), true
+			} else {
+				payload, found = cm.BinaryData[ref.Key]
+			}
+		}
+	case PayloadSourceSecret:
+		secret := &corev1.Secret{}
+		if err = reader.Get(ctx, key, secret); err == nil {
+			annotations = secret.Annotations
+			payload, found = secret.Data[ref.Key]
+		}
+	default:
+		return nil, &PayloadReferenceError{Reference: ref, Reason: "has an unknown kind"}
+	}
+	if apierrors.IsNotFound(err) {
+		return nil, &PayloadReferenceError{Reference: ref, Reason: "does not exist"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &PayloadReferenceError{Reference: ref, Reason: "does not exist"}
+	}
+
+	digest := PayloadDigest(payload)
+	if digest != ref.SHA256 {
+		return nil, &PayloadReferenceError{Reference: ref,
+			Reason: fmt.Sprintf("has digest %s instead of the pinned %s", digest, ref.SHA256)}
+	}
+	if recorded, ok := recordedPayloadDigest(annotations, ref.Key); ok && recorded != digest {
+		return nil, &PayloadReferenceError{Reference: ref,
+			Reason: fmt.Sprintf("has digest %s instead of the %s recorded by its %s annotation",
+				digest, recorded, PayloadSHA256Annotation)}
+	}
+	return payload, nil
+}
+
+// recordedPayloadDigest returns the digest of the given key recorded by the
+// PayloadSHA256Annotation of the annotations, if any.
+func recordedPayloadDigest(annotations map[string]string, key string) (string, bool) {
+	for _, entry := range strings.Split(annotations[PayloadSHA256Annotation], ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) == 2 && parts[0] == key {
+			return parts[1], true
+		}
+	}
+	return "", false
+}
+`
+
+var _ input.File = &ControllerPayload{}
+
+// ControllerPayload scaffolds the helper of the controller of a Resource
+// resolving the payloads referenced by its spec
+type ControllerPayload struct {
+	input.Input
+
+	// Resource is the Resource to make the helper for
+	Resource *resource.Resource
+
+	// ResourcePackage is the package of the Resource
+	ResourcePackage string
+}
+
+// GetInput implements input.File
+func (p *ControllerPayload) GetInput() (input.Input, error) {
+	p.ResourcePackage, _ = getResourceInfo(p.Resource, p.Input)
+	if p.Path == "" {
+		p.Path = filepath.Join(controllersDir(p.Resource, p.Input),
+			strings.ToLower(p.Resource.Kind)+"_payload.go")
+	}
+	p.TemplateBody = controllerPayloadTemplate
+	p.Input.IfExistsAction = input.Error
+	return p.Input, nil
+}
+
+var controllerPayloadTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	"context"
+
+	{{ .Resource.Group}}{{ .Resource.Version }} "{{ .ResourcePackage }}/{{ .Resource.Version }}"
+)
+
+// +kubebuilder:rbac:groups="",resources=configmaps;secrets,verbs=get;list;watch
+
+// payloadOf returns the payload referenced by the spec of {{ lower .Resource.Kind }}, nil
+// when it references none. Call it from Reconcile once the {{ .Resource.Kind }} is read.
+//
+// A *{{ .Resource.Group}}{{ .Resource.Version }}.PayloadReferenceError means the payload was
+// changed or deleted since the {{ .Resource.Kind }} was admitted: the reconcile fails, and
+// is retried with a backoff until the payload or the reference is fixed.
+// Record it in the status of the {{ .Resource.Kind }} to let its users know.
+func (r *{{ .Resource.Kind }}Reconciler) payloadOf(ctx context.Context, {{ lower .Resource.Kind }} *{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}) ([]byte, error) {
+	if {{ lower .Resource.Kind }}.Spec.Payload == nil {
+		return nil, nil
+	}
+	return {{ .Resource.Group}}{{ .Resource.Version }}.ResolvePayload(ctx, r, {{ lower .Resource.Kind }}.Namespace, *{{ lower .Resource.Kind }}.Spec.Payload)
+}
+`
+
+var _ input.File = &PayloadWebhook{}
+
+// PayloadWebhook scaffolds a validating webhook denying the objects of a
+// Resource whose spec references a payload which does not exist or does not
+// match its pinned digest
+type PayloadWebhook struct {
+	input.Input
+
+	// Resource is the Resource to make the webhook for
+	Resource *resource.Resource
+
+	// GroupDomainWithDash is the API group of the Resource with dots replaced
+	// by dashes, as used by controller-runtime in the webhook paths
+	GroupDomainWithDash string
+}
+
+// GetInput implements input.File
+func (w *PayloadWebhook) GetInput() (input.Input, error) {
+	if w.Path == "" {
+		w.Path = filepath.Join(apiDir(w.Resource, w.Input),
+			fmt.Sprintf("%s_payload_webhook.go", strings.ToLower(w.Resource.Kind)))
+	}
+	w.GroupDomainWithDash = strings.Replace(
+		fmt.Sprintf("%s.%s", w.Resource.Group, w.Domain), ".", "-", -1)
+	w.TemplateBody = payloadWebhookTemplate
+	w.Input.IfExistsAction = input.Error
+	return w.Input, nil
+}
+
+// Validate validates the values
+func (w *PayloadWebhook) Validate() error {
+	return w.Resource.Validate()
+}
+
+var payloadWebhookTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	"context"
+	"net/http"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// The webhook below denies the {{ .Resource.Kind }} objects whose spec references a
+// payload which does not exist or does not match the digest it pins, so that
+// only the payloads checked at admission reach the controller. It reads them
+// with the API reader of the manager, which only needs the get permission.
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// +kubebuilder:webhook:path=/validate-payload-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }},mutating=false,failurePolicy=fail,groups={{ .Resource.Group }}.{{ .Domain }},resources={{ .Resource.Resource }},verbs=create;update,versions={{ .Resource.Version }},name=p{{ lower .Resource.Kind }}.{{ .Domain }}
+// +kubebuilder:rbac:groups="",resources=configmaps;secrets,verbs=get
+
+// SetupPayloadWebhookWithManager registers the webhook validating the payload
+// references of {{ .Resource.Kind }} with the manager's webhook server.
+func (r *{{ .Resource.Kind }}) SetupPayloadWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register("/validate-payload-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }}",
+		&admission.Webhook{Handler: &{{ lower .Resource.Kind }}PayloadValidator{}})
+	return nil
+}
+
+// {{ lower .Resource.Kind }}PayloadValidator is the admission handler validating
+// the payload references of {{ .Resource.Kind }}. Its reader and decoder are
+// injected by the manager when the webhook server starts.
+type {{ lower .Resource.Kind }}PayloadValidator struct {
+	reader  client.Reader
+	decoder *admission.Decoder
+}
+
+var _ inject.APIReader = &{{ lower .Resource.Kind }}PayloadValidator{}
+var _ admission.DecoderInjector = &{{ lower .Resource.Kind }}PayloadValidator{}
+
+// InjectAPIReader implements inject.APIReader.
+func (v *{{ lower .Resource.Kind }}PayloadValidator) InjectAPIReader(r client.Reader) error {
+	v.reader = r
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *{{ lower .Resource.Kind }}PayloadValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle denies the request if the payload referenced by the object does not
+// resolve to the digest it pins.
+func (v *{{ lower .Resource.Kind }}PayloadValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	obj := &{{ .Resource.Kind }}{}
+	if err := v.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if obj.Spec.Payload == nil {
+		return admission.Allowed("")
+	}
+
+	_, err := ResolvePayload(ctx, v.reader, req.Namespace, *obj.Spec.Payload)
+	if refErr, ok := err.(*PayloadReferenceError); ok {
+		return admission.Denied(refErr.Error())
+	}
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.Allowed("")
+}
+`
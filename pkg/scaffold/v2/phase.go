@@ -0,0 +1,157 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &PhaseTransitions{}
+
+// PhaseTransitions scaffolds the helpers transitioning the phase of a Resource
+// along the transitions allowed between its phases
+type PhaseTransitions struct {
+	input.Input
+
+	// Resource is the Resource to make the helpers for
+	Resource *resource.Resource
+
+	// ResourcePackage is the package of the Resource
+	ResourcePackage string
+
+	// Transitions are the phases each phase of the Resource can transition to,
+	// in the order of the phases. A phase can transition to the phases listed
+	// after it, and the initial empty phase to any of them.
+	Transitions []PhaseTransition
+}
+
+// PhaseTransition lists the phases a phase can transition to
+type PhaseTransition struct {
+	From string
+	To   []string
+}
+
+// GetInput implements input.File
+func (p *PhaseTransitions) GetInput() (input.Input, error) {
+	p.ResourcePackage, _ = getResourceInfo(p.Resource, p.Input)
+	if p.Path == "" {
+		p.Path = filepath.Join(controllersDir(p.Resource, p.Input),
+			strings.ToLower(p.Resource.Kind)+"_phase.go")
+	}
+	if p.Transitions == nil {
+		phases := p.Resource.Phases
+		p.Transitions = []PhaseTransition{{From: "", To: phases}}
+		for i, phase := range phases {
+			p.Transitions = append(p.Transitions, PhaseTransition{From: phase, To: phases[i+1:]})
+		}
+	}
+	p.TemplateBody = phaseTransitionsTemplate
+	p.Input.IfExistsAction = input.Error
+	return p.Input, nil
+}
+
+var phaseRegex = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// ValidatePhases validates the phases of a Resource are distinct CamelCase
+// identifiers, as they name Go constants.
+func ValidatePhases(phases []string) error {
+	seen := map[string]bool{}
+	for _, phase := range phases {
+		if !phaseRegex.MatchString(phase) {
+			return fmt.Errorf("phase %q must be CamelCase, starting with an uppercase letter", phase)
+		}
+		if seen[phase] {
+			return fmt.Errorf("phase %q is listed more than once", phase)
+		}
+		seen[phase] = true
+	}
+	return nil
+}
+
+// Validate validates the values
+func (p *PhaseTransitions) Validate() error {
+	if len(p.Resource.Phases) == 0 {
+		return fmt.Errorf("at least one phase must be given")
+	}
+	if err := ValidatePhases(p.Resource.Phases); err != nil {
+		return err
+	}
+	return p.Resource.Validate()
+}
+
+var phaseTransitionsTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	"fmt"
+
+	{{ .Resource.Group }}{{ .Resource.Version }} "{{ .ResourcePackage }}/{{ .Resource.Version }}"
+)
+
+// {{ lower .Resource.Kind }}PhaseTransitions are the phases each phase of a {{ .Resource.Kind }} can
+// transition to. A {{ .Resource.Kind }} starts in the empty phase.
+// TODO(user): restrict the transitions to those of the lifecycle of {{ .Resource.Kind }}.
+var {{ lower .Resource.Kind }}PhaseTransitions = map[{{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}Phase][]{{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}Phase{
+{{- range .Transitions }}
+	{{ if .From }}{{ $.Resource.Group }}{{ $.Resource.Version }}.{{ $.Resource.Kind }}Phase{{ .From }}{{ else }}""{{ end }}: {
+{{- range $i, $to := .To }}{{ if $i }}, {{ end }}{{ $.Resource.Group }}{{ $.Resource.Version }}.{{ $.Resource.Kind }}Phase{{ $to }}{{ end -}}
+},
+{{- end }}
+}
+
+// Set{{ .Resource.Kind }}Phase transitions the phase of {{ lower .Resource.Kind }} to phase. The transitions
+// not listed in {{ lower .Resource.Kind }}PhaseTransitions are refused with an error, so that
+// a bug in the controller can not move a {{ .Resource.Kind }} back to an earlier phase. It
+// reports whether the phase changed, so that the status is only written when
+// needed.
+func Set{{ .Resource.Kind }}Phase({{ lower .Resource.Kind }} *{{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}, phase {{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}Phase) (bool, error) {
+	current := {{ lower .Resource.Kind }}.Status.Phase
+	if current == phase {
+		return false, nil
+	}
+	if !Can{{ .Resource.Kind }}PhaseTransition(current, phase) {
+		return false, fmt.Errorf("{{ lower .Resource.Kind }} %s/%s can not transition from phase %q to %q",
+			{{ lower .Resource.Kind }}.Namespace, {{ lower .Resource.Kind }}.Name, current, phase)
+	}
+	{{ lower .Resource.Kind }}.Status.Phase = phase
+	return true, nil
+}
+
+// Can{{ .Resource.Kind }}PhaseTransition reports whether a {{ .Resource.Kind }} can transition from
+// phase from to phase to.
+func Can{{ .Resource.Kind }}PhaseTransition(from, to {{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}Phase) bool {
+	for _, allowed := range {{ lower .Resource.Kind }}PhaseTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Is{{ .Resource.Kind }}PhaseFinal reports whether a {{ .Resource.Kind }} in phase can not
+// transition to any other phase.
+func Is{{ .Resource.Kind }}PhaseFinal(phase {{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}Phase) bool {
+	return len({{ lower .Resource.Kind }}PhaseTransitions[phase]) == 0
+}
+`
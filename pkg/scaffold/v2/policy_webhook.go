@@ -0,0 +1,279 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &PolicyWebhook{}
+
+// PolicyWebhook scaffolds a validating webhook delegating the admission of the
+// objects of a Resource to an external policy engine
+type PolicyWebhook struct {
+	input.Input
+
+	// Resource is the Resource to make the webhook for
+	Resource *resource.Resource
+
+	// Delegate is the kind of policy engine the webhook calls, one of opa or
+	// http
+	Delegate string
+
+	// GroupDomainWithDash is the API group of the Resource with dots replaced
+	// by dashes, as used by controller-runtime in the webhook paths
+	GroupDomainWithDash string
+}
+
+// GetInput implements input.File
+func (w *PolicyWebhook) GetInput() (input.Input, error) {
+	if w.Path == "" {
+		w.Path = filepath.Join(apiDir(w.Resource, w.Input),
+			fmt.Sprintf("%s_policy_webhook.go", strings.ToLower(w.Resource.Kind)))
+	}
+	w.GroupDomainWithDash = strings.Replace(
+		fmt.Sprintf("%s.%s", w.Resource.Group, w.Domain), ".", "-", -1)
+	w.TemplateBody = policyWebhookTemplate
+	w.Input.IfExistsAction = input.Error
+	return w.Input, nil
+}
+
+// Validate validates the values
+func (w *PolicyWebhook) Validate() error {
+	if w.Delegate != project.PolicyDelegateOPA && w.Delegate != project.PolicyDelegateHTTP {
+		return fmt.Errorf("unknown policy delegate %q, must be one of %s, %s", w.Delegate,
+			project.PolicyDelegateOPA, project.PolicyDelegateHTTP)
+	}
+	return w.Resource.Validate()
+}
+
+var policyWebhookTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+{{- if eq .Delegate "opa" }}
+	"strings"
+{{- end }}
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// The webhook below delegates the admission of the {{ .Resource.Kind }} objects
+// to an external policy engine, so that the policies of the organization are
+// written and audited in one place rather than in each operator.
+//
+{{- if eq .Delegate "opa" }}
+// It queries the deny rule of an Open Policy Agent, {{ .Resource.Kind }}PolicyPath
+// of {{ .Resource.Kind }}PolicyEndpoint, with the admission request as input, and
+// denies the request when the rule returns any message.
+{{- else }}
+// It posts the admission request as JSON to {{ .Resource.Kind }}PolicyEndpoint,
+// which answers with a JSON object of the form
+//
+//	{"allowed": false, "reason": "why the request is denied"}
+{{- end }}
+//
+// The calls are bounded by {{ .Resource.Kind }}PolicyTimeout, well within the
+// timeout of the apiserver calling the webhook. When the policy engine cannot be
+// reached, times out or answers with an error, {{ .Resource.Kind }}PolicyFailureMode
+// decides the outcome: "fallback" validates the object with validatePolicyLocally,
+// "deny" denies the request and "allow" admits it.
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// +kubebuilder:webhook:path=/validate-policy-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }},mutating=false,failurePolicy=fail,groups={{ .Resource.Group }}.{{ .Domain }},resources={{ .Resource.Resource }},verbs=create;update,versions={{ .Resource.Version }},name=p{{ lower .Resource.Kind }}.{{ .Domain }}
+
+var (
+	// {{ .Resource.Kind }}PolicyEndpoint is the URL of the policy engine, read from
+	// the POLICY_ENDPOINT environment variable of the manager when unset.
+	{{ .Resource.Kind }}PolicyEndpoint = ""
+{{- if eq .Delegate "opa" }}
+
+	// {{ .Resource.Kind }}PolicyPath is the path of the deny rule queried with the
+	// Data API of the Open Policy Agent.
+	{{ .Resource.Kind }}PolicyPath = "/v1/data/kubernetes/admission/deny"
+{{- end }}
+
+	// {{ .Resource.Kind }}PolicyTimeout bounds each call to the policy engine.
+	{{ .Resource.Kind }}PolicyTimeout = 2 * time.Second
+
+	// {{ .Resource.Kind }}PolicyFailureMode is the outcome of the requests the
+	// policy engine could not decide, one of "fallback", "deny" or "allow".
+	{{ .Resource.Kind }}PolicyFailureMode = "fallback"
+)
+
+var {{ lower .Resource.Kind }}policylog = logf.Log.WithName("{{ lower .Resource.Kind }}-policy-webhook")
+
+// SetupPolicyWebhookWithManager registers the webhook delegating the admission
+// of {{ .Resource.Kind }} to the policy engine with the manager's webhook server.
+func (r *{{ .Resource.Kind }}) SetupPolicyWebhookWithManager(mgr ctrl.Manager) error {
+	endpoint := {{ .Resource.Kind }}PolicyEndpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("POLICY_ENDPOINT")
+	}
+	if endpoint == "" {
+		return fmt.Errorf("the policy endpoint is not set, set the POLICY_ENDPOINT environment variable")
+	}
+	switch {{ .Resource.Kind }}PolicyFailureMode {
+	case "fallback", "deny", "allow":
+	default:
+		return fmt.Errorf("unknown policy failure mode %q, must be one of fallback, deny or allow",
+			{{ .Resource.Kind }}PolicyFailureMode)
+	}
+
+	mgr.GetWebhookServer().Register("/validate-policy-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }}",
+		&admission.Webhook{Handler: &{{ lower .Resource.Kind }}PolicyValidator{
+			endpoint: endpoint,
+			client:   &http.Client{Timeout: {{ .Resource.Kind }}PolicyTimeout},
+		}})
+	return nil
+}
+
+// {{ lower .Resource.Kind }}PolicyValidator is the admission handler delegating
+// the admission of {{ .Resource.Kind }} to the policy engine.
+type {{ lower .Resource.Kind }}PolicyValidator struct {
+	endpoint string
+	client   *http.Client
+}
+
+{{ if eq .Delegate "opa" -}}
+// {{ lower .Resource.Kind }}PolicyDecision is the answer of the Data API of the
+// Open Policy Agent to the query of the deny rule.
+type {{ lower .Resource.Kind }}PolicyDecision struct {
+	Result []string ` + "`" + `json:"result"` + "`" + `
+}
+{{- else -}}
+// {{ lower .Resource.Kind }}PolicyDecision is the answer of the policy endpoint.
+type {{ lower .Resource.Kind }}PolicyDecision struct {
+	Allowed bool   ` + "`" + `json:"allowed"` + "`" + `
+	Reason  string ` + "`" + `json:"reason,omitempty"` + "`" + `
+}
+{{- end }}
+
+// Handle admits the request as decided by the policy engine, or as decided by
+// {{ .Resource.Kind }}PolicyFailureMode when the policy engine fails to decide.
+func (v *{{ lower .Resource.Kind }}PolicyValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	resp, err := v.query(ctx, req)
+	if err == nil {
+		return resp
+	}
+
+	{{ lower .Resource.Kind }}policylog.Error(err, "unable to query the policy engine", "endpoint", v.endpoint,
+		"namespace", req.Namespace, "name", req.Name, "failureMode", {{ .Resource.Kind }}PolicyFailureMode)
+	switch {{ .Resource.Kind }}PolicyFailureMode {
+	case "allow":
+		resp = admission.Allowed("")
+	case "deny":
+		resp = admission.Denied(fmt.Sprintf("the policy engine could not decide: %v", err))
+	default:
+		obj := &{{ .Resource.Kind }}{}
+		if err := json.Unmarshal(req.Object.Raw, obj); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if err := obj.validatePolicyLocally(); err != nil {
+			resp = admission.Denied(err.Error())
+		} else {
+			resp = admission.Allowed("")
+		}
+	}
+	resp.AuditAnnotations = map[string]string{
+		"policy-failure": err.Error(),
+	}
+	return resp
+}
+
+// query asks the policy engine whether to admit the request.
+func (v *{{ lower .Resource.Kind }}PolicyValidator) query(ctx context.Context, req admission.Request) (admission.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, {{ .Resource.Kind }}PolicyTimeout)
+	defer cancel()
+
+{{- if eq .Delegate "opa" }}
+	body, err := json.Marshal(map[string]interface{}{"input": req.AdmissionRequest})
+	if err != nil {
+		return admission.Response{}, err
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, v.endpoint+{{ .Resource.Kind }}PolicyPath, bytes.NewReader(body))
+{{- else }}
+	body, err := json.Marshal(req.AdmissionRequest)
+	if err != nil {
+		return admission.Response{}, err
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, v.endpoint, bytes.NewReader(body))
+{{- end }}
+	if err != nil {
+		return admission.Response{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := v.client.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return admission.Response{}, err
+	}
+	defer httpResp.Body.Close()
+	data, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return admission.Response{}, err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return admission.Response{}, fmt.Errorf("the policy engine answered %s: %s", httpResp.Status, data)
+	}
+
+	decision := &{{ lower .Resource.Kind }}PolicyDecision{}
+	if err := json.Unmarshal(data, decision); err != nil {
+		return admission.Response{}, fmt.Errorf("unable to decode the decision of the policy engine: %v", err)
+	}
+{{- if eq .Delegate "opa" }}
+	if len(decision.Result) > 0 {
+		return admission.Denied(strings.Join(decision.Result, "; ")), nil
+	}
+	return admission.Allowed(""), nil
+{{- else }}
+	if !decision.Allowed {
+		return admission.Denied(decision.Reason), nil
+	}
+	return admission.Allowed(decision.Reason), nil
+{{- end }}
+}
+
+// validatePolicyLocally validates the object when the policy engine cannot be
+// reached and {{ .Resource.Kind }}PolicyFailureMode is "fallback". Implement the
+// subset of the policies which must hold even when the policy engine is down.
+func (r *{{ .Resource.Kind }}) validatePolicyLocally() error {
+	// TODO(user): fill in your local validation logic, e.g.
+	// if r.Spec.Foo == "" {
+	// 	return fmt.Errorf("spec.foo must be set")
+	// }
+	return nil
+}
+`
@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+// PrinterColumn is an additional column printed by kubectl get for the
+// objects of a CRD.
+type PrinterColumn struct {
+	// Name is the header of the column
+	Name string
+
+	// Type is the OpenAPI type of the column, e.g. string or date
+	Type string
+
+	// JSONPath is the path of the field printed in the column
+	JSONPath string
+
+	// Priority is 0 for the columns printed by default, and 1 for the columns
+	// only printed with -o wide
+	Priority int
+}
+
+// Marker returns the +kubebuilder:printcolumn marker generating the column.
+func (c PrinterColumn) Marker() string {
+	marker := fmt.Sprintf("+kubebuilder:printcolumn:name=%q,type=%q,JSONPath=%q", c.Name, c.Type, c.JSONPath)
+	if c.Priority != 0 {
+		marker += fmt.Sprintf(",priority=%d", c.Priority)
+	}
+	return marker
+}
+
+// ConditionJSONPath returns the JSONPath of the given field of the condition
+// of the given type, e.g. .status.conditions[?(@.type=="Ready")].status.
+func ConditionJSONPath(conditionType, field string) string {
+	return fmt.Sprintf(`.status.conditions[?(@.type==%q)].%s`, conditionType, field)
+}
+
+// ConditionPrinterColumns returns the columns printing the status, reason and
+// message of the condition of the given type.
+func ConditionPrinterColumns(conditionType string) []PrinterColumn {
+	return []PrinterColumn{
+		{Name: conditionType, Type: "string", JSONPath: ConditionJSONPath(conditionType, "status")},
+		{Name: "Reason", Type: "string", JSONPath: ConditionJSONPath(conditionType, "reason")},
+		{Name: "Message", Type: "string", JSONPath: ConditionJSONPath(conditionType, "message"), Priority: 1},
+	}
+}
+
+// printerColumns returns the additional columns of the CRD of the Resource:
+// its phase, its replicas when it is scaled, the Ready condition when it has
+// conditions, the error of its last reconcile, and its age.
+func printerColumns(r *resource.Resource) []PrinterColumn {
+	columns := []PrinterColumn{}
+	if len(r.Phases) > 0 {
+		columns = append(columns, PrinterColumn{Name: "Phase", Type: "string", JSONPath: ".status.phase"})
+	}
+	if r.Scale {
+		columns = append(columns,
+			PrinterColumn{Name: "Desired", Type: "integer", JSONPath: ".spec.replicas"},
+			PrinterColumn{Name: "Current", Type: "integer", JSONPath: ".status.replicas"})
+	}
+	if r.CreationGuard || r.WithConditions {
+		columns = append(columns, ConditionPrinterColumns("Ready")...)
+	}
+	if r.ReconcileStatus {
+		columns = append(columns, PrinterColumn{Name: "Last Error", Type: "string", JSONPath: ".status.lastError", Priority: 1})
+	}
+	if len(columns) > 0 {
+		// kubectl only prints the age when the CRD has no additional columns
+		columns = append(columns, PrinterColumn{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"})
+	}
+	return columns
+}
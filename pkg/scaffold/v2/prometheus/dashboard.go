@@ -0,0 +1,111 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &Dashboard{}
+
+// Dashboard scaffolds a Grafana dashboard for the controller-runtime metrics
+// exposed by the manager.
+type Dashboard struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (d *Dashboard) GetInput() (input.Input, error) {
+	if d.Path == "" {
+		d.Path = filepath.Join("config", "prometheus", "dashboards", "controller-runtime.json")
+	}
+	d.TemplateBody = dashboardTemplate
+	d.Input.IfExistsAction = input.Error
+	return d.Input, nil
+}
+
+var dashboardTemplate = `{
+  "title": "Controller Manager",
+  "uid": "controller-manager",
+  "schemaVersion": 16,
+  "time": {
+    "from": "now-1h",
+    "to": "now"
+  },
+  "templating": {
+    "list": [
+      {
+        "name": "datasource",
+        "type": "datasource",
+        "query": "prometheus"
+      }
+    ]
+  },
+  "panels": [
+    {
+      "title": "Reconciles per second",
+      "type": "graph",
+      "datasource": "$datasource",
+      "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+      "targets": [
+        {
+          "expr": "sum(rate(controller_runtime_reconcile_total[5m])) by (controller, result)",
+          "legendFormat": "{{ "{{" }}controller{{ "}}" }} {{ "{{" }}result{{ "}}" }}"
+        }
+      ]
+    },
+    {
+      "title": "Reconcile errors per second",
+      "type": "graph",
+      "datasource": "$datasource",
+      "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8},
+      "targets": [
+        {
+          "expr": "sum(rate(controller_runtime_reconcile_errors_total[5m])) by (controller)",
+          "legendFormat": "{{ "{{" }}controller{{ "}}" }}"
+        }
+      ]
+    },
+    {
+      "title": "Reconcile duration (p99)",
+      "type": "graph",
+      "datasource": "$datasource",
+      "gridPos": {"x": 0, "y": 8, "w": 12, "h": 8},
+      "targets": [
+        {
+          "expr": "histogram_quantile(0.99, sum(rate(controller_runtime_reconcile_time_seconds_bucket[5m])) by (controller, le))",
+          "legendFormat": "{{ "{{" }}controller{{ "}}" }}"
+        }
+      ]
+    },
+    {
+      "title": "Workqueue depth",
+      "type": "graph",
+      "datasource": "$datasource",
+      "gridPos": {"x": 12, "y": 8, "w": 12, "h": 8},
+      "targets": [
+        {
+          "expr": "sum(workqueue_depth) by (name)",
+          "legendFormat": "{{ "{{" }}name{{ "}}" }}"
+        }
+      ]
+    }
+  ]
+}
+`
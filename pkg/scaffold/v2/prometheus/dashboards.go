@@ -0,0 +1,102 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus
+
+import (
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/rollback"
+	"sigs.k8s.io/kubebuilder/pkg/textfile"
+)
+
+const (
+	prometheusBase = "../prometheus"
+	dashboardsBase = "../prometheus/dashboards"
+)
+
+var _ input.File = &DashboardsKustomization{}
+
+// DashboardsKustomization scaffolds the Kustomization file generating the
+// ConfigMap of the Grafana dashboards in the prometheus folder.
+type DashboardsKustomization struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (k *DashboardsKustomization) GetInput() (input.Input, error) {
+	if k.Path == "" {
+		k.Path = filepath.Join("config", "prometheus", "dashboards", "kustomization.yaml")
+	}
+	k.TemplateBody = dashboardsKustomizationTemplate
+	k.Input.IfExistsAction = input.Error
+	return k.Input, nil
+}
+
+// Update lists the dashboards, commented out, below the prometheus base of
+// the default overlay, unless they are already listed. It returns false if
+// the overlay has no prometheus base to list them below.
+func (k *DashboardsKustomization) Update() (bool, error) {
+	path := filepath.Join("config", "default", "kustomization.yaml")
+	content, format, err := textfile.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	lines := strings.Split(string(content), "\n")
+	for _, line := range lines {
+		if strings.HasSuffix(strings.TrimSpace(line), "- "+dashboardsBase) {
+			return true, nil
+		}
+	}
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "- "+prometheusBase && trimmed != "#- "+prometheusBase {
+			continue
+		}
+		indent := line[:strings.Index(line, trimmed)]
+		dashboards := []string{
+			indent + "# [GRAFANA] To deploy the Grafana dashboards in a ConfigMap, run 'kubebuilder edit --grafana-dashboard' and uncomment next line.",
+			indent + "#- " + dashboardsBase,
+		}
+		lines = append(lines[:i+1], append(dashboards, lines[i+1:]...)...)
+		if err := rollback.Save(path); err != nil {
+			return false, err
+		}
+		if err := textfile.WriteFile(path, []byte(strings.Join(lines, "\n")), format, 0644); err != nil {
+			return false, err
+		}
+		result.FileModified(path)
+		return true, nil
+	}
+	return false, nil
+}
+
+var dashboardsKustomizationTemplate = `# Generates the ConfigMap of the Grafana dashboards of the manager, labelled
+# for the dashboard sidecar of Grafana, e.g. deployed by its Helm chart with
+# sidecar.dashboards.enabled=true, to load them.
+configMapGenerator:
+- name: grafana-dashboards
+  files:
+  - controller-runtime.json
+
+generatorOptions:
+  disableNameSuffixHash: true
+  labels:
+    grafana_dashboard: "1"
+`
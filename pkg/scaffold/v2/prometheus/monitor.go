@@ -0,0 +1,63 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &ServiceMonitor{}
+
+// ServiceMonitor scaffolds a prometheus ServiceMonitor for the manager metrics
+type ServiceMonitor struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (m *ServiceMonitor) GetInput() (input.Input, error) {
+	if m.Path == "" {
+		m.Path = filepath.Join("config", "prometheus", "monitor.yaml")
+	}
+	m.TemplateBody = serviceMonitorTemplate
+	m.Input.IfExistsAction = input.Error
+	return m.Input, nil
+}
+
+var serviceMonitorTemplate = `
+# Prometheus Monitor Service (Metrics)
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  labels:
+    control-plane: controller-manager
+  name: controller-manager-metrics-monitor
+  namespace: system
+spec:
+  endpoints:
+    - path: /metrics
+      port: https
+      scheme: https
+      # the metrics endpoint is protected by the auth proxy
+      bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
+      tlsConfig:
+        insecureSkipVerify: true
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+`
@@ -0,0 +1,122 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &PrometheusRule{}
+
+// PrometheusRule scaffolds a prometheus-operator PrometheusRule holding the
+// alerts for the manager.
+type PrometheusRule struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (r *PrometheusRule) GetInput() (input.Input, error) {
+	if r.Path == "" {
+		r.Path = filepath.Join("config", "prometheus", "rules.yaml")
+	}
+	r.TemplateBody = prometheusRuleTemplate
+	r.Input.IfExistsAction = input.Error
+	return r.Input, nil
+}
+
+var _ input.File = &Alerts{}
+
+// Alerts scaffolds a plain prometheus rules file holding the same alerts as
+// the PrometheusRule, for prometheus installations not managed by the
+// prometheus-operator.
+type Alerts struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (a *Alerts) GetInput() (input.Input, error) {
+	if a.Path == "" {
+		a.Path = filepath.Join("config", "prometheus", "alerts", "alerts.yaml")
+	}
+	a.TemplateBody = alertsTemplate
+	a.Input.IfExistsAction = input.Error
+	return a.Input, nil
+}
+
+// indent prefixes every non-empty line of s with n spaces
+func indent(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = strings.Repeat(" ", n) + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// alertGroups are the alerting rules on the controller-runtime metrics
+// exposed by every manager. Adjust the thresholds to your operator.
+var alertGroups = `groups:
+- name: controller-manager.rules
+  rules:
+  - alert: ControllerManagerDown
+    expr: absent(up{service=~".*controller-manager-metrics-service"} == 1)
+    for: 5m
+    labels:
+      severity: critical
+    annotations:
+      summary: The controller manager is not exposing metrics.
+  - alert: ControllerReconcileErrorsHigh
+    expr: sum(rate(controller_runtime_reconcile_errors_total[5m])) by (controller) > 0.1
+    for: 15m
+    labels:
+      severity: warning
+    annotations:
+      summary: Controller {{ "{{" }} $labels.controller {{ "}}" }} is failing to reconcile.
+  - alert: ControllerReconcileSlow
+    expr: histogram_quantile(0.99, sum(rate(controller_runtime_reconcile_time_seconds_bucket[5m])) by (controller, le)) > 10
+    for: 15m
+    labels:
+      severity: warning
+    annotations:
+      summary: Controller {{ "{{" }} $labels.controller {{ "}}" }} reconciles take longer than 10s.
+  - alert: ControllerWorkqueueDepthHigh
+    expr: sum(workqueue_depth) by (name) > 100
+    for: 15m
+    labels:
+      severity: warning
+    annotations:
+      summary: The workqueue of controller {{ "{{" }} $labels.name {{ "}}" }} is backing up.
+`
+
+var prometheusRuleTemplate = fmt.Sprintf(`apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  labels:
+    control-plane: controller-manager
+  name: controller-manager-rules
+  namespace: system
+spec:
+%s`, indent(alertGroups, 2))
+
+var alertsTemplate = `# Alerting rules for the controller manager, for prometheus installations
+# not managed by the prometheus-operator. See rules.yaml otherwise.
+` + alertGroups
@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &QuotaWebhook{}
+
+// QuotaWebhook scaffolds a validating webhook denying the creation of the
+// objects of a Resource beyond a maximum number per namespace
+type QuotaWebhook struct {
+	input.Input
+
+	// Resource is the Resource to make the webhook for
+	Resource *resource.Resource
+
+	// Limit is the maximum number of objects of the Resource per namespace
+	Limit int
+
+	// GroupDomainWithDash is the API group of the Resource with dots replaced
+	// by dashes, as used by controller-runtime in the webhook paths
+	GroupDomainWithDash string
+}
+
+// GetInput implements input.File
+func (w *QuotaWebhook) GetInput() (input.Input, error) {
+	if w.Path == "" {
+		w.Path = filepath.Join(apiDir(w.Resource, w.Input),
+			fmt.Sprintf("%s_quota_webhook.go", strings.ToLower(w.Resource.Kind)))
+	}
+	w.GroupDomainWithDash = strings.Replace(
+		fmt.Sprintf("%s.%s", w.Resource.Group, w.Domain), ".", "-", -1)
+	w.TemplateBody = quotaWebhookTemplate
+	w.Input.IfExistsAction = input.Error
+	return w.Input, nil
+}
+
+// Validate validates the values
+func (w *QuotaWebhook) Validate() error {
+	if w.Limit < 1 {
+		return fmt.Errorf("quota limit %d must be at least 1", w.Limit)
+	}
+	return w.Resource.Validate()
+}
+
+var quotaWebhookTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// The webhook below denies the creation of a {{ .Resource.Kind }} in a namespace
+// already holding {{ .Resource.Kind }}QuotaLimit of them. It counts them with the
+// client of the manager, which lists them from the informer of the cache shared
+// with the controllers, rather than from the apiserver on each request.
+//
+// The cache lags behind the apiserver, and the requests are admitted
+// concurrently: a burst of creations can exceed the limit by a few objects.
+// Have the controller report, or clean up, the objects beyond the limit if it
+// must never be exceeded.
+//
+// The {{ .Resource.Kind }} objects of a cluster-scoped kind have no namespace,
+// the limit then applies to the whole cluster.
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// +kubebuilder:webhook:path=/validate-quota-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }},mutating=false,failurePolicy=fail,groups={{ .Resource.Group }}.{{ .Domain }},resources={{ .Resource.Resource }},verbs=create,versions={{ .Resource.Version }},name=q{{ lower .Resource.Kind }}.{{ .Domain }}
+// +kubebuilder:rbac:groups={{ .Resource.Group }}.{{ .Domain }},resources={{ .Resource.Resource }},verbs=get;list;watch
+
+// {{ .Resource.Kind }}QuotaLimit is the maximum number of {{ .Resource.Kind }}
+// objects per namespace. Set it before the manager starts, e.g. from a flag of
+// main.go, to make it configurable.
+var {{ .Resource.Kind }}QuotaLimit = {{ .Limit }}
+
+// SetupQuotaWebhookWithManager registers the webhook enforcing the quota of
+// {{ .Resource.Kind }} with the manager's webhook server.
+func (r *{{ .Resource.Kind }}) SetupQuotaWebhookWithManager(mgr ctrl.Manager) error {
+	// get the informer of the kind, so that the cache starts and syncs it with
+	// the manager rather than on the first request
+	if _, err := mgr.GetCache().GetInformer(&{{ .Resource.Kind }}{}); err != nil {
+		return err
+	}
+	mgr.GetWebhookServer().Register("/validate-quota-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }}",
+		&admission.Webhook{Handler: &{{ lower .Resource.Kind }}QuotaValidator{}})
+	return nil
+}
+
+// {{ lower .Resource.Kind }}QuotaValidator is the admission handler enforcing
+// the quota of {{ .Resource.Kind }}. Its client, reading from the cache of the
+// manager, is injected when the webhook server starts.
+type {{ lower .Resource.Kind }}QuotaValidator struct {
+	client client.Client
+}
+
+var _ inject.Client = &{{ lower .Resource.Kind }}QuotaValidator{}
+
+// InjectClient implements inject.Client.
+func (v *{{ lower .Resource.Kind }}QuotaValidator) InjectClient(c client.Client) error {
+	v.client = c
+	return nil
+}
+
+// Handle denies the request if the namespace already holds the maximum number
+// of {{ .Resource.Kind }} objects. The objects being deleted are not counted.
+func (v *{{ lower .Resource.Kind }}QuotaValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	list := &{{ .Resource.Kind }}List{}
+	if err := v.client.List(ctx, list, client.InNamespace(req.Namespace)); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	count := 0
+	for _, item := range list.Items {
+		if item.DeletionTimestamp == nil {
+			count++
+		}
+	}
+	if count >= {{ .Resource.Kind }}QuotaLimit {
+		return admission.Denied(fmt.Sprintf("the maximum of %d {{ .Resource.Resource }} is already reached in namespace %q",
+			{{ .Resource.Kind }}QuotaLimit, req.Namespace))
+	}
+	return admission.Allowed("")
+}
+`
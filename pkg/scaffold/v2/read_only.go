@@ -0,0 +1,128 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &ReadOnly{}
+
+// ReadOnly scaffolds the controllers/read_only.go file, the client of the
+// controllers under the --read-only flag of the manager, which writes the
+// status of the objects but skips their creations, updates and deletions.
+type ReadOnly struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (r *ReadOnly) GetInput() (input.Input, error) {
+	if r.Path == "" {
+		r.Path = filepath.Join("controllers", "read_only.go")
+	}
+	r.TemplateBody = readOnlyTemplate
+	r.Input.IfExistsAction = input.Error
+	return r.Input, nil
+}
+
+var readOnlyTemplate = `{{ .Boilerplate }}
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var readOnlySkippedWrites = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "controller_read_only_skipped_writes_total",
+	Help: "Total number of writes skipped by the controllers in read-only mode, per verb and kind",
+}, []string{"verb", "kind"})
+
+func init() {
+	metrics.Registry.MustRegister(readOnlySkippedWrites)
+}
+
+// ReadOnlyClient returns the client of a controller of the manager running
+// in read-only mode, e.g. during an incident or a migration: it reads the
+// objects and writes their status with c, but skips their creations, updates,
+// patches and deletions, logging them and counting them in the
+// controller_read_only_skipped_writes_total metric.
+//
+// The skipped writes succeed: the reconciles go on as if they were made, with
+// the objects left as they were passed, and make them again once the manager
+// runs without --read-only. The finalizers are neither added nor removed, the
+// objects being deleted stay so in the meantime.
+func ReadOnlyClient(c client.Client, log logr.Logger) client.Client {
+	return &readOnlyClient{Client: c, log: log}
+}
+
+// readOnlyClient embeds the client it wraps for its reads and its status
+// writes, overriding its other writes.
+type readOnlyClient struct {
+	client.Client
+	log logr.Logger
+}
+
+// Create implements client.Writer, skipping the creation.
+func (c *readOnlyClient) Create(_ context.Context, obj runtime.Object, _ ...client.CreateOptionFunc) error {
+	c.skip("create", obj)
+	return nil
+}
+
+// Update implements client.Writer, skipping the update.
+func (c *readOnlyClient) Update(_ context.Context, obj runtime.Object, _ ...client.UpdateOptionFunc) error {
+	c.skip("update", obj)
+	return nil
+}
+
+// Patch implements client.Writer, skipping the patch.
+func (c *readOnlyClient) Patch(_ context.Context, obj runtime.Object, _ client.Patch, _ ...client.PatchOptionFunc) error {
+	c.skip("patch", obj)
+	return nil
+}
+
+// Delete implements client.Writer, skipping the deletion.
+func (c *readOnlyClient) Delete(_ context.Context, obj runtime.Object, _ ...client.DeleteOptionFunc) error {
+	c.skip("delete", obj)
+	return nil
+}
+
+func (c *readOnlyClient) skip(verb string, obj runtime.Object) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		// the typed objects seldom have their kind set, the name of their type
+		// is the kind
+		kind = reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+	}
+	keysAndValues := []interface{}{"verb", verb, "kind", kind}
+	if accessor, err := meta.Accessor(obj); err == nil {
+		keysAndValues = append(keysAndValues, "namespace", accessor.GetNamespace(), "name", accessor.GetName())
+	}
+	c.log.Info("skipped the write of the read-only mode", keysAndValues...)
+	readOnlySkippedWrites.WithLabelValues(verb, kind).Inc()
+}
+`
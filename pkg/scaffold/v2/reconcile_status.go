@@ -0,0 +1,107 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &ReconcileStatus{}
+
+// ReconcileStatus scaffolds the helper recording the outcome of the last
+// reconcile of a Resource in its status
+type ReconcileStatus struct {
+	input.Input
+
+	// Resource is the Resource to make the helper for
+	Resource *resource.Resource
+
+	// ResourcePackage is the package of the Resource
+	ResourcePackage string
+}
+
+// GetInput implements input.File
+func (s *ReconcileStatus) GetInput() (input.Input, error) {
+	s.ResourcePackage, _ = getResourceInfo(s.Resource, s.Input)
+	if s.Path == "" {
+		s.Path = filepath.Join(controllersDir(s.Resource, s.Input),
+			strings.ToLower(s.Resource.Kind)+"_reconcile_status.go")
+	}
+	s.TemplateBody = reconcileStatusTemplate
+	s.Input.IfExistsAction = input.Error
+	return s.Input, nil
+}
+
+// Validate validates the values
+func (s *ReconcileStatus) Validate() error {
+	return s.Resource.Validate()
+}
+
+var reconcileStatusTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	{{ .Resource.Group }}{{ .Resource.Version }} "{{ .ResourcePackage }}/{{ .Resource.Version }}"
+)
+
+// {{ lower .Resource.Kind }}ReconcileStatusInterval is the least time between two writes of the
+// reconcile status of a {{ .Resource.Kind }} recording the same error.
+var {{ lower .Resource.Kind }}ReconcileStatusInterval = time.Minute
+
+// Record{{ .Resource.Kind }}Reconcile records the outcome of a reconcile of {{ lower .Resource.Kind }} in its
+// status: the time of the reconcile, and the error it failed with, reconcileErr,
+// cleared once a reconcile succeeds. The write is skipped when the error is the
+// same as the recorded one and was recorded less than
+// {{ lower .Resource.Kind }}ReconcileStatusInterval ago, so that a {{ .Resource.Kind }} failing in a loop
+// does not flood the apiserver with status updates.
+//
+// It returns reconcileErr, or the error of the status write when the reconcile
+// succeeded, so that Reconcile can return it:
+//
+//	return ctrl.Result{}, Record{{ .Resource.Kind }}Reconcile(ctx, r, &{{ lower .Resource.Kind }}, err)
+func Record{{ .Resource.Kind }}Reconcile(ctx context.Context, c client.StatusClient, {{ lower .Resource.Kind }} *{{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}, reconcileErr error) error {
+	lastError := ""
+	if reconcileErr != nil {
+		lastError = reconcileErr.Error()
+	}
+
+	now := metav1.Now()
+	status := &{{ lower .Resource.Kind }}.Status
+	if status.LastReconcileTime != nil && status.LastError == lastError &&
+		now.Sub(status.LastReconcileTime.Time) < {{ lower .Resource.Kind }}ReconcileStatusInterval {
+		return reconcileErr
+	}
+	status.LastReconcileTime = &now
+	status.LastError = lastError
+
+	if err := c.Status().Update(ctx, {{ lower .Resource.Kind }}); err != nil && reconcileErr == nil {
+		return err
+	}
+	return reconcileErr
+}
+`
@@ -0,0 +1,82 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &ReconcileTimeout{}
+
+// ReconcileTimeout scaffolds the controllers/reconcile_timeout.go file, giving
+// the reconciles a context canceled past the --reconcile-timeout of the manager
+// and counting the ones exceeding it in a metric.
+type ReconcileTimeout struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (r *ReconcileTimeout) GetInput() (input.Input, error) {
+	if r.Path == "" {
+		r.Path = filepath.Join("controllers", "reconcile_timeout.go")
+	}
+	r.TemplateBody = reconcileTimeoutTemplate
+	r.Input.IfExistsAction = input.Error
+	return r.Input, nil
+}
+
+var reconcileTimeoutTemplate = `{{ .Boilerplate }}
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var reconcileDeadlineExceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "controller_reconcile_deadline_exceeded_total",
+	Help: "Total number of reconciles which ran past the reconcile timeout, per controller",
+}, []string{"controller"})
+
+func init() {
+	metrics.Registry.MustRegister(reconcileDeadlineExceeded)
+}
+
+// ReconcileContext returns the context of a reconcile of the controller,
+// canceled once the timeout has passed so that a stuck reconcile releases its
+// worker, and the function to defer until the reconcile returns. The function
+// counts the reconcile in the controller_reconcile_deadline_exceeded_total
+// metric when it ran past the timeout. A zero timeout never cancels the context.
+func ReconcileContext(controller string, timeout time.Duration) (context.Context, func()) {
+	if timeout <= 0 {
+		return context.Background(), func() {}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	return ctx, func() {
+		if ctx.Err() == context.DeadlineExceeded {
+			reconcileDeadlineExceeded.WithLabelValues(controller).Inc()
+		}
+		cancel()
+	}
+}
+`
@@ -0,0 +1,211 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &ReferenceWebhook{}
+
+// ReferenceWebhook scaffolds a validating webhook denying the objects of a
+// Resource which reference Secrets that do not exist
+type ReferenceWebhook struct {
+	input.Input
+
+	// Resource is the Resource to make the webhook for
+	Resource *resource.Resource
+
+	// GroupDomainWithDash is the API group of the Resource with dots replaced
+	// by dashes, as used by controller-runtime in the webhook paths
+	GroupDomainWithDash string
+}
+
+// GetInput implements input.File
+func (w *ReferenceWebhook) GetInput() (input.Input, error) {
+	if w.Path == "" {
+		w.Path = filepath.Join(apiDir(w.Resource, w.Input),
+			fmt.Sprintf("%s_references_webhook.go", strings.ToLower(w.Resource.Kind)))
+	}
+	w.GroupDomainWithDash = strings.Replace(
+		fmt.Sprintf("%s.%s", w.Resource.Group, w.Domain), ".", "-", -1)
+	w.TemplateBody = referenceWebhookTemplate
+	w.Input.IfExistsAction = input.Error
+	return w.Input, nil
+}
+
+// Validate validates the values
+func (w *ReferenceWebhook) Validate() error {
+	return w.Resource.Validate()
+}
+
+var referenceWebhookTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// The webhook below denies the {{ .Resource.Kind }} objects referencing Secrets
+// which do not exist. It reads the Secrets with the API reader of the manager
+// rather than its client: the client reads from informers, which would cache
+// every Secret of the cluster and require the list and watch permissions on
+// them. Instead, the Secrets found are remembered for a short time, so that a
+// burst of requests referencing the same Secret only costs a single read.
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// +kubebuilder:webhook:path=/validate-references-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }},mutating=false,failurePolicy=fail,groups={{ .Resource.Group }}.{{ .Domain }},resources={{ .Resource.Resource }},verbs=create;update,versions={{ .Resource.Version }},name=r{{ lower .Resource.Kind }}.{{ .Domain }}
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// {{ lower .Resource.Kind }}ReferenceTTL is how long a referenced Secret is
+// remembered once found.
+const {{ lower .Resource.Kind }}ReferenceTTL = 10 * time.Second
+
+// referencedSecrets returns the Secrets referenced by the spec of r.
+{{- if .Resource.Namespaced }} The
+// references without a namespace are looked up in the namespace of r.
+{{- else }} A
+// {{ .Resource.Kind }} is cluster-scoped, so its references must give the namespace of
+// their Secret.
+{{- end }}
+func (r *{{ .Resource.Kind }}) referencedSecrets() []types.NamespacedName {
+	// TODO(user): return the Secrets referenced by your spec, e.g. for a field
+{{- if .Resource.Namespaced }}
+	//	SecretRef *corev1.LocalObjectReference ` + "`" + `json:"secretRef,omitempty"` + "`" + `
+{{- else }}
+	//	SecretRef *corev1.SecretReference ` + "`" + `json:"secretRef,omitempty"` + "`" + `
+{{- end }}
+	// of {{ .Resource.Kind }}Spec:
+	//	if r.Spec.SecretRef != nil {
+{{- if .Resource.Namespaced }}
+	//		return []types.NamespacedName{{ "{{" }}Name: r.Spec.SecretRef.Name{{ "}}" }}
+{{- else }}
+	//		return []types.NamespacedName{{ "{{" }}Namespace: r.Spec.SecretRef.Namespace, Name: r.Spec.SecretRef.Name{{ "}}" }}
+{{- end }}
+	//	}
+	return nil
+}
+
+// SetupReferenceWebhookWithManager registers the webhook validating the
+// references of {{ .Resource.Kind }} with the manager's webhook server.
+func (r *{{ .Resource.Kind }}) SetupReferenceWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register("/validate-references-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }}",
+		&admission.Webhook{Handler: &{{ lower .Resource.Kind }}ReferenceValidator{}})
+	return nil
+}
+
+// {{ lower .Resource.Kind }}ReferenceValidator is the admission handler
+// validating the references of {{ .Resource.Kind }}. Its reader and decoder are
+// injected by the manager when the webhook server starts.
+type {{ lower .Resource.Kind }}ReferenceValidator struct {
+	reader  client.Reader
+	decoder *admission.Decoder
+
+	mu sync.Mutex
+	// found holds when each of the Secrets found stops being remembered. The
+	// missing Secrets are not remembered, so that a {{ .Resource.Kind }} is
+	// admitted as soon as the Secret it references is created.
+	found map[types.NamespacedName]time.Time
+}
+
+var _ inject.APIReader = &{{ lower .Resource.Kind }}ReferenceValidator{}
+var _ admission.DecoderInjector = &{{ lower .Resource.Kind }}ReferenceValidator{}
+
+// InjectAPIReader implements inject.APIReader.
+func (v *{{ lower .Resource.Kind }}ReferenceValidator) InjectAPIReader(r client.Reader) error {
+	v.reader = r
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *{{ lower .Resource.Kind }}ReferenceValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle denies the request if one of the Secrets referenced by the object does not exist.
+func (v *{{ lower .Resource.Kind }}ReferenceValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	obj := &{{ .Resource.Kind }}{}
+	if err := v.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	for _, ref := range obj.referencedSecrets() {
+		if ref.Namespace == "" {
+			ref.Namespace = req.Namespace
+		}
+		found, err := v.secretExists(ctx, ref)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if !found {
+			return admission.Denied(fmt.Sprintf("Secret %s referenced by the spec does not exist", ref))
+		}
+	}
+	return admission.Allowed("")
+}
+
+func (v *{{ lower .Resource.Kind }}ReferenceValidator) secretExists(ctx context.Context, key types.NamespacedName) (bool, error) {
+	now := time.Now()
+	v.mu.Lock()
+	expiry, remembered := v.found[key]
+	v.mu.Unlock()
+	if remembered && now.Before(expiry) {
+		return true, nil
+	}
+
+	err := v.reader.Get(ctx, key, &corev1.Secret{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.found == nil {
+		v.found = map[types.NamespacedName]time.Time{}
+	}
+	for k, expiry := range v.found {
+		if now.After(expiry) {
+			delete(v.found, k)
+		}
+	}
+	v.found[key] = now.Add({{ lower .Resource.Kind }}ReferenceTTL)
+	return true, nil
+}
+`
@@ -0,0 +1,154 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &RemoteCluster{}
+
+// RemoteCluster scaffolds the controllers/remote_cluster.go file, giving the
+// controllers a cache and client of a second cluster, read from a kubeconfig
+// Secret.
+type RemoteCluster struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (c *RemoteCluster) GetInput() (input.Input, error) {
+	if c.Path == "" {
+		c.Path = filepath.Join("controllers", "remote_cluster.go")
+	}
+	c.TemplateBody = remoteClusterTemplate
+	c.Input.IfExistsAction = input.Error
+	return c.Input, nil
+}
+
+var remoteClusterTemplate = `{{ .Boilerplate }}
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// RemoteClusterKubeconfigKey is the key of the kubeconfig in the Secret of a
+// remote cluster.
+const RemoteClusterKubeconfigKey = "kubeconfig"
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// RemoteCluster is a cluster other than the one of the manager, watched by the
+// controllers through a cache of its own. Add it to the manager to start its
+// cache, and watch its objects with Source. The permissions on its objects are
+// the ones of the user of its kubeconfig, they are not part of config/rbac.
+type RemoteCluster struct {
+	// Name is the name of the cluster, as logged by the controllers
+	Name   string
+	Config *rest.Config
+	Scheme *runtime.Scheme
+	// Cache is the cache of the objects of the cluster watched by the
+	// controllers
+	Cache cache.Cache
+	// Client reads the objects of the cluster from Cache, and writes them to
+	// the cluster
+	Client client.Client
+}
+
+// NewRemoteCluster returns the RemoteCluster of the given config.
+func NewRemoteCluster(name string, config *rest.Config, scheme *runtime.Scheme) (*RemoteCluster, error) {
+	mapper, err := apiutil.NewDiscoveryRESTMapper(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to discover the APIs of cluster %s: %v", name, err)
+	}
+	c, err := cache.New(config, cache.Options{Scheme: scheme, Mapper: mapper})
+	if err != nil {
+		return nil, err
+	}
+	direct, err := client.New(config, client.Options{Scheme: scheme, Mapper: mapper})
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteCluster{
+		Name:   name,
+		Config: config,
+		Scheme: scheme,
+		Cache:  c,
+		Client: &client.DelegatingClient{
+			Reader:       &client.DelegatingReader{CacheReader: c, ClientReader: direct},
+			Writer:       direct,
+			StatusClient: direct,
+		},
+	}, nil
+}
+
+// NewRemoteClusterFromSecret returns the RemoteCluster of the kubeconfig of the
+// given <namespace>/<name> Secret, under its RemoteClusterKubeconfigKey key.
+// The Secret is read with reader, e.g. the API reader of the manager, since
+// the cache of the manager is not started yet.
+func NewRemoteClusterFromSecret(ctx context.Context, reader client.Reader, name, secret string,
+	scheme *runtime.Scheme) (*RemoteCluster, error) {
+	parts := strings.SplitN(secret, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("kubeconfig secret %q of cluster %s must be <namespace>/<name>", secret, name)
+	}
+	s := &corev1.Secret{}
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: parts[0], Name: parts[1]}, s); err != nil {
+		return nil, err
+	}
+	kubeconfig, found := s.Data[RemoteClusterKubeconfigKey]
+	if !found {
+		return nil, fmt.Errorf("secret %s has no %s key", secret, RemoteClusterKubeconfigKey)
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig in secret %s: %v", secret, err)
+	}
+	return NewRemoteCluster(name, config, scheme)
+}
+
+// Start implements manager.Runnable, running the cache of the cluster until
+// stop is closed.
+func (c *RemoteCluster) Start(stop <-chan struct{}) error {
+	return c.Cache.Start(stop)
+}
+
+// Source returns a source of the events of the objects of the given type in
+// the cluster, to be watched by a controller of the manager.
+func (c *RemoteCluster) Source(obj runtime.Object) source.Source {
+	src := &source.Kind{Type: obj}
+	// the cache injected by the manager when the source is watched is only
+	// used if none is injected before
+	_ = src.InjectCache(c.Cache)
+	return src
+}
+`
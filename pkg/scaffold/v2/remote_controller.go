@@ -0,0 +1,96 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &RemoteController{}
+
+// RemoteController scaffolds the controllers/remote_configmap_controller.go
+// file, an example controller reconciling the ConfigMaps of the remote cluster.
+type RemoteController struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (c *RemoteController) GetInput() (input.Input, error) {
+	if c.Path == "" {
+		c.Path = filepath.Join("controllers", "remote_configmap_controller.go")
+	}
+	c.TemplateBody = remoteControllerTemplate
+	c.Input.IfExistsAction = input.Error
+	return c.Input, nil
+}
+
+var remoteControllerTemplate = `{{ .Boilerplate }}
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// RemoteConfigMapReconciler is an example controller reconciling the
+// ConfigMaps of a remote cluster, which can write to the cluster of the
+// manager with Client.
+type RemoteConfigMapReconciler struct {
+	// Client is the client of the cluster of the manager
+	Client client.Client
+	// Remote is the cluster whose ConfigMaps are reconciled
+	Remote *RemoteCluster
+	Log    logr.Logger
+}
+
+func (r *RemoteConfigMapReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("cluster", r.Remote.Name, "configmap", req.NamespacedName)
+
+	cm := &corev1.ConfigMap{}
+	if err := r.Remote.Client.Get(ctx, req.NamespacedName, cm); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// your logic here
+	log.Info("reconciling remote configmap", "keys", len(cm.Data))
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager watches the ConfigMaps of the remote cluster. The builder
+// is not used since it watches the type it reconciles in the cluster of the
+// manager.
+func (r *RemoteConfigMapReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	c, err := controller.New("remote-configmap", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	return c.Watch(r.Remote.Source(&corev1.ConfigMap{}), &handler.EnqueueRequestForObject{})
+}
+`
@@ -0,0 +1,88 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/internal"
+)
+
+var _ input.File = &SchemeRegistry{}
+
+// SchemeRegistry scaffolds the api/scheme.go file which collects the
+// AddToScheme functions of every API group version in the project, for
+// projects using the "registry" scheme registration strategy.
+type SchemeRegistry struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (s *SchemeRegistry) GetInput() (input.Input, error) {
+	if s.Path == "" {
+		s.Path = filepath.Join("api", "scheme.go")
+	}
+	s.TemplateBody = schemeRegistryTemplate
+	s.Input.IfExistsAction = input.Skip
+	return s.Input, nil
+}
+
+// Update updates api/scheme.go with the code fragments required to register
+// the given resource's group version.
+func (s *SchemeRegistry) Update(r *resource.Resource) error {
+	if s.Path == "" {
+		s.Path = filepath.Join("api", "scheme.go")
+	}
+
+	resPkg, _ := getResourceInfo(r, s.Input)
+
+	apiImportCodeFragment := fmt.Sprintf(`%s%s "%s/%s"
+`, r.Group, r.Version, resPkg, r.Version)
+	addschemeCodeFragment := fmt.Sprintf(`%s%s.AddToScheme,
+`, r.Group, r.Version)
+
+	return internal.InsertStringsInFile(s.Path,
+		map[string][]string{
+			apiPkgImportScaffoldMarker: []string{apiImportCodeFragment},
+			apiSchemeScaffoldMarker:    []string{addschemeCodeFragment},
+		})
+}
+
+var schemeRegistryTemplate = fmt.Sprintf(`{{ .Boilerplate }}
+
+// Package api registers all the API group versions of this project.
+package api
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+
+	%s
+)
+
+// AddToSchemes may be used to add all resources defined in the project to a Scheme
+var AddToSchemes = runtime.SchemeBuilder{
+	%s
+}
+
+// AddToScheme adds all Resources to the Scheme
+func AddToScheme(s *runtime.Scheme) error {
+	return AddToSchemes.AddToScheme(s)
+}
+`, apiPkgImportScaffoldMarker, apiSchemeScaffoldMarker)
@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &SecretProviderClass{}
+
+// SecretProviderClass scaffolds the SecretProviderClass describing where the
+// secrets-store CSI driver reads the webhook serving certificate from.
+type SecretProviderClass struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (p *SecretProviderClass) GetInput() (input.Input, error) {
+	if p.Path == "" {
+		p.Path = filepath.Join("config", "secretstore", "secretproviderclass.yaml")
+	}
+	p.TemplateBody = secretProviderClassTemplate
+	p.Input.IfExistsAction = input.Skip
+	return p.Input, nil
+}
+
+var secretProviderClassTemplate = `# The SecretProviderClass below reads the webhook serving certificate from
+# Vault. Edit the provider and its parameters to match the secret store of
+# your cluster, e.g. azure or gcp. The key pair must be issued for the DNS name
+# of the webhook service, <namePrefix>webhook-service<nameSuffix>.<namespace>.svc.
+apiVersion: secrets-store.csi.x-k8s.io/v1
+kind: SecretProviderClass
+metadata:
+  name: webhook-server-cert
+  namespace: system
+spec:
+  provider: vault
+  parameters:
+    vaultAddress: https://vault.vault.svc:8200
+    roleName: controller-manager
+    objects: |
+      - objectName: tls.crt
+        secretPath: secret/data/webhook-server-cert
+        secretKey: tls.crt
+      - objectName: tls.key
+        secretPath: secret/data/webhook-server-cert
+        secretKey: tls.key
+`
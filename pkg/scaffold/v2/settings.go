@@ -0,0 +1,225 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &Settings{}
+
+// Settings scaffolds the controllers/settings.go file, a Runnable which
+// watches the settings ConfigMap of the operator so that its controllers pick
+// up the changes without a restart.
+type Settings struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (s *Settings) GetInput() (input.Input, error) {
+	if s.Path == "" {
+		s.Path = filepath.Join("controllers", "settings.go")
+	}
+	s.TemplateBody = settingsTemplate
+	s.Input.IfExistsAction = input.Error
+	return s.Input, nil
+}
+
+var settingsTemplate = `{{ .Boilerplate }}
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+
+// Settings watches the data of the settings ConfigMap of the operator. The
+// controllers read it with Get, and are given an event on each change with
+// Source, to reconcile all their objects with the new settings. The changes
+// can also be handled by the functions registered with OnChange, to reload a
+// component.
+type Settings struct {
+	// Config is used to watch the settings ConfigMap
+	Config *rest.Config
+	Log    logr.Logger
+
+	// Name is the name of the settings ConfigMap
+	Name string
+	// Namespace is the namespace of the settings ConfigMap. It defaults to the
+	// namespace the manager is running in, or default outside of a cluster.
+	Namespace string
+
+	mu       sync.RWMutex
+	data     map[string]string
+	onChange []func(data map[string]string)
+	events   []chan event.GenericEvent
+}
+
+// Get returns the value of the setting key, and whether it is set.
+func (s *Settings) Get(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, found := s.data[key]
+	return value, found
+}
+
+// OnChange registers f to be called with the data of the settings ConfigMap
+// each time it changes, empty once it is deleted.
+func (s *Settings) OnChange(f func(data map[string]string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = append(s.onChange, f)
+}
+
+// Source returns a source of an event for each change of the settings, to
+// be watched by a controller with the handler returned by EnqueueAll.
+func (s *Settings) Source() source.Source {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// a pending event already reconciles with the latest settings, so a
+	// single one is buffered
+	events := make(chan event.GenericEvent, 1)
+	s.events = append(s.events, events)
+	return &source.Channel{Source: events}
+}
+
+// EnqueueAll returns a handler enqueuing a reconcile of each object of list,
+// e.g. &v1.FrigateList{}, read with c.
+func (s *Settings) EnqueueAll(c client.Client, list runtime.Object) handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(handler.MapObject) []reconcile.Request {
+			objects := list.DeepCopyObject()
+			if err := c.List(context.Background(), objects); err != nil {
+				s.Log.Error(err, "unable to list the objects to reconcile with the new settings")
+				return nil
+			}
+			items, err := meta.ExtractList(objects)
+			if err != nil {
+				s.Log.Error(err, "unable to list the objects to reconcile with the new settings")
+				return nil
+			}
+			requests := make([]reconcile.Request, 0, len(items))
+			for _, item := range items {
+				accessor, err := meta.Accessor(item)
+				if err != nil {
+					continue
+				}
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: accessor.GetNamespace(),
+					Name:      accessor.GetName(),
+				}})
+			}
+			return requests
+		}),
+	}
+}
+
+// Start implements manager.Runnable, watching the settings ConfigMap until
+// stop is closed.
+func (s *Settings) Start(stop <-chan struct{}) error {
+	if s.Namespace == "" {
+		var inCluster bool
+		s.Namespace, inCluster = operatorNamespace()
+		if !inCluster {
+			s.Log.Info("not running in a cluster, reading the settings from the default namespace, "+
+				"set another one with --settings-namespace", "namespace", s.Namespace)
+		}
+	}
+	clientset, err := kubernetes.NewForConfig(s.Config)
+	if err != nil {
+		return err
+	}
+
+	// only the settings ConfigMap is watched, rather than caching all the
+	// ConfigMaps of the namespace
+	watchlist := cache.NewListWatchFromClient(clientset.CoreV1().RESTClient(), "configmaps", s.Namespace,
+		fields.OneTermEqualSelector("metadata.name", s.Name))
+	_, informer := cache.NewInformer(watchlist, &corev1.ConfigMap{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			s.update(obj.(*corev1.ConfigMap).Data)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			s.update(obj.(*corev1.ConfigMap).Data)
+		},
+		DeleteFunc: func(interface{}) {
+			s.update(nil)
+		},
+	})
+	informer.Run(stop)
+	return nil
+}
+
+// update records the new data of the settings ConfigMap, notifying the
+// controllers and the functions registered with OnChange if it changed.
+func (s *Settings) update(data map[string]string) {
+	settings := map[string]string{}
+	for key, value := range data {
+		settings[key] = value
+	}
+
+	s.mu.Lock()
+	if s.data != nil && reflect.DeepEqual(s.data, settings) {
+		s.mu.Unlock()
+		return
+	}
+	s.data = settings
+	onChange := append([]func(data map[string]string){}, s.onChange...)
+	events := append([]chan event.GenericEvent{}, s.events...)
+	s.mu.Unlock()
+
+	s.Log.Info("settings changed", "configmap", s.Namespace+"/"+s.Name)
+	for _, f := range onChange {
+		copied := map[string]string{}
+		for key, value := range settings {
+			copied[key] = value
+		}
+		f(copied)
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: s.Namespace, Name: s.Name},
+		Data:       settings,
+	}
+	for _, ch := range events {
+		select {
+		case ch <- event.GenericEvent{Meta: cm, Object: cm}:
+		default:
+		}
+	}
+}
+`
@@ -0,0 +1,155 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &ShardAutoscaling{}
+
+// ShardAutoscaling scaffolds the controllers/shard_autoscaling.go file, which
+// makes the number of shards follow the replicas of a StatefulSet scaled by
+// an autoscaler.
+type ShardAutoscaling struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (s *ShardAutoscaling) GetInput() (input.Input, error) {
+	if s.Path == "" {
+		s.Path = filepath.Join("controllers", "shard_autoscaling.go")
+	}
+	s.TemplateBody = shardAutoscalingTemplate
+	s.Input.IfExistsAction = input.Error
+	return s.Input, nil
+}
+
+var shardAutoscalingTemplate = `{{ .Boilerplate }}
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get
+
+// ShardFromStatefulSet returns the shard of this replica out of the replicas
+// of its StatefulSet, for a StatefulSet scaled by an autoscaler, which can not
+// update SHARD_COUNT. Its ordinal is the suffix of the pod name, read from the
+// POD_NAME environment variable, the StatefulSet being named after the pod
+// name without it. Read it with mgr.GetAPIReader(), the cache of the manager
+// not being started yet, and add a ShardCountWatcher of the shard to the
+// manager.
+func ShardFromStatefulSet(ctx context.Context, reader client.Reader) (Shard, error) {
+	key, ordinal, err := statefulSetOfPod()
+	if err != nil {
+		return Shard{}, err
+	}
+	replicas, err := statefulSetReplicas(ctx, reader, key)
+	if err != nil {
+		return Shard{}, err
+	}
+	if ordinal >= replicas {
+		return Shard{}, fmt.Errorf("pod %q is out of the %d replicas of StatefulSet %s", os.Getenv("POD_NAME"), replicas, key)
+	}
+	return Shard{Ordinal: ordinal, Count: replicas}, nil
+}
+
+// ShardCountWatcher is a Runnable stopping the manager once the replicas of
+// the StatefulSet of this replica differ from the number of shards, so that
+// the pod restarts with the new number after the StatefulSet was scaled.
+// Until every pod has restarted, two shards can reconcile the same object:
+// the reconciles must be idempotent.
+type ShardCountWatcher struct {
+	// Reader is used to read the StatefulSet without starting an informer
+	Reader client.Reader
+	Log    logr.Logger
+
+	// Shard is the shard of this replica
+	Shard Shard
+	// Interval is how often the replicas are checked, 30 seconds by default
+	Interval time.Duration
+}
+
+// Start implements manager.Runnable, checking the replicas until stop is
+// closed or they changed.
+func (w *ShardCountWatcher) Start(stop <-chan struct{}) error {
+	key, _, err := statefulSetOfPod()
+	if err != nil {
+		return err
+	}
+	if w.Interval <= 0 {
+		w.Interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+		replicas, err := statefulSetReplicas(context.Background(), w.Reader, key)
+		if err != nil {
+			w.Log.Error(err, "unable to read the replicas", "statefulset", key.String())
+			continue
+		}
+		if replicas != w.Shard.Count {
+			return fmt.Errorf("StatefulSet %s was scaled from %d to %d replicas, restarting with %d shards",
+				key, w.Shard.Count, replicas, replicas)
+		}
+	}
+}
+
+// statefulSetOfPod returns the StatefulSet of this pod and its ordinal.
+func statefulSetOfPod() (types.NamespacedName, int, error) {
+	podName := os.Getenv("POD_NAME")
+	i := strings.LastIndex(podName, "-")
+	ordinal, err := strconv.Atoi(podName[i+1:])
+	if i < 0 || err != nil {
+		return types.NamespacedName{}, 0, fmt.Errorf("pod %q is not a pod of a StatefulSet", podName)
+	}
+	namespace, _ := operatorNamespace()
+	return types.NamespacedName{Namespace: namespace, Name: podName[:i]}, ordinal, nil
+}
+
+func statefulSetReplicas(ctx context.Context, reader client.Reader, key types.NamespacedName) (int, error) {
+	sts := &appsv1.StatefulSet{}
+	if err := reader.Get(ctx, key, sts); err != nil {
+		return 0, err
+	}
+	if sts.Spec.Replicas == nil {
+		return 1, nil
+	}
+	return int(*sts.Spec.Replicas), nil
+}
+`
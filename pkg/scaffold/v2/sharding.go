@@ -0,0 +1,137 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &Sharding{}
+
+// Sharding scaffolds the controllers/sharding.go file, which splits the
+// objects reconciled by the controllers across the replicas of a StatefulSet.
+type Sharding struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (s *Sharding) GetInput() (input.Input, error) {
+	if s.Path == "" {
+		s.Path = filepath.Join("controllers", "sharding.go")
+	}
+	s.TemplateBody = shardingTemplate
+	s.Input.IfExistsAction = input.Error
+	return s.Input, nil
+}
+
+var shardingTemplate = `{{ .Boilerplate }}
+
+package controllers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// Shard identifies the objects reconciled by one replica of a manager
+// deployed as a StatefulSet, each pod of which is a shard.
+//
+// To shard a controller, filter its events with the predicate of the shard:
+//
+//	ctrl.NewControllerManagedBy(mgr).
+//		For(&v1.Kind{}).
+//		WithEventFilter(shard.Predicate()).
+//		Complete(r)
+type Shard struct {
+	// Ordinal is the index of this shard, from 0 to Count-1
+	Ordinal int
+	// Count is the number of shards, which must match the StatefulSet replicas
+	Count int
+}
+
+// ShardFromEnv returns the shard of this replica. Its ordinal is the suffix of
+// the StatefulSet pod name, read from the POD_NAME environment variable, and
+// the number of shards is read from the SHARD_COUNT environment variable.
+// Without SHARD_COUNT, the replica is the only shard and reconciles every object.
+func ShardFromEnv() (Shard, error) {
+	count := os.Getenv("SHARD_COUNT")
+	if count == "" {
+		return Shard{Ordinal: 0, Count: 1}, nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 1 {
+		return Shard{}, fmt.Errorf("invalid SHARD_COUNT %q", count)
+	}
+
+	podName := os.Getenv("POD_NAME")
+	ordinal, err := strconv.Atoi(podName[strings.LastIndex(podName, "-")+1:])
+	if err != nil || ordinal >= n {
+		return Shard{}, fmt.Errorf("unable to determine the shard of pod %q out of %d shards", podName, n)
+	}
+	return Shard{Ordinal: ordinal, Count: n}, nil
+}
+
+// Owns returns whether the object is reconciled by this shard. Objects are
+// assigned to shards by a consistent hash of their namespace and name, so
+// changing the number of shards only moves the objects of the added or
+// removed shards. Objects controlled by another object are assigned to the
+// shard of their controller, so that a controller sees the events of the
+// objects it owns.
+func (s Shard) Owns(obj metav1.Object) bool {
+	if s.Count <= 1 {
+		return true
+	}
+	name := obj.GetName()
+	if owner := metav1.GetControllerOf(obj); owner != nil {
+		name = owner.Name
+	}
+	h := fnv.New64a()
+	h.Write([]byte(obj.GetNamespace() + "/" + name)) // nolint: errcheck
+	return jumpHash(h.Sum64(), s.Count) == s.Ordinal
+}
+
+// Predicate filters out the events of the objects this shard does not own.
+func (s Shard) Predicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return s.Owns(e.Meta) },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return s.Owns(e.MetaNew) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return s.Owns(e.Meta) },
+		GenericFunc: func(e event.GenericEvent) bool { return s.Owns(e.Meta) },
+	}
+}
+
+// jumpHash is the jump consistent hash of Lamping and Veach, mapping key to
+// one of the given number of buckets.
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+`
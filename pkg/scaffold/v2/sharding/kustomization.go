@@ -0,0 +1,88 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &Kustomization{}
+
+// Kustomization scaffolds the Kustomization file of the sharded overlay,
+// which deploys the manager as a StatefulSet instead of a Deployment.
+type Kustomization struct {
+	input.Input
+
+	// Prefix to use for name prefix customization
+	Prefix string
+
+	// Suffix to use for name suffix customization
+	Suffix string
+
+	// Namespace to deploy the resources to
+	Namespace string
+}
+
+// GetInput implements input.File
+func (k *Kustomization) GetInput() (input.Input, error) {
+	if k.Path == "" {
+		k.Path = filepath.Join("config", "sharding", "kustomization.yaml")
+	}
+	var err error
+	if k.Prefix == "" {
+		if k.Prefix, err = k.GetNamePrefix(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	if k.Suffix == "" {
+		k.Suffix = k.NameSuffix
+	}
+	if k.Namespace == "" {
+		if k.Namespace, err = k.GetNamespace(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	k.TemplateBody = kustomizationTemplate
+	k.Input.IfExistsAction = input.Error
+	return k.Input, nil
+}
+
+var kustomizationTemplate = `# This overlay deploys the manager as a StatefulSet, each replica of which
+# reconciles a shard of the objects, in place of config/default. Deploy it with
+#   cd config/sharding && kustomize edit set image controller=${IMG}
+#   kustomize build config/sharding | kubectl apply -f -
+namespace: {{ .Namespace }}
+
+namePrefix: {{ .Prefix }}
+{{- if .Suffix }}
+nameSuffix: {{ .Suffix }}
+{{- end }}
+
+bases:
+- ../crd
+- ../rbac
+
+resources:
+- manager.yaml
+
+images:
+- name: controller
+  newName: controller
+  newTag: latest
+`
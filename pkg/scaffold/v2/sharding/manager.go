@@ -0,0 +1,131 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &Manager{}
+
+// Manager scaffolds the StatefulSet running one shard of the manager per pod.
+type Manager struct {
+	input.Input
+
+	// DualStack indicates whether the governing Service prefers both IP
+	// families, as the Services scaffolded by init do
+	DualStack bool
+}
+
+// GetInput implements input.File
+func (m *Manager) GetInput() (input.Input, error) {
+	if m.Path == "" {
+		m.Path = filepath.Join("config", "sharding", "manager.yaml")
+	}
+	m.TemplateBody = managerTemplate
+	m.Input.IfExistsAction = input.Error
+	return m.Input, nil
+}
+
+var managerTemplate = `apiVersion: v1
+kind: Namespace
+metadata:
+  labels:
+    control-plane: controller-manager
+  name: system
+---
+# The StatefulSet needs a governing service, which gives every shard a stable
+# network identity.
+apiVersion: v1
+kind: Service
+metadata:
+  name: controller-manager-shards
+  namespace: system
+  labels:
+    control-plane: controller-manager
+spec:
+  clusterIP: None
+{{- if .DualStack }}
+  ipFamilyPolicy: PreferDualStack
+{{- end }}
+  selector:
+    control-plane: controller-manager
+  ports:
+  - name: metrics
+    port: 8080
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: controller-manager
+  namespace: system
+  labels:
+    control-plane: controller-manager
+spec:
+  serviceName: controller-manager-shards
+  # Shards start and stop independently of each other.
+  podManagementPolicy: Parallel
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+  # SHARD_COUNT below must be changed along with the number of replicas.
+  replicas: 2
+  template:
+    metadata:
+      labels:
+        control-plane: controller-manager
+    spec:
+      # Run the pods as allowed by the restricted Pod Security Standard
+      securityContext:
+        runAsNonRoot: true
+        seccompProfile:
+          type: RuntimeDefault
+      containers:
+      - command:
+        - /manager
+        # Each shard reconciles its own objects, so leader election stays
+        # disabled: every replica runs its controllers.
+        args:
+        - --metrics-addr=:8080
+        env:
+        - name: POD_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+        - name: SHARD_COUNT
+          value: "2"
+        image: controller:latest
+        name: manager
+        ports:
+        - containerPort: 8080
+          name: metrics
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop:
+            - ALL
+        resources:
+          limits:
+            cpu: 100m
+            memory: 30Mi
+          requests:
+            cpu: 100m
+            memory: 20Mi
+      terminationGracePeriodSeconds: 10
+`
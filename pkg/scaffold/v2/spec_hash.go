@@ -0,0 +1,162 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &SpecHash{}
+
+// SpecHash scaffolds the helper shared by the controllers of a package writing
+// their children only when the hash of their desired state changes
+type SpecHash struct {
+	input.Input
+
+	// Resource is a Resource whose controller writes its children with the helper
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (s *SpecHash) GetInput() (input.Input, error) {
+	if s.Path == "" {
+		s.Path = filepath.Join(controllersDir(s.Resource, s.Input), "spec_hash.go")
+	}
+	s.TemplateBody = specHashTemplate
+	s.Input.IfExistsAction = input.Skip
+	return s.Input, nil
+}
+
+var specHashTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// SpecHashAnnotation records on a child the hash of the desired state it was
+// last written with.
+const SpecHashAnnotation = "{{ .Domain }}/spec-hash"
+
+var (
+	childUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_child_updates_total",
+		Help: "Total number of updates of children whose desired state changed, per controller",
+	}, []string{"controller"})
+	childUpdatesSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_child_updates_skipped_total",
+		Help: "Total number of updates of children skipped as their desired state was unchanged, per controller",
+	}, []string{"controller"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(childUpdates, childUpdatesSkipped)
+}
+
+// SpecHash returns the hash of the desired state of a child, the hex SHA-256 of
+// its JSON encoding.
+func SpecHash(desired interface{}) (string, error) {
+	data, err := json.Marshal(desired)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CreateOrUpdateChild creates or updates the child obj of a reconcile of the
+// controller, like controllerutil.CreateOrUpdate: obj is read and mutate sets
+// its desired state. Desired is the desired state computed by the reconcile,
+// e.g. the spec mutate sets, whose hash is recorded in the SpecHashAnnotation of
+// the child.
+//
+// The update of a child written with the same hash is skipped without calling
+// mutate, and counted in the controller_child_updates_skipped_total metric. The
+// comparison does not depend on the fields defaulted by the apiserver, which
+// make a mutated child differ from the one read, so the children reconciled
+// again and again are not written each time. The changes made to a child by
+// others are not reverted until its desired state changes, though: include
+// all the fields the controller owns in desired.
+func CreateOrUpdateChild(ctx context.Context, c client.Client, controller string, obj runtime.Object,
+	desired interface{}, mutate controllerutil.MutateFn) (controllerutil.OperationResult, error) {
+	hash, err := SpecHash(desired)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	key, err := client.ObjectKeyFromObject(obj)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	if err := c.Get(ctx, key, obj); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return controllerutil.OperationResultNone, err
+		}
+		if err := mutate(); err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		setSpecHash(accessor, hash)
+		if err := c.Create(ctx, obj); err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		return controllerutil.OperationResultCreated, nil
+	}
+
+	if accessor.GetAnnotations()[SpecHashAnnotation] == hash {
+		childUpdatesSkipped.WithLabelValues(controller).Inc()
+		return controllerutil.OperationResultNone, nil
+	}
+	if err := mutate(); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	setSpecHash(accessor, hash)
+	if err := c.Update(ctx, obj); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	childUpdates.WithLabelValues(controller).Inc()
+	return controllerutil.OperationResultUpdated, nil
+}
+
+func setSpecHash(obj metav1.Object, hash string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[SpecHashAnnotation] = hash
+	obj.SetAnnotations(annotations)
+}
+`
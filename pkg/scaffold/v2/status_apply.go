@@ -0,0 +1,186 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &StatusApply{}
+
+// StatusApply scaffolds the helper applying the status of a Resource with
+// server-side apply, along with the apply configuration of the Resource
+type StatusApply struct {
+	input.Input
+
+	// Resource is the Resource to make the helper for
+	Resource *resource.Resource
+
+	// ResourcePackage is the package of the Resource
+	ResourcePackage string
+}
+
+// GetInput implements input.File
+func (s *StatusApply) GetInput() (input.Input, error) {
+	s.ResourcePackage, _ = getResourceInfo(s.Resource, s.Input)
+	if s.Path == "" {
+		s.Path = filepath.Join(controllersDir(s.Resource, s.Input),
+			strings.ToLower(s.Resource.Kind)+"_status.go")
+	}
+	s.TemplateBody = statusApplyTemplate
+	s.Input.IfExistsAction = input.Error
+	return s.Input, nil
+}
+
+var statusApplyTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	{{ .Resource.Group }}{{ .Resource.Version }} "{{ .ResourcePackage }}/{{ .Resource.Version }}"
+)
+
+// {{ .Resource.Kind }}ApplyConfiguration is the part of a {{ .Resource.Kind }} applied by the
+// operator with server-side apply. Only the fields it sets are sent, and owned
+// by FieldManager, so the status is written without reading the object first
+// and without the conflicts of a Get and Update loop.
+type {{ .Resource.Kind }}ApplyConfiguration struct {
+	metav1.TypeMeta ` + "`" + `json:",inline"` + "`" + `
+	ObjectMeta      applyObjectMeta ` + "`" + `json:"metadata"` + "`" + `
+	Status          *{{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}Status ` + "`" + `json:"status,omitempty"` + "`" + `
+}
+
+// {{ .Resource.Kind }}Apply returns the apply configuration of the {{ .Resource.Kind }} with the
+// given name and namespace.
+func {{ .Resource.Kind }}Apply(name, namespace string) *{{ .Resource.Kind }}ApplyConfiguration {
+	return &{{ .Resource.Kind }}ApplyConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: {{ .Resource.Group }}{{ .Resource.Version }}.GroupVersion.String(),
+			Kind:       "{{ .Resource.Kind }}",
+		},
+		ObjectMeta: applyObjectMeta{Name: name, Namespace: namespace},
+	}
+}
+
+// WithStatus sets the status to apply. The fields of {{ .Resource.Kind }}Status should all
+// be omitempty: the zero values sent would be owned by FieldManager as well.
+func (c *{{ .Resource.Kind }}ApplyConfiguration) WithStatus(status {{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}Status) *{{ .Resource.Kind }}ApplyConfiguration {
+	c.Status = &status
+	return c
+}
+
+// Apply{{ .Resource.Kind }}Status applies the status of the given configuration as
+// FieldManager, forcing the conflicts with the other managers since the operator
+// is the authority on the status. The applied object is read back into obj.
+//
+// For example, at the end of Reconcile:
+//	status := {{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}Status{}
+//	// TODO(user): fill in the observed state
+//	config := {{ .Resource.Kind }}Apply(req.Name, req.Namespace).WithStatus(status)
+//	if err := Apply{{ .Resource.Kind }}Status(ctx, r, config, &{{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}{}); err != nil {
+//		return ctrl.Result{}, err
+//	}
+func Apply{{ .Resource.Kind }}Status(ctx context.Context, c client.StatusClient, config *{{ .Resource.Kind }}ApplyConfiguration, obj *{{ .Resource.Group }}{{ .Resource.Version }}.{{ .Resource.Kind }}) error {
+	obj.Name, obj.Namespace = config.ObjectMeta.Name, config.ObjectMeta.Namespace
+	return c.Status().Patch(ctx, obj, applyPatch{config: config},
+		client.FieldOwner(FieldManager), client.ForceOwnership)
+}
+`
+
+var _ input.File = &ApplyPatch{}
+
+// ApplyPatch scaffolds the server-side apply patch shared by the status
+// helpers of a controllers package
+type ApplyPatch struct {
+	input.Input
+
+	// Resource is a Resource of the controllers package
+	Resource *resource.Resource
+
+	// FieldManager is the name of the field manager of the operator
+	FieldManager string
+}
+
+// GetInput implements input.File
+func (a *ApplyPatch) GetInput() (input.Input, error) {
+	if a.Path == "" {
+		a.Path = filepath.Join(controllersDir(a.Resource, a.Input), "apply.go")
+	}
+	if a.FieldManager == "" {
+		// use the project name as the field manager, like the name prefix
+		name, err := a.GetProjectName()
+		if err != nil {
+			return input.Input{}, err
+		}
+		a.FieldManager = name
+	}
+	a.TemplateBody = applyPatchTemplate
+	a.Input.IfExistsAction = input.Skip
+	return a.Input, nil
+}
+
+var applyPatchTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FieldManager is the name the operator applies its changes with. The
+// apiserver tracks the fields each manager owns by name, so it must not
+// change between releases of the operator.
+const FieldManager = "{{ .FieldManager }}"
+
+// applyObjectMeta is the metadata of an apply configuration, identifying the
+// object to apply.
+type applyObjectMeta struct {
+	Name      string ` + "`" + `json:"name"` + "`" + `
+	Namespace string ` + "`" + `json:"namespace,omitempty"` + "`" + `
+}
+
+// applyPatch is a server-side apply patch sending an apply configuration
+// rather than the object it is applied to.
+type applyPatch struct {
+	config interface{}
+}
+
+var _ client.Patch = applyPatch{}
+
+// Type implements client.Patch.
+func (p applyPatch) Type() types.PatchType {
+	return types.ApplyPatchType
+}
+
+// Data implements client.Patch.
+func (p applyPatch) Data(obj runtime.Object) ([]byte, error) {
+	return json.Marshal(p.config)
+}
+`
@@ -0,0 +1,255 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &TLSConfig{}
+
+// TLSConfig scaffolds the tlsconfig/tlsconfig.go file, which registers the
+// --tls-min-version and --tls-cipher-suites flags of the manager and serves the
+// webhooks with their TLS configuration. It is scaffolded along the first
+// webhook of the project.
+type TLSConfig struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (c *TLSConfig) GetInput() (input.Input, error) {
+	if c.Path == "" {
+		c.Path = filepath.Join("tlsconfig", "tlsconfig.go")
+	}
+	c.TemplateBody = tlsConfigTemplate
+	c.Input.IfExistsAction = input.Skip
+	return c.Input, nil
+}
+
+var tlsConfigTemplate = `{{ .Boilerplate }}
+
+// Package tlsconfig configures the TLS versions and cipher suites the webhooks
+// of the manager are served with.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var minVersionFlag, cipherSuitesFlag string
+
+func init() {
+	flag.StringVar(&minVersionFlag, "tls-min-version", "VersionTLS12",
+		"The minimum TLS version the webhooks are served with, one of VersionTLS10, VersionTLS11, VersionTLS12 or VersionTLS13.")
+	flag.StringVar(&cipherSuitesFlag, "tls-cipher-suites", "",
+		"The comma-separated IANA names of the TLS cipher suites the webhooks are served with. Empty uses the Go defaults.")
+}
+
+// versions are the TLS versions by the names of their crypto/tls constants.
+var versions = map[string]uint16{
+	"VersionTLS10": tls.VersionTLS10,
+	"VersionTLS11": tls.VersionTLS11,
+	"VersionTLS12": tls.VersionTLS12,
+	"VersionTLS13": tls.VersionTLS13,
+}
+
+// cipherSuites are the cipher suites of TLS 1.2 and below by their IANA
+// names, the cipher suites of TLS 1.3 are not configurable.
+var cipherSuites = map[string]uint16{
+	"TLS_RSA_WITH_RC4_128_SHA":                tls.TLS_RSA_WITH_RC4_128_SHA,
+	"TLS_RSA_WITH_3DES_EDE_CBC_SHA":           tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+	"TLS_RSA_WITH_AES_128_CBC_SHA":            tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_RSA_WITH_AES_256_CBC_SHA":            tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_RSA_WITH_AES_128_CBC_SHA256":         tls.TLS_RSA_WITH_AES_128_CBC_SHA256,
+	"TLS_RSA_WITH_AES_128_GCM_SHA256":         tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_RSA_WITH_AES_256_GCM_SHA384":         tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_RC4_128_SHA":        tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_RC4_128_SHA":          tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA,
+	"TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA":     tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384": tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305":    tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305":  tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+}
+
+// New returns the TLS configuration of the given minimum version, the name of
+// a crypto/tls constant, e.g. VersionTLS12, and cipher suites, given as a
+// comma-separated list of IANA names which defaults to the Go cipher suites
+// when empty.
+func New(minVersion, suites string) (*tls.Config, error) {
+	version, found := versions[minVersion]
+	if !found {
+		return nil, fmt.Errorf("unknown TLS version %q, must be one of VersionTLS10, VersionTLS11, "+
+			"VersionTLS12 or VersionTLS13", minVersion)
+	}
+	cfg := &tls.Config{MinVersion: version}
+	for _, name := range strings.Split(suites, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		suite, found := cipherSuites[name]
+		if !found {
+			return nil, fmt.Errorf("unknown TLS cipher suite %q", name)
+		}
+		cfg.CipherSuites = append(cfg.CipherSuites, suite)
+	}
+	return cfg, nil
+}
+
+// ServeWebhooks serves the webhooks registered with the webhook server of the
+// manager at addr, with the TLS configuration of the --tls-min-version and
+// --tls-cipher-suites flags. The webhook server of the manager only listens on
+// the loopback interface instead, localhost resolving to the loopback address
+// of whichever IP family the pod has.
+func ServeWebhooks(mgr manager.Manager, addr string) error {
+	cfg, err := New(minVersionFlag, cipherSuitesFlag)
+	if err != nil {
+		return fmt.Errorf("invalid TLS flags: %v", err)
+	}
+	server := mgr.GetWebhookServer()
+	server.Host, server.Port = "localhost", 9444
+	return mgr.Add(&WebhookServer{Server: server, Addr: addr, Config: cfg})
+}
+
+// WebhookServer serves the webhooks registered with the webhook server of the
+// manager with a TLS configuration of its own, which the webhook server of
+// controller-runtime does not allow to set. The webhook server of the manager
+// is bound to the loopback interface instead, where it injects the
+// dependencies of the webhooks when it starts.
+type WebhookServer struct {
+	// Server is the webhook server of the manager
+	Server *webhook.Server
+
+	// Addr is the address the webhooks are served at
+	Addr string
+
+	// Config is the TLS configuration the webhooks are served with, given the
+	// serving certificate of the webhook server of the manager
+	Config *tls.Config
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, the webhooks
+// are served by every replica.
+func (s *WebhookServer) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable, serving the webhooks until stop is closed.
+func (s *WebhookServer) Start(stop <-chan struct{}) error {
+	// the webhooks are only served once the webhook server of the manager has
+	// injected their dependencies, i.e. listens
+	if !s.waitForServer(stop) {
+		return nil
+	}
+
+	cert := &certificate{
+		certFile: filepath.Join(s.Server.CertDir, "tls.crt"),
+		keyFile:  filepath.Join(s.Server.CertDir, "tls.key"),
+	}
+	if _, err := cert.get(nil); err != nil {
+		return err
+	}
+	cfg := s.Config.Clone()
+	// the API server talks HTTP/2, other clients of the webhooks may not
+	cfg.NextProtos = []string{"h2", "http/1.1"}
+	cfg.GetCertificate = cert.get
+
+	listener, err := tls.Listen("tcp", s.Addr, cfg)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: s.Server.WebhookMux}
+	go func() {
+		<-stop
+		_ = srv.Shutdown(context.Background())
+	}()
+	if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// waitForServer waits for the webhook server of the manager to listen,
+// returning false if stop is closed first.
+func (s *WebhookServer) waitForServer(stop <-chan struct{}) bool {
+	addr := net.JoinHostPort(s.Server.Host, strconv.Itoa(s.Server.Port))
+	for {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			_ = conn.Close()
+			return true
+		}
+		select {
+		case <-stop:
+			return false
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// certificate is the serving certificate, reloaded when its file changes, e.g.
+// when cert-manager renews it.
+type certificate struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	modTime time.Time
+	cert    *tls.Certificate
+}
+
+func (c *certificate) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cert == nil || !info.ModTime().Equal(c.modTime) {
+		cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		c.cert, c.modTime = &cert, info.ModTime()
+	}
+	return c.cert, nil
+}
+`
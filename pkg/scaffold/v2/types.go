@@ -33,14 +33,18 @@ type Types struct {
 
 	// Resource is the resource to scaffold the types_test.go file for
 	Resource *resource.Resource
+
+	// PrinterColumns are the additional columns printed by kubectl get
+	PrinterColumns []PrinterColumn
 }
 
 // GetInput implements input.File
 func (t *Types) GetInput() (input.Input, error) {
 	if t.Path == "" {
-		t.Path = filepath.Join("pkg", "apis", t.Resource.Group, t.Resource.Version,
+		t.Path = filepath.Join(apiDir(t.Resource, t.Input),
 			fmt.Sprintf("%s_types.go", strings.ToLower(t.Resource.Kind)))
 	}
+	t.PrinterColumns = printerColumns(t.Resource)
 	t.TemplateBody = typesTemplate
 	t.IfExistsAction = input.Error
 	return t.Input, nil
@@ -66,15 +70,140 @@ import (
 type {{.Resource.Kind}}Spec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+	// Precede the fields with +kubebuilder:example=<value> markers to fill in
+	// the sample in config/samples.
+{{- if .Resource.EmbedPodTemplate }}
+
+	// Template describes the pods managed for this {{.Resource.Kind}}.
+	// +optional
+	Template *EmbeddedPodTemplateSpec ` + "`" + `json:"template,omitempty"` + "`" + `
+{{- end }}
+{{- if .Resource.PayloadReference }}
+
+	// Payload references the large payload of the {{.Resource.Kind}}, stored in
+	// a ConfigMap or a Secret rather than in its spec.
+	// +optional
+	Payload *PayloadReference ` + "`" + `json:"payload,omitempty"` + "`" + `
+{{- end }}
+{{- if .Resource.Scale }}
+
+	// Replicas is the desired number of pods of the {{.Resource.Kind}}, set by
+	// kubectl scale and the HorizontalPodAutoscaler through the scale
+	// subresource.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:example=1
+	// +optional
+	Replicas *int32 ` + "`" + `json:"replicas,omitempty"` + "`" + `
+{{- end }}
+{{- if .Resource.ValidationStubs }}
+
+	// The following fields show the common validation markers, enforced by
+	// the schema of the CRD. Adapt them to your fields, then remove them.
+	// See https://book.kubebuilder.io/reference/markers/crd-validation.html
+	// for the other markers.
+{{- if not .Resource.Scale }}
+
+	// Replicas shows the bounds of a number.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	// +kubebuilder:example=1
+	// +optional
+	Replicas *int32 ` + "`" + `json:"replicas,omitempty"` + "`" + `
+{{- end }}
+
+	// Name shows the pattern and length of a string, here a DNS-1123 label.
+	// +kubebuilder:validation:Pattern="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:example=my-name
+	// +optional
+	Name string ` + "`" + `json:"name,omitempty"` + "`" + `
+
+	// Mode shows the enumeration of the valid values of a string.
+	// +kubebuilder:validation:Enum=Auto;Manual
+	// +kubebuilder:example=Auto
+	// +optional
+	Mode string ` + "`" + `json:"mode,omitempty"` + "`" + `
+{{- end }}
+	// +kubebuilder:scaffold:spec
 }
 
+{{- if .Resource.Phases }}
+
+// {{.Resource.Kind}}Phase is the phase of the lifecycle of a {{.Resource.Kind}}.
+// +kubebuilder:validation:Enum={{ range $i, $phase := .Resource.Phases }}{{ if $i }};{{ end }}{{ $phase }}{{ end }}
+type {{.Resource.Kind}}Phase string
+
+const (
+{{- range .Resource.Phases }}
+	// {{$.Resource.Kind}}Phase{{ . }} is the {{ . }} phase of a {{$.Resource.Kind}}.
+	{{$.Resource.Kind}}Phase{{ . }} {{$.Resource.Kind}}Phase = "{{ . }}"
+{{- end }}
+)
+{{- end }}
+
 // {{.Resource.Kind}}Status defines the observed state of {{.Resource.Kind}}
 type {{.Resource.Kind}}Status struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+{{- if .Resource.Phases }}
+
+	// Phase is the phase of the lifecycle of the {{.Resource.Kind}}.
+	// +optional
+	Phase {{.Resource.Kind}}Phase ` + "`" + `json:"phase,omitempty"` + "`" + `
+{{- end }}
+{{- if .Resource.Scale }}
+
+	// Replicas is the observed number of pods of the {{.Resource.Kind}}.
+	// +optional
+	Replicas int32 ` + "`" + `json:"replicas,omitempty"` + "`" + `
+
+	// Selector is the label selector of the pods of the {{.Resource.Kind}}, in
+	// the string form read by the HorizontalPodAutoscaler through the scale
+	// subresource.
+	// +optional
+	Selector string ` + "`" + `json:"selector,omitempty"` + "`" + `
+{{- end }}
+{{- if or .Resource.CreationGuard .Resource.WithConditions }}
+
+	// Conditions are the latest observations of the state of the {{.Resource.Kind}}.
+{{- if .Resource.WithConditions }}
+	// The Ready condition records whether it is ready, set with SetCondition.
+{{- end }}
+{{- if .Resource.CreationGuard }}
+	// The Degraded condition records that its controller limits the objects it
+	// creates.
+{{- end }}
+	// +optional
+	Conditions []Condition ` + "`" + `json:"conditions,omitempty"` + "`" + `
+{{- end }}
+{{- if .Resource.ReconcileStatus }}
+
+	// LastReconcileTime is the time of the last reconcile of the {{.Resource.Kind}}
+	// recorded in its status.
+	// +optional
+	LastReconcileTime *metav1.Time ` + "`" + `json:"lastReconcileTime,omitempty"` + "`" + `
+
+	// LastError is the error the last reconcile of the {{.Resource.Kind}} failed
+	// with, empty when it succeeded.
+	// +optional
+	LastError string ` + "`" + `json:"lastError,omitempty"` + "`" + `
+{{- end }}
 }
 
 // +kubebuilder:object:root=true
+{{- if not .Resource.Namespaced }}
+// +kubebuilder:resource:scope=Cluster
+{{- end }}
+{{- if or .Resource.CreationGuard .Resource.WithConditions .Resource.Phases .Resource.ReconcileStatus .Resource.Scale }}
+// +kubebuilder:subresource:status
+{{- end }}
+{{- if .Resource.Scale }}
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+{{- end }}
+{{- range .PrinterColumns }}
+// {{ .Marker }}
+{{- end }}
 
 // {{.Resource.Kind}} is the Schema for the {{ .Resource.Resource }} API
 type {{.Resource.Kind}} struct {
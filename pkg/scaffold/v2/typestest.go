@@ -37,7 +37,7 @@ type TypesTest struct {
 // GetInput implements input.File
 func (t *TypesTest) GetInput() (input.Input, error) {
 	if t.Path == "" {
-		t.Path = filepath.Join("api", t.Resource.Version,
+		t.Path = filepath.Join(apiDir(t.Resource, t.Input),
 			fmt.Sprintf("%s_types_test.go", strings.ToLower(t.Resource.Kind)))
 	}
 	t.TemplateBody = typesTestTemplate
@@ -61,6 +61,9 @@ import (
 	. "github.com/onsi/gomega"
 
 	"golang.org/x/net/context"
+	{{- if .Resource.EmbedPodTemplate }}
+	corev1 "k8s.io/api/core/v1"
+	{{- end }}
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -116,7 +119,45 @@ var _ = Describe("{{ .Resource.Kind }}", func() {
 		 Expect(k8sClient.Delete(context.TODO(), created)).To(Succeed())
 		 Expect(k8sClient.Get(context.TODO(), key, created)).ToNot(Succeed())
 		})
+{{ if .Resource.EmbedPodTemplate }}
+		It("should keep the labels and annotations of the pod template", func() {
 
+		 key = types.NamespacedName{
+				 Name: "foo-template",
+				 {{ if .Resource.Namespaced -}}
+				 Namespace: "default",
+				 {{ end -}}
+		 }
+		 created = &{{ .Resource.Kind }}{
+				 ObjectMeta: metav1.ObjectMeta{
+						 Name: "foo-template",
+						 {{ if .Resource.Namespaced -}}
+						 Namespace: "default",
+						 {{ end -}}
+				 },
+				 Spec: {{ .Resource.Kind }}Spec{
+						 Template: &EmbeddedPodTemplateSpec{
+								 ObjectMeta: EmbeddedObjectMeta{
+										 Labels:      map[string]string{"app": "foo"},
+										 Annotations: map[string]string{"example.com/owner": "foo"},
+								 },
+								 Spec: corev1.PodSpec{
+										 Containers: []corev1.Container{ {Name: "foo", Image: "busybox"} },
+								 },
+						 },
+				 }}
+
+		 By("creating an API obj with a pod template")
+		 Expect(k8sClient.Create(context.TODO(), created)).To(Succeed())
+
+		 fetched = &{{ .Resource.Kind }}{}
+		 Expect(k8sClient.Get(context.TODO(), key, fetched)).To(Succeed())
+		 Expect(fetched.Spec.Template.ObjectMeta).To(Equal(created.Spec.Template.ObjectMeta))
+
+		 By("deleting the created object")
+		 Expect(k8sClient.Delete(context.TODO(), created)).To(Succeed())
+		})
+{{ end }}
 	})
 
 })
@@ -0,0 +1,211 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uninstall
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/markbates/inflect"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/internal"
+)
+
+const (
+	crdsScaffoldMarker   = "# +kubebuilder:scaffold:uninstallcrds"
+	groupsScaffoldMarker = "# +kubebuilder:scaffold:uninstallgroups"
+)
+
+var _ input.File = &Job{}
+
+// Job scaffolds the Job running config/uninstall/cleanup.sh against the CRDs
+// of the project.
+type Job struct {
+	input.Input
+
+	// Resources are the resources of the project whose CRDs the Job deletes
+	Resources []*resource.Resource
+
+	// CRDs are the names of the CRDs of Resources
+	CRDs []string
+}
+
+// GetInput implements input.File
+func (j *Job) GetInput() (input.Input, error) {
+	if j.Path == "" {
+		j.Path = filepath.Join("config", "uninstall", "job.yaml")
+	}
+	j.CRDs = nil
+	for _, r := range j.Resources {
+		j.CRDs = appendUnique(j.CRDs, crdName(r, j.Domain))
+	}
+	j.TemplateBody = jobTemplate
+	j.Input.IfExistsAction = input.Error
+	return j.Input, nil
+}
+
+// Update adds the CRD of the given resource to the CRDs the Job deletes and
+// its group to the ones the Job is allowed to clean up.
+func (j *Job) Update(r *resource.Resource) error {
+	if j.Path == "" {
+		j.Path = filepath.Join("config", "uninstall", "job.yaml")
+	}
+	err := internal.InsertStringsInFile(j.Path,
+		map[string][]string{
+			crdsScaffoldMarker: {fmt.Sprintf("        - %s\n", crdName(r, j.Domain))},
+		})
+	if err != nil {
+		return err
+	}
+	return internal.InsertStringsInFile(filepath.Join(filepath.Dir(j.Path), "rbac.yaml"),
+		map[string][]string{
+			groupsScaffoldMarker: {fmt.Sprintf("  - %s\n", r.GroupDomain(j.Domain))},
+		})
+}
+
+// crdName returns the name of the CRD of the resource.
+func crdName(r *resource.Resource, domain string) string {
+	// TODO: not valid if the plural is changed with a marker of the types
+	plural := inflect.NewDefaultRuleset().Pluralize(strings.ToLower(r.Kind))
+	return fmt.Sprintf("%s.%s", plural, r.GroupDomain(domain))
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+var jobTemplate = fmt.Sprintf(`apiVersion: batch/v1
+kind: Job
+metadata:
+  name: uninstall-cleanup
+  annotations:
+    # run by the charts packaging the operator before uninstalling it
+    helm.sh/hook: pre-delete
+    helm.sh/hook-delete-policy: before-hook-creation,hook-succeeded
+spec:
+  backoffLimit: 2
+  template:
+    spec:
+      serviceAccountName: uninstall-cleanup
+      restartPolicy: Never
+      containers:
+      - name: cleanup
+        image: bitnami/kubectl:1.15
+        command:
+        - sh
+        - /scripts/cleanup.sh
+        # the CRDs deleted, after their CRs
+        args:
+{{- range .CRDs }}
+        - {{ . }}
+{{- end }}
+        %s
+        env:
+        # time given to the controllers to finalize the CRs before their
+        # finalizers are removed
+        - name: CLEANUP_TIMEOUT
+          value: 60s
+        volumeMounts:
+        - name: scripts
+          mountPath: /scripts
+      volumes:
+      - name: scripts
+        configMap:
+          name: uninstall-cleanup
+`, crdsScaffoldMarker)
+
+var _ input.File = &RBAC{}
+
+// RBAC scaffolds the ServiceAccount of the uninstall Job and the ClusterRole
+// allowing it to delete the CRs and CRDs of the project.
+type RBAC struct {
+	input.Input
+
+	// Resources are the resources of the project whose CRs the Job deletes
+	Resources []*resource.Resource
+
+	// Groups are the API groups of Resources
+	Groups []string
+}
+
+// GetInput implements input.File
+func (c *RBAC) GetInput() (input.Input, error) {
+	if c.Path == "" {
+		c.Path = filepath.Join("config", "uninstall", "rbac.yaml")
+	}
+	c.Groups = nil
+	for _, r := range c.Resources {
+		c.Groups = appendUnique(c.Groups, r.GroupDomain(c.Domain))
+	}
+	c.TemplateBody = rbacTemplate
+	c.Input.IfExistsAction = input.Error
+	return c.Input, nil
+}
+
+var rbacTemplate = fmt.Sprintf(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: uninstall-cleanup
+  namespace: system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: uninstall-cleanup
+rules:
+- apiGroups:
+  - apiextensions.k8s.io
+  resources:
+  - customresourcedefinitions
+  verbs:
+  - get
+  - delete
+- apiGroups:
+{{- range .Groups }}
+  - {{ . }}
+{{- end }}
+  %s
+  resources:
+  - '*'
+  verbs:
+  - get
+  - list
+  - watch
+  - patch
+  - delete
+  - deletecollection
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: uninstall-cleanup
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: uninstall-cleanup
+subjects:
+- kind: ServiceAccount
+  name: uninstall-cleanup
+  namespace: system
+`, groupsScaffoldMarker)
@@ -0,0 +1,88 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uninstall
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &Kustomization{}
+
+// Kustomization scaffolds the Kustomization file of the uninstall Job, which
+// is applied on its own before the uninstallation of the operator.
+type Kustomization struct {
+	input.Input
+
+	// Prefix to use for name prefix customization
+	Prefix string
+
+	// Suffix to use for name suffix customization
+	Suffix string
+
+	// Namespace to deploy the resources to
+	Namespace string
+}
+
+// GetInput implements input.File
+func (k *Kustomization) GetInput() (input.Input, error) {
+	if k.Path == "" {
+		k.Path = filepath.Join("config", "uninstall", "kustomization.yaml")
+	}
+	var err error
+	if k.Prefix == "" {
+		if k.Prefix, err = k.GetNamePrefix(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	if k.Suffix == "" {
+		k.Suffix = k.NameSuffix
+	}
+	if k.Namespace == "" {
+		if k.Namespace, err = k.GetNamespace(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	k.TemplateBody = kustomizationTemplate
+	k.Input.IfExistsAction = input.Error
+	return k.Input, nil
+}
+
+var kustomizationTemplate = `# This kustomization runs the Job deleting the CRs of the project, removing
+# the finalizers the controllers leave, and then the CRDs. Run it while the
+# manager is still deployed, before deleting config/default, with
+#   kustomize build config/uninstall | kubectl apply -f -
+#   kubectl wait --for=condition=complete --timeout=5m \
+#     --namespace {{ .Namespace }} job/{{ .Prefix }}uninstall-cleanup{{ .Suffix }}
+# The Job is also annotated as a pre-delete hook for the charts packaging it.
+namespace: {{ .Namespace }}
+
+namePrefix: {{ .Prefix }}
+{{- if .Suffix }}
+nameSuffix: {{ .Suffix }}
+{{- end }}
+
+resources:
+- rbac.yaml
+- job.yaml
+
+configMapGenerator:
+- name: uninstall-cleanup
+  files:
+  - cleanup.sh
+`
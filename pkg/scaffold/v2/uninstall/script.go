@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uninstall
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &Script{}
+
+// Script scaffolds the config/uninstall/cleanup.sh script, which deletes the
+// CRs of the given CRDs before the CRDs themselves, run by make uninstall-safe
+// and the uninstall Job.
+type Script struct {
+	input.Input
+}
+
+// GetInput implements input.File
+func (s *Script) GetInput() (input.Input, error) {
+	if s.Path == "" {
+		s.Path = filepath.Join("config", "uninstall", "cleanup.sh")
+	}
+	s.TemplateBody = scriptTemplate
+	// projects initialized before the script was scaffolded get it with the Job
+	s.Input.IfExistsAction = input.Skip
+	return s.Input, nil
+}
+
+var scriptTemplate = `#!/bin/sh
+# Deletes the CRs of the given CRDs, then the CRDs, so that neither the CRDs nor
+# the namespaces of the CRs get stuck on the finalizers of CRs no manager is
+# left to remove. The controllers are given CLEANUP_TIMEOUT to finalize the
+# CRs, after which the finalizers left are removed.
+#
+# usage: cleanup.sh <crd>...
+set -e
+
+timeout=${CLEANUP_TIMEOUT:-60s}
+
+for crd in "$@"; do
+	if ! kubectl get crd "$crd" >/dev/null 2>&1; then
+		echo "skipping $crd: not installed"
+		continue
+	fi
+
+	echo "deleting the $crd objects"
+	if ! kubectl delete "$crd" --all --all-namespaces --timeout="$timeout"; then
+		echo "removing the finalizers of the $crd objects left after $timeout"
+		kubectl get "$crd" --all-namespaces \
+			-o jsonpath='{range .items[*]}{.metadata.namespace}/{.metadata.name}{"\n"}{end}' |
+			while IFS=/ read -r namespace name; do
+				kubectl patch "$crd" "$name" ${namespace:+--namespace "$namespace"} \
+					--type=merge -p '{"metadata":{"finalizers":null}}'
+			done
+		kubectl delete "$crd" --all --all-namespaces
+	fi
+
+	kubectl delete crd "$crd"
+done
+`
@@ -36,7 +36,7 @@ type VersionSuiteTest struct {
 // GetInput implements input.File
 func (v *VersionSuiteTest) GetInput() (input.Input, error) {
 	if v.Path == "" {
-		v.Path = filepath.Join("api", v.Resource.Version, "suite_test.go")
+		v.Path = filepath.Join(apiDir(v.Resource, v.Input), "suite_test.go")
 	}
 	v.TemplateBody = versionSuiteTestTemplate
 	return v.Input, nil
@@ -86,7 +86,7 @@ var _ = BeforeSuite(func(done Done) {
 
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths: []string{filepath.Join("..", "..", "config", "crd", "bases")},
+		CRDDirectoryPaths: []string{filepath.Join("..", "..", {{ if .MultiGroup }}"..", {{ end }}"config", "crd", "bases")},
 	}
 
 	err := SchemeBuilder.AddToScheme(scheme.Scheme)
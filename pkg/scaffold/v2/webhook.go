@@ -0,0 +1,340 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &Webhook{}
+
+// Webhook scaffolds the defaulting and validating webhooks of a Resource
+type Webhook struct {
+	input.Input
+
+	// Resource is the Resource to make the webhooks for
+	Resource *resource.Resource
+
+	// Defaulting indicates whether to scaffold a mutating webhook calling Default
+	Defaulting bool
+
+	// DefaultMarkers indicates whether Default fills in the field of the Spec
+	// defaulted by a +kubebuilder:default marker, scaffolded by DefaultedField
+	DefaultMarkers bool
+
+	// Validating indicates whether to scaffold a validating webhook calling
+	// ValidateCreate and ValidateUpdate
+	Validating bool
+
+	// ReportOnly indicates whether the validating webhook only reports the
+	// requests failing validation instead of denying them
+	ReportOnly bool
+
+	// GroupDomainWithDash is the API group of the Resource with dots replaced
+	// by dashes, as used by controller-runtime in the webhook paths
+	GroupDomainWithDash string
+}
+
+// GetInput implements input.File
+func (w *Webhook) GetInput() (input.Input, error) {
+	if w.Path == "" {
+		w.Path = filepath.Join(apiDir(w.Resource, w.Input),
+			fmt.Sprintf("%s_webhook.go", strings.ToLower(w.Resource.Kind)))
+	}
+	w.GroupDomainWithDash = strings.Replace(
+		fmt.Sprintf("%s.%s", w.Resource.Group, w.Domain), ".", "-", -1)
+	w.TemplateBody = webhookTemplate
+	w.Input.IfExistsAction = input.Error
+	return w.Input, nil
+}
+
+// Validate validates the values
+func (w *Webhook) Validate() error {
+	if !w.Defaulting && !w.Validating {
+		return fmt.Errorf("at least one of defaulting or validating webhooks must be scaffolded")
+	}
+	if w.DefaultMarkers && !w.Defaulting {
+		return fmt.Errorf("default markers require the defaulting webhook to be scaffolded")
+	}
+	if w.ReportOnly && !w.Validating {
+		return fmt.Errorf("report-only mode requires the validating webhook to be scaffolded")
+	}
+	return w.Resource.Validate()
+}
+
+var webhookTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	"context"
+{{- if .Defaulting }}
+	"encoding/json"
+{{- end }}
+	"net/http"
+
+{{- if .Validating }}
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+{{- end }}
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// log is for logging in this package.
+var {{ lower .Resource.Kind }}log = logf.Log.WithName("{{ lower .Resource.Kind }}-resource")
+
+// The webhooks below are registered with the manager's webhook server by
+// SetupWebhookWithManager, called from main.go. Their handlers decode the
+// objects of the requests with the decoder injected once by the manager, and
+// pass the context and the admission.Request to the methods you fill in, e.g.
+// to authorize a change depending on the UserInfo of the request.
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// SetupWebhookWithManager registers the webhooks of {{ .Resource.Kind }} with the
+// manager's webhook server.
+func (r *{{ .Resource.Kind }}) SetupWebhookWithManager(mgr ctrl.Manager) error {
+{{- if .Defaulting }}
+	mgr.GetWebhookServer().Register("/mutate-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }}",
+		&admission.Webhook{Handler: &{{ .Resource.Kind }}Defaulter{}})
+{{- end }}
+{{- if and .Validating (not .ReportOnly) }}
+	mgr.GetWebhookServer().Register("/validate-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }}",
+		&admission.Webhook{Handler: &{{ .Resource.Kind }}Validator{}})
+{{- end }}
+	return nil
+}
+{{- if .Defaulting }}
+
+// +kubebuilder:webhook:path=/mutate-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }},mutating=true,failurePolicy=fail,groups={{ .Resource.Group }}.{{ .Domain }},resources={{ .Resource.Resource }},verbs=create;update,versions={{ .Resource.Version }},name=m{{ lower .Resource.Kind }}.{{ .Domain }}
+
+// Default fills in the defaults of r, created or updated by req.
+func (r *{{ .Resource.Kind }}) Default(ctx context.Context, req admission.Request) {
+	{{ lower .Resource.Kind }}log.Info("default", "name", r.Name, "user", req.UserInfo.Username)
+
+	// TODO(user): fill in your defaulting logic.
+{{- if .DefaultMarkers }}
+	// The defaults filled in here must be the ones of the +kubebuilder:default
+	// markers of {{ .Resource.Kind }}Spec: the apiserver fills those in from the
+	// schema of the CRD before calling the webhook, while the objects defaulted
+	// outside of the apiserver, e.g. in tests, only get these.
+	// {{ lower .Resource.Kind }}_defaults_test.go checks they match.
+	if r.Spec.Size == nil {
+		size := int32(1)
+		r.Spec.Size = &size
+	}
+{{- end }}
+}
+
+// {{ .Resource.Kind }}Defaulter is the admission handler of the defaulting
+// webhook of {{ .Resource.Kind }}.
+// +kubebuilder:object:generate=false
+type {{ .Resource.Kind }}Defaulter struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.DecoderInjector = &{{ .Resource.Kind }}Defaulter{}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (d *{{ .Resource.Kind }}Defaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle answers with the JSON patch of the defaults of the object of req.
+func (d *{{ .Resource.Kind }}Defaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	obj := &{{ .Resource.Kind }}{}
+	if err := d.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	obj.Default(ctx, req)
+
+	defaulted, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, defaulted)
+}
+{{- end }}
+{{- if .Validating }}
+{{- if .ReportOnly }}
+
+// The validating webhook below runs in report-only mode: the requests failing
+// ValidateCreate or ValidateUpdate are admitted, and the reason they would have
+// been denied is recorded as an audit annotation of the request. Once the audit
+// log shows no unexpected violations, enforce the validation by changing the
+// path of the marker below to /validate-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }} and its
+// failurePolicy to fail.
+// +kubebuilder:webhook:path=/report-validate-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }},mutating=false,failurePolicy=ignore,groups={{ .Resource.Group }}.{{ .Domain }},resources={{ .Resource.Resource }},verbs=create;update,versions={{ .Resource.Version }},name=v{{ lower .Resource.Kind }}.{{ .Domain }}
+
+// SetupReportOnlyWebhookWithManager registers the report-only validating
+// webhook of {{ .Resource.Kind }} with the manager's webhook server.
+func (r *{{ .Resource.Kind }}) SetupReportOnlyWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register("/report-validate-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }}",
+		reportOnlyWebhookFor(&{{ .Resource.Kind }}Validator{}))
+	return nil
+}
+{{- else }}
+
+// +kubebuilder:webhook:path=/validate-{{ .GroupDomainWithDash }}-{{ .Resource.Version }}-{{ lower .Resource.Kind }},mutating=false,failurePolicy=fail,groups={{ .Resource.Group }}.{{ .Domain }},resources={{ .Resource.Resource }},verbs=create;update,versions={{ .Resource.Version }},name=v{{ lower .Resource.Kind }}.{{ .Domain }}
+{{- end }}
+
+// ValidateCreate validates r, created by req.
+func (r *{{ .Resource.Kind }}) ValidateCreate(ctx context.Context, req admission.Request) error {
+	{{ lower .Resource.Kind }}log.Info("validate create", "name", r.Name, "user", req.UserInfo.Username)
+
+	// TODO(user): fill in your validation logic upon object creation.
+	return nil
+}
+
+// ValidateUpdate validates the update of old to r by req.
+func (r *{{ .Resource.Kind }}) ValidateUpdate(ctx context.Context, req admission.Request, old *{{ .Resource.Kind }}) error {
+	{{ lower .Resource.Kind }}log.Info("validate update", "name", r.Name, "user", req.UserInfo.Username)
+
+	// TODO(user): fill in your validation logic upon object update. For
+	// example, to only let the members of a group change a field:
+	//	if r.Spec.Foo != old.Spec.Foo && !containsString(req.UserInfo.Groups, "foo-admins") {
+	//		return fmt.Errorf("only the foo-admins can change spec.foo")
+	//	}
+	return nil
+}
+
+// {{ .Resource.Kind }}Validator is the admission handler of the validating
+// webhook of {{ .Resource.Kind }}.
+// +kubebuilder:object:generate=false
+type {{ .Resource.Kind }}Validator struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.DecoderInjector = &{{ .Resource.Kind }}Validator{}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *{{ .Resource.Kind }}Validator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+	return nil
+}
+
+// Handle denies req if the object it creates or updates is not valid.
+func (v *{{ .Resource.Kind }}Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	obj := &{{ .Resource.Kind }}{}
+	if err := v.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	var err error
+	switch req.Operation {
+	case admissionv1beta1.Create:
+		err = obj.ValidateCreate(ctx, req)
+	case admissionv1beta1.Update:
+		old := &{{ .Resource.Kind }}{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		err = obj.ValidateUpdate(ctx, req, old)
+	}
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("")
+}
+{{- end }}
+`
+
+var _ input.File = &ReportOnlyWebhook{}
+
+// ReportOnlyWebhook scaffolds the handler shared by the report-only validating
+// webhooks of an API version
+type ReportOnlyWebhook struct {
+	input.Input
+
+	// Resource is a Resource of the API version
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (w *ReportOnlyWebhook) GetInput() (input.Input, error) {
+	if w.Path == "" {
+		w.Path = filepath.Join(apiDir(w.Resource, w.Input), "webhook_reportonly.go")
+	}
+	w.TemplateBody = reportOnlyWebhookTemplate
+	w.Input.IfExistsAction = input.Skip
+	return w.Input, nil
+}
+
+var reportOnlyWebhookTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	"context"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ReportOnlyAnnotation is the key of the audit annotation recording why a
+// request would have been denied by a report-only validating webhook.
+const ReportOnlyAnnotation = "validation-violation"
+
+var reportonlylog = logf.Log.WithName("report-only-webhook")
+
+// reportOnlyWebhookFor creates a validating webhook which admits every
+// request, recording the reason the given validating handler denied it instead.
+func reportOnlyWebhookFor(handler admission.Handler) *admission.Webhook {
+	return &admission.Webhook{
+		Handler: &reportOnlyHandler{Handler: handler},
+	}
+}
+
+type reportOnlyHandler struct {
+	admission.Handler
+}
+
+var _ admission.DecoderInjector = &reportOnlyHandler{}
+
+// InjectDecoder injects the decoder into the wrapped validating handler.
+func (h *reportOnlyHandler) InjectDecoder(d *admission.Decoder) error {
+	_, err := admission.InjectDecoderInto(d, h.Handler)
+	return err
+}
+
+// Handle admits the request, reporting why the validating handler denied it.
+func (h *reportOnlyHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	resp := h.Handler.Handle(ctx, req)
+	if resp.Allowed || resp.Result == nil {
+		return resp
+	}
+
+	reason := resp.Result.Message
+	if reason == "" {
+		reason = string(resp.Result.Reason)
+	}
+	reportonlylog.Info("admitting request failing validation", "kind", req.Kind.Kind,
+		"namespace", req.Namespace, "name", req.Name, "reason", reason)
+
+	allowed := admission.Allowed("")
+	allowed.AuditAnnotations = map[string]string{ReportOnlyAnnotation: reason}
+	return allowed
+}
+`
@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &CSIManagerPatch{}
+
+// CSIManagerPatch scaffolds a patch of the manager Deployment which mounts
+// the webhook serving certificate with the secrets-store CSI driver.
+type CSIManagerPatch struct {
+	input.Input
+
+	// Prefix and Suffix are the name prefix and suffix of the default overlay,
+	// kustomize does not add them to the SecretProviderClass reference of the volume
+	Prefix string
+	Suffix string
+}
+
+// GetInput implements input.File
+func (p *CSIManagerPatch) GetInput() (input.Input, error) {
+	if p.Path == "" {
+		p.Path = filepath.Join("config", "default", "manager_webhook_csi_patch.yaml")
+	}
+	var err error
+	if p.Prefix == "" {
+		// use the name prefix of the default overlay
+		if p.Prefix, err = p.GetNamePrefix(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	if p.Suffix == "" {
+		p.Suffix = p.NameSuffix
+	}
+	p.TemplateBody = csiManagerPatchTemplate
+	p.Input.IfExistsAction = input.Skip
+	return p.Input, nil
+}
+
+var csiManagerPatchTemplate = `# This patch mounts the webhook serving certificate from an external secret
+# store with the secrets-store CSI driver, as described by the
+# SecretProviderClass in config/secretstore. Use it instead of
+# manager_webhook_patch.yaml when cert-manager is not available.
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: system
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        ports:
+        - containerPort: 9443
+          name: webhook-server
+          protocol: TCP
+        volumeMounts:
+        - mountPath: /tmp/k8s-webhook-server/serving-certs
+          name: cert
+          readOnly: true
+      volumes:
+      - name: cert
+        csi:
+          driver: secrets-store.csi.k8s.io
+          readOnly: true
+          volumeAttributes:
+            secretProviderClass: {{ .Prefix }}webhook-server-cert{{ .Suffix }}
+`
@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &SelfSignedManagerPatch{}
+
+// SelfSignedManagerPatch scaffolds a patch of the manager Deployment which has
+// the manager issue a self-signed webhook serving certificate.
+type SelfSignedManagerPatch struct {
+	input.Input
+
+	// Prefix and Suffix are the name prefix and suffix of the default overlay,
+	// kustomize does not add them to the names given in environment variables
+	Prefix string
+	Suffix string
+}
+
+// GetInput implements input.File
+func (p *SelfSignedManagerPatch) GetInput() (input.Input, error) {
+	if p.Path == "" {
+		p.Path = filepath.Join("config", "default", "manager_webhook_selfsigned_patch.yaml")
+	}
+	var err error
+	if p.Prefix == "" {
+		// use the name prefix of the default overlay
+		if p.Prefix, err = p.GetNamePrefix(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	if p.Suffix == "" {
+		p.Suffix = p.NameSuffix
+	}
+	p.TemplateBody = selfSignedManagerPatchTemplate
+	p.Input.IfExistsAction = input.Skip
+	return p.Input, nil
+}
+
+var selfSignedManagerPatchTemplate = `# This patch has the manager issue the webhook serving certificate with a
+# self-signed CA, kept in the Secret below and renewed before it expires, and
+# inject the CA in the webhook configurations and the CRDs. Use it instead of
+# manager_webhook_patch.yaml when cert-manager is not available.
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: system
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        ports:
+        - containerPort: 9443
+          name: webhook-server
+          protocol: TCP
+        env:
+        - name: POD_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        - name: WEBHOOK_SERVICE
+          value: {{ .Prefix }}webhook-service{{ .Suffix }}
+        - name: WEBHOOK_CERT_SECRET
+          value: {{ .Prefix }}webhook-server-cert{{ .Suffix }}
+        volumeMounts:
+        - mountPath: /tmp/k8s-webhook-server/serving-certs
+          name: cert
+      volumes:
+      - name: cert
+        emptyDir: {}
+`
@@ -27,6 +27,9 @@ var _ input.File = &Service{}
 // Service scaffolds the Service file in manager folder.
 type Service struct {
 	input.Input
+
+	// DualStack indicates whether the Service prefers both IP families
+	DualStack bool
 }
 
 // GetInput implements input.File
@@ -46,9 +49,12 @@ metadata:
   name: webhook-service
   namespace: system
 spec:
+{{- if .DualStack }}
+  ipFamilyPolicy: PreferDualStack
+{{- end }}
   ports:
     - port: 443
-      targetPort: 443
+      targetPort: 9443
   selector:
     control-plane: controller-manager
 `
@@ -0,0 +1,102 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+)
+
+var _ input.File = &VaultManagerPatch{}
+
+// VaultManagerPatch scaffolds a patch of the manager Deployment which has the
+// Vault agent injector render the webhook serving certificate.
+type VaultManagerPatch struct {
+	input.Input
+
+	// Prefix and Suffix are the name prefix and suffix of the default overlay,
+	// used to compute the DNS name of the webhook service
+	Prefix string
+	Suffix string
+
+	// Namespace is the namespace of the default overlay
+	Namespace string
+}
+
+// GetInput implements input.File
+func (p *VaultManagerPatch) GetInput() (input.Input, error) {
+	if p.Path == "" {
+		p.Path = filepath.Join("config", "default", "manager_webhook_vault_patch.yaml")
+	}
+	var err error
+	if p.Prefix == "" {
+		// use the name prefix of the default overlay
+		if p.Prefix, err = p.GetNamePrefix(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	if p.Suffix == "" {
+		p.Suffix = p.NameSuffix
+	}
+	if p.Namespace == "" {
+		if p.Namespace, err = p.GetNamespace(); err != nil {
+			return input.Input{}, err
+		}
+	}
+	p.TemplateBody = vaultManagerPatchTemplate
+	p.Input.IfExistsAction = input.Skip
+	return p.Input, nil
+}
+
+// Both templates below issue a certificate with the same arguments, so the
+// Vault agent makes a single request and tls.crt and tls.key always match.
+var vaultManagerPatchTemplate = `# This patch has the Vault agent injector write the webhook serving certificate,
+# issued by the Vault PKI secrets engine, to the directory the webhook server
+# reads it from. Use it instead of manager_webhook_patch.yaml when cert-manager
+# is not available. The Vault role and the PKI path below have to be created to
+# match your Vault setup.
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: system
+spec:
+  template:
+    metadata:
+      annotations:
+        vault.hashicorp.com/agent-inject: "true"
+        vault.hashicorp.com/role: {{ .Prefix }}controller-manager{{ .Suffix }}
+        vault.hashicorp.com/secret-volume-path: /tmp/k8s-webhook-server/serving-certs
+        vault.hashicorp.com/agent-inject-secret-tls.crt: pki/issue/{{ .Prefix }}webhook{{ .Suffix }}
+        vault.hashicorp.com/agent-inject-template-tls.crt: |
+          {{ "{{" }}- with secret "pki/issue/{{ .Prefix }}webhook{{ .Suffix }}" "common_name={{ .Prefix }}webhook-service{{ .Suffix }}.{{ .Namespace }}.svc" -{{ "}}" }}
+          {{ "{{" }} .Data.certificate {{ "}}" }}
+          {{ "{{" }}- end {{ "}}" }}
+        vault.hashicorp.com/agent-inject-secret-tls.key: pki/issue/{{ .Prefix }}webhook{{ .Suffix }}
+        vault.hashicorp.com/agent-inject-template-tls.key: |
+          {{ "{{" }}- with secret "pki/issue/{{ .Prefix }}webhook{{ .Suffix }}" "common_name={{ .Prefix }}webhook-service{{ .Suffix }}.{{ .Namespace }}.svc" -{{ "}}" }}
+          {{ "{{" }} .Data.private_key {{ "}}" }}
+          {{ "{{" }}- end {{ "}}" }}
+    spec:
+      containers:
+      - name: manager
+        ports:
+        - containerPort: 9443
+          name: webhook-server
+          protocol: TCP
+`
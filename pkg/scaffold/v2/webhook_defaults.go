@@ -0,0 +1,256 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/internal"
+)
+
+const specScaffoldMarker = "// +kubebuilder:scaffold:spec"
+
+// DefaultedField adds to the Spec of a Resource a field defaulted both by a
+// +kubebuilder:default marker, in the schema of the CRD, and by the defaulting
+// webhook scaffolded with DefaultMarkers.
+type DefaultedField struct {
+	// Path is the path of the <kind>_types.go file of the Resource
+	Path string
+}
+
+// Update inserts the defaulted field at the spec scaffold marker of the types.
+func (f *DefaultedField) Update() error {
+	types, err := ioutil.ReadFile(f.Path) // nolint: gosec
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(string(types), specScaffoldMarker) {
+		return fmt.Errorf("%s has no %q marker to add the defaulted field at", f.Path, specScaffoldMarker)
+	}
+	return internal.InsertStringsInFile(f.Path,
+		map[string][]string{
+			specScaffoldMarker: {defaultedFieldCodeFragment},
+		})
+}
+
+var defaultedFieldCodeFragment = `
+	// Size shows a field defaulted by the apiserver, from the schema of the
+	// CRD, and by the defaulting webhook. Keep its +kubebuilder:default marker
+	// and the defaults of Default in sync.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Size *int32 ` + "`" + `json:"size,omitempty"` + "`" + `
+`
+
+var _ input.File = &WebhookDefaultsTest{}
+
+// WebhookDefaultsTest scaffolds the api/version/kind_defaults_test.go file
+// checking the defaults of the schema of the CRD match the ones of the
+// defaulting webhook
+type WebhookDefaultsTest struct {
+	input.Input
+
+	// Resource is the resource to scaffold the defaults_test.go file for
+	Resource *resource.Resource
+}
+
+// GetInput implements input.File
+func (t *WebhookDefaultsTest) GetInput() (input.Input, error) {
+	if t.Path == "" {
+		t.Path = filepath.Join(apiDir(t.Resource, t.Input),
+			fmt.Sprintf("%s_defaults_test.go", strings.ToLower(t.Resource.Kind)))
+	}
+	t.TemplateBody = webhookDefaultsTestTemplate
+	t.IfExistsAction = input.Error
+	return t.Input, nil
+}
+
+// Validate validates the values
+func (t *WebhookDefaultsTest) Validate() error {
+	return t.Resource.Validate()
+}
+
+var webhookDefaultsTestTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/yaml"
+)
+
+// These tests check that the apiserver, filling in the defaults of the schema
+// of the CRD from the +kubebuilder:default markers, and the defaulting webhook
+// of {{ .Resource.Kind }} default the objects the same way. The objects created
+// through the apiserver get the defaults of both, but the ones defaulted by a
+// single of them, e.g. the objects created while the webhook is unavailable,
+// or defaulted with Default in tests, would otherwise differ.
+// Run make manifests to regenerate the CRD after changing the markers.
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// {{ lower .Resource.Kind }}CRD is the CRD generated by make manifests.
+var {{ lower .Resource.Kind }}CRD = filepath.Join({{ if .MultiGroup }}"..", {{ end }}"..", "..", "config", "crd", "bases",
+	"{{ .Resource.Group }}.{{ .Domain }}_{{ .Resource.Resource }}.yaml")
+
+// schemaOf returns the OpenAPI schema of version of the CRD at path.
+func schemaOf(path, version string) map[string]interface{} {
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		Skip("run make manifests to generate " + path)
+	}
+	Expect(err).NotTo(HaveOccurred())
+	var crd map[string]interface{}
+	Expect(yaml.Unmarshal(raw, &crd)).To(Succeed())
+
+	spec, _ := crd["spec"].(map[string]interface{})
+	versions, _ := spec["versions"].([]interface{})
+	for _, v := range versions {
+		v, _ := v.(map[string]interface{})
+		if v["name"] != version {
+			continue
+		}
+		if s, ok := v["schema"].(map[string]interface{}); ok {
+			return s["openAPIV3Schema"].(map[string]interface{})
+		}
+	}
+	validation, _ := spec["validation"].(map[string]interface{})
+	schema, _ := validation["openAPIV3Schema"].(map[string]interface{})
+	Expect(schema).NotTo(BeNil(), "%s has no schema for version %s", path, version)
+	return schema
+}
+
+// applySchemaDefaults fills in obj the way the apiserver does: the missing
+// properties of the objects present in obj are set to the default of their
+// schema, and the items of the lists and maps present are defaulted in turn.
+func applySchemaDefaults(obj interface{}, schema map[string]interface{}) {
+	switch obj := obj.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, p := range properties {
+			p, _ := p.(map[string]interface{})
+			if _, set := obj[name]; !set {
+				if def, ok := p["default"]; ok {
+					obj[name] = copyJSON(def)
+				}
+			}
+			if value, set := obj[name]; set {
+				applySchemaDefaults(value, p)
+			}
+		}
+		if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			for _, value := range obj {
+				applySchemaDefaults(value, additional)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for _, item := range obj {
+				applySchemaDefaults(item, items)
+			}
+		}
+	}
+}
+
+// hasDefaults returns whether schema, or the schema of any of its properties
+// or items, has a default.
+func hasDefaults(schema interface{}) bool {
+	switch schema := schema.(type) {
+	case map[string]interface{}:
+		for key, value := range schema {
+			if key == "default" || hasDefaults(value) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, value := range schema {
+			if hasDefaults(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// copyJSON copies a JSON value, so that the defaults of the schema are not
+// shared between objects.
+func copyJSON(value interface{}) interface{} {
+	raw, err := json.Marshal(value)
+	Expect(err).NotTo(HaveOccurred())
+	var copied interface{}
+	Expect(json.Unmarshal(raw, &copied)).To(Succeed())
+	return copied
+}
+
+// toJSON returns obj as the JSON values the apiserver defaults.
+func toJSON(obj interface{}) map[string]interface{} {
+	raw, err := json.Marshal(obj)
+	Expect(err).NotTo(HaveOccurred())
+	var values map[string]interface{}
+	Expect(json.Unmarshal(raw, &values)).To(Succeed())
+	return values
+}
+
+var _ = Describe("{{ .Resource.Kind }} defaults", func() {
+	table.DescribeTable("the schema of the CRD and the defaulting webhook default the same fields",
+		func(obj *{{ .Resource.Kind }}) {
+			obj = obj.DeepCopy()
+			obj.APIVersion = GroupVersion.String()
+			obj.Kind = "{{ .Resource.Kind }}"
+
+			By("defaulting the object from the schema of the CRD")
+			schema := schemaOf({{ lower .Resource.Kind }}CRD, GroupVersion.Version)
+			if !hasDefaults(schema) {
+				Skip("the CRD has no defaults: regenerate it with a controller-gen supporting the +kubebuilder:default markers")
+			}
+			byCRD := toJSON(obj)
+			applySchemaDefaults(byCRD, schema)
+
+			By("defaulting the object with the defaulting webhook")
+			defaulted := obj.DeepCopy()
+			defaulted.Default(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1beta1.AdmissionRequest{Operation: admissionv1beta1.Create},
+			})
+			byWebhook := toJSON(defaulted)
+
+			Expect(byWebhook).To(Equal(byCRD))
+		},
+		table.Entry("an empty spec",
+			&{{ .Resource.Kind }}{ObjectMeta: metav1.ObjectMeta{Name: "foo"{{ if .Resource.Namespaced }}, Namespace: "default"{{ end }}}}),
+
+		// TODO(user): add the objects whose lists and maps hold items defaulted
+		// by the schema, e.g. a spec with containers without a pull policy.
+	)
+})
+`
@@ -0,0 +1,233 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &WebhookIntegrationTest{}
+
+// WebhookIntegrationTest scaffolds the controllers/kind_integration_test.go
+// file, running the webhooks and the controller of a Resource together
+type WebhookIntegrationTest struct {
+	input.Input
+
+	// Resource is the Resource to scaffold the integration test for
+	Resource *resource.Resource
+
+	// ResourcePackage is the package of the Resource
+	ResourcePackage string
+
+	// Defaulting indicates whether the Resource has a defaulting webhook
+	Defaulting bool
+
+	// Validating indicates whether the Resource has a validating webhook
+	Validating bool
+
+	// CreationGuard indicates whether the reconciler of the Resource caps the
+	// objects it creates with a CreationGuard
+	CreationGuard bool
+
+	// TransitionEvents indicates whether the reconciler of the Resource records
+	// the transitions of its Resources with a Recorder
+	TransitionEvents bool
+}
+
+// GetInput implements input.File
+func (t *WebhookIntegrationTest) GetInput() (input.Input, error) {
+	t.ResourcePackage, _ = getResourceInfo(t.Resource, t.Input)
+	if t.Path == "" {
+		t.Path = filepath.Join(controllersDir(t.Resource, t.Input),
+			fmt.Sprintf("%s_integration_test.go", strings.ToLower(t.Resource.Kind)))
+	}
+	t.TemplateBody = webhookIntegrationTestTemplate
+	t.IfExistsAction = input.Error
+	return t.Input, nil
+}
+
+// Validate validates the values
+func (t *WebhookIntegrationTest) Validate() error {
+	if !t.Defaulting && !t.Validating {
+		return fmt.Errorf("at least one of defaulting or validating webhooks must be tested")
+	}
+	return t.Resource.Validate()
+}
+
+var webhookIntegrationTestTemplate = `{{ .Boilerplate }}
+
+package {{ if .MultiGroup }}{{ .Resource.Group }}{{ else }}controllers{{ end }}
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+{{- if .Defaulting }}
+	jsonpatchapply "github.com/evanphx/json-patch"
+{{- end }}
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	{{ .Resource.Group}}{{ .Resource.Version }} "{{ .ResourcePackage }}/{{ .Resource.Version }}"
+)
+
+// This test runs the webhooks and the controller of {{ .Resource.Kind }} together in
+// the test environment of the suite: a {{ .Resource.Kind }} goes through its
+// {{ if and .Defaulting .Validating }}defaulting and validating webhooks{{ else if .Defaulting }}defaulting webhook{{ else }}validating webhook{{ end }} the way the apiserver sends it to them, is
+// created, and is then reconciled by a {{ .Resource.Kind }}Reconciler run by a manager.
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+var _ = Describe("{{ .Resource.Kind }} webhooks and controller", func() {
+	var (
+		stop       chan struct{}
+		reconciled chan reconcile.Request
+	)
+
+	BeforeEach(func() {
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{Scheme: scheme.Scheme, MetricsBindAddress: "0"})
+		Expect(err).NotTo(HaveOccurred())
+
+		// TODO(user): set the other fields of the reconciler as main.go does.
+		r := &{{ .Resource.Kind }}Reconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("{{ .Resource.Kind }}"),
+{{- if .CreationGuard }}
+			CreationGuard: &CreationGuard{},
+{{- end }}
+{{- if .TransitionEvents }}
+			Recorder:      mgr.GetEventRecorderFor("{{ lower .Resource.Kind }}-integration"),
+{{- end }}
+		}
+
+		// The controller is set up without the builder, which would also serve
+		// the webhooks of {{ .Resource.Kind }} and require a serving certificate. It
+		// reports the requests reconciled without error.
+		reconciled = make(chan reconcile.Request, 10)
+		c, err := controller.New("{{ lower .Resource.Kind }}-integration", mgr, controller.Options{
+			Reconciler: reconcile.Func(func(req reconcile.Request) (reconcile.Result, error) {
+				result, err := r.Reconcile(req)
+				if err == nil {
+					select {
+					case reconciled <- req:
+					default:
+					}
+				}
+				return result, err
+			}),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		err = c.Watch(&source.Kind{Type: &{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}{}}, &handler.EnqueueRequestForObject{})
+		Expect(err).NotTo(HaveOccurred())
+
+		stop = make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			Expect(mgr.Start(stop)).To(Succeed())
+		}()
+	})
+
+	AfterEach(func() {
+		close(stop)
+	})
+
+	// admit sends the creation of obj to the webhooks the way the apiserver
+	// does, and returns the object they admitted.
+	admit := func(obj *{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}) *{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }} {
+		decoder, err := admission.NewDecoder(scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+		raw, err := json.Marshal(obj)
+		Expect(err).NotTo(HaveOccurred())
+		request := func() admission.Request {
+			return admission.Request{
+				AdmissionRequest: admissionv1beta1.AdmissionRequest{
+					Operation: admissionv1beta1.Create,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			}
+		}
+{{- if .Defaulting }}
+
+		By("defaulting the {{ .Resource.Kind }}")
+		mutating := &admission.Webhook{Handler: &{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}Defaulter{}}
+		_, err = admission.InjectDecoderInto(decoder, mutating.Handler)
+		Expect(err).NotTo(HaveOccurred())
+		resp := mutating.Handle(context.Background(), request())
+		Expect(resp.Allowed).To(BeTrue(), "the defaulting webhook denied the request: %v", resp.Result)
+		ops, err := json.Marshal(resp.Patches)
+		Expect(err).NotTo(HaveOccurred())
+		patch, err := jsonpatchapply.DecodePatch(ops)
+		Expect(err).NotTo(HaveOccurred())
+		raw, err = patch.Apply(raw)
+		Expect(err).NotTo(HaveOccurred(), "the patch does not apply to the object")
+{{- end }}
+{{- if .Validating }}
+
+		By("validating the {{ .Resource.Kind }}")
+		validating := &admission.Webhook{Handler: &{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}Validator{}}
+		_, err = admission.InjectDecoderInto(decoder, validating.Handler)
+		Expect(err).NotTo(HaveOccurred())
+		{{ if .Defaulting }}resp = {{ else }}resp := {{ end }}validating.Handle(context.Background(), request())
+		Expect(resp.Allowed).To(BeTrue(), "the validating webhook denied the request: %v", resp.Result)
+{{- end }}
+
+		admitted := &{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}{}
+		Expect(json.Unmarshal(raw, admitted)).To(Succeed())
+		return admitted
+	}
+
+	It("should admit, create and reconcile a {{ .Resource.Kind }}", func() {
+		key := types.NamespacedName{Name: "integration"{{ if .Resource.Namespaced }}, Namespace: "default"{{ end }}}
+		obj := &{{ .Resource.Group}}{{ .Resource.Version }}.{{ .Resource.Kind }}{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			// TODO(user): fill in the spec of a valid {{ .Resource.Kind }}.
+		}
+		obj.APIVersion = {{ .Resource.Group}}{{ .Resource.Version }}.GroupVersion.String()
+		obj.Kind = "{{ .Resource.Kind }}"
+
+		admitted := admit(obj)
+
+		By("creating the {{ .Resource.Kind }}")
+		Expect(k8sClient.Create(context.Background(), admitted)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(context.Background(), admitted)).To(Succeed())
+		}()
+
+		By("waiting for the {{ .Resource.Kind }} to be reconciled")
+		Eventually(reconciled, 10*time.Second).Should(Receive(Equal(reconcile.Request{NamespacedName: key})))
+
+		// TODO(user): check the state the reconcile of the {{ .Resource.Kind }} results in.
+	})
+})
+`
@@ -44,10 +44,23 @@ metadata:
 spec:
   template:
     spec:
+      # Wait for the webhook serving certificate to be provisioned before
+      # starting the manager, instead of crashlooping until it exists.
+      initContainers:
+      - name: wait-for-webhook-cert
+        image: busybox:1.31
+        command:
+        - sh
+        - -c
+        - until [ -s /tmp/k8s-webhook-server/serving-certs/tls.crt ]; do echo waiting for webhook-server-cert; sleep 2; done
+        volumeMounts:
+        - mountPath: /tmp/k8s-webhook-server/serving-certs
+          name: cert
+          readOnly: true
       containers:
       - name: manager
         ports:
-        - containerPort: 443
+        - containerPort: 9443
           name: webhook-server
           protocol: TCP
         volumeMounts:
@@ -59,4 +72,7 @@ spec:
         secret:
           defaultMode: 420
           secretName: webhook-server-cert
+          # the secret may not exist yet when the pod is created, the init
+          # container above waits for it to be populated
+          optional: true
 `
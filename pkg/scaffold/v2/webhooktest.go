@@ -0,0 +1,271 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+)
+
+var _ input.File = &WebhookTest{}
+
+// WebhookTest scaffolds the api/version/kind_webhook_test.go file with the
+// table-driven tests of the JSON patch of the defaulting webhook and of the
+// validation of the creations and updates by the validating webhook
+type WebhookTest struct {
+	input.Input
+
+	// Resource is the resource to scaffold the webhook_test.go file for
+	Resource *resource.Resource
+
+	// Defaulting is true to test the defaulting webhook
+	Defaulting bool
+
+	// Validating is true to test the validating webhook
+	Validating bool
+
+	// DefaultMarkers indicates whether the webhook defaults the field of the
+	// Spec scaffolded by DefaultedField
+	DefaultMarkers bool
+}
+
+// GetInput implements input.File
+func (t *WebhookTest) GetInput() (input.Input, error) {
+	if t.Path == "" {
+		t.Path = filepath.Join(apiDir(t.Resource, t.Input),
+			fmt.Sprintf("%s_webhook_test.go", strings.ToLower(t.Resource.Kind)))
+	}
+	t.TemplateBody = webhookTestTemplate
+	t.IfExistsAction = input.Error
+	return t.Input, nil
+}
+
+// Validate validates the values
+func (t *WebhookTest) Validate() error {
+	if !t.Defaulting && !t.Validating {
+		return fmt.Errorf("at least one of defaulting or validating webhooks must be tested")
+	}
+	return t.Resource.Validate()
+}
+
+var webhookTestTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/appscode/jsonpatch"
+	jsonpatchapply "github.com/evanphx/json-patch"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// These tests run the webhooks of {{ .Resource.Kind }} the way the apiserver
+// does: the object is sent as JSON in an admission request.
+{{- if .Defaulting }}
+//
+// The defaulting webhook answers with the JSON patch turning it into the
+// defaulted object. The patch is checked against the expected operations,
+// and applied to check it results in the object defaulted by Default: the
+// operations on the items of a list depend on each other, so a patch with the
+// expected defaults may still fail to apply, or apply them to the wrong items.
+{{- end }}
+{{- if .Validating }}
+//
+// The validating webhook admits or denies the creation or update, as
+// ValidateCreate or ValidateUpdate decide. Add an entry for each rule they
+// enforce, with an object breaking it and one just within it.
+{{- end }}
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+{{- if .Defaulting }}
+
+var _ = Describe("{{ .Resource.Kind }} defaulting webhook", func() {
+	var webhook *admission.Webhook
+
+	BeforeEach(func() {
+		webhook = &admission.Webhook{Handler: &{{ .Resource.Kind }}Defaulter{}}
+		decoder, err := admission.NewDecoder(scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = admission.InjectDecoderInto(decoder, webhook.Handler)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	// defaultingPatch sends the creation of obj to the webhook, and returns the
+	// JSON patch it answers with along with obj patched by the apiserver.
+	defaultingPatch := func(obj *{{ .Resource.Kind }}) ([]jsonpatch.JsonPatchOperation, *{{ .Resource.Kind }}) {
+		raw, err := json.Marshal(obj)
+		Expect(err).NotTo(HaveOccurred())
+
+		resp := webhook.Handle(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		})
+		Expect(resp.Allowed).To(BeTrue(), "the webhook denied the request: %v", resp.Result)
+
+		ops, err := json.Marshal(resp.Patches)
+		Expect(err).NotTo(HaveOccurred())
+		patch, err := jsonpatchapply.DecodePatch(ops)
+		Expect(err).NotTo(HaveOccurred())
+		patchedRaw, err := patch.Apply(raw)
+		Expect(err).NotTo(HaveOccurred(), "the patch does not apply to the object")
+
+		patched := &{{ .Resource.Kind }}{}
+		Expect(json.Unmarshal(patchedRaw, patched)).To(Succeed())
+		return resp.Patches, patched
+	}
+
+	// sortedByPath sorts the patch operations by path. The operations on the
+	// fields of an object are listed in random order, so only the order of the
+	// operations on a same path is significant.
+	sortedByPath := func(ops []jsonpatch.JsonPatchOperation) []jsonpatch.JsonPatchOperation {
+		sorted := append([]jsonpatch.JsonPatchOperation{}, ops...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+		return sorted
+	}
+
+	table.DescribeTable("defaulting",
+		func(obj *{{ .Resource.Kind }}, expected []jsonpatch.JsonPatchOperation) {
+			obj = obj.DeepCopy()
+			obj.APIVersion = GroupVersion.String()
+			obj.Kind = "{{ .Resource.Kind }}"
+
+			patches, patched := defaultingPatch(obj.DeepCopy())
+
+			By("checking the operations of the patch")
+			Expect(sortedByPath(patches)).To(Equal(sortedByPath(expected)))
+
+			By("checking the patched object is the defaulted object")
+			defaulted := obj.DeepCopy()
+			defaulted.Default(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1beta1.AdmissionRequest{Operation: admissionv1beta1.Create},
+			})
+			Expect(patched).To(Equal(defaulted))
+		},
+		table.Entry("an empty spec",
+			&{{ .Resource.Kind }}{ObjectMeta: metav1.ObjectMeta{Name: "foo"{{ if .Resource.Namespaced }}, Namespace: "default"{{ end }}}},
+{{- if .DefaultMarkers }}
+			[]jsonpatch.JsonPatchOperation{
+				{Operation: "add", Path: "/spec/size", Value: float64(1)},
+			}),
+{{- else }}
+			nil),
+{{- end }}
+
+		// TODO(user): add the lists and maps your Default fills in. For example
+		// with a Containers list whose items default their ImagePullPolicy:
+		//	table.Entry("containers without a pull policy",
+		//		&{{ .Resource.Kind }}{
+		//			ObjectMeta: metav1.ObjectMeta{Name: "foo"{{ if .Resource.Namespaced }}, Namespace: "default"{{ end }}},
+		//			Spec: {{ .Resource.Kind }}Spec{Containers: []Container{ {Name: "a"}, {Name: "b", ImagePullPolicy: "Always"}, {Name: "c"} }},
+		//		},
+		//		[]jsonpatch.JsonPatchOperation{
+		//			{Operation: "add", Path: "/spec/containers/0/imagePullPolicy", Value: "IfNotPresent"},
+		//			{Operation: "add", Path: "/spec/containers/2/imagePullPolicy", Value: "IfNotPresent"},
+		//		}),
+		// The values of the operations are decoded from JSON: numbers are float64,
+		// and objects map[string]interface{}.
+	)
+})
+{{- end }}
+{{- if .Validating }}
+
+var _ = Describe("{{ .Resource.Kind }} validating webhook", func() {
+	var webhook *admission.Webhook
+
+	BeforeEach(func() {
+		webhook = &admission.Webhook{Handler: &{{ .Resource.Kind }}Validator{}}
+		decoder, err := admission.NewDecoder(scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = admission.InjectDecoderInto(decoder, webhook.Handler)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	// rawObject returns obj as sent by the apiserver.
+	rawObject := func(obj *{{ .Resource.Kind }}) runtime.RawExtension {
+		obj = obj.DeepCopy()
+		obj.APIVersion = GroupVersion.String()
+		obj.Kind = "{{ .Resource.Kind }}"
+		raw, err := json.Marshal(obj)
+		Expect(err).NotTo(HaveOccurred())
+		return runtime.RawExtension{Raw: raw}
+	}
+
+	// sample returns a valid {{ .Resource.Kind }}, edited by the entries into the
+	// objects they test.
+	sample := func(edit func(obj *{{ .Resource.Kind }})) *{{ .Resource.Kind }} {
+		obj := &{{ .Resource.Kind }}{ObjectMeta: metav1.ObjectMeta{Name: "foo"{{ if .Resource.Namespaced }}, Namespace: "default"{{ end }}}}
+		if edit != nil {
+			edit(obj)
+		}
+		return obj
+	}
+
+	table.DescribeTable("ValidateCreate",
+		func(obj *{{ .Resource.Kind }}, allowed bool) {
+			resp := webhook.Handle(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1beta1.AdmissionRequest{
+					Operation: admissionv1beta1.Create,
+					Object:    rawObject(obj),
+				},
+			})
+			Expect(resp.Allowed).To(Equal(allowed), "the answer of the webhook: %v", resp.Result)
+		},
+		table.Entry("an empty spec", sample(nil), true),
+
+		// TODO(user): add the objects ValidateCreate admits and denies, e.g.
+		//	table.Entry("a negative size",
+		//		sample(func(obj *{{ .Resource.Kind }}) { obj.Spec.Size = -1 }), false),
+	)
+
+	table.DescribeTable("ValidateUpdate",
+		func(old, obj *{{ .Resource.Kind }}, allowed bool) {
+			resp := webhook.Handle(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1beta1.AdmissionRequest{
+					Operation: admissionv1beta1.Update,
+					Object:    rawObject(obj),
+					OldObject: rawObject(old),
+				},
+			})
+			Expect(resp.Allowed).To(Equal(allowed), "the answer of the webhook: %v", resp.Result)
+		},
+		table.Entry("an unchanged object", sample(nil), sample(nil), true),
+
+		// TODO(user): add the updates ValidateUpdate admits and denies, e.g.
+		//	table.Entry("a change of the immutable spec.foo",
+		//		sample(nil), sample(func(obj *{{ .Resource.Kind }}) { obj.Spec.Foo = "bar" }), false),
+		// The user and groups of the update are set in the UserInfo of the
+		// AdmissionRequest, for the rules depending on who makes it.
+	)
+})
+{{- end }}
+`
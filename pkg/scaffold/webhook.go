@@ -0,0 +1,544 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/input"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/project"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/result"
+	resourcev1 "sigs.k8s.io/kubebuilder/pkg/scaffold/v1/resource"
+	resourcev2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2"
+	crdv2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2/crd"
+	"sigs.k8s.io/kubebuilder/pkg/scaffold/v2/secretstore"
+	webhookv2 "sigs.k8s.io/kubebuilder/pkg/scaffold/v2/webhook"
+)
+
+// Webhook contains configuration for generating scaffolding for the
+// defaulting and validating webhooks of an existing API.
+type Webhook struct {
+	Resource *resourcev1.Resource
+
+	project *input.ProjectFile
+
+	// Defaulting indicates whether to scaffold a defaulting webhook
+	Defaulting bool
+
+	// DefaultMarkers indicates whether to add a field defaulted by a
+	// +kubebuilder:default marker to the Spec, defaulted the same way by the
+	// defaulting webhook, with a test checking both defaults match
+	DefaultMarkers bool
+
+	// Validation indicates whether to scaffold a validating webhook
+	Validation bool
+
+	// ReportOnly indicates whether the validating webhook admits the requests
+	// failing validation, only recording them in the audit log
+	ReportOnly bool
+
+	// Envtest indicates whether to serve the defaulting and validating
+	// webhooks in the test environment of the controller suite
+	Envtest bool
+
+	// References indicates whether to scaffold a validating webhook denying
+	// the objects which reference Secrets that do not exist
+	References bool
+
+	// Payloads indicates whether to scaffold a validating webhook denying the
+	// objects whose payload references do not resolve to the pinned payloads
+	Payloads bool
+
+	// DeletionProtection indicates whether to scaffold a validating webhook
+	// denying the deletion of the protected objects
+	DeletionProtection bool
+
+	// Quota indicates whether to scaffold a validating webhook denying the
+	// creation of the objects beyond QuotaLimit per namespace
+	Quota bool
+
+	// QuotaLimit is the maximum number of objects per namespace enforced by
+	// the quota webhook
+	QuotaLimit int
+
+	// Delegate is the policy engine a validating webhook delegates the
+	// admission of the objects to, one of opa or http, none when empty
+	Delegate string
+
+	// Conversion indicates whether to scaffold the conversion webhook of the
+	// CRD of the resource
+	Conversion bool
+
+	// HubVersion is the version of the resource the other versions are
+	// converted through, the version of the resource when empty
+	HubVersion string
+
+	// ConversionPath is the path the conversion webhook is served at
+	ConversionPath string
+
+	// ConversionPort is the port of the webhook service receiving the
+	// conversion reviews, the apiserver default of 443 when 0
+	ConversionPort int
+
+	// ConversionReviewVersions are the ConversionReview versions accepted by
+	// the conversion webhook, the apiserver default of v1beta1 when empty
+	ConversionReviewVersions []string
+
+	// CertProvider is the tool provisioning the webhook serving certificate,
+	// one of cert-manager, vault or csi
+	CertProvider string
+}
+
+// Validate validates whether the webhook scaffold has correct bits to
+// generate scaffolding for the webhooks.
+func (wh *Webhook) Validate() error {
+	if err := wh.setDefaults(); err != nil {
+		return err
+	}
+	if !project.HasV2Layout(wh.project.Version) {
+		return fmt.Errorf("create webhook is not supported for project version %s", wh.project.Version)
+	}
+	if wh.Resource.Group == "" {
+		return fmt.Errorf("missing group information for resource")
+	}
+	if wh.Resource.Version == "" {
+		return fmt.Errorf("missing version information for resource")
+	}
+	if wh.Resource.Kind == "" {
+		return fmt.Errorf("missing kind information for resource")
+	}
+	if !wh.Defaulting && !wh.Validation && !wh.References && !wh.Payloads && !wh.DeletionProtection && !wh.Quota &&
+		wh.Delegate == "" && !wh.Conversion {
+		return fmt.Errorf("at least one of defaulting, validation, reference validation, payload validation, deletion protection, " +
+			"quota, delegated or conversion webhooks must be requested")
+	}
+	if wh.DefaultMarkers && !wh.Defaulting {
+		return fmt.Errorf("default markers require the defaulting webhook to be requested")
+	}
+	if wh.ReportOnly && !wh.Validation {
+		return fmt.Errorf("report-only mode requires the validating webhook to be requested")
+	}
+	if wh.Quota && !wh.Resource.Namespaced {
+		return fmt.Errorf("the quota webhook limits the objects per namespace, %s is cluster-scoped", wh.Resource.Kind)
+	}
+	if wh.Quota && wh.QuotaLimit < 1 {
+		return fmt.Errorf("quota limit %d must be at least 1", wh.QuotaLimit)
+	}
+	switch wh.Delegate {
+	case "", project.PolicyDelegateOPA, project.PolicyDelegateHTTP:
+	default:
+		return fmt.Errorf("unknown policy delegate %q, must be one of %s, %s", wh.Delegate,
+			project.PolicyDelegateOPA, project.PolicyDelegateHTTP)
+	}
+	if !wh.Conversion && (wh.ConversionPath != "/convert" || wh.ConversionPort != 0 ||
+		len(wh.ConversionReviewVersions) > 0 || wh.HubVersion != "") {
+		return fmt.Errorf("the conversion path, port, review versions and hub version require the conversion webhook to be requested")
+	}
+	if wh.HubVersion != "" && wh.HubVersion != wh.Resource.Version && !wh.hasVersion(wh.HubVersion) {
+		return fmt.Errorf("hub version %s of %s is not a resource of the project", wh.HubVersion, wh.Resource.Kind)
+	}
+	if !strings.HasPrefix(wh.ConversionPath, "/") {
+		return fmt.Errorf("conversion path %q must start with /", wh.ConversionPath)
+	}
+	if wh.ConversionPort < 0 || wh.ConversionPort > 65535 {
+		return fmt.Errorf("conversion port %d must be between 1 and 65535", wh.ConversionPort)
+	}
+	for _, v := range wh.ConversionReviewVersions {
+		if v == "" {
+			return fmt.Errorf("conversion review versions must not be empty")
+		}
+	}
+	switch wh.CertProvider {
+	case project.CertProviderCertManager, project.CertProviderVault, project.CertProviderCSI,
+		project.CertProviderSelfSigned:
+	default:
+		return fmt.Errorf("unknown cert provider %q, must be one of %s, %s, %s, %s", wh.CertProvider,
+			project.CertProviderCertManager, project.CertProviderVault, project.CertProviderCSI,
+			project.CertProviderSelfSigned)
+	}
+	return nil
+}
+
+func (wh *Webhook) setDefaults() error {
+	if wh.project == nil {
+		p, err := LoadProjectFile("PROJECT")
+		if err != nil {
+			return err
+		}
+		wh.project = &p
+	}
+	if wh.CertProvider == "" {
+		wh.CertProvider = project.CertProviderCertManager
+	}
+	// the scope of the resource is not given to create webhook
+	wh.Resource.Namespaced = isNamespaced(wh.project, wh.Resource)
+	// nor is the domain of a group created with --force-group-suffix=false
+	for _, res := range wh.project.Resources {
+		if res.Group == wh.Resource.Group {
+			wh.Resource.Domain = res.Domain
+			break
+		}
+	}
+	if wh.ConversionPath == "" {
+		wh.ConversionPath = "/convert"
+	}
+	return nil
+}
+
+// newScaffold returns the Scaffold of the files of the webhooks, with the domain
+// of the group of the resource.
+func (wh *Webhook) newScaffold() *Scaffold {
+	return &Scaffold{Domain: wh.Resource.Domain}
+}
+
+// Scaffold scaffolds the webhooks and the configuration of the chosen cert provider.
+func (wh *Webhook) Scaffold() error {
+	if err := wh.setDefaults(); err != nil {
+		return err
+	}
+	r := wh.Resource
+	// the webhooks are wired in main.go at once, once all of them are scaffolded
+	mainUpdate := &resourcev2.MainUpdateOptions{Project: wh.project, Resource: r}
+
+	controller := filepath.Join(controllersDir(wh.project, r), fmt.Sprintf("%s_controller.go", strings.ToLower(r.Kind)))
+	controllerCode, err := ioutil.ReadFile(controller) // nolint: gosec
+	hasController := err == nil
+
+	if wh.Defaulting || wh.Validation {
+		fmt.Println(filepath.Join(apiDir(wh.project, r),
+			fmt.Sprintf("%s_webhook.go", strings.ToLower(r.Kind))))
+
+		if wh.DefaultMarkers {
+			err = (&resourcev2.DefaultedField{
+				Path: filepath.Join(apiDir(wh.project, r), fmt.Sprintf("%s_types.go", strings.ToLower(r.Kind))),
+			}).Update()
+			if err != nil {
+				return fmt.Errorf("error adding the defaulted field: %v", err)
+			}
+		}
+
+		files := []input.File{
+			&resourcev2.Webhook{
+				Resource:       r,
+				Defaulting:     wh.Defaulting,
+				DefaultMarkers: wh.DefaultMarkers,
+				Validating:     wh.Validation,
+				ReportOnly:     wh.ReportOnly,
+			},
+		}
+		files = append(files, &resourcev2.WebhookTest{
+			Resource:       r,
+			Defaulting:     wh.Defaulting,
+			Validating:     wh.Validation,
+			DefaultMarkers: wh.DefaultMarkers,
+		})
+		if wh.DefaultMarkers {
+			files = append(files, &resourcev2.WebhookDefaultsTest{Resource: r})
+		}
+		if hasController {
+			files = append(files, &resourcev2.WebhookIntegrationTest{
+				Resource:         r,
+				Defaulting:       wh.Defaulting,
+				Validating:       wh.Validation,
+				CreationGuard:    strings.Contains(string(controllerCode), "CreationGuard *CreationGuard"),
+				TransitionEvents: strings.Contains(string(controllerCode), "Recorder record.EventRecorder"),
+			})
+		}
+		err = wh.newScaffold().Execute(input.Options{}, files...)
+		if err != nil {
+			return fmt.Errorf("error scaffolding webhook: %v", err)
+		}
+	}
+
+	if wh.Defaulting || (wh.Validation && !wh.ReportOnly) {
+		mainUpdate.WireWebhook = true
+
+		if wh.Envtest {
+			if err := wh.serveInEnvtest(); err != nil {
+				return fmt.Errorf("error registering the webhooks in the controller suite: %v", err)
+			}
+		}
+	}
+
+	if wh.ReportOnly {
+		err = wh.newScaffold().Execute(
+			input.Options{},
+			&resourcev2.ReportOnlyWebhook{Resource: r},
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding report-only webhook: %v", err)
+		}
+
+		mainUpdate.WireReportOnlyWebhook = true
+	}
+
+	if wh.References {
+		fmt.Println(filepath.Join(apiDir(wh.project, r),
+			fmt.Sprintf("%s_references_webhook.go", strings.ToLower(r.Kind))))
+
+		err = wh.newScaffold().Execute(
+			input.Options{},
+			&resourcev2.ReferenceWebhook{Resource: r},
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding reference validation webhook: %v", err)
+		}
+
+		mainUpdate.WireReferenceWebhook = true
+	}
+
+	if wh.Payloads {
+		payloadTypes := filepath.Join(apiDir(wh.project, r), "payload_types.go")
+		if _, err := os.Stat(payloadTypes); err != nil {
+			return fmt.Errorf("payload validation requires the payload references of an API created with "+
+				"--payload-reference at %s: %v", payloadTypes, err)
+		}
+		fmt.Println(filepath.Join(apiDir(wh.project, r),
+			fmt.Sprintf("%s_payload_webhook.go", strings.ToLower(r.Kind))))
+
+		err = wh.newScaffold().Execute(
+			input.Options{},
+			&resourcev2.PayloadWebhook{Resource: r},
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding payload validation webhook: %v", err)
+		}
+
+		mainUpdate.WirePayloadWebhook = true
+	}
+
+	if wh.DeletionProtection {
+		fmt.Println(filepath.Join(apiDir(wh.project, r),
+			fmt.Sprintf("%s_deletion_webhook.go", strings.ToLower(r.Kind))))
+
+		err = wh.newScaffold().Execute(
+			input.Options{},
+			&resourcev2.DeletionProtector{Resource: r},
+			&resourcev2.DeletionProtectionWebhook{Resource: r},
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding deletion protection webhook: %v", err)
+		}
+
+		mainUpdate.WireDeletionProtectionWebhook = true
+	}
+
+	if wh.Quota {
+		fmt.Println(filepath.Join(apiDir(wh.project, r),
+			fmt.Sprintf("%s_quota_webhook.go", strings.ToLower(r.Kind))))
+
+		err = wh.newScaffold().Execute(
+			input.Options{},
+			&resourcev2.QuotaWebhook{Resource: r, Limit: wh.QuotaLimit},
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding quota webhook: %v", err)
+		}
+
+		mainUpdate.WireQuotaWebhook = true
+	}
+
+	if wh.Delegate != "" {
+		fmt.Println(filepath.Join(apiDir(wh.project, r),
+			fmt.Sprintf("%s_policy_webhook.go", strings.ToLower(r.Kind))))
+
+		err = wh.newScaffold().Execute(
+			input.Options{},
+			&resourcev2.PolicyWebhook{Resource: r, Delegate: wh.Delegate},
+		)
+		if err != nil {
+			return fmt.Errorf("error scaffolding delegated policy webhook: %v", err)
+		}
+
+		mainUpdate.WirePolicyWebhook = true
+	}
+
+	if wh.Conversion {
+		for _, v := range wh.ConversionReviewVersions {
+			if v != "v1beta1" {
+				result.Warnf("the conversion webhook of controller-runtime only answers v1beta1 ConversionReviews, "+
+					"the %s ones need a handler of your own.", v)
+			}
+		}
+
+		files := []input.File{
+			&crdv2.EnableWebhookPatch{
+				// the patch scaffolded with the API points to the default path
+				// and port
+				Input:                    input.Input{IfExistsAction: input.Overwrite},
+				Resource:                 r,
+				WebhookPath:              wh.ConversionPath,
+				WebhookPort:              wh.ConversionPort,
+				ConversionReviewVersions: wh.ConversionReviewVersions,
+			},
+		}
+		files = append(files, wh.conversionFiles()...)
+		err = wh.newScaffold().Execute(input.Options{}, files...)
+		if err != nil {
+			return fmt.Errorf("error scaffolding conversion webhook: %v", err)
+		}
+
+		err = (&crdv2.Kustomization{Resource: r}).EnableConversion(wh.CertProvider == project.CertProviderCertManager)
+		if err != nil {
+			return fmt.Errorf("error enabling conversion webhook patch: %v", err)
+		}
+
+		mainUpdate.WireConversionWebhook = true
+		mainUpdate.ConversionWebhookPath = wh.ConversionPath
+	}
+
+	switch wh.CertProvider {
+	case project.CertProviderVault:
+		err = wh.newScaffold().Execute(
+			input.Options{},
+			&webhookv2.VaultManagerPatch{},
+		)
+	case project.CertProviderCSI:
+		err = wh.newScaffold().Execute(
+			input.Options{},
+			&webhookv2.CSIManagerPatch{},
+			&secretstore.Kustomization{},
+			&secretstore.SecretProviderClass{},
+		)
+	case project.CertProviderSelfSigned:
+		err = wh.newScaffold().Execute(
+			input.Options{},
+			&resourcev2.CertRotator{},
+			&webhookv2.SelfSignedManagerPatch{},
+		)
+		mainUpdate.WireCertRotator = true
+	}
+	if err != nil {
+		return fmt.Errorf("error scaffolding %s cert provider: %v", wh.CertProvider, err)
+	}
+
+	// the webhooks are served with the TLS configuration of the flags, whose
+	// package is scaffolded along the first webhook of the project
+	if mainUpdate.WiresWebhooks() {
+		if err := wh.newScaffold().Execute(input.Options{}, &resourcev2.TLSConfig{}); err != nil {
+			return fmt.Errorf("error scaffolding the webhook TLS configuration: %v", err)
+		}
+	}
+
+	if err := (&resourcev2.Main{}).Update(mainUpdate); err != nil {
+		return fmt.Errorf("error updating main.go: %v", err)
+	}
+
+	if wh.project.Version == project.Version3 {
+		res := trackResource(wh.project, r)
+		if res.Webhooks == nil {
+			res.Webhooks = &input.ResourceWebhooks{}
+		}
+		webhooks := res.Webhooks
+		webhooks.Defaulting = webhooks.Defaulting || wh.Defaulting
+		webhooks.Validation = webhooks.Validation || wh.Validation
+		webhooks.ReportOnly = webhooks.ReportOnly || wh.ReportOnly
+		webhooks.References = webhooks.References || wh.References
+		webhooks.Payloads = webhooks.Payloads || wh.Payloads
+		webhooks.DeletionProtection = webhooks.DeletionProtection || wh.DeletionProtection
+		webhooks.Quota = webhooks.Quota || wh.Quota
+		if wh.Delegate != "" {
+			webhooks.Delegate = wh.Delegate
+		}
+		webhooks.Conversion = webhooks.Conversion || wh.Conversion
+		if err := saveProjectFile("PROJECT", wh.project); err != nil {
+			result.Warnf("error updating project file with webhook information : %v", err)
+		}
+	}
+
+	return nil
+}
+
+// serveInEnvtest registers the defaulting and validating webhooks in the
+// envtest_webhooks_test.go file of the controller suite, serving them in its
+// test environment, which the first webhook of the suite installs them in. The
+// suites scaffolded without the markers installing the webhooks are left as
+// they are.
+func (wh *Webhook) serveInEnvtest() error {
+	r := wh.Resource
+	dir := controllersDir(wh.project, r)
+	suite, err := ioutil.ReadFile(filepath.Join(dir, "suite_test.go")) // nolint: gosec
+	if os.IsNotExist(err) {
+		// the suite is scaffolded with the first controller of the package
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(string(suite), "webhookInstall.Install(") &&
+		!strings.Contains(string(suite), "+kubebuilder:scaffold:envtestsetup") {
+		result.Warnf("%s does not install the webhooks in the test environment: "+
+			"the objects created by the tests are not defaulted or validated by them.", filepath.Join(dir, "suite_test.go"))
+		return nil
+	}
+
+	envtestWebhooks := &resourcev2.EnvtestWebhooks{
+		Resource:   r,
+		Defaulting: wh.Defaulting,
+		Validating: wh.Validation && !wh.ReportOnly,
+	}
+	if err := wh.newScaffold().Execute(input.Options{}, envtestWebhooks); err != nil {
+		return err
+	}
+	if err := envtestWebhooks.Install(); err != nil {
+		return err
+	}
+	return envtestWebhooks.Update()
+}
+
+// hasVersion returns true if the given version of the resource is in the
+// project.
+func (wh *Webhook) hasVersion(version string) bool {
+	res := wh.project.GetResource(wh.Resource.Group, version, wh.Resource.Kind)
+	return res != nil && res.HasAPI()
+}
+
+// conversionFiles returns the files marking the hub version of the resource
+// as the conversion.Hub, and the stubs of the conversion functions of its
+// other versions in the project whose API types exist.
+func (wh *Webhook) conversionFiles() []input.File {
+	r := wh.Resource
+	hubVersion := wh.HubVersion
+	if hubVersion == "" {
+		hubVersion = r.Version
+	}
+	hub := &resourcev1.Resource{Group: r.Group, Domain: r.Domain, Version: hubVersion, Kind: r.Kind, Resource: r.Resource}
+	files := []input.File{&resourcev2.ConversionHub{Resource: hub}}
+
+	versions := []string{r.Version}
+	for _, res := range wh.project.Resources {
+		if res.Group == r.Group && res.Kind == r.Kind && res.Version != r.Version {
+			versions = append(versions, res.Version)
+		}
+	}
+	for _, version := range versions {
+		if version == hubVersion {
+			continue
+		}
+		spoke := &resourcev1.Resource{Group: r.Group, Domain: r.Domain, Version: version, Kind: r.Kind, Resource: r.Resource}
+		types := filepath.Join(apiDir(wh.project, spoke), fmt.Sprintf("%s_types.go", strings.ToLower(r.Kind)))
+		if _, err := os.Stat(types); err != nil {
+			continue
+		}
+		files = append(files, &resourcev2.ConversionSpoke{Resource: spoke, Hub: hub})
+	}
+	return files
+}
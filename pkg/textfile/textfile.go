@@ -0,0 +1,118 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package textfile reads and writes the text files edited by kubebuilder
+// while preserving their encoding, so that a project created on Windows keeps
+// its line endings and byte order mark once code is inserted into its files.
+//
+// The files are edited in their normal form: UTF-8 without a byte order mark,
+// with LF line endings. Normalize returns the normal form of a file along with
+// its Format, which Restore applies back to the edited content. A file is
+// given CRLF line endings when its first line ends with CRLF. The UTF-16 and
+// UTF-32 encodings are refused rather than corrupted.
+package textfile
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF32LE = []byte{0xFF, 0xFE, 0x00, 0x00}
+	bomUTF32BE = []byte{0x00, 0x00, 0xFE, 0xFF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// Format is the encoding of a text file beyond its UTF-8 content.
+type Format struct {
+	// BOM is true if the file starts with the UTF-8 byte order mark
+	BOM bool
+
+	// CRLF is true if the lines of the file end with CRLF rather than LF
+	CRLF bool
+}
+
+// Detect returns the Format of content. It fails if content is encoded
+// with UTF-16 or UTF-32.
+func Detect(content []byte) (Format, error) {
+	for _, bom := range []struct {
+		mark     []byte
+		encoding string
+	}{
+		// the UTF-32LE mark starts with the UTF-16LE one, it is checked first
+		{bomUTF32LE, "UTF-32LE"},
+		{bomUTF32BE, "UTF-32BE"},
+		{bomUTF16LE, "UTF-16LE"},
+		{bomUTF16BE, "UTF-16BE"},
+	} {
+		if bytes.HasPrefix(content, bom.mark) {
+			return Format{}, fmt.Errorf("%s encoding is not supported, convert the file to UTF-8", bom.encoding)
+		}
+	}
+
+	f := Format{BOM: bytes.HasPrefix(content, bomUTF8)}
+	if i := bytes.IndexByte(content, '\n'); i > 0 && content[i-1] == '\r' {
+		f.CRLF = true
+	}
+	return f, nil
+}
+
+// Normalize returns the normal form of content, without byte order mark and
+// with LF line endings, along with the Format to Restore it with.
+func Normalize(content []byte) ([]byte, Format, error) {
+	f, err := Detect(content)
+	if err != nil {
+		return nil, Format{}, err
+	}
+	content = bytes.TrimPrefix(content, bomUTF8)
+	return bytes.Replace(content, []byte("\r\n"), []byte("\n"), -1), f, nil
+}
+
+// Restore returns content, in normal form, encoded with the Format.
+func (f Format) Restore(content []byte) []byte {
+	if f.CRLF {
+		content = bytes.Replace(content, []byte("\r\n"), []byte("\n"), -1)
+		content = bytes.Replace(content, []byte("\n"), []byte("\r\n"), -1)
+	}
+	if f.BOM && !bytes.HasPrefix(content, bomUTF8) {
+		content = append(append([]byte{}, bomUTF8...), content...)
+	}
+	return content
+}
+
+// ReadFile reads the file at path, returning its content in normal form and
+// its Format.
+func ReadFile(path string) ([]byte, Format, error) {
+	content, err := ioutil.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return nil, Format{}, err
+	}
+	content, f, err := Normalize(content)
+	if err != nil {
+		return nil, Format{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return content, f, nil
+}
+
+// WriteFile writes content, in normal form, to the file at path encoded with
+// the Format.
+func WriteFile(path string, content []byte, f Format, perm os.FileMode) error {
+	return ioutil.WriteFile(path, f.Restore(content), perm)
+}
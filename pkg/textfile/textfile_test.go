@@ -0,0 +1,105 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textfile_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/kubebuilder/pkg/textfile"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		format   textfile.Format
+	}{
+		{name: "lf", content: "a\nb\n", expected: "a\nb\n"},
+		{name: "crlf", content: "a\r\nb\r\n", expected: "a\nb\n", format: textfile.Format{CRLF: true}},
+		{name: "bom", content: "\xEF\xBB\xBFa\nb\n", expected: "a\nb\n", format: textfile.Format{BOM: true}},
+		{name: "bom and crlf", content: "\xEF\xBB\xBFa\r\nb\r\n", expected: "a\nb\n",
+			format: textfile.Format{BOM: true, CRLF: true}},
+		{name: "single line", content: "a", expected: "a"},
+		{name: "empty", content: "", expected: ""},
+	}
+
+	for _, test := range tests {
+		content, format, err := textfile.Normalize([]byte(test.content))
+		if err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+			continue
+		}
+		if string(content) != test.expected {
+			t.Errorf("%s: got: %q and wanted: %q", test.name, content, test.expected)
+		}
+		if format != test.format {
+			t.Errorf("%s: got format: %+v and wanted: %+v", test.name, format, test.format)
+		}
+		if restored := format.Restore(content); string(restored) != test.content {
+			t.Errorf("%s: got restored: %q and wanted: %q", test.name, restored, test.content)
+		}
+	}
+}
+
+func TestNormalizeRefusesUTF16(t *testing.T) {
+	for _, content := range []string{"\xFF\xFEa\x00", "\xFE\xFF\x00a", "\xFF\xFE\x00\x00a\x00\x00\x00"} {
+		if _, _, err := textfile.Normalize([]byte(content)); err == nil {
+			t.Errorf("got no error for %q", content)
+		}
+	}
+}
+
+func TestRestoreInsertedLines(t *testing.T) {
+	// the lines inserted in normal form are given the line endings of the file
+	f := textfile.Format{CRLF: true}
+	got := string(f.Restore([]byte("a\nb\r\nc\n")))
+	if expected := "a\r\nb\r\nc\r\n"; got != expected {
+		t.Errorf("got: %q and wanted: %q", got, expected)
+	}
+}
+
+func TestReadWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "textfile")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(path, []byte("\xEF\xBB\xBFpackage main\r\n"), 0600); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	content, format, err := textfile.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if err := textfile.WriteFile(path, append(content, "\nfunc main() {}\n"...), format, 0600); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if expected := "\xEF\xBB\xBFpackage main\r\n\r\nfunc main() {}\r\n"; string(got) != expected {
+		t.Errorf("got: %q and wanted: %q", got, expected)
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+)
+
+const (
+	providerKind     = "kind"
+	providerMinikube = "minikube"
+	providerK3d      = "k3d"
+	providerExisting = "existing"
+)
+
+// ClusterProvider is the cluster the suite deploys the projects to. It makes
+// the images built by the suite available to the nodes of the cluster, and
+// removes them once the test is done.
+type ClusterProvider interface {
+	// Name is the name of the provider, as set in KB_E2E_CLUSTER_PROVIDER
+	Name() string
+
+	// Image returns the reference the image of the given name and tag is
+	// built and deployed under
+	Image(name, tag string) string
+
+	// LoadImage makes the image, saved to the given archive when not empty,
+	// available to the nodes of the cluster
+	LoadImage(kc *KBTestContext, image, archive string) error
+
+	// Cleanup removes the image loaded with LoadImage from the cluster
+	Cleanup(kc *KBTestContext, image string)
+}
+
+// newClusterProvider returns the provider selected by the
+// KB_E2E_CLUSTER_PROVIDER environment variable, kind when empty. The cluster
+// is the one named by KB_E2E_CLUSTER_NAME, or the default cluster of the
+// provider. The existing provider deploys to the cluster of the current
+// kubeconfig, e.g. a GKE cluster, pushing the images to the registry of
+// KB_E2E_IMAGE_REGISTRY the cluster pulls them from.
+func newClusterProvider() (ClusterProvider, error) {
+	name := os.Getenv("KB_E2E_CLUSTER_NAME")
+	switch provider := os.Getenv("KB_E2E_CLUSTER_PROVIDER"); provider {
+	case "", providerKind:
+		if name == "" {
+			name = "kind"
+		}
+		return &kindProvider{cluster: name}, nil
+	case providerMinikube:
+		if name == "" {
+			name = "minikube"
+		}
+		return &minikubeProvider{profile: name}, nil
+	case providerK3d:
+		if name == "" {
+			name = "k3s-default"
+		}
+		return &k3dProvider{cluster: name}, nil
+	case providerExisting:
+		registry := strings.TrimSuffix(os.Getenv("KB_E2E_IMAGE_REGISTRY"), "/")
+		if registry == "" {
+			return nil, fmt.Errorf("the %s cluster provider requires KB_E2E_IMAGE_REGISTRY, "+
+				"the registry the cluster pulls the images of the tests from", providerExisting)
+		}
+		return &existingProvider{registry: registry}, nil
+	default:
+		return nil, fmt.Errorf("unknown KB_E2E_CLUSTER_PROVIDER %q, must be one of %s, %s, %s, %s",
+			provider, providerKind, providerMinikube, providerK3d, providerExisting)
+	}
+}
+
+// saveImage saves image to a new archive, unless it is already saved to
+// archive. The returned function removes the archive.
+func saveImage(kc *KBTestContext, image, archive string) (string, func(), error) {
+	if archive != "" {
+		return archive, func() { os.Remove(archive) }, nil
+	}
+
+	archive, err := tempArchive()
+	if err != nil {
+		return "", nil, err
+	}
+	remove := func() { os.Remove(archive) }
+
+	if ref := kc.runtime.archiveReference(image); ref != image {
+		cmd := exec.Command(kc.runtime.Tool, "tag", image, ref)
+		if _, err := kc.Run(cmd); err != nil {
+			remove()
+			return "", nil, err
+		}
+		image = ref
+	}
+	cmd := exec.Command(kc.runtime.Tool, "save", "-o", archive, image)
+	if _, err := kc.Run(cmd); err != nil {
+		remove()
+		return "", nil, err
+	}
+	return archive, remove, nil
+}
+
+// removeImageFromNodes removes image from the containers running the nodes
+// of a cluster, selected by the given label, with the crictl of the nodes.
+func removeImageFromNodes(kc *KBTestContext, label, image string) {
+	cmd := exec.Command(kc.runtime.Tool, "ps", "--filter", "label="+label, "--format", "{{.Names}}")
+	output, err := kc.Run(cmd)
+	if err != nil {
+		fmt.Fprintf(GinkgoWriter, "error when listing the nodes of the cluster: %v\n", err)
+		return
+	}
+	for _, node := range strings.Fields(string(output)) {
+		cmd := exec.Command(kc.runtime.Tool, "exec", node, "crictl", "rmi", kc.runtime.archiveReference(image))
+		if _, err := kc.Run(cmd); err != nil {
+			fmt.Fprintf(GinkgoWriter, "error when removing the image from node %s: %v\n", node, err)
+		}
+	}
+}
+
+// kindProvider loads the images to a kind cluster. The image is saved to an
+// archive streamed to kind, since `kind load docker-image` only reads the
+// images of a local Docker daemon.
+type kindProvider struct {
+	cluster string
+}
+
+func (p *kindProvider) Name() string { return providerKind }
+
+func (p *kindProvider) Image(name, tag string) string { return name + ":" + tag }
+
+func (p *kindProvider) LoadImage(kc *KBTestContext, image, archive string) error {
+	archive, remove, err := saveImage(kc, image, archive)
+	if err != nil {
+		return err
+	}
+	defer remove()
+	cmd := exec.Command("kind", "load", "image-archive", archive, "--name", p.cluster)
+	_, err = kc.Run(cmd)
+	return err
+}
+
+func (p *kindProvider) Cleanup(kc *KBTestContext, image string) {
+	removeImageFromNodes(kc, "io.x-k8s.kind.cluster="+p.cluster, image)
+}
+
+// minikubeProvider loads the images to a minikube profile.
+type minikubeProvider struct {
+	profile string
+}
+
+func (p *minikubeProvider) Name() string { return providerMinikube }
+
+func (p *minikubeProvider) Image(name, tag string) string { return name + ":" + tag }
+
+func (p *minikubeProvider) LoadImage(kc *KBTestContext, image, archive string) error {
+	archive, remove, err := saveImage(kc, image, archive)
+	if err != nil {
+		return err
+	}
+	defer remove()
+	cmd := exec.Command("minikube", "image", "load", archive, "--profile", p.profile)
+	_, err = kc.Run(cmd)
+	return err
+}
+
+func (p *minikubeProvider) Cleanup(kc *KBTestContext, image string) {
+	cmd := exec.Command("minikube", "image", "rm", kc.runtime.archiveReference(image), "--profile", p.profile)
+	if _, err := kc.Run(cmd); err != nil {
+		fmt.Fprintf(GinkgoWriter, "error when removing the image from minikube: %v\n", err)
+	}
+}
+
+// k3dProvider loads the images to a k3d cluster.
+type k3dProvider struct {
+	cluster string
+}
+
+func (p *k3dProvider) Name() string { return providerK3d }
+
+func (p *k3dProvider) Image(name, tag string) string { return name + ":" + tag }
+
+func (p *k3dProvider) LoadImage(kc *KBTestContext, image, archive string) error {
+	archive, remove, err := saveImage(kc, image, archive)
+	if err != nil {
+		return err
+	}
+	defer remove()
+	cmd := exec.Command("k3d", "image", "import", archive, "--cluster", p.cluster)
+	_, err = kc.Run(cmd)
+	return err
+}
+
+func (p *k3dProvider) Cleanup(kc *KBTestContext, image string) {
+	removeImageFromNodes(kc, "k3d.cluster="+p.cluster, image)
+}
+
+// existingProvider deploys to the cluster of the current kubeconfig, pushing
+// the images to a registry the cluster pulls them from.
+type existingProvider struct {
+	registry string
+}
+
+func (p *existingProvider) Name() string { return providerExisting }
+
+func (p *existingProvider) Image(name, tag string) string {
+	return p.registry + "/" + name + ":" + tag
+}
+
+func (p *existingProvider) LoadImage(kc *KBTestContext, image, archive string) error {
+	if archive != "" {
+		defer os.Remove(archive)
+		cmd := exec.Command(kc.runtime.Tool, "load", "-i", archive)
+		if _, err := kc.Run(cmd); err != nil {
+			return err
+		}
+	}
+	cmd := exec.Command(kc.runtime.Tool, "push", image)
+	_, err := kc.Run(cmd)
+	return err
+}
+
+// Cleanup leaves the image in the registry, as removing it depends on the
+// registry: rely on its retention policy to delete the images of the tests.
+func (p *existingProvider) Cleanup(kc *KBTestContext, image string) {
+	fmt.Fprintf(GinkgoWriter, "leaving the image %s in the registry\n", image)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+const (
+	toolDocker = "docker"
+	toolPodman = "podman"
+)
+
+// containerRuntime is the container tool building the images of the suite,
+// along with the environment pointing it and kind to its daemon.
+type containerRuntime struct {
+	// Tool is the CLI building and saving the images, docker or podman
+	Tool string
+
+	// Env holds the environment variables, in k=v format, needed by the tool
+	// and kind to reach the daemon
+	Env []string
+}
+
+// detectContainerRuntime detects the container tool to use. The
+// KB_E2E_CONTAINER_TOOL environment variable selects the tool explicitly. A
+// daemon set with DOCKER_HOST, e.g. a remote Docker host, is used as is;
+// otherwise the rootful Docker socket is preferred, then the rootless Docker
+// socket and last rootless podman.
+func detectContainerRuntime() containerRuntime {
+	tool := os.Getenv("KB_E2E_CONTAINER_TOOL")
+	if os.Getenv("DOCKER_HOST") != "" {
+		if tool == "" {
+			tool = toolDocker
+		}
+		return newContainerRuntime(tool)
+	}
+
+	if tool != toolPodman {
+		if fileExists("/var/run/docker.sock") {
+			return newContainerRuntime(toolDocker)
+		}
+		rootless := filepath.Join(os.Getenv("XDG_RUNTIME_DIR"), "docker.sock")
+		if os.Getenv("XDG_RUNTIME_DIR") != "" && fileExists(rootless) {
+			r := newContainerRuntime(toolDocker)
+			r.Env = append(r.Env, "DOCKER_HOST=unix://"+rootless)
+			return r
+		}
+	}
+
+	if tool == "" {
+		tool = toolDocker
+		if _, err := exec.LookPath(toolDocker); err != nil {
+			if _, err := exec.LookPath(toolPodman); err == nil {
+				tool = toolPodman
+			}
+		}
+	}
+	return newContainerRuntime(tool)
+}
+
+func newContainerRuntime(tool string) containerRuntime {
+	r := containerRuntime{Tool: tool}
+	if tool == toolPodman {
+		r.Env = append(r.Env, "KIND_EXPERIMENTAL_PROVIDER=podman")
+	}
+	return r
+}
+
+// archiveReference returns the reference under which image is saved into the
+// archive loaded in kind. podman qualifies the images it builds with
+// localhost/, so they are saved under the docker.io name the kubelet resolves
+// the unqualified image names of the manifests to.
+func (r containerRuntime) archiveReference(image string) string {
+	if r.Tool == toolPodman {
+		return "docker.io/" + image
+	}
+	return image
+}
+
+// writeDockerShim writes a docker executable running the container tool to
+// dir, for the docker-build target of the scaffolded Makefile to build the
+// images with podman.
+func (r containerRuntime) writeDockerShim(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	shim := fmt.Sprintf("#!/bin/sh\nexec %s \"$@\"\n", r.Tool)
+	return ioutil.WriteFile(filepath.Join(dir, toolDocker), []byte(shim), 0755)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
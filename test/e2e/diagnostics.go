@@ -0,0 +1,268 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+)
+
+// containerState is the subset of the state of a container inspected by the
+// diagnostics.
+type containerState struct {
+	Waiting *struct {
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	} `json:"waiting"`
+	Terminated *struct {
+		Reason   string `json:"reason"`
+		Message  string `json:"message"`
+		ExitCode int    `json:"exitCode"`
+	} `json:"terminated"`
+}
+
+// podStatuses is the list of pods decoded by CollectDiagnostics, with the
+// statuses of their containers.
+type podStatuses struct {
+	Items []struct {
+		Metadata objectMeta `json:"metadata"`
+		Status   struct {
+			Phase             string `json:"phase"`
+			ContainerStatuses []struct {
+				Name         string         `json:"name"`
+				Image        string         `json:"image"`
+				RestartCount int            `json:"restartCount"`
+				State        containerState `json:"state"`
+				LastState    containerState `json:"lastState"`
+			} `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// eventList is the list of events decoded by CollectDiagnostics.
+type eventList struct {
+	Items []Event `json:"items"`
+}
+
+// Event is the subset of an event of the namespace of the test reported by
+// the diagnostics.
+type Event struct {
+	Type           string `json:"type"`
+	Reason         string `json:"reason"`
+	Message        string `json:"message"`
+	Count          int    `json:"count"`
+	LastTimestamp  string `json:"lastTimestamp"`
+	InvolvedObject struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"involvedObject"`
+}
+
+// String formats the event as kubectl get events does.
+func (e Event) String() string {
+	return fmt.Sprintf("%s %s %s/%s: %s", e.Type, e.Reason,
+		strings.ToLower(e.InvolvedObject.Kind), e.InvolvedObject.Name, e.Message)
+}
+
+// WaitingContainer is a container which is not running, with the reason
+// given by the kubelet, e.g. ImagePullBackOff or CrashLoopBackOff.
+type WaitingContainer struct {
+	Pod       string
+	Container string
+	Image     string
+	Reason    string
+	Message   string
+}
+
+// Diagnostics is the state of the deployment of the project, collected to
+// explain why it is not running.
+type Diagnostics struct {
+	// Waiting are the containers of the pods of the namespace which are not
+	// running
+	Waiting []WaitingContainer
+
+	// Events are the events of the namespace, the most recent last
+	Events []Event
+
+	// Text is the report of the diagnostics, the waiting containers and
+	// warning events first, followed by the description of the deployments
+	Text string
+}
+
+// CollectDiagnostics collects the state of the pods, events and deployments
+// of the namespace of the test. The report is written to GinkgoWriter, and to
+// the artifacts directory of the test, if any.
+func (kc *KBTestContext) CollectDiagnostics() (*Diagnostics, error) {
+	d := &Diagnostics{}
+	text := &strings.Builder{}
+
+	pods := &podStatuses{}
+	if err := kc.Kubectl.GetJSON("pods", "", pods); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(text, "# pods of namespace %s\n", kc.Kubectl.Namespace)
+	for _, p := range pods.Items {
+		fmt.Fprintf(text, "%s: %s\n", p.Metadata.Name, p.Status.Phase)
+		for _, c := range p.Status.ContainerStatuses {
+			switch {
+			case c.State.Waiting != nil:
+				d.Waiting = append(d.Waiting, WaitingContainer{
+					Pod:       p.Metadata.Name,
+					Container: c.Name,
+					Image:     c.Image,
+					Reason:    c.State.Waiting.Reason,
+					Message:   c.State.Waiting.Message,
+				})
+				fmt.Fprintf(text, "  container %s (image %s) is waiting: %s: %s\n",
+					c.Name, c.Image, c.State.Waiting.Reason, c.State.Waiting.Message)
+			case c.State.Terminated != nil:
+				fmt.Fprintf(text, "  container %s (image %s) terminated with exit code %d: %s: %s\n",
+					c.Name, c.Image, c.State.Terminated.ExitCode, c.State.Terminated.Reason, c.State.Terminated.Message)
+			default:
+				fmt.Fprintf(text, "  container %s (image %s) is running\n", c.Name, c.Image)
+			}
+			if t := c.LastState.Terminated; t != nil {
+				fmt.Fprintf(text, "  container %s restarted %d times, last terminated with exit code %d: %s: %s\n",
+					c.Name, c.RestartCount, t.ExitCode, t.Reason, t.Message)
+			}
+		}
+	}
+
+	events := &eventList{}
+	if err := kc.Kubectl.GetJSON("events", "", events); err != nil {
+		return nil, err
+	}
+	d.Events = events.Items
+	sort.SliceStable(d.Events, func(i, j int) bool { return d.Events[i].LastTimestamp < d.Events[j].LastTimestamp })
+	fmt.Fprintf(text, "\n# warning events of namespace %s\n", kc.Kubectl.Namespace)
+	for _, e := range d.Events {
+		if e.Type != "Normal" {
+			fmt.Fprintln(text, e)
+		}
+	}
+
+	// the description of the deployments gives their conditions, e.g. a
+	// Progressing condition whose deadline is exceeded, and all the events
+	describe, err := kc.Kubectl.CommandInNamespace("describe", "deployments")
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(text, "\n# deployments of namespace %s\n%s", kc.Kubectl.Namespace, describe)
+	d.Text = text.String()
+
+	fmt.Fprintf(GinkgoWriter, "diagnostics of namespace %s:\n%s\n", kc.Kubectl.Namespace, d.Text)
+	if err := kc.writeArtifact("diagnostics.txt", d.Text); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// artifactsDir returns the directory the artifacts of the running test are
+// written to, in the directory given by the ARTIFACTS environment variable, as
+// set by Prow, or else by KB_E2E_REPORT_DIR. It is empty when neither is set.
+func (kc *KBTestContext) artifactsDir() string {
+	dir := os.Getenv("ARTIFACTS")
+	if dir == "" {
+		dir = os.Getenv("KB_E2E_REPORT_DIR")
+	}
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, kc.reportName())
+}
+
+// writeArtifact writes the artifact of the given name to the artifacts
+// directory of the running test, if any.
+func (kc *KBTestContext) writeArtifact(name, content string) error {
+	dir := kc.artifactsDir()
+	if dir == "" {
+		return nil
+	}
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	fmt.Fprintf(GinkgoWriter, "writing the %s artifact of the test to %s\n", name, path)
+	return ioutil.WriteFile(path, []byte(content), 0644)
+}
+
+// CollectArtifacts writes the logs of the containers of the controller-manager
+// pods, their descriptions, the events of the namespace of the test and its
+// webhook configurations, along with the diagnostics, to the artifacts
+// directory of the test. It is called by CleanupManifests when the test has
+// failed, before the objects are deleted. All the artifacts are collected
+// even when some of them cannot be, the first error being returned.
+func (kc *KBTestContext) CollectArtifacts() error {
+	var errs []error
+	collect := func(name string, get func() (string, error)) {
+		content, err := get()
+		if err != nil {
+			errs = append(errs, err)
+			// the error of kubectl explains the missing artifact
+			content = err.Error()
+		}
+		if err := kc.writeArtifact(name, content); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if _, err := kc.CollectDiagnostics(); err != nil {
+		errs = append(errs, err)
+	}
+
+	pods := &podStatuses{}
+	if err := kc.Kubectl.GetJSON("pods", "", pods, "-l", "control-plane=controller-manager"); err != nil {
+		errs = append(errs, err)
+	}
+	for _, p := range pods.Items {
+		name := p.Metadata.Name
+		for _, c := range p.Status.ContainerStatuses {
+			container := c.Name
+			collect(filepath.Join("logs", name+"-"+container+".log"), func() (string, error) {
+				return kc.Kubectl.Logs(name, "-c", container)
+			})
+			if c.RestartCount > 0 {
+				collect(filepath.Join("logs", name+"-"+container+".previous.log"), func() (string, error) {
+					return kc.Kubectl.Logs(name, "-c", container, "--previous")
+				})
+			}
+		}
+	}
+	collect("describe-manager-pods.txt", func() (string, error) {
+		return kc.Kubectl.CommandInNamespace("describe", "pods", "-l", "control-plane=controller-manager")
+	})
+	collect("events.txt", func() (string, error) {
+		return kc.Kubectl.Get(true, "events", "-o", "wide", "--sort-by=.lastTimestamp")
+	})
+	collect("webhookconfigurations.yaml", func() (string, error) {
+		return kc.Kubectl.Get(false,
+			"mutatingwebhookconfigurations.admissionregistration.k8s.io/"+kc.Name("mutating-webhook-configuration"),
+			"validatingwebhookconfigurations.admissionregistration.k8s.io/"+kc.Name("validating-webhook-configuration"),
+			"--ignore-not-found", "-o", "yaml")
+	})
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
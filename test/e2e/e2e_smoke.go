@@ -0,0 +1,217 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/kubebuilder/pkg/kustomizelint"
+)
+
+// The specs tagged [smoke] do not need a cluster nor a container tool. They
+// are the fast tier of the suite, run alone with KB_E2E_PROFILE=smoke, while
+// the specs deploying the projects are run with KB_E2E_PROFILE=deploy.
+
+var _ = Describe("kubebuilder", func() {
+	Context("[smoke] with v2 scaffolding", func() {
+		var kbc *KBTestContext
+		BeforeEach(func() {
+			var err error
+			kbc, err = TestContext("GO111MODULE=on")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kbc.Prepare()).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(kbc.WriteReport()).To(Succeed())
+
+			kbc.By("remove the work dir")
+			kbc.Destroy()
+		})
+
+		It("should generate a project which builds with manifests kustomize builds", func() {
+			if kbc.Prescaffolded {
+				kbc.By("reusing the project scaffolded in " + kbc.Dir)
+			} else {
+				kbc.By("init v2 project")
+				Expect(kbc.Init(
+					"--project-version", "2",
+					"--domain", kbc.Domain,
+					"--dep=false")).To(Succeed())
+
+				kbc.By("creating api definition")
+				Expect(kbc.CreateAPI(
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", kbc.Kind,
+					"--namespaced",
+					"--resource",
+					"--controller",
+					"--make=false")).To(Succeed())
+
+				kbc.By("creating the mutating and validating webhooks")
+				cmd := exec.Command("kubebuilder", "create", "webhook",
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", kbc.Kind,
+					"--defaulting",
+					"--programmatic-validation")
+				_, err := kbc.Run(cmd)
+				Expect(err).Should(Succeed())
+
+				kbc.By("uncomment kustomization.yaml to enable webhook and ca injection")
+				for _, target := range []string{
+					"#- ../webhook", "#- ../certmanager", "#- manager_webhook_patch.yaml", "#- webhookcainjection_patch.yaml",
+				} {
+					Expect(uncommentCode(
+						filepath.Join(kbc.Dir, "config", "default", "kustomization.yaml"), target, "#")).To(Succeed())
+				}
+			}
+
+			kbc.By("building the manager")
+			Expect(kbc.Make("manager")).To(Succeed())
+
+			kbc.By("generating the manifests")
+			Expect(kbc.Make("manifests")).To(Succeed())
+
+			kbc.By("checking config/default builds with kustomize")
+			diagnostics, err := kustomizelint.Lint(filepath.Join(kbc.Dir, "config", "default"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diagnostics).To(BeEmpty())
+		})
+
+		It("should generate webhook tests of two kinds of a version which build", func() {
+			if kbc.Prescaffolded {
+				Skip("the project is scaffolded once by a previous run")
+			}
+			kind := kbc.Kind + "Other"
+
+			kbc.By("init v2 project")
+			Expect(kbc.Init(
+				"--project-version", "2",
+				"--domain", kbc.Domain,
+				"--dep=false")).To(Succeed())
+
+			for _, k := range []string{kbc.Kind, kind} {
+				kbc.By("creating the api definition and the webhooks of " + k)
+				Expect(kbc.CreateAPI(
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", k,
+					"--namespaced",
+					"--resource",
+					"--controller",
+					"--make=false")).To(Succeed())
+				cmd := exec.Command("kubebuilder", "create", "webhook",
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", k,
+					"--defaulting",
+					"--programmatic-validation")
+				_, err := kbc.Run(cmd)
+				Expect(err).Should(Succeed())
+			}
+
+			kbc.By("generating the deepcopy functions of the package of the webhooks")
+			Expect(kbc.Make("generate")).To(Succeed())
+
+			kbc.By("building the manager")
+			Expect(kbc.Make("manager")).To(Succeed())
+
+			kbc.By("building the tests of the webhooks of both kinds in one package")
+			_, err := kbc.Run(exec.Command("go", "test", "-count=1", "-run", "^$", "./..."))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should add the scheme registry to the controllers suite once", func() {
+			if kbc.Prescaffolded {
+				Skip("the project is scaffolded once by a previous run")
+			}
+
+			kbc.By("init v2 project registering the group versions in api/scheme.go")
+			Expect(kbc.Init(
+				"--project-version", "2",
+				"--domain", kbc.Domain,
+				"--scheme-registration", "registry",
+				"--dep=false")).To(Succeed())
+
+			for _, k := range []string{kbc.Kind, kbc.Kind + "Other"} {
+				kbc.By("creating the api definition of " + k)
+				Expect(kbc.CreateAPI(
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", k,
+					"--namespaced",
+					"--resource",
+					"--controller",
+					"--make=false")).To(Succeed())
+			}
+
+			kbc.By("checking the registry is added to the scheme of the suite once")
+			suite, err := ioutil.ReadFile(filepath.Join(kbc.Dir, "controllers", "suite_test.go"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.Count(string(suite), "api.AddToScheme(scheme.Scheme)")).To(Equal(1))
+
+			kbc.By("building the manager and the tests")
+			Expect(kbc.Make("manager")).To(Succeed())
+			_, err = kbc.Run(exec.Command("go", "test", "-count=1", "-run", "^$", "./..."))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should generate a heartbeat reporting the reconciles whose test passes", func() {
+			if kbc.Prescaffolded {
+				Skip("the project is scaffolded once by a previous run")
+			}
+
+			kbc.By("init v2 project with the heartbeat")
+			Expect(kbc.Init(
+				"--project-version", "2",
+				"--domain", kbc.Domain,
+				"--heartbeat",
+				"--dep=false")).To(Succeed())
+
+			kbc.By("creating api definition")
+			Expect(kbc.CreateAPI(
+				"--group", kbc.Group,
+				"--version", kbc.Version,
+				"--kind", kbc.Kind,
+				"--namespaced",
+				"--resource",
+				"--controller",
+				"--make=false")).To(Succeed())
+
+			kbc.By("building the manager")
+			Expect(kbc.Make("manager")).To(Succeed())
+
+			kbc.By("testing the heartbeat, without the test environment")
+			_, err := kbc.Run(exec.Command("go", "test", "-count=1", "-run", "^TestHeartbeat", "./controllers/..."))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should load the declarative steps", func() {
+			steps, err := kbc.LoadSteps(stepsDir())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(steps).NotTo(BeEmpty())
+		})
+	})
+})
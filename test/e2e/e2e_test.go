@@ -30,3 +30,12 @@ func TestE2E(t *testing.T) {
 	fmt.Fprintf(GinkgoWriter, "Starting kubebuilder suite\n")
 	RunSpecs(t, "Kubebuilder e2e suite")
 }
+
+// the installations shared by the specs are cleaned up once the specs of all
+// the parallel nodes have finished, in case a spec did not release them
+var _ = SynchronizedAfterSuite(func() {}, func() {
+	k := &Kubectl{cmdContext: &cmdContext{}}
+	for _, s := range sharedInstalls {
+		s.cleanup(k)
+	}
+})
@@ -0,0 +1,98 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/pkg/scaffold"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("kubebuilder", func() {
+	Context("[smoke] with a restrictive umask", func() {
+		var kbc *KBTestContext
+		BeforeEach(func() {
+			var err error
+			kbc, err = TestContext("GO111MODULE=on")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kbc.Prepare()).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(kbc.WriteReport()).To(Succeed())
+
+			kbc.By("remove the work dir")
+			kbc.Destroy()
+		})
+
+		It("should scaffold the files with the same modes as with the default umask", func() {
+			if kbc.Prescaffolded {
+				Skip("the project is scaffolded once by a previous run")
+			}
+
+			kbc.By("restricting the permissions of the project directory")
+			Expect(os.Chmod(kbc.Dir, 0711)).To(Succeed())
+
+			kbc.By("scaffolding a v2 project with umask 077")
+			script := strings.Join([]string{
+				"umask 077",
+				fmt.Sprintf("kubebuilder init --project-version 2 --domain %s --fetch-deps=false", kbc.Domain),
+				fmt.Sprintf("kubebuilder create api --group %s --version %s --kind %s --resource --controller --make=false",
+					kbc.Group, kbc.Version, kbc.Kind),
+				fmt.Sprintf("kubebuilder create webhook --group %s --version %s --kind %s --defaulting --programmatic-validation",
+					kbc.Group, kbc.Version, kbc.Kind),
+			}, " && ")
+			_, err := kbc.Run(exec.Command("sh", "-c", script))
+			Expect(err).NotTo(HaveOccurred())
+
+			kbc.By("validating the modes of the scaffolded files and directories")
+			var wrongModes []string
+			err = filepath.Walk(kbc.Dir, func(path string, info os.FileInfo, err error) error {
+				if err != nil || path == kbc.Dir {
+					return err
+				}
+				// go.sum is written by the go command, with the modes of the umask
+				if path == filepath.Join(kbc.Dir, "go.sum") {
+					return nil
+				}
+				expected := scaffold.FileMode
+				if info.IsDir() {
+					expected = scaffold.DirMode
+				}
+				if info.Mode().Perm() != expected {
+					wrongModes = append(wrongModes, fmt.Sprintf("%s has mode %v instead of %v",
+						path, info.Mode().Perm(), expected))
+				}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wrongModes).To(BeEmpty())
+
+			kbc.By("validating the mode of the project directory is left as it is")
+			info, err := os.Stat(kbc.Dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0711)))
+		})
+	})
+})
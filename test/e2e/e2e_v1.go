@@ -38,7 +38,9 @@ var _ = Describe("kubebuilder", func() {
 		})
 
 		AfterEach(func() {
-			By("clean up created API objects during test process")
+			Expect(kbc.WriteReport()).To(Succeed())
+
+			kbc.By("clean up created API objects during test process")
 			kbc.CleanupManifests(filepath.Join("config", "default"))
 			if _, err := kbc.Kubectl.Command(
 				"delete", "--recursive",
@@ -47,100 +49,93 @@ var _ = Describe("kubebuilder", func() {
 				fmt.Fprintf(GinkgoWriter, "error when running kubectl delete during cleaning up crd: %v\n", err)
 			}
 
-			By("remove container image and work dir")
+			kbc.By("remove container image and work dir")
 			kbc.Destroy()
 		})
 
 		It("should generate a runnable project", func() {
-			// prepare v1 vendor
-			By("untar the vendor tarball")
-			cmd := exec.Command("tar", "-zxf", "../../../testdata/vendor.v1.tgz")
-			_, err := kbc.Run(cmd)
-			Expect(err).Should(Succeed())
-
 			var controllerPodName string
+			var err error
+			if kbc.Prescaffolded {
+				kbc.By("reusing the project scaffolded in " + kbc.Dir)
+			} else {
+				// prepare v1 vendor
+				kbc.By("untar the vendor tarball")
+				cmd := exec.Command("tar", "-zxf", "../../../testdata/vendor.v1.tgz")
+				_, err = kbc.Run(cmd)
+				Expect(err).Should(Succeed())
+
+				kbc.By("init v1 project")
+				err = kbc.Init(
+					"--project-version", "1",
+					"--domain", kbc.Domain,
+					"--dep=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("creating api definition")
+				err = kbc.CreateAPI(
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", kbc.Kind,
+					"--namespaced",
+					"--resource",
+					"--controller",
+					"--make=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("creating core-type resource controller")
+				err = kbc.CreateAPI(
+					"--group", "apps",
+					"--version", "v1",
+					"--kind", "Deployment",
+					"--namespaced",
+					"--resource=false",
+					"--controller",
+					"--make=false")
+				Expect(err).Should(Succeed())
+			}
 
-			By("init v1 project")
-			err = kbc.Init(
-				"--project-version", "1",
-				"--domain", kbc.Domain,
-				"--dep=false")
-			Expect(err).Should(Succeed())
-
-			By("creating api definition")
-			err = kbc.CreateAPI(
-				"--group", kbc.Group,
-				"--version", kbc.Version,
-				"--kind", kbc.Kind,
-				"--namespaced",
-				"--resource",
-				"--controller",
-				"--make=false")
-			Expect(err).Should(Succeed())
-
-			By("creating core-type resource controller")
-			err = kbc.CreateAPI(
-				"--group", "apps",
-				"--version", "v1",
-				"--kind", "Deployment",
-				"--namespaced",
-				"--resource=false",
-				"--controller",
-				"--make=false")
-			Expect(err).Should(Succeed())
-
-			By("building image")
+			kbc.By("building image")
 			err = kbc.Make("docker-build", "IMG="+kbc.ImageName)
 			Expect(err).Should(Succeed())
 
-			By("loading docker image into kind cluster")
-			err = kbc.LoadImageToKindCluster()
+			kbc.By("loading docker image into the cluster")
+			err = kbc.LoadImageToCluster()
 			Expect(err).Should(Succeed())
 
 			// NOTE: If you want to run the test against a GKE cluster, you will need to grant yourself permission.
 			// Otherwise, you may see "... is forbidden: attempt to grant extra privileges"
 			// $ kubectl create clusterrolebinding myname-cluster-admin-binding --clusterrole=cluster-admin --user=myname@mycompany.com
 			// https://cloud.google.com/kubernetes-engine/docs/how-to/role-based-access-control
-			By("deploying controller manager")
+			kbc.By("deploying controller manager")
 			err = kbc.Make("deploy")
 			Expect(err).Should(Succeed())
 
-			By("validate the controller-manager pod running as expected")
+			kbc.By("validate the controller-manager pod running as expected")
 			verifyControllerUp := func() error {
-				// Get pod name
-				podOutput, err := kbc.Kubectl.Get(
-					true,
-					"pods", "-l", "control-plane=controller-manager",
-					"-o", "go-template={{ range .items }}{{ if not .metadata.deletionTimestamp }}{{ .metadata.name }}{{ \"\\n\" }}{{ end }}{{ end }}",
-				)
-				Expect(err).NotTo(HaveOccurred())
-				podNames := getNonEmptyLines(podOutput)
-				if len(podNames) != 1 {
-					return fmt.Errorf("expect 1 controller pods running, but got %d", len(podNames))
+				pods := &podList{}
+				Expect(kbc.Kubectl.GetJSON("pods", "", pods, "-l", "control-plane=controller-manager")).To(Succeed())
+				live := pods.live()
+				if len(live) != 1 {
+					return fmt.Errorf("expect 1 controller pods running, but got %d", len(live))
 				}
-				controllerPodName = podNames[0]
+				controllerPodName = live[0].Metadata.Name
 				Expect(controllerPodName).Should(ContainSubstring("controller-manager"))
 
 				// Validate pod status
-				status, err := kbc.Kubectl.Get(
-					true,
-					"pods", controllerPodName, "-o", "jsonpath={.status.phase}",
-				)
-				Expect(err).NotTo(HaveOccurred())
-				if status != "Running" {
-					return fmt.Errorf("controller pod in %s status", status)
+				if live[0].Status.Phase != "Running" {
+					return fmt.Errorf("controller pod in %s status", live[0].Status.Phase)
 				}
-
 				return nil
 			}
 			Eventually(verifyControllerUp, 2*time.Minute, time.Second).Should(Succeed())
 
-			By("creating an instance of CR")
+			kbc.By("creating an instance of CR")
 			inputFile := filepath.Join("config", "samples", fmt.Sprintf("%s_%s_%s.yaml", kbc.Group, kbc.Version, strings.ToLower(kbc.Kind)))
 			_, err = kbc.Kubectl.Apply(false, "-f", inputFile)
 			Expect(err).NotTo(HaveOccurred())
 
-			By("validate the created resource object gets reconciled in controller")
+			kbc.By("validate the created resource object gets reconciled in controller")
 			controllerContainerLogs := func() string {
 				// Check container log to validate that the created resource object gets reconciled in controller
 				logOutput, err := kbc.Kubectl.Logs(controllerPodName, "-c", "manager")
@@ -149,6 +144,11 @@ var _ = Describe("kubebuilder", func() {
 				return logOutput
 			}
 			Eventually(controllerContainerLogs, 2*time.Minute, time.Second).Should(ContainSubstring("Updating"))
+
+			kbc.By("validate the controller-manager pod has not restarted")
+			Consistently(func() error {
+				return kbc.VerifyNoRestarts(controllerPodName)
+			}, 30*time.Second, 5*time.Second).Should(Succeed())
 		})
 	})
 })
@@ -18,6 +18,7 @@ package e2e
 
 import (
 	"fmt"
+	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -27,6 +28,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("kubebuilder", func() {
@@ -38,152 +40,204 @@ var _ = Describe("kubebuilder", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(kbc.Prepare()).To(Succeed())
 
-			By("installing cert manager bundle")
+			kbc.By("installing cert manager bundle")
 			Expect(kbc.InstallCertManager()).To(Succeed())
 		})
 
 		AfterEach(func() {
-			By("clean up created API objects during test process")
+			Expect(kbc.WriteReport()).To(Succeed())
+
+			kbc.By("clean up created API objects during test process")
 			kbc.CleanupManifests(filepath.Join("config", "default"))
 
-			By("uninstalling cert manager bundle")
+			kbc.By("uninstalling cert manager bundle")
 			kbc.UninstallCertManager()
 
-			By("remove container image and work dir")
+			kbc.By("remove container image and work dir")
 			kbc.Destroy()
 		})
 
 		It("should generate a runnable project", func() {
 			var controllerPodName string
-			By("init v2 project")
-			err := kbc.Init(
-				"--project-version", "2",
-				"--domain", kbc.Domain,
-				"--dep=false")
-			Expect(err).Should(Succeed())
+			var err error
+			soak, err := loadSoakConfig()
+			Expect(err).NotTo(HaveOccurred())
+			if kbc.Prescaffolded {
+				kbc.By("reusing the project scaffolded in " + kbc.Dir)
+			} else {
+				kbc.By("init v2 project")
+				err = kbc.Init(
+					"--project-version", "2",
+					"--domain", kbc.Domain,
+					"--dep=false")
+				Expect(err).Should(Succeed())
 
-			By("creating api definition")
-			err = kbc.CreateAPI(
-				"--group", kbc.Group,
-				"--version", kbc.Version,
-				"--kind", kbc.Kind,
-				"--namespaced",
-				"--resource",
-				"--controller",
-				"--make=false")
-			Expect(err).Should(Succeed())
+				kbc.By("creating api definition")
+				err = kbc.CreateAPI(
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", kbc.Kind,
+					"--namespaced",
+					"--resource",
+					"--controller",
+					"--make=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("checking the scaffold markers")
+				Expect(verifyMarkers(kbc.Dir, v2ScaffoldMarkers(kbc))).To(Succeed())
 
-			By("implementing the API")
-			Expect(insertCode(
-				filepath.Join(kbc.Dir, "api", kbc.Version, fmt.Sprintf("%s_types.go", strings.ToLower(kbc.Kind))),
-				fmt.Sprintf(`type %sSpec struct {
-`, kbc.Kind),
-				`	// +optional
+				kbc.By("implementing the API")
+				Expect(insertCode(
+					filepath.Join(kbc.Dir, "api", kbc.Version, fmt.Sprintf("%s_types.go", strings.ToLower(kbc.Kind))),
+					"+kubebuilder:scaffold:spec",
+					`	// +optional
 	Count int `+"`"+`json:"count,omitempty"`+"`"+`
 `)).Should(Succeed())
 
-			By("implementing the mutating and validating webhooks")
-			err = (&scaffold.Webhook{
-				Domain:    kbc.Domain,
-				Group:     kbc.Group,
-				Version:   kbc.Version,
-				Kind:      kbc.Kind,
-				Resources: kbc.Resources,
-			}).WriteTo(filepath.Join(
-				kbc.Dir, "api", kbc.Version,
-				fmt.Sprintf("%s_webhook.go", strings.ToLower(kbc.Kind))))
-			Expect(err).Should(Succeed())
+				kbc.By("implementing the mutating and validating webhooks")
+				err = (&scaffold.Webhook{
+					Domain:    kbc.Domain,
+					Group:     kbc.Group,
+					Version:   kbc.Version,
+					Kind:      kbc.Kind,
+					Resources: kbc.Resources,
+				}).WriteTo(filepath.Join(
+					kbc.Dir, "api", kbc.Version,
+					fmt.Sprintf("%s_webhook.go", strings.ToLower(kbc.Kind))))
+				Expect(err).Should(Succeed())
+
+				kbc.By("uncomment kustomization.yaml to enable webhook and ca injection")
+				Expect(uncommentCode(
+					filepath.Join(kbc.Dir, "config", "default", "kustomization.yaml"),
+					"#- ../webhook", "#")).To(Succeed())
+				Expect(uncommentCode(
+					filepath.Join(kbc.Dir, "config", "default", "kustomization.yaml"),
+					"#- ../certmanager", "#")).To(Succeed())
+				Expect(uncommentCode(
+					filepath.Join(kbc.Dir, "config", "default", "kustomization.yaml"),
+					"#- manager_webhook_patch.yaml", "#")).To(Succeed())
+				Expect(uncommentCode(
+					filepath.Join(kbc.Dir, "config", "default", "kustomization.yaml"),
+					"#- webhookcainjection_patch.yaml", "#")).To(Succeed())
 
-			By("uncomment kustomization.yaml to enable webhook and ca injection")
-			Expect(uncommentCode(
-				filepath.Join(kbc.Dir, "config", "default", "kustomization.yaml"),
-				"#- ../webhook", "#")).To(Succeed())
-			Expect(uncommentCode(
-				filepath.Join(kbc.Dir, "config", "default", "kustomization.yaml"),
-				"#- ../certmanager", "#")).To(Succeed())
-			Expect(uncommentCode(
-				filepath.Join(kbc.Dir, "config", "default", "kustomization.yaml"),
-				"#- manager_webhook_patch.yaml", "#")).To(Succeed())
-			Expect(uncommentCode(
-				filepath.Join(kbc.Dir, "config", "default", "kustomization.yaml"),
-				"#- webhookcainjection_patch.yaml", "#")).To(Succeed())
-
-			By("building image")
-			err = kbc.Make("docker-build", "IMG="+kbc.ImageName)
+				kbc.By("restricting the cipher suites of the metrics and webhooks")
+				Expect(uncommentCode(
+					filepath.Join(kbc.Dir, "config", "default", "manager_auth_proxy_patch.yaml"),
+					"#- \"--tls-cipher-suites=", "#")).To(Succeed())
+				Expect(insertCode(
+					filepath.Join(kbc.Dir, "config", "default", "manager_auth_proxy_patch.yaml"),
+					"+kubebuilder:scaffold:managerargs",
+					tlsCipherSuitesArgs)).To(Succeed())
+
+				if soak.Duration > 0 {
+					kbc.By("registering the Go and process metrics measured by the soak")
+					Expect(insertCode(
+						filepath.Join(kbc.Dir, "main.go"),
+						"+kubebuilder:scaffold:imports",
+						soakMetricsImports)).To(Succeed())
+					Expect(insertCode(
+						filepath.Join(kbc.Dir, "main.go"),
+						"+kubebuilder:scaffold:scheme",
+						soakMetricsCode)).To(Succeed())
+				}
+
+				if args := kbc.ManagerRateLimitArgs(); len(args) > 0 {
+					kbc.By("constraining the manager client-side rate limits")
+					code := ""
+					for _, arg := range args {
+						code += fmt.Sprintf("        - %s\n", arg)
+					}
+					Expect(insertCode(
+						filepath.Join(kbc.Dir, "config", "manager", "manager.yaml"),
+						"+kubebuilder:scaffold:managerargs",
+						code)).To(Succeed())
+				}
+			}
+
+			kbc.By("building image")
+			err = kbc.BuildImage()
 			Expect(err).Should(Succeed())
 
-			By("loading docker image into kind cluster")
-			err = kbc.LoadImageToKindCluster()
+			kbc.By("checking the project stays within its size budget")
+			Expect(kbc.Make("manager")).To(Succeed())
+			Expect(kbc.VerifySizeBudget()).To(Succeed())
+
+			kbc.By("loading docker image into the cluster")
+			err = kbc.LoadImageToCluster()
 			Expect(err).Should(Succeed())
 
 			// NOTE: If you want to run the test against a GKE cluster, you will need to grant yourself permission.
 			// Otherwise, you may see "... is forbidden: attempt to grant extra privileges"
 			// $ kubectl create clusterrolebinding myname-cluster-admin-binding --clusterrole=cluster-admin --user=myname@mycompany.com
 			// https://cloud.google.com/kubernetes-engine/docs/how-to/role-based-access-control
-			By("deploying controller manager")
+			kbc.By("enforcing the restricted Pod Security Standard in the namespace")
+			_, err = kbc.Kubectl.Command("create", "namespace", kbc.Kubectl.Namespace)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = kbc.Kubectl.Command("label", "--overwrite", "namespace", kbc.Kubectl.Namespace,
+				"pod-security.kubernetes.io/enforce=restricted")
+			Expect(err).NotTo(HaveOccurred())
+
+			kbc.By("deploying controller manager")
 			err = kbc.Make("deploy")
 			Expect(err).Should(Succeed())
 
-			By("validate the controller-manager pod running as expected")
+			kbc.By("validate the controller-manager pod running as expected")
 			verifyControllerUp := func() error {
-				// Get pod name
-				podOutput, err := kbc.Kubectl.Get(
-					true,
-					"pods", "-l", "control-plane=controller-manager",
-					"-o", "go-template={{ range .items }}{{ if not .metadata.deletionTimestamp }}{{ .metadata.name }}{{ \"\\n\" }}{{ end }}{{ end }}")
-				Expect(err).NotTo(HaveOccurred())
-				podNames := getNonEmptyLines(podOutput)
-				if len(podNames) != 1 {
-					return fmt.Errorf("expect 1 controller pods running, but got %d", len(podNames))
+				pods := &podList{}
+				Expect(kbc.Kubectl.GetJSON("pods", "", pods, "-l", "control-plane=controller-manager")).To(Succeed())
+				live := pods.live()
+				if len(live) != 1 {
+					return fmt.Errorf("expect 1 controller pods running, but got %d", len(live))
 				}
-				controllerPodName = podNames[0]
+				controllerPodName = live[0].Metadata.Name
 				Expect(controllerPodName).Should(ContainSubstring("controller-manager"))
 
 				// Validate pod status
-				status, err := kbc.Kubectl.Get(
-					true,
-					"pods", controllerPodName, "-o", "jsonpath={.status.phase}")
-				Expect(err).NotTo(HaveOccurred())
-				if status != "Running" {
-					return fmt.Errorf("controller pod in %s status", status)
+				if live[0].Status.Phase != "Running" {
+					return fmt.Errorf("controller pod in %s status", live[0].Status.Phase)
 				}
 				return nil
 			}
 			Eventually(verifyControllerUp, time.Minute, time.Second).Should(Succeed())
 
-			By("validate cert manager has provisioned the certificate secret")
-			Eventually(func() error {
-				_, err := kbc.Kubectl.Get(
-					true,
-					"secrets", "webhook-server-cert")
-				return err
-			}, time.Minute, time.Second).Should(Succeed())
+			kbc.By("validating the controller pod runs as non-root")
+			controllerPod := &pod{}
+			Expect(kbc.Kubectl.GetJSON("pods", controllerPodName, controllerPod)).To(Succeed())
+			Expect(controllerPod.Spec.SecurityContext.RunAsNonRoot).NotTo(BeNil())
+			Expect(*controllerPod.Spec.SecurityContext.RunAsNonRoot).To(BeTrue())
 
-			By("validate the mutating|validating webhooks have the CA injected")
-			verifyCAInjection := func() error {
-				mwhOutput, err := kbc.Kubectl.Get(
-					false,
-					"mutatingwebhookconfigurations.admissionregistration.k8s.io",
-					fmt.Sprintf("e2e-%s-mutating-webhook-configuration", kbc.TestSuffix),
-					"-o", "go-template={{ range .webhooks }}{{ .clientConfig.caBundle }}{{ end }}")
-				Expect(err).NotTo(HaveOccurred())
-				// sanity check that ca should be long enough, because there may be a place holder "\n"
-				Expect(len(mwhOutput)).To(BeNumerically(">", 10))
-
-				vwhOutput, err := kbc.Kubectl.Get(
-					false,
-					"validatingwebhookconfigurations.admissionregistration.k8s.io",
-					fmt.Sprintf("e2e-%s-validating-webhook-configuration", kbc.TestSuffix),
-					"-o", "go-template={{ range .webhooks }}{{ .clientConfig.caBundle }}{{ end }}")
-				Expect(err).NotTo(HaveOccurred())
-				// sanity check that ca should be long enough, because there may be a place holder "\n"
-				Expect(len(vwhOutput)).To(BeNumerically(">", 10))
+			if kbc.Arch != "" {
+				kbc.By("validating the controller pod runs on a node of the built architecture")
+				Expect(kbc.VerifyNodeArchitecture(controllerPodName)).To(Succeed())
+			}
 
+			kbc.By("validate cert manager has provisioned the certificate secret")
+			Expect(kbc.Kubectl.WaitForCondition("certificates.certmanager.k8s.io",
+				kbc.Name("serving-cert"), "Ready", time.Minute)).To(Succeed())
+			_, err = kbc.Kubectl.Get(true, "secrets", "webhook-server-cert")
+			Expect(err).NotTo(HaveOccurred())
+
+			kbc.By("validate the mutating|validating webhooks have the CA injected")
+			verifyCAInjection := func() error {
+				for _, resource := range []string{"mutating", "validating"} {
+					config := &webhookConfiguration{}
+					Expect(kbc.Kubectl.GetJSON(
+						resource+"webhookconfigurations.admissionregistration.k8s.io",
+						kbc.Name(resource+"-webhook-configuration"),
+						config)).To(Succeed())
+					Expect(config.Webhooks).NotTo(BeEmpty())
+					for _, webhook := range config.Webhooks {
+						// sanity check that ca should be long enough, because there may be a place holder "\n"
+						if len(webhook.ClientConfig.CABundle) <= 10 {
+							return fmt.Errorf("the CA is not injected in the %s webhook configuration", resource)
+						}
+					}
+				}
 				return nil
 			}
 			Eventually(verifyCAInjection, time.Minute, time.Second).Should(Succeed())
 
-			By("creating an instance of CR")
+			kbc.By("creating an instance of CR")
 			// currently controller-runtime doesn't provide a readiness probe, we retry a few times
 			// we can change it to probe the readiness endpoint after CR supports it.
 			sampleFile := filepath.Join("config", "samples", fmt.Sprintf("%s_%s_%s.yaml", kbc.Group, kbc.Version, strings.ToLower(kbc.Kind)))
@@ -192,7 +246,7 @@ var _ = Describe("kubebuilder", func() {
 				return err
 			}, time.Minute, time.Second).Should(Succeed())
 
-			By("validate the created resource object gets reconciled in controller")
+			kbc.By("validate the created resource object gets reconciled in controller")
 			managerContainerLogs := func() string {
 				logOutput, err := kbc.Kubectl.Logs(controllerPodName, "-c", "manager")
 				Expect(err).NotTo(HaveOccurred())
@@ -200,7 +254,13 @@ var _ = Describe("kubebuilder", func() {
 			}
 			Eventually(managerContainerLogs, time.Minute, time.Second).Should(ContainSubstring("Successfully Reconciled"))
 
-			By("validate mutating and validating webhooks are working fine")
+			kbc.By("measuring the memory of the controller-manager pod")
+			Expect(kbc.MeasureManagerMemory(controllerPodName)).To(Succeed())
+
+			kbc.By("validate the manager RBAC denies the actions the manager does not need")
+			Expect(kbc.VerifyManagerRBAC()).To(Succeed())
+
+			kbc.By("validate mutating and validating webhooks are working fine")
 			cnt, err := kbc.Kubectl.Get(
 				true,
 				"-f", sampleFile,
@@ -209,6 +269,118 @@ var _ = Describe("kubebuilder", func() {
 			count, err := strconv.Atoi(cnt)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(count).To(BeNumerically("==", 5))
+
+			kbc.By("running the declarative steps of " + stepsDir())
+			steps, err := kbc.LoadSteps(stepsDir())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kbc.RunSteps(steps)).To(Succeed())
+
+			kbc.By("validate the webhooks and metrics only serve TLS 1.2 and above with the configured cipher suites")
+			Expect(kbc.VerifyTLS(controllerPodName, 9443)).To(Succeed())
+			Expect(kbc.VerifyTLS(controllerPodName, 8443)).To(Succeed())
+
+			kbc.By("validate the controller-manager pod has not restarted")
+			Consistently(func() error {
+				return kbc.VerifyNoRestarts(controllerPodName)
+			}, 30*time.Second, 5*time.Second).Should(Succeed())
+
+			if soak.Duration > 0 {
+				kbc.By(fmt.Sprintf("soaking the manager with %d CRs churned for %s", soak.Objects, soak.Duration))
+				Expect(kbc.Soak(controllerPodName, soak)).To(Succeed())
+			}
+		})
+
+		It("should run the manager locally with the webhooks disabled", func() {
+			var err error
+			if kbc.Prescaffolded {
+				kbc.By("reusing the project scaffolded in " + kbc.Dir)
+			} else {
+				kbc.By("init v2 project")
+				err = kbc.Init(
+					"--project-version", "2",
+					"--domain", kbc.Domain,
+					"--dep=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("creating api definition")
+				err = kbc.CreateAPI(
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", kbc.Kind,
+					"--namespaced",
+					"--resource",
+					"--controller",
+					"--make=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("creating a deletion protection webhook registered in main.go")
+				cmd := exec.Command("kubebuilder", "create", "webhook",
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", kbc.Kind,
+					"--deletion-protection")
+				_, err = kbc.Run(cmd)
+				Expect(err).Should(Succeed())
+			}
+
+			kbc.By("building the manager binary")
+			Expect(kbc.Make("manager")).To(Succeed())
+
+			kbc.By("installing the CRDs")
+			Expect(kbc.Make("install")).To(Succeed())
+			_, err = kbc.Kubectl.Command("create", "namespace", kbc.Kubectl.Namespace)
+			Expect(err).NotTo(HaveOccurred())
+
+			kbc.By("running the manager locally with ENABLE_WEBHOOKS=false")
+			manager, output, err := kbc.StartManager([]string{"ENABLE_WEBHOOKS=false"}, "--metrics-addr=0")
+			Expect(err).NotTo(HaveOccurred())
+			defer kbc.StopManager(manager)
+
+			kbc.By("creating an instance of CR")
+			sampleFile := filepath.Join("config", "samples", fmt.Sprintf("%s_%s_%s.yaml", kbc.Group, kbc.Version, strings.ToLower(kbc.Kind)))
+			Eventually(func() error {
+				_, err = kbc.Kubectl.Apply(true, "-f", sampleFile)
+				return err
+			}, time.Minute, time.Second).Should(Succeed())
+
+			kbc.By("validate the created resource object gets reconciled without serving the webhooks")
+			Eventually(output, time.Minute, time.Second).Should(gbytes.Say("Successfully Reconciled"))
+
+			kbc.By("validate the manager does not fail on the missing webhook serving certificate")
+			Consistently(func() string {
+				return string(output.Contents())
+			}, 10*time.Second, time.Second).ShouldNot(ContainSubstring("problem running manager"))
 		})
 	})
 })
+
+// v2ScaffoldMarkers returns the scaffold markers of the files of a v2 project
+// with the API of the test context, which the e2e and the scaffolds of create
+// api and create webhook insert code at.
+func v2ScaffoldMarkers(kbc *KBTestContext) map[string][]string {
+	return map[string][]string{
+		"main.go": {
+			"+kubebuilder:scaffold:imports",
+			"+kubebuilder:scaffold:scheme",
+			"+kubebuilder:scaffold:builder",
+			"+kubebuilder:scaffold:webhooktls",
+		},
+		filepath.Join("api", kbc.Version, fmt.Sprintf("%s_types.go", strings.ToLower(kbc.Kind))): {
+			"+kubebuilder:scaffold:spec",
+		},
+		filepath.Join("config", "crd", "kustomization.yaml"): {
+			"+kubebuilder:scaffold:crdkustomizeresource",
+			"+kubebuilder:scaffold:crdkustomizewebhookpatch",
+			"+kubebuilder:scaffold:crdkustomizecainjectionpatch",
+		},
+		filepath.Join("config", "default", "kustomization.yaml"): {
+			"+kubebuilder:scaffold:components",
+		},
+		filepath.Join("config", "default", "manager_auth_proxy_patch.yaml"): {
+			"+kubebuilder:scaffold:managerargs",
+		},
+		filepath.Join("config", "manager", "manager.yaml"): {
+			"+kubebuilder:scaffold:managerargs",
+		},
+	}
+}
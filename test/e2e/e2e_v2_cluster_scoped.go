@@ -0,0 +1,161 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("kubebuilder", func() {
+	Context("with v2 cluster-scoped scaffolding", func() {
+		var kbc *KBTestContext
+		BeforeEach(func() {
+			var err error
+			kbc, err = TestContext("GO111MODULE=on")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kbc.Prepare()).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(kbc.WriteReport()).To(Succeed())
+
+			kbc.By("clean up created API objects during test process")
+			kbc.CleanupManifests(filepath.Join("config", "default"))
+
+			kbc.By("remove container image and work dir")
+			kbc.Destroy()
+		})
+
+		It("should generate a runnable project with a cluster-scoped API", func() {
+			var controllerPodName string
+			var err error
+			if kbc.Prescaffolded {
+				kbc.By("reusing the project scaffolded in " + kbc.Dir)
+			} else {
+				kbc.By("init v2 project")
+				err = kbc.Init(
+					"--project-version", "2",
+					"--domain", kbc.Domain,
+					"--dep=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("creating a cluster-scoped api definition")
+				err = kbc.CreateAPI(
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", kbc.Kind,
+					"--namespaced=false",
+					"--resource",
+					"--controller",
+					"--make=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("checking the types are marked cluster-scoped")
+				types, err := ioutil.ReadFile(filepath.Join(kbc.Dir, "api", kbc.Version,
+					fmt.Sprintf("%s_types.go", strings.ToLower(kbc.Kind))))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(types)).To(ContainSubstring("+kubebuilder:resource:scope=Cluster"))
+
+				kbc.By("checking the sample has no namespace")
+				sample, err := ioutil.ReadFile(filepath.Join(kbc.Dir, "config", "samples",
+					fmt.Sprintf("%s_%s_%s.yaml", kbc.Group, kbc.Version, strings.ToLower(kbc.Kind))))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(sample)).NotTo(ContainSubstring("namespace:"))
+			}
+
+			kbc.By("building image")
+			err = kbc.BuildImage()
+			Expect(err).Should(Succeed())
+
+			kbc.By("loading docker image into the cluster")
+			err = kbc.LoadImageToCluster()
+			Expect(err).Should(Succeed())
+
+			kbc.By("deploying controller manager")
+			err = kbc.Make("deploy")
+			Expect(err).Should(Succeed())
+
+			kbc.By("validate the CRD is cluster-scoped")
+			resource := fmt.Sprintf("%s.%s.%s", kbc.Resources, kbc.Group, kbc.Domain)
+			scope, err := kbc.Kubectl.Get(false, "crd", resource, "-o", "jsonpath={.spec.scope}")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.TrimSpace(scope)).To(Equal("Cluster"))
+
+			kbc.By("validate the controller-manager pod running as expected")
+			verifyControllerUp := func() error {
+				pods := &podList{}
+				Expect(kbc.Kubectl.GetJSON("pods", "", pods, "-l", "control-plane=controller-manager")).To(Succeed())
+				live := pods.live()
+				if len(live) != 1 {
+					return fmt.Errorf("expect 1 controller pods running, but got %d", len(live))
+				}
+				controllerPodName = live[0].Metadata.Name
+				Expect(controllerPodName).Should(ContainSubstring("controller-manager"))
+
+				// Validate pod status
+				if live[0].Status.Phase != "Running" {
+					return fmt.Errorf("controller pod in %s status", live[0].Status.Phase)
+				}
+				return nil
+			}
+			Eventually(verifyControllerUp, time.Minute, time.Second).Should(Succeed())
+
+			kbc.By("creating an instance of the cluster-scoped CR outside of any namespace")
+			sampleFile := filepath.Join("config", "samples",
+				fmt.Sprintf("%s_%s_%s.yaml", kbc.Group, kbc.Version, strings.ToLower(kbc.Kind)))
+			Eventually(func() error {
+				_, err = kbc.Kubectl.Apply(false, "-f", sampleFile)
+				return err
+			}, time.Minute, time.Second).Should(Succeed())
+			_, err = kbc.Kubectl.Get(false, resource, fmt.Sprintf("%s-sample", strings.ToLower(kbc.Kind)))
+			Expect(err).NotTo(HaveOccurred())
+
+			kbc.By("validate the created resource object gets reconciled in controller")
+			reconciled := func() bool {
+				logOutput, err := kbc.Kubectl.Logs(controllerPodName, "-c", "manager")
+				Expect(err).NotTo(HaveOccurred())
+				for _, line := range getNonEmptyLines(logOutput) {
+					if strings.Contains(line, "Successfully Reconciled") &&
+						strings.Contains(line, fmt.Sprintf("%q", strings.ToLower(kbc.Kind))) {
+						return true
+					}
+				}
+				return false
+			}
+			Eventually(reconciled, time.Minute, time.Second).Should(BeTrue())
+
+			kbc.By("clean up the cluster-scoped CR, which the namespace cleanup does not delete")
+			_, err = kbc.Kubectl.Delete(false, "-f", sampleFile)
+			Expect(err).NotTo(HaveOccurred())
+
+			kbc.By("validate the manager RBAC denies the actions the manager does not need")
+			Expect(kbc.VerifyManagerRBAC()).To(Succeed())
+
+			kbc.By("validate the controller-manager pod has not restarted")
+			Consistently(func() error {
+				return kbc.VerifyNoRestarts(controllerPodName)
+			}, 30*time.Second, 5*time.Second).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,315 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// storageVersionMarker marks the storage version of a multi-version CRD.
+const storageVersionMarker = "// +kubebuilder:storageversion\n"
+
+var _ = Describe("kubebuilder", func() {
+	Context("with v2 scaffolding of a multi-version API", func() {
+		var kbc *KBTestContext
+		BeforeEach(func() {
+			var err error
+			kbc, err = TestContext("GO111MODULE=on")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kbc.Prepare()).To(Succeed())
+
+			kbc.By("installing cert manager bundle")
+			Expect(kbc.InstallCertManager()).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(kbc.WriteReport()).To(Succeed())
+
+			kbc.By("clean up created API objects during test process")
+			kbc.CleanupManifests(filepath.Join("config", "default"))
+
+			kbc.By("uninstalling cert manager bundle")
+			kbc.UninstallCertManager()
+
+			kbc.By("remove container image and work dir")
+			kbc.Destroy()
+		})
+
+		It("should migrate the stored objects to a new storage version", func() {
+			// the hub version is stored first, the objects are then migrated
+			// to the spoke version converted by the webhook
+			hubVersion, spokeVersion := kbc.Version, "v1beta1"
+			typesFile := func(version string) string {
+				return filepath.Join(kbc.Dir, "api", version, fmt.Sprintf("%s_types.go", strings.ToLower(kbc.Kind)))
+			}
+			// the CRD lists all the versions, not only the storage version
+			crdOptions := "CRD_OPTIONS=crd:trivialVersions=false"
+
+			var err error
+			if kbc.Prescaffolded {
+				kbc.By("reusing the project scaffolded in " + kbc.Dir)
+			} else {
+				kbc.By("init v2 project")
+				err = kbc.Init(
+					"--project-version", "2",
+					"--domain", kbc.Domain,
+					"--dep=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("creating the api definitions of two versions")
+				for _, version := range []string{hubVersion, spokeVersion} {
+					err = kbc.CreateAPI(
+						"--group", kbc.Group,
+						"--version", version,
+						"--kind", kbc.Kind,
+						"--namespaced",
+						"--resource",
+						fmt.Sprintf("--controller=%t", version == hubVersion),
+						"--make=false")
+					Expect(err).Should(Succeed())
+
+					Expect(insertCode(typesFile(version), "+kubebuilder:scaffold:spec",
+						`	// +optional
+	Count int `+"`"+`json:"count,omitempty"`+"`"+`
+`)).Should(Succeed())
+				}
+
+				kbc.By("storing the hub version")
+				Expect(insertCode(typesFile(hubVersion), "+kubebuilder:object:root=true",
+					storageVersionMarker)).Should(Succeed())
+
+				kbc.By("creating the conversion webhook")
+				cmd := exec.Command("kubebuilder", "create", "webhook",
+					"--group", kbc.Group,
+					"--version", hubVersion,
+					"--kind", kbc.Kind,
+					"--conversion")
+				_, err = kbc.Run(cmd)
+				Expect(err).Should(Succeed())
+
+				kbc.By("implementing the conversion of the spoke version")
+				todo := "	// TODO(user): convert the spec and status of src to the ones of dst,\n"
+				Expect(replaceCode(
+					filepath.Join(kbc.Dir, "api", spokeVersion, fmt.Sprintf("%s_conversion.go", strings.ToLower(kbc.Kind))),
+					todo, "	dst.Spec.Count = src.Spec.Count\n\n"+todo)).To(Succeed())
+
+				kbc.By("uncomment kustomization.yaml to enable webhook and ca injection")
+				for _, target := range []string{
+					"#- ../webhook", "#- ../certmanager", "#- manager_webhook_patch.yaml", "#- webhookcainjection_patch.yaml",
+				} {
+					Expect(uncommentCode(
+						filepath.Join(kbc.Dir, "config", "default", "kustomization.yaml"), target, "#")).To(Succeed())
+				}
+			}
+
+			kbc.By("building image")
+			Expect(kbc.BuildImage()).To(Succeed())
+
+			kbc.By("loading docker image into the cluster")
+			Expect(kbc.LoadImageToCluster()).To(Succeed())
+
+			kbc.By("deploying controller manager")
+			Expect(kbc.Make("deploy", crdOptions)).To(Succeed())
+
+			kbc.By("creating an object stored in the hub version")
+			name := strings.ToLower(kbc.Kind) + "-migrated"
+			manifest := fmt.Sprintf(`apiVersion: %s.%s/%s
+kind: %s
+metadata:
+  name: %s
+spec:
+  count: 3
+`, kbc.Group, kbc.Domain, hubVersion, kbc.Kind, name)
+			Eventually(func() error {
+				_, err = kbc.Kubectl.CommandWithInput(manifest, "-n", kbc.Kubectl.Namespace, "apply", "-f", "-")
+				return err
+			}, time.Minute, time.Second).Should(Succeed())
+			Expect(kbc.StoredAPIVersion(name)).To(HaveSuffix("/" + hubVersion))
+
+			kbc.By("reading the object in the spoke version through the conversion webhook")
+			Eventually(func() (string, error) {
+				return kbc.Kubectl.Get(true, fmt.Sprintf("%s.%s.%s.%s", kbc.Resources, spokeVersion, kbc.Group, kbc.Domain),
+					name, "-o", "jsonpath={.spec.count}")
+			}, time.Minute, time.Second).Should(Equal("3"))
+
+			kbc.By("flipping the storage version to the spoke version")
+			Expect(replaceCode(typesFile(hubVersion), storageVersionMarker, "")).To(Succeed())
+			Expect(insertCode(typesFile(spokeVersion), "+kubebuilder:object:root=true",
+				storageVersionMarker)).Should(Succeed())
+			Expect(kbc.Make("deploy", crdOptions)).To(Succeed())
+			Eventually(kbc.StoredVersions, time.Minute, time.Second).Should(ConsistOf(hubVersion, spokeVersion))
+
+			kbc.By("validate the existing object is still stored in the hub version")
+			Expect(kbc.StoredAPIVersion(name)).To(HaveSuffix("/" + hubVersion))
+
+			kbc.By("migrating the stored objects to the spoke version")
+			Expect(kbc.MigrateStorageVersion(spokeVersion)).To(Succeed())
+			Expect(kbc.StoredVersions()).To(ConsistOf(spokeVersion))
+
+			kbc.By("validate the existing object is rewritten in the spoke version")
+			Expect(kbc.StoredAPIVersion(name)).To(HaveSuffix("/" + spokeVersion))
+
+			kbc.By("validate the object reads the same in both versions")
+			for _, version := range []string{hubVersion, spokeVersion} {
+				count, err := kbc.Kubectl.Get(true, fmt.Sprintf("%s.%s.%s.%s", kbc.Resources, version, kbc.Group, kbc.Domain),
+					name, "-o", "jsonpath={.spec.count}")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal("3"))
+			}
+		})
+
+		It("should serve the samples of an older version from the new storage version", func() {
+			// the older version renames the field of the new one, only the
+			// conversion webhook maps one to the other
+			oldVersion, newVersion := kbc.Version, "v1"
+			typesFile := func(version string) string {
+				return filepath.Join(kbc.Dir, "api", version, fmt.Sprintf("%s_types.go", strings.ToLower(kbc.Kind)))
+			}
+			sampleFile := func(version string) string {
+				return filepath.Join("config", "samples",
+					fmt.Sprintf("%s_%s_%s.yaml", kbc.Group, version, strings.ToLower(kbc.Kind)))
+			}
+			versionedResource := func(version string) string {
+				return fmt.Sprintf("%s.%s.%s.%s", kbc.Resources, version, kbc.Group, kbc.Domain)
+			}
+			crdOptions := "CRD_OPTIONS=crd:trivialVersions=false"
+
+			var err error
+			if kbc.Prescaffolded {
+				kbc.By("reusing the project scaffolded in " + kbc.Dir)
+			} else {
+				kbc.By("init v2 project")
+				err = kbc.Init(
+					"--project-version", "2",
+					"--domain", kbc.Domain,
+					"--dep=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("creating the api definitions of the older and the new version")
+				fields := map[string]string{
+					oldVersion: `	// +kubebuilder:example=3
+	// +optional
+	Count int ` + "`" + `json:"count,omitempty"` + "`" + `
+`,
+					newVersion: `	// +kubebuilder:example=1
+	// +optional
+	Replicas int ` + "`" + `json:"replicas,omitempty"` + "`" + `
+`,
+				}
+				for _, version := range []string{oldVersion, newVersion} {
+					err = kbc.CreateAPI(
+						"--group", kbc.Group,
+						"--version", version,
+						"--kind", kbc.Kind,
+						"--namespaced",
+						"--resource",
+						fmt.Sprintf("--controller=%t", version == newVersion),
+						"--make=false")
+					Expect(err).Should(Succeed())
+					Expect(insertCode(typesFile(version), "+kubebuilder:scaffold:spec", fields[version])).Should(Succeed())
+				}
+
+				kbc.By("storing the new version")
+				Expect(insertCode(typesFile(newVersion), "+kubebuilder:object:root=true",
+					storageVersionMarker)).Should(Succeed())
+
+				kbc.By("creating the conversion webhook with the new version as the hub")
+				err = kbc.CreateWebhook(
+					"--group", kbc.Group,
+					"--version", newVersion,
+					"--kind", kbc.Kind,
+					"--conversion")
+				Expect(err).Should(Succeed())
+
+				kbc.By("implementing the conversion of the older version")
+				conversionFile := filepath.Join(kbc.Dir, "api", oldVersion,
+					fmt.Sprintf("%s_conversion.go", strings.ToLower(kbc.Kind)))
+				todo := "	// TODO(user): convert the spec and status of src to the ones of dst,\n"
+				Expect(replaceCode(conversionFile, todo+"	// keeping",
+					"	dst.Spec.Replicas = src.Spec.Count\n\n"+todo+"	// keeping")).To(Succeed())
+				Expect(replaceCode(conversionFile, todo+"	// restoring",
+					"	dst.Spec.Count = src.Spec.Replicas\n\n"+todo+"	// restoring")).To(Succeed())
+
+				kbc.By("uncomment kustomization.yaml to enable webhook and ca injection")
+				for _, target := range []string{
+					"#- ../webhook", "#- ../certmanager", "#- manager_webhook_patch.yaml", "#- webhookcainjection_patch.yaml",
+				} {
+					Expect(uncommentCode(
+						filepath.Join(kbc.Dir, "config", "default", "kustomization.yaml"), target, "#")).To(Succeed())
+				}
+			}
+
+			kbc.By("building image")
+			Expect(kbc.BuildImage()).To(Succeed())
+
+			kbc.By("loading docker image into the cluster")
+			Expect(kbc.LoadImageToCluster()).To(Succeed())
+
+			kbc.By("deploying controller manager")
+			Expect(kbc.Make("deploy", crdOptions)).To(Succeed())
+
+			kbc.By("generating the samples of both versions")
+			Expect(kbc.Make("samples")).To(Succeed())
+			sample, err := ioutil.ReadFile(filepath.Join(kbc.Dir, sampleFile(oldVersion)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(sample)).To(ContainSubstring("count: 3"))
+
+			kbc.By("applying the sample of the older version, as a client of the older version would")
+			name := strings.ToLower(kbc.Kind) + "-sample"
+			Eventually(func() error {
+				_, err = kbc.Kubectl.Apply(true, "-f", sampleFile(oldVersion))
+				return err
+			}, time.Minute, time.Second).Should(Succeed())
+
+			kbc.By("validate the object is stored in the new version")
+			Expect(kbc.StoredAPIVersion(name)).To(HaveSuffix("/" + newVersion))
+			Expect(kbc.StoredVersions()).To(ConsistOf(newVersion))
+
+			kbc.By("validate the object reads the same in both versions")
+			replicas, err := kbc.Kubectl.Get(true, versionedResource(newVersion), name, "-o", "jsonpath={.spec.replicas}")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(replicas).To(Equal("3"))
+			count, err := kbc.Kubectl.Get(true, versionedResource(oldVersion), name, "-o", "jsonpath={.spec.count}")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(count).To(Equal("3"))
+
+			kbc.By("updating the object with the sample of the older version")
+			Expect(replaceCode(filepath.Join(kbc.Dir, sampleFile(oldVersion)), "count: 3", "count: 5")).To(Succeed())
+			_, err = kbc.Kubectl.Apply(true, "-f", sampleFile(oldVersion))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() (string, error) {
+				return kbc.Kubectl.Get(true, versionedResource(newVersion), name, "-o", "jsonpath={.spec.replicas}")
+			}, time.Minute, time.Second).Should(Equal("5"))
+			Expect(kbc.StoredAPIVersion(name)).To(HaveSuffix("/" + newVersion))
+
+			kbc.By("applying the sample of the new version over the object of the older one")
+			_, err = kbc.Kubectl.Apply(true, "-f", sampleFile(newVersion))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() (string, error) {
+				return kbc.Kubectl.Get(true, versionedResource(oldVersion), name, "-o", "jsonpath={.spec.count}")
+			}, time.Minute, time.Second).Should(Equal("1"))
+		})
+	})
+})
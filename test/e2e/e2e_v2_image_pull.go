@@ -0,0 +1,131 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("kubebuilder", func() {
+	Context("with v2 scaffolding and a manager image which cannot be pulled", func() {
+		var kbc *KBTestContext
+		BeforeEach(func() {
+			var err error
+			kbc, err = TestContext("GO111MODULE=on")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kbc.Prepare()).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(kbc.WriteReport()).To(Succeed())
+
+			kbc.By("clean up created API objects during test process")
+			kbc.CleanupManifests(filepath.Join("config", "default"))
+
+			kbc.By("remove the work dir")
+			kbc.Destroy()
+		})
+
+		It("should surface the ImagePullBackOff of the manager in the diagnostics", func() {
+			var err error
+			// the image is never built nor loaded, so the cluster has to pull it
+			missingImage := kbc.ImageName + "-missing"
+			if kbc.Prescaffolded {
+				kbc.By("reusing the project scaffolded in " + kbc.Dir)
+			} else {
+				kbc.By("init v2 project")
+				err = kbc.Init(
+					"--project-version", "2",
+					"--domain", kbc.Domain,
+					"--dep=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("creating api definition")
+				err = kbc.CreateAPI(
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", kbc.Kind,
+					"--namespaced",
+					"--resource",
+					"--controller",
+					"--make=false")
+				Expect(err).Should(Succeed())
+			}
+
+			kbc.By("setting a manager image which does not exist")
+			imagePatch := filepath.Join(kbc.Dir, "config", "default", "manager_image_patch.yaml")
+			Expect(replaceCode(imagePatch, "image: IMAGE_URL", "image: "+missingImage)).To(Succeed())
+
+			kbc.By("deploying controller manager")
+			err = kbc.Make("deploy")
+			Expect(err).Should(Succeed())
+
+			kbc.By("shortening the progress deadline of the deployment")
+			deployment := kbc.Name("controller-manager")
+			_, err = kbc.Kubectl.CommandInNamespace("patch", "deployment", deployment,
+				"-p", `{"spec":{"progressDeadlineSeconds":30}}`)
+			Expect(err).NotTo(HaveOccurred())
+
+			kbc.By("validate the diagnostics give the ImagePullBackOff of the manager container")
+			var diagnostics *Diagnostics
+			verifyImagePullBackOff := func() error {
+				if diagnostics, err = kbc.CollectDiagnostics(); err != nil {
+					return err
+				}
+				for _, c := range diagnostics.Waiting {
+					if c.Container == "manager" && c.Reason == "ImagePullBackOff" {
+						Expect(c.Image).To(ContainSubstring(missingImage))
+						return nil
+					}
+				}
+				return fmt.Errorf("the manager container is not backing off pulling %s: %v", missingImage, diagnostics.Waiting)
+			}
+			Eventually(verifyImagePullBackOff, 2*time.Minute, 5*time.Second).Should(Succeed())
+			Expect(diagnostics.Text).To(ContainSubstring("container manager (image " + missingImage + ") is waiting: ImagePullBackOff"))
+
+			kbc.By("validate the diagnostics give the events of the failed pulls")
+			var pullFailures []string
+			for _, e := range diagnostics.Events {
+				if e.Type == "Warning" && e.Reason == "Failed" && strings.Contains(e.Message, missingImage) {
+					pullFailures = append(pullFailures, e.String())
+				}
+			}
+			Expect(pullFailures).NotTo(BeEmpty())
+			for _, failure := range pullFailures {
+				Expect(diagnostics.Text).To(ContainSubstring(failure))
+			}
+
+			kbc.By("validate the diagnostics give the exceeded progress deadline of the deployment")
+			Expect(kbc.Kubectl.WaitForCondition("deployment", deployment, "Progressing=False", 2*time.Minute)).To(Succeed())
+			diagnostics, err = kbc.CollectDiagnostics()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diagnostics.Text).To(ContainSubstring("ProgressDeadlineExceeded"))
+
+			kbc.By("validate the manager container falls back to its logs for its termination message")
+			policy, err := kbc.Kubectl.Get(true, "deployment", deployment, "-o",
+				`jsonpath={.spec.template.spec.containers[?(@.name=="manager")].terminationMessagePolicy}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal("FallbackToLogsOnError"))
+		})
+	})
+})
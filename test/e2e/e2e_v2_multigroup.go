@@ -0,0 +1,164 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("kubebuilder", func() {
+	Context("with v2 multigroup scaffolding", func() {
+		var kbc *KBTestContext
+		BeforeEach(func() {
+			var err error
+			kbc, err = TestContext("GO111MODULE=on")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kbc.Prepare()).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(kbc.WriteReport()).To(Succeed())
+
+			kbc.By("clean up created API objects during test process")
+			kbc.CleanupManifests(filepath.Join("config", "default"))
+
+			kbc.By("remove container image and work dir")
+			kbc.Destroy()
+		})
+
+		It("should generate a runnable project with APIs in two groups", func() {
+			var controllerPodName string
+			// a second group, next to kbc.Group, holding a kind of its own
+			secondGroup := "baz" + kbc.TestSuffix
+			secondKind := "Bar" + kbc.TestSuffix
+
+			var err error
+			if kbc.Prescaffolded {
+				kbc.By("reusing the project scaffolded in " + kbc.Dir)
+			} else {
+				kbc.By("init v2 project")
+				err = kbc.Init(
+					"--project-version", "2",
+					"--domain", kbc.Domain,
+					"--dep=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("converting the project to multigroup")
+				Expect(kbc.Edit("--multigroup=true")).To(Succeed())
+
+				kbc.By("creating api definitions in two groups")
+				for _, gk := range [][]string{{kbc.Group, kbc.Kind}, {secondGroup, secondKind}} {
+					err = kbc.CreateAPI(
+						"--group", gk[0],
+						"--version", kbc.Version,
+						"--kind", gk[1],
+						"--namespaced",
+						"--resource",
+						"--controller",
+						"--make=false")
+					Expect(err).Should(Succeed())
+
+					Expect(filepath.Join(kbc.Dir, "api", gk[0], kbc.Version,
+						fmt.Sprintf("%s_types.go", strings.ToLower(gk[1])))).To(BeAnExistingFile())
+					Expect(filepath.Join(kbc.Dir, "controllers", gk[0],
+						fmt.Sprintf("%s_controller.go", strings.ToLower(gk[1])))).To(BeAnExistingFile())
+				}
+			}
+
+			kbc.By("building image")
+			err = kbc.BuildImage()
+			Expect(err).Should(Succeed())
+
+			kbc.By("loading docker image into the cluster")
+			err = kbc.LoadImageToCluster()
+			Expect(err).Should(Succeed())
+
+			kbc.By("deploying controller manager")
+			err = kbc.Make("deploy")
+			Expect(err).Should(Succeed())
+
+			kbc.By("validate the controller-manager pod running as expected")
+			verifyControllerUp := func() error {
+				pods := &podList{}
+				Expect(kbc.Kubectl.GetJSON("pods", "", pods, "-l", "control-plane=controller-manager")).To(Succeed())
+				live := pods.live()
+				if len(live) != 1 {
+					return fmt.Errorf("expect 1 controller pods running, but got %d", len(live))
+				}
+				controllerPodName = live[0].Metadata.Name
+				Expect(controllerPodName).Should(ContainSubstring("controller-manager"))
+
+				// Validate pod status
+				if live[0].Status.Phase != "Running" {
+					return fmt.Errorf("controller pod in %s status", live[0].Status.Phase)
+				}
+				return nil
+			}
+			Eventually(verifyControllerUp, time.Minute, time.Second).Should(Succeed())
+
+			if kbc.Arch != "" {
+				kbc.By("validating the controller pod runs on a node of the built architecture")
+				Expect(kbc.VerifyNodeArchitecture(controllerPodName)).To(Succeed())
+			}
+
+			kbc.By("creating an instance of CR in each group")
+			for _, gk := range [][]string{{kbc.Group, kbc.Kind}, {secondGroup, secondKind}} {
+				sampleFile := filepath.Join("config", "samples",
+					fmt.Sprintf("%s_%s_%s.yaml", gk[0], kbc.Version, strings.ToLower(gk[1])))
+				Eventually(func() error {
+					_, err = kbc.Kubectl.Apply(true, "-f", sampleFile)
+					return err
+				}, time.Minute, time.Second).Should(Succeed())
+			}
+
+			kbc.By("validate the created resource objects get reconciled by both controllers")
+			reconciledKinds := func() []string {
+				logOutput, err := kbc.Kubectl.Logs(controllerPodName, "-c", "manager")
+				Expect(err).NotTo(HaveOccurred())
+
+				var kinds []string
+				for _, line := range getNonEmptyLines(logOutput) {
+					if !strings.Contains(line, "Successfully Reconciled") {
+						continue
+					}
+					for _, kind := range []string{kbc.Kind, secondKind} {
+						if strings.Contains(line, fmt.Sprintf("%q", strings.ToLower(kind))) {
+							kinds = append(kinds, kind)
+						}
+					}
+				}
+				return kinds
+			}
+			Eventually(reconciledKinds, time.Minute, time.Second).Should(ContainElement(kbc.Kind))
+			Eventually(reconciledKinds, time.Minute, time.Second).Should(ContainElement(secondKind))
+
+			kbc.By("validate the manager RBAC denies the actions the manager does not need")
+			Expect(kbc.VerifyManagerRBAC()).To(Succeed())
+
+			kbc.By("validate the controller-manager pod has not restarted")
+			Consistently(func() error {
+				return kbc.VerifyNoRestarts(controllerPodName)
+			}, 30*time.Second, 5*time.Second).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,172 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("kubebuilder", func() {
+	Context("with v2 scaffolding and self-signed webhook certificates", func() {
+		var kbc *KBTestContext
+		BeforeEach(func() {
+			var err error
+			kbc, err = TestContext("GO111MODULE=on")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kbc.Prepare()).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(kbc.WriteReport()).To(Succeed())
+
+			kbc.By("clean up created API objects during test process")
+			kbc.CleanupManifests(filepath.Join("config", "default"))
+
+			kbc.By("remove container image and work dir")
+			kbc.Destroy()
+		})
+
+		It("should serve the webhooks without cert-manager", func() {
+			var controllerPodName string
+			var err error
+			if kbc.Prescaffolded {
+				kbc.By("reusing the project scaffolded in " + kbc.Dir)
+			} else {
+				kbc.By("init v2 project")
+				err = kbc.Init(
+					"--project-version", "2",
+					"--domain", kbc.Domain,
+					"--dep=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("creating api definition")
+				err = kbc.CreateAPI(
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", kbc.Kind,
+					"--namespaced",
+					"--resource",
+					"--controller",
+					"--make=false")
+				Expect(err).Should(Succeed())
+
+				kbc.By("creating the webhooks with a self-signed certificate")
+				err = kbc.CreateWebhook(
+					"--group", kbc.Group,
+					"--version", kbc.Version,
+					"--kind", kbc.Kind,
+					"--defaulting",
+					"--programmatic-validation",
+					"--cert-provider=self-signed")
+				Expect(err).Should(Succeed())
+				Expect(filepath.Join(kbc.Dir, "certrotator", "certrotator.go")).To(BeAnExistingFile())
+
+				kbc.By("uncomment kustomization.yaml to enable the webhook with the self-signed patch")
+				kustomization := filepath.Join(kbc.Dir, "config", "default", "kustomization.yaml")
+				Expect(uncommentCode(kustomization, "#- ../webhook", "#")).To(Succeed())
+				Expect(uncommentCode(kustomization, "#- manager_webhook_patch.yaml", "#")).To(Succeed())
+				Expect(replaceCode(kustomization,
+					"- manager_webhook_patch.yaml", "- manager_webhook_selfsigned_patch.yaml")).To(Succeed())
+			}
+
+			kbc.By("building image")
+			err = kbc.BuildImage()
+			Expect(err).Should(Succeed())
+
+			kbc.By("loading docker image into the cluster")
+			err = kbc.LoadImageToCluster()
+			Expect(err).Should(Succeed())
+
+			kbc.By("deploying controller manager")
+			err = kbc.Make("deploy")
+			Expect(err).Should(Succeed())
+
+			kbc.By("validate the controller-manager pod running as expected")
+			verifyControllerUp := func() error {
+				pods := &podList{}
+				Expect(kbc.Kubectl.GetJSON("pods", "", pods, "-l", "control-plane=controller-manager")).To(Succeed())
+				live := pods.live()
+				if len(live) != 1 {
+					return fmt.Errorf("expect 1 controller pods running, but got %d", len(live))
+				}
+				controllerPodName = live[0].Metadata.Name
+				Expect(controllerPodName).Should(ContainSubstring("controller-manager"))
+
+				// Validate pod status
+				if live[0].Status.Phase != "Running" {
+					return fmt.Errorf("controller pod in %s status", live[0].Status.Phase)
+				}
+				return nil
+			}
+			Eventually(verifyControllerUp, time.Minute, time.Second).Should(Succeed())
+
+			kbc.By("validate the manager has issued the certificate secret")
+			_, err = kbc.Kubectl.Get(true, "secrets", kbc.Name("webhook-server-cert"))
+			Expect(err).NotTo(HaveOccurred())
+
+			kbc.By("validate the mutating|validating webhooks have the CA injected")
+			verifyCAInjection := func() error {
+				for _, resource := range []string{"mutating", "validating"} {
+					config := &webhookConfiguration{}
+					Expect(kbc.Kubectl.GetJSON(
+						resource+"webhookconfigurations.admissionregistration.k8s.io",
+						kbc.Name(resource+"-webhook-configuration"),
+						config)).To(Succeed())
+					Expect(config.Webhooks).NotTo(BeEmpty())
+					for _, webhook := range config.Webhooks {
+						// the CA is longer than the place holder of the manifests
+						if len(webhook.ClientConfig.CABundle) <= 10 {
+							return fmt.Errorf("the CA is not injected in the %s webhook configuration", resource)
+						}
+					}
+				}
+				return nil
+			}
+			Eventually(verifyCAInjection, time.Minute, time.Second).Should(Succeed())
+
+			kbc.By("creating an instance of CR through the webhooks")
+			sampleFile := filepath.Join("config", "samples",
+				fmt.Sprintf("%s_%s_%s.yaml", kbc.Group, kbc.Version, strings.ToLower(kbc.Kind)))
+			Eventually(func() error {
+				_, err = kbc.Kubectl.Apply(true, "-f", sampleFile)
+				return err
+			}, time.Minute, time.Second).Should(Succeed())
+
+			kbc.By("validate the created resource object gets reconciled in controller")
+			managerContainerLogs := func() string {
+				logOutput, err := kbc.Kubectl.Logs(controllerPodName, "-c", "manager")
+				Expect(err).NotTo(HaveOccurred())
+				return logOutput
+			}
+			Eventually(managerContainerLogs, time.Minute, time.Second).Should(ContainSubstring("Successfully Reconciled"))
+
+			kbc.By("validate the manager RBAC denies the actions the manager does not need")
+			Expect(kbc.VerifyManagerRBAC()).To(Succeed())
+
+			kbc.By("validate the controller-manager pod has not restarted")
+			Consistently(func() error {
+				return kbc.VerifyNoRestarts(controllerPodName)
+			}, 30*time.Second, 5*time.Second).Should(Succeed())
+		})
+	})
+})
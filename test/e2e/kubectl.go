@@ -17,9 +17,13 @@ limitations under the License.
 package e2e
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os/exec"
+	"strings"
+	"time"
 )
 
 // Kubectl contains context to run kubectl commands
@@ -93,3 +97,99 @@ func (k *Kubectl) Logs(cmdOptions ...string) (string, error) {
 	ops := append([]string{"logs"}, cmdOptions...)
 	return k.CommandInNamespace(ops...)
 }
+
+// CanI is a func to run kubectl auth can-i commands in the namespace as the
+// given user, returning whether the action is allowed
+func (k *Kubectl) CanI(as string, cmdOptions ...string) (bool, error) {
+	ops := append([]string{"auth", "can-i", "--as", as}, cmdOptions...)
+	output, err := k.CommandInNamespace(ops...)
+	// kubectl exits with 1 when the action is not allowed
+	lines := getNonEmptyLines(output)
+	if len(lines) > 0 {
+		switch answer := lines[len(lines)-1]; {
+		case strings.HasPrefix(answer, "yes"):
+			return true, nil
+		case strings.HasPrefix(answer, "no"):
+			return false, nil
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, fmt.Errorf("unexpected kubectl auth can-i output: %s", output)
+}
+
+// GetJSON is a func to run kubectl get commands in the namespace, decoding the
+// object of the given resource and name into out. With an empty name, the list
+// of the objects of the resource is decoded, e.g. into a podList.
+func (k *Kubectl) GetJSON(resource, name string, out interface{}, cmdOptions ...string) error {
+	ops := []string{resource}
+	if name != "" {
+		ops = append(ops, name)
+	}
+	ops = append(append(ops, "-o", "json"), cmdOptions...)
+	output, err := k.Get(true, ops...)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(output), out); err != nil {
+		return fmt.Errorf("error decoding %s %s: %v", resource, name, err)
+	}
+	return nil
+}
+
+// WaitForCondition is a func to run kubectl wait commands in the namespace,
+// waiting up to timeout for the condition of the object of the given resource
+// and name to be True. The object must exist.
+func (k *Kubectl) WaitForCondition(resource, name, condition string, timeout time.Duration) error {
+	_, err := k.CommandInNamespace("wait", resource+"/"+name,
+		"--for=condition="+condition, "--timeout="+timeout.String())
+	return err
+}
+
+// objectMeta is the subset of the metadata of an object inspected by the
+// tests.
+type objectMeta struct {
+	Name              string  `json:"name"`
+	DeletionTimestamp *string `json:"deletionTimestamp"`
+}
+
+// pod is the subset of a pod inspected by the tests.
+type pod struct {
+	Metadata objectMeta `json:"metadata"`
+	Spec     struct {
+		NodeName        string `json:"nodeName"`
+		SecurityContext struct {
+			RunAsNonRoot *bool `json:"runAsNonRoot"`
+		} `json:"securityContext"`
+	} `json:"spec"`
+	Status struct {
+		Phase string `json:"phase"`
+	} `json:"status"`
+}
+
+// podList is a list of pods, as decoded by GetJSON.
+type podList struct {
+	Items []pod `json:"items"`
+}
+
+// live returns the pods of the list which are not being deleted.
+func (l *podList) live() []pod {
+	var pods []pod
+	for _, p := range l.Items {
+		if p.Metadata.DeletionTimestamp == nil {
+			pods = append(pods, p)
+		}
+	}
+	return pods
+}
+
+// webhookConfiguration is the subset of a mutating or validating webhook
+// configuration inspected by the tests.
+type webhookConfiguration struct {
+	Webhooks []struct {
+		ClientConfig struct {
+			CABundle string `json:"caBundle"`
+		} `json:"clientConfig"`
+	} `json:"webhooks"`
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+
+	"sigs.k8s.io/kubebuilder/test/e2e/report"
+)
+
+// By records the start of a step of the test in its report, and describes it
+// with ginkgo's By.
+func (kc *KBTestContext) By(text string) {
+	kc.endStep()
+	kc.report.Steps = append(kc.report.Steps, report.Step{Name: text})
+	kc.stepStart = time.Now()
+	By(text)
+}
+
+// endStep records the duration of the running step.
+func (kc *KBTestContext) endStep() {
+	if n := len(kc.report.Steps); n > 0 && kc.report.Steps[n-1].Seconds == 0 {
+		kc.report.Steps[n-1].Seconds = time.Since(kc.stepStart).Seconds()
+	}
+}
+
+// summary is the subset of the kubelet stats summary read to measure the
+// memory of a pod.
+type summary struct {
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		Containers []struct {
+			Memory struct {
+				WorkingSetBytes int64 `json:"workingSetBytes"`
+			} `json:"memory"`
+		} `json:"containers"`
+	} `json:"pods"`
+}
+
+// MeasureManagerMemory records the working set of the containers of the given
+// manager pod in the report. It is read from the stats summary of the kubelet
+// of its node, which does not need a metrics server in the cluster.
+func (kc *KBTestContext) MeasureManagerMemory(podName string) error {
+	nodeName, err := kc.Kubectl.Get(true, "pods", podName, "-o", "jsonpath={.spec.nodeName}")
+	if err != nil {
+		return err
+	}
+	output, err := kc.Kubectl.Command("get", "--raw", fmt.Sprintf("/api/v1/nodes/%s/proxy/stats/summary", nodeName))
+	if err != nil {
+		return err
+	}
+	stats := summary{}
+	if err := json.Unmarshal([]byte(output), &stats); err != nil {
+		return err
+	}
+	for _, pod := range stats.Pods {
+		if pod.PodRef.Name != podName || pod.PodRef.Namespace != kc.Kubectl.Namespace {
+			continue
+		}
+		kc.report.ManagerMemoryBytes = 0
+		for _, c := range pod.Containers {
+			kc.report.ManagerMemoryBytes += c.Memory.WorkingSetBytes
+		}
+		fmt.Fprintf(GinkgoWriter, "manager memory: %.1fMiB\n", float64(kc.report.ManagerMemoryBytes)/(1<<20))
+		return nil
+	}
+	return fmt.Errorf("pod %s is not in the stats summary of node %s", podName, nodeName)
+}
+
+var reportNameRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// WriteReport writes the report of the test to the directory given by the
+// KB_E2E_REPORT_DIR environment variable, if set, to be compared with the
+// reports of another run by test/e2e/report/compare.
+func (kc *KBTestContext) WriteReport() error {
+	kc.endStep()
+	dir := os.Getenv("KB_E2E_REPORT_DIR")
+	if dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	kc.report.Test = CurrentGinkgoTestDescription().FullTestText
+	if output, err := kc.Run(exec.Command("kubebuilder", "version")); err == nil {
+		kc.report.KubebuilderVersion = strings.TrimSpace(string(output))
+	}
+	path := filepath.Join(dir, kc.reportName()+".json")
+	fmt.Fprintf(GinkgoWriter, "writing the report of the test to %s\n", path)
+	return kc.report.Write(path)
+}
+
+// reportName is the name of the files of the report of the running test,
+// derived from its full text.
+func (kc *KBTestContext) reportName() string {
+	test := CurrentGinkgoTestDescription().FullTestText
+	return strings.Trim(reportNameRegex.ReplaceAllString(strings.ToLower(test), "-"), "-")
+}
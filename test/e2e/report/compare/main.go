@@ -0,0 +1,81 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command compare prints the changes of the e2e reports of a head run against
+// the ones of a base run, e.g. of the previous kubebuilder release, failing
+// when a measure regressed over a threshold.
+//
+//	go run ./test/e2e/report/compare -threshold 10 base-reports/ head-reports/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"sigs.k8s.io/kubebuilder/test/e2e/report"
+)
+
+func main() {
+	threshold := flag.Float64("threshold", 0,
+		"fail when a size or the manager memory grew by more than this percentage, 0 to only print the changes")
+	stepThreshold := flag.Float64("step-threshold", 0,
+		"fail when a step took more than this percentage longer, 0 to only print the changes")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <base report or directory> <head report or directory>\n",
+			os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	base, err := report.Load(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	head, err := report.Load(flag.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TEST\tMEASURE\tBASE\tHEAD\tCHANGE\t")
+	regressed := 0
+	for _, d := range report.Compare(base, head) {
+		limit := *threshold
+		format := "%.0f"
+		if d.Duration {
+			limit, format = *stepThreshold, "%.1fs"
+		}
+		mark := ""
+		if limit > 0 && d.Change() > limit {
+			mark = " REGRESSED"
+			regressed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t"+format+"\t"+format+"\t%+.1f%%%s\t\n", d.Test, d.Measure, d.Base, d.Head, d.Change(), mark)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if regressed > 0 {
+		log.Fatalf("%d measures regressed over the threshold", regressed)
+	}
+}
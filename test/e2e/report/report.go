@@ -0,0 +1,161 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package report defines the machine-readable report of an e2e test, the
+// durations of its steps and the resources used by the scaffolded project, and
+// compares the reports of two runs to quantify the impact of template changes
+// between kubebuilder versions.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Report is the report of an e2e test.
+type Report struct {
+	// Test is the full text of the test
+	Test string `json:"test"`
+
+	// KubebuilderVersion is the output of kubebuilder version
+	KubebuilderVersion string `json:"kubebuilderVersion,omitempty"`
+
+	// Steps are the steps of the test, in the order they ran
+	Steps []Step `json:"steps"`
+
+	// Modules is the number of modules of the build list of the project
+	Modules int `json:"modules,omitempty"`
+
+	// BinaryBytes is the size of the manager binary
+	BinaryBytes int64 `json:"binaryBytes,omitempty"`
+
+	// ImageBytes is the size of the manager image
+	ImageBytes int64 `json:"imageBytes,omitempty"`
+
+	// ManagerMemoryBytes is the working set of the manager pod, once it has
+	// reconciled the sample
+	ManagerMemoryBytes int64 `json:"managerMemoryBytes,omitempty"`
+}
+
+// Step is a step of a test, as given to By.
+type Step struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+}
+
+// Write writes the report as JSON to path.
+func (r *Report) Write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Load loads the report at path, or the reports of the *.json files in the
+// directory at path.
+func Load(path string) ([]Report, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(path, "*.json")); err != nil {
+			return nil, err
+		}
+	}
+
+	reports := make([]Report, 0, len(files))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file) // nolint: gosec
+		if err != nil {
+			return nil, err
+		}
+		r := Report{}
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("invalid report %s: %v", file, err)
+		}
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+// Delta is the change of a measure of a test between two runs.
+type Delta struct {
+	Test    string
+	Measure string
+	// Duration indicates whether the measure is the duration of a step,
+	// rather than a size
+	Duration   bool
+	Base, Head float64
+}
+
+// Change returns the relative change of the measure, in percent, 0 when it
+// was not measured by the base run.
+func (d Delta) Change() float64 {
+	if d.Base == 0 {
+		return 0
+	}
+	return (d.Head - d.Base) / d.Base * 100
+}
+
+// Compare returns the changes of the measures of the tests reported by both
+// the base and the head runs, sorted by test.
+func Compare(base, head []Report) []Delta {
+	bases := map[string]Report{}
+	for _, r := range base {
+		bases[r.Test] = r
+	}
+
+	var deltas []Delta
+	for _, h := range head {
+		b, found := bases[h.Test]
+		if !found {
+			continue
+		}
+		sizes := []struct {
+			measure    string
+			base, head float64
+		}{
+			{"modules", float64(b.Modules), float64(h.Modules)},
+			{"binary bytes", float64(b.BinaryBytes), float64(h.BinaryBytes)},
+			{"image bytes", float64(b.ImageBytes), float64(h.ImageBytes)},
+			{"manager memory bytes", float64(b.ManagerMemoryBytes), float64(h.ManagerMemoryBytes)},
+		}
+		for _, s := range sizes {
+			if s.base != 0 || s.head != 0 {
+				deltas = append(deltas, Delta{Test: h.Test, Measure: s.measure, Base: s.base, Head: s.head})
+			}
+		}
+
+		baseSteps := map[string]float64{}
+		for _, s := range b.Steps {
+			baseSteps[s.Name] += s.Seconds
+		}
+		for _, s := range h.Steps {
+			if seconds, found := baseSteps[s.Name]; found {
+				deltas = append(deltas, Delta{Test: h.Test, Measure: s.Name, Duration: true, Base: seconds, Head: s.Seconds})
+			}
+		}
+	}
+	sort.SliceStable(deltas, func(i, j int) bool { return deltas[i].Test < deltas[j].Test })
+	return deltas
+}
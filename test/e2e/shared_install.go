@@ -0,0 +1,155 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+)
+
+const certManagerManifest = "https://github.com/jetstack/cert-manager/releases/download/v0.8.0/cert-manager.yaml"
+
+// sharedInstall is a cluster-wide installation shared by the specs of a run,
+// e.g. the cert manager. It is installed by the first spec using it and
+// uninstalled by the last one, whether the specs run one after the other or
+// on parallel ginkgo nodes, the number of its users being counted in a file
+// locked by each node in turn.
+type sharedInstall struct {
+	name      string
+	install   func(k *Kubectl) error
+	uninstall func(k *Kubectl)
+}
+
+// sharedInstalls are the installations uninstalled at the end of the run, if
+// a spec exited without releasing them.
+var sharedInstalls = []*sharedInstall{certManager}
+
+var certManager = &sharedInstall{
+	name: "cert-manager",
+	install: func(k *Kubectl) error {
+		if _, err := k.Command("create", "namespace", "cert-manager"); err != nil {
+			return err
+		}
+		if _, err := k.Command("label", "namespace", "cert-manager", "certmanager.k8s.io/disable-validation=true"); err != nil {
+			return err
+		}
+		_, err := k.Apply(false, "-f", certManagerManifest)
+		return err
+	},
+	uninstall: func(k *Kubectl) {
+		if _, err := k.Delete(false, "-f", certManagerManifest); err != nil {
+			fmt.Fprintf(GinkgoWriter, "error when running kubectl delete during cleaning up cert manager: %v\n", err)
+		}
+		if _, err := k.Delete(false, "namespace", "cert-manager"); err != nil {
+			fmt.Fprintf(GinkgoWriter, "error when cleaning up the cert manager namespace: %v\n", err)
+		}
+	},
+}
+
+// usersFile is the path of the file counting the users of the installation.
+// The parallel nodes of a run share its random seed.
+func (s *sharedInstall) usersFile() string {
+	return filepath.Join(os.TempDir(),
+		fmt.Sprintf("kubebuilder-e2e-%d-%s.users", config.GinkgoConfig.RandomSeed, s.name))
+}
+
+// acquire installs it unless another spec of the run already did. The spec
+// is counted as a user even if the installation fails, for its release to
+// uninstall what was installed.
+func (s *sharedInstall) acquire(k *Kubectl) error {
+	return s.updateUsers(func(users int) (int, error) {
+		if users == 0 {
+			return 1, s.install(k)
+		}
+		return users + 1, nil
+	})
+}
+
+// release uninstalls it if the spec was its last user.
+func (s *sharedInstall) release(k *Kubectl) {
+	err := s.updateUsers(func(users int) (int, error) {
+		if users <= 1 {
+			s.uninstall(k)
+			return 0, nil
+		}
+		fmt.Fprintf(GinkgoWriter, "keeping %s for the %d other specs using it\n", s.name, users-1)
+		return users - 1, nil
+	})
+	if err != nil {
+		fmt.Fprintf(GinkgoWriter, "error when releasing %s: %v\n", s.name, err)
+	}
+}
+
+// cleanup uninstalls it if it still has users, once all the specs of the run have
+// finished, and removes its users file.
+func (s *sharedInstall) cleanup(k *Kubectl) {
+	err := s.updateUsers(func(users int) (int, error) {
+		if users > 0 {
+			fmt.Fprintf(GinkgoWriter, "uninstalling %s left by %d specs\n", s.name, users)
+			s.uninstall(k)
+		}
+		return 0, nil
+	})
+	if err != nil {
+		fmt.Fprintf(GinkgoWriter, "error when cleaning up %s: %v\n", s.name, err)
+	}
+	if err := os.Remove(s.usersFile()); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(GinkgoWriter, "error when removing the users file of %s: %v\n", s.name, err)
+	}
+}
+
+// updateUsers updates the number of users of the installation to the one
+// returned by f, holding the lock of its users file, and returns the error of f.
+func (s *sharedInstall) updateUsers(f func(users int) (int, error)) error {
+	file, err := os.OpenFile(s.usersFile(), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN) // nolint: errcheck
+
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	users := 0
+	if content = bytes.TrimSpace(content); len(content) > 0 {
+		if users, err = strconv.Atoi(string(content)); err != nil {
+			return fmt.Errorf("invalid users file %s: %v", s.usersFile(), err)
+		}
+	}
+
+	users, updateErr := f(users)
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := file.WriteAt([]byte(strconv.Itoa(users)), 0); err != nil {
+		return err
+	}
+	return updateErr
+}
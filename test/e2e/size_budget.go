@@ -0,0 +1,151 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+)
+
+// projectSize is the size of a scaffolded project, as experienced by its users.
+type projectSize struct {
+	// Modules is the number of modules of the build list of the project
+	Modules int
+
+	// BinaryBytes is the size of the manager binary
+	BinaryBytes int64
+
+	// ImageBytes is the size of the manager image, 0 when not measured
+	ImageBytes int64
+}
+
+// sizeBudget is the maximum size of a freshly scaffolded project. The
+// templates pulling a new dependency or bloating the manager have to raise it
+// explicitly, protecting every new operator from the regression.
+var sizeBudget = projectSize{
+	Modules:     80,
+	BinaryBytes: 40 << 20,
+	ImageBytes:  45 << 20,
+}
+
+// loadSizeBudget returns sizeBudget, overridden by the KB_E2E_MAX_MODULES,
+// KB_E2E_MAX_BINARY_MB and KB_E2E_MAX_IMAGE_MB environment variables.
+func loadSizeBudget() (projectSize, error) {
+	budget := sizeBudget
+	if v := os.Getenv("KB_E2E_MAX_MODULES"); v != "" {
+		modules, err := strconv.Atoi(v)
+		if err != nil {
+			return budget, fmt.Errorf("invalid KB_E2E_MAX_MODULES %q: %v", v, err)
+		}
+		budget.Modules = modules
+	}
+	for env, bytes := range map[string]*int64{
+		"KB_E2E_MAX_BINARY_MB": &budget.BinaryBytes,
+		"KB_E2E_MAX_IMAGE_MB":  &budget.ImageBytes,
+	} {
+		if v := os.Getenv(env); v != "" {
+			mb, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return budget, fmt.Errorf("invalid %s %q: %v", env, v, err)
+			}
+			*bytes = mb << 20
+		}
+	}
+	return budget, nil
+}
+
+// MeasureProjectSize measures the project, whose manager binary is built by
+// the manager target and image by BuildImage.
+func (kc *KBTestContext) MeasureProjectSize() (projectSize, error) {
+	size := projectSize{}
+
+	// the output of the go command is read alone, leaving out the modules it
+	// reports downloading
+	cmd := exec.Command("go", "list", "-m", "all")
+	cmd.Dir = kc.Dir
+	cmd.Env = append(os.Environ(), kc.Env...)
+	out, err := cmd.Output()
+	if err != nil {
+		return size, fmt.Errorf("go list -m all failed with error: %v", err)
+	}
+	size.Modules = len(getNonEmptyLines(string(out)))
+
+	binary, err := os.Stat(filepath.Join(kc.Dir, "bin", "manager"))
+	if err != nil {
+		return size, err
+	}
+	size.BinaryBytes = binary.Size()
+
+	if kc.imageArchive != "" {
+		// the image cross-built by docker buildx is only in its archive
+		archive, err := os.Stat(kc.imageArchive)
+		if err != nil {
+			return size, err
+		}
+		size.ImageBytes = archive.Size()
+	} else {
+		out, err := kc.Run(exec.Command(kc.runtime.Tool, "image", "inspect", "--format", "{{ .Size }}", kc.ImageName))
+		if err != nil {
+			return size, err
+		}
+		if size.ImageBytes, err = strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err != nil {
+			return size, fmt.Errorf("invalid size of image %s: %v", kc.ImageName, err)
+		}
+	}
+
+	kc.report.Modules, kc.report.BinaryBytes, kc.report.ImageBytes = size.Modules, size.BinaryBytes, size.ImageBytes
+	fmt.Fprintf(GinkgoWriter, "project size: %d modules, %.1fMiB binary, %.1fMiB image\n",
+		size.Modules, float64(size.BinaryBytes)/(1<<20), float64(size.ImageBytes)/(1<<20))
+	return size, nil
+}
+
+// VerifySizeBudget returns an error listing the measures of the project over
+// the size budget.
+func (kc *KBTestContext) VerifySizeBudget() error {
+	budget, err := loadSizeBudget()
+	if err != nil {
+		return err
+	}
+	size, err := kc.MeasureProjectSize()
+	if err != nil {
+		return err
+	}
+
+	var exceeded []string
+	if size.Modules > budget.Modules {
+		exceeded = append(exceeded, fmt.Sprintf("the build list has %d modules, over the budget of %d",
+			size.Modules, budget.Modules))
+	}
+	if size.BinaryBytes > budget.BinaryBytes {
+		exceeded = append(exceeded, fmt.Sprintf("the manager binary is %d bytes, over the budget of %d",
+			size.BinaryBytes, budget.BinaryBytes))
+	}
+	if size.ImageBytes > budget.ImageBytes {
+		exceeded = append(exceeded, fmt.Sprintf("the manager image is %d bytes, over the budget of %d",
+			size.ImageBytes, budget.ImageBytes))
+	}
+	if len(exceeded) > 0 {
+		return fmt.Errorf("the scaffolded project is over its size budget:\n%s", strings.Join(exceeded, "\n"))
+	}
+	return nil
+}
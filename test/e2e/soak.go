@@ -0,0 +1,258 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+)
+
+// soakConfig configures the soak mode, churning the CRs reconciled by the
+// manager for a while to catch the leaks a single CR does not reveal.
+type soakConfig struct {
+	// Duration is how long the CRs are churned, the soak mode is disabled when 0
+	Duration time.Duration
+
+	// Objects is the number of CRs created, updated and deleted by each round
+	Objects int
+}
+
+// The growth of the goroutines and resident memory of the manager tolerated
+// between the first and the last round of the soak. The informers and the
+// work queues have reached their steady state after the first round, the
+// allowances only absorb the noise of the runtime.
+const (
+	soakMaxGoroutineGrowth = 20
+	soakMaxMemoryGrowth    = 0.5
+)
+
+// loadSoakConfig reads the soak mode configuration from the
+// KB_E2E_SOAK_DURATION (e.g. 10m) and KB_E2E_SOAK_OBJECTS environment
+// variables, churning 100 CRs per round by default.
+func loadSoakConfig() (soakConfig, error) {
+	config := soakConfig{Objects: 100}
+	if v := os.Getenv("KB_E2E_SOAK_DURATION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return config, fmt.Errorf("invalid KB_E2E_SOAK_DURATION %q: %v", v, err)
+		}
+		config.Duration = d
+	}
+	if v := os.Getenv("KB_E2E_SOAK_OBJECTS"); v != "" {
+		objects, err := strconv.Atoi(v)
+		if err != nil || objects <= 0 {
+			return config, fmt.Errorf("invalid KB_E2E_SOAK_OBJECTS %q, must be a positive integer", v)
+		}
+		config.Objects = objects
+	}
+	return config, nil
+}
+
+// soakMetricsCode registers the collectors of the Go runtime and the process
+// with the registry of the manager metrics, the soak mode measuring the
+// goroutines and memory of the manager with them.
+const soakMetricsCode = `	metrics.Registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+`
+
+// soakMetricsImports are the imports of soakMetricsCode.
+const soakMetricsImports = `	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+`
+
+// managerUsage is the usage of the manager measured by the soak mode.
+type managerUsage struct {
+	Goroutines    int
+	ResidentBytes int64
+}
+
+// Soak creates, updates and deletes config.Objects CRs in rounds for
+// config.Duration, then verifies the manager of the given pod has reconciled
+// them all without leaking goroutines or memory, nor restarting.
+func (kc *KBTestContext) Soak(podName string, config soakConfig) error {
+	addr, stop, err := kc.portForward(podName, 8080)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	var baseline managerUsage
+	deadline := time.Now().Add(config.Duration)
+	for round := 1; round == 1 || time.Now().Before(deadline); round++ {
+		if err := kc.churn(round, config.Objects); err != nil {
+			return fmt.Errorf("round %d: %v", round, err)
+		}
+		if round == 1 {
+			if baseline, err = kc.managerUsage(addr); err != nil {
+				return err
+			}
+			fmt.Fprintf(GinkgoWriter, "soak baseline: %d goroutines, %.1fMiB resident\n",
+				baseline.Goroutines, float64(baseline.ResidentBytes)/(1<<20))
+		}
+	}
+
+	// let the manager settle, e.g. its work queues forget the deleted CRs
+	time.Sleep(30 * time.Second)
+	usage, err := kc.managerUsage(addr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(GinkgoWriter, "soak end: %d goroutines, %.1fMiB resident\n",
+		usage.Goroutines, float64(usage.ResidentBytes)/(1<<20))
+
+	var leaks []string
+	if usage.Goroutines > baseline.Goroutines+soakMaxGoroutineGrowth {
+		leaks = append(leaks, fmt.Sprintf("the goroutines grew from %d to %d",
+			baseline.Goroutines, usage.Goroutines))
+	}
+	if float64(usage.ResidentBytes) > float64(baseline.ResidentBytes)*(1+soakMaxMemoryGrowth) {
+		leaks = append(leaks, fmt.Sprintf("the resident memory grew from %.1fMiB to %.1fMiB",
+			float64(baseline.ResidentBytes)/(1<<20), float64(usage.ResidentBytes)/(1<<20)))
+	}
+	if len(leaks) > 0 {
+		return fmt.Errorf("the manager leaks over the soak: %s", strings.Join(leaks, ", "))
+	}
+	return kc.VerifyNoRestarts(podName)
+}
+
+// churn creates the given number of CRs, updates and deletes them, waiting
+// for the deletions to complete.
+func (kc *KBTestContext) churn(round, objects int) error {
+	manifests := func(count int) string {
+		var b strings.Builder
+		for i := 0; i < objects; i++ {
+			fmt.Fprintf(&b, `---
+apiVersion: %s.%s/%s
+kind: %s
+metadata:
+  name: soak-%d
+  labels:
+    e2e-soak: "true"
+spec:
+  count: %d
+`, kc.Group, kc.Domain, kc.Version, kc.Kind, i, count)
+		}
+		return b.String()
+	}
+
+	if _, err := kc.Kubectl.CommandWithInput(manifests(round), "-n", kc.Kubectl.Namespace, "apply", "-f", "-"); err != nil {
+		return err
+	}
+	if _, err := kc.Kubectl.CommandWithInput(manifests(round+1), "-n", kc.Kubectl.Namespace, "apply", "-f", "-"); err != nil {
+		return err
+	}
+	_, err := kc.Kubectl.Delete(true, kc.Resources, "-l", "e2e-soak=true", "--wait=true", "--timeout=5m")
+	return err
+}
+
+var forwardingRegex = regexp.MustCompile(`Forwarding from (127\.0\.0\.1:[0-9]+) ->`)
+
+// portForward forwards a local port to the given port of the pod, which
+// reaches the ports the containers of the pod bind on localhost, e.g. the
+// metrics of a manager behind the auth proxy. It returns the local address
+// and a func stopping the forwarding.
+func (kc *KBTestContext) portForward(podName string, port int) (string, func(), error) {
+	cmd := exec.Command("kubectl", "-n", kc.Kubectl.Namespace, "port-forward",
+		"pod/"+podName, fmt.Sprintf(":%d", port))
+	cmd.Dir = kc.Dir
+	cmd.Env = append(os.Environ(), kc.Env...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", nil, err
+	}
+	cmd.Stderr = GinkgoWriter
+	fmt.Fprintf(GinkgoWriter, "running: %s\n", strings.Join(cmd.Args, " "))
+	if err := cmd.Start(); err != nil {
+		return "", nil, err
+	}
+	stop := func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}
+
+	found := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if m := forwardingRegex.FindStringSubmatch(scanner.Text()); m != nil {
+				select {
+				case found <- m[1]:
+				default:
+				}
+			}
+			fmt.Fprintln(GinkgoWriter, scanner.Text())
+		}
+		close(found)
+	}()
+	select {
+	case addr, ok := <-found:
+		if ok {
+			return addr, stop, nil
+		}
+	case <-time.After(30 * time.Second):
+	}
+	stop()
+	return "", nil, fmt.Errorf("unable to forward port %d of pod %s", port, podName)
+}
+
+// managerUsage scrapes the goroutines and resident memory of the manager from
+// its metrics served at the given address.
+func (kc *KBTestContext) managerUsage(addr string) (managerUsage, error) {
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		return managerUsage{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return managerUsage{}, fmt.Errorf("unable to scrape the metrics of the manager: %s", resp.Status)
+	}
+	output, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return managerUsage{}, err
+	}
+	usage := managerUsage{Goroutines: -1, ResidentBytes: -1}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "go_goroutines":
+			usage.Goroutines = int(value)
+		case "process_resident_memory_bytes":
+			usage.ResidentBytes = int64(value)
+		}
+	}
+	if usage.Goroutines < 0 || usage.ResidentBytes < 0 {
+		return usage, fmt.Errorf("the metrics of the manager lack go_goroutines or process_resident_memory_bytes, " +
+			"the project must register the Go and process collectors for the soak mode")
+	}
+	return usage, nil
+}
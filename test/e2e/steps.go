@@ -0,0 +1,296 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The declarative steps extend the e2e tests of the deployed project without
+// writing Go: each YAML file of the steps directory lists steps applying or
+// deleting manifests and asserting the objects reach a given state in time,
+// e.g.
+//
+//   steps:
+//   - name: create a sample
+//     apply: config/samples/${GROUP}_${VERSION}_${KIND_LOWER}.yaml
+//   - name: the sample is defaulted by the webhook
+//     assert:
+//       resource: ${RESOURCES}
+//       name: ${KIND_LOWER}-sample
+//       jsonPath: '{.spec.count}'
+//       equals: "5"
+//     timeout: 1m
+//
+// The steps are run in order, in the namespace of the manager, by the runnable
+// project spec. The paths of the manifests are relative to the project.
+
+// defaultStepsDir is the directory of the steps run by default, relative to
+// the test/e2e directory the suite runs in.
+const defaultStepsDir = "steps"
+
+// defaultStepTimeout is how long a step is retried when it sets no timeout.
+const defaultStepTimeout = time.Minute
+
+// stepFile is a YAML file of declarative steps.
+type stepFile struct {
+	Steps []Step `yaml:"steps"`
+}
+
+// Step is a declarative step of an e2e test, which does one of applying a
+// manifest, deleting it or asserting the state of an object.
+type Step struct {
+	// Name describes the step in the output and the report of the test
+	Name string `yaml:"name,omitempty"`
+
+	// Apply is a manifest applied with kubectl apply, either the path of its
+	// file or the manifest itself when it spans several lines
+	Apply string `yaml:"apply,omitempty"`
+
+	// Delete is a manifest whose objects are deleted, waiting for their
+	// deletion, given as Apply
+	Delete string `yaml:"delete,omitempty"`
+
+	// Assert asserts the state of an object
+	Assert *StepAssertion `yaml:"assert,omitempty"`
+
+	// Timeout is how long the step is retried until it succeeds, e.g. 30s.
+	// It defaults to a minute.
+	Timeout string `yaml:"timeout,omitempty"`
+
+	// timeout is the parsed Timeout
+	timeout time.Duration
+}
+
+// StepAssertion asserts the JSONPath of an object equals a value, or the object
+// has a condition of status True.
+type StepAssertion struct {
+	// Resource and Name are the resource and the name of the object
+	Resource string `yaml:"resource"`
+	Name     string `yaml:"name"`
+
+	// JSONPath is the kubectl JSONPath template of the asserted field, e.g.
+	// {.spec.count}, whose output must be Equals
+	JSONPath string `yaml:"jsonPath,omitempty"`
+	Equals   string `yaml:"equals,omitempty"`
+
+	// Condition is the type of a condition which must be True, e.g. Ready
+	Condition string `yaml:"condition,omitempty"`
+}
+
+// stepVariables returns the variables expanded in the steps, the names of
+// the test resources being derived from the random suffix of the test.
+func (kc *KBTestContext) stepVariables() map[string]string {
+	return map[string]string{
+		"NAMESPACE":  kc.Kubectl.Namespace,
+		"DOMAIN":     kc.Domain,
+		"GROUP":      kc.Group,
+		"VERSION":    kc.Version,
+		"KIND":       kc.Kind,
+		"KIND_LOWER": strings.ToLower(kc.Kind),
+		"RESOURCES":  kc.Resources,
+	}
+}
+
+// LoadSteps loads the steps of the YAML files of the given directory, in the
+// lexical order of the files, expanding the variables of the test context.
+func (kc *KBTestContext) LoadSteps(dir string) ([]Step, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	vars := kc.stepVariables()
+	var steps []Step
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file) // nolint: gosec
+		if err != nil {
+			return nil, err
+		}
+		expanded, err := expandVariables(string(content), vars)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		f := stepFile{}
+		if err := yaml.UnmarshalStrict([]byte(expanded), &f); err != nil {
+			return nil, fmt.Errorf("error decoding the steps of %s: %v", file, err)
+		}
+		for i := range f.Steps {
+			if err := f.Steps[i].validate(); err != nil {
+				return nil, fmt.Errorf("%s: step %d: %v", file, i+1, err)
+			}
+		}
+		steps = append(steps, f.Steps...)
+	}
+	return steps, nil
+}
+
+// expandVariables expands the ${VAR} variables of the steps, failing on the
+// unknown ones rather than expanding them empty.
+func expandVariables(s string, vars map[string]string) (string, error) {
+	var unknown []string
+	expanded := os.Expand(s, func(name string) string {
+		value, found := vars[name]
+		if !found {
+			unknown = append(unknown, name)
+		}
+		return value
+	})
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("unknown variables %s", strings.Join(unknown, ", "))
+	}
+	return expanded, nil
+}
+
+// validate validates the step does one thing and parses its timeout.
+func (s *Step) validate() error {
+	actions := 0
+	for _, set := range []bool{s.Apply != "", s.Delete != "", s.Assert != nil} {
+		if set {
+			actions++
+		}
+	}
+	if actions != 1 {
+		return fmt.Errorf("a step must have exactly one of apply, delete or assert")
+	}
+	if a := s.Assert; a != nil {
+		if a.Resource == "" || a.Name == "" {
+			return fmt.Errorf("an assertion must have a resource and a name")
+		}
+		if (a.JSONPath == "") == (a.Condition == "") {
+			return fmt.Errorf("an assertion must have exactly one of jsonPath or condition")
+		}
+	}
+	s.timeout = defaultStepTimeout
+	if s.Timeout != "" {
+		timeout, err := time.ParseDuration(s.Timeout)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q", s.Timeout)
+		}
+		s.timeout = timeout
+	}
+	return nil
+}
+
+// description returns the name of the step, or what it does.
+func (s *Step) description() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Apply != "":
+		return "applying " + manifestDescription(s.Apply)
+	case s.Delete != "":
+		return "deleting " + manifestDescription(s.Delete)
+	case s.Assert.Condition != "":
+		return fmt.Sprintf("asserting %s %s is %s", s.Assert.Resource, s.Assert.Name, s.Assert.Condition)
+	default:
+		return fmt.Sprintf("asserting %s of %s %s is %q", s.Assert.JSONPath, s.Assert.Resource,
+			s.Assert.Name, s.Assert.Equals)
+	}
+}
+
+// RunSteps runs the steps in order, retrying each step until it succeeds or
+// its timeout expires, e.g. while the webhooks of the manager are not serving.
+func (kc *KBTestContext) RunSteps(steps []Step) error {
+	for i := range steps {
+		s := &steps[i]
+		kc.By(s.description())
+		if err := retry(s.timeout, kc.runStep, s); err != nil {
+			return fmt.Errorf("step %q did not succeed within %s: %v", s.description(), s.timeout, err)
+		}
+	}
+	return nil
+}
+
+// runStep runs the step once, within the given remaining time of the step.
+func (kc *KBTestContext) runStep(s *Step, remaining time.Duration) error {
+	var err error
+	switch {
+	case s.Apply != "":
+		err = kc.kubectlManifest(s.Apply, "apply")
+	case s.Delete != "":
+		err = kc.kubectlManifest(s.Delete, "delete", "--ignore-not-found", "--wait")
+	case s.Assert.Condition != "":
+		// kubectl wait fails right away on a missing object, hence the retries
+		err = kc.Kubectl.WaitForCondition(s.Assert.Resource, s.Assert.Name, s.Assert.Condition, remaining)
+	default:
+		var out string
+		out, err = kc.Kubectl.Get(true, s.Assert.Resource, s.Assert.Name, "-o", "jsonpath="+s.Assert.JSONPath)
+		if err == nil && out != s.Assert.Equals {
+			err = fmt.Errorf("%s is %q", s.Assert.JSONPath, out)
+		}
+	}
+	return err
+}
+
+// isInline returns whether the manifest of a step is inline rather than the
+// path of its file.
+func isInline(manifest string) bool {
+	return strings.Contains(strings.TrimSpace(manifest), "\n")
+}
+
+// manifestDescription describes the manifest of a step by its path, or as an
+// inline manifest.
+func manifestDescription(manifest string) string {
+	if isInline(manifest) {
+		return "an inline manifest"
+	}
+	return manifest
+}
+
+// kubectlManifest runs the kubectl command in the namespace with the manifest
+// of a step, passed on the standard input when inline.
+func (kc *KBTestContext) kubectlManifest(manifest, command string, cmdOptions ...string) error {
+	var err error
+	if isInline(manifest) {
+		args := append([]string{command, "-n", kc.Kubectl.Namespace, "-f", "-"}, cmdOptions...)
+		_, err = kc.Kubectl.CommandWithInput(manifest, args...)
+	} else {
+		_, err = kc.Kubectl.CommandInNamespace(append([]string{command, "-f", manifest}, cmdOptions...)...)
+	}
+	return err
+}
+
+// retry runs the step until it succeeds or the timeout expires, returning the
+// last error.
+func retry(timeout time.Duration, run func(*Step, time.Duration) error, s *Step) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := run(s, time.Until(deadline))
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// stepsDir returns the directory of the steps run by the runnable project
+// spec, read from the KB_E2E_STEPS_DIR environment variable.
+func stepsDir() string {
+	if dir := os.Getenv("KB_E2E_STEPS_DIR"); dir != "" {
+		return dir
+	}
+	return defaultStepsDir
+}
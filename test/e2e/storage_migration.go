@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// etcdctlArgs are the arguments of etcdctl reaching the etcd of a kind
+// cluster from its static pod.
+var etcdctlArgs = []string{
+	"etcdctl",
+	"--endpoints=https://127.0.0.1:2379",
+	"--cacert=/etc/kubernetes/pki/etcd/ca.crt",
+	"--cert=/etc/kubernetes/pki/etcd/server.crt",
+	"--key=/etc/kubernetes/pki/etcd/server.key",
+}
+
+// crdName returns the name of the CRD of the test resources.
+func (kc *KBTestContext) crdName() string {
+	return fmt.Sprintf("%s.%s.%s", kc.Resources, kc.Group, kc.Domain)
+}
+
+// StoredVersions returns the versions the apiserver recorded the objects of
+// the CRD of the test resources may be stored in.
+func (kc *KBTestContext) StoredVersions() ([]string, error) {
+	out, err := kc.Kubectl.Command("get", "crd", kc.crdName(), "-o", "jsonpath={.status.storedVersions}")
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	if err := json.Unmarshal([]byte(out), &versions); err != nil {
+		return nil, fmt.Errorf("error decoding the stored versions %q: %v", out, err)
+	}
+	return versions, nil
+}
+
+// StoredAPIVersion returns the API version the object of the test resources
+// of the given name is stored with in etcd, read from the etcd pod of the kind
+// cluster since the apiserver converts the objects it serves.
+func (kc *KBTestContext) StoredAPIVersion(name string) (string, error) {
+	pod, err := kc.Kubectl.Command("get", "pods", "-n", "kube-system", "-l", "component=etcd",
+		"-o", "jsonpath={.items[0].metadata.name}")
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("/registry/%s.%s/%s/%s/%s", kc.Group, kc.Domain, kc.Resources, kc.Kubectl.Namespace, name)
+	args := append([]string{"exec", "-n", "kube-system", pod, "--"}, etcdctlArgs...)
+	out, err := kc.Kubectl.Command(append(args, "get", key, "--print-value-only")...)
+	if err != nil {
+		return "", err
+	}
+	var obj struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := json.Unmarshal([]byte(out), &obj); err != nil {
+		return "", fmt.Errorf("error decoding %s stored in etcd: %v", key, err)
+	}
+	return obj.APIVersion, nil
+}
+
+// MigrateStorageVersion rewrites the stored objects of the test resources in
+// the storage version of their CRD, then drops the other versions from its
+// stored versions, the way the kube-storage-version-migrator does: each
+// object is read and written back unchanged, which the apiserver stores in
+// the current storage version.
+func (kc *KBTestContext) MigrateStorageVersion(storageVersion string) error {
+	out, err := kc.Kubectl.Get(false, kc.crdName(), "--all-namespaces",
+		"-o", `jsonpath={range .items[*]}{.metadata.namespace}{" "}{.metadata.name}{"\n"}{end}`)
+	if err != nil {
+		return err
+	}
+	for _, line := range getNonEmptyLines(out) {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return fmt.Errorf("unexpected object %q", line)
+		}
+		if err := kc.migrate(fields[0], fields[1]); err != nil {
+			return fmt.Errorf("error migrating %s/%s: %v", fields[0], fields[1], err)
+		}
+	}
+
+	status := fmt.Sprintf(`{"status":{"storedVersions":[%q]}}`, storageVersion)
+	_, err = kc.Kubectl.Command("patch", "crd", kc.crdName(), "--subresource=status", "--type=merge", "-p", status)
+	return err
+}
+
+// migrate writes the object back unchanged, retrying on the conflicts with the
+// writes of the controller.
+func (kc *KBTestContext) migrate(namespace, name string) error {
+	var err error
+	for i := 0; i < 5; i++ {
+		var obj string
+		obj, err = kc.Kubectl.Command("get", "-n", namespace, kc.crdName(), name, "-o", "json")
+		if err != nil {
+			return err
+		}
+		_, err = kc.Kubectl.CommandWithInput(obj, "replace", "-f", "-")
+		// the apiserver rejects the writes of a stale resourceVersion
+		if err == nil || !strings.Contains(err.Error(), "the object has been modified") {
+			return err
+		}
+	}
+	return err
+}
@@ -18,57 +18,155 @@ package e2e
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/gomega/gbytes"
+
+	"sigs.k8s.io/kubebuilder/test/e2e/report"
 )
 
-// KBTestContext specified to run e2e tests
+// KBTestContext specified to run e2e tests. The names of the API group,
+// kind, project and namespace of a context are derived from its random
+// suffix, so that the specs of parallel ginkgo nodes, e.g. run with ginkgo -p,
+// do not conflict on the CRDs, webhook configurations and other cluster-wide
+// resources of their projects.
 type KBTestContext struct {
 	*cmdContext
 	TestSuffix string
-	Domain     string
-	Group      string
-	Version    string
-	Kind       string
-	Resources  string
-	ImageName  string
-	Kubectl    *Kubectl
+	// ProjectName is the name of the project, e2e-<suffix>, which prefixes
+	// the names of its resources and names its namespace, <name>-system
+	ProjectName string
+	Domain      string
+	Group       string
+	Version     string
+	Kind        string
+	Resources   string
+	ImageName   string
+	Kubectl     *Kubectl
+
+	// KubeAPIQPS and KubeAPIBurst constrain the manager's client-side rate
+	// limits, simulating a throttled apiserver. They are read from the
+	// KB_E2E_KUBE_API_QPS and KB_E2E_KUBE_API_BURST environment variables and
+	// left to the manager defaults when empty.
+	KubeAPIQPS   string
+	KubeAPIBurst string
+
+	// Arch is the architecture the manager image is built for with docker
+	// buildx, read from the KB_E2E_ARCH environment variable. The image is
+	// built for the architecture of the container tool when empty.
+	Arch string
+
+	// Cluster is the cluster provider the images are loaded to, selected with
+	// the KB_E2E_CLUSTER_PROVIDER environment variable
+	Cluster ClusterProvider
+
+	// runtime builds the images and saves them for the cluster provider
+	runtime containerRuntime
+
+	// imageArchive is the archive docker buildx exported the image to
+	imageArchive string
+
+	// imageLoaded is true once the image is loaded to the cluster
+	imageLoaded bool
+
+	// Prescaffolded is true when the project directory, read from the
+	// KB_E2E_PROJECT_DIR environment variable, holds a project scaffolded by a
+	// previous run. The tests then skip its scaffolding and only deploy and
+	// verify it, shortening the loop of debugging the generated code.
+	Prescaffolded bool
+
+	// keepDir is true when the project directory is kept for the next runs
+	keepDir bool
+
+	// usesCertManager is true once the test has acquired the cert manager
+	// shared with the other specs
+	usesCertManager bool
+
+	// report is the report of the test, whose steps are recorded by By
+	report *report.Report
+	// stepStart is the start time of the running step
+	stepStart time.Time
 }
 
+// projectDirRegex matches the name of the project directories of the tests,
+// whose suffix the names of the test resources are derived from.
+var projectDirRegex = regexp.MustCompile(`^e2e-[a-z0-9]+$`)
+
 // TestContext init with a random suffix for test KBTestContext stuff,
-// to avoid conflict when running tests synchronously.
+// to avoid conflict when running tests synchronously or on parallel nodes.
 func TestContext(env ...string) (*KBTestContext, error) {
-	testSuffix, err := randomSuffix()
+	testSuffix, err := nodeSuffix()
 	if err != nil {
 		return nil, err
 	}
 
-	testGroup := "bar" + testSuffix
 	path, err := filepath.Abs("e2e-" + testSuffix)
 	if err != nil {
 		return nil, err
 	}
 
+	// a project directory given with KB_E2E_PROJECT_DIR is scaffolded by the
+	// first run and reused by the next ones
+	prescaffolded, keepDir := false, false
+	if dir := os.Getenv("KB_E2E_PROJECT_DIR"); dir != "" {
+		if config.GinkgoConfig.ParallelTotal > 1 {
+			return nil, fmt.Errorf("KB_E2E_PROJECT_DIR cannot be shared by the %d parallel nodes",
+				config.GinkgoConfig.ParallelTotal)
+		}
+		if path, err = filepath.Abs(dir); err != nil {
+			return nil, err
+		}
+		if !projectDirRegex.MatchString(filepath.Base(path)) {
+			return nil, fmt.Errorf("KB_E2E_PROJECT_DIR %s must be named e2e-<suffix>, the suffix "+
+				"being lowercase letters and digits", dir)
+		}
+		testSuffix = strings.TrimPrefix(filepath.Base(path), "e2e-")
+		_, err := os.Stat(filepath.Join(path, "PROJECT"))
+		prescaffolded, keepDir = err == nil, true
+	}
+
+	cluster, err := newClusterProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	testGroup := "bar" + testSuffix
+
+	runtime := detectContainerRuntime()
 	cc := &cmdContext{
-		Env: env,
+		Env: append(env, runtime.Env...),
 		Dir: path,
 	}
 
+	projectName := "e2e-" + testSuffix
 	return &KBTestContext{
-		TestSuffix: testSuffix,
-		Domain:     "example.com" + testSuffix,
-		Group:      testGroup,
-		Version:    "v1alpha1",
-		Kind:       "Foo" + testSuffix,
-		Resources:  "foo" + testSuffix + "s",
-		ImageName:  "e2e-test/controller-manager:" + testSuffix,
-		cmdContext: cc,
+		TestSuffix:    testSuffix,
+		ProjectName:   projectName,
+		Domain:        "example.com" + testSuffix,
+		Group:         testGroup,
+		Version:       "v1alpha1",
+		Kind:          "Foo" + testSuffix,
+		Resources:     "foo" + testSuffix + "s",
+		ImageName:     cluster.Image("e2e-test/controller-manager", testSuffix),
+		KubeAPIQPS:    os.Getenv("KB_E2E_KUBE_API_QPS"),
+		KubeAPIBurst:  os.Getenv("KB_E2E_KUBE_API_BURST"),
+		cmdContext:    cc,
+		Cluster:       cluster,
+		runtime:       runtime,
+		Prescaffolded: prescaffolded,
+		keepDir:       keepDir,
+		report:        &report.Report{},
 		Kubectl: &Kubectl{
-			Namespace:  fmt.Sprintf("e2e-%s-system", testSuffix),
+			Namespace:  projectName + "-system",
 			cmdContext: cc,
 		},
 	}, nil
@@ -77,33 +175,55 @@ func TestContext(env ...string) (*KBTestContext, error) {
 // Prepare prepare a work directory for testing
 func (kc *KBTestContext) Prepare() error {
 	fmt.Fprintf(GinkgoWriter, "preparing testing directory: %s\n", kc.Dir)
-	return os.MkdirAll(kc.Dir, 0755)
-}
-
-// InstallCertManager installs the cert manager bundle.
-func (kc *KBTestContext) InstallCertManager() error {
-	if _, err := kc.Kubectl.Command("create", "namespace", "cert-manager"); err != nil {
+	if err := os.MkdirAll(kc.Dir, 0755); err != nil {
 		return err
 	}
-	if _, err := kc.Kubectl.Command("label", "namespace", "cert-manager", "certmanager.k8s.io/disable-validation=true"); err != nil {
-		return err
+
+	if kc.runtime.Tool != toolDocker {
+		fmt.Fprintf(GinkgoWriter, "building images with %s\n", kc.runtime.Tool)
+		if err := kc.runtime.writeDockerShim(kc.shimDir()); err != nil {
+			return err
+		}
+		kc.Env = append(kc.Env, "PATH="+kc.shimDir()+string(os.PathListSeparator)+os.Getenv("PATH"))
 	}
-	_, err := kc.Kubectl.Apply(false, "-f", "https://github.com/jetstack/cert-manager/releases/download/v0.8.0/cert-manager.yaml")
-	return err
+	return nil
+}
+
+// shimDir is the directory of the docker shim running the container tool.
+func (kc *KBTestContext) shimDir() string {
+	return kc.Dir + "-bin"
 }
 
-// UninstallCertManager uninstalls the cert manager bundle.
+// Name returns the name of a resource of the project, prefixed with the
+// project name.
+func (kc *KBTestContext) Name(name string) string {
+	return kc.ProjectName + "-" + name
+}
+
+// InstallCertManager installs the cert manager bundle, unless another spec of
+// the run already did.
+func (kc *KBTestContext) InstallCertManager() error {
+	kc.usesCertManager = true
+	return certManager.acquire(kc.Kubectl)
+}
+
+// UninstallCertManager uninstalls the cert manager bundle, unless other specs
+// of the run still use it.
 func (kc *KBTestContext) UninstallCertManager() {
-	if _, err := kc.Kubectl.Delete(false, "-f", "https://github.com/jetstack/cert-manager/releases/download/v0.8.0/cert-manager.yaml"); err != nil {
-		fmt.Fprintf(GinkgoWriter, "error when running kubectl delete during cleaning up cert manager: %v\n", err)
-	}
-	if _, err := kc.Kubectl.Delete(false, "namespace", "cert-manager"); err != nil {
-		fmt.Fprintf(GinkgoWriter, "error when cleaning up the cert manager namespace: %v\n", err)
+	if kc.usesCertManager {
+		kc.usesCertManager = false
+		certManager.release(kc.Kubectl)
 	}
 }
 
 // CleanupManifests is a helper func to run kustomize build and pipe the output to kubectl delete -f -
+// The artifacts of a failed test are collected first.
 func (kc *KBTestContext) CleanupManifests(dir string) {
+	if CurrentGinkgoTestDescription().Failed {
+		if err := kc.CollectArtifacts(); err != nil {
+			fmt.Fprintf(GinkgoWriter, "error when collecting the artifacts: %v\n", err)
+		}
+	}
 	cmd := exec.Command("kustomize", "build", dir)
 	output, err := kc.Run(cmd)
 	if err != nil {
@@ -114,9 +234,22 @@ func (kc *KBTestContext) CleanupManifests(dir string) {
 	}
 }
 
-// Init is for running `kubebuilder init`
+// ManagerRateLimitArgs returns the manager args constraining its client-side
+// rate limits, if any were configured.
+func (kc *KBTestContext) ManagerRateLimitArgs() []string {
+	var args []string
+	if kc.KubeAPIQPS != "" {
+		args = append(args, "--kube-api-qps="+kc.KubeAPIQPS)
+	}
+	if kc.KubeAPIBurst != "" {
+		args = append(args, "--kube-api-burst="+kc.KubeAPIBurst)
+	}
+	return args
+}
+
+// Init is for running `kubebuilder init`, naming the project ProjectName
 func (kc *KBTestContext) Init(initOptions ...string) error {
-	initOptions = append([]string{"init"}, initOptions...)
+	initOptions = append([]string{"init", "--project-name", kc.ProjectName}, initOptions...)
 	cmd := exec.Command("kubebuilder", initOptions...)
 	_, err := kc.Run(cmd)
 	return err
@@ -130,6 +263,22 @@ func (kc *KBTestContext) CreateAPI(resourceOptions ...string) error {
 	return err
 }
 
+// CreateWebhook is for running `kubebuilder create webhook`
+func (kc *KBTestContext) CreateWebhook(resourceOptions ...string) error {
+	resourceOptions = append([]string{"create", "webhook"}, resourceOptions...)
+	cmd := exec.Command("kubebuilder", resourceOptions...)
+	_, err := kc.Run(cmd)
+	return err
+}
+
+// Edit is for running `kubebuilder edit`
+func (kc *KBTestContext) Edit(editOptions ...string) error {
+	editOptions = append([]string{"edit"}, editOptions...)
+	cmd := exec.Command("kubebuilder", editOptions...)
+	_, err := kc.Run(cmd)
+	return err
+}
+
 // Make is for running `make` with various targets
 func (kc *KBTestContext) Make(makeOptions ...string) error {
 	cmd := exec.Command("make", makeOptions...)
@@ -139,21 +288,206 @@ func (kc *KBTestContext) Make(makeOptions ...string) error {
 
 // CleanupImage is for cleaning up the docker images for testing
 func (kc *KBTestContext) Destroy() {
-	cmd := exec.Command("docker", "rmi", "-f", kc.ImageName)
+	if kc.imageLoaded {
+		kc.Cluster.Cleanup(kc, kc.ImageName)
+	}
+	cmd := exec.Command(kc.runtime.Tool, "rmi", "-f", kc.ImageName)
 	if _, err := kc.Run(cmd); err != nil {
 		fmt.Fprintf(GinkgoWriter, "error when removing the local image: %v\n", err)
 	}
-	if err := os.RemoveAll(kc.Dir); err != nil {
+	if kc.keepDir {
+		fmt.Fprintf(GinkgoWriter, "keeping the project in %s for the next runs\n", kc.Dir)
+	} else if err := os.RemoveAll(kc.Dir); err != nil {
 		fmt.Fprintf(GinkgoWriter, "error when removing the word dir: %v\n", err)
 	}
+	if err := os.RemoveAll(kc.shimDir()); err != nil {
+		fmt.Fprintf(GinkgoWriter, "error when removing the docker shim dir: %v\n", err)
+	}
 }
 
-// LoadImageToKindCluster loads a local docker image to the kind cluster
-func (kc *KBTestContext) LoadImageToKindCluster() error {
-	kindOptions := []string{"load", "docker-image", kc.ImageName}
-	cmd := exec.Command("kind", kindOptions...)
-	_, err := kc.Run(cmd)
-	return err
+// BuildImage builds the manager image with the docker-build target of the
+// project, or when Arch is set, cross-builds it with the docker-buildx target
+// into an archive for LoadImageToCluster.
+func (kc *KBTestContext) BuildImage() error {
+	if kc.Arch == "" {
+		return kc.Make("docker-build", "IMG="+kc.ImageName)
+	}
+
+	archive, err := tempArchive()
+	if err != nil {
+		return err
+	}
+	kc.imageArchive = archive
+	return kc.Make("docker-buildx", "IMG="+kc.ImageName, "PLATFORMS=linux/"+kc.Arch,
+		"BUILDX_OUTPUT=--output=type=docker,dest="+archive)
+}
+
+// LoadImageToCluster loads the image built by the container tool to the
+// cluster of the cluster provider.
+func (kc *KBTestContext) LoadImageToCluster() error {
+	archive := kc.imageArchive
+	kc.imageArchive = ""
+	kc.imageLoaded = true
+	return kc.Cluster.LoadImage(kc, kc.ImageName, archive)
+}
+
+// tempArchive returns the path of a new empty image archive.
+func tempArchive() (string, error) {
+	archive, err := ioutil.TempFile("", "e2e-image-*.tar")
+	if err != nil {
+		return "", err
+	}
+	return archive.Name(), archive.Close()
+}
+
+// VerifyNodeArchitecture returns an error if the node the given pod runs on
+// is not of architecture Arch.
+func (kc *KBTestContext) VerifyNodeArchitecture(podName string) error {
+	p := &pod{}
+	if err := kc.Kubectl.GetJSON("pods", podName, p); err != nil {
+		return err
+	}
+	nodeName := p.Spec.NodeName
+	arch, err := kc.Kubectl.Get(false, "nodes", nodeName, "-o", "jsonpath={.status.nodeInfo.architecture}")
+	if err != nil {
+		return err
+	}
+	if arch != kc.Arch {
+		return fmt.Errorf("pod %s runs on node %s of architecture %s, expected %s", podName, nodeName, arch, kc.Arch)
+	}
+	return nil
+}
+
+// containerStatuses is the subset of a pod status inspected to detect
+// container restarts.
+type containerStatuses struct {
+	Status struct {
+		InitContainerStatuses []containerStatus `json:"initContainerStatuses"`
+		ContainerStatuses     []containerStatus `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// managerDeniedActions are the kubectl auth can-i arguments of the actions the
+// scaffolded RBAC must not allow the manager, in its namespace unless the
+// arguments say otherwise.
+var managerDeniedActions = [][]string{
+	{"*", "*"},
+	{"delete", "secrets"},
+	{"list", "secrets", "--all-namespaces"},
+	{"create", "clusterrolebindings"},
+	{"escalate", "clusterroles"},
+	{"delete", "customresourcedefinitions"},
+	{"delete", "namespaces"},
+	{"update", "deployments"},
+	{"create", "configmaps", "--namespace", "kube-system"},
+}
+
+// VerifyManagerRBAC returns an error if the service account of the manager is
+// allowed any of managerDeniedActions. It first checks the manager is allowed
+// to update the test resources, so a denial is not only the sign of a wrong
+// service account.
+func (kc *KBTestContext) VerifyManagerRBAC() error {
+	sa := fmt.Sprintf("system:serviceaccount:%s:default", kc.Kubectl.Namespace)
+	resource := fmt.Sprintf("%s.%s.%s", kc.Resources, kc.Group, kc.Domain)
+	allowed, err := kc.Kubectl.CanI(sa, "update", resource)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("%s is not allowed to update %s", sa, resource)
+	}
+
+	var granted []string
+	for _, action := range managerDeniedActions {
+		allowed, err := kc.Kubectl.CanI(sa, action...)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			granted = append(granted, strings.Join(action, " "))
+		}
+	}
+	if len(granted) > 0 {
+		return fmt.Errorf("%s is allowed actions the scaffolded RBAC should deny:\n%s", sa, strings.Join(granted, "\n"))
+	}
+	return nil
+}
+
+type containerStatus struct {
+	Name         string `json:"name"`
+	RestartCount int    `json:"restartCount"`
+	LastState    struct {
+		Terminated *struct {
+			Reason   string `json:"reason"`
+			Message  string `json:"message"`
+			ExitCode int    `json:"exitCode"`
+		} `json:"terminated"`
+	} `json:"lastState"`
+}
+
+// VerifyNoRestarts returns an error if any container of the given pod has
+// restarted, describing why each restarted container last terminated along
+// with the logs it wrote before terminating.
+func (kc *KBTestContext) VerifyNoRestarts(podName string) error {
+	pod := containerStatuses{}
+	if err := kc.Kubectl.GetJSON("pods", podName, &pod); err != nil {
+		return err
+	}
+
+	var restarts []string
+	statuses := append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if cs.RestartCount == 0 {
+			continue
+		}
+		restart := fmt.Sprintf("container %s restarted %d times", cs.Name, cs.RestartCount)
+		if t := cs.LastState.Terminated; t != nil {
+			restart += fmt.Sprintf(", last terminated with reason %s (exit code %d): %s",
+				t.Reason, t.ExitCode, t.Message)
+		}
+		if logs, err := kc.Kubectl.Logs(podName, "-c", cs.Name, "--previous", "--tail=50"); err == nil {
+			restart += fmt.Sprintf("\nprevious logs:\n%s", logs)
+		}
+		restarts = append(restarts, restart)
+	}
+	if len(restarts) > 0 {
+		return fmt.Errorf("pod %s has restarted containers:\n%s", podName, strings.Join(restarts, "\n"))
+	}
+	return nil
+}
+
+// StartManager starts the manager binary built by the manager target of the
+// project in the background, against the cluster of the current kubeconfig.
+// The given environment variables are added to the ones of the test context,
+// and the combined output of the manager is written to the returned buffer.
+func (kc *KBTestContext) StartManager(env []string, args ...string) (*exec.Cmd, *gbytes.Buffer, error) {
+	cmd := exec.Command(filepath.Join(kc.Dir, "bin", "manager"), args...)
+	cmd.Dir = kc.Dir
+	cmd.Env = append(append(os.Environ(), kc.Env...), env...)
+	output := gbytes.NewBuffer()
+	cmd.Stdout = io.MultiWriter(output, GinkgoWriter)
+	cmd.Stderr = io.MultiWriter(output, GinkgoWriter)
+	fmt.Fprintf(GinkgoWriter, "starting: %s %s\n", strings.Join(env, " "), strings.Join(cmd.Args, " "))
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+	return cmd, output, nil
+}
+
+// StopManager stops a manager started with StartManager.
+func (kc *KBTestContext) StopManager(cmd *exec.Cmd) {
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		fmt.Fprintf(GinkgoWriter, "error when stopping the manager: %v\n", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		fmt.Fprintf(GinkgoWriter, "the manager did not stop, killing it\n")
+		_ = cmd.Process.Kill()
+		<-done
+	}
 }
 
 type cmdContext struct {
@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+)
+
+// The cipher suites the e2e project serves its webhooks and metrics with, the
+// ones of the commented --tls-cipher-suites argument of the auth proxy, and
+// one it does not.
+const (
+	tlsCipherSuites        = "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"
+	tlsUnservedCipherSuite = tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA
+)
+
+// tlsCipherSuitesArgs is the argument of the manager container of
+// config/default/manager_auth_proxy_patch.yaml restricting the webhooks to
+// tlsCipherSuites.
+const tlsCipherSuitesArgs = `        - "--tls-cipher-suites=` + tlsCipherSuites + `"
+`
+
+// VerifyTLS verifies the given port of the pod only serves TLS 1.2 and above,
+// with the cipher suites of tlsCipherSuites.
+func (kc *KBTestContext) VerifyTLS(podName string, port int) error {
+	addr, stop, err := kc.portForward(podName, port)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	probes := []struct {
+		description string
+		config      *tls.Config
+		served      bool
+	}{
+		{
+			description: "TLS 1.1",
+			config:      &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS11},
+		},
+		{
+			description: "TLS 1.2",
+			config: &tls.Config{MinVersion: tls.VersionTLS12, MaxVersion: tls.VersionTLS12,
+				CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}},
+			served: true,
+		},
+		{
+			description: "TLS 1.2 with an unlisted cipher suite",
+			config: &tls.Config{MinVersion: tls.VersionTLS12, MaxVersion: tls.VersionTLS12,
+				CipherSuites: []uint16{tlsUnservedCipherSuite}},
+		},
+	}
+	for _, probe := range probes {
+		// the probes check the protocol, not the certificate
+		probe.config.InsecureSkipVerify = true // nolint: gosec
+		conn, err := tls.Dial("tcp", addr, probe.config)
+		if err == nil {
+			_ = conn.Close()
+		}
+		fmt.Fprintf(GinkgoWriter, "%s on port %d: %v\n", probe.description, port, err)
+		if probe.served && err != nil {
+			return fmt.Errorf("port %d of pod %s does not serve %s: %v", port, podName, probe.description, err)
+		}
+		if !probe.served && err == nil {
+			return fmt.Errorf("port %d of pod %s serves %s", port, podName, probe.description)
+		}
+	}
+	return nil
+}
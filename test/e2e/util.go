@@ -20,12 +20,43 @@ import (
 	"bufio"
 	"bytes"
 	"crypto/rand"
+	"fmt"
 	"io/ioutil"
 	"math/big"
-	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/onsi/ginkgo/config"
+
+	"sigs.k8s.io/kubebuilder/pkg/textfile"
 )
 
+// nodeSuffix returns the random suffix of the names of a test. When the specs
+// run on parallel ginkgo nodes, it starts with the letters of the node number,
+// so that the tests of two nodes never pick the same one.
+func nodeSuffix() (string, error) {
+	suffix, err := randomSuffix()
+	if err != nil {
+		return "", err
+	}
+	if config.GinkgoConfig.ParallelTotal > 1 {
+		suffix = nodeLetters(config.GinkgoConfig.ParallelNode) + suffix
+	}
+	return suffix, nil
+}
+
+// nodeLetters returns the letters numbering the given node: a to z for the
+// nodes 1 to 26, then aa, ab, etc. The names of the API groups only allow
+// letters.
+func nodeLetters(node int) string {
+	letters := ""
+	for ; node > 0; node = (node - 1) / 26 {
+		letters = string(rune('a'+(node-1)%26)) + letters
+	}
+	return letters
+}
+
 // randomSuffix returns a 4-letter string.
 func randomSuffix() (string, error) {
 	source := []rune("abcdefghijklmnopqrstuvwxyz")
@@ -55,38 +86,109 @@ func getNonEmptyLines(output string) []string {
 	return res
 }
 
-// insertCode searches target content in the file and insert `toInsert` after the target.
-func insertCode(filename, target, code string) error {
-	contents, err := ioutil.ReadFile(filename)
+// insertCode inserts code above the line of the given scaffold marker, e.g.
+// "+kubebuilder:scaffold:imports", in the file, failing if the file has no
+// such marker. The file keeps its byte order mark and line endings.
+func insertCode(filename, marker, code string) error {
+	contents, format, err := textfile.ReadFile(filename)
 	if err != nil {
 		return err
 	}
-	idx := strings.Index(string(contents), target)
-	out := string(contents[:idx+len(target)]) + code + string(contents[idx+len(target):])
-	return ioutil.WriteFile(filename, []byte(out), 0644)
+	lines := strings.SplitAfter(string(contents), "\n")
+	for i, line := range lines {
+		if isMarkerLine(line, marker) {
+			out := strings.Join(lines[:i], "") + code + strings.Join(lines[i:], "")
+			return textfile.WriteFile(filename, []byte(out), format, 0644)
+		}
+	}
+	return fmt.Errorf("%s has no %s marker", filename, marker)
 }
 
-// uncommentCode searches for target in the file and remove the prefix of the target content.
+// replaceCode replaces every occurrence of target in the file with code,
+// failing if the file has no such target. The file keeps its byte order mark
+// and line endings.
+func replaceCode(filename, target, code string) error {
+	contents, format, err := textfile.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(string(contents), target) {
+		return fmt.Errorf("%s has no %q to replace", filename, target)
+	}
+	out := strings.Replace(string(contents), target, code, -1)
+	return textfile.WriteFile(filename, []byte(out), format, 0644)
+}
+
+// isMarkerLine returns true if the line is the comment of the given marker.
+func isMarkerLine(line, marker string) bool {
+	line = strings.TrimSpace(line)
+	for _, prefix := range []string{"//", "#"} {
+		if strings.HasPrefix(line, prefix) && strings.TrimSpace(strings.TrimPrefix(line, prefix)) == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyMarkers verifies the files, relative to dir, have the scaffold
+// markers the e2e and the scaffolds of the later commands insert code at.
+func verifyMarkers(dir string, markers map[string][]string) error {
+	var missing []string
+	for file, fileMarkers := range markers {
+		contents, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return err
+		}
+		for _, marker := range fileMarkers {
+			found := false
+			for _, line := range strings.Split(string(contents), "\n") {
+				if isMarkerLine(line, marker) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				missing = append(missing, file+": "+marker)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing scaffold markers:\n%s", strings.Join(missing, "\n"))
+	}
+	return nil
+}
+
+// uncommentCode searches for target in the file and remove the prefix of the
+// target content, failing if the file has no such target. The file keeps its
+// byte order mark and line endings.
 func uncommentCode(filename, target, prefix string) error {
-	f, err := os.Open(filename)
+	contents, format, err := textfile.ReadFile(filename)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
+	found := false
 	out := new(bytes.Buffer)
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
 	for scanner.Scan() {
 		line := scanner.Text()
 
 		// uncomment the target line
 		if strings.Contains(line, target) {
 			line = strings.ReplaceAll(line, target, strings.TrimSpace(strings.TrimPrefix(target, prefix)))
+			found = true
 		}
 		_, err = out.WriteString(line + "\n")
 		if err != nil {
 			return err
 		}
 	}
-	return ioutil.WriteFile(filename, out.Bytes(), 0644)
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%s has no %q to uncomment", filename, target)
+	}
+	return textfile.WriteFile(filename, out.Bytes(), format, 0644)
 }
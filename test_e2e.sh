@@ -22,9 +22,121 @@ source common.sh
 fetch_tools
 build_kb
 
+# KB_E2E_PROFILE selects the tier of the suite run: smoke only runs the specs
+# tagged [smoke], building the scaffolded projects and checking their
+# manifests without a cluster nor a container tool, for fast PR gating;
+# deploy only runs the slower specs deploying the projects to a cluster. Both
+# tiers are run when it is empty
+profile=${KB_E2E_PROFILE:-}
+case "$profile" in
+  ""|smoke|deploy) ;;
+  *) echo "unknown KB_E2E_PROFILE $profile, must be smoke or deploy" >&2; exit 1 ;;
+esac
+
+if [ "$profile" != "smoke" ]; then
+  # pick the container tool the same way as the e2e suite: an explicit
+  # KB_E2E_CONTAINER_TOOL, the daemon set with DOCKER_HOST, the rootful or
+  # rootless Docker socket, and last rootless podman
+  container_tool=${KB_E2E_CONTAINER_TOOL:-}
+  if [ -z "${DOCKER_HOST:-}" ] && [ "$container_tool" != "podman" ] && [ ! -S /var/run/docker.sock ] \
+    && [ -S "${XDG_RUNTIME_DIR:-}/docker.sock" ]; then
+    export DOCKER_HOST="unix://${XDG_RUNTIME_DIR}/docker.sock"
+  fi
+  if [ -z "$container_tool" ]; then
+    container_tool=docker
+    if ! command -v docker >/dev/null 2>&1 && command -v podman >/dev/null 2>&1; then
+      container_tool=podman
+    fi
+  fi
+  if [ "$container_tool" == "podman" ]; then
+    export KIND_EXPERIMENTAL_PROVIDER=podman
+  fi
+fi
+
 setup_envs
 
-docker pull gcr.io/kubebuilder/kube-rbac-proxy:v0.4.0
-kind load docker-image gcr.io/kubebuilder/kube-rbac-proxy:v0.4.0
+if [ "$profile" != "smoke" ]; then
+  # with KB_E2E_ARCH set (e.g. arm64) the manager image is cross-built with
+  # docker buildx, and the cluster is expected to run nodes of that
+  # architecture, natively or emulated with QEMU
+  pull_args=""
+  if [ -n "${KB_E2E_ARCH:-}" ]; then
+    pull_args="--platform linux/${KB_E2E_ARCH}"
+  fi
+
+  # the cluster the suite deploys to is selected with KB_E2E_CLUSTER_PROVIDER,
+  # one of kind (the default), minikube, k3d or existing, and named with
+  # KB_E2E_CLUSTER_NAME. The existing provider deploys to the cluster of the
+  # current kubeconfig, e.g. a GKE cluster, pushing the images of the tests to
+  # KB_E2E_IMAGE_REGISTRY, e.g. gcr.io/<project>/kubebuilder-e2e
+  cluster_provider=${KB_E2E_CLUSTER_PROVIDER:-kind}
+
+  # stream the image into the cluster as an archive, which works whatever the
+  # daemon; the clusters of the existing provider pull it themselves
+  if [ "$cluster_provider" != "existing" ]; then
+    rbac_proxy_archive=$(mktemp)
+    $container_tool pull $pull_args gcr.io/kubebuilder/kube-rbac-proxy:v0.4.1
+    $container_tool save -o $rbac_proxy_archive gcr.io/kubebuilder/kube-rbac-proxy:v0.4.1
+    case "$cluster_provider" in
+      kind)
+        kind load image-archive $rbac_proxy_archive --name "${KB_E2E_CLUSTER_NAME:-kind}" ;;
+      minikube)
+        minikube image load $rbac_proxy_archive --profile "${KB_E2E_CLUSTER_NAME:-minikube}" ;;
+      k3d)
+        k3d image import $rbac_proxy_archive --cluster "${KB_E2E_CLUSTER_NAME:-k3s-default}" ;;
+    esac
+    rm -f $rbac_proxy_archive
+  fi
+fi
+
+# the scaffolded project is checked against a budget of modules, binary and
+# image size, which a change legitimately growing the project raises with
+# KB_E2E_MAX_MODULES, KB_E2E_MAX_BINARY_MB and KB_E2E_MAX_IMAGE_MB
+#
+# with KB_E2E_REPORT_DIR set, a JSON report of the durations of the steps of
+# each test and of the sizes and memory of the project is written to it. The
+# reports of two runs, e.g. of two kubebuilder releases, are compared with
+#   go run ./test/e2e/report/compare -threshold 10 <base dir> <head dir>
+#
+# the artifacts of the failed tests, the logs and descriptions of the
+# controller-manager pods, the events of their namespace, the webhook
+# configurations and the diagnostics, are written to a directory per test in
+# ARTIFACTS, as set by Prow, or else in KB_E2E_REPORT_DIR
+#
+# the runnable project spec then runs the declarative steps of the YAML files
+# of test/e2e/steps, applying manifests and asserting the state of the objects
+# as described in test/e2e/steps.go. The steps of another directory, relative
+# to test/e2e, are run instead with KB_E2E_STEPS_DIR, e.g.
+#   KB_E2E_STEPS_DIR=$PWD/qa/steps ./test_e2e.sh
+#
+# with KB_E2E_SOAK_DURATION set (e.g. 10m), the runnable project spec then
+# creates, updates and deletes KB_E2E_SOAK_OBJECTS CRs (100 by default) in
+# rounds for that long, and fails if the goroutines or memory of the manager
+# grew over the soak. Raise the timeout of go test accordingly, e.g.
+#   KB_E2E_SOAK_DURATION=30m ./test_e2e.sh -timeout 60m
+#
+# with KB_E2E_PROJECT_DIR set to a directory named e2e-<suffix>, the project
+# is scaffolded in it by the first run and kept, the next runs only building,
+# deploying and verifying it. The specs scaffold different projects, so focus
+# a single one, e.g.
+#   ./test_e2e.sh -ginkgo.focus="v2 scaffolding should generate a runnable project"
+#
+# with KB_E2E_NODES set (e.g. 4), the specs run in parallel on that many
+# ginkgo nodes against the same cluster, sharing the cert manager, and the
+# arguments are the flags of the ginkgo CLI rather than of go test, e.g.
+#   KB_E2E_NODES=4 ./test_e2e.sh -timeout 2h
+if [ -n "${KB_E2E_NODES:-}" ]; then
+  ginkgo="go run github.com/onsi/ginkgo/ginkgo -nodes=${KB_E2E_NODES}"
+  case "$profile" in
+    smoke) $ginkgo -focus='\[smoke\]' "$@" ./test/e2e ;;
+    deploy) $ginkgo -skip='\[smoke\]' "$@" ./test/e2e ;;
+    *) $ginkgo "$@" ./test/e2e ;;
+  esac
+  exit
+fi
 
-go test ./test/e2e
+case "$profile" in
+  smoke) go test ./test/e2e -ginkgo.focus='\[smoke\]' "$@" ;;
+  deploy) go test ./test/e2e -ginkgo.skip='\[smoke\]' "$@" ;;
+  *) go test ./test/e2e "$@" ;;
+esac
@@ -27,6 +27,9 @@ import (
 type CaptainSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+	// Precede the fields with +kubebuilder:example=<value> markers to fill in
+	// the sample in config/samples.
+	// +kubebuilder:scaffold:spec
 }
 
 // CaptainStatus defines the observed state of Captain
@@ -27,6 +27,9 @@ import (
 type FirstMateSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+	// Precede the fields with +kubebuilder:example=<value> markers to fill in
+	// the sample in config/samples.
+	// +kubebuilder:scaffold:spec
 }
 
 // FirstMateStatus defines the observed state of FirstMate
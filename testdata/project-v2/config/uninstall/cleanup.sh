@@ -0,0 +1,31 @@
+#!/bin/sh
+# Deletes the CRs of the given CRDs, then the CRDs, so that neither the CRDs nor
+# the namespaces of the CRs get stuck on the finalizers of CRs no manager is
+# left to remove. The controllers are given CLEANUP_TIMEOUT to finalize the
+# CRs, after which the finalizers left are removed.
+#
+# usage: cleanup.sh <crd>...
+set -e
+
+timeout=${CLEANUP_TIMEOUT:-60s}
+
+for crd in "$@"; do
+	if ! kubectl get crd "$crd" >/dev/null 2>&1; then
+		echo "skipping $crd: not installed"
+		continue
+	fi
+
+	echo "deleting the $crd objects"
+	if ! kubectl delete "$crd" --all --all-namespaces --timeout="$timeout"; then
+		echo "removing the finalizers of the $crd objects left after $timeout"
+		kubectl get "$crd" --all-namespaces \
+			-o jsonpath='{range .items[*]}{.metadata.namespace}/{.metadata.name}{"\n"}{end}' |
+			while IFS=/ read -r namespace name; do
+				kubectl patch "$crd" "$name" ${namespace:+--namespace "$namespace"} \
+					--type=merge -p '{"metadata":{"finalizers":null}}'
+			done
+		kubectl delete "$crd" --all --all-namespaces
+	fi
+
+	kubectl delete crd "$crd"
+done
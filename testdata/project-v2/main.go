@@ -45,17 +45,29 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var kubeAPIQPS float64
+	var kubeAPIBurst int
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 0,
+		"The maximum queries per second from the manager to the Kubernetes API server. Zero uses the client-go default.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 0,
+		"The maximum burst of queries from the manager to the Kubernetes API server. Zero uses the client-go default.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.Logger(true))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	cfg := ctrl.GetConfigOrDie()
+	cfg.QPS = float32(kubeAPIQPS)
+	cfg.Burst = kubeAPIBurst
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		LeaderElection:     enableLeaderElection,
+		// Serve the webhooks on an unprivileged port, the manager runs as non-root
+		Port: 9443,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -88,6 +100,8 @@ func main() {
 	}
 	// +kubebuilder:scaffold:builder
 
+	// +kubebuilder:scaffold:webhooktls
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")